@@ -32,6 +32,7 @@ import (
 	"kubesphere.io/devops/controllers/jenkins/config"
 	jenkinspipeline "kubesphere.io/devops/controllers/jenkins/pipeline"
 	"kubesphere.io/devops/controllers/jenkins/pipelinerun"
+	"kubesphere.io/devops/controllers/jenkins/sharedlibrary"
 	"kubesphere.io/devops/pkg/client/devops"
 	"kubesphere.io/devops/pkg/client/k8s"
 	"kubesphere.io/devops/pkg/informers"
@@ -55,6 +56,7 @@ func addControllers(mgr manager.Manager, client k8s.Client, informerFactory info
 			DevOpsClient:         devopsClient,
 			JenkinsCore:          jenkinsCore,
 			TokenIssuer:          tokenIssuer,
+			ReplaySecret:         s.JWTOptions.Secret,
 			PipelineRunDataStore: s.FeatureOptions.PipelineRunDataStore,
 		}).SetupWithManager(mgr); err != nil {
 			klog.Errorf("unable to create pipelinerun-controller, err: %v", err)
@@ -71,9 +73,33 @@ func addControllers(mgr manager.Manager, client k8s.Client, informerFactory info
 		}
 
 		// add Pipeline metadata controller
-		err = (&jenkinspipeline.Reconciler{
+		if err = (&jenkinspipeline.Reconciler{
 			Client:      mgr.GetClient(),
 			JenkinsCore: jenkinsCore,
+		}).SetupWithManager(mgr); err != nil {
+			klog.Errorf("unable to create pipeline-metadata-controller, err: %v", err)
+			return
+		}
+
+		// add the cleanup controller of the PipelineRuns of the removed branches
+		if err = (&jenkinspipeline.BranchCleanupReconciler{
+			Client: mgr.GetClient(),
+		}).SetupWithManager(mgr); err != nil {
+			klog.Errorf("unable to create pipeline-branch-cleanup-controller, err: %v", err)
+			return
+		}
+
+		// add the controller which records the revisions of the Pipeline spec
+		if err = (&jenkinspipeline.RevisionReconciler{
+			Client: mgr.GetClient(),
+		}).SetupWithManager(mgr); err != nil {
+			klog.Errorf("unable to create pipeline-revision-controller, err: %v", err)
+			return
+		}
+
+		// add the controller which checks the credentials referenced by the Pipelines
+		err = (&jenkinspipeline.CredentialCheckReconciler{
+			Client: mgr.GetClient(),
 		}).SetupWithManager(mgr)
 		return
 	}
@@ -133,6 +159,14 @@ func getAllControllers(mgr manager.Manager, client k8s.Client, informerFactory i
 		Client:                   mgr.GetClient(),
 		TargetConfigMapNamespace: s.FeatureOptions.SystemNamespace,
 	}
+	sharedLibraryReconciler := &sharedlibrary.Reconciler{
+		Client:       mgr.GetClient(),
+		DevOpsClient: devopsClient,
+	}
+	clusterSharedLibraryReconciler := &sharedlibrary.ClusterReconciler{
+		Client:                   mgr.GetClient(),
+		TargetConfigMapNamespace: s.FeatureOptions.SystemNamespace,
+	}
 	fluxcdApplicationReconciler := &fluxcd.ApplicationReconciler{
 		Client: mgr.GetClient(),
 	}
@@ -149,6 +183,14 @@ func getAllControllers(mgr manager.Manager, client k8s.Client, informerFactory i
 				Client:          mgr.GetClient(),
 				ExternalAddress: s.FeatureOptions.ExternalAddress,
 				ClusterName:     s.FeatureOptions.ClusterName,
+				ConsoleOption:   s.ConsoleOption,
+			}).SetupWithManager(mgr)
+			if err != nil {
+				return err
+			}
+			err = (&gitrepository.OAuthTokenReconciler{
+				Client:      mgr.GetClient(),
+				OAuthOption: s.SCMOAuthOption,
 			}).SetupWithManager(mgr)
 			if err != nil {
 				return err
@@ -212,6 +254,12 @@ func getAllControllers(mgr manager.Manager, client k8s.Client, informerFactory i
 			if err == nil {
 				err = jenkinsAgentLabelsReconciler.SetupWithManager(mgr)
 			}
+			if err == nil {
+				err = sharedLibraryReconciler.SetupWithManager(mgr)
+			}
+			if err == nil {
+				err = clusterSharedLibraryReconciler.SetupWithManager(mgr)
+			}
 			return err
 		},
 		argocdReconciler.GetGroupName(): func(mgr manager.Manager) (err error) {
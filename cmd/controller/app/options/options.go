@@ -45,6 +45,8 @@ type DevOpsControllerManagerOptions struct {
 	FeatureOptions    *FeatureOptions
 	JWTOptions        *JWTOptions
 	ArgoCDOption      *config.ArgoCDOption
+	ConsoleOption     *config.ConsoleOption
+	SCMOAuthOption    *config.SCMOAuthOption
 
 	// KubeSphere is using sigs.k8s.io/application as fundamental object to implement Application Management.
 	// There are other projects also built on sigs.k8s.io/application, when KubeSphere installed along side
@@ -70,6 +72,8 @@ func NewDevOpsControllerManagerOptions() *DevOpsControllerManagerOptions {
 		ApplicationSelector: "",
 		KubernetesOptions:   &k8s.KubernetesOptions{},
 		ArgoCDOption:        &config.ArgoCDOption{},
+		ConsoleOption:       config.NewConsoleOption(),
+		SCMOAuthOption:      &config.SCMOAuthOption{},
 	}
 
 	return s
@@ -82,6 +86,7 @@ func (s *DevOpsControllerManagerOptions) Flags() cliflag.NamedFlagSets {
 	s.JenkinsOptions.AddFlags(fss.FlagSet("devops"), s.JenkinsOptions)
 	s.FeatureOptions.AddFlags(fss.FlagSet("feature"), s.FeatureOptions)
 	s.ArgoCDOption.AddFlags(fss.FlagSet("argocd"))
+	s.ConsoleOption.AddFlags(fss.FlagSet("console"))
 
 	fs := fss.FlagSet("leaderelection")
 	s.bindLeaderElectionFlags(s.LeaderElection, fs)
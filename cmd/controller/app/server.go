@@ -50,6 +50,12 @@ func NewControllerManagerCommand() *cobra.Command {
 		if conf.ArgoCDOption == nil {
 			conf.ArgoCDOption = &config.ArgoCDOption{}
 		}
+		if conf.ConsoleOption == nil {
+			conf.ConsoleOption = config.NewConsoleOption()
+		}
+		if conf.SCMOAuthOption == nil {
+			conf.SCMOAuthOption = &config.SCMOAuthOption{}
+		}
 		// make sure LeaderElection is not nil
 		// override devops controller manager options
 		s = &options.DevOpsControllerManagerOptions{
@@ -61,6 +67,8 @@ func NewControllerManagerCommand() *cobra.Command {
 				MaximumClockSkew: conf.AuthenticationOptions.MaximumClockSkew,
 			},
 			ArgoCDOption:   conf.ArgoCDOption,
+			ConsoleOption:  conf.ConsoleOption,
+			SCMOAuthOption: conf.SCMOAuthOption,
 			FeatureOptions: s.FeatureOptions,
 			LeaderElection: s.LeaderElection,
 			LeaderElect:    s.LeaderElect,
@@ -192,6 +200,12 @@ func Run(s *options.DevOpsControllerManagerOptions, ctx context.Context) error {
 	if err = indexers.CreatePipelineRunSCMRefNameIndexer(mgr.GetCache()); err != nil {
 		return err
 	}
+	if err = indexers.CreateGitRepositoryURLIndexer(mgr.GetCache()); err != nil {
+		return err
+	}
+	if err = indexers.CreatePipelineCredentialIDIndexer(mgr.GetCache()); err != nil {
+		return err
+	}
 
 	// Start cache data after all informer is registered
 	klog.V(0).Info("Starting cache resource from apiserver...")
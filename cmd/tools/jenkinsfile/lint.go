@@ -0,0 +1,65 @@
+/*
+Copyright 2022 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+
+	"github.com/spf13/cobra"
+	"kubesphere.io/devops/pkg/jenkinsfile"
+)
+
+func createLintCommand() (cmd *cobra.Command) {
+	cmd = &cobra.Command{
+		Use:   "lint [files]",
+		Short: "Lint the declarative Jenkinsfiles, the default file is Jenkinsfile",
+		Example: `jenkinsfile lint
+jenkinsfile lint ci/Jenkinsfile ci/Jenkinsfile.release`,
+		SilenceUsage: true,
+		RunE:         lintE,
+	}
+	return
+}
+
+func lintE(cmd *cobra.Command, args []string) (err error) {
+	if len(args) == 0 {
+		args = []string{"Jenkinsfile"}
+	}
+
+	count := 0
+	for _, file := range args {
+		var data []byte
+		if data, err = ioutil.ReadFile(file); err != nil {
+			err = fmt.Errorf("failed to read file: %s, error %v", file, err)
+			return
+		}
+
+		for _, lintErr := range jenkinsfile.Lint(string(data)) {
+			if lintErr.Warning {
+				cmd.Printf("%s:%d: warning: %s\n", file, lintErr.Line, lintErr.Message)
+				continue
+			}
+			cmd.Printf("%s:%d: %s\n", file, lintErr.Line, lintErr.Message)
+			count++
+		}
+	}
+	if count > 0 {
+		err = fmt.Errorf("found %d problem(s) in the Jenkinsfiles", count)
+	}
+	return
+}
@@ -0,0 +1,67 @@
+/*
+Copyright 2022 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLintCommand(t *testing.T) {
+	dir := t.TempDir()
+	valid := filepath.Join(dir, "Jenkinsfile")
+	invalid := filepath.Join(dir, "Jenkinsfile.invalid")
+	assert.Nil(t, os.WriteFile(valid, []byte("pipeline {\n  agent any\n  stages {\n    stage('a') { steps { echo 'a' } }\n  }\n}"), 0644))
+	assert.Nil(t, os.WriteFile(invalid, []byte("pipeline {\n  agent any\n}"), 0644))
+
+	tests := []struct {
+		name       string
+		args       []string
+		wantOutput string
+		wantErr    bool
+	}{{
+		name: "valid Jenkinsfile",
+		args: []string{valid},
+	}, {
+		name:       "invalid Jenkinsfile",
+		args:       []string{valid, invalid},
+		wantOutput: invalid + ":1: missing stages section in pipeline\n",
+		wantErr:    true,
+	}, {
+		name:    "file not found",
+		args:    []string{filepath.Join(dir, "missing")},
+		wantErr: true,
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buf := &bytes.Buffer{}
+			cmd := createLintCommand()
+			cmd.SetOut(buf)
+			cmd.SetErr(buf)
+			cmd.SetArgs(tt.args)
+			err := cmd.Execute()
+			assert.Equal(t, tt.wantErr, err != nil)
+			if tt.wantOutput != "" {
+				assert.Contains(t, buf.String(), tt.wantOutput)
+			}
+		})
+	}
+}
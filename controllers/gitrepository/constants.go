@@ -15,4 +15,6 @@ package gitrepository
 
 const (
 	groupName = "gitrepository"
+	// statusLabel is the context of the commit status
+	statusLabel = "KubeSphere DevOps"
 )
@@ -0,0 +1,359 @@
+/*
+Copyright 2022 The KubeSphere Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gitrepository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/jenkins-x/go-scm/scm"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/client-go/util/retry"
+	"kubesphere.io/devops/pkg/api/devops/v1alpha3"
+	"kubesphere.io/devops/pkg/client/git"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+)
+
+const (
+	// defaultProbeInterval is the interval between two checks of a healthy git repository
+	defaultProbeInterval = 10 * time.Minute
+	// defaultProbeMinBackoff is the delay of the first retry after a failed check
+	defaultProbeMinBackoff = 30 * time.Second
+	// defaultProbeMaxBackoff is the maximum delay of the retries after failed checks
+	defaultProbeMaxBackoff = time.Hour
+)
+
+// ProbeReconciler checks the connectivity of GitRepositories periodically
+type ProbeReconciler struct {
+	client.Client
+	// Interval is the interval between two checks of a healthy git repository
+	Interval time.Duration
+	// MinBackoff is the delay of the first retry after a failed check
+	MinBackoff time.Duration
+	// MaxBackoff is the maximum delay of the retries after failed checks
+	MaxBackoff time.Duration
+
+	log      logr.Logger
+	recorder record.EventRecorder
+}
+
+//+kubebuilder:rbac:groups=devops.kubesphere.io,resources=gitrepositories,verbs=get;list;watch
+//+kubebuilder:rbac:groups=devops.kubesphere.io,resources=gitrepositories/status,verbs=get;update;patch
+//+kubebuilder:rbac:groups=devops.kubesphere.io,resources=webhooks,verbs=get
+//+kubebuilder:rbac:groups="",resources=secrets,verbs=get
+
+// Reconcile checks the GitRepository and records the result into its status
+func (r *ProbeReconciler) Reconcile(ctx context.Context, req ctrl.Request) (result ctrl.Result, err error) {
+	repo := &v1alpha3.GitRepository{}
+	if err = r.Get(ctx, req.NamespacedName, repo); err != nil {
+		err = client.IgnoreNotFound(err)
+		return
+	}
+	if !repo.DeletionTimestamp.IsZero() {
+		return
+	}
+
+	r.log.V(6).Info(fmt.Sprintf("start to probe %s", req.NamespacedName))
+	status := repo.Status.DeepCopy()
+	healthy := r.probe(ctx, repo, status)
+	if healthy {
+		status.ConsecutiveFailures = 0
+		status.Connection = v1alpha3.GitRepoConnectionOK
+	} else {
+		status.ConsecutiveFailures++
+		status.Connection = v1alpha3.GitRepoConnectionFailed
+	}
+	now := metav1.Now()
+	status.LastCheckedTime = &now
+
+	if err = r.updateStatus(ctx, req.NamespacedName, status); err != nil {
+		return
+	}
+	result.RequeueAfter = r.getNextCheckDelay(status.ConsecutiveFailures)
+	return
+}
+
+// probe checks the reachability, credentials, default branch and webhooks of a GitRepository.
+// It returns true if the git repository is reachable with valid credentials.
+func (r *ProbeReconciler) probe(ctx context.Context, repo *v1alpha3.GitRepository, status *v1alpha3.GitRepositoryStatus) (healthy bool) {
+	factory := getClientFactory(repo, r.Client)
+	sshAuth, err := factory.GetSSHAuth()
+	if err == nil && sshAuth != nil {
+		return r.probeWithSSH(ctx, sshAuth, repo, status)
+	}
+
+	repoPath := git.GetRepoPath(repo)
+	if repoPath == "" {
+		status.Message = "the owner and name of the git repository are unknown"
+		status.SetCondition(v1alpha3.Condition{
+			Type:    v1alpha3.GitRepoConditionReachable,
+			Status:  v1alpha3.ConditionUnknown,
+			Reason:  "InvalidRepository",
+			Message: status.Message,
+		})
+		return
+	}
+
+	var gitClient *scm.Client
+	if err == nil {
+		gitClient, err = factory.GetClientWithContext(ctx)
+	}
+	if err != nil {
+		status.Message = err.Error()
+		status.SetCondition(v1alpha3.Condition{
+			Type:    v1alpha3.GitRepoConditionAuthenticated,
+			Status:  v1alpha3.ConditionFalse,
+			Reason:  "InvalidSecret",
+			Message: status.Message,
+		})
+		return
+	}
+
+	gitRepo, res, err := gitClient.Repositories.Find(ctx, repoPath)
+	if err != nil {
+		status.Message = err.Error()
+		setConnectionConditions(status, res, err)
+		return
+	}
+	setConnectionConditions(status, res, nil)
+	healthy = true
+	status.Message = ""
+
+	status.DefaultBranch = gitRepo.Branch
+	if gitRepo.Branch != "" {
+		if branch, _, branchErr := gitClient.Git.FindBranch(ctx, repoPath, gitRepo.Branch); branchErr == nil {
+			status.LatestCommit = branch.Sha
+		} else {
+			r.log.V(6).Info(fmt.Sprintf("failed to find the branch %s of %s, error: %v", gitRepo.Branch, repoPath, branchErr))
+		}
+	}
+
+	r.probeWebhooks(ctx, gitClient, repo, repoPath, status)
+	return
+}
+
+// setConnectionConditions sets the reachable and authenticated conditions according to the response of the git provider
+func setConnectionConditions(status *v1alpha3.GitRepositoryStatus, res *scm.Response, err error) {
+	if err == nil {
+		status.SetCondition(v1alpha3.Condition{
+			Type:   v1alpha3.GitRepoConditionReachable,
+			Status: v1alpha3.ConditionTrue,
+		})
+		status.SetCondition(v1alpha3.Condition{
+			Type:   v1alpha3.GitRepoConditionAuthenticated,
+			Status: v1alpha3.ConditionTrue,
+		})
+		return
+	}
+
+	if res == nil || res.Status == 0 || res.Status >= http.StatusInternalServerError {
+		status.SetCondition(v1alpha3.Condition{
+			Type:    v1alpha3.GitRepoConditionReachable,
+			Status:  v1alpha3.ConditionFalse,
+			Reason:  "Unreachable",
+			Message: err.Error(),
+		})
+		status.SetCondition(v1alpha3.Condition{
+			Type:   v1alpha3.GitRepoConditionAuthenticated,
+			Status: v1alpha3.ConditionUnknown,
+		})
+		return
+	}
+
+	status.SetCondition(v1alpha3.Condition{
+		Type:   v1alpha3.GitRepoConditionReachable,
+		Status: v1alpha3.ConditionTrue,
+	})
+	reason := "Unauthorized"
+	if res.Status == http.StatusNotFound {
+		// the private repositories are invisible without valid credentials
+		reason = "NotFound"
+	}
+	status.SetCondition(v1alpha3.Condition{
+		Type:    v1alpha3.GitRepoConditionAuthenticated,
+		Status:  v1alpha3.ConditionFalse,
+		Reason:  reason,
+		Message: err.Error(),
+	})
+}
+
+// probeWebhooks checks if all the referenced webhooks are registered on the git provider
+func (r *ProbeReconciler) probeWebhooks(ctx context.Context, gitClient *scm.Client, repo *v1alpha3.GitRepository,
+	repoPath string, status *v1alpha3.GitRepositoryStatus) {
+	if len(repo.Spec.Webhooks) == 0 {
+		status.SetCondition(v1alpha3.Condition{
+			Type:   v1alpha3.GitRepoConditionWebhookRegistered,
+			Status: v1alpha3.ConditionUnknown,
+			Reason: "NoWebhooks",
+		})
+		return
+	}
+
+	hooks, _, err := gitClient.Repositories.ListHooks(ctx, repoPath, &scm.ListOptions{
+		Page: 1,
+		Size: 100,
+	})
+	if err != nil {
+		status.SetCondition(v1alpha3.Condition{
+			Type:    v1alpha3.GitRepoConditionWebhookRegistered,
+			Status:  v1alpha3.ConditionUnknown,
+			Reason:  "ListFailed",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	var missing []string
+	for _, webhookRef := range repo.Spec.Webhooks {
+		webhook := &v1alpha3.Webhook{}
+		if err = r.Get(ctx, types.NamespacedName{Namespace: repo.Namespace, Name: webhookRef.Name}, webhook); err != nil {
+			missing = append(missing, webhookRef.Name)
+			continue
+		}
+		if ok, _ := exist(webhook.Spec.Server, hooks); !ok {
+			missing = append(missing, webhookRef.Name)
+		}
+	}
+
+	if len(missing) > 0 {
+		status.SetCondition(v1alpha3.Condition{
+			Type:    v1alpha3.GitRepoConditionWebhookRegistered,
+			Status:  v1alpha3.ConditionFalse,
+			Reason:  "NotRegistered",
+			Message: fmt.Sprintf("webhooks are not registered: %s", strings.Join(missing, ",")),
+		})
+	} else {
+		status.SetCondition(v1alpha3.Condition{
+			Type:   v1alpha3.GitRepoConditionWebhookRegistered,
+			Status: v1alpha3.ConditionTrue,
+		})
+	}
+}
+
+// getNextCheckDelay returns the delay of the next check, the delay grows exponentially with the failures
+func (r *ProbeReconciler) getNextCheckDelay(failures int) (delay time.Duration) {
+	interval := getDurationOrDefault(r.Interval, defaultProbeInterval)
+	if failures <= 0 {
+		return interval
+	}
+
+	maxBackoff := getDurationOrDefault(r.MaxBackoff, defaultProbeMaxBackoff)
+	delay = getDurationOrDefault(r.MinBackoff, defaultProbeMinBackoff)
+	for i := 1; i < failures && delay < maxBackoff; i++ {
+		delay *= 2
+	}
+	if delay > maxBackoff {
+		delay = maxBackoff
+	}
+	return
+}
+
+func getDurationOrDefault(duration, defaultDuration time.Duration) time.Duration {
+	if duration <= 0 {
+		return defaultDuration
+	}
+	return duration
+}
+
+// probeWithSSH checks the git repository through the SSH protocol, the webhooks cannot be checked in this way
+func (r *ProbeReconciler) probeWithSSH(ctx context.Context, sshAuth *git.SSHAuth, repo *v1alpha3.GitRepository,
+	status *v1alpha3.GitRepositoryStatus) (healthy bool) {
+	refs, err := sshAuth.ListRemoteRefs(ctx, repo.Spec.URL)
+	if err != nil {
+		status.Message = err.Error()
+		var netErr net.Error
+		if errors.As(err, &netErr) || errors.Is(err, context.DeadlineExceeded) {
+			setConnectionConditions(status, nil, err)
+		} else {
+			setConnectionConditions(status, &scm.Response{Status: http.StatusUnauthorized}, err)
+		}
+		return
+	}
+	setConnectionConditions(status, nil, nil)
+	healthy = true
+	status.Message = ""
+
+	if branch, ok := refs.DefaultBranch(); ok {
+		status.DefaultBranch = branch.ShortName()
+		status.LatestCommit = branch.SHA
+	}
+	status.SetCondition(v1alpha3.Condition{
+		Type:    v1alpha3.GitRepoConditionWebhookRegistered,
+		Status:  v1alpha3.ConditionUnknown,
+		Reason:  "SSHAuth",
+		Message: "the webhooks cannot be checked through the git protocol",
+	})
+	return
+}
+
+// getClientFactory returns the git client factory of a GitRepository
+func getClientFactory(repo *v1alpha3.GitRepository, k8sClient client.Client) *git.ClientFactory {
+	var secretRef *v1.SecretReference
+	if repo.Spec.Secret != nil {
+		secretRef = repo.Spec.Secret.DeepCopy()
+		// make sure the namespace exist
+		if secretRef.Namespace == "" {
+			secretRef.Namespace = repo.Namespace
+		}
+	}
+
+	factory := git.NewClientFactory(repo.Spec.Provider, secretRef, k8sClient)
+	factory.Server = repo.Spec.Server
+	return factory
+}
+
+func (r *ProbeReconciler) updateStatus(ctx context.Context, key types.NamespacedName, status *v1alpha3.GitRepositoryStatus) error {
+	return retry.RetryOnConflict(retry.DefaultRetry, func() (err error) {
+		repo := &v1alpha3.GitRepository{}
+		if err = r.Get(ctx, key, repo); err != nil {
+			return client.IgnoreNotFound(err)
+		}
+		// the webhooks are maintained by the webhook reconciler
+		status.Webhooks = repo.Status.Webhooks
+		repo.Status = *status
+		return r.Status().Update(ctx, repo)
+	})
+}
+
+// GetName returns the name of this reconciler
+func (r *ProbeReconciler) GetName() string {
+	return "gitrepository-probe"
+}
+
+// GetGroupName returns the group name of the set of reconcilers
+func (r *ProbeReconciler) GetGroupName() string {
+	return groupName
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *ProbeReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	r.recorder = mgr.GetEventRecorderFor(r.GetName())
+	r.log = ctrl.Log.WithName(r.GetName())
+	return ctrl.NewControllerManagedBy(mgr).
+		Named(r.GetName()).
+		For(&v1alpha3.GitRepository{}).
+		WithEventFilter(predicate.GenerationChangedPredicate{}).
+		Complete(r)
+}
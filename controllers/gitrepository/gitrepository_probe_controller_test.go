@@ -0,0 +1,292 @@
+/*
+Copyright 2022 The KubeSphere Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gitrepository
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/h2non/gock"
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	mgrcore "kubesphere.io/devops/controllers/core"
+	"kubesphere.io/devops/pkg/api/devops/v1alpha3"
+	"kubesphere.io/devops/pkg/client/git"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+func TestProbeReconciler_Reconcile(t *testing.T) {
+	schema, err := v1alpha3.SchemeBuilder.Register().Build()
+	assert.Nil(t, err)
+	err = v1.SchemeBuilder.AddToScheme(schema)
+	assert.Nil(t, err)
+
+	secret := &v1.Secret{}
+	secret.SetName("token")
+	secret.SetNamespace("ns")
+	secret.Type = v1.SecretTypeBasicAuth
+	secret.Data = map[string][]byte{
+		v1.BasicAuthPasswordKey: []byte("token"),
+	}
+
+	webhook := &v1alpha3.Webhook{}
+	webhook.SetName("hook")
+	webhook.SetNamespace("ns")
+	webhook.Spec.Server = "http://example.com/webhook"
+
+	missingWebhook := webhook.DeepCopy()
+	missingWebhook.SetName("missing")
+	missingWebhook.Spec.Server = "http://missing.com/webhook"
+
+	repo := &v1alpha3.GitRepository{}
+	repo.SetName("repo")
+	repo.SetNamespace("ns")
+	repo.Spec = v1alpha3.GitRepositorySpec{
+		Provider: "github",
+		Owner:    "octocat",
+		Repo:     "hello-world",
+		Secret:   &v1.SecretReference{Name: "token"},
+		Webhooks: []v1.LocalObjectReference{{Name: "hook"}},
+	}
+
+	repoWithMissingWebhook := repo.DeepCopy()
+	repoWithMissingWebhook.Spec.Webhooks = append(repoWithMissingWebhook.Spec.Webhooks, v1.LocalObjectReference{Name: "missing"})
+
+	failedRepo := repo.DeepCopy()
+	failedRepo.Status.ConsecutiveFailures = 2
+
+	tests := []struct {
+		name           string
+		repo           *v1alpha3.GitRepository
+		prepare        func()
+		wantConnection string
+		wantFailures   int
+		wantRequeue    time.Duration
+		verify         func(*testing.T, *v1alpha3.GitRepositoryStatus)
+	}{{
+		name: "healthy repository",
+		repo: repo.DeepCopy(),
+		prepare: func() {
+			gock.New("https://api.github.com").
+				Get("/repos/octocat/hello-world").
+				Reply(200).
+				Type("application/json").
+				SetHeaders(mockHeaders).
+				File("testdata/repo.json")
+			gock.New("https://api.github.com").
+				Get("/repos/octocat/hello-world/branches/master").
+				Reply(200).
+				Type("application/json").
+				SetHeaders(mockHeaders).
+				File("testdata/branch.json")
+			gock.New("https://api.github.com").
+				Get("/repos/octocat/hello-world/hooks").
+				MatchParam("page", "1").
+				MatchParam("per_page", "100").
+				Reply(200).
+				Type("application/json").
+				SetHeaders(mockHeaders).
+				File("testdata/hooks.json")
+		},
+		wantConnection: v1alpha3.GitRepoConnectionOK,
+		wantRequeue:    defaultProbeInterval,
+		verify: func(t *testing.T, status *v1alpha3.GitRepositoryStatus) {
+			assert.Equal(t, "master", status.DefaultBranch)
+			assert.Equal(t, "7fd1a60b01f91b314f59955a4e4d4e80d8edf11d", status.LatestCommit)
+			assert.NotNil(t, status.LastCheckedTime)
+			assert.Equal(t, v1alpha3.ConditionTrue, status.GetCondition(v1alpha3.GitRepoConditionReachable).Status)
+			assert.Equal(t, v1alpha3.ConditionTrue, status.GetCondition(v1alpha3.GitRepoConditionAuthenticated).Status)
+			assert.Equal(t, v1alpha3.ConditionTrue, status.GetCondition(v1alpha3.GitRepoConditionWebhookRegistered).Status)
+		},
+	}, {
+		name: "webhook is not registered",
+		repo: repoWithMissingWebhook.DeepCopy(),
+		prepare: func() {
+			gock.New("https://api.github.com").
+				Get("/repos/octocat/hello-world").
+				Reply(200).
+				Type("application/json").
+				SetHeaders(mockHeaders).
+				File("testdata/repo.json")
+			gock.New("https://api.github.com").
+				Get("/repos/octocat/hello-world/branches/master").
+				Reply(200).
+				Type("application/json").
+				SetHeaders(mockHeaders).
+				File("testdata/branch.json")
+			gock.New("https://api.github.com").
+				Get("/repos/octocat/hello-world/hooks").
+				MatchParam("page", "1").
+				MatchParam("per_page", "100").
+				Reply(200).
+				Type("application/json").
+				SetHeaders(mockHeaders).
+				File("testdata/hooks.json")
+		},
+		wantConnection: v1alpha3.GitRepoConnectionOK,
+		wantRequeue:    defaultProbeInterval,
+		verify: func(t *testing.T, status *v1alpha3.GitRepositoryStatus) {
+			condition := status.GetCondition(v1alpha3.GitRepoConditionWebhookRegistered)
+			assert.Equal(t, v1alpha3.ConditionFalse, condition.Status)
+			assert.Contains(t, condition.Message, "missing")
+		},
+	}, {
+		name: "invalid credentials",
+		repo: failedRepo.DeepCopy(),
+		prepare: func() {
+			gock.New("https://api.github.com").
+				Get("/repos/octocat/hello-world").
+				Reply(401).
+				Type("application/json").
+				SetHeaders(mockHeaders).
+				BodyString(`{"message": "Bad credentials"}`)
+		},
+		wantConnection: v1alpha3.GitRepoConnectionFailed,
+		wantFailures:   3,
+		wantRequeue:    4 * defaultProbeMinBackoff,
+		verify: func(t *testing.T, status *v1alpha3.GitRepositoryStatus) {
+			assert.Equal(t, v1alpha3.ConditionTrue, status.GetCondition(v1alpha3.GitRepoConditionReachable).Status)
+			assert.Equal(t, v1alpha3.ConditionFalse, status.GetCondition(v1alpha3.GitRepoConditionAuthenticated).Status)
+		},
+	}, {
+		name: "unreachable server",
+		repo: repo.DeepCopy(),
+		prepare: func() {
+			gock.New("https://api.github.com").
+				Get("/repos/octocat/hello-world").
+				ReplyError(context.DeadlineExceeded)
+		},
+		wantConnection: v1alpha3.GitRepoConnectionFailed,
+		wantFailures:   1,
+		wantRequeue:    defaultProbeMinBackoff,
+		verify: func(t *testing.T, status *v1alpha3.GitRepositoryStatus) {
+			assert.Equal(t, v1alpha3.ConditionFalse, status.GetCondition(v1alpha3.GitRepoConditionReachable).Status)
+		},
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer gock.Off()
+			if tt.prepare != nil {
+				tt.prepare()
+			}
+
+			k8sClient := fake.NewClientBuilder().WithScheme(schema).
+				WithObjects(tt.repo, secret.DeepCopy(), webhook.DeepCopy(), missingWebhook.DeepCopy()).Build()
+			r := &ProbeReconciler{
+				Client: k8sClient,
+				log:    logr.New(log.NullLogSink{}),
+			}
+			key := types.NamespacedName{Namespace: "ns", Name: "repo"}
+			result, err := r.Reconcile(context.TODO(), ctrl.Request{NamespacedName: key})
+			assert.Nil(t, err)
+			assert.Equal(t, tt.wantRequeue, result.RequeueAfter)
+
+			updated := &v1alpha3.GitRepository{}
+			assert.Nil(t, k8sClient.Get(context.TODO(), key, updated))
+			assert.Equal(t, tt.wantConnection, updated.Status.Connection)
+			assert.Equal(t, tt.wantFailures, updated.Status.ConsecutiveFailures)
+			if tt.verify != nil {
+				tt.verify(t, &updated.Status)
+			}
+		})
+	}
+}
+
+func TestProbeReconciler_ReconcileWithSSH(t *testing.T) {
+	schema, err := v1alpha3.SchemeBuilder.Register().Build()
+	assert.Nil(t, err)
+	err = v1.SchemeBuilder.AddToScheme(schema)
+	assert.Nil(t, err)
+
+	privateKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.Nil(t, err)
+	secret := &v1.Secret{}
+	secret.SetName("ssh")
+	secret.SetNamespace("ns")
+	secret.Type = v1.SecretTypeSSHAuth
+	secret.Data = map[string][]byte{
+		v1.SSHAuthPrivateKey: pem.EncodeToMemory(&pem.Block{
+			Type:  "RSA PRIVATE KEY",
+			Bytes: x509.MarshalPKCS1PrivateKey(privateKey),
+		}),
+		git.SSHInsecureSkipHostKeyVerifyKey: []byte("true"),
+	}
+
+	// an address without any listeners
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.Nil(t, err)
+	address := listener.Addr().String()
+	_ = listener.Close()
+
+	repo := &v1alpha3.GitRepository{}
+	repo.SetName("repo")
+	repo.SetNamespace("ns")
+	repo.Spec = v1alpha3.GitRepositorySpec{
+		Provider: "git",
+		URL:      fmt.Sprintf("ssh://git@%s/owner/repo.git", address),
+		Secret:   &v1.SecretReference{Name: "ssh"},
+	}
+
+	k8sClient := fake.NewClientBuilder().WithScheme(schema).WithObjects(repo, secret).Build()
+	r := &ProbeReconciler{
+		Client: k8sClient,
+		log:    logr.New(log.NullLogSink{}),
+	}
+	key := types.NamespacedName{Namespace: "ns", Name: "repo"}
+	result, err := r.Reconcile(context.TODO(), ctrl.Request{NamespacedName: key})
+	assert.Nil(t, err)
+	assert.Equal(t, defaultProbeMinBackoff, result.RequeueAfter)
+
+	updated := &v1alpha3.GitRepository{}
+	assert.Nil(t, k8sClient.Get(context.TODO(), key, updated))
+	assert.Equal(t, v1alpha3.GitRepoConnectionFailed, updated.Status.Connection)
+	assert.Equal(t, v1alpha3.ConditionFalse, updated.Status.GetCondition(v1alpha3.GitRepoConditionReachable).Status)
+	assert.Nil(t, updated.Status.GetCondition(v1alpha3.GitRepoConditionWebhookRegistered))
+}
+
+func TestProbeReconciler_getNextCheckDelay(t *testing.T) {
+	r := &ProbeReconciler{
+		Interval:   time.Minute,
+		MinBackoff: time.Second,
+		MaxBackoff: 10 * time.Second,
+	}
+	assert.Equal(t, time.Minute, r.getNextCheckDelay(0))
+	assert.Equal(t, time.Second, r.getNextCheckDelay(1))
+	assert.Equal(t, 2*time.Second, r.getNextCheckDelay(2))
+	assert.Equal(t, 8*time.Second, r.getNextCheckDelay(4))
+	assert.Equal(t, 10*time.Second, r.getNextCheckDelay(5))
+	assert.Equal(t, 10*time.Second, r.getNextCheckDelay(100))
+}
+
+func TestProbeReconciler_SetupWithManager(t *testing.T) {
+	schema, err := v1alpha3.SchemeBuilder.Register().Build()
+	assert.Nil(t, err)
+
+	r := &ProbeReconciler{}
+	mgr := &mgrcore.FakeManager{
+		Scheme: schema,
+	}
+	assert.Nil(t, r.SetupWithManager(mgr))
+}
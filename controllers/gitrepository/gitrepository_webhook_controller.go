@@ -15,20 +15,27 @@ package gitrepository
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"strings"
 	"time"
 
 	"github.com/jenkins-x/go-scm/scm"
 	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
 	"kubesphere.io/devops/pkg/api/devops/v1alpha3"
 	"kubesphere.io/devops/pkg/client/git"
+	"kubesphere.io/devops/pkg/utils/k8sutil"
+	"kubesphere.io/devops/pkg/utils/sliceutil"
 
 	"github.com/go-logr/logr"
 	"k8s.io/client-go/tools/record"
+	"k8s.io/client-go/util/retry"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
 )
 
 // Reconciler reconciles a GitRepository object
@@ -40,7 +47,11 @@ type Reconciler struct {
 
 //+kubebuilder:rbac:groups=devops.kubesphere.io,resources=webhooks,verbs=get;list;update;patch
 //+kubebuilder:rbac:groups=devops.kubesphere.io,resources=secrets,verbs=get
-//+kubebuilder:rbac:groups=devops.kubesphere.io,resources=gitrepositories,verbs=get;list;watch
+//+kubebuilder:rbac:groups=devops.kubesphere.io,resources=gitrepositories,verbs=get;list;watch;update
+//+kubebuilder:rbac:groups=devops.kubesphere.io,resources=gitrepositories/status,verbs=get;update;patch
+
+// webhookResyncPeriod is the period to check if the hooks were changed or removed on the git provider
+const webhookResyncPeriod = 10 * time.Minute
 
 // Reconcile is part of the main kubernetes reconciliation loop which aims to
 // move the current state of the cluster closer to the desired state.
@@ -55,9 +66,15 @@ func (r *Reconciler) Reconcile(ctx context.Context, req ctrl.Request) (result ct
 		return
 	}
 
+	if !repo.ObjectMeta.DeletionTimestamp.IsZero() {
+		err = r.finalize(ctx, repo)
+		return
+	}
+
 	webhooks := repo.Spec.Webhooks
-	if len(webhooks) == 0 {
+	if len(webhooks) == 0 && len(repo.Status.Webhooks) == 0 {
 		// do nothing if there are not any webhooks
+		err = r.removeFinalizer(ctx, repo)
 		return
 	}
 
@@ -72,76 +89,283 @@ func (r *Reconciler) Reconcile(ctx context.Context, req ctrl.Request) (result ct
 			return
 		}
 
-		err = r.createOrUpdateWebhook(repo)
+		if k8sutil.AddFinalizer(&repo.ObjectMeta, v1alpha3.GitRepoWebhookFinalizerName) {
+			if err = r.Update(ctx, repo); err != nil {
+				return
+			}
+		}
+
+		if err = r.createOrUpdateWebhook(repo); err == nil {
+			if len(webhooks) == 0 {
+				// all the hooks were removed from the git provider
+				err = r.removeFinalizer(ctx, repo)
+				return
+			}
+			// check the drift of the hooks periodically
+			result.RequeueAfter = webhookResyncPeriod
+		}
+	}
+	return
+}
+
+// finalize removes the hooks from the git provider, then removes the finalizer
+func (r *Reconciler) finalize(ctx context.Context, repo *v1alpha3.GitRepository) (err error) {
+	if !sliceutil.HasString(repo.Finalizers, v1alpha3.GitRepoWebhookFinalizerName) {
+		return
 	}
+
+	var gitClient *scm.Client
+	repoAddress := git.GetRepoPath(repo)
+	if gitClient, err = r.getGitClient(repo); err != nil || repoAddress == "" {
+		// there is no way to remove the hooks without a valid client, ignore them to avoid blocking the deletion
+		r.log.Info(fmt.Sprintf("skip removing the hooks of GitRepository %s/%s, error: %v", repo.Namespace, repo.Name, err))
+	} else {
+		var hooks []*scm.Hook
+		if hooks, err = listAllHooks(ctx, gitClient, repoAddress); err != nil {
+			if !errors.Is(err, scm.ErrNotFound) {
+				return
+			}
+			// the repository was removed from the git provider
+			hooks = nil
+		}
+
+		for i := range repo.Status.Webhooks {
+			if hooks, err = deleteHook(ctx, gitClient, repoAddress, &repo.Status.Webhooks[i], hooks); err != nil {
+				return
+			}
+		}
+		for i := range repo.Spec.Webhooks {
+			webhook := &v1alpha3.Webhook{}
+			if getErr := r.Get(ctx, types.NamespacedName{Namespace: repo.Namespace, Name: repo.Spec.Webhooks[i].Name}, webhook); getErr != nil {
+				continue
+			}
+			if hooks, err = deleteHook(ctx, gitClient, repoAddress, &v1alpha3.WebhookStatus{Server: webhook.Spec.Server}, hooks); err != nil {
+				return
+			}
+		}
+	}
+
+	err = r.removeFinalizer(ctx, repo)
 	return
 }
 
+func (r *Reconciler) removeFinalizer(ctx context.Context, repo *v1alpha3.GitRepository) error {
+	if !sliceutil.HasString(repo.Finalizers, v1alpha3.GitRepoWebhookFinalizerName) {
+		return nil
+	}
+	return retry.RetryOnConflict(retry.DefaultRetry, func() (err error) {
+		latest := &v1alpha3.GitRepository{}
+		if err = r.Get(ctx, types.NamespacedName{Namespace: repo.Namespace, Name: repo.Name}, latest); err != nil {
+			return client.IgnoreNotFound(err)
+		}
+		k8sutil.RemoveFinalizer(&latest.ObjectMeta, v1alpha3.GitRepoWebhookFinalizerName)
+		return r.Update(ctx, latest)
+	})
+}
+
 func (r *Reconciler) createOrUpdateWebhook(repo *v1alpha3.GitRepository) (err error) {
+	ctx := context.TODO()
 	var gitClient *scm.Client
 	if gitClient, err = r.getGitClient(repo); err != nil {
+		if errors.Is(err, git.ErrSSHAuth) {
+			err = fmt.Errorf("webhooks cannot be registered with a SSH auth secret, please use a token or GitHub App instead")
+		}
 		return
 	}
 
-	repoAddress := getRepo(repo)
+	repoAddress := git.GetRepoPath(repo)
 	if repoAddress == "" {
 		err = fmt.Errorf("failed to createOrUpdate webhook due to repo address is empty")
 		return
 	}
 
 	var hooks []*scm.Hook
-	if hooks, _, err = gitClient.Repositories.ListHooks(context.TODO(), repoAddress, &scm.ListOptions{
-		Page: 1,
-		Size: 30,
-	}); err != nil {
+	if hooks, err = listAllHooks(ctx, gitClient, repoAddress); err != nil {
 		err = fmt.Errorf("failed to list the existing webhooks, error: %v", err)
 		return
 	}
 
+	var statuses []v1alpha3.WebhookStatus
+	var failed []string
 	for index := range repo.Spec.Webhooks {
 		webhookRef := repo.Spec.Webhooks[index]
-		webhook := &v1alpha3.Webhook{}
-		if err = r.Client.Get(context.TODO(), types.NamespacedName{
-			Namespace: repo.Namespace,
-			Name:      webhookRef.Name,
-		}, webhook); err != nil {
-			// TODO need to print the error log output
+		status := v1alpha3.WebhookStatus{Name: webhookRef.Name}
+		if existing := repo.Status.GetWebhookStatus(webhookRef.Name); existing != nil {
+			status = *existing.DeepCopy()
+		}
+
+		if syncErr := r.syncHook(ctx, gitClient, repo, repoAddress, hooks, &status); syncErr != nil {
+			failed = append(failed, webhookRef.Name)
+			status.Registered = false
+			status.Message = syncErr.Error()
+		} else {
+			status.Message = ""
+		}
+		now := metav1.Now()
+		status.LastSyncTime = &now
+		statuses = append(statuses, status)
+	}
+
+	// remove the hooks which are not referenced anymore
+	for i := range repo.Status.Webhooks {
+		status := &repo.Status.Webhooks[i]
+		if referenced(repo.Spec.Webhooks, status.Name) {
 			continue
 		}
+		var deleteErr error
+		if hooks, deleteErr = deleteHook(ctx, gitClient, repoAddress, status, hooks); deleteErr != nil {
+			failed = append(failed, status.Name)
+			status.Message = deleteErr.Error()
+			statuses = append(statuses, *status)
+		}
+	}
 
-		// the token is optional, we can ignore the error
-		webhookToken, _ := r.getTokenFromSecret(repo.Spec.Secret, repo.Namespace)
-
-		// TODO users need to add every single event of target git provider if they want to add all of them
-		//   it's possible to have a solution to allow users add all events in an easy way.
-		//   For instance, we can use 'all' represents it.
-		hookInput := &scm.HookInput{
-			Name:         webhookRef.Name,
-			Target:       webhook.Spec.Server,
-			Secret:       webhookToken,
-			SkipVerify:   webhook.Spec.SkipVerify,
-			NativeEvents: webhook.Spec.Events,
+	if err = r.updateWebhookStatus(ctx, types.NamespacedName{Namespace: repo.Namespace, Name: repo.Name}, statuses); err == nil && len(failed) > 0 {
+		err = fmt.Errorf("failed to sync webhooks: %v", failed)
+	}
+	return
+}
+
+// syncHook creates the hook if it does not exist, or updates it if it is drifted
+func (r *Reconciler) syncHook(ctx context.Context, gitClient *scm.Client, repo *v1alpha3.GitRepository,
+	repoAddress string, hooks []*scm.Hook, status *v1alpha3.WebhookStatus) (err error) {
+	webhook := &v1alpha3.Webhook{}
+	if err = r.Client.Get(ctx, types.NamespacedName{
+		Namespace: repo.Namespace,
+		Name:      status.Name,
+	}, webhook); err != nil {
+		return
+	}
+
+	hookInput := &scm.HookInput{
+		Name:       status.Name,
+		Target:     webhook.Spec.Server,
+		SkipVerify: getHookSkipVerify(repo.Spec.Provider, webhook.Spec.SkipVerify),
+	}
+	if webhook.Spec.Secret != nil {
+		if hookInput.Secret, err = r.getTokenFromSecret(webhook.Spec.Secret, webhook.Namespace); err != nil {
+			return
 		}
+	}
+	hookInput.Events, hookInput.NativeEvents = getHookEvents(webhook.Spec.Events)
+	events := getProviderEvents(repo.Spec.Provider, hookInput.Events, hookInput.NativeEvents)
 
-		if ok, _ := exist(webhook.Spec.Server, hooks); ok {
-			// update the existing webhooks
-			_, _, err = gitClient.Repositories.UpdateHook(context.TODO(), repoAddress, hookInput)
-		} else {
-			// create the webhook
-			_, _, err = gitClient.Repositories.CreateHook(context.TODO(), repoAddress, hookInput)
+	// the hook of a previous server should be removed if the server was changed
+	if status.Server != "" && status.Server != webhook.Spec.Server {
+		if _, err = deleteHook(ctx, gitClient, repoAddress, status, hooks); err != nil {
+			return
 		}
+		status.ID = ""
+	}
+
+	var hook *scm.Hook
+	if existing := findHook(webhook.Spec.Server, hooks); existing == nil {
+		hook, _, err = gitClient.Repositories.CreateHook(ctx, repoAddress, hookInput)
+	} else if existing.ID != status.ID || webhook.Generation != status.ObservedGeneration ||
+		isHookDrifted(repo.Spec.Provider, existing, events, webhook.Spec.SkipVerify) {
+		hook, err = updateHook(ctx, gitClient, repoAddress, existing.ID, hookInput)
+	} else {
+		hook = existing
+	}
+	if err != nil {
+		return
 	}
+
+	if hook != nil && hook.ID != "" {
+		status.ID = hook.ID
+	}
+	status.Server = webhook.Spec.Server
+	status.Events = events
+	status.ObservedGeneration = webhook.Generation
+	status.Registered = true
 	return
 }
 
-func exist(server string, hooks []*scm.Hook) (exist bool, id string) {
+// updateHook updates the hook, it will recreate the hook if the git provider does not support updating it
+func updateHook(ctx context.Context, gitClient *scm.Client, repoAddress, id string, hookInput *scm.HookInput) (hook *scm.Hook, err error) {
+	input := *hookInput
+	// some of the git providers take the name as the ID of the hook
+	input.Name = id
+	if hook, _, err = gitClient.Repositories.UpdateHook(ctx, repoAddress, &input); errors.Is(err, scm.ErrNotSupported) {
+		if _, err = gitClient.Repositories.DeleteHook(ctx, repoAddress, id); err == nil {
+			hook, _, err = gitClient.Repositories.CreateHook(ctx, repoAddress, hookInput)
+		}
+	}
+	return
+}
+
+// deleteHook deletes the hooks which match the ID or server of the status, the missing hooks are ignored.
+// It returns the hooks which are not deleted.
+func deleteHook(ctx context.Context, gitClient *scm.Client, repoAddress string, status *v1alpha3.WebhookStatus,
+	hooks []*scm.Hook) (remaining []*scm.Hook, err error) {
+	for i, hook := range hooks {
+		if (status.ID == "" || hook.ID != status.ID) && (status.Server == "" || hook.Target != status.Server) {
+			remaining = append(remaining, hook)
+			continue
+		}
+		if _, err = gitClient.Repositories.DeleteHook(ctx, repoAddress, hook.ID); err != nil && !errors.Is(err, scm.ErrNotFound) {
+			remaining = append(remaining, hooks[i:]...)
+			return
+		}
+		err = nil
+	}
+	return
+}
+
+// listAllHooks lists the hooks of all pages
+func listAllHooks(ctx context.Context, gitClient *scm.Client, repoAddress string) (hooks []*scm.Hook, err error) {
+	opts := &scm.ListOptions{
+		Page: 1,
+		Size: 30,
+	}
+	for {
+		var pagedHooks []*scm.Hook
+		var res *scm.Response
+		if pagedHooks, res, err = gitClient.Repositories.ListHooks(ctx, repoAddress, opts); err != nil {
+			return
+		}
+		hooks = append(hooks, pagedHooks...)
+		if res == nil || res.Page.Next <= opts.Page || len(pagedHooks) == 0 {
+			return
+		}
+		opts.Page = res.Page.Next
+	}
+}
+
+func (r *Reconciler) updateWebhookStatus(ctx context.Context, key types.NamespacedName, statuses []v1alpha3.WebhookStatus) error {
+	return retry.RetryOnConflict(retry.DefaultRetry, func() (err error) {
+		repo := &v1alpha3.GitRepository{}
+		if err = r.Get(ctx, key, repo); err != nil {
+			return client.IgnoreNotFound(err)
+		}
+		repo.Status.Webhooks = statuses
+		return r.Status().Update(ctx, repo)
+	})
+}
+
+func referenced(refs []v1.LocalObjectReference, name string) bool {
+	for _, ref := range refs {
+		if ref.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+func findHook(server string, hooks []*scm.Hook) *scm.Hook {
 	for _, hook := range hooks {
 		if hook.Target == server {
-			id = hook.ID
-			exist = true
-			break
+			return hook
 		}
 	}
+	return nil
+}
+
+func exist(server string, hooks []*scm.Hook) (exist bool, id string) {
+	if hook := findHook(server, hooks); hook != nil {
+		id = hook.ID
+		exist = true
+	}
 	return
 }
 
@@ -153,20 +377,16 @@ func (r *Reconciler) getGitClient(repo *v1alpha3.GitRepository) (client *scm.Cli
 	if spec.Secret != nil && spec.Secret.Namespace == "" {
 		spec.Secret.Namespace = repo.Namespace
 	}
-	return git.NewClientFactory(provider, spec.Secret, r.Client).GetClient()
+	factory := git.NewClientFactory(provider, spec.Secret, r.Client)
+	factory.Server = spec.Server
+	return factory.GetClient()
 }
 
+// getTokenFromSecret returns the token of a secret, all the DevOps credentials which hold a token are supported
 func (r *Reconciler) getTokenFromSecret(secretRef *v1.SecretReference, defaultNamespace string) (token string, err error) {
 	var gitSecret *v1.Secret
-	if gitSecret, err = r.getSecret(secretRef, defaultNamespace); err != nil {
-		return
-	}
-
-	switch gitSecret.Type {
-	case v1.SecretTypeBasicAuth:
-		token = string(gitSecret.Data[v1.BasicAuthPasswordKey])
-	case v1.SecretTypeOpaque:
-		token = string(gitSecret.Data[v1.ServiceAccountTokenKey])
+	if gitSecret, err = r.getSecret(secretRef, defaultNamespace); err == nil {
+		token, _, err = git.GetTokenFromSecret(gitSecret)
 	}
 	return
 }
@@ -187,21 +407,6 @@ func (r *Reconciler) getSecret(ref *v1.SecretReference, defaultNamespace string)
 	return
 }
 
-func getRepo(repo *v1alpha3.GitRepository) string {
-	if repo == nil || repo.Spec.Provider == "" {
-		return ""
-	}
-
-	address := repo.Spec.URL
-	switch repo.Spec.Provider {
-	case "github":
-		return strings.ReplaceAll(address, "https://github.com/", "")
-	case "gitlab":
-		return strings.ReplaceAll(address, "https://gitlab.com/", "")
-	}
-	return ""
-}
-
 func (r *Reconciler) linkToWebhooks(repo *v1alpha3.GitRepository) (err error) {
 	var failedLinks []string
 	for i := range repo.Spec.Webhooks {
@@ -261,6 +466,11 @@ func (r *Reconciler) SetupWithManager(mgr ctrl.Manager) error {
 	r.recorder = mgr.GetEventRecorderFor(r.GetName())
 	r.log = ctrl.Log.WithName(r.GetName())
 	return ctrl.NewControllerManagedBy(mgr).
+		Named(r.GetName()).
 		For(&v1alpha3.GitRepository{}).
+		WithEventFilter(predicate.Or(predicate.GenerationChangedPredicate{}, predicate.AnnotationChangedPredicate{},
+			predicate.Funcs{UpdateFunc: func(e event.UpdateEvent) bool {
+				return !e.ObjectNew.GetDeletionTimestamp().IsZero()
+			}})).
 		Complete(r)
 }
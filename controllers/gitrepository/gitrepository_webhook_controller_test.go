@@ -40,56 +40,6 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/log"
 )
 
-func Test_getRepo(t *testing.T) {
-	type args struct {
-		repo *v1alpha3.GitRepository
-	}
-	tests := []struct {
-		name string
-		args args
-		want string
-	}{{
-		name: "not supported provider",
-		args: args{
-			repo: &v1alpha3.GitRepository{Spec: v1alpha3.GitRepositorySpec{Provider: "fake"}},
-		},
-		want: "",
-	}, {
-		name: "provider is emtpy",
-		args: args{
-			repo: &v1alpha3.GitRepository{Spec: v1alpha3.GitRepositorySpec{
-				URL: "https://github.com/linuxsuren/test",
-			}},
-		},
-		want: "",
-	}, {
-		name: "github as the provider",
-		args: args{
-			repo: &v1alpha3.GitRepository{Spec: v1alpha3.GitRepositorySpec{
-				Provider: "github",
-				URL:      "https://github.com/linuxsuren/test",
-			}},
-		},
-		want: "linuxsuren/test",
-	}, {
-		name: "gitlab as the provider",
-		args: args{
-			repo: &v1alpha3.GitRepository{Spec: v1alpha3.GitRepositorySpec{
-				Provider: "gitlab",
-				URL:      "https://gitlab.com/linuxsuren/test",
-			}},
-		},
-		want: "linuxsuren/test",
-	}}
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			if got := getRepo(tt.args.repo); got != tt.want {
-				t.Errorf("getRepo() = %v, want %v", got, tt.want)
-			}
-		})
-	}
-}
-
 func Test_exist(t *testing.T) {
 	type args struct {
 		server string
@@ -431,6 +381,28 @@ func TestReconciler_getTokenFromSecret(t *testing.T) {
 		},
 	}
 
+	secretText := &v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "secretText",
+			Namespace: "ns",
+		},
+		Type: v1alpha3.SecretTypeSecretText,
+		Data: map[string][]byte{
+			v1alpha3.SecretTextSecretKey: []byte("token"),
+		},
+	}
+	devopsBasicSecret := &v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "devopsBasicSecret",
+			Namespace: "ns",
+		},
+		Type: v1alpha3.SecretTypeBasicAuth,
+		Data: map[string][]byte{
+			v1alpha3.BasicAuthUsernameKey: []byte("admin"),
+			v1alpha3.BasicAuthPasswordKey: []byte("token"),
+		},
+	}
+
 	type fields struct {
 		Client   client.Client
 		log      logr.Logger
@@ -447,6 +419,34 @@ func TestReconciler_getTokenFromSecret(t *testing.T) {
 		wantToken string
 		wantErr   assert.ErrorAssertionFunc
 	}{{
+		name: "DevOps secret-text credential",
+		fields: fields{
+			Client: fake.NewFakeClientWithScheme(schema, secretText.DeepCopy()),
+		},
+		args: args{
+			secretRef:        &v1.SecretReference{Name: "secretText"},
+			defaultNamespace: "ns",
+		},
+		wantToken: "token",
+		wantErr: func(t assert.TestingT, err error, i ...interface{}) bool {
+			assert.Nil(t, err)
+			return false
+		},
+	}, {
+		name: "DevOps basic-auth credential",
+		fields: fields{
+			Client: fake.NewFakeClientWithScheme(schema, devopsBasicSecret.DeepCopy()),
+		},
+		args: args{
+			secretRef:        &v1.SecretReference{Name: "devopsBasicSecret"},
+			defaultNamespace: "ns",
+		},
+		wantToken: "token",
+		wantErr: func(t assert.TestingT, err error, i ...interface{}) bool {
+			assert.Nil(t, err)
+			return false
+		},
+	}, {
 		name: "normal case, basic auth secret",
 		fields: fields{
 			Client: fake.NewFakeClientWithScheme(schema, basicSecret.DeepCopy()),
@@ -732,6 +732,31 @@ func TestReconciler_Reconcile(t *testing.T) {
 	secret.Namespace = "ns"
 	secret.Name = "fake"
 
+	registeredRepo := repoWithSecret.DeepCopy()
+	registeredRepo.Finalizers = []string{v1alpha3.GitRepoWebhookFinalizerName}
+	registeredRepo.Status.Webhooks = []v1alpha3.WebhookStatus{{
+		Name:       "fake",
+		ID:         "1",
+		Server:     "http://example.com",
+		Events:     []string{"pull_request", "push"},
+		Registered: true,
+	}}
+
+	unreferencedRepo := registeredRepo.DeepCopy()
+	unreferencedRepo.Spec.Webhooks = nil
+
+	deletingRepo := registeredRepo.DeepCopy()
+	deletingRepo.DeletionTimestamp = &metav1.Time{Time: time.Now()}
+	// keep the object alive after removing the webhook finalizer
+	deletingRepo.Finalizers = append(deletingRepo.Finalizers, "fake")
+
+	var mockHeaders = map[string]string{
+		"X-GitHub-Request-Id":   "DD0E:6011:12F21A8:1926790:5A2064E2",
+		"X-RateLimit-Limit":     "60",
+		"X-RateLimit-Remaining": "59",
+		"X-RateLimit-Reset":     "1512076018",
+	}
+
 	type fields struct {
 		Client client.Client
 	}
@@ -745,6 +770,7 @@ func TestReconciler_Reconcile(t *testing.T) {
 		wantResult controllerruntime.Result
 		wantErr    assert.ErrorAssertionFunc
 		prepare    func()
+		verify     func(t *testing.T, c client.Client)
 	}{{
 		name:   "not found git repository",
 		fields: fields{Client: fake.NewFakeClientWithScheme(schema)},
@@ -836,20 +862,6 @@ func TestReconciler_Reconcile(t *testing.T) {
 		},
 		args: args{req: req},
 		prepare: func() {
-			var mockHeaders = map[string]string{
-				"X-GitHub-Request-Id":   "DD0E:6011:12F21A8:1926790:5A2064E2",
-				"X-RateLimit-Limit":     "60",
-				"X-RateLimit-Remaining": "59",
-				"X-RateLimit-Reset":     "1512076018",
-			}
-
-			var mockPageHeaders = map[string]string{
-				"Link": `<https://api.github.com/resource?page=2>; rel="next",` +
-					`<https://api.github.com/resource?page=1>; rel="prev",` +
-					`<https://api.github.com/resource?page=1>; rel="first",` +
-					`<https://api.github.com/resource?page=5>; rel="last"`,
-			}
-
 			gock.New("https://api.github.com").
 				Get("/repos/linuxsuren/test/hooks").
 				MatchParam("page", "1").
@@ -857,11 +869,21 @@ func TestReconciler_Reconcile(t *testing.T) {
 				Reply(200).
 				Type("application/json").
 				SetHeaders(mockHeaders).
-				SetHeaders(mockPageHeaders).
 				File("testdata/hooks.json")
 
 			gock.New("https://api.github.com").
 				Post("/repos/linuxsuren/test/hooks").
+				JSON(map[string]interface{}{
+					"name":   "web",
+					"events": []string{"push", "pull_request"},
+					"active": true,
+					"config": map[string]string{
+						"url":          "http://example.com",
+						"secret":       "",
+						"content_type": "json",
+						"insecure_ssl": "1",
+					},
+				}).
 				Reply(201).
 				Type("application/json").
 				SetHeaders(mockHeaders).
@@ -871,6 +893,167 @@ func TestReconciler_Reconcile(t *testing.T) {
 			assert.Nil(t, err)
 			return true
 		},
+		wantResult: controllerruntime.Result{RequeueAfter: webhookResyncPeriod},
+		verify: func(t *testing.T, c client.Client) {
+			repo := &v1alpha3.GitRepository{}
+			assert.Nil(t, c.Get(context.Background(), req.NamespacedName, repo))
+			assert.Contains(t, repo.Finalizers, v1alpha3.GitRepoWebhookFinalizerName)
+			if assert.Equal(t, 1, len(repo.Status.Webhooks)) {
+				status := repo.Status.Webhooks[0]
+				assert.Equal(t, "fake", status.Name)
+				assert.Equal(t, "1", status.ID)
+				assert.Equal(t, "http://example.com", status.Server)
+				assert.Equal(t, []string{"pull_request", "push"}, status.Events)
+				assert.True(t, status.Registered)
+				assert.NotNil(t, status.LastSyncTime)
+			}
+		},
+	}, {
+		name: "the hook was edited on the git provider",
+		fields: fields{
+			Client: fake.NewFakeClientWithScheme(schema, registeredRepo.DeepCopy(), secret.DeepCopy(), webhook.DeepCopy()),
+		},
+		args: args{req: req},
+		prepare: func() {
+			gock.New("https://api.github.com").
+				Get("/repos/linuxsuren/test/hooks").
+				Reply(200).
+				Type("application/json").
+				SetHeaders(mockHeaders).
+				BodyString(`[{"id": 1, "name": "web", "events": ["push"], "active": true,
+"config": {"url": "http://example.com", "content_type": "json", "insecure_ssl": "1"}}]`)
+
+			// GitHub does not support to update the hook, it will be recreated
+			gock.New("https://api.github.com").
+				Delete("/repos/linuxsuren/test/hooks/1").
+				Reply(204)
+
+			gock.New("https://api.github.com").
+				Post("/repos/linuxsuren/test/hooks").
+				Reply(201).
+				Type("application/json").
+				SetHeaders(mockHeaders).
+				BodyString(`{"id": 2, "name": "web", "events": ["push", "pull_request"], "active": true,
+"config": {"url": "http://example.com", "content_type": "json", "insecure_ssl": "1"}}`)
+		},
+		wantErr: func(t assert.TestingT, err error, i ...interface{}) bool {
+			assert.Nil(t, err)
+			return true
+		},
+		wantResult: controllerruntime.Result{RequeueAfter: webhookResyncPeriod},
+		verify: func(t *testing.T, c client.Client) {
+			assert.True(t, gock.IsDone())
+			repo := &v1alpha3.GitRepository{}
+			assert.Nil(t, c.Get(context.Background(), req.NamespacedName, repo))
+			if assert.Equal(t, 1, len(repo.Status.Webhooks)) {
+				assert.Equal(t, "2", repo.Status.Webhooks[0].ID)
+			}
+		},
+	}, {
+		name: "the hook is in sync",
+		fields: fields{
+			Client: fake.NewFakeClientWithScheme(schema, registeredRepo.DeepCopy(), secret.DeepCopy(), webhook.DeepCopy()),
+		},
+		args: args{req: req},
+		prepare: func() {
+			gock.New("https://api.github.com").
+				Get("/repos/linuxsuren/test/hooks").
+				Reply(200).
+				Type("application/json").
+				SetHeaders(mockHeaders).
+				BodyString(`[{"id": 1, "name": "web", "events": ["pull_request", "push"], "active": true,
+"config": {"url": "http://example.com", "content_type": "json", "insecure_ssl": "1"}}]`)
+		},
+		wantErr: func(t assert.TestingT, err error, i ...interface{}) bool {
+			assert.Nil(t, err)
+			return true
+		},
+		wantResult: controllerruntime.Result{RequeueAfter: webhookResyncPeriod},
+		verify: func(t *testing.T, c client.Client) {
+			assert.True(t, gock.IsDone())
+		},
+	}, {
+		name: "the webhook is not referenced anymore",
+		fields: fields{
+			Client: fake.NewFakeClientWithScheme(schema, unreferencedRepo.DeepCopy(), secret.DeepCopy(), webhook.DeepCopy()),
+		},
+		args: args{req: req},
+		prepare: func() {
+			gock.New("https://api.github.com").
+				Get("/repos/linuxsuren/test/hooks").
+				Reply(200).
+				Type("application/json").
+				SetHeaders(mockHeaders).
+				BodyString(`[{"id": 1, "name": "web", "events": ["pull_request", "push"], "active": true,
+"config": {"url": "http://example.com", "content_type": "json", "insecure_ssl": "1"}}]`)
+
+			gock.New("https://api.github.com").
+				Delete("/repos/linuxsuren/test/hooks/1").
+				Reply(204)
+		},
+		wantErr: func(t assert.TestingT, err error, i ...interface{}) bool {
+			assert.Nil(t, err)
+			return true
+		},
+		verify: func(t *testing.T, c client.Client) {
+			assert.True(t, gock.IsDone())
+			repo := &v1alpha3.GitRepository{}
+			assert.Nil(t, c.Get(context.Background(), req.NamespacedName, repo))
+			assert.Empty(t, repo.Status.Webhooks)
+			assert.NotContains(t, repo.Finalizers, v1alpha3.GitRepoWebhookFinalizerName)
+		},
+	}, {
+		name: "remove the hooks when deleting the git repository",
+		fields: fields{
+			Client: fake.NewFakeClientWithScheme(schema, deletingRepo.DeepCopy(), secret.DeepCopy(), webhook.DeepCopy()),
+		},
+		args: args{req: req},
+		prepare: func() {
+			gock.New("https://api.github.com").
+				Get("/repos/linuxsuren/test/hooks").
+				Reply(200).
+				Type("application/json").
+				SetHeaders(mockHeaders).
+				BodyString(`[{"id": 1, "name": "web", "events": ["pull_request", "push"], "active": true,
+"config": {"url": "http://example.com", "content_type": "json", "insecure_ssl": "1"}}]`)
+
+			gock.New("https://api.github.com").
+				Delete("/repos/linuxsuren/test/hooks/1").
+				Reply(204)
+		},
+		wantErr: func(t assert.TestingT, err error, i ...interface{}) bool {
+			assert.Nil(t, err)
+			return true
+		},
+		verify: func(t *testing.T, c client.Client) {
+			assert.True(t, gock.IsDone())
+			repo := &v1alpha3.GitRepository{}
+			assert.Nil(t, c.Get(context.Background(), req.NamespacedName, repo))
+			assert.NotContains(t, repo.Finalizers, v1alpha3.GitRepoWebhookFinalizerName)
+		},
+	}, {
+		name: "failed to remove the hooks when deleting the git repository",
+		fields: fields{
+			Client: fake.NewFakeClientWithScheme(schema, deletingRepo.DeepCopy(), secret.DeepCopy(), webhook.DeepCopy()),
+		},
+		args: args{req: req},
+		prepare: func() {
+			gock.New("https://api.github.com").
+				Get("/repos/linuxsuren/test/hooks").
+				Reply(500).
+				Type("application/json").
+				SetHeaders(mockHeaders).
+				BodyString(`{"message": "Server Error"}`)
+		},
+		wantErr: func(t assert.TestingT, err error, i ...interface{}) bool {
+			assert.NotNil(t, err)
+			return true
+		},
+		verify: func(t *testing.T, c client.Client) {
+			repo := &v1alpha3.GitRepository{}
+			assert.Nil(t, c.Get(context.Background(), req.NamespacedName, repo))
+			assert.Contains(t, repo.Finalizers, v1alpha3.GitRepoWebhookFinalizerName)
+		},
 	}}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -888,6 +1071,9 @@ func TestReconciler_Reconcile(t *testing.T) {
 				return
 			}
 			assert.Equalf(t, tt.wantResult, gotResult, "Reconcile(%v)", tt.args.req)
+			if tt.verify != nil {
+				tt.verify(t, tt.fields.Client)
+			}
 		})
 	}
 }
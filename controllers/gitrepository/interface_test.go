@@ -50,6 +50,12 @@ func TestInterfaceImplement(t *testing.T) {
 			NamedReconciler: &WebhookReconciler{},
 			GroupReconciler: &WebhookReconciler{},
 		},
+	}, {
+		name: "ProbeReconciler",
+		instance: interInstance{
+			NamedReconciler: &ProbeReconciler{},
+			GroupReconciler: &ProbeReconciler{},
+		},
 	}, {
 		name: "PullRequestStatusReconciler",
 		instance: interInstance{
@@ -0,0 +1,122 @@
+/*
+Copyright 2022 The KubeSphere Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gitrepository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/go-logr/logr"
+	"golang.org/x/oauth2"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/tools/record"
+	"kubesphere.io/devops/pkg/api/devops/v1alpha3"
+	"kubesphere.io/devops/pkg/client/git"
+	"kubesphere.io/devops/pkg/config"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+)
+
+// defaultOAuthTokenRefreshBefore is how long before the expiry an OAuth token is refreshed
+const defaultOAuthTokenRefreshBefore = 5 * time.Minute
+
+// OAuthTokenReconciler refreshes the access tokens of the credentials which were issued by the SCM OAuth flow
+// before they expire, so all the git clients which take the credentials get valid tokens
+type OAuthTokenReconciler struct {
+	client.Client
+	OAuthOption *config.SCMOAuthOption
+	// RefreshBefore is how long before the expiry an OAuth token is refreshed
+	RefreshBefore time.Duration
+
+	log      logr.Logger
+	recorder record.EventRecorder
+}
+
+//+kubebuilder:rbac:groups="",resources=secrets,verbs=get;list;watch;update
+
+// Reconcile refreshes the OAuth token of the secret if it is going to expire
+func (r *OAuthTokenReconciler) Reconcile(ctx context.Context, req ctrl.Request) (result ctrl.Result, err error) {
+	secret := &v1.Secret{}
+	if err = r.Get(ctx, req.NamespacedName, secret); err != nil {
+		err = client.IgnoreNotFound(err)
+		return
+	}
+	if !secret.DeletionTimestamp.IsZero() {
+		return
+	}
+
+	var oauthToken *oauth2.Token
+	var provider, server string
+	if oauthToken, provider, server, err = git.GetOAuthToken(secret); err != nil || oauthToken == nil ||
+		oauthToken.Expiry.IsZero() {
+		// the token cannot be refreshed or never expires
+		return
+	}
+
+	refreshBefore := getDurationOrDefault(r.RefreshBefore, defaultOAuthTokenRefreshBefore)
+	if delay := time.Until(oauthToken.Expiry) - refreshBefore; delay > 0 {
+		result.RequeueAfter = delay
+		return
+	}
+
+	app := r.OAuthOption.GetServer(provider, server)
+	if app == nil {
+		r.log.V(6).Info(fmt.Sprintf("no OAuth application of git provider %q with server %q to refresh %s",
+			provider, server, req.NamespacedName))
+		return
+	}
+	var oauthConfig *oauth2.Config
+	if oauthConfig, err = app.GetOAuth2Config(); err != nil {
+		return
+	}
+
+	// the token source only refreshes expired tokens
+	oauthToken.Expiry = time.Now()
+	var newToken *oauth2.Token
+	if newToken, err = oauthConfig.TokenSource(ctx, oauthToken).Token(); err != nil {
+		r.recorder.Eventf(secret, v1.EventTypeWarning, "RefreshFailed", "cannot refresh the OAuth token: %v", err)
+		err = fmt.Errorf("cannot refresh the access token of secret %s, error is: %v", req.NamespacedName, err)
+		return
+	}
+	git.SetOAuthToken(secret, provider, server, newToken)
+	if err = r.Update(ctx, secret); err == nil && !newToken.Expiry.IsZero() {
+		result.RequeueAfter = time.Until(newToken.Expiry) - refreshBefore
+	}
+	return
+}
+
+// GetName returns the name of this reconciler
+func (r *OAuthTokenReconciler) GetName() string {
+	return "scm-oauth-token"
+}
+
+// GetGroupName returns the group name of the set of reconcilers
+func (r *OAuthTokenReconciler) GetGroupName() string {
+	return groupName
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *OAuthTokenReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	r.recorder = mgr.GetEventRecorderFor(r.GetName())
+	r.log = ctrl.Log.WithName(r.GetName())
+	return ctrl.NewControllerManagedBy(mgr).
+		Named(r.GetName()).
+		For(&v1.Secret{}).
+		WithEventFilter(predicate.NewPredicateFuncs(func(object client.Object) bool {
+			return object.GetAnnotations()[v1alpha3.CredentialSCMOAuthProviderAnnoKey] != ""
+		})).
+		Complete(r)
+}
@@ -0,0 +1,153 @@
+/*
+Copyright 2022 The KubeSphere Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gitrepository
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"kubesphere.io/devops/pkg/api/devops/v1alpha3"
+	"kubesphere.io/devops/pkg/config"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+func TestOAuthTokenReconciler_Reconcile(t *testing.T) {
+	schema, err := v1alpha3.SchemeBuilder.Register().Build()
+	assert.Nil(t, err)
+	err = v1.SchemeBuilder.AddToScheme(schema)
+	assert.Nil(t, err)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Nil(t, r.ParseForm())
+		w.Header().Set("Content-Type", "application/json")
+		if r.PostForm.Get("grant_type") != "refresh_token" || r.PostForm.Get("refresh_token") != "refresh" {
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`{"error": "invalid_grant"}`))
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"access_token":  "new-access",
+			"refresh_token": "refresh",
+			"token_type":    "bearer",
+			"expires_in":    3600,
+		})
+	}))
+	defer server.Close()
+
+	newSecret := func(expiry time.Time, refreshToken string) *v1.Secret {
+		return &v1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: "ns",
+				Name:      "gitea",
+				Annotations: map[string]string{
+					v1alpha3.CredentialSCMOAuthProviderAnnoKey: "gitea",
+					v1alpha3.CredentialSCMOAuthServerAnnoKey:   server.URL,
+				},
+			},
+			Type: v1alpha3.SecretTypeBasicAuth,
+			Data: map[string][]byte{
+				v1alpha3.BasicAuthPasswordKey: []byte("access"),
+				v1alpha3.OAuthRefreshTokenKey: []byte(refreshToken),
+				v1alpha3.OAuthExpiryKey:       []byte(expiry.Format(time.RFC3339)),
+			},
+		}
+	}
+	oauthOption := &config.SCMOAuthOption{Servers: []config.SCMOAuthServer{{
+		Provider:     "gitea",
+		Server:       server.URL,
+		ClientID:     "id",
+		ClientSecret: "secret",
+	}}}
+
+	tests := []struct {
+		name        string
+		secret      *v1.Secret
+		oauthOption *config.SCMOAuthOption
+		wantToken   string
+		wantRequeue bool
+		wantErr     bool
+	}{{
+		name:        "expired token",
+		secret:      newSecret(time.Now().Add(-time.Hour), "refresh"),
+		oauthOption: oauthOption,
+		wantToken:   "new-access",
+		wantRequeue: true,
+	}, {
+		name:        "token which is going to expire",
+		secret:      newSecret(time.Now().Add(time.Minute), "refresh"),
+		oauthOption: oauthOption,
+		wantToken:   "new-access",
+		wantRequeue: true,
+	}, {
+		name:        "valid token",
+		secret:      newSecret(time.Now().Add(time.Hour), "refresh"),
+		oauthOption: oauthOption,
+		wantToken:   "access",
+		wantRequeue: true,
+	}, {
+		name:      "expired token without the OAuth application",
+		secret:    newSecret(time.Now().Add(-time.Hour), "refresh"),
+		wantToken: "access",
+	}, {
+		name:        "invalid refresh token",
+		secret:      newSecret(time.Now().Add(-time.Hour), "invalid"),
+		oauthOption: oauthOption,
+		wantToken:   "access",
+		wantErr:     true,
+	}, {
+		name: "not from the OAuth flow",
+		secret: &v1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "gitea"},
+			Type:       v1alpha3.SecretTypeBasicAuth,
+			Data:       map[string][]byte{v1alpha3.BasicAuthPasswordKey: []byte("access")},
+		},
+		oauthOption: oauthOption,
+		wantToken:   "access",
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := &OAuthTokenReconciler{
+				Client:      fake.NewClientBuilder().WithScheme(schema).WithObjects(tt.secret).Build(),
+				OAuthOption: tt.oauthOption,
+				log:         logr.New(log.NullLogSink{}),
+				recorder:    &record.FakeRecorder{},
+			}
+			key := types.NamespacedName{Namespace: "ns", Name: "gitea"}
+			result, err := r.Reconcile(context.TODO(), ctrl.Request{NamespacedName: key})
+			assert.Equal(t, tt.wantErr, err != nil, err)
+			assert.Equal(t, tt.wantRequeue, result.RequeueAfter > 0)
+
+			secret := &v1.Secret{}
+			assert.Nil(t, r.Get(context.TODO(), key, secret))
+			assert.Equal(t, tt.wantToken, string(secret.Data[v1alpha3.BasicAuthPasswordKey]))
+		})
+	}
+
+	// the secret was removed
+	r := &OAuthTokenReconciler{Client: fake.NewClientBuilder().WithScheme(schema).Build()}
+	_, err = r.Reconcile(context.TODO(), ctrl.Request{NamespacedName: types.NamespacedName{Namespace: "ns", Name: "fake"}})
+	assert.Nil(t, err)
+}
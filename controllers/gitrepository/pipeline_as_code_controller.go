@@ -0,0 +1,252 @@
+/*
+Copyright 2022 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gitrepository
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/jenkins-x/go-scm/scm"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/client-go/util/retry"
+	"kubesphere.io/devops/pkg/api/devops/v1alpha3"
+	"kubesphere.io/devops/pkg/client/git"
+	"kubesphere.io/devops/pkg/constants"
+	modelpipeline "kubesphere.io/devops/pkg/models/pipeline"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+)
+
+const (
+	// defaultPipelineAsCodeInterval is the interval between two synchronizations without any SCM events
+	defaultPipelineAsCodeInterval = 10 * time.Minute
+
+	// PipelineAsCodeSynced indicates the Pipeline spec has been synchronized from the git repository
+	PipelineAsCodeSynced = "PipelineAsCodeSynced"
+	// FailedPipelineAsCodeSync indicates the controller fails to synchronize the Pipeline spec from the git repository
+	FailedPipelineAsCodeSync = "FailedPipelineAsCodeSync"
+)
+
+//+kubebuilder:rbac:groups=devops.kubesphere.io,resources=pipelines,verbs=get;list;watch;update
+//+kubebuilder:rbac:groups=devops.kubesphere.io,resources=gitrepositories,verbs=get
+//+kubebuilder:rbac:groups="",resources=secrets,verbs=get
+//+kubebuilder:rbac:groups="",resources=events,verbs=create;patch
+
+// PipelineAsCodeReconciler keeps the spec of Pipelines reconciled to the pipeline-as-code file of the git repositories
+type PipelineAsCodeReconciler struct {
+	client.Client
+	// Interval is the interval between two synchronizations without any SCM events
+	Interval time.Duration
+
+	log      logr.Logger
+	recorder record.EventRecorder
+}
+
+// Reconcile reads the pipeline-as-code file and updates the Pipeline spec if there are any differences
+func (r *PipelineAsCodeReconciler) Reconcile(ctx context.Context, req ctrl.Request) (result ctrl.Result, err error) {
+	log := r.log.WithValues("Pipeline", req.NamespacedName)
+	pipeline := &v1alpha3.Pipeline{}
+	if err = r.Get(ctx, req.NamespacedName, pipeline); err != nil {
+		err = client.IgnoreNotFound(err)
+		return
+	}
+	if !pipeline.IsPipelineAsCode() || !pipeline.DeletionTimestamp.IsZero() {
+		return
+	}
+
+	source, revision, spec, syncErr := r.readPipelineSpec(ctx, pipeline)
+	if syncErr != nil {
+		log.Error(syncErr, "failed to read the pipeline-as-code file")
+		r.recorder.Eventf(pipeline, v1.EventTypeWarning, FailedPipelineAsCodeSync,
+			"Failed to synchronize the Pipeline from the git repository, err = %v", syncErr)
+	}
+
+	var specChanged bool
+	if specChanged, err = r.updatePipeline(ctx, req.NamespacedName, revision, spec, syncErr); err != nil {
+		return
+	}
+	if specChanged {
+		log.Info("synchronized the Pipeline from the git repository", "source", source, "revision", revision)
+		r.recorder.Eventf(pipeline, v1.EventTypeNormal, PipelineAsCodeSynced,
+			"Synchronized the Pipeline from %s at %s", source, revision)
+	}
+	result.RequeueAfter = getDurationOrDefault(r.Interval, defaultPipelineAsCodeInterval)
+	return
+}
+
+// readPipelineSpec reads the pipeline-as-code file and the Jenkinsfile at the latest commit of the branch,
+// then returns the desired Pipeline spec
+func (r *PipelineAsCodeReconciler) readPipelineSpec(ctx context.Context, pipeline *v1alpha3.Pipeline) (
+	source, revision string, spec *v1alpha3.PipelineSpec, err error) {
+	repo := &v1alpha3.GitRepository{}
+	if err = r.Get(ctx, types.NamespacedName{
+		Namespace: pipeline.Namespace,
+		Name:      pipeline.Annotations[v1alpha3.PipelineAsCodeGitRepoAnnoKey],
+	}, repo); err != nil {
+		return
+	}
+
+	repoPath := git.GetRepoPath(repo)
+	if repoPath == "" {
+		err = fmt.Errorf("the owner and name of the git repository %s are unknown", repo.Name)
+		return
+	}
+	factory := getClientFactory(repo, r.Client)
+	if sshAuth, sshErr := factory.GetSSHAuth(); sshErr == nil && sshAuth != nil {
+		err = fmt.Errorf("the SSH credentials of the git repository %s are not supported", repo.Name)
+		return
+	}
+	var gitClient *scm.Client
+	if gitClient, err = factory.GetClientWithContext(ctx); err != nil {
+		return
+	}
+
+	branch := pipeline.GetPipelineAsCodeBranch(repo.Status.DefaultBranch)
+	path := pipeline.GetPipelineAsCodePath()
+	source = fmt.Sprintf("%s/%s@%s", repoPath, path, branch)
+
+	var ref *scm.Reference
+	if ref, _, err = gitClient.Git.FindBranch(ctx, repoPath, branch); err != nil {
+		err = fmt.Errorf("failed to find the branch %s of %s: %v", branch, repoPath, err)
+		return
+	}
+	revision = ref.Sha
+
+	var content *scm.Content
+	if content, _, err = gitClient.Contents.Find(ctx, repoPath, path, revision); err != nil {
+		err = fmt.Errorf("failed to read %s: %v", source, err)
+		return
+	}
+	var asCode *modelpipeline.AsCode
+	if asCode, err = modelpipeline.ParseAsCode(content.Data); err != nil {
+		return
+	}
+
+	var jenkinsfile string
+	if !pipeline.IsMultiBranch() {
+		jenkinsfilePath := asCode.GetJenkinsfilePath()
+		if content, _, err = gitClient.Contents.Find(ctx, repoPath, jenkinsfilePath, revision); err != nil {
+			err = fmt.Errorf("failed to read %s/%s@%s: %v", repoPath, jenkinsfilePath, branch, err)
+			return
+		}
+		jenkinsfile = string(content.Data)
+	}
+
+	spec = pipeline.Spec.DeepCopy()
+	err = asCode.Apply(pipeline.Name, spec, jenkinsfile)
+	return
+}
+
+// updatePipeline updates the Pipeline spec and the synchronization status, returns true if the spec changed
+func (r *PipelineAsCodeReconciler) updatePipeline(ctx context.Context, key types.NamespacedName, revision string,
+	spec *v1alpha3.PipelineSpec, syncErr error) (specChanged bool, err error) {
+	err = retry.RetryOnConflict(retry.DefaultRetry, func() (err error) {
+		pipeline := &v1alpha3.Pipeline{}
+		if err = r.Get(ctx, key, pipeline); err != nil {
+			return client.IgnoreNotFound(err)
+		}
+
+		annotations := map[string]string{}
+		for k, v := range pipeline.Annotations {
+			annotations[k] = v
+		}
+		if syncErr != nil {
+			annotations[v1alpha3.PipelineAsCodeSyncStatusAnnoKey] = constants.StatusFailed
+			annotations[v1alpha3.PipelineAsCodeSyncMsgAnnoKey] = syncErr.Error()
+		} else {
+			annotations[v1alpha3.PipelineAsCodeSyncStatusAnnoKey] = constants.StatusSuccessful
+			annotations[v1alpha3.PipelineAsCodeRevisionAnnoKey] = revision
+			delete(annotations, v1alpha3.PipelineAsCodeSyncMsgAnnoKey)
+
+			if specChanged = !reflect.DeepEqual(pipeline.Spec, *spec); specChanged {
+				if !pipeline.IsMultiBranch() && !reflect.DeepEqual(pipeline.Spec.Pipeline, spec.Pipeline) &&
+					(pipeline.Spec.Pipeline == nil || pipeline.Spec.Pipeline.Jenkinsfile != spec.Pipeline.Jenkinsfile) {
+					// convert the Jenkinsfile into JSON for the graphical editor
+					annotations[v1alpha3.PipelineJenkinsfileEditModeAnnoKey] = v1alpha3.PipelineJenkinsfileEditModeRaw
+				}
+				pipeline.Spec = *spec
+			}
+		}
+
+		if !specChanged && reflect.DeepEqual(pipeline.Annotations, annotations) {
+			return
+		}
+		pipeline.Annotations = annotations
+		return r.Update(ctx, pipeline)
+	})
+	return
+}
+
+// GetName returns the name of this reconciler
+func (r *PipelineAsCodeReconciler) GetName() string {
+	return "pipeline-as-code"
+}
+
+// GetGroupName returns the group name of the set of reconcilers
+func (r *PipelineAsCodeReconciler) GetGroupName() string {
+	return groupName
+}
+
+// pipelineAsCodePredicate cares about the changes of the spec, the pipeline-as-code settings and the sync requests.
+// The changes of the spec are reverted if they are different from the pipeline-as-code file.
+var pipelineAsCodePredicate = predicate.Funcs{
+	CreateFunc: func(ce event.CreateEvent) bool {
+		pipeline, ok := ce.Object.(*v1alpha3.Pipeline)
+		return ok && pipeline.IsPipelineAsCode()
+	},
+	UpdateFunc: func(ue event.UpdateEvent) bool {
+		oldPipeline, okOld := ue.ObjectOld.(*v1alpha3.Pipeline)
+		newPipeline, okNew := ue.ObjectNew.(*v1alpha3.Pipeline)
+		if !okOld || !okNew || !newPipeline.IsPipelineAsCode() {
+			return false
+		}
+		if !reflect.DeepEqual(oldPipeline.Spec, newPipeline.Spec) {
+			return true
+		}
+		for _, key := range []string{v1alpha3.PipelineAsCodeGitRepoAnnoKey, v1alpha3.PipelineAsCodeBranchAnnoKey,
+			v1alpha3.PipelineAsCodePathAnnoKey, v1alpha3.PipelineAsCodeRequestSyncAnnoKey} {
+			if oldPipeline.Annotations[key] != newPipeline.Annotations[key] {
+				return true
+			}
+		}
+		return false
+	},
+	DeleteFunc: func(de event.DeleteEvent) bool {
+		return false
+	},
+	GenericFunc: func(ge event.GenericEvent) bool {
+		return false
+	},
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *PipelineAsCodeReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	r.recorder = mgr.GetEventRecorderFor(r.GetName())
+	r.log = ctrl.Log.WithName(r.GetName())
+	return ctrl.NewControllerManagedBy(mgr).
+		Named(r.GetName()).
+		For(&v1alpha3.Pipeline{}).
+		WithEventFilter(pipelineAsCodePredicate).
+		Complete(r)
+}
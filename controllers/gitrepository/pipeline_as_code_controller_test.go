@@ -0,0 +1,220 @@
+/*
+Copyright 2022 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gitrepository
+
+import (
+	"context"
+	"encoding/base64"
+	"testing"
+
+	"github.com/go-logr/logr"
+	"github.com/h2non/gock"
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"kubesphere.io/devops/pkg/api/devops/v1alpha3"
+	"kubesphere.io/devops/pkg/constants"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+func TestPipelineAsCodeReconciler_Reconcile(t *testing.T) {
+	schema, err := v1alpha3.SchemeBuilder.Register().Build()
+	assert.Nil(t, err)
+	err = v1.SchemeBuilder.AddToScheme(schema)
+	assert.Nil(t, err)
+
+	const revision = "7fd1a60b01f91b314f59955a4e4d4e80d8edf11d"
+	repo := &v1alpha3.GitRepository{}
+	repo.SetName("repo")
+	repo.SetNamespace("ns")
+	repo.Spec = v1alpha3.GitRepositorySpec{
+		Provider: "github",
+		Owner:    "octocat",
+		Repo:     "hello-world",
+	}
+
+	pip := &v1alpha3.Pipeline{}
+	pip.SetName("pipeline")
+	pip.SetNamespace("ns")
+	pip.Annotations = map[string]string{
+		v1alpha3.PipelineAsCodeGitRepoAnnoKey: "repo",
+	}
+	pip.Spec = v1alpha3.PipelineSpec{
+		Type:     v1alpha3.NoScmPipelineType,
+		Pipeline: &v1alpha3.NoScmPipeline{Name: "pipeline", Description: "console", Jenkinsfile: "pipeline {}"},
+	}
+
+	multiBranchPip := pip.DeepCopy()
+	multiBranchPip.Annotations[v1alpha3.PipelineAsCodeBranchAnnoKey] = "master"
+	multiBranchPip.Spec = v1alpha3.PipelineSpec{
+		Type: v1alpha3.MultiBranchPipelineType,
+		MultiBranchPipeline: &v1alpha3.MultiBranchPipeline{
+			Name: "pipeline", SourceType: v1alpha3.SourceTypeGithub, ScriptPath: "Jenkinsfile",
+		},
+	}
+
+	notPipelineAsCode := pip.DeepCopy()
+	notPipelineAsCode.Annotations = nil
+
+	mockContent := func(path, content string) {
+		gock.New("https://api.github.com").
+			Get("/repos/octocat/hello-world/contents/"+path).
+			MatchParam("ref", revision).
+			Reply(200).
+			Type("application/json").
+			SetHeaders(mockHeaders).
+			JSON(map[string]string{
+				"path":    path,
+				"content": base64.StdEncoding.EncodeToString([]byte(content)),
+			})
+	}
+	mockBranch := func() {
+		gock.New("https://api.github.com").
+			Get("/repos/octocat/hello-world/branches/master").
+			Reply(200).
+			Type("application/json").
+			SetHeaders(mockHeaders).
+			File("testdata/branch.json")
+	}
+
+	tests := []struct {
+		name     string
+		objects  []client.Object
+		prepare  func()
+		verify   func(t *testing.T, pipeline *v1alpha3.Pipeline)
+		notFound bool
+	}{{
+		name:     "not found",
+		notFound: true,
+	}, {
+		name:    "not a pipeline-as-code Pipeline",
+		objects: []client.Object{notPipelineAsCode.DeepCopy(), repo.DeepCopy()},
+		verify: func(t *testing.T, pipeline *v1alpha3.Pipeline) {
+			assert.Equal(t, notPipelineAsCode.Spec, pipeline.Spec)
+			assert.Empty(t, pipeline.Annotations)
+		},
+	}, {
+		name:    "git repository not found",
+		objects: []client.Object{pip.DeepCopy()},
+		verify: func(t *testing.T, pipeline *v1alpha3.Pipeline) {
+			assert.Equal(t, pip.Spec, pipeline.Spec)
+			assert.Equal(t, constants.StatusFailed, pipeline.Annotations[v1alpha3.PipelineAsCodeSyncStatusAnnoKey])
+			assert.NotEmpty(t, pipeline.Annotations[v1alpha3.PipelineAsCodeSyncMsgAnnoKey])
+		},
+	}, {
+		name:    "Pipeline without SCM",
+		objects: []client.Object{pip.DeepCopy(), repo.DeepCopy()},
+		prepare: func() {
+			mockBranch()
+			mockContent(".ks-devops/pipeline.yaml", "description: from git\nagent: go\njenkinsfile: ci/Jenkinsfile\n")
+			mockContent("ci/Jenkinsfile", "pipeline {\n  agent any\n}")
+		},
+		verify: func(t *testing.T, pipeline *v1alpha3.Pipeline) {
+			assert.Equal(t, &v1alpha3.NoScmPipeline{
+				Name:        "pipeline",
+				Description: "from git",
+				Jenkinsfile: "pipeline {\n  agent { node { label 'go' } }\n}",
+			}, pipeline.Spec.Pipeline)
+			assert.Equal(t, constants.StatusSuccessful, pipeline.Annotations[v1alpha3.PipelineAsCodeSyncStatusAnnoKey])
+			assert.Equal(t, revision, pipeline.Annotations[v1alpha3.PipelineAsCodeRevisionAnnoKey])
+			assert.Equal(t, v1alpha3.PipelineJenkinsfileEditModeRaw, pipeline.Annotations[v1alpha3.PipelineJenkinsfileEditModeAnnoKey])
+		},
+	}, {
+		name:    "multi-branch Pipeline",
+		objects: []client.Object{multiBranchPip.DeepCopy(), repo.DeepCopy()},
+		prepare: func() {
+			mockBranch()
+			mockContent(".ks-devops/pipeline.yaml", "jenkinsfile: ci/Jenkinsfile\n")
+		},
+		verify: func(t *testing.T, pipeline *v1alpha3.Pipeline) {
+			assert.Equal(t, "ci/Jenkinsfile", pipeline.Spec.MultiBranchPipeline.ScriptPath)
+			assert.Equal(t, v1alpha3.SourceTypeGithub, pipeline.Spec.MultiBranchPipeline.SourceType)
+			assert.Equal(t, constants.StatusSuccessful, pipeline.Annotations[v1alpha3.PipelineAsCodeSyncStatusAnnoKey])
+			assert.Empty(t, pipeline.Annotations[v1alpha3.PipelineJenkinsfileEditModeAnnoKey])
+		},
+	}, {
+		name:    "invalid pipeline-as-code file",
+		objects: []client.Object{pip.DeepCopy(), repo.DeepCopy()},
+		prepare: func() {
+			mockBranch()
+			mockContent(".ks-devops/pipeline.yaml", "unknown: field\n")
+		},
+		verify: func(t *testing.T, pipeline *v1alpha3.Pipeline) {
+			assert.Equal(t, pip.Spec, pipeline.Spec)
+			assert.Equal(t, constants.StatusFailed, pipeline.Annotations[v1alpha3.PipelineAsCodeSyncStatusAnnoKey])
+			assert.Contains(t, pipeline.Annotations[v1alpha3.PipelineAsCodeSyncMsgAnnoKey], "unknown")
+		},
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer gock.Off()
+			if tt.prepare != nil {
+				tt.prepare()
+			}
+
+			k8sClient := fake.NewClientBuilder().WithScheme(schema).WithObjects(tt.objects...).Build()
+			r := &PipelineAsCodeReconciler{
+				Client:   k8sClient,
+				log:      logr.New(log.NullLogSink{}),
+				recorder: &record.FakeRecorder{},
+			}
+			key := types.NamespacedName{Namespace: "ns", Name: "pipeline"}
+			_, err := r.Reconcile(context.Background(), ctrl.Request{NamespacedName: key})
+			assert.Nil(t, err)
+
+			pipeline := &v1alpha3.Pipeline{}
+			err = k8sClient.Get(context.Background(), key, pipeline)
+			if tt.notFound {
+				assert.NotNil(t, err)
+				return
+			}
+			assert.Nil(t, err)
+			tt.verify(t, pipeline)
+		})
+	}
+}
+
+func Test_pipelineAsCodePredicate(t *testing.T) {
+	pip := &v1alpha3.Pipeline{}
+	assert.False(t, pipelineAsCodePredicate.Create(event.CreateEvent{Object: pip}))
+	assert.False(t, pipelineAsCodePredicate.Update(event.UpdateEvent{ObjectOld: pip, ObjectNew: pip}))
+
+	oldPipeline := pip.DeepCopy()
+	oldPipeline.Annotations = map[string]string{v1alpha3.PipelineAsCodeGitRepoAnnoKey: "repo"}
+	assert.True(t, pipelineAsCodePredicate.Create(event.CreateEvent{Object: oldPipeline}))
+	assert.False(t, pipelineAsCodePredicate.Delete(event.DeleteEvent{Object: oldPipeline}))
+
+	newPipeline := oldPipeline.DeepCopy()
+	newPipeline.Annotations[v1alpha3.PipelineAsCodeRevisionAnnoKey] = "sha"
+	assert.False(t, pipelineAsCodePredicate.Update(event.UpdateEvent{ObjectOld: oldPipeline, ObjectNew: newPipeline}))
+
+	for _, key := range []string{v1alpha3.PipelineAsCodeBranchAnnoKey, v1alpha3.PipelineAsCodePathAnnoKey,
+		v1alpha3.PipelineAsCodeRequestSyncAnnoKey} {
+		newPipeline := oldPipeline.DeepCopy()
+		newPipeline.Annotations[key] = "changed"
+		assert.True(t, pipelineAsCodePredicate.Update(event.UpdateEvent{ObjectOld: oldPipeline, ObjectNew: newPipeline}), key)
+	}
+
+	newPipeline = oldPipeline.DeepCopy()
+	newPipeline.Spec.Type = v1alpha3.NoScmPipelineType
+	assert.True(t, pipelineAsCodePredicate.Update(event.UpdateEvent{ObjectOld: oldPipeline, ObjectNew: newPipeline}))
+}
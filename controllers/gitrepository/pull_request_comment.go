@@ -0,0 +1,198 @@
+/*
+Copyright 2022 The KubeSphere Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gitrepository
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jenkins-x/go-scm/scm"
+	"k8s.io/apimachinery/pkg/types"
+	"kubesphere.io/devops/pkg/api/devops/v1alpha3"
+	"kubesphere.io/devops/pkg/indexers"
+	"kubesphere.io/devops/pkg/models/pipelinerun"
+)
+
+// maxCommentPages is the max pages of the comments which are searched for the existing summary comment
+const maxCommentPages = 10
+
+// isSummaryCommentEnabled checks if the Pipeline of the PipelineRun wants a summary comment in the pull requests.
+// The annotation of the Pipeline takes precedence over the one of the GitRepository which has the same address.
+func (r *PullRequestStatusReconciler) isSummaryCommentEnabled(ctx context.Context, pipelineRun *v1alpha3.PipelineRun) bool {
+	if pipelineRun.Spec.PipelineRef == nil {
+		return false
+	}
+	pipeline := &v1alpha3.Pipeline{}
+	if err := r.Get(ctx, types.NamespacedName{
+		Namespace: pipelineRun.Namespace,
+		Name:      pipelineRun.Spec.PipelineRef.Name,
+	}, pipeline); err != nil {
+		return false
+	}
+	if enabled, ok := pipeline.GetAnnotations()[v1alpha3.PipelinePRCommentAnnoKey]; ok {
+		return enabled == "true"
+	}
+
+	if repo := r.findGitRepository(ctx, pipelineRun.Namespace, indexers.GetPipelineRepoURL(pipeline)); repo != nil {
+		return repo.GetAnnotations()[v1alpha3.AnnotationKeyPRComment] == "true"
+	}
+	return false
+}
+
+// isLatestPipelineRun checks if there is no newer PipelineRun of the same Pipeline and SCM reference,
+// a summary comment of a newer PipelineRun should not be overridden by an older one
+func (r *PullRequestStatusReconciler) isLatestPipelineRun(ctx context.Context, pipelineRun *v1alpha3.PipelineRun) bool {
+	newerPipelineRuns, err := listNewerPipelineRuns(ctx, r.Client, pipelineRun)
+	return err != nil || len(newerPipelineRuns) == 0
+}
+
+// getSummaryCommentMarker returns the hidden marker which identifies the summary comment of a Pipeline
+func getSummaryCommentMarker(pipelineRun *v1alpha3.PipelineRun) string {
+	var pipelineName string
+	if pipelineRun.Spec.PipelineRef != nil {
+		pipelineName = pipelineRun.Spec.PipelineRef.Name
+	}
+	return fmt.Sprintf("<!-- ks-devops-summary: %s/%s -->", pipelineRun.Namespace, pipelineName)
+}
+
+// renderSummaryComment renders the summary comment of a PipelineRun in Markdown. It contains the phase,
+// a table of the stages with their durations, the failed steps and a link to the PipelineRun.
+func renderSummaryComment(pipelineRun *v1alpha3.PipelineRun, stages []pipelinerun.NodeDetail, target string) string {
+	var pipelineName string
+	if pipelineRun.Spec.PipelineRef != nil {
+		pipelineName = pipelineRun.Spec.PipelineRef.Name
+	}
+
+	buf := &strings.Builder{}
+	buf.WriteString(getSummaryCommentMarker(pipelineRun) + "\n")
+	buf.WriteString(fmt.Sprintf("### KubeSphere DevOps: %s\n\n", pipelineName))
+
+	phase := fmt.Sprintf("**%s**", pipelineRun.Status.Phase)
+	if pipelineRun.Status.Phase == v1alpha3.Failed {
+		if reason := pipelineRun.Status.GetLatestCondition().Reason; reason != "" {
+			phase += ": " + reason
+		}
+	}
+	if target != "" {
+		phase += fmt.Sprintf(" · [%s](%s)", pipelineRun.Name, target)
+	} else {
+		phase += " · " + pipelineRun.Name
+	}
+	buf.WriteString(phase + "\n")
+
+	var failedSteps []string
+	if len(stages) > 0 {
+		buf.WriteString("\n| Stage | Result | Duration |\n| --- | --- | --- |\n")
+		for _, stage := range stages {
+			buf.WriteString(fmt.Sprintf("| %s | %s | %s |\n", escapeTableCell(stage.DisplayName),
+				getNodeResult(stage.State, stage.Result), formatDuration(int64(stage.DurationInMillis))))
+
+			for _, step := range stage.Steps {
+				if step.Result != "FAILURE" {
+					continue
+				}
+				failedStep := fmt.Sprintf("%s / %s", stage.DisplayName, step.DisplayName)
+				if step.DisplayDescription != "" {
+					failedStep += fmt.Sprintf(" (`%s`)", strings.ReplaceAll(step.DisplayDescription, "`", "'"))
+				}
+				failedSteps = append(failedSteps, failedStep)
+			}
+		}
+	}
+
+	if len(failedSteps) > 0 {
+		buf.WriteString("\n**Failed steps**\n\n")
+		for _, step := range failedSteps {
+			buf.WriteString("* " + step + "\n")
+		}
+	}
+	return buf.String()
+}
+
+// getNodeResult returns the result of a finished stage, or the state of an unfinished one
+func getNodeResult(state, result string) string {
+	if state == "FINISHED" || state == "" && result != "" {
+		return result
+	}
+	if state == "" {
+		return "QUEUED"
+	}
+	return state
+}
+
+func formatDuration(millis int64) string {
+	if millis <= 0 {
+		return "-"
+	}
+	return (time.Duration(millis) * time.Millisecond).Round(time.Second).String()
+}
+
+func escapeTableCell(text string) string {
+	return strings.ReplaceAll(text, "|", "\\|")
+}
+
+// CreateOrUpdateComment creates a comment in the pull request, or updates the existing one which has the marker
+func (s *StatusMaker) CreateOrUpdateComment(ctx context.Context, marker, body string) (err error) {
+	if s.pr <= 0 {
+		return fmt.Errorf("no pull request found for repository %s", s.repo)
+	}
+	var scmClient *scm.Client
+	if scmClient, err = s.getClient(); err != nil {
+		return
+	}
+
+	// only the comments of the current user are taken, the marker could be copied by anyone
+	var user *scm.User
+	if user, _, err = scmClient.Users.Find(ctx); err != nil {
+		err = fmt.Errorf("failed to find the current user, error: %w", err)
+		return
+	}
+
+	var previous *scm.Comment
+	if previous, err = s.findComment(ctx, scmClient, user.Login, marker); err != nil {
+		return
+	}
+
+	input := &scm.CommentInput{Body: body}
+	switch {
+	case previous == nil:
+		_, _, err = scmClient.PullRequests.CreateComment(ctx, s.repo, s.pr, input)
+	case previous.Body != body:
+		_, _, err = scmClient.PullRequests.EditComment(ctx, s.repo, s.pr, previous.ID, input)
+	}
+	return
+}
+
+// findComment finds the comment which is created by the author and contains the marker in the pull request
+func (s *StatusMaker) findComment(ctx context.Context, scmClient *scm.Client, author, marker string) (*scm.Comment, error) {
+	opts := &scm.ListOptions{Page: 1, Size: 100}
+	for i := 0; i < maxCommentPages; i++ {
+		comments, res, err := scmClient.PullRequests.ListComments(ctx, s.repo, s.pr, opts)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list the comments of pull request %d, error: %w", s.pr, err)
+		}
+		for _, comment := range comments {
+			if comment.Author.Login == author && strings.Contains(comment.Body, marker) {
+				return comment, nil
+			}
+		}
+		if res == nil || res.Page.Next == 0 {
+			break
+		}
+		opts.Page = res.Page.Next
+	}
+	return nil, nil
+}
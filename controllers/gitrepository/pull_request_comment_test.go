@@ -0,0 +1,302 @@
+/*
+Copyright 2022 The KubeSphere Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gitrepository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/h2non/gock"
+	"github.com/jenkins-zh/jenkins-client/pkg/job"
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"kubesphere.io/devops/pkg/api/devops/v1alpha3"
+	"kubesphere.io/devops/pkg/models/pipelinerun"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func newSummaryPipelineRun() *v1alpha3.PipelineRun {
+	run := &v1alpha3.PipelineRun{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "ks-devops-abcde",
+			Namespace: "ns",
+			Labels:    map[string]string{v1alpha3.PipelineNameLabelKey: "ks-devops"},
+		},
+		Spec: v1alpha3.PipelineRunSpec{
+			PipelineRef: &v1.ObjectReference{Name: "ks-devops"},
+			SCM:         &v1alpha3.SCM{RefName: "PR-1347"},
+		},
+		Status: v1alpha3.PipelineRunStatus{
+			Phase:     v1alpha3.Failed,
+			StartTime: &metav1.Time{Time: time.Now()},
+			Conditions: []v1alpha3.Condition{{
+				Type:   v1alpha3.ConditionSucceeded,
+				Status: v1alpha3.ConditionFalse,
+				Reason: "FAILURE",
+			}},
+		},
+	}
+	return run
+}
+
+func Test_renderSummaryComment(t *testing.T) {
+	run := newSummaryPipelineRun()
+	stages := []pipelinerun.NodeDetail{{
+		Node: job.Node{DisplayName: "Build", State: "FINISHED", Result: "SUCCESS", DurationInMillis: 62400},
+	}, {
+		Node: job.Node{DisplayName: "Test | Lint", State: "FINISHED", Result: "FAILURE", DurationInMillis: 1500},
+		Steps: []pipelinerun.Step{{
+			Step: job.Step{DisplayName: "Shell Script", DisplayDescription: "make test", Result: "FAILURE"},
+		}, {
+			Step: job.Step{DisplayName: "Print Message", Result: "SUCCESS"},
+		}},
+	}, {
+		Node: job.Node{DisplayName: "Deploy", State: "SKIPPED"},
+	}}
+
+	assert.Equal(t, `<!-- ks-devops-summary: ns/ks-devops -->
+### KubeSphere DevOps: ks-devops
+
+**Failed**: FAILURE · [ks-devops-abcde](https://console/ks-devops-abcde)
+
+| Stage | Result | Duration |
+| --- | --- | --- |
+| Build | SUCCESS | 1m2s |
+| Test \| Lint | FAILURE | 2s |
+| Deploy | SKIPPED | - |
+
+**Failed steps**
+
+* Test | Lint / Shell Script (`+"`make test`"+`)
+`, renderSummaryComment(run, stages, "https://console/ks-devops-abcde"))
+
+	run.Status.Phase = v1alpha3.Running
+	assert.Equal(t, `<!-- ks-devops-summary: ns/ks-devops -->
+### KubeSphere DevOps: ks-devops
+
+**Running** · ks-devops-abcde
+`, renderSummaryComment(run, nil, ""))
+}
+
+func TestStatusMaker_CreateOrUpdateComment(t *testing.T) {
+	const marker = "<!-- ks-devops-summary: ns/ks-devops -->"
+	body := marker + "\nnew"
+	bot := map[string]string{"login": "devops-bot"}
+	mockCurrentUser := func() {
+		gock.New("https://api.github.com").
+			Get("/user").
+			Reply(200).
+			Type("application/json").
+			JSON(bot)
+	}
+
+	tests := []struct {
+		name    string
+		prepare func()
+		pr      int
+		wantErr bool
+	}{{
+		name: "not a pull request",
+	}, {
+		name: "create a new comment",
+		pr:   1347,
+		prepare: func() {
+			mockCurrentUser()
+			gock.New("https://api.github.com").
+				Get("/repos/octocat/hello-world/issues/1347/comments").
+				Reply(200).
+				Type("application/json").
+				JSON([]map[string]interface{}{{"id": 1, "body": "LGTM", "user": bot}})
+			gock.New("https://api.github.com").
+				Post("/repos/octocat/hello-world/issues/1347/comments").
+				BodyString("new").
+				Reply(201).
+				Type("application/json").
+				JSON(map[string]interface{}{"id": 2, "body": body})
+		},
+	}, {
+		name: "update the existing comment",
+		pr:   1347,
+		prepare: func() {
+			mockCurrentUser()
+			gock.New("https://api.github.com").
+				Get("/repos/octocat/hello-world/issues/1347/comments").
+				Reply(200).
+				Type("application/json").
+				JSON([]map[string]interface{}{{"id": 1, "body": "LGTM", "user": bot}, {"id": 2, "body": marker + "\nold", "user": bot}})
+			gock.New("https://api.github.com").
+				Patch("/repos/octocat/hello-world/issues/comments/2").
+				Reply(200).
+				Type("application/json").
+				JSON(map[string]interface{}{"id": 2, "body": body})
+		},
+	}, {
+		name: "the existing comment is up to date",
+		pr:   1347,
+		prepare: func() {
+			mockCurrentUser()
+			gock.New("https://api.github.com").
+				Get("/repos/octocat/hello-world/issues/1347/comments").
+				Reply(200).
+				Type("application/json").
+				JSON([]map[string]interface{}{{"id": 2, "body": body, "user": bot}})
+		},
+	}, {
+		name: "the marker is copied by another user",
+		pr:   1347,
+		prepare: func() {
+			mockCurrentUser()
+			gock.New("https://api.github.com").
+				Get("/repos/octocat/hello-world/issues/1347/comments").
+				Reply(200).
+				Type("application/json").
+				JSON([]map[string]interface{}{{"id": 1, "body": marker + "\nfake", "user": map[string]string{"login": "someone"}}})
+			gock.New("https://api.github.com").
+				Post("/repos/octocat/hello-world/issues/1347/comments").
+				BodyString("new").
+				Reply(201).
+				Type("application/json").
+				JSON(map[string]interface{}{"id": 2, "body": body})
+		},
+	}, {
+		name: "failed to find the current user",
+		pr:   1347,
+		prepare: func() {
+			gock.New("https://api.github.com").
+				Get("/user").
+				Reply(401)
+		},
+		wantErr: true,
+	}, {
+		name: "failed to list the comments",
+		pr:   1347,
+		prepare: func() {
+			mockCurrentUser()
+			gock.New("https://api.github.com").
+				Get("/repos/octocat/hello-world/issues/1347/comments").
+				Reply(500)
+		},
+		wantErr: true,
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer gock.Off()
+			if tt.prepare != nil {
+				tt.prepare()
+			}
+			maker := NewStatusMaker("octocat/hello-world", "")
+			maker.WithProvider("github").WithPR(tt.pr)
+
+			err := maker.CreateOrUpdateComment(context.Background(), marker, body)
+			if tt.wantErr || tt.pr == 0 {
+				assert.NotNil(t, err)
+			} else {
+				assert.Nil(t, err)
+			}
+			assert.True(t, gock.IsDone())
+		})
+	}
+}
+
+func TestPullRequestStatusReconciler_isSummaryCommentEnabled(t *testing.T) {
+	schema, err := v1alpha3.SchemeBuilder.Register().Build()
+	assert.Nil(t, err)
+
+	pipeline := &v1alpha3.Pipeline{
+		ObjectMeta: metav1.ObjectMeta{Name: "ks-devops", Namespace: "ns"},
+		Spec: v1alpha3.PipelineSpec{
+			Type: v1alpha3.MultiBranchPipelineType,
+			MultiBranchPipeline: &v1alpha3.MultiBranchPipeline{
+				SourceType:   v1alpha3.SourceTypeGithub,
+				GitHubSource: &v1alpha3.GithubSource{Owner: "octocat", Repo: "hello-world"},
+			},
+		},
+	}
+	disabledPipeline := pipeline.DeepCopy()
+	disabledPipeline.Annotations = map[string]string{v1alpha3.PipelinePRCommentAnnoKey: "false"}
+	enabledPipeline := pipeline.DeepCopy()
+	enabledPipeline.Annotations = map[string]string{v1alpha3.PipelinePRCommentAnnoKey: "true"}
+
+	repo := &v1alpha3.GitRepository{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "hello-world",
+			Namespace:   "ns",
+			Annotations: map[string]string{v1alpha3.AnnotationKeyPRComment: "true"},
+		},
+		Spec: v1alpha3.GitRepositorySpec{URL: "https://github.com/octocat/hello-world.git"},
+	}
+
+	tests := []struct {
+		name    string
+		objects []runtime.Object
+		want    bool
+	}{{
+		name: "no Pipeline found",
+	}, {
+		name:    "no annotations",
+		objects: []runtime.Object{pipeline.DeepCopy()},
+	}, {
+		name:    "enabled by the Pipeline",
+		objects: []runtime.Object{enabledPipeline.DeepCopy()},
+		want:    true,
+	}, {
+		name:    "enabled by the GitRepository",
+		objects: []runtime.Object{pipeline.DeepCopy(), repo.DeepCopy()},
+		want:    true,
+	}, {
+		name: "GitRepository of another address",
+		objects: []runtime.Object{pipeline.DeepCopy(), &v1alpha3.GitRepository{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        "another",
+				Namespace:   "ns",
+				Annotations: map[string]string{v1alpha3.AnnotationKeyPRComment: "true"},
+			},
+			Spec: v1alpha3.GitRepositorySpec{URL: "https://github.com/octocat/another.git"},
+		}},
+	}, {
+		name:    "disabled by the Pipeline",
+		objects: []runtime.Object{disabledPipeline.DeepCopy(), repo.DeepCopy()},
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := &PullRequestStatusReconciler{
+				Client: fake.NewClientBuilder().WithScheme(schema).WithRuntimeObjects(tt.objects...).Build(),
+			}
+			assert.Equal(t, tt.want, r.isSummaryCommentEnabled(context.TODO(), newSummaryPipelineRun()))
+		})
+	}
+}
+
+func TestPullRequestStatusReconciler_isLatestPipelineRun(t *testing.T) {
+	schema, err := v1alpha3.SchemeBuilder.Register().Build()
+	assert.Nil(t, err)
+
+	current := newSummaryPipelineRun()
+	newer := current.DeepCopy()
+	newer.Name = "ks-devops-fghij"
+	newer.Status.StartTime = &metav1.Time{Time: current.Status.StartTime.Add(time.Minute)}
+	otherBranch := newer.DeepCopy()
+	otherBranch.Spec.SCM.RefName = "master"
+
+	r := &PullRequestStatusReconciler{
+		Client: fake.NewClientBuilder().WithScheme(schema).WithObjects(current.DeepCopy(), otherBranch).Build(),
+	}
+	assert.True(t, r.isLatestPipelineRun(context.TODO(), current))
+
+	r.Client = fake.NewClientBuilder().WithScheme(schema).WithObjects(current.DeepCopy(), newer).Build()
+	assert.False(t, r.isLatestPipelineRun(context.TODO(), current))
+}
@@ -15,6 +15,8 @@ package gitrepository
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"regexp"
 	"strconv"
@@ -24,29 +26,40 @@ import (
 
 	"github.com/go-logr/logr"
 	"github.com/jenkins-x/go-scm/scm"
-	"github.com/jenkins-x/go-scm/scm/factory"
+	"github.com/jenkins-zh/jenkins-client/pkg/job"
 	v1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/tools/record"
 	"kubesphere.io/devops/pkg/api/devops/v1alpha3"
+	"kubesphere.io/devops/pkg/client/git"
+	"kubesphere.io/devops/pkg/config"
+	"kubesphere.io/devops/pkg/indexers"
+	"kubesphere.io/devops/pkg/models/pipelinerun"
+	cmstore "kubesphere.io/devops/pkg/store/configmap"
 	"kubesphere.io/devops/pkg/utils/net"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
-// PullRequestStatusReconciler reconciles a Pipeline build status to the Pull Requests
+// PullRequestStatusReconciler reconciles a Pipeline build status to the commits of
+// Pull Requests, branches and tags
 type PullRequestStatusReconciler struct {
 	client.Client
 	ExternalAddress string
 	ClusterName     string
+	ConsoleOption   *config.ConsoleOption
 
 	log      logr.Logger
 	recorder record.EventRecorder
 }
 
 //+kubebuilder:rbac:groups=devops.kubesphere.io,resources=webhooks,verbs=get;list;update;patch
+//+kubebuilder:rbac:groups=devops.kubesphere.io,resources=pipelines,verbs=get
+//+kubebuilder:rbac:groups=devops.kubesphere.io,resources=pipelineruns,verbs=get;list;patch
+//+kubebuilder:rbac:groups=devops.kubesphere.io,resources=gitrepositories,verbs=list
 //+kubebuilder:rbac:groups=devops.kubesphere.io,resources=secrets,verbs=get
+//+kubebuilder:rbac:groups="",resources=configmaps,verbs=get
 
 // Reconcile is the main entry of this reconciler
 func (r *PullRequestStatusReconciler) Reconcile(ctx context.Context, req ctrl.Request) (
@@ -57,45 +70,81 @@ func (r *PullRequestStatusReconciler) Reconcile(ctx context.Context, req ctrl.Re
 		return
 	}
 
-	if !pipelinerun.Spec.IsMultiBranchPipeline() || pipelinerun.Spec.SCM == nil || pipelinerun.Status.Phase == "" {
+	if pipelinerun.Status.Phase == "" {
 		return
 	}
 
+	var (
+		repoInfo repoInformation
+		scmRef   *v1alpha3.SCM
+	)
+	if pipelinerun.Spec.IsMultiBranchPipeline() {
+		if scmRef = pipelinerun.Spec.SCM; scmRef == nil {
+			return
+		}
+		repoInfo = getRepoInfo(pipelinerun.Spec.PipelineSpec.MultiBranchPipeline)
+	} else {
+		// only the commit which triggered the PipelineRun through a webhook is known
+		if pipelinerun.GetAnnotations()[v1alpha3.PipelineRunCommitAnnoKey] == "" {
+			return
+		}
+		scmRef = &v1alpha3.SCM{}
+		repoInfo = r.getRepoInfoOfPipeline(ctx, pipelinerun)
+	}
+
 	r.log.Info(fmt.Sprintf("start to reconcile %s", req.NamespacedName))
-	var prNumber int
-	if prNumber, err = getPRNumber(pipelinerun.Spec.SCM.RefName); err != nil {
-		err = nil
+	if repoInfo.isInvalid() {
 		return
 	}
 
-	repoInfo := getRepoInfo(pipelinerun.Spec.PipelineSpec.MultiBranchPipeline)
-	if repoInfo.isInvalid() {
+	prNumber, isPR := getPRNumberFromSCM(scmRef)
+	sha := getCommitSHA(pipelinerun)
+	if !isPR && sha == "" {
+		// the head of the branch might be a newer commit, wait for the commit of the PipelineRun
+		r.log.Info("skip sending the status until the commit is known", "pipelinerun", req.NamespacedName)
 		return
 	}
 
-	var (
-		token    string
-		username string
-	)
-	if username, token, err = r.getTokenFromSecret(&v1.SecretReference{
+	// the client factory supports all kinds of the git credentials, such as GitHub App and OAuth
+	factory := git.NewClientFactory(repoInfo.provider, &v1.SecretReference{
 		Name:      repoInfo.tokenId,
-		Namespace: pipelinerun.Namespace,
-	}, ""); err != nil {
-		err = fmt.Errorf("failed to get token, error %v", err)
+		Namespace: stringutils.SetOrDefault(repoInfo.tokenNamespace, pipelinerun.Namespace),
+	}, r.Client)
+	factory.Server = repoInfo.server
+	var scmClient *scm.Client
+	if scmClient, err = factory.GetClientWithContext(ctx); err != nil {
+		err = fmt.Errorf("failed to create the git client, error %v", err)
 		return
 	}
 
 	repo := repoInfo.getRepoPath()
-	r.log.Info(fmt.Sprintf("start sending status to %s with pr %d", repo, prNumber))
+	r.log.Info(fmt.Sprintf("start sending status to %s with ref %s", repo, scmRef.RefName))
 
 	var target string
 	if target, err = r.getExternalPipelineRunAddress(ctx, pipelinerun); err != nil {
 		return
 	}
 
-	maker := NewStatusMaker(repo, token)
-	maker.WithTarget(target).WithPR(prNumber).WithProvider(repoInfo.provider).WithUsername(username)
+	maker := NewStatusMaker(repo, "")
+	maker.WithTarget(target).WithProvider(repoInfo.provider).WithServer(repoInfo.server).WithClient(scmClient)
 	maker.WithExpirationCheck(createExpirationCheckFunc(ctx, r, pipelinerun.DeepCopy()))
+	if isPR {
+		// the commit of a pull request build might be a merge commit which only exists in Jenkins,
+		// so the head commit of the pull request is always used
+		maker.WithPR(prNumber)
+	} else {
+		maker.WithSHA(sha)
+	}
+
+	// record the commit before sending the statuses, the older PipelineRuns of the same commit take it as newer one
+	var statusSHA string
+	if statusSHA, err = maker.GetSHA(ctx); err != nil {
+		r.log.Error(err, "failed to resolve the commit of the statuses")
+		return
+	}
+	if err = r.recordStatusCommit(ctx, pipelinerun, statusSHA); err != nil {
+		return
+	}
 
 	var desc string
 	switch pipelinerun.Status.Phase {
@@ -108,62 +157,210 @@ func (r *PullRequestStatusReconciler) Reconcile(ctx context.Context, req ctrl.Re
 		desc = string(pipelinerun.Status.Phase)
 	}
 
-	err = maker.CreateWithPipelinePhase(ctx, pipelinerun.Status.Phase, "KubeSphere DevOps", desc)
-	if err != nil {
+	statusSupported := true
+	if err = maker.CreateWithPipelinePhase(ctx, pipelinerun.Status.Phase, statusLabel, desc); errors.Is(err, scm.ErrNotSupported) {
+		// there is no commit status in some of the git providers, such as Gitee
+		r.log.Info("the commit statuses are not supported by the git provider", "provider", repoInfo.provider)
+		statusSupported = false
+		err = nil
+	} else if err != nil {
 		r.log.Error(err, "failed to send status")
+		return
+	}
+
+	if statusSupported && r.isStageStatusEnabled(ctx, pipelinerun) {
+		for _, stage := range r.getStages(ctx, pipelinerun) {
+			state, ok := convertNodeToSCMStatus(stage.Node)
+			if !ok {
+				continue
+			}
+			if err = maker.Create(ctx, state, statusLabel+" / "+stage.DisplayName, strings.ToLower(state.String())); err != nil {
+				r.log.Error(err, "failed to send stage status", "stage", stage.DisplayName)
+				return
+			}
+		}
+	}
+
+	if maker.pr > 0 && r.isSummaryCommentEnabled(ctx, pipelinerun) && r.isLatestPipelineRun(ctx, pipelinerun) {
+		body := renderSummaryComment(pipelinerun, r.getStages(ctx, pipelinerun), target)
+		if err = maker.CreateOrUpdateComment(ctx, getSummaryCommentMarker(pipelinerun), body); err != nil {
+			if errors.Is(err, scm.ErrNotSupported) {
+				err = nil
+				return
+			}
+			r.log.Error(err, "failed to send the summary comment")
+		}
+	}
+	return
+}
+
+// isStageStatusEnabled checks if the Pipeline of the PipelineRun wants a status for each stage
+func (r *PullRequestStatusReconciler) isStageStatusEnabled(ctx context.Context, pipelineRun *v1alpha3.PipelineRun) bool {
+	if pipelineRun.Spec.PipelineRef == nil {
+		return false
+	}
+	pipeline := &v1alpha3.Pipeline{}
+	if err := r.Get(ctx, types.NamespacedName{
+		Namespace: pipelineRun.Namespace,
+		Name:      pipelineRun.Spec.PipelineRef.Name,
+	}, pipeline); err != nil {
+		return false
+	}
+	return pipeline.GetAnnotations()[v1alpha3.PipelineStageStatusAnnoKey] == "true"
+}
+
+// getStages returns the stages of a PipelineRun from its annotation or the ConfigMap store
+func (r *PullRequestStatusReconciler) getStages(ctx context.Context, pipelineRun *v1alpha3.PipelineRun) (stages []pipelinerun.NodeDetail) {
+	stagesJSON, ok := pipelineRun.Annotations[v1alpha3.JenkinsPipelineRunStagesStatusAnnoKey]
+	if !ok {
+		if pipelineRunStore, err := cmstore.NewConfigMapStore(ctx, types.NamespacedName{
+			Namespace: pipelineRun.Namespace,
+			Name:      pipelineRun.Name,
+		}, r.Client); err == nil {
+			stagesJSON = pipelineRunStore.GetStages()
+		}
+	}
+
+	if stagesJSON != "" {
+		if err := json.Unmarshal([]byte(stagesJSON), &stages); err != nil {
+			r.log.Error(err, "failed to parse the stages", "pipelinerun", pipelineRun.Name)
+		}
+	}
+	return
+}
+
+// getCommitSHA returns the commit SHA of a PipelineRun. It comes from the cause which triggered the PipelineRun,
+// or from the run data of Jenkins.
+func getCommitSHA(pipelineRun *v1alpha3.PipelineRun) (sha string) {
+	if sha = pipelineRun.GetAnnotations()[v1alpha3.PipelineRunCommitAnnoKey]; sha != "" {
+		return
+	}
+
+	runStatusJSON := pipelineRun.GetAnnotations()[v1alpha3.JenkinsPipelineRunStatusAnnoKey]
+	if runStatusJSON == "" {
+		return
+	}
+	run := &job.PipelineRun{}
+	if err := json.Unmarshal([]byte(runStatusJSON), run); err == nil {
+		sha = run.CommitID
+		if sha == "" && len(run.ChangeSet) > 0 {
+			sha = run.ChangeSet[len(run.ChangeSet)-1].CommitID
+		}
 	}
 	return
 }
 
-// createExpirationCheckFunc checks the start time of the PipelineRun
+// convertNodeToSCMStatus converts the state of a Jenkins stage to a commit status.
+// It returns false if the stage was skipped.
+func convertNodeToSCMStatus(node job.Node) (status scm.State, ok bool) {
+	ok = true
+	switch node.State {
+	case "FINISHED":
+		switch node.Result {
+		case "SUCCESS":
+			status = scm.StateSuccess
+		case "FAILURE", "UNSTABLE":
+			status = scm.StateFailure
+		case "ABORTED":
+			status = scm.StateCanceled
+		default:
+			status = scm.StateUnknown
+		}
+	case "RUNNING", "PAUSED":
+		status = scm.StateRunning
+	case "QUEUED", "":
+		status = scm.StatePending
+	default:
+		// SKIPPED or NOT_BUILT
+		ok = false
+	}
+	return
+}
+
+// recordStatusCommit records the commit which the statuses of a PipelineRun are sent to, see also createExpirationCheckFunc
+func (r *PullRequestStatusReconciler) recordStatusCommit(ctx context.Context, pipelineRun *v1alpha3.PipelineRun, sha string) (err error) {
+	if pipelineRun.GetAnnotations()[v1alpha3.PipelineRunStatusCommitAnnoKey] == sha {
+		return
+	}
+	patch := client.MergeFrom(pipelineRun.DeepCopy())
+	if pipelineRun.Annotations == nil {
+		pipelineRun.Annotations = map[string]string{}
+	}
+	pipelineRun.Annotations[v1alpha3.PipelineRunStatusCommitAnnoKey] = sha
+	if err = r.Patch(ctx, pipelineRun, patch); err != nil {
+		err = fmt.Errorf("failed to record the commit of the statuses, error: %v", err)
+	}
+	return
+}
+
+// createExpirationCheckFunc checks the start time of the PipelineRun. The current status is expired if a newer
+// PipelineRun of the same Pipeline and SCM reference sent the statuses to the same commit.
+// The PipelineRuns are identified by the annotation instead of the descriptions of the previous statuses.
 func createExpirationCheckFunc(ctx context.Context, k8sClient client.Client, currentPipelineRun *v1alpha3.PipelineRun) expirationCheckFunc {
-	return func(previousStatus *scm.Status, currentStatus *scm.StatusInput) bool {
-		if previousStatus == nil {
+	return func(sha string, previousStatus *scm.Status, currentStatus *scm.StatusInput) bool {
+		if previousStatus == nil || currentPipelineRun.Status.StartTime == nil {
 			// consider this is the first build status
 			return false
 		}
-
-		name, ns, err := getPipelineRunNameAndNsFromURL(previousStatus.Target)
-		if err == nil {
-			if name == currentPipelineRun.Name && ns == currentPipelineRun.Namespace {
-				// allow it pass if they belong to the same PipelineRun
-				return false
-			}
-
-			previousPipelineRun := &v1alpha3.PipelineRun{}
-			if err = k8sClient.Get(ctx, types.NamespacedName{Namespace: ns, Name: name}, previousPipelineRun); err == nil &&
-				previousPipelineRun.Status.StartTime != nil &&
-				currentPipelineRun.Status.StartTime != nil {
-				return !previousPipelineRun.Status.StartTime.Before(currentPipelineRun.Status.StartTime)
+		newerPipelineRuns, err := listNewerPipelineRuns(ctx, k8sClient, currentPipelineRun)
+		if err != nil {
+			return false
+		}
+		for i := range newerPipelineRuns {
+			if newerPipelineRuns[i].GetAnnotations()[v1alpha3.PipelineRunStatusCommitAnnoKey] == sha {
+				return true
 			}
 		}
 		return false
 	}
 }
 
-// getPipelineRunNameAndNsFromURL parse a URL and returns the name and namespace of a PipelineRun
-//
-// http://ip:port/ks-devops-core/clusters/host/devops/core58fgv/pipelines/ks-devops/branch/PR-816/run/ks-devops-pzdcz/task-status
-func getPipelineRunNameAndNsFromURL(link string) (name, ns string, err error) {
-	var reg *regexp.Regexp
-	if reg, err = regexp.Compile("run/.*/task-status"); err == nil {
-		name = reg.FindString(link)
-		name = strings.TrimPrefix(name, "run/")
-		name = strings.TrimSuffix(name, "/task-status")
+// listNewerPipelineRuns returns the PipelineRuns of the same Pipeline and SCM reference which started later.
+// The PipelineRuns are listed by the indexer of the SCM reference name.
+func listNewerPipelineRuns(ctx context.Context, reader client.Reader, pipelineRun *v1alpha3.PipelineRun) (
+	pipelineRuns []v1alpha3.PipelineRun, err error) {
+	pipelineName := pipelineRun.GetLabels()[v1alpha3.PipelineNameLabelKey]
+	if pipelineName == "" || pipelineRun.Status.StartTime == nil {
+		return
+	}
+
+	refName := getRefName(pipelineRun)
+	listOptions := []client.ListOption{client.InNamespace(pipelineRun.Namespace),
+		client.MatchingLabels{v1alpha3.PipelineNameLabelKey: pipelineName}}
+	if refName != "" {
+		listOptions = append(listOptions, client.MatchingFields{v1alpha3.PipelineRunSCMRefNameField: refName})
 	}
-	if reg, err = regexp.Compile("devops/core58fgv/pipelines"); err == nil {
-		ns = reg.FindString(link)
-		ns = strings.TrimPrefix(ns, "devops/")
-		ns = strings.TrimSuffix(ns, "/pipelines")
+	pipelineRunList := &v1alpha3.PipelineRunList{}
+	if err = reader.List(ctx, pipelineRunList, listOptions...); err != nil {
+		return
+	}
+	for i := range pipelineRunList.Items {
+		item := pipelineRunList.Items[i]
+		// double check the reference in case the client does not support the field selector
+		if item.Name == pipelineRun.Name || item.Status.StartTime == nil || getRefName(&item) != refName {
+			continue
+		}
+		if pipelineRun.Status.StartTime.Before(item.Status.StartTime) {
+			pipelineRuns = append(pipelineRuns, item)
+		}
+	}
+	return
+}
+
+func getRefName(pipelineRun *v1alpha3.PipelineRun) (refName string) {
+	if pipelineRun.Spec.SCM != nil {
+		refName = pipelineRun.Spec.SCM.RefName
 	}
 	return
 }
 
 type repoInformation struct {
-	provider string
-	owner    string
-	repo     string
-	tokenId  string
+	provider       string
+	server         string
+	owner          string
+	repo           string
+	tokenId        string
+	tokenNamespace string
 }
 
 func (r repoInformation) getRepoPath() string {
@@ -204,16 +401,102 @@ func getRepoInfo(repo *v1alpha3.MultiBranchPipeline) (info repoInformation) {
 			info.repo = strings.TrimPrefix(repo.GitlabSource.Repo, repo.GitlabSource.Owner+"/")
 			info.tokenId = repo.GitlabSource.CredentialId
 		}
+	case v1alpha3.SourceTypeAzureDevOps:
+		if repo.AzureDevOpsSource != nil {
+			info.provider = "azure_devops"
+			// the server of the client is the organization, and the projects are taken as the owners
+			server := strings.TrimSuffix(repo.AzureDevOpsSource.ApiUri, "/")
+			if server == "" {
+				server = "https://dev.azure.com"
+			}
+			info.server = server + "/" + repo.AzureDevOpsSource.Owner
+			info.owner = repo.AzureDevOpsSource.Project
+			info.repo = repo.AzureDevOpsSource.Repo
+			info.tokenId = repo.AzureDevOpsSource.CredentialId
+		}
+	case v1alpha3.SourceTypeGitee:
+		if repo.GiteeSource != nil {
+			info.provider = "gitee"
+			info.server = repo.GiteeSource.ApiUri
+			info.owner = repo.GiteeSource.Owner
+			info.repo = repo.GiteeSource.Repo
+			info.tokenId = repo.GiteeSource.CredentialId
+		}
 	}
 	return
 }
 
+// getRepoInfoOfPipeline returns the repository information of a non multi-branch Pipeline.
+// The repository address comes from the annotation of the Pipeline, and the provider and credential
+// come from the GitRepository which has the same address.
+func (r *PullRequestStatusReconciler) getRepoInfoOfPipeline(ctx context.Context, pipelineRun *v1alpha3.PipelineRun) (info repoInformation) {
+	if pipelineRun.Spec.PipelineRef == nil {
+		return
+	}
+	pipeline := &v1alpha3.Pipeline{}
+	if err := r.Get(ctx, types.NamespacedName{
+		Namespace: pipelineRun.Namespace,
+		Name:      pipelineRun.Spec.PipelineRef.Name,
+	}, pipeline); err != nil {
+		return
+	}
+
+	gitRepo := r.findGitRepository(ctx, pipeline.Namespace, indexers.GetPipelineRepoURL(pipeline))
+	if gitRepo == nil || gitRepo.Spec.Secret == nil {
+		return
+	}
+	info.provider = gitRepo.Spec.Provider
+	info.server = gitRepo.Spec.Server
+	if repoPath := git.GetRepoPath(gitRepo); strings.Contains(repoPath, "/") {
+		// the owner of Gitlab might contain the subgroups
+		index := strings.LastIndex(repoPath, "/")
+		info.owner, info.repo = repoPath[:index], repoPath[index+1:]
+	}
+	info.tokenId = gitRepo.Spec.Secret.Name
+	info.tokenNamespace = stringutils.SetOrDefault(gitRepo.Spec.Secret.Namespace, gitRepo.Namespace)
+	return
+}
+
+// findGitRepository finds the GitRepository which has the normalized address in a namespace
+func (r *PullRequestStatusReconciler) findGitRepository(ctx context.Context, namespace, repoURL string) *v1alpha3.GitRepository {
+	if repoURL == "" {
+		return nil
+	}
+	repoList := &v1alpha3.GitRepositoryList{}
+	if err := r.List(ctx, repoList, client.InNamespace(namespace),
+		client.MatchingFields{v1alpha3.GitRepositoryURLIndexerName: repoURL}); err != nil {
+		return nil
+	}
+	for i := range repoList.Items {
+		// double check the address in case the client does not support the field selector
+		if git.NormalizeURL(repoList.Items[i].Spec.URL) == repoURL {
+			return &repoList.Items[i]
+		}
+	}
+	return nil
+}
+
 func (r *PullRequestStatusReconciler) getExternalPipelineRunAddress(ctx context.Context, pipelineRun *v1alpha3.PipelineRun) (target string, err error) {
 	var ws string
 	if ws, err = r.getWorkspace(ctx, pipelineRun.GetNamespace()); err == nil {
-		target = fmt.Sprintf("%s/%s/clusters/%s/devops/%s/pipelines/%s/branch/%s/run/%s/task-status",
-			net.ParseURL(r.ExternalAddress), ws, r.ClusterName,
-			pipelineRun.Namespace, pipelineRun.Spec.PipelineRef.Name, pipelineRun.Spec.SCM.RefName, pipelineRun.Name)
+		consoleOption := r.ConsoleOption
+		if consoleOption == nil {
+			consoleOption = config.NewConsoleOption()
+		}
+
+		var pipelineName string
+		if pipelineRun.Spec.PipelineRef != nil {
+			pipelineName = pipelineRun.Spec.PipelineRef.Name
+		}
+		target = consoleOption.GetPipelineRunURL(config.PipelineRunURLParams{
+			Address:   net.ParseURL(r.ExternalAddress),
+			Workspace: ws,
+			Cluster:   r.ClusterName,
+			Namespace: pipelineRun.Namespace,
+			Pipeline:  pipelineName,
+			Branch:    getRefName(pipelineRun),
+			Run:       pipelineRun.Name,
+		})
 	}
 	return
 }
@@ -228,27 +511,15 @@ func (r *PullRequestStatusReconciler) getWorkspace(ctx context.Context, ns strin
 	return
 }
 
-func (r *PullRequestStatusReconciler) getTokenFromSecret(secretRef *v1.SecretReference, defaultNamespace string) (username, token string, err error) {
-	var gitSecret *v1.Secret
-	if gitSecret, err = r.getSecret(secretRef, defaultNamespace); err == nil {
-		switch gitSecret.Type {
-		case v1.SecretTypeBasicAuth, v1alpha3.SecretTypeBasicAuth:
-			token = string(gitSecret.Data[v1.BasicAuthPasswordKey])
-			username = string(gitSecret.Data[v1.BasicAuthUsernameKey])
-		case v1.SecretTypeOpaque, v1alpha3.SecretTypeSecretText:
-			token = string(gitSecret.Data[v1.ServiceAccountTokenKey])
-		}
+// getPRNumberFromSCM returns the pull request number if the SCM reference is a pull request or merge request
+func getPRNumberFromSCM(scmRef *v1alpha3.SCM) (prNumber int, ok bool) {
+	switch scmRef.RefType {
+	case v1alpha3.Branch, v1alpha3.Tag:
+		return
 	}
-	return
-}
-
-func (r *PullRequestStatusReconciler) getSecret(ref *v1.SecretReference, defaultNamespace string) (secret *v1.Secret, err error) {
-	secret = &v1.Secret{}
-	ns := stringutils.SetOrDefault(ref.Namespace, defaultNamespace)
-	err = r.Client.Get(context.TODO(), types.NamespacedName{
-		Namespace: ns, Name: ref.Name,
-	}, secret)
-	err = stringutils.ErrorOverride(err, "cannot get secret %v, error is: %v", secret, err)
+	var err error
+	prNumber, err = getPRNumber(scmRef.RefName)
+	ok = err == nil
 	return
 }
 
@@ -302,16 +573,20 @@ func (r *PullRequestStatusReconciler) getTimeSinceFinished(time *metav1.Time) (s
 	return
 }
 
-// StatusMaker responsible for Pull Requests status creating
+// StatusMaker responsible for commit status creating of Pull Requests, branches and tags
 type StatusMaker struct {
 	provider string
 	server   string
 	repo     string
 	pr       int
+	sha      string
 	token    string
 	username string
 	target   string
 
+	scmClient   *scm.Client
+	resolvedSHA string
+
 	// expirationCheck checks if the current status is expiration that compared to the previous one
 	expirationCheck expirationCheckFunc
 }
@@ -321,13 +596,14 @@ func NewStatusMaker(repo, token string) *StatusMaker {
 	return &StatusMaker{
 		repo:  repo,
 		token: token,
-		expirationCheck: func(previousStatus *scm.Status, currentStatus *scm.StatusInput) bool {
+		expirationCheck: func(sha string, previousStatus *scm.Status, currentStatus *scm.StatusInput) bool {
 			return false
 		},
 	}
 }
 
-type expirationCheckFunc func(previousStatus *scm.Status, currentStatus *scm.StatusInput) bool
+// expirationCheckFunc checks if the current status of a commit is expired
+type expirationCheckFunc func(sha string, previousStatus *scm.Status, currentStatus *scm.StatusInput) bool
 
 // WithExpirationCheck set the expiration check function
 func (s *StatusMaker) WithExpirationCheck(check expirationCheckFunc) *StatusMaker {
@@ -365,37 +641,103 @@ func (s *StatusMaker) WithPR(pr int) *StatusMaker {
 	return s
 }
 
-// Create creates a generic status
+// WithSHA sets the commit SHA
+func (s *StatusMaker) WithSHA(sha string) *StatusMaker {
+	s.sha = sha
+	return s
+}
+
+// WithClient sets the git client, the token and username are ignored in this case
+func (s *StatusMaker) WithClient(scmClient *scm.Client) *StatusMaker {
+	s.scmClient = scmClient
+	return s
+}
+
+// Create creates a generic status, it returns scm.ErrNotSupported if there is no commit status in the git provider
 func (s *StatusMaker) Create(ctx context.Context, status scm.State, label, desc string) (err error) {
-	var scmClient *scm.Client
-	scmClient, err = factory.NewClient(s.provider, s.server, s.token, func(c *scm.Client) {
-		c.Username = s.username
-	})
-	if err != nil {
+	var (
+		scmClient *scm.Client
+		sha       string
+	)
+	if scmClient, err = s.getClient(); err != nil {
+		return
+	}
+	if sha, err = s.resolveSHA(ctx, scmClient); err != nil {
 		return
 	}
 
-	var pullRequest *scm.PullRequest
-	if pullRequest, _, err = scmClient.PullRequests.Find(ctx, s.repo, s.pr); err == nil {
-		var previousStatus *scm.Status
-		if previousStatus, err = s.FindPreviousStatus(ctx, scmClient, pullRequest.Sha, label); err != nil {
-			return
-		}
+	var previousStatus *scm.Status
+	if previousStatus, err = s.FindPreviousStatus(ctx, scmClient, sha, label); err != nil {
+		return
+	}
 
-		currentStatus := &scm.StatusInput{
-			Desc:   desc,
-			Label:  label,
-			State:  status,
-			Target: s.target,
-		}
-		// avoid the previous building status override newer one
-		if !s.expirationCheck(previousStatus, currentStatus) {
-			_, _, err = scmClient.Repositories.CreateStatus(ctx, s.repo, pullRequest.Sha, currentStatus)
+	currentStatus := &scm.StatusInput{
+		Desc:   desc,
+		Label:  label,
+		State:  status,
+		Target: s.target,
+	}
+	if isSameStatus(previousStatus, currentStatus) {
+		return
+	}
+	// avoid the previous building status override newer one
+	if !s.expirationCheck(sha, previousStatus, currentStatus) {
+		_, _, err = scmClient.Repositories.CreateStatus(ctx, s.repo, sha, currentStatus)
+	}
+	return
+}
+
+func (s *StatusMaker) getClient() (scmClient *scm.Client, err error) {
+	if s.scmClient == nil {
+		s.scmClient, err = git.NewClient(s.provider, s.server, s.token, func(c *scm.Client) {
+			c.Username = s.username
+		})
+	}
+	scmClient = s.scmClient
+	return
+}
+
+// GetSHA returns the commit SHA which the statuses belong to
+func (s *StatusMaker) GetSHA(ctx context.Context) (sha string, err error) {
+	var scmClient *scm.Client
+	if scmClient, err = s.getClient(); err == nil {
+		sha, err = s.resolveSHA(ctx, scmClient)
+	}
+	return
+}
+
+// resolveSHA returns the commit SHA which the status belongs to
+func (s *StatusMaker) resolveSHA(ctx context.Context, scmClient *scm.Client) (sha string, err error) {
+	if s.resolvedSHA != "" {
+		sha = s.resolvedSHA
+		return
+	}
+
+	switch {
+	case s.pr > 0:
+		var pullRequest *scm.PullRequest
+		if pullRequest, _, err = scmClient.PullRequests.Find(ctx, s.repo, s.pr); err == nil {
+			sha = pullRequest.Sha
 		}
+	case s.sha != "":
+		sha = s.sha
+	default:
+		// never take the head of a branch, it might be a newer commit which is not built by the PipelineRun
+		err = fmt.Errorf("no pull request or commit found for repository %s", s.repo)
+	}
+	if err == nil && sha == "" {
+		err = fmt.Errorf("cannot resolve the commit SHA of repository %s", s.repo)
 	}
+	s.resolvedSHA = sha
 	return
 }
 
+// isSameStatus checks if the current status is same as the previous one, there is no need to send it again
+func isSameStatus(previousStatus *scm.Status, currentStatus *scm.StatusInput) bool {
+	return previousStatus != nil && previousStatus.State == currentStatus.State &&
+		previousStatus.Desc == currentStatus.Desc && previousStatus.Target == currentStatus.Target
+}
+
 // FindPreviousStatus finds the existing status by sha and label
 func (s *StatusMaker) FindPreviousStatus(ctx context.Context, scmClient *scm.Client, sha, label string) (target *scm.Status, err error) {
 	var exists []*scm.Status
@@ -403,7 +745,7 @@ func (s *StatusMaker) FindPreviousStatus(ctx context.Context, scmClient *scm.Cli
 		Page: 1,
 		Size: 100, // assume this list has not too many items
 	}); err != nil {
-		err = fmt.Errorf("failed to list the existing status, error: %v", err)
+		err = fmt.Errorf("failed to list the existing status, error: %w", err)
 		return
 	}
 
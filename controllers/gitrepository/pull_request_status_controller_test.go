@@ -24,12 +24,14 @@ import (
 	"github.com/go-logr/logr"
 	"github.com/h2non/gock"
 	"github.com/jenkins-x/go-scm/scm"
+	"github.com/jenkins-zh/jenkins-client/pkg/job"
 	"github.com/stretchr/testify/assert"
 	v1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/tools/record"
 	mgrcore "kubesphere.io/devops/controllers/core"
 	"kubesphere.io/devops/pkg/api/devops/v1alpha3"
+	"kubesphere.io/devops/pkg/config"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/client/fake"
@@ -109,6 +111,46 @@ func TestCreatePullRequestStatus(t *testing.T) {
 			return maker
 		},
 		wantErr: true,
+	}, {
+		name: "branch with a known commit",
+		createStatusMaker: func() *StatusMaker {
+			gock.New("https://api.github.com").
+				Post("/repos/octocat/hello-world/statuses/6dcb09b5b57875f334f61aebed695e2e4193db5e").
+				Reply(201).
+				Type("application/json").
+				SetHeaders(mockHeaders).
+				File("testdata/status.json")
+
+			gock.New("https://api.github.com").
+				Get("/repos/octocat/hello-world/statuses/6dcb09b5b57875f334f61aebed695e2e4193db5e").
+				MatchParam("page", "1").
+				MatchParam("per_page", "100").
+				Reply(200).
+				Type("application/json").
+				SetHeaders(mockHeaders).
+				File("testdata/statuses.json")
+
+			maker := NewStatusMaker("octocat/hello-world", "")
+			maker.WithTarget("https://ci.example.com/1000/output").
+				WithSHA("6dcb09b5b57875f334f61aebed695e2e4193db5e")
+			return maker
+		},
+		wantErr: false,
+	}, {
+		name: "branch without a known commit",
+		createStatusMaker: func() *StatusMaker {
+			// the head of the branch is never taken
+			maker := NewStatusMaker("octocat/hello-world", "")
+			maker.WithTarget("https://ci.example.com/1000/output")
+			return maker
+		},
+		wantErr: true,
+	}, {
+		name: "neither pull request nor reference",
+		createStatusMaker: func() *StatusMaker {
+			return NewStatusMaker("octocat/hello-world", "")
+		},
+		wantErr: true,
 	}, {
 		name: "invalid go scm provider",
 		createStatusMaker: func() *StatusMaker {
@@ -131,6 +173,14 @@ func TestCreatePullRequestStatus(t *testing.T) {
 			return maker
 		},
 		wantErr: true,
+	}, {
+		name: "git provider without commit statuses",
+		createStatusMaker: func() *StatusMaker {
+			maker := NewStatusMaker("octocat/hello-world", "")
+			maker.WithProvider("gitee").WithSHA("6dcb09b5b57875f334f61aebed695e2e4193db5e")
+			return maker
+		},
+		wantErr: true,
 	}}
 	for i, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -234,6 +284,41 @@ func TestPullRequestStatusReconciler(t *testing.T) {
 	finalTime := metav1.NewTime(theTime)
 	pipRun.Status.CompletionTime = &finalTime
 
+	branchPipRun := pipRun.DeepCopy()
+	branchPipRun.Spec.SCM = &v1alpha3.SCM{RefType: v1alpha3.Branch, RefName: "master"}
+	branchPipRun.Annotations = map[string]string{
+		v1alpha3.JenkinsPipelineRunStatusAnnoKey:       `{"commitId":"6dcb09b5b57875f334f61aebed695e2e4193db5e"}`,
+		v1alpha3.JenkinsPipelineRunStagesStatusAnnoKey: `[{"displayName":"build","state":"FINISHED","result":"SUCCESS"},{"displayName":"deploy","state":"SKIPPED"}]`,
+	}
+
+	pipeline := &v1alpha3.Pipeline{}
+	pipeline.SetName("pipeline")
+	pipeline.SetNamespace(defaultReq.namespace)
+	pipeline.Annotations = map[string]string{
+		v1alpha3.PipelineStageStatusAnnoKey: "true",
+	}
+
+	noSCMPipRun := pipRun.DeepCopy()
+	noSCMPipRun.Spec.SCM = nil
+	noSCMPipRun.Spec.PipelineSpec = &v1alpha3.PipelineSpec{Type: v1alpha3.NoScmPipelineType}
+	noSCMPipRun.Annotations = map[string]string{
+		v1alpha3.PipelineRunCommitAnnoKey: "6dcb09b5b57875f334f61aebed695e2e4193db5e",
+	}
+
+	noSCMPipeline := pipeline.DeepCopy()
+	noSCMPipeline.Annotations = map[string]string{
+		v1alpha3.PipelineSCMAnnoKey: "https://github.com/octocat/hello-world.git",
+	}
+
+	gitRepo := &v1alpha3.GitRepository{}
+	gitRepo.SetName("hello-world")
+	gitRepo.SetNamespace(defaultReq.namespace)
+	gitRepo.Spec = v1alpha3.GitRepositorySpec{
+		Provider: "github",
+		URL:      "https://github.com/octocat/hello-world",
+		Secret:   &v1.SecretReference{Name: "token"},
+	}
+
 	project := &v1alpha3.DevOpsProject{}
 	project.SetName(defaultReq.namespace)
 	project.Labels = map[string]string{
@@ -283,6 +368,101 @@ func TestPullRequestStatusReconciler(t *testing.T) {
 		},
 		k8sClient: fake.NewClientBuilder().WithScheme(schema).WithRuntimeObjects(pipRun.DeepCopy(), secret.DeepCopy(), project.DeepCopy()).Build(),
 		wantErr:   false,
+	}, {
+		name:    "branch with stage status",
+		request: defaultReq,
+		prepare: func(t *testing.T) {
+			gock.New("https://api.github.com").
+				Get("/repos/octocat/hello-world/statuses/6dcb09b5b57875f334f61aebed695e2e4193db5e").
+				MatchParam("page", "1").
+				MatchParam("per_page", "100").
+				Times(2).
+				Reply(200).
+				Type("application/json").
+				SetHeaders(mockHeaders).
+				BodyString("[]")
+
+			gock.New("https://api.github.com").
+				Post("/repos/octocat/hello-world/statuses/6dcb09b5b57875f334f61aebed695e2e4193db5e").
+				Times(2).
+				Reply(201).
+				Type("application/json").
+				SetHeaders(mockHeaders).
+				File("testdata/status.json")
+		},
+		k8sClient: fake.NewClientBuilder().WithScheme(schema).WithRuntimeObjects(branchPipRun.DeepCopy(),
+			pipeline.DeepCopy(), secret.DeepCopy(), project.DeepCopy()).Build(),
+		wantErr: false,
+	}, {
+		name:    "branch without the commit",
+		request: defaultReq,
+		k8sClient: fake.NewClientBuilder().WithScheme(schema).WithRuntimeObjects(func() *v1alpha3.PipelineRun {
+			run := branchPipRun.DeepCopy()
+			delete(run.Annotations, v1alpha3.JenkinsPipelineRunStatusAnnoKey)
+			return run
+		}(), pipeline.DeepCopy(), secret.DeepCopy(), project.DeepCopy()).Build(),
+		wantErr: false,
+	}, {
+		name:    "non multi-branch pipeline triggered by a webhook",
+		request: defaultReq,
+		prepare: func(t *testing.T) {
+			gock.New("https://api.github.com").
+				Get("/repos/octocat/hello-world/statuses/6dcb09b5b57875f334f61aebed695e2e4193db5e").
+				MatchParam("page", "1").
+				MatchParam("per_page", "100").
+				Reply(200).
+				Type("application/json").
+				SetHeaders(mockHeaders).
+				BodyString("[]")
+
+			gock.New("https://api.github.com").
+				Post("/repos/octocat/hello-world/statuses/6dcb09b5b57875f334f61aebed695e2e4193db5e").
+				Reply(201).
+				Type("application/json").
+				SetHeaders(mockHeaders).
+				File("testdata/status.json")
+		},
+		k8sClient: fake.NewClientBuilder().WithScheme(schema).WithRuntimeObjects(noSCMPipRun.DeepCopy(),
+			noSCMPipeline.DeepCopy(), gitRepo.DeepCopy(), secret.DeepCopy(), project.DeepCopy()).Build(),
+		wantErr: false,
+	}, {
+		name:    "secret text credential",
+		request: defaultReq,
+		prepare: func(t *testing.T) {
+			gock.New("https://api.github.com").
+				Get("/repos/octocat/hello-world/statuses/6dcb09b5b57875f334f61aebed695e2e4193db5e").
+				MatchHeader("Authorization", "fake-token").
+				MatchParam("page", "1").
+				MatchParam("per_page", "100").
+				Reply(200).
+				Type("application/json").
+				SetHeaders(mockHeaders).
+				BodyString("[]")
+
+			gock.New("https://api.github.com").
+				Post("/repos/octocat/hello-world/statuses/6dcb09b5b57875f334f61aebed695e2e4193db5e").
+				MatchHeader("Authorization", "fake-token").
+				Reply(201).
+				Type("application/json").
+				SetHeaders(mockHeaders).
+				File("testdata/status.json")
+		},
+		k8sClient: fake.NewClientBuilder().WithScheme(schema).WithRuntimeObjects(noSCMPipRun.DeepCopy(),
+			noSCMPipeline.DeepCopy(), gitRepo.DeepCopy(), &v1.Secret{
+				ObjectMeta: metav1.ObjectMeta{Name: "token", Namespace: defaultReq.namespace},
+				Type:       v1alpha3.SecretTypeSecretText,
+				Data:       map[string][]byte{"secret": []byte("fake-token")},
+			}, project.DeepCopy()).Build(),
+		wantErr: false,
+	}, {
+		name:    "non multi-branch pipeline without the commit",
+		request: defaultReq,
+		k8sClient: fake.NewClientBuilder().WithScheme(schema).WithRuntimeObjects(func() *v1alpha3.PipelineRun {
+			run := noSCMPipRun.DeepCopy()
+			run.Annotations = nil
+			return run
+		}(), noSCMPipeline.DeepCopy(), gitRepo.DeepCopy(), secret.DeepCopy(), project.DeepCopy()).Build(),
+		wantErr: false,
 	}}
 	for i, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -304,6 +484,7 @@ func TestPullRequestStatusReconciler(t *testing.T) {
 			})
 
 			assert.Equal(t, tt.wantResult, result)
+			assert.True(t, gock.IsDone(), "should send all the expected requests in case [%s]-[%d]", tt.name, i)
 			if tt.wantErr {
 				assert.NotNil(t, err, "should have error in case [%s]-[%d]", tt.name, i)
 			} else {
@@ -445,92 +626,315 @@ func TestGetRepoInfo(t *testing.T) {
 	}
 }
 
-func Test_getPipelineRunNameAndNsFromURL(t *testing.T) {
-	type args struct {
-		link string
-	}
-	tests := []struct {
-		name     string
-		args     args
-		wantName string
-		wantNs   string
-		wantErr  assert.ErrorAssertionFunc
-	}{{
-		name: "normal",
-		args: args{
-			link: "http://ip:port/ks-devops-core/clusters/host/devops/core58fgv/pipelines/ks-devops/branch/PR-816/run/ks-devops-pzdcz/task-status",
-		},
-		wantName: "ks-devops-pzdcz",
-		wantNs:   "core58fgv",
-		wantErr: func(t assert.TestingT, err error, i ...interface{}) bool {
-			return true
-		},
-	}}
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			gotName, gotNs, err := getPipelineRunNameAndNsFromURL(tt.args.link)
-			if !tt.wantErr(t, err, fmt.Sprintf("getPipelineRunNameAndNsFromURL(%v)", tt.args.link)) {
-				return
-			}
-			assert.Equalf(t, tt.wantName, gotName, "getPipelineRunNameAndNsFromURL(%v)", tt.args.link)
-			assert.Equalf(t, tt.wantNs, gotNs, "getPipelineRunNameAndNsFromURL(%v)", tt.args.link)
-		})
-	}
-}
-
 func TestCreateExpirationCheckFunc(t *testing.T) {
 	schema, err := v1alpha3.SchemeBuilder.Register().Build()
 	assert.Nil(t, err)
 	err = v1.SchemeBuilder.AddToScheme(schema)
 	assert.Nil(t, err)
 
-	previousStatus := &scm.Status{Target: "http://ip:port/ks-devops-core/clusters/host/devops/core58fgv/pipelines/ks-devops/branch/PR-816/run/ks-devops-pzdcz/task-status"}
+	const sha = "6dcb09b5b57875f334f61aebed695e2e4193db5e"
+	previousStatus := &scm.Status{Desc: "Successful in 1m"}
 
-	previousPipelineRun := &v1alpha3.PipelineRun{
+	newerPipelineRun := &v1alpha3.PipelineRun{
+		Spec: v1alpha3.PipelineRunSpec{
+			SCM: &v1alpha3.SCM{RefName: "PR-816"},
+		},
 		Status: v1alpha3.PipelineRunStatus{
 			StartTime: &metav1.Time{Time: time.Now()},
 		},
 	}
-	previousPipelineRun.SetName("ks-devops-pzdcz")
-	previousPipelineRun.SetNamespace("core58fgv")
+	newerPipelineRun.SetName("ks-devops-pzdcz")
+	newerPipelineRun.SetNamespace("ns")
+	newerPipelineRun.SetLabels(map[string]string{v1alpha3.PipelineNameLabelKey: "ks-devops"})
+	newerPipelineRun.SetAnnotations(map[string]string{v1alpha3.PipelineRunStatusCommitAnnoKey: sha})
 
-	currentPipelineRun := &v1alpha3.PipelineRun{
-		Status: v1alpha3.PipelineRunStatus{
-			StartTime: &metav1.Time{Time: time.Now().Add(time.Minute)},
-		},
-	}
+	currentPipelineRun := newerPipelineRun.DeepCopy()
+	currentPipelineRun.SetName("ks-devops-abcde")
+	currentPipelineRun.Status.StartTime = &metav1.Time{Time: time.Now().Add(-time.Minute)}
+
+	otherCommitPipelineRun := newerPipelineRun.DeepCopy()
+	otherCommitPipelineRun.Annotations[v1alpha3.PipelineRunStatusCommitAnnoKey] = "7fd1a60b01f91b314f59955a4e4d4e80d8edf11d"
+
+	otherBranchPipelineRun := newerPipelineRun.DeepCopy()
+	otherBranchPipelineRun.Spec.SCM.RefName = "master"
+
+	otherPipelinePipelineRun := newerPipelineRun.DeepCopy()
+	otherPipelinePipelineRun.Labels[v1alpha3.PipelineNameLabelKey] = "other"
+
+	olderPipelineRun := newerPipelineRun.DeepCopy()
+	olderPipelineRun.Status.StartTime = &metav1.Time{Time: time.Now().Add(-time.Hour)}
 
 	tests := []struct {
 		name               string
 		k8sClient          client.Client
 		currentPipelineRun *v1alpha3.PipelineRun
 		previousStatus     *scm.Status
-		currentStatus      *scm.StatusInput
 		wantBool           bool
 	}{{
-		name:               "cannot find previous PipelineRun",
+		name:               "no previous status",
+		k8sClient:          fake.NewClientBuilder().WithScheme(schema).WithObjects(newerPipelineRun.DeepCopy()).Build(),
+		currentPipelineRun: currentPipelineRun.DeepCopy(),
+		wantBool:           false,
+	}, {
+		name:               "no other PipelineRuns",
 		k8sClient:          fake.NewClientBuilder().WithScheme(schema).Build(),
 		currentPipelineRun: currentPipelineRun.DeepCopy(),
 		previousStatus:     previousStatus,
 		wantBool:           false,
 	}, {
-		name:               "the current PipelineRun is newer",
-		k8sClient:          fake.NewClientBuilder().WithScheme(schema).WithObjects(previousPipelineRun.DeepCopy()).Build(),
+		name:               "a newer PipelineRun sent the statuses of the same commit",
+		k8sClient:          fake.NewClientBuilder().WithScheme(schema).WithObjects(newerPipelineRun.DeepCopy()).Build(),
+		previousStatus:     previousStatus,
+		currentPipelineRun: currentPipelineRun.DeepCopy(),
+		wantBool:           true,
+	}, {
+		name:               "the other PipelineRun is older",
+		k8sClient:          fake.NewClientBuilder().WithScheme(schema).WithObjects(olderPipelineRun.DeepCopy()).Build(),
 		previousStatus:     previousStatus,
 		currentPipelineRun: currentPipelineRun.DeepCopy(),
 		wantBool:           false,
 	}, {
-		name:               "previous and the current one belong to the same PipelineRun",
-		k8sClient:          fake.NewClientBuilder().WithScheme(schema).WithObjects(previousPipelineRun.DeepCopy()).Build(),
+		name:               "the newer PipelineRun sent the statuses of another commit",
+		k8sClient:          fake.NewClientBuilder().WithScheme(schema).WithObjects(otherCommitPipelineRun.DeepCopy()).Build(),
 		previousStatus:     previousStatus,
-		currentPipelineRun: previousPipelineRun.DeepCopy(),
+		currentPipelineRun: currentPipelineRun.DeepCopy(),
+		wantBool:           false,
+	}, {
+		name:               "the newer PipelineRun belongs to another branch",
+		k8sClient:          fake.NewClientBuilder().WithScheme(schema).WithObjects(otherBranchPipelineRun.DeepCopy()).Build(),
+		previousStatus:     previousStatus,
+		currentPipelineRun: currentPipelineRun.DeepCopy(),
+		wantBool:           false,
+	}, {
+		name:               "the newer PipelineRun belongs to another Pipeline",
+		k8sClient:          fake.NewClientBuilder().WithScheme(schema).WithObjects(otherPipelinePipelineRun.DeepCopy()).Build(),
+		previousStatus:     previousStatus,
+		currentPipelineRun: currentPipelineRun.DeepCopy(),
+		wantBool:           false,
+	}, {
+		name:               "the same PipelineRun",
+		k8sClient:          fake.NewClientBuilder().WithScheme(schema).WithObjects(newerPipelineRun.DeepCopy()).Build(),
+		previousStatus:     previousStatus,
+		currentPipelineRun: newerPipelineRun.DeepCopy(),
 		wantBool:           false,
 	}}
 	for i, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			checkFunc := createExpirationCheckFunc(context.TODO(), tt.k8sClient, tt.currentPipelineRun)
-			result := checkFunc(tt.previousStatus, tt.currentStatus)
+			result := checkFunc(sha, tt.previousStatus, &scm.StatusInput{Desc: "Running"})
 			assert.Equal(t, tt.wantBool, result, "failed in case [%d]", i)
 		})
 	}
 }
+
+func TestPullRequestStatusReconciler_recordStatusCommit(t *testing.T) {
+	schema, err := v1alpha3.SchemeBuilder.Register().Build()
+	assert.Nil(t, err)
+
+	pipelineRun := &v1alpha3.PipelineRun{}
+	pipelineRun.SetName("fake")
+	pipelineRun.SetNamespace("ns")
+	k8sClient := fake.NewClientBuilder().WithScheme(schema).WithObjects(pipelineRun.DeepCopy()).Build()
+	recon := &PullRequestStatusReconciler{Client: k8sClient}
+
+	run := &v1alpha3.PipelineRun{}
+	assert.Nil(t, k8sClient.Get(context.TODO(), types.NamespacedName{Namespace: "ns", Name: "fake"}, run))
+	assert.Nil(t, recon.recordStatusCommit(context.TODO(), run, "6dcb09b5b57875f334f61aebed695e2e4193db5e"))
+
+	result := &v1alpha3.PipelineRun{}
+	assert.Nil(t, k8sClient.Get(context.TODO(), types.NamespacedName{Namespace: "ns", Name: "fake"}, result))
+	assert.Equal(t, "6dcb09b5b57875f334f61aebed695e2e4193db5e", result.Annotations[v1alpha3.PipelineRunStatusCommitAnnoKey])
+	// nothing changed
+	assert.Nil(t, recon.recordStatusCommit(context.TODO(), result, "6dcb09b5b57875f334f61aebed695e2e4193db5e"))
+}
+
+func TestPullRequestStatusReconciler_getExternalPipelineRunAddress(t *testing.T) {
+	schema, err := v1alpha3.SchemeBuilder.Register().Build()
+	assert.Nil(t, err)
+
+	project := &v1alpha3.DevOpsProject{}
+	project.SetName("ns")
+	project.Labels = map[string]string{
+		"kubesphere.io/workspace": "ws",
+	}
+
+	multiBranchPipelineRun := &v1alpha3.PipelineRun{
+		Spec: v1alpha3.PipelineRunSpec{
+			PipelineRef: &v1.ObjectReference{Name: "pipeline"},
+			SCM:         &v1alpha3.SCM{RefName: "PR-1"},
+		},
+	}
+	multiBranchPipelineRun.SetName("run")
+	multiBranchPipelineRun.SetNamespace("ns")
+
+	plainPipelineRun := multiBranchPipelineRun.DeepCopy()
+	plainPipelineRun.Spec.SCM = nil
+
+	tests := []struct {
+		name          string
+		consoleOption *config.ConsoleOption
+		pipelineRun   *v1alpha3.PipelineRun
+		wantTarget    string
+	}{{
+		name:        "multi-branch Pipeline with the default template",
+		pipelineRun: multiBranchPipelineRun,
+		wantTarget:  "https://ks.com/ws/clusters/host/devops/ns/pipelines/pipeline/branch/PR-1/run/run/task-status",
+	}, {
+		name:        "plain Pipeline with the default template",
+		pipelineRun: plainPipelineRun,
+		wantTarget:  "https://ks.com/ws/clusters/host/devops/ns/pipelines/pipeline/run/run/task-status",
+	}, {
+		name: "multi-branch Pipeline with a custom template",
+		consoleOption: &config.ConsoleOption{
+			MultiBranchPipelineRunURLTemplate: "{address}/console/{namespace}/{pipeline}/{branch}/{run}",
+		},
+		pipelineRun: multiBranchPipelineRun,
+		wantTarget:  "https://ks.com/console/ns/pipeline/PR-1/run",
+	}, {
+		name: "plain Pipeline with a custom template",
+		consoleOption: &config.ConsoleOption{
+			PipelineRunURLTemplate: "{address}/console/{workspace}/{namespace}/{pipeline}/{run}",
+		},
+		pipelineRun: plainPipelineRun,
+		wantTarget:  "https://ks.com/console/ws/ns/pipeline/run",
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := &PullRequestStatusReconciler{
+				Client:          fake.NewClientBuilder().WithScheme(schema).WithObjects(project.DeepCopy()).Build(),
+				ExternalAddress: "ks.com",
+				ClusterName:     "host",
+				ConsoleOption:   tt.consoleOption,
+			}
+			target, err := r.getExternalPipelineRunAddress(context.TODO(), tt.pipelineRun)
+			assert.Nil(t, err)
+			assert.Equal(t, tt.wantTarget, target)
+		})
+	}
+}
+
+func Test_getPRNumberFromSCM(t *testing.T) {
+	tests := []struct {
+		name       string
+		scmRef     *v1alpha3.SCM
+		wantNumber int
+		wantOk     bool
+	}{{
+		name:       "pull request without reference type",
+		scmRef:     &v1alpha3.SCM{RefName: "PR-1"},
+		wantNumber: 1,
+		wantOk:     true,
+	}, {
+		name:       "merge request",
+		scmRef:     &v1alpha3.SCM{RefType: v1alpha3.MergeRequest, RefName: "MR-2"},
+		wantNumber: 2,
+		wantOk:     true,
+	}, {
+		name:   "branch",
+		scmRef: &v1alpha3.SCM{RefName: "master"},
+		wantOk: false,
+	}, {
+		name:   "branch looks like a pull request",
+		scmRef: &v1alpha3.SCM{RefType: v1alpha3.Branch, RefName: "pr-1"},
+		wantOk: false,
+	}, {
+		name:   "tag",
+		scmRef: &v1alpha3.SCM{RefType: v1alpha3.Tag, RefName: "v1.0.0"},
+		wantOk: false,
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			number, ok := getPRNumberFromSCM(tt.scmRef)
+			assert.Equal(t, tt.wantNumber, number)
+			assert.Equal(t, tt.wantOk, ok)
+		})
+	}
+}
+
+func Test_getCommitSHA(t *testing.T) {
+	tests := []struct {
+		name        string
+		annotations map[string]string
+		wantSHA     string
+	}{{
+		name:    "no annotations",
+		wantSHA: "",
+	}, {
+		name: "from the cause",
+		annotations: map[string]string{
+			v1alpha3.PipelineRunCommitAnnoKey:        "abc",
+			v1alpha3.JenkinsPipelineRunStatusAnnoKey: `{"commitId":"def"}`,
+		},
+		wantSHA: "abc",
+	}, {
+		name: "from the run data",
+		annotations: map[string]string{
+			v1alpha3.JenkinsPipelineRunStatusAnnoKey: `{"commitId":"def"}`,
+		},
+		wantSHA: "def",
+	}, {
+		name: "from the change set",
+		annotations: map[string]string{
+			v1alpha3.JenkinsPipelineRunStatusAnnoKey: `{"changeSet":[{"commitId":"first"},{"commitId":"last"}]}`,
+		},
+		wantSHA: "last",
+	}, {
+		name: "invalid run data",
+		annotations: map[string]string{
+			v1alpha3.JenkinsPipelineRunStatusAnnoKey: `invalid`,
+		},
+		wantSHA: "",
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pipelineRun := &v1alpha3.PipelineRun{}
+			pipelineRun.Annotations = tt.annotations
+			assert.Equal(t, tt.wantSHA, getCommitSHA(pipelineRun))
+		})
+	}
+}
+
+func Test_convertNodeToSCMStatus(t *testing.T) {
+	tests := []struct {
+		name       string
+		node       job.Node
+		wantStatus scm.State
+		wantOk     bool
+	}{{
+		name:       "success",
+		node:       job.Node{State: "FINISHED", Result: "SUCCESS"},
+		wantStatus: scm.StateSuccess,
+		wantOk:     true,
+	}, {
+		name:       "unstable",
+		node:       job.Node{State: "FINISHED", Result: "UNSTABLE"},
+		wantStatus: scm.StateFailure,
+		wantOk:     true,
+	}, {
+		name:       "aborted",
+		node:       job.Node{State: "FINISHED", Result: "ABORTED"},
+		wantStatus: scm.StateCanceled,
+		wantOk:     true,
+	}, {
+		name:       "running",
+		node:       job.Node{State: "RUNNING", Result: "UNKNOWN"},
+		wantStatus: scm.StateRunning,
+		wantOk:     true,
+	}, {
+		name:       "queued",
+		node:       job.Node{State: "QUEUED"},
+		wantStatus: scm.StatePending,
+		wantOk:     true,
+	}, {
+		name:   "skipped",
+		node:   job.Node{State: "SKIPPED", Result: "NOT_BUILT"},
+		wantOk: false,
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status, ok := convertNodeToSCMStatus(tt.node)
+			assert.Equal(t, tt.wantStatus, status)
+			assert.Equal(t, tt.wantOk, ok)
+		})
+	}
+}
@@ -23,5 +23,14 @@ func GetReconcilers(k8s client.Client) core.GroupedReconcilers {
 		&AmendReconciler{
 			Client: k8s,
 		},
+		&ProbeReconciler{
+			Client: k8s,
+		},
+		&Reconciler{
+			Client: k8s,
+		},
+		&PipelineAsCodeReconciler{
+			Client: k8s,
+		},
 	}
 }
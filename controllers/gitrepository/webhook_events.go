@@ -0,0 +1,212 @@
+/*
+Copyright 2022 The KubeSphere Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gitrepository
+
+import (
+	"sort"
+
+	"github.com/jenkins-x/go-scm/scm"
+)
+
+// the provider-agnostic events which are supported in the Webhook
+const (
+	webhookEventAll                = "*"
+	webhookEventPush               = "push"
+	webhookEventTag                = "tag"
+	webhookEventBranch             = "branch"
+	webhookEventPullRequest        = "pull_request"
+	webhookEventPullRequestComment = "pull_request_comment"
+	webhookEventReview             = "review"
+	webhookEventIssue              = "issue"
+	webhookEventIssueComment       = "issue_comment"
+	webhookEventRelease            = "release"
+	webhookEventDeployment         = "deployment"
+)
+
+// getHookEvents maps the events of a Webhook to the events of go-scm.
+// The events which are not provider-agnostic are taken as the native events of the git provider.
+// Only the push event is subscribed if there are no events.
+func getHookEvents(events []string) (hookEvents scm.HookEvents, nativeEvents []string) {
+	if len(events) == 0 {
+		hookEvents.Push = true
+		return
+	}
+
+	for _, event := range events {
+		switch event {
+		case webhookEventAll, "all":
+			hookEvents = scm.HookEvents{
+				Branch: true, Deployment: true, Issue: true, IssueComment: true, PullRequest: true,
+				PullRequestComment: true, Push: true, Release: true, Review: true, Tag: true,
+			}
+		case webhookEventPush:
+			hookEvents.Push = true
+		case webhookEventTag:
+			hookEvents.Tag = true
+		case webhookEventBranch:
+			hookEvents.Branch = true
+		case webhookEventPullRequest:
+			hookEvents.PullRequest = true
+		case webhookEventPullRequestComment:
+			hookEvents.PullRequestComment = true
+		case webhookEventReview:
+			hookEvents.Review = true
+		case webhookEventIssue:
+			hookEvents.Issue = true
+		case webhookEventIssueComment:
+			hookEvents.IssueComment = true
+		case webhookEventRelease:
+			hookEvents.Release = true
+		case webhookEventDeployment:
+			hookEvents.Deployment = true
+		default:
+			nativeEvents = append(nativeEvents, event)
+		}
+	}
+	return
+}
+
+// getProviderEvents returns the events which the git provider reports when listing the hooks.
+// It returns nil if the events of the provider cannot be compared.
+func getProviderEvents(provider string, hookEvents scm.HookEvents, nativeEvents []string) (events []string) {
+	switch provider {
+	case "github":
+		events = append(events, nativeEvents...)
+		if hookEvents.Push {
+			events = append(events, "push")
+		}
+		if hookEvents.PullRequest {
+			events = append(events, "pull_request")
+		}
+		if hookEvents.Review {
+			events = append(events, "pull_request_review")
+		}
+		if hookEvents.PullRequestComment {
+			events = append(events, "pull_request_review_comment")
+		}
+		if hookEvents.Issue {
+			events = append(events, "issues")
+		}
+		if hookEvents.IssueComment || hookEvents.PullRequestComment {
+			events = append(events, "issue_comment")
+		}
+		if hookEvents.Branch || hookEvents.Tag {
+			events = append(events, "create", "delete")
+		}
+		if hookEvents.Deployment {
+			events = append(events, "deployment")
+		}
+		if hookEvents.Release {
+			events = append(events, "release")
+		}
+	case "gitlab":
+		all := false
+		for _, event := range nativeEvents {
+			all = all || event == webhookEventAll
+		}
+		// GitLab has no release event in the listed hooks
+		if hookEvents.Issue || all {
+			events = append(events, "issues")
+		}
+		if hookEvents.Tag || all {
+			events = append(events, "tag")
+		}
+		if hookEvents.Push || hookEvents.Branch || all {
+			events = append(events, "push")
+		}
+		if hookEvents.IssueComment || hookEvents.PullRequestComment || all {
+			events = append(events, "comment")
+		}
+		if hookEvents.PullRequest || all {
+			events = append(events, "merge")
+		}
+	case "gitee":
+		// the native events of Gitee are the same as the ones which are reported
+		for _, event := range nativeEvents {
+			switch event {
+			case "push", "tag_push", "issues", "note", "merge_requests":
+				events = append(events, event)
+			}
+		}
+		if hookEvents.Push || hookEvents.Branch {
+			events = append(events, "push")
+		}
+		if hookEvents.Tag {
+			events = append(events, "tag_push")
+		}
+		if hookEvents.Issue {
+			events = append(events, "issues")
+		}
+		if hookEvents.IssueComment || hookEvents.PullRequestComment {
+			events = append(events, "note")
+		}
+		if hookEvents.PullRequest {
+			events = append(events, "merge_requests")
+		}
+	default:
+		return nil
+	}
+	return normalizeEvents(events)
+}
+
+// normalizeEvents sorts the events and removes the duplicated ones
+func normalizeEvents(events []string) []string {
+	result := make([]string, 0, len(events))
+	exists := map[string]bool{}
+	for _, event := range events {
+		if !exists[event] {
+			exists[event] = true
+			result = append(result, event)
+		}
+	}
+	sort.Strings(result)
+	return result
+}
+
+// isHookDrifted checks if the hook on the git provider is different from the desired one
+func isHookDrifted(provider string, hook *scm.Hook, events []string, skipVerify bool) bool {
+	if events != nil && !equalEvents(normalizeEvents(hook.Events), events) {
+		return true
+	}
+	switch provider {
+	case "github":
+		return hook.SkipVerify != skipVerify
+	case "gitlab":
+		// go-scm is only able to enable the SSL verification of GitLab, see also getHookSkipVerify
+		return !skipVerify && hook.SkipVerify
+	}
+	return false
+}
+
+// getHookSkipVerify returns the SkipVerify of the hook input.
+// go-scm enables the SSL verification of GitLab if SkipVerify is true, so it is inverted for GitLab.
+// The SSL verification of GitLab is enabled by default if SkipVerify is false.
+func getHookSkipVerify(provider string, skipVerify bool) bool {
+	if provider == "gitlab" {
+		return !skipVerify
+	}
+	return skipVerify
+}
+
+func equalEvents(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,95 @@
+/*
+Copyright 2022 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gitrepository
+
+import (
+	"testing"
+
+	"github.com/jenkins-x/go-scm/scm"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_getProviderEvents(t *testing.T) {
+	tests := []struct {
+		name     string
+		provider string
+		events   []string
+		want     []string
+	}{{
+		name:     "no events",
+		provider: "github",
+		want:     []string{"push"},
+	}, {
+		name:     "GitHub with the provider-agnostic events",
+		provider: "github",
+		events:   []string{"push", "tag", "pull_request", "issue_comment"},
+		want:     []string{"create", "delete", "issue_comment", "pull_request", "push"},
+	}, {
+		name:     "GitHub with the native events",
+		provider: "github",
+		events:   []string{"push", "workflow_run"},
+		want:     []string{"push", "workflow_run"},
+	}, {
+		name:     "GitLab with the provider-agnostic events",
+		provider: "gitlab",
+		events:   []string{"push", "branch", "tag", "pull_request", "pull_request_comment"},
+		want:     []string{"comment", "merge", "push", "tag"},
+	}, {
+		name:     "GitLab with all the events",
+		provider: "gitlab",
+		events:   []string{"*"},
+		want:     []string{"comment", "issues", "merge", "push", "tag"},
+	}, {
+		name:     "Gitee with the provider-agnostic and native events",
+		provider: "gitee",
+		events:   []string{"branch", "pull_request", "issue_comment", "tag_push"},
+		want:     []string{"merge_requests", "note", "push", "tag_push"},
+	}, {
+		name:     "not comparable provider",
+		provider: "bitbucket",
+		events:   []string{"push"},
+		want:     nil,
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hookEvents, nativeEvents := getHookEvents(tt.events)
+			assert.Equal(t, tt.want, getProviderEvents(tt.provider, hookEvents, nativeEvents))
+		})
+	}
+}
+
+func Test_getHookSkipVerify(t *testing.T) {
+	assert.True(t, getHookSkipVerify("github", true))
+	assert.False(t, getHookSkipVerify("github", false))
+	// go-scm inverts the SSL verification of GitLab
+	assert.False(t, getHookSkipVerify("gitlab", true))
+	assert.True(t, getHookSkipVerify("gitlab", false))
+}
+
+func Test_isHookDrifted(t *testing.T) {
+	hook := &scm.Hook{Events: []string{"push", "pull_request"}, SkipVerify: true}
+	assert.False(t, isHookDrifted("github", hook, []string{"pull_request", "push"}, true))
+	assert.True(t, isHookDrifted("github", hook, []string{"push"}, true))
+	assert.True(t, isHookDrifted("github", hook, []string{"pull_request", "push"}, false))
+	// the SSL verification of GitLab can only be enabled
+	assert.True(t, isHookDrifted("gitlab", hook, []string{"pull_request", "push"}, false))
+	assert.False(t, isHookDrifted("gitlab", hook, []string{"pull_request", "push"}, true))
+	assert.False(t, isHookDrifted("gitlab", &scm.Hook{Events: hook.Events}, []string{"pull_request", "push"}, true))
+	assert.False(t, isHookDrifted("gitlab", &scm.Hook{Events: hook.Events}, []string{"pull_request", "push"}, false))
+	// the events are not comparable
+	assert.False(t, isHookDrifted("gitea", hook, nil, true))
+}
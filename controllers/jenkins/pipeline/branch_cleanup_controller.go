@@ -0,0 +1,233 @@
+/*
+Copyright 2022 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pipeline
+
+import (
+	"context"
+	"net/url"
+
+	"github.com/go-logr/logr"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/client-go/util/retry"
+	"kubesphere.io/devops/pkg/api/devops/v1alpha3"
+	modelpipeline "kubesphere.io/devops/pkg/models/pipeline"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+)
+
+const (
+	// BranchRunsCleaned indicates the PipelineRuns of the removed branches have been cleaned up
+	BranchRunsCleaned = "BranchRunsCleaned"
+	// FailedBranchRunsCleanup indicates the controller fails to clean up the PipelineRuns of the removed branches
+	FailedBranchRunsCleanup = "FailedBranchRunsCleanup"
+)
+
+//+kubebuilder:rbac:groups=devops.kubesphere.io,resources=pipelines,verbs=get;list;watch
+//+kubebuilder:rbac:groups=devops.kubesphere.io,resources=pipelineruns,verbs=get;list;watch;update;delete
+//+kubebuilder:rbac:groups=devops.kubesphere.io,resources=pipelineruns/status,verbs=get;update;patch
+//+kubebuilder:rbac:groups="",resources=events,verbs=create;patch
+
+// BranchCleanupReconciler archives or deletes the PipelineRuns of the branches which are removed
+// from a multi-branch Pipeline. The branches come from the Jenkins scan results and the SCM events.
+type BranchCleanupReconciler struct {
+	client.Client
+	log      logr.Logger
+	recorder record.EventRecorder
+}
+
+// Reconcile is the main entrypoint of this controller
+func (r *BranchCleanupReconciler) Reconcile(ctx context.Context, req ctrl.Request) (result ctrl.Result, err error) {
+	log := r.log.WithValues("Pipeline", req.NamespacedName)
+	pipeline := &v1alpha3.Pipeline{}
+	if err = r.Get(ctx, req.NamespacedName, pipeline); err != nil {
+		err = client.IgnoreNotFound(err)
+		return
+	}
+
+	branchesJSON, ok := pipeline.Annotations[v1alpha3.PipelineJenkinsBranchesAnnoKey]
+	if !pipeline.IsMultiBranch() || !ok {
+		// skip it until the branches are synchronized from Jenkins
+		return
+	}
+	var branches modelpipeline.BranchSlice
+	if branches, err = modelpipeline.GetBranchSlice(branchesJSON); err != nil {
+		log.Error(err, "unable to parse the branches of Pipeline")
+		err = nil
+		return
+	}
+
+	pipelineRuns := &v1alpha3.PipelineRunList{}
+	if err = r.List(ctx, pipelineRuns, client.InNamespace(pipeline.Namespace),
+		client.MatchingLabels{v1alpha3.PipelineNameLabelKey: pipeline.Name}); err != nil {
+		return
+	}
+
+	policy := pipeline.GetBranchCleanupPolicy()
+	removedBranches := pipeline.GetRemovedBranches()
+	lastScanTime := pipeline.GetLastScanTime()
+	var errs []error
+	count := 0
+	for i := range pipelineRuns.Items {
+		pipelineRun := &pipelineRuns.Items[i]
+		if !needCleanup(pipelineRun, policy, branches, removedBranches, lastScanTime) {
+			continue
+		}
+
+		if cleanupErr := r.cleanupPipelineRun(ctx, pipelineRun, policy); cleanupErr != nil {
+			errs = append(errs, cleanupErr)
+			// continue next cleanup
+		} else {
+			count++
+		}
+	}
+
+	if err = utilerrors.NewAggregate(errs); err != nil {
+		log.Error(err, "failed to clean up all of the PipelineRuns of the removed branches")
+		r.recorder.Eventf(pipeline, v1.EventTypeWarning, FailedBranchRunsCleanup,
+			"Failed to clean up the PipelineRuns of the removed branches, err = %v", err)
+	}
+	if count > 0 {
+		log.Info("cleaned up the PipelineRuns of the removed branches", "policy", policy, "count", count)
+		r.recorder.Eventf(pipeline, v1.EventTypeNormal, BranchRunsCleaned,
+			"Cleaned up %d PipelineRun(s) of the removed branches with policy %s", count, policy)
+	}
+	return
+}
+
+// needCleanup checks if the PipelineRun belongs to a removed branch and has not been cleaned up yet
+func needCleanup(pipelineRun *v1alpha3.PipelineRun, policy string, branches modelpipeline.BranchSlice,
+	removedBranches []string, lastScanTime *metav1.Time) bool {
+	if pipelineRun.Spec.SCM == nil || pipelineRun.Spec.SCM.RefName == "" || !pipelineRun.DeletionTimestamp.IsZero() {
+		return false
+	}
+	if pipelineRun.IsBranchRemoved() {
+		// the archived PipelineRuns are deleted once the policy is changed to Delete
+		return policy == v1alpha3.BranchCleanupPolicyDelete
+	}
+	return isBranchRemoved(pipelineRun, branches, removedBranches, lastScanTime)
+}
+
+// isBranchRemoved checks if the branch of the PipelineRun is removed according to the SCM events, or is disabled in
+// Jenkins. The disabled branches are the orphaned items of Jenkins which are removed from the SCM.
+// A branch which does not exist in Jenkins might not be discovered yet, so it is taken as removed only if
+// the PipelineRun was created before the latest finished scan.
+func isBranchRemoved(pipelineRun *v1alpha3.PipelineRun, branches modelpipeline.BranchSlice, removedBranches []string,
+	lastScanTime *metav1.Time) bool {
+	refName := pipelineRun.Spec.SCM.RefName
+	unescapedRefName, _ := url.PathUnescape(refName)
+	for _, removedBranch := range removedBranches {
+		if removedBranch == refName || removedBranch == unescapedRefName {
+			return true
+		}
+	}
+	for i := range branches {
+		if branches[i].MatchName(refName) {
+			return branches[i].Disabled
+		}
+	}
+	return lastScanTime != nil && pipelineRun.CreationTimestamp.Before(lastScanTime)
+}
+
+func (r *BranchCleanupReconciler) cleanupPipelineRun(ctx context.Context, pipelineRun *v1alpha3.PipelineRun, policy string) (err error) {
+	key := client.ObjectKeyFromObject(pipelineRun)
+	// label it first, then the PipelineRun controller does not try to delete the history of the removed Jenkins job
+	if err = r.labelAsBranchRemoved(ctx, key); err != nil || policy != v1alpha3.BranchCleanupPolicyDelete {
+		return
+	}
+	return client.IgnoreNotFound(r.Delete(ctx, pipelineRun))
+}
+
+func (r *BranchCleanupReconciler) labelAsBranchRemoved(ctx context.Context, key client.ObjectKey) error {
+	return retry.RetryOnConflict(retry.DefaultRetry, func() (err error) {
+		pipelineRun := &v1alpha3.PipelineRun{}
+		if err = r.Get(ctx, key, pipelineRun); err != nil {
+			return
+		}
+
+		if !pipelineRun.IsBranchRemoved() {
+			pipelineRun.LabelAsBranchRemoved()
+			if err = r.Update(ctx, pipelineRun); err != nil {
+				return
+			}
+		}
+
+		if pipelineRun.HasCompleted() || pipelineRun.Status.Phase == v1alpha3.Unknown {
+			return
+		}
+		// the uncompleted PipelineRun will never be synchronized from Jenkins
+		now := metav1.Now()
+		pipelineRun.Status.AddCondition(&v1alpha3.Condition{
+			Type:               v1alpha3.ConditionSucceeded,
+			Status:             v1alpha3.ConditionUnknown,
+			Reason:             "SKIPPED",
+			Message:            "skipped to reconcile this PipelineRun due to its branch was removed.",
+			LastTransitionTime: now,
+			LastProbeTime:      now,
+		})
+		pipelineRun.Status.Phase = v1alpha3.Unknown
+		return r.Status().Update(ctx, pipelineRun)
+	})
+}
+
+// GetName returns the name of this controller
+func (r *BranchCleanupReconciler) GetName() string {
+	return "pipeline-branch-cleanup-controller"
+}
+
+// GetGroupName returns the group name of this controller
+func (r *BranchCleanupReconciler) GetGroupName() string {
+	return ControllerGroupName
+}
+
+// branchCleanupPredicate only cares about the changes of the branches and the cleanup policy
+var branchCleanupPredicate = predicate.Funcs{
+	UpdateFunc: func(ue event.UpdateEvent) bool {
+		oldPipeline, okOld := ue.ObjectOld.(*v1alpha3.Pipeline)
+		newPipeline, okNew := ue.ObjectNew.(*v1alpha3.Pipeline)
+		if okOld && okNew {
+			for _, key := range []string{v1alpha3.PipelineJenkinsBranchesAnnoKey, v1alpha3.PipelineRemovedBranchesAnnoKey,
+				v1alpha3.PipelineBranchCleanupPolicyAnnoKey, v1alpha3.PipelineLastScanTimeAnnoKey} {
+				if oldPipeline.Annotations[key] != newPipeline.Annotations[key] {
+					return true
+				}
+			}
+		}
+		return false
+	},
+	DeleteFunc: func(de event.DeleteEvent) bool {
+		return false
+	},
+	GenericFunc: func(ge event.GenericEvent) bool {
+		return false
+	},
+}
+
+// SetupWithManager setups the log and recorder
+func (r *BranchCleanupReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	r.log = ctrl.Log.WithName(r.GetName())
+	r.recorder = mgr.GetEventRecorderFor(r.GetName())
+	return ctrl.NewControllerManagedBy(mgr).
+		Named(r.GetName()).
+		WithEventFilter(branchCleanupPredicate).
+		For(&v1alpha3.Pipeline{}).
+		Complete(r)
+}
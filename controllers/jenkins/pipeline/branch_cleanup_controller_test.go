@@ -0,0 +1,213 @@
+/*
+Copyright 2022 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pipeline
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"kubesphere.io/devops/pkg/api/devops/v1alpha3"
+	modelpipeline "kubesphere.io/devops/pkg/models/pipeline"
+	controllerruntime "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+func TestBranchCleanupReconciler_Reconcile(t *testing.T) {
+	schema, err := v1alpha3.SchemeBuilder.Register().Build()
+	assert.Nil(t, err)
+	err = v1.SchemeBuilder.AddToScheme(schema)
+	assert.Nil(t, err)
+
+	defaultReq := controllerruntime.Request{
+		NamespacedName: types.NamespacedName{Namespace: "ns", Name: "name"},
+	}
+
+	pip := &v1alpha3.Pipeline{}
+	pip.SetNamespace("ns")
+	pip.SetName("name")
+	pip.Spec.Type = v1alpha3.MultiBranchPipelineType
+	pip.Annotations = map[string]string{
+		v1alpha3.PipelineJenkinsBranchesAnnoKey: `[{"name":"master"},{"name":"feat%2Fa","rawName":"feat/a"},{"name":"old","disabled":true}]`,
+		v1alpha3.PipelineRemovedBranchesAnnoKey: `["PR-1"]`,
+		v1alpha3.PipelineLastScanTimeAnnoKey:    "2022-08-01T10:00:00Z",
+	}
+
+	deletePolicyPip := pip.DeepCopy()
+	deletePolicyPip.Annotations[v1alpha3.PipelineBranchCleanupPolicyAnnoKey] = v1alpha3.BranchCleanupPolicyDelete
+
+	notSyncedPip := pip.DeepCopy()
+	delete(notSyncedPip.Annotations, v1alpha3.PipelineJenkinsBranchesAnnoKey)
+
+	newPipelineRun := func(name, branch string) *v1alpha3.PipelineRun {
+		pipelineRun := &v1alpha3.PipelineRun{}
+		pipelineRun.SetNamespace("ns")
+		pipelineRun.SetName(name)
+		pipelineRun.SetLabels(map[string]string{v1alpha3.PipelineNameLabelKey: "name"})
+		pipelineRun.Spec.SCM = &v1alpha3.SCM{RefName: branch}
+		return pipelineRun
+	}
+	now := metav1.Now()
+	completedRun := newPipelineRun("completed", "deleted")
+	completedRun.Status.CompletionTime = &now
+	archivedRun := newPipelineRun("archived", "deleted-before")
+	archivedRun.LabelAsBranchRemoved()
+	archivedRun.Status.CompletionTime = &now
+	// the branch of a PipelineRun which is created after the last scan might not be discovered yet
+	undiscoveredRun := newPipelineRun("undiscovered", "new")
+	undiscoveredRun.CreationTimestamp = metav1.NewTime(time.Date(2022, 8, 1, 11, 0, 0, 0, time.UTC))
+	runs := []client.Object{
+		undiscoveredRun,
+		newPipelineRun("master", "master"),
+		newPipelineRun("feat", "feat%2Fa"),
+		newPipelineRun("old", "old"),
+		newPipelineRun("pr", "PR-1"),
+		completedRun,
+		archivedRun,
+	}
+
+	getRun := func(t *testing.T, c client.Client, name string) (pipelineRun *v1alpha3.PipelineRun, found bool) {
+		pipelineRun = &v1alpha3.PipelineRun{}
+		err := c.Get(context.Background(), types.NamespacedName{Namespace: "ns", Name: name}, pipelineRun)
+		assert.Nil(t, client.IgnoreNotFound(err))
+		return pipelineRun, err == nil
+	}
+
+	tests := []struct {
+		name    string
+		objects []client.Object
+		verify  func(t *testing.T, c client.Client)
+	}{{
+		name: "not found",
+	}, {
+		name:    "branches are not synchronized from Jenkins yet",
+		objects: append([]client.Object{notSyncedPip.DeepCopy()}, runs...),
+		verify: func(t *testing.T, c client.Client) {
+			run, _ := getRun(t, c, "pr")
+			assert.False(t, run.IsBranchRemoved())
+		},
+	}, {
+		name:    "archive policy",
+		objects: append([]client.Object{pip.DeepCopy()}, runs...),
+		verify: func(t *testing.T, c client.Client) {
+			for name, removed := range map[string]bool{
+				"master": false, "feat": false, "old": true, "pr": true, "completed": true, "archived": true,
+				"undiscovered": false,
+			} {
+				run, found := getRun(t, c, name)
+				assert.True(t, found, name)
+				assert.Equal(t, removed, run.IsBranchRemoved(), name)
+			}
+
+			run, _ := getRun(t, c, "pr")
+			assert.Equal(t, v1alpha3.Unknown, run.Status.Phase)
+			run, _ = getRun(t, c, "completed")
+			assert.NotEqual(t, v1alpha3.Unknown, run.Status.Phase)
+		},
+	}, {
+		name:    "delete policy",
+		objects: append([]client.Object{deletePolicyPip.DeepCopy()}, runs...),
+		verify: func(t *testing.T, c client.Client) {
+			for name, exist := range map[string]bool{
+				"master": true, "feat": true, "old": false, "pr": false, "completed": false, "archived": false,
+				"undiscovered": true,
+			} {
+				_, found := getRun(t, c, name)
+				assert.Equal(t, exist, found, name)
+			}
+		},
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := fake.NewClientBuilder().WithScheme(schema).WithObjects(tt.objects...).Build()
+			r := &BranchCleanupReconciler{
+				Client:   c,
+				log:      logr.New(log.NullLogSink{}),
+				recorder: &record.FakeRecorder{},
+			}
+			_, err := r.Reconcile(context.Background(), defaultReq)
+			assert.Nil(t, err)
+			if tt.verify != nil {
+				tt.verify(t, c)
+			}
+		})
+	}
+}
+
+func Test_isBranchRemoved(t *testing.T) {
+	branches := modelpipeline.BranchSlice{{Name: "master"}, {Name: "feat%2Fa", RawName: "feat/a"}, {Name: "old", Disabled: true}}
+	createdTime := metav1.NewTime(time.Date(2022, 8, 1, 10, 0, 0, 0, time.UTC))
+	newPipelineRun := func(refName string) *v1alpha3.PipelineRun {
+		pipelineRun := &v1alpha3.PipelineRun{}
+		pipelineRun.CreationTimestamp = createdTime
+		pipelineRun.Spec.SCM = &v1alpha3.SCM{RefName: refName}
+		return pipelineRun
+	}
+	scanTimeBefore := metav1.NewTime(createdTime.Add(-time.Minute))
+	scanTimeAfter := metav1.NewTime(createdTime.Add(time.Minute))
+
+	assert.False(t, isBranchRemoved(newPipelineRun("master"), branches, nil, &scanTimeAfter))
+	assert.False(t, isBranchRemoved(newPipelineRun("feat/a"), branches, nil, &scanTimeAfter))
+	assert.False(t, isBranchRemoved(newPipelineRun("feat%2Fa"), branches, nil, &scanTimeAfter))
+	assert.True(t, isBranchRemoved(newPipelineRun("old"), branches, nil, nil))
+	assert.True(t, isBranchRemoved(newPipelineRun("master"), branches, []string{"master"}, nil))
+	assert.True(t, isBranchRemoved(newPipelineRun("feat%2Fa"), branches, []string{"feat/a"}, nil))
+	// the branches which do not exist in Jenkins
+	assert.True(t, isBranchRemoved(newPipelineRun("unknown"), branches, nil, &scanTimeAfter))
+	assert.False(t, isBranchRemoved(newPipelineRun("unknown"), branches, nil, &scanTimeBefore))
+	assert.False(t, isBranchRemoved(newPipelineRun("unknown"), branches, nil, nil))
+}
+
+func Test_branchCleanupPredicate(t *testing.T) {
+	oldPipeline := &v1alpha3.Pipeline{}
+	oldPipeline.Annotations = map[string]string{v1alpha3.PipelineJenkinsBranchesAnnoKey: "[]"}
+
+	newPipeline := oldPipeline.DeepCopy()
+	newPipeline.Annotations["other"] = "other"
+	assert.False(t, branchCleanupPredicate.Update(event.UpdateEvent{ObjectOld: oldPipeline, ObjectNew: newPipeline}))
+
+	for _, key := range []string{v1alpha3.PipelineJenkinsBranchesAnnoKey, v1alpha3.PipelineRemovedBranchesAnnoKey,
+		v1alpha3.PipelineBranchCleanupPolicyAnnoKey, v1alpha3.PipelineLastScanTimeAnnoKey} {
+		newPipeline := oldPipeline.DeepCopy()
+		newPipeline.Annotations[key] = "changed"
+		assert.True(t, branchCleanupPredicate.Update(event.UpdateEvent{ObjectOld: oldPipeline, ObjectNew: newPipeline}), key)
+	}
+	assert.True(t, branchCleanupPredicate.Create(event.CreateEvent{Object: oldPipeline}))
+	assert.False(t, branchCleanupPredicate.Delete(event.DeleteEvent{Object: oldPipeline}))
+}
+
+func Test_filterRemovedBranches(t *testing.T) {
+	branches := []modelpipeline.Branch{{Name: "master"}, {Name: "feat%2Fa", RawName: "feat/a"}, {Name: "PR-1"}}
+
+	existingBranches, removedBranches := filterRemovedBranches(branches, nil)
+	assert.Equal(t, branches, existingBranches)
+	assert.Nil(t, removedBranches)
+
+	existingBranches, removedBranches = filterRemovedBranches(branches, []string{"feat/a", "PR-1", "PR-2"})
+	assert.Equal(t, []modelpipeline.Branch{{Name: "master"}}, existingBranches)
+	// PR-2 has been removed from Jenkins
+	assert.Equal(t, []string{"feat/a", "PR-1"}, removedBranches)
+}
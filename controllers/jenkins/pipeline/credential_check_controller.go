@@ -0,0 +1,159 @@
+/*
+Copyright 2022 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pipeline
+
+import (
+	"context"
+	"reflect"
+	"strings"
+
+	"github.com/go-logr/logr"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/util/retry"
+	"kubesphere.io/devops/pkg/api/devops/v1alpha3"
+	"kubesphere.io/devops/pkg/indexers"
+	modelpipeline "kubesphere.io/devops/pkg/models/pipeline"
+	"kubesphere.io/devops/pkg/utils/sliceutil"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+)
+
+//+kubebuilder:rbac:groups=devops.kubesphere.io,resources=pipelines,verbs=get;list;watch;update
+//+kubebuilder:rbac:groups="",resources=secrets,verbs=get;list;watch
+
+// CredentialCheckReconciler checks if the credentials referenced by a Pipeline exist in the DevOps project,
+// the result is set as the CredentialsResolved condition of the Pipeline.
+type CredentialCheckReconciler struct {
+	client.Client
+	log logr.Logger
+}
+
+// Reconcile is the main entrypoint of this controller
+func (r *CredentialCheckReconciler) Reconcile(ctx context.Context, req ctrl.Request) (result ctrl.Result, err error) {
+	pipeline := &v1alpha3.Pipeline{}
+	if err = r.Get(ctx, req.NamespacedName, pipeline); err != nil {
+		err = client.IgnoreNotFound(err)
+		return
+	}
+	if !pipeline.DeletionTimestamp.IsZero() {
+		return
+	}
+
+	var report *modelpipeline.CredentialCheckReport
+	if report, err = modelpipeline.CheckCredentials(ctx, r.Client, pipeline); err != nil {
+		return
+	}
+	condition := report.Condition()
+	if condition.Status == v1alpha3.ConditionFalse {
+		r.log.V(4).Info("found unresolved credentials", "Pipeline", req.NamespacedName, "message", condition.Message)
+	}
+
+	err = retry.RetryOnConflict(retry.DefaultRetry, func() (err error) {
+		latest := &v1alpha3.Pipeline{}
+		if err = r.Get(ctx, req.NamespacedName, latest); err != nil {
+			return client.IgnoreNotFound(err)
+		}
+		if latest.Status.SetCondition(condition) {
+			err = r.Update(ctx, latest)
+		}
+		return
+	})
+	return
+}
+
+// GetName returns the name of this controller
+func (r *CredentialCheckReconciler) GetName() string {
+	return "pipeline-credential-check-controller"
+}
+
+// GetGroupName returns the group name of this controller
+func (r *CredentialCheckReconciler) GetGroupName() string {
+	return ControllerGroupName
+}
+
+// credentialCheckPredicate only cares about the changes which might reference other credentials
+var credentialCheckPredicate = predicate.Funcs{
+	UpdateFunc: func(ue event.UpdateEvent) bool {
+		oldPipeline, okOld := ue.ObjectOld.(*v1alpha3.Pipeline)
+		newPipeline, okNew := ue.ObjectNew.(*v1alpha3.Pipeline)
+		if okOld && okNew {
+			return !reflect.DeepEqual(oldPipeline.Spec, newPipeline.Spec) ||
+				oldPipeline.Annotations[v1alpha3.PipelineJenkinsfileValueAnnoKey] != newPipeline.Annotations[v1alpha3.PipelineJenkinsfileValueAnnoKey]
+		}
+		return false
+	},
+	DeleteFunc: func(de event.DeleteEvent) bool {
+		return false
+	},
+}
+
+// credentialPredicate only cares about the creation and deletion of the DevOps credentials,
+// the type of a secret cannot be changed
+var credentialPredicate = predicate.Funcs{
+	CreateFunc: func(ce event.CreateEvent) bool {
+		return isCredential(ce.Object)
+	},
+	UpdateFunc: func(ue event.UpdateEvent) bool {
+		return false
+	},
+	DeleteFunc: func(de event.DeleteEvent) bool {
+		return isCredential(de.Object)
+	},
+	GenericFunc: func(ge event.GenericEvent) bool {
+		return false
+	},
+}
+
+// isCredential returns true if the object is a DevOps credential
+func isCredential(object client.Object) bool {
+	secret, ok := object.(*v1.Secret)
+	return ok && strings.HasPrefix(string(secret.Type), v1alpha3.DevOpsCredentialPrefix)
+}
+
+// findPipelines returns the Pipelines which reference the credential, they need to be checked again
+func (r *CredentialCheckReconciler) findPipelines(object client.Object) (requests []reconcile.Request) {
+	pipelines := &v1alpha3.PipelineList{}
+	if err := r.List(context.Background(), pipelines, client.InNamespace(object.GetNamespace()),
+		client.MatchingFields{v1alpha3.PipelineCredentialIDIndexerName: object.GetName()}); err != nil {
+		r.log.Error(err, "failed to list Pipelines", "namespace", object.GetNamespace(), "credential", object.GetName())
+		return
+	}
+	for i := range pipelines.Items {
+		// double check in case the field selector is not supported
+		if sliceutil.HasString(indexers.GetPipelineCredentialIDs(&pipelines.Items[i]), object.GetName()) {
+			requests = append(requests, reconcile.Request{NamespacedName: client.ObjectKeyFromObject(&pipelines.Items[i])})
+		}
+	}
+	return
+}
+
+// SetupWithManager setups the log and the watchers
+func (r *CredentialCheckReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	r.log = ctrl.Log.WithName(r.GetName())
+	return ctrl.NewControllerManagedBy(mgr).
+		Named(r.GetName()).
+		For(&v1alpha3.Pipeline{}, builder.WithPredicates(credentialCheckPredicate)).
+		Watches(&source.Kind{Type: &v1.Secret{}}, handler.EnqueueRequestsFromMapFunc(r.findPipelines),
+			builder.WithPredicates(credentialPredicate)).
+		Complete(r)
+}
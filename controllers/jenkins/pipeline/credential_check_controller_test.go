@@ -0,0 +1,143 @@
+/*
+Copyright 2022 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pipeline
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/go-logr/logr"
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"kubesphere.io/devops/pkg/api/devops/v1alpha3"
+	controllerruntime "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+func TestCredentialCheckReconciler_Reconcile(t *testing.T) {
+	schema, err := v1alpha3.SchemeBuilder.Register().Build()
+	assert.Nil(t, err)
+	assert.Nil(t, v1.SchemeBuilder.AddToScheme(schema))
+
+	req := controllerruntime.Request{NamespacedName: types.NamespacedName{Namespace: "ns", Name: "name"}}
+	pip := &v1alpha3.Pipeline{}
+	pip.SetNamespace("ns")
+	pip.SetName("name")
+	pip.Spec.Type = v1alpha3.NoScmPipelineType
+	pip.Spec.Pipeline = &v1alpha3.NoScmPipeline{
+		Name:        "name",
+		Jenkinsfile: `withCredentials([usernamePassword(credentialsId: 'git', passwordVariable: 'P', usernameVariable: 'U')]) {}`,
+	}
+
+	secret := &v1.Secret{Type: v1alpha3.SecretTypeBasicAuth}
+	secret.SetNamespace("ns")
+	secret.SetName("git")
+
+	getCondition := func(t *testing.T, c client.Client) *v1alpha3.Condition {
+		pipeline := &v1alpha3.Pipeline{}
+		assert.Nil(t, c.Get(context.Background(), req.NamespacedName, pipeline))
+		return pipeline.Status.GetCondition(v1alpha3.PipelineConditionCredentialsResolved)
+	}
+
+	tests := []struct {
+		name    string
+		objects []client.Object
+		verify  func(t *testing.T, c client.Client)
+	}{{
+		name: "not found",
+	}, {
+		name:    "the credential is missing",
+		objects: []client.Object{pip.DeepCopy()},
+		verify: func(t *testing.T, c client.Client) {
+			condition := getCondition(t, c)
+			if assert.NotNil(t, condition) {
+				assert.Equal(t, v1alpha3.ConditionFalse, condition.Status)
+				assert.Contains(t, condition.Message, "credential git is not found")
+			}
+		},
+	}, {
+		name:    "the credential exists",
+		objects: []client.Object{pip.DeepCopy(), secret.DeepCopy()},
+		verify: func(t *testing.T, c client.Client) {
+			condition := getCondition(t, c)
+			if assert.NotNil(t, condition) {
+				assert.Equal(t, v1alpha3.ConditionTrue, condition.Status)
+			}
+		},
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := fake.NewClientBuilder().WithScheme(schema).WithObjects(tt.objects...).Build()
+			r := &CredentialCheckReconciler{
+				Client: c,
+				log:    logr.New(log.NullLogSink{}),
+			}
+			_, err := r.Reconcile(context.Background(), req)
+			assert.Nil(t, err)
+			if tt.verify != nil {
+				tt.verify(t, c)
+			}
+		})
+	}
+}
+
+func TestCredentialCheckReconciler_findPipelines(t *testing.T) {
+	schema, err := v1alpha3.SchemeBuilder.Register().Build()
+	assert.Nil(t, err)
+
+	newPipeline := func(namespace, name, credentialID string) *v1alpha3.Pipeline {
+		pipeline := &v1alpha3.Pipeline{}
+		pipeline.SetNamespace(namespace)
+		pipeline.SetName(name)
+		pipeline.Spec.Pipeline = &v1alpha3.NoScmPipeline{
+			Jenkinsfile: fmt.Sprintf("environment { TOKEN = credentials('%s') }", credentialID),
+		}
+		return pipeline
+	}
+	r := &CredentialCheckReconciler{
+		Client: fake.NewClientBuilder().WithScheme(schema).WithObjects(
+			newPipeline("ns", "a", "git"), newPipeline("ns", "b", "other"), newPipeline("other", "c", "git")).Build(),
+		log: logr.New(log.NullLogSink{}),
+	}
+
+	secret := &v1.Secret{}
+	secret.SetNamespace("ns")
+	secret.SetName("git")
+	requests := r.findPipelines(secret)
+	assert.Equal(t, []reconcile.Request{{NamespacedName: types.NamespacedName{Namespace: "ns", Name: "a"}}}, requests)
+}
+
+func Test_credentialPredicate(t *testing.T) {
+	credential := &v1.Secret{Type: v1alpha3.SecretTypeSecretText}
+	assert.True(t, credentialPredicate.Create(event.CreateEvent{Object: credential}))
+	assert.True(t, credentialPredicate.Delete(event.DeleteEvent{Object: credential}))
+	assert.False(t, credentialPredicate.Update(event.UpdateEvent{ObjectOld: credential, ObjectNew: credential}))
+	assert.False(t, credentialPredicate.Create(event.CreateEvent{Object: &v1.Secret{Type: v1.SecretTypeOpaque}}))
+
+	oldPipeline := &v1alpha3.Pipeline{}
+	newPipeline := oldPipeline.DeepCopy()
+	newPipeline.Status.SetCondition(v1alpha3.Condition{Type: v1alpha3.PipelineConditionCredentialsResolved})
+	assert.False(t, credentialCheckPredicate.Update(event.UpdateEvent{ObjectOld: oldPipeline, ObjectNew: newPipeline}))
+	newPipeline.Annotations = map[string]string{v1alpha3.PipelineJenkinsfileValueAnnoKey: "{}"}
+	assert.True(t, credentialCheckPredicate.Update(event.UpdateEvent{ObjectOld: oldPipeline, ObjectNew: newPipeline}))
+}
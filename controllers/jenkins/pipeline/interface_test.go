@@ -37,6 +37,18 @@ func TestInterfaceImplement(t *testing.T) {
 			NamedReconciler: &JenkinsfileReconciler{},
 			GroupReconciler: &JenkinsfileReconciler{},
 		},
+	}, {
+		name: "RevisionReconciler",
+		instance: interInstance{
+			NamedReconciler: &RevisionReconciler{},
+			GroupReconciler: &RevisionReconciler{},
+		},
+	}, {
+		name: "CredentialCheckReconciler",
+		instance: interInstance{
+			NamedReconciler: &CredentialCheckReconciler{},
+			GroupReconciler: &CredentialCheckReconciler{},
+		},
 	}}
 	for i := range tests {
 		tt := tests[i]
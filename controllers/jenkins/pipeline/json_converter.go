@@ -20,15 +20,18 @@ import (
 	"context"
 	"fmt"
 	"reflect"
+	"strings"
 	"time"
 
 	"github.com/go-logr/logr"
 	"github.com/jenkins-zh/jenkins-client/pkg/core"
+	v1 "k8s.io/api/core/v1"
 	"k8s.io/apiserver/pkg/authentication/user"
 	"k8s.io/client-go/tools/record"
 	"k8s.io/client-go/util/retry"
 
 	v1alpha3 "kubesphere.io/devops/pkg/api/devops/v1alpha3"
+	"kubesphere.io/devops/pkg/jenkinsfile"
 	"kubesphere.io/devops/pkg/jwt/token"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
@@ -39,6 +42,13 @@ import (
 // tokenExpireIn indicates that the temporary token issued by controller will be expired in some time.
 const tokenExpireIn time.Duration = 5 * time.Minute
 
+const (
+	// InvalidJenkinsfile indicates the Jenkinsfile is rejected by the linter before converting it by Jenkins
+	InvalidJenkinsfile = "InvalidJenkinsfile"
+	// JenkinsfileWarnings indicates the linter finds some names which might be provided by the plugins of Jenkins
+	JenkinsfileWarnings = "JenkinsfileWarnings"
+)
+
 //+kubebuilder:rbac:groups=devops.kubesphere.io,resources=pipelines,verbs=get;list;update;patch;watch
 //+kubebuilder:rbac:groups="",resources=events,verbs=create;patch
 
@@ -73,19 +83,20 @@ func (r *JenkinsfileReconciler) Reconcile(ctx context.Context, req ctrl.Request)
 		return
 	}
 	c.RoundTripper = r.JenkinsCore.RoundTripper
-	coreClient := core.Client{JenkinsCore: *c}
+	// Jenkins is only required by the constructs which are not supported by the native converter
+	converter := jenkinsfile.NewConverter(&core.Client{JenkinsCore: *c})
 
 	editMode := pip.Annotations[v1alpha3.PipelineJenkinsfileEditModeAnnoKey]
 	switch editMode {
 	case v1alpha3.PipelineJenkinsfileEditModeRaw:
-		result, err = r.reconcileJenkinsfileEditMode(pip, req.NamespacedName, coreClient)
+		result, err = r.reconcileJenkinsfileEditMode(pip, req.NamespacedName, converter)
 	case v1alpha3.PipelineJenkinsfileEditModeJSON:
-		result, err = r.reconcileJSONEditMode(pip, req.NamespacedName, coreClient)
+		result, err = r.reconcileJSONEditMode(pip, req.NamespacedName, converter)
 	case "":
 		// Reconcile pipeline version <= v3.3.2
 		if _, ok := pip.Annotations[v1alpha3.PipelineJenkinsfileValueAnnoKey]; !ok {
 			if pip.Spec.Pipeline != nil && pip.Spec.Pipeline.Jenkinsfile != "" {
-				result, err = r.reconcileJenkinsfileEditMode(pip, req.NamespacedName, coreClient)
+				result, err = r.reconcileJenkinsfileEditMode(pip, req.NamespacedName, converter)
 			}
 		}
 	default:
@@ -95,18 +106,33 @@ func (r *JenkinsfileReconciler) Reconcile(ctx context.Context, req ctrl.Request)
 	return
 }
 
-func (r *JenkinsfileReconciler) reconcileJenkinsfileEditMode(pip *v1alpha3.Pipeline, pipelineKey client.ObjectKey, coreClient core.Client) (
+func (r *JenkinsfileReconciler) reconcileJenkinsfileEditMode(pip *v1alpha3.Pipeline, pipelineKey client.ObjectKey, converter jenkinsfile.Converter) (
 	result ctrl.Result, err error) {
-	jenkinsfile := pip.Spec.Pipeline.Jenkinsfile
+	jenkinsfileText := pip.Spec.Pipeline.Jenkinsfile
 	toJsonJenkinsfile := ""
 	if pip.Annotations == nil {
 		pip.Annotations = map[string]string{}
 	}
 
 	// Users are able to clean jenkinsfile
-	if jenkinsfile != "" {
+	if jenkinsfileText != "" {
+		warnings, lintErr := lintJenkinsfile(jenkinsfileText)
+		if len(warnings) > 0 {
+			r.recorder.Eventf(pip, v1.EventTypeWarning, JenkinsfileWarnings, "Jenkinsfile warnings: %s",
+				strings.Join(warnings, "; "))
+		}
+		if lintErr != nil {
+			r.log.Info(fmt.Sprintf("invalid Jenkinsfile of %s: %v", pipelineKey, lintErr))
+			r.recorder.Eventf(pip, v1.EventTypeWarning, InvalidJenkinsfile, "Invalid Jenkinsfile: %v", lintErr)
+			pip.Annotations[v1alpha3.PipelineJenkinsfileValueAnnoKey] = ""
+			pip.Annotations[v1alpha3.PipelineJenkinsfileEditModeAnnoKey] = ""
+			pip.Annotations[v1alpha3.PipelineJenkinsfileValidateAnnoKey] = v1alpha3.PipelineJenkinsfileValidateFailure
+			err = r.updateAnnotations(pip.Annotations, pipelineKey)
+			return
+		}
+
 		var toJSONResult core.GenericResult
-		if toJSONResult, err = coreClient.ToJSON(jenkinsfile); err != nil || toJSONResult.GetStatus() != "success" {
+		if toJSONResult, err = converter.ToJSON(jenkinsfileText); err != nil || toJSONResult.GetStatus() != "success" {
 			r.log.Error(err, "failed to convert jenkinsfile to json format")
 			pip.Annotations[v1alpha3.PipelineJenkinsfileValueAnnoKey] = ""
 			pip.Annotations[v1alpha3.PipelineJenkinsfileEditModeAnnoKey] = ""
@@ -124,6 +150,26 @@ func (r *JenkinsfileReconciler) reconcileJenkinsfileEditMode(pip *v1alpha3.Pipel
 	return
 }
 
+// lintJenkinsfile checks a declarative Jenkinsfile without Jenkins, the other kinds of Jenkinsfile are left to Jenkins.
+// Only the structural problems are errors, the unknown names are returned as warnings.
+func lintJenkinsfile(text string) (warnings []string, err error) {
+	if !jenkinsfile.IsDeclarative(text) {
+		return
+	}
+	var messages []string
+	for _, lintErr := range jenkinsfile.Lint(text) {
+		if lintErr.Warning {
+			warnings = append(warnings, lintErr.Error())
+		} else {
+			messages = append(messages, lintErr.Error())
+		}
+	}
+	if len(messages) > 0 {
+		err = fmt.Errorf("%s", strings.Join(messages, "; "))
+	}
+	return
+}
+
 func (r *JenkinsfileReconciler) updateAnnotations(annotations map[string]string, pipelineKey client.ObjectKey) error {
 	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
 		pipeline := &v1alpha3.Pipeline{}
@@ -142,12 +188,12 @@ func (r *JenkinsfileReconciler) updateAnnotations(annotations map[string]string,
 	})
 }
 
-func (r *JenkinsfileReconciler) reconcileJSONEditMode(pip *v1alpha3.Pipeline, pipelineKey client.ObjectKey, coreClient core.Client) (
+func (r *JenkinsfileReconciler) reconcileJSONEditMode(pip *v1alpha3.Pipeline, pipelineKey client.ObjectKey, converter jenkinsfile.Converter) (
 	result ctrl.Result, err error) {
 	var jsonData string
 	if jsonData = pip.Annotations[v1alpha3.PipelineJenkinsfileValueAnnoKey]; jsonData != "" {
 		var toResult core.GenericResult
-		if toResult, err = coreClient.ToJenkinsfile(jsonData); err != nil || toResult.GetStatus() != "success" {
+		if toResult, err = converter.ToJenkinsfile(jsonData); err != nil || toResult.GetStatus() != "success" {
 			r.log.Error(err, "failed to convert json format to Jenkinsfile")
 			pip.Annotations[v1alpha3.PipelineJenkinsfileEditModeAnnoKey] = ""
 			pip.Annotations[v1alpha3.PipelineJenkinsfileValidateAnnoKey] = v1alpha3.PipelineJenkinsfileValidateFailure
@@ -176,7 +222,12 @@ func (r *JenkinsfileReconciler) updateAnnotationsAndJenkinsfile(annotations map[
 		// update annotations
 		pipeline.Annotations[v1alpha3.PipelineJenkinsfileEditModeAnnoKey] = annotations[v1alpha3.PipelineJenkinsfileEditModeAnnoKey]
 		pipeline.Annotations[v1alpha3.PipelineJenkinsfileValidateAnnoKey] = annotations[v1alpha3.PipelineJenkinsfileValidateAnnoKey]
+		// the converted Jenkinsfile is still the change of the last modifier
+		modifier := pipeline.GetLastModifier()
 		pipeline.Spec.Pipeline.Jenkinsfile = jenkinsfile
+		if modifier != "" {
+			pipeline.SetLastModifier(modifier)
+		}
 		return r.Update(context.Background(), pipeline)
 	})
 }
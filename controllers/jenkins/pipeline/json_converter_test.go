@@ -74,6 +74,16 @@ func TestJenkinsfileReconciler_Reconcile(t *testing.T) {
 	irregularPip := pip.DeepCopy()
 	irregularPip.Spec.Type = ""
 
+	declarativePip := pip.DeepCopy()
+	declarativePip.Spec.Pipeline.Jenkinsfile = "pipeline {\n  agent any\n  stages {\n    stage('a') { steps { echo 'a' } }\n  }\n}"
+
+	warningJenkinsfilePip := pip.DeepCopy()
+	warningJenkinsfilePip.Spec.Pipeline.Jenkinsfile = "pipeline {\n  agent any\n  stages {\n    stage('a') {\n" +
+		"      when { fromPlugin() }\n      steps { echo 'a' }\n    }\n  }\n}"
+
+	invalidJenkinsfilePip := pip.DeepCopy()
+	invalidJenkinsfilePip.Spec.Pipeline.Jenkinsfile = "pipeline {\n  agent any\n}"
+
 	type fields struct {
 		Client      client.Client
 		log         logr.Logger
@@ -181,6 +191,100 @@ func TestJenkinsfileReconciler_Reconcile(t *testing.T) {
 			assert.Nil(t, err)
 			return true
 		},
+	}, {
+		name: "a declarative Jenkinsfile is converted without Jenkins",
+		fields: fields{
+			Client: fake.NewClientBuilder().WithScheme(schema).WithRuntimeObjects(declarativePip).Build(),
+			JenkinsCore: core.JenkinsCore{
+				URL: "http://localhost",
+			},
+			TokenIssuer: &token.FakeIssuer{},
+		},
+		args: args{
+			req: defaultReq,
+		},
+		prepare: func(t *testing.T, c *core.JenkinsCore) {
+			// there are no expected requests to Jenkins
+			c.RoundTripper = mhttp.NewMockRoundTripper(gomock.NewController(t))
+		},
+		verify: func(t *testing.T, Client client.Client) {
+			pip := &v1alpha3.Pipeline{}
+			err := Client.Get(context.Background(), types.NamespacedName{
+				Namespace: "ns",
+				Name:      "name",
+			}, pip)
+			assert.Nil(t, err)
+			assert.JSONEq(t, `{"pipeline":{"agent":{"type":"any"},"stages":[{"name":"a","branches":[{"name":"default",
+"steps":[{"name":"echo","arguments":[{"key":"message","value":{"isLiteral":true,"value":"a"}}]}]}]}]}}`,
+				pip.Annotations[v1alpha3.PipelineJenkinsfileValueAnnoKey])
+			assert.Equal(t, v1alpha3.PipelineJenkinsfileValidateSuccess, pip.Annotations[v1alpha3.PipelineJenkinsfileValidateAnnoKey])
+		},
+		wantErr: func(t assert.TestingT, err error, i ...interface{}) bool {
+			assert.Nil(t, err)
+			return true
+		},
+	}, {
+		name: "the warnings of a declarative Jenkinsfile do not block it",
+		fields: fields{
+			Client: fake.NewClientBuilder().WithScheme(schema).WithRuntimeObjects(warningJenkinsfilePip).Build(),
+			JenkinsCore: core.JenkinsCore{
+				URL: "http://localhost",
+			},
+			recorder:    &record.FakeRecorder{},
+			TokenIssuer: &token.FakeIssuer{},
+		},
+		args: args{
+			req: defaultReq,
+		},
+		prepare: func(t *testing.T, c *core.JenkinsCore) {
+			// there are no expected requests to Jenkins
+			c.RoundTripper = mhttp.NewMockRoundTripper(gomock.NewController(t))
+		},
+		verify: func(t *testing.T, Client client.Client) {
+			pip := &v1alpha3.Pipeline{}
+			err := Client.Get(context.Background(), types.NamespacedName{
+				Namespace: "ns",
+				Name:      "name",
+			}, pip)
+			assert.Nil(t, err)
+			assert.NotEmpty(t, pip.Annotations[v1alpha3.PipelineJenkinsfileValueAnnoKey])
+			assert.Equal(t, v1alpha3.PipelineJenkinsfileValidateSuccess, pip.Annotations[v1alpha3.PipelineJenkinsfileValidateAnnoKey])
+		},
+		wantErr: func(t assert.TestingT, err error, i ...interface{}) bool {
+			assert.Nil(t, err)
+			return true
+		},
+	}, {
+		name: "an invalid declarative Jenkinsfile is rejected without Jenkins",
+		fields: fields{
+			Client: fake.NewClientBuilder().WithScheme(schema).WithRuntimeObjects(invalidJenkinsfilePip).Build(),
+			JenkinsCore: core.JenkinsCore{
+				URL: "http://localhost",
+			},
+			recorder:    &record.FakeRecorder{},
+			TokenIssuer: &token.FakeIssuer{},
+		},
+		args: args{
+			req: defaultReq,
+		},
+		prepare: func(t *testing.T, c *core.JenkinsCore) {
+			// there are no expected requests to Jenkins
+			c.RoundTripper = mhttp.NewMockRoundTripper(gomock.NewController(t))
+		},
+		verify: func(t *testing.T, Client client.Client) {
+			pip := &v1alpha3.Pipeline{}
+			err := Client.Get(context.Background(), types.NamespacedName{
+				Namespace: "ns",
+				Name:      "name",
+			}, pip)
+			assert.Nil(t, err)
+			assert.Equal(t, "", pip.Annotations[v1alpha3.PipelineJenkinsfileEditModeAnnoKey])
+			assert.Equal(t, v1alpha3.PipelineJenkinsfileValidateFailure, pip.Annotations[v1alpha3.PipelineJenkinsfileValidateAnnoKey])
+		},
+		wantErr: func(t assert.TestingT, err error, i ...interface{}) bool {
+			assert.Nil(t, err)
+			return true
+		},
 	}, {
 		name: "a regular pipeline with JSON edit mode",
 		fields: fields{
@@ -240,3 +344,23 @@ func TestJenkinsfileReconciler_Reconcile(t *testing.T) {
 		})
 	}
 }
+
+func Test_lintJenkinsfile(t *testing.T) {
+	warnings, err := lintJenkinsfile("node {\n  sh 'make'\n}")
+	assert.Nil(t, err)
+	assert.Nil(t, warnings)
+
+	warnings, err = lintJenkinsfile("pipeline {\n  agent any\n  stages {\n    stage('a') { steps { echo 'a' } }\n  }\n}")
+	assert.Nil(t, err)
+	assert.Nil(t, warnings)
+
+	// the names which might be provided by the plugins are not errors
+	warnings, err = lintJenkinsfile("pipeline {\n  agent { pod 'a' }\n  stages {\n    stage('a') {\n" +
+		"      when { fromPlugin() }\n      steps { echo 'a' }\n    }\n  }\n}")
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"line 2: unknown agent type 'pod'", "line 5: unknown when condition 'fromPlugin'"}, warnings)
+
+	warnings, err = lintJenkinsfile("pipeline {\n  stages {\n  }\n  unknown {}\n}")
+	assert.EqualError(t, err, "line 1: missing agent section in pipeline; line 2: stages must contain at least one stage")
+	assert.Equal(t, []string{"line 4: unknown section 'unknown' in pipeline"}, warnings)
+}
@@ -50,8 +50,12 @@ import (
 	devopsinformers "kubesphere.io/devops/pkg/client/informers/externalversions/devops/v1alpha3"
 	devopslisters "kubesphere.io/devops/pkg/client/listers/devops/v1alpha3"
 	"kubesphere.io/devops/pkg/constants"
+	modelsdevops "kubesphere.io/devops/pkg/models/devops"
 )
 
+// DriftCorrected indicates the changes made in Jenkins directly were overwritten by the Pipeline spec
+const DriftCorrected = "DriftCorrected"
+
 // Controller is the controller of the Pipeline
 type Controller struct {
 	client           clientset.Interface
@@ -106,6 +110,10 @@ func NewController(client clientset.Interface,
 			oldPipeline := oldObj.(*devopsv1alpha3.Pipeline)
 			newPipeline := newObj.(*devopsv1alpha3.Pipeline)
 			if oldPipeline.ResourceVersion == newPipeline.ResourceVersion {
+				// check the out-of-band changes in Jenkins on each resync
+				if isDriftCorrectionEnabled(newPipeline) {
+					v.enqueuePipeline(newObj)
+				}
 				return
 			}
 
@@ -242,10 +250,12 @@ func (c *Controller) syncHandler(key string) error {
 		}
 
 		//If the sync is successful, return handle
+		specChanged := true
 		if state, ok := copyPipeline.Annotations[devopsv1alpha3.PipelineSyncStatusAnnoKey]; ok && state == constants.StatusSuccessful {
 			specHash := utils.ComputeHash(copyPipeline.Spec)
 			oldHash := copyPipeline.Annotations[devopsv1alpha3.PipelineSpecHash] // don't need to check if it's nil, only compare if they're different
-			if specHash == oldHash {
+			specChanged = specHash != oldHash
+			if !specChanged && !isDriftCorrectionEnabled(copyPipeline) {
 				klog.V(9).Info(fmt.Sprintf("%s/%s has no changes in spec", copyPipeline.Namespace, copyPipeline.Name))
 				// it was synced successfully, and there's any change with the Pipeline spec, skip this round
 				return nil
@@ -262,12 +272,24 @@ func (c *Controller) syncHandler(key string) error {
 		// if pipeline exists, check & update config
 		jenkinsPipeline, err := c.devopsClient.GetProjectPipelineConfig(nsName, pipeline.Name)
 		if err == nil {
-			if !reflect.DeepEqual(jenkinsPipeline.Spec, copyPipeline.Spec) {
+			// only compare the fields which are written to Jenkins, e.g. the description of a NoScm Pipeline is not
+			var desiredPipeline *devopsv1alpha3.Pipeline
+			if desiredPipeline, err = c.devopsClient.GetProjectPipelineDesiredConfig(nsName, copyPipeline); err != nil {
+				klog.V(8).Info(err, fmt.Sprintf("failed to generate the pipeline config %s ", key))
+				return err
+			}
+			if !reflect.DeepEqual(jenkinsPipeline.Spec, desiredPipeline.Spec) {
 				_, err := c.devopsClient.UpdateProjectPipeline(nsName, copyPipeline)
 				if err != nil {
 					klog.V(8).Info(err, fmt.Sprintf("failed to update pipeline config %s ", key))
 					return err
 				}
+				if !specChanged {
+					// the Jenkins job was changed out-of-band
+					c.eventRecorder.Eventf(copyPipeline, v1.EventTypeWarning, DriftCorrected,
+						"Overwrote the changes made in Jenkins, fields: %v",
+						modelsdevops.GetDriftedFields(&desiredPipeline.Spec, &jenkinsPipeline.Spec))
+				}
 			} else {
 				klog.V(8).Info(fmt.Sprintf("nothing was changed, pipeline '%v'", copyPipeline.Spec))
 			}
@@ -323,6 +345,11 @@ func (c *Controller) syncHandler(key string) error {
 	return nil
 }
 
+// isDriftCorrectionEnabled returns true if the changes made in Jenkins directly should be overwritten
+func isDriftCorrectionEnabled(pipeline *devopsv1alpha3.Pipeline) bool {
+	return pipeline.Annotations[devopsv1alpha3.PipelineDriftCorrectionAnnoKey] == "true"
+}
+
 // Update with retry, if update failed, get new version and update again
 func (c *Controller) updatePipeline(ctx context.Context, name string, nsName string, pipeline *devopsv1alpha3.Pipeline) (err error) {
 	return retry.RetryOnConflict(retry.DefaultRetry, func() (err error) {
@@ -24,10 +24,12 @@ import (
 	v1 "k8s.io/api/core/v1"
 
 	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
 	fakeDevOps "kubesphere.io/devops/pkg/client/devops/fake"
 	"kubesphere.io/devops/pkg/client/devops/jclient"
 
 	"kubesphere.io/devops/pkg/constants"
+	"kubesphere.io/devops/pkg/utils"
 
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
@@ -438,3 +440,85 @@ func TestUpdatePipelineConfig(t *testing.T) {
 	f.expectPipeline = []*devops.Pipeline{expectPipeline}
 	f.run(getKey(modifiedPipeline, t))
 }
+
+func TestCorrectPipelineDrift(t *testing.T) {
+	nsName := "test-123"
+	pipelineName := "test"
+	projectName := "test_project"
+
+	newSyncedPipeline := func(driftCorrection bool) *devops.Pipeline {
+		pipeline := newPipeline(nsName, pipelineName, devops.PipelineSpec{Type: "aa"}, true, true)
+		pipeline.Annotations[devops.PipelineSpecHash] = utils.ComputeHash(pipeline.Spec)
+		if driftCorrection {
+			pipeline.Annotations[devops.PipelineDriftCorrectionAnnoKey] = "true"
+		}
+		return pipeline
+	}
+
+	tests := []struct {
+		name            string
+		driftCorrection bool
+	}{{
+		name: "keep the changes made in Jenkins by default",
+	}, {
+		name:            "overwrite the changes made in Jenkins",
+		driftCorrection: true,
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newFixture(t)
+			pipeline := newSyncedPipeline(tt.driftCorrection)
+			// the job was changed in Jenkins directly
+			jenkinsPipeline := newPipeline(nsName, pipelineName, devops.PipelineSpec{Type: "bb"}, true, false)
+
+			f.pipelineLister = append(f.pipelineLister, pipeline)
+			f.namespaceLister = append(f.namespaceLister, newNamespace(nsName, projectName))
+			f.objects = append(f.objects, pipeline)
+			f.initDevOpsProject = nsName
+			f.initPipeline = []*devops.Pipeline{jenkinsPipeline}
+			if tt.driftCorrection {
+				f.expectPipeline = []*devops.Pipeline{newSyncedPipeline(true)}
+			} else {
+				f.expectPipeline = []*devops.Pipeline{jenkinsPipeline.DeepCopy()}
+			}
+			f.run(getKey(pipeline, t))
+		})
+	}
+}
+
+func TestCorrectPipelineDrift_unmanagedFields(t *testing.T) {
+	nsName := "test-123"
+	pipelineName := "test"
+	projectName := "test_project"
+
+	spec := devops.PipelineSpec{
+		Type:     devops.NoScmPipelineType,
+		Pipeline: &devops.NoScmPipeline{Name: pipelineName, Description: "desired", Jenkinsfile: "echo 1"},
+	}
+	pipeline := newPipeline(nsName, pipelineName, spec, true, true)
+	pipeline.Annotations[devops.PipelineSpecHash] = utils.ComputeHash(pipeline.Spec)
+	pipeline.Annotations[devops.PipelineDriftCorrectionAnnoKey] = "true"
+
+	// the description is not written to Jenkins, it should not be taken as a drift
+	jenkinsSpec := *spec.DeepCopy()
+	jenkinsSpec.Pipeline.Description = "in Jenkins"
+	jenkinsPipeline := newPipeline(nsName, pipelineName, jenkinsSpec, true, false)
+
+	f := newFixture(t)
+	f.pipelineLister = append(f.pipelineLister, pipeline)
+	f.namespaceLister = append(f.namespaceLister, newNamespace(nsName, projectName))
+	f.objects = append(f.objects, pipeline)
+	f.initDevOpsProject = nsName
+	f.initPipeline = []*devops.Pipeline{jenkinsPipeline}
+	f.expectPipeline = []*devops.Pipeline{jenkinsPipeline.DeepCopy()}
+	f.run(getKey(pipeline, t))
+}
+
+func Test_isDriftCorrectionEnabled(t *testing.T) {
+	pipeline := &devops.Pipeline{}
+	assert.False(t, isDriftCorrectionEnabled(pipeline))
+	pipeline.Annotations = map[string]string{devops.PipelineDriftCorrectionAnnoKey: "false"}
+	assert.False(t, isDriftCorrectionEnabled(pipeline))
+	pipeline.Annotations[devops.PipelineDriftCorrectionAnnoKey] = "true"
+	assert.True(t, isDriftCorrectionEnabled(pipeline))
+}
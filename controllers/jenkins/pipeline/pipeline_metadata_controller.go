@@ -19,6 +19,8 @@ package pipeline
 import (
 	"context"
 	"encoding/json"
+	"fmt"
+	"net/http"
 	"reflect"
 	"time"
 
@@ -29,6 +31,7 @@ import (
 	"k8s.io/client-go/tools/record"
 	"k8s.io/client-go/util/retry"
 	"kubesphere.io/devops/pkg/api/devops/v1alpha3"
+	"kubesphere.io/devops/pkg/models/pipeline"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/event"
@@ -76,6 +79,11 @@ func (r *Reconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Resu
 		return ctrl.Result{}, err
 	}
 
+	if err := r.obtainAndUpdatePipelineLastScanTime(pipeline); err != nil {
+		// the PipelineRuns of the branches which do not exist in Jenkins are kept until the scan time is known
+		log.Error(err, "unable to obtain the last scan time of Pipeline from Jenkins")
+	}
+
 	if err := r.updateAnnotations(pipeline.Annotations, req.NamespacedName); err != nil {
 		log.Error(err, "unable to update annotations of Pipeline")
 		return ctrl.Result{}, err
@@ -130,16 +138,69 @@ func (r *Reconciler) obtainAndUpdatePipelineBranches(pipeline *v1alpha3.Pipeline
 		return err
 	}
 
-	branchesJSON, err := json.Marshal(convertBranches(jobBranches))
+	branches, removedBranches := filterRemovedBranches(convertBranches(jobBranches), pipeline.GetRemovedBranches())
+	branchesJSON, err := json.Marshal(branches)
 	if err != nil {
 		return err
 	}
+	// forget the removed branches which have been removed from Jenkins as well
+	pipeline.SetRemovedBranches(removedBranches)
 
 	// update annotation
 	pipeline.Annotations[v1alpha3.PipelineJenkinsBranchesAnnoKey] = string(branchesJSON)
 	return nil
 }
 
+// obtainAndUpdatePipelineLastScanTime records the start time of the latest finished branch scan
+func (r *Reconciler) obtainAndUpdatePipelineLastScanTime(pipeline *v1alpha3.Pipeline) error {
+	if pipeline.Spec.Type != v1alpha3.MultiBranchPipelineType {
+		// skip non multi-branch Pipeline
+		return nil
+	}
+	computation := struct {
+		Timestamp int64  `json:"timestamp"`
+		Result    string `json:"result"`
+	}{}
+	if err := r.JenkinsCore.RequestWithData(http.MethodGet,
+		fmt.Sprintf("/job/%s/job/%s/indexing/api/json?tree=timestamp,result", pipeline.Namespace, pipeline.Name),
+		nil, nil, http.StatusOK, &computation); err != nil {
+		return err
+	}
+	if computation.Result == "" || computation.Timestamp <= 0 {
+		// the scan is still running or never happened
+		return nil
+	}
+	pipeline.Annotations[v1alpha3.PipelineLastScanTimeAnnoKey] = time.UnixMilli(computation.Timestamp).UTC().Format(time.RFC3339)
+	return nil
+}
+
+// filterRemovedBranches filters out the branches which are removed according to the SCM events,
+// and returns the removed branches which still exist in Jenkins.
+func filterRemovedBranches(branches []pipeline.Branch, removedBranches []string) (
+	existingBranches []pipeline.Branch, existingRemovedBranches []string) {
+	existingBranches = make([]pipeline.Branch, 0, len(branches))
+	found := map[string]bool{}
+	for i := range branches {
+		branch := &branches[i]
+		removed := false
+		for _, removedBranch := range removedBranches {
+			if branch.MatchName(removedBranch) {
+				found[removedBranch] = true
+				removed = true
+			}
+		}
+		if !removed {
+			existingBranches = append(existingBranches, *branch)
+		}
+	}
+	for _, removedBranch := range removedBranches {
+		if found[removedBranch] {
+			existingRemovedBranches = append(existingRemovedBranches, removedBranch)
+		}
+	}
+	return
+}
+
 func (r *Reconciler) updateAnnotations(annotations map[string]string, pipelineKey client.ObjectKey) error {
 	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
 		pipeline := &v1alpha3.Pipeline{}
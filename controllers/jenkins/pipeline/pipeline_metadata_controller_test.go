@@ -311,6 +311,30 @@ var _ = Describe("Pipeline metadata", func() {
 			gomega.Expect(len(pipeline.Annotations)).To(gomega.Equal(0))
 		})
 
+		It("last scan time", func() {
+			pipeline := &v1alpha3.Pipeline{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        "pipelineA",
+					Namespace:   "namespaceA",
+					Annotations: map[string]string{},
+				},
+				Spec: v1alpha3.PipelineSpec{
+					Type: v1alpha3.MultiBranchPipelineType,
+				},
+			}
+			requestURL, _ := util.URLJoinAsString(c.JenkinsCore.URL,
+				"/job/namespaceA/job/pipelineA/indexing/api/json?tree=timestamp,result")
+
+			// the scan is still running
+			given(requestURL, http.StatusOK, `{"timestamp":1659348000000}`)
+			gomega.Expect(c.obtainAndUpdatePipelineLastScanTime(pipeline)).To(gomega.Succeed())
+			gomega.Expect(pipeline.Annotations).NotTo(gomega.HaveKey(v1alpha3.PipelineLastScanTimeAnnoKey))
+
+			given(requestURL, http.StatusOK, `{"timestamp":1659348000000,"result":"SUCCESS"}`)
+			gomega.Expect(c.obtainAndUpdatePipelineLastScanTime(pipeline)).To(gomega.Succeed())
+			gomega.Expect(pipeline.Annotations[v1alpha3.PipelineLastScanTimeAnnoKey]).To(gomega.Equal("2022-08-01T10:00:00Z"))
+		})
+
 		It("pipeline Metadata Predicate should call create", func() {
 			pipelineName := "pipelineA"
 			pipeline := &v1alpha3.Pipeline{
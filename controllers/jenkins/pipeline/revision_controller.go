@@ -0,0 +1,179 @@
+/*
+Copyright 2022 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pipeline
+
+import (
+	"context"
+	"reflect"
+	"strconv"
+
+	"github.com/go-logr/logr"
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/client-go/util/retry"
+	"kubesphere.io/devops/pkg/api/devops/v1alpha3"
+	modelpipeline "kubesphere.io/devops/pkg/models/pipeline"
+	"kubesphere.io/devops/pkg/utils"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+)
+
+// RevisionCreated indicates a new revision of the Pipeline spec is recorded
+const RevisionCreated = "RevisionCreated"
+
+// DefaultRevisionHistoryLimit is the default number of the revisions to keep for a Pipeline
+const DefaultRevisionHistoryLimit = 20
+
+//+kubebuilder:rbac:groups=devops.kubesphere.io,resources=pipelines,verbs=get;list;watch;update
+//+kubebuilder:rbac:groups="",resources=configmaps,verbs=get;list;watch;create;delete
+//+kubebuilder:rbac:groups="",resources=events,verbs=create;patch
+
+// RevisionReconciler records an immutable revision of the Pipeline spec in a ConfigMap once the spec is changed.
+// The number of the current revision is set to the annotation of the Pipeline.
+type RevisionReconciler struct {
+	client.Client
+	// HistoryLimit is the number of the revisions to keep, the oldest revisions are deleted
+	HistoryLimit int
+	log          logr.Logger
+	recorder     record.EventRecorder
+}
+
+// Reconcile is the main entrypoint of this controller
+func (r *RevisionReconciler) Reconcile(ctx context.Context, req ctrl.Request) (result ctrl.Result, err error) {
+	log := r.log.WithValues("Pipeline", req.NamespacedName)
+	pipeline := &v1alpha3.Pipeline{}
+	if err = r.Get(ctx, req.NamespacedName, pipeline); err != nil {
+		err = client.IgnoreNotFound(err)
+		return
+	}
+	if !pipeline.DeletionTimestamp.IsZero() {
+		return
+	}
+
+	var cms []v1.ConfigMap
+	if cms, err = modelpipeline.ListRevisionConfigMaps(ctx, r.Client, pipeline.Namespace, pipeline.Name); err != nil {
+		return
+	}
+
+	specHash := utils.ComputeHash(pipeline.Spec)
+	revision := 0
+	if len(cms) > 0 {
+		revision, _ = strconv.Atoi(cms[0].Labels[v1alpha3.PipelineRevisionLabelKey])
+	}
+	if len(cms) == 0 || cms[0].Annotations[v1alpha3.PipelineSpecHash] != specHash {
+		revision++
+		var cm *v1.ConfigMap
+		if cm, err = modelpipeline.NewRevisionConfigMap(pipeline, revision, specHash); err != nil {
+			return
+		}
+		if err = r.Create(ctx, cm); apierrors.IsAlreadyExists(err) {
+			// the cache is not up to date, try it again later
+			return ctrl.Result{Requeue: true}, nil
+		} else if err != nil {
+			log.Error(err, "failed to create the revision", "revision", revision)
+			return
+		}
+		log.Info("recorded a new revision", "revision", revision)
+		r.recorder.Eventf(pipeline, v1.EventTypeNormal, RevisionCreated, "Recorded revision %d of the Pipeline", revision)
+		cms = append([]v1.ConfigMap{*cm}, cms...)
+	}
+
+	if err = r.pruneRevisions(ctx, cms); err != nil {
+		log.Error(err, "failed to delete the old revisions")
+		return
+	}
+	err = r.setRevision(ctx, req.NamespacedName, strconv.Itoa(revision), cms[0].Annotations[v1alpha3.PipelineSpecHash])
+	return
+}
+
+// pruneRevisions deletes the oldest revisions which are out of the history limit
+func (r *RevisionReconciler) pruneRevisions(ctx context.Context, cms []v1.ConfigMap) error {
+	limit := r.HistoryLimit
+	if limit <= 0 {
+		limit = DefaultRevisionHistoryLimit
+	}
+	for i := limit; i < len(cms); i++ {
+		if err := r.Delete(ctx, &cms[i]); client.IgnoreNotFound(err) != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// setRevision sets the revision number and its spec hash to the Pipeline
+func (r *RevisionReconciler) setRevision(ctx context.Context, key client.ObjectKey, revision, specHash string) error {
+	return retry.RetryOnConflict(retry.DefaultRetry, func() (err error) {
+		pipeline := &v1alpha3.Pipeline{}
+		if err = r.Get(ctx, key, pipeline); err != nil {
+			return client.IgnoreNotFound(err)
+		}
+		if pipeline.Annotations[v1alpha3.PipelineRevisionAnnoKey] == revision &&
+			pipeline.Annotations[v1alpha3.PipelineRevisionSpecHashAnnoKey] == specHash {
+			return
+		}
+		if pipeline.Annotations == nil {
+			pipeline.Annotations = map[string]string{}
+		}
+		pipeline.Annotations[v1alpha3.PipelineRevisionAnnoKey] = revision
+		pipeline.Annotations[v1alpha3.PipelineRevisionSpecHashAnnoKey] = specHash
+		return r.Update(ctx, pipeline)
+	})
+}
+
+// GetName returns the name of this controller
+func (r *RevisionReconciler) GetName() string {
+	return "pipeline-revision-controller"
+}
+
+// GetGroupName returns the group name of this controller
+func (r *RevisionReconciler) GetGroupName() string {
+	return ControllerGroupName
+}
+
+// revisionPredicate only cares about the changes of the spec and the revision annotation
+var revisionPredicate = predicate.Funcs{
+	UpdateFunc: func(ue event.UpdateEvent) bool {
+		oldPipeline, okOld := ue.ObjectOld.(*v1alpha3.Pipeline)
+		newPipeline, okNew := ue.ObjectNew.(*v1alpha3.Pipeline)
+		if okOld && okNew {
+			return !reflect.DeepEqual(oldPipeline.Spec, newPipeline.Spec) ||
+				oldPipeline.Annotations[v1alpha3.PipelineRevisionAnnoKey] != newPipeline.Annotations[v1alpha3.PipelineRevisionAnnoKey] ||
+				oldPipeline.Annotations[v1alpha3.PipelineRevisionSpecHashAnnoKey] != newPipeline.Annotations[v1alpha3.PipelineRevisionSpecHashAnnoKey]
+		}
+		return false
+	},
+	DeleteFunc: func(de event.DeleteEvent) bool {
+		return false
+	},
+	GenericFunc: func(ge event.GenericEvent) bool {
+		return false
+	},
+}
+
+// SetupWithManager setups the log and recorder
+func (r *RevisionReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	r.log = ctrl.Log.WithName(r.GetName())
+	r.recorder = mgr.GetEventRecorderFor(r.GetName())
+	return ctrl.NewControllerManagedBy(mgr).
+		Named(r.GetName()).
+		WithEventFilter(revisionPredicate).
+		For(&v1alpha3.Pipeline{}).
+		Complete(r)
+}
@@ -0,0 +1,164 @@
+/*
+Copyright 2022 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pipeline
+
+import (
+	"context"
+	"testing"
+
+	"github.com/go-logr/logr"
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"kubesphere.io/devops/pkg/api/devops/v1alpha3"
+	modelpipeline "kubesphere.io/devops/pkg/models/pipeline"
+	"kubesphere.io/devops/pkg/utils"
+	controllerruntime "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+func TestRevisionReconciler_Reconcile(t *testing.T) {
+	schema, err := v1alpha3.SchemeBuilder.Register().Build()
+	assert.Nil(t, err)
+	err = v1.SchemeBuilder.AddToScheme(schema)
+	assert.Nil(t, err)
+
+	defaultReq := controllerruntime.Request{
+		NamespacedName: types.NamespacedName{Namespace: "ns", Name: "name"},
+	}
+
+	pip := &v1alpha3.Pipeline{}
+	pip.SetNamespace("ns")
+	pip.SetName("name")
+	pip.Spec.Type = v1alpha3.NoScmPipelineType
+	pip.Spec.Pipeline = &v1alpha3.NoScmPipeline{Name: "name", Jenkinsfile: "echo 1"}
+	pip.SetLastModifier("tester")
+
+	newRevision := func(spec v1alpha3.PipelineSpec, revision int) *v1.ConfigMap {
+		pipeline := pip.DeepCopy()
+		pipeline.Spec = spec
+		cm, err := modelpipeline.NewRevisionConfigMap(pipeline, revision, utils.ComputeHash(spec))
+		assert.Nil(t, err)
+		return cm
+	}
+	oldSpec := *pip.Spec.DeepCopy()
+	oldSpec.Pipeline.Jenkinsfile = "echo 0"
+
+	listRevisions := func(t *testing.T, c client.Client) []*modelpipeline.Revision {
+		revisions, err := modelpipeline.ListRevisions(context.Background(), c, "ns", "name")
+		assert.Nil(t, err)
+		return revisions
+	}
+	getPipeline := func(t *testing.T, c client.Client) *v1alpha3.Pipeline {
+		pipeline := &v1alpha3.Pipeline{}
+		assert.Nil(t, c.Get(context.Background(), defaultReq.NamespacedName, pipeline))
+		return pipeline
+	}
+
+	tests := []struct {
+		name         string
+		objects      []client.Object
+		historyLimit int
+		verify       func(t *testing.T, c client.Client)
+	}{{
+		name: "not found",
+	}, {
+		name:    "the first revision",
+		objects: []client.Object{pip.DeepCopy()},
+		verify: func(t *testing.T, c client.Client) {
+			revisions := listRevisions(t, c)
+			if assert.Equal(t, 1, len(revisions)) {
+				assert.Equal(t, 1, revisions[0].Revision)
+				assert.Equal(t, "tester", revisions[0].Author)
+			}
+			assert.Equal(t, "1", getPipeline(t, c).Annotations[v1alpha3.PipelineRevisionAnnoKey])
+			assert.Equal(t, utils.ComputeHash(pip.Spec), getPipeline(t, c).Annotations[v1alpha3.PipelineRevisionSpecHashAnnoKey])
+		},
+	}, {
+		name:    "the spec is not changed",
+		objects: []client.Object{pip.DeepCopy(), newRevision(pip.Spec, 1)},
+		verify: func(t *testing.T, c client.Client) {
+			assert.Equal(t, 1, len(listRevisions(t, c)))
+			assert.Equal(t, "1", getPipeline(t, c).Annotations[v1alpha3.PipelineRevisionAnnoKey])
+		},
+	}, {
+		name:    "the spec is changed",
+		objects: []client.Object{pip.DeepCopy(), newRevision(oldSpec, 1)},
+		verify: func(t *testing.T, c client.Client) {
+			revisions := listRevisions(t, c)
+			if assert.Equal(t, 2, len(revisions)) {
+				assert.Equal(t, 2, revisions[0].Revision)
+			}
+			revision, err := modelpipeline.GetRevision(context.Background(), c, "ns", "name", 2)
+			assert.Nil(t, err)
+			assert.Equal(t, "echo 1", revision.Spec.Pipeline.Jenkinsfile)
+			assert.Equal(t, "2", getPipeline(t, c).Annotations[v1alpha3.PipelineRevisionAnnoKey])
+			assert.Equal(t, utils.ComputeHash(pip.Spec), getPipeline(t, c).Annotations[v1alpha3.PipelineRevisionSpecHashAnnoKey])
+		},
+	}, {
+		name:         "prune the old revisions",
+		objects:      []client.Object{pip.DeepCopy(), newRevision(oldSpec, 1), newRevision(oldSpec, 2)},
+		historyLimit: 2,
+		verify: func(t *testing.T, c client.Client) {
+			revisions := listRevisions(t, c)
+			if assert.Equal(t, 2, len(revisions)) {
+				assert.Equal(t, 3, revisions[0].Revision)
+				assert.Equal(t, 2, revisions[1].Revision)
+			}
+		},
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := fake.NewClientBuilder().WithScheme(schema).WithObjects(tt.objects...).Build()
+			r := &RevisionReconciler{
+				Client:       c,
+				HistoryLimit: tt.historyLimit,
+				log:          logr.New(log.NullLogSink{}),
+				recorder:     &record.FakeRecorder{},
+			}
+			_, err := r.Reconcile(context.Background(), defaultReq)
+			assert.Nil(t, err)
+			if tt.verify != nil {
+				tt.verify(t, c)
+			}
+		})
+	}
+}
+
+func Test_revisionPredicate(t *testing.T) {
+	oldPipeline := &v1alpha3.Pipeline{}
+	oldPipeline.Annotations = map[string]string{v1alpha3.PipelineRevisionAnnoKey: "1"}
+
+	newPipeline := oldPipeline.DeepCopy()
+	newPipeline.Annotations["other"] = "other"
+	assert.False(t, revisionPredicate.Update(event.UpdateEvent{ObjectOld: oldPipeline, ObjectNew: newPipeline}))
+
+	newPipeline = oldPipeline.DeepCopy()
+	newPipeline.Annotations[v1alpha3.PipelineRevisionAnnoKey] = "2"
+	assert.True(t, revisionPredicate.Update(event.UpdateEvent{ObjectOld: oldPipeline, ObjectNew: newPipeline}))
+
+	newPipeline = oldPipeline.DeepCopy()
+	newPipeline.Spec.Type = v1alpha3.NoScmPipelineType
+	assert.True(t, revisionPredicate.Update(event.UpdateEvent{ObjectOld: oldPipeline, ObjectNew: newPipeline}))
+
+	assert.True(t, revisionPredicate.Create(event.CreateEvent{Object: oldPipeline}))
+	assert.False(t, revisionPredicate.Delete(event.DeleteEvent{Object: oldPipeline}))
+}
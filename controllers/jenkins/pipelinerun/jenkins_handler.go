@@ -17,7 +17,13 @@ limitations under the License.
 package pipelinerun
 
 import (
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"regexp"
 	"strconv"
 	"strings"
 
@@ -110,14 +116,33 @@ func (handler *jenkinsHandler) getPipelineRunResult(devopsProjectName, pipelineN
 	})
 }
 
-func (handler *jenkinsHandler) triggerJenkinsJob(devopsProjectName, pipelineName string, prSpec *v1alpha3.PipelineRunSpec) (*job.PipelineRun, error) {
+// triggerJenkinsJob triggers a Jenkins build for the PipelineRun. The returned run has no ID but the queue item ID
+// if the build is still in the Jenkins queue.
+func (handler *jenkinsHandler) triggerJenkinsJob(devopsProjectName, pipelineName string, pr *v1alpha3.PipelineRun,
+	replaySecret string) (*job.PipelineRun, error) {
 	c := job.BlueOceanClient{JenkinsCore: *handler.JenkinsCore, Organization: "jenkins"}
 
+	prSpec := &pr.Spec
 	branch, err := getSCMRefName(prSpec)
 	if err != nil {
 		return nil, err
 	}
 
+	if runID := pr.Annotations[v1alpha3.PipelineRunReplayAnnoKey]; runID != "" {
+		if _, ok := pr.Annotations[v1alpha3.PipelineRunReplayJenkinsfileSignatureAnnoKey]; ok {
+			// only the Jenkinsfile signed by the apiserver could replace the one of the original build
+			if !pipelinerun.VerifyReplayJenkinsfile(replaySecret, pr) {
+				return nil, errors.New("the signature of the replaced Jenkinsfile is invalid")
+			}
+			return handler.replayWithJenkinsfile(devopsProjectName, pipelineName, runID, pipelinerun.GetReplayJenkinsfile(prSpec))
+		}
+		return c.Replay(job.ReplayOption{
+			Folders: []string{devopsProjectName, pipelineName},
+			Branch:  branch,
+			RunID:   runID,
+		})
+	}
+
 	return c.Build(job.BuildOption{
 		Pipelines:  []string{devopsProjectName, pipelineName},
 		Parameters: parameterConverter{parameters: prSpec.Parameters}.convert(),
@@ -125,6 +150,95 @@ func (handler *jenkinsHandler) triggerJenkinsJob(devopsProjectName, pipelineName
 	})
 }
 
+var (
+	// queueItemPattern matches the URL of a Jenkins queue item, such as "http://jenkins/queue/item/12/"
+	queueItemPattern = regexp.MustCompile(`/queue/item/(\d+)/?$`)
+	// errQueueItemCancelled means the queue item was cancelled before it started a build
+	errQueueItemCancelled = errors.New("the queue item was cancelled")
+)
+
+// replayWithJenkinsfile replays a Jenkins build with another Jenkinsfile. The Blue Ocean API cannot replace the Jenkinsfile,
+// so the build is replayed through the form of Jenkins. The returned run only has the queue item ID of the replay.
+func (handler *jenkinsHandler) replayWithJenkinsfile(devopsProjectName, pipelineName, runID, jenkinsfile string) (*job.PipelineRun, error) {
+	jobPath := fmt.Sprintf("/job/%s/job/%s", devopsProjectName, pipelineName)
+	location, err := handler.postReplay(fmt.Sprintf("%s/%s/replay/run", jobPath, runID), jenkinsfile)
+	if err != nil {
+		return nil, err
+	}
+
+	match := queueItemPattern.FindStringSubmatch(location)
+	if match == nil {
+		return nil, fmt.Errorf("cannot find the queue item of the replay of build %s in %s, location: %q", runID, jobPath, location)
+	}
+
+	jobRun := &job.PipelineRun{}
+	jobRun.QueueID = match[1]
+	jobRun.Pipeline = pipelineName
+	return jobRun, nil
+}
+
+// postReplay submits the replay form, returns the location which Jenkins redirects to
+func (handler *jenkinsHandler) postReplay(api, jenkinsfile string) (location string, err error) {
+	script, _ := json.Marshal(map[string]string{"mainScript": jenkinsfile})
+	form := url.Values{"mainScript": {jenkinsfile}, "json": {string(script)}}
+
+	var req *http.Request
+	if req, err = http.NewRequest(http.MethodPost, handler.URL+api, strings.NewReader(form.Encode())); err != nil {
+		return
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	if err = handler.AuthHandle(req); err != nil {
+		return
+	}
+
+	httpClient := handler.GetClient()
+	// the redirect location might be the queue item of the replay
+	httpClient.CheckRedirect = func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	}
+	var rsp *http.Response
+	if rsp, err = httpClient.Do(req); err != nil {
+		return
+	}
+	defer func() {
+		_ = rsp.Body.Close()
+	}()
+	if rsp.StatusCode >= http.StatusBadRequest {
+		data, _ := ioutil.ReadAll(rsp.Body)
+		err = handler.ErrorHandle(rsp.StatusCode, data)
+		return
+	}
+	location = rsp.Header.Get("Location")
+	return
+}
+
+// getQueuedJobRun returns the run which the queue item starts. The returned run has no ID if the item is still in the
+// queue, errQueueItemCancelled is returned if the item was cancelled.
+func (handler *jenkinsHandler) getQueuedJobRun(pipelineName, queueID string) (*job.PipelineRun, error) {
+	item := struct {
+		Cancelled  bool `json:"cancelled"`
+		Executable *struct {
+			Number int `json:"number"`
+		} `json:"executable"`
+	}{}
+	if err := handler.RequestWithData(http.MethodGet,
+		fmt.Sprintf("/queue/item/%s/api/json?tree=cancelled,executable[number]", queueID), nil, nil,
+		http.StatusOK, &item); err != nil {
+		return nil, err
+	}
+	if item.Cancelled {
+		return nil, errQueueItemCancelled
+	}
+
+	jobRun := &job.PipelineRun{}
+	jobRun.QueueID = queueID
+	jobRun.Pipeline = pipelineName
+	if item.Executable != nil {
+		jobRun.ID = strconv.Itoa(item.Executable.Number)
+	}
+	return jobRun, nil
+}
+
 func (handler *jenkinsHandler) deleteJenkinsJobHistory(pipelineRun *v1alpha3.PipelineRun) (err error) {
 	var buildNum int
 	if buildNum = getJenkinsBuildNumber(pipelineRun); buildNum < 0 {
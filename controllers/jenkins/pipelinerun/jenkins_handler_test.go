@@ -23,6 +23,7 @@ import (
 	"github.com/stretchr/testify/assert"
 	"io/ioutil"
 	"net/http"
+	"net/http/httptest"
 	"testing"
 
 	"github.com/golang/mock/gomock"
@@ -33,6 +34,7 @@ import (
 	corev1 "k8s.io/api/core/v1"
 	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"kubesphere.io/devops/pkg/api/devops/v1alpha3"
+	"kubesphere.io/devops/pkg/models/pipelinerun"
 )
 
 func Test_getJenkinsBuildNumber(t *testing.T) {
@@ -311,3 +313,170 @@ func Test_getJenkinsJobPath(t *testing.T) {
 		})
 	}
 }
+
+func Test_triggerJenkinsJob_replay(t *testing.T) {
+	var replayedScript, replayLocation, blueOceanReplay string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/crumbIssuer/api/json":
+			w.WriteHeader(http.StatusNotFound)
+		case "/job/ns/job/pipeline/2/replay/run":
+			_ = r.ParseForm()
+			replayedScript = r.PostForm.Get("mainScript")
+			w.Header().Set("Location", replayLocation)
+			w.WriteHeader(http.StatusFound)
+		case "/blue/rest/organizations/jenkins/pipelines/ns/pipelines/pipeline/branches/main/runs/2/replay/",
+			"/blue/rest/organizations/jenkins/pipelines/ns/pipelines/pipeline/runs/2/replay/":
+			blueOceanReplay = r.URL.Path
+			_, _ = w.Write([]byte(`{"id": "3", "pipeline": "main"}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+	handler := &jenkinsHandler{&core.JenkinsCore{URL: server.URL}}
+
+	newReplay := func(jenkinsfile string) *v1alpha3.PipelineRun {
+		return &v1alpha3.PipelineRun{
+			ObjectMeta: v1.ObjectMeta{
+				Namespace:   "ns",
+				Annotations: map[string]string{v1alpha3.PipelineRunReplayAnnoKey: "2"},
+			},
+			Spec: v1alpha3.PipelineRunSpec{
+				PipelineRef: &corev1.ObjectReference{Name: "pipeline"},
+				PipelineSpec: &v1alpha3.PipelineSpec{
+					Type:     v1alpha3.NoScmPipelineType,
+					Pipeline: &v1alpha3.NoScmPipeline{Jenkinsfile: jenkinsfile},
+				},
+			},
+		}
+	}
+	signed := newReplay("echo 2")
+	signed.Annotations[v1alpha3.PipelineRunReplayJenkinsfileSignatureAnnoKey] = pipelinerun.SignReplayJenkinsfile("secret", signed)
+	forged := newReplay("echo 2")
+	forged.Annotations[v1alpha3.PipelineRunReplayJenkinsfileSignatureAnnoKey] = pipelinerun.SignReplayJenkinsfile("secret", forged)
+	forged.Spec.PipelineSpec.Pipeline.Jenkinsfile = "echo forged"
+
+	tests := []struct {
+		name          string
+		pipelineRun   *v1alpha3.PipelineRun
+		secret        string
+		location      string
+		wantScript    string
+		wantBlueOcean bool
+		wantID        string
+		wantQueueID   string
+		wantErr       bool
+	}{{
+		name:        "the location is the queue item",
+		pipelineRun: signed,
+		secret:      "secret",
+		location:    server.URL + "/queue/item/7/",
+		wantScript:  "echo 2",
+		wantQueueID: "7",
+	}, {
+		name:        "no queue item in the location",
+		pipelineRun: signed,
+		secret:      "secret",
+		location:    server.URL + "/job/ns/job/pipeline/",
+		wantScript:  "echo 2",
+		wantErr:     true,
+	}, {
+		name:        "the Jenkinsfile does not match its signature",
+		pipelineRun: forged,
+		secret:      "secret",
+		location:    server.URL + "/queue/item/7/",
+		wantErr:     true,
+	}, {
+		name:        "signed by another secret",
+		pipelineRun: signed,
+		secret:      "another",
+		location:    server.URL + "/queue/item/7/",
+		wantErr:     true,
+	}, {
+		name:          "the Jenkinsfile without a signature is ignored",
+		pipelineRun:   newReplay("echo unsigned"),
+		secret:        "secret",
+		wantBlueOcean: true,
+		wantID:        "3",
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			replayedScript, replayLocation, blueOceanReplay = "", tt.location, ""
+			jobRun, err := handler.triggerJenkinsJob("ns", "pipeline", tt.pipelineRun, tt.secret)
+			assert.Equal(t, tt.wantScript, replayedScript)
+			assert.Equal(t, tt.wantBlueOcean, blueOceanReplay != "")
+			if tt.wantErr {
+				assert.NotNil(t, err)
+				return
+			}
+			assert.Nil(t, err)
+			assert.Equal(t, tt.wantID, jobRun.ID)
+			assert.Equal(t, tt.wantQueueID, jobRun.QueueID)
+		})
+	}
+
+	multiBranchReplay := &v1alpha3.PipelineRun{
+		ObjectMeta: v1.ObjectMeta{
+			Annotations: map[string]string{v1alpha3.PipelineRunReplayAnnoKey: "2"},
+		},
+		Spec: v1alpha3.PipelineRunSpec{
+			PipelineSpec: &v1alpha3.PipelineSpec{Type: v1alpha3.MultiBranchPipelineType},
+			SCM:          &v1alpha3.SCM{RefName: "main"},
+		},
+	}
+	jobRun, err := handler.triggerJenkinsJob("ns", "pipeline", multiBranchReplay, "secret")
+	assert.Nil(t, err)
+	assert.Equal(t, "3", jobRun.ID)
+	assert.Equal(t, "main", jobRun.Pipeline)
+}
+
+func Test_getQueuedJobRun(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/queue/item/7/api/json":
+			_, _ = w.Write([]byte(`{"executable": {"number": 4}}`))
+		case "/queue/item/8/api/json":
+			_, _ = w.Write([]byte(`{"executable": null}`))
+		case "/queue/item/9/api/json":
+			_, _ = w.Write([]byte(`{"cancelled": true}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+	handler := &jenkinsHandler{&core.JenkinsCore{URL: server.URL}}
+
+	tests := []struct {
+		name    string
+		queueID string
+		wantID  string
+		wantErr error
+	}{{
+		name:    "the queue item started a build",
+		queueID: "7",
+		wantID:  "4",
+	}, {
+		name:    "the queue item is waiting",
+		queueID: "8",
+	}, {
+		name:    "the queue item was cancelled",
+		queueID: "9",
+		wantErr: errQueueItemCancelled,
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			jobRun, err := handler.getQueuedJobRun("pipeline", tt.queueID)
+			assert.Equal(t, tt.wantErr, err)
+			if err != nil {
+				return
+			}
+			assert.Equal(t, tt.wantID, jobRun.ID)
+			assert.Equal(t, tt.queueID, jobRun.QueueID)
+			assert.Equal(t, "pipeline", jobRun.Pipeline)
+		})
+	}
+
+	_, err := handler.getQueuedJobRun("pipeline", "10")
+	assert.NotNil(t, err)
+}
@@ -19,6 +19,7 @@ package pipelinerun
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"github.com/go-logr/logr"
 	cmstore "kubesphere.io/devops/pkg/store/configmap"
@@ -61,6 +62,8 @@ type Reconciler struct {
 	TokenIssuer          token.Issuer
 	recorder             record.EventRecorder
 	PipelineRunDataStore string
+	// ReplaySecret verifies the replaced Jenkinsfile of a replay, it is shared with the apiserver
+	ReplaySecret string
 }
 
 //+kubebuilder:rbac:groups=devops.kubesphere.io,resources=pipelineruns,verbs=get;list;watch;create;update;patch;delete
@@ -87,6 +90,11 @@ func (r *Reconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Resu
 
 	// DeletionTimestamp.IsZero() means copyPipeline has not been deleted.
 	if !pipelineRunCopied.ObjectMeta.DeletionTimestamp.IsZero() {
+		if pipelineRunCopied.IsBranchRemoved() {
+			// the branch job was removed from Jenkins, there is no history to delete
+			k8sutil.RemoveFinalizer(&pipelineRunCopied.ObjectMeta, v1alpha3.PipelineRunFinalizerName)
+			return ctrl.Result{}, r.Update(context.TODO(), pipelineRunCopied)
+		}
 		if err = jHandler.deleteJenkinsJobHistory(pipelineRunCopied); err != nil {
 			klog.V(4).Infof("failed to delete Jenkins job history from PipelineRun: %s/%s, error: %v",
 				pipelineRunCopied.Namespace, pipelineRunCopied.Name, err)
@@ -149,17 +157,21 @@ func (r *Reconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Resu
 		status := pipelineRunCopied.Status.DeepCopy()
 		pbApplier := pipelineBuildApplier{pipelineBuild}
 		pbApplier.apply(status)
-		// Because the status is a subresource of PipelineRun, we have to update status separately.
-		// See also: https://book-v1.book.kubebuilder.io/basics/status_subresource.html
-		if err := r.updateStatus(ctx, status, req.NamespacedName); err != nil {
-			log.Error(err, "unable to update PipelineRun status.")
-			return ctrl.Result{}, err
-		}
 
 		nodeDetails, err := jHandler.getPipelineNodeDetails(pipelineName, namespaceName, pipelineRunCopied)
 		if err != nil {
 			log.Error(err, "unable to get PipelineRun nodes detail")
 			r.recorder.Eventf(pipelineRunCopied, corev1.EventTypeWarning, v1alpha3.RetrieveFailed, "Failed to retrieve nodes detail from Jenkins, and error was %v", err)
+		} else {
+			// the stage summaries are kept as they were if the nodes detail is unavailable
+			stagesApplier{nodeDetails}.apply(status)
+		}
+
+		// Because the status is a subresource of PipelineRun, we have to update status separately.
+		// See also: https://book-v1.book.kubebuilder.io/basics/status_subresource.html
+		if err := r.updateStatus(ctx, status, req.NamespacedName); err != nil {
+			log.Error(err, "unable to update PipelineRun status.")
+			return ctrl.Result{}, err
 		}
 		runResultJSON, err := json.Marshal(pipelineBuild)
 		if err != nil {
@@ -203,13 +215,37 @@ func (r *Reconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Resu
 	}
 	// create trigger handler
 	triggerHandler := &jenkinsHandler{jenkinsCore}
-	// first run
-	jobRun, err := triggerHandler.triggerJenkinsJob(namespaceName, pipelineName, &pipelineRunCopied.Spec)
+	if pipelineRunCopied.Annotations == nil {
+		pipelineRunCopied.Annotations = make(map[string]string)
+	}
+	var jobRun *job.PipelineRun
+	if queueID := pipelineRunCopied.Annotations[v1alpha3.JenkinsQueueItemIDAnnoKey]; queueID != "" {
+		// the build was triggered, but it was still in the Jenkins queue
+		jobRun, err = triggerHandler.getQueuedJobRun(pipelineName, queueID)
+		if errors.Is(err, errQueueItemCancelled) {
+			log.Info("The queue item of PipelineRun was cancelled", "queueID", queueID)
+			return ctrl.Result{}, r.cancelQueuedPipelineRun(ctx, pipelineRunCopied)
+		}
+	} else {
+		// first run
+		jobRun, err = triggerHandler.triggerJenkinsJob(namespaceName, pipelineName, pipelineRunCopied, r.ReplaySecret)
+	}
 	if err != nil {
 		log.Error(err, "unable to run pipeline", "namespace", namespaceName, "pipeline", pipeline.Name)
 		r.recorder.Eventf(pipelineRunCopied, corev1.EventTypeWarning, v1alpha3.TriggerFailed, "Failed to trigger PipelineRun %s, and error was %v", req.NamespacedName, err)
 		return ctrl.Result{}, err
 	}
+	if jobRun.ID == "" {
+		// check the queue item again later instead of waiting for it here
+		if pipelineRunCopied.Annotations[v1alpha3.JenkinsQueueItemIDAnnoKey] != jobRun.QueueID {
+			pipelineRunCopied.Annotations[v1alpha3.JenkinsQueueItemIDAnnoKey] = jobRun.QueueID
+			if err := r.updateLabelsAndAnnotations(ctx, pipelineRunCopied); err != nil {
+				log.Error(err, "unable to update PipelineRun labels and annotations.")
+				return ctrl.Result{}, err
+			}
+		}
+		return ctrl.Result{RequeueAfter: 3 * time.Second}, nil
+	}
 	// check if there is still a same PipelineRun
 	if exists, err := r.hasSamePipelineRun(jobRun, pipeline); err != nil {
 		return ctrl.Result{}, err
@@ -226,10 +262,8 @@ func (r *Reconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Resu
 	log.Info("Triggered a PipelineRun", "runID", jobRun.ID)
 
 	// set Jenkins run ID
-	if pipelineRunCopied.Annotations == nil {
-		pipelineRunCopied.Annotations = make(map[string]string)
-	}
 	pipelineRunCopied.Annotations[v1alpha3.JenkinsPipelineRunIDAnnoKey] = jobRun.ID
+	delete(pipelineRunCopied.Annotations, v1alpha3.JenkinsQueueItemIDAnnoKey)
 
 	// the Update method only updates fields except subresource: status
 	if err := r.updateLabelsAndAnnotations(ctx, pipelineRunCopied); err != nil {
@@ -368,6 +402,31 @@ func (r *Reconciler) makePipelineRunOrphan(ctx context.Context, pr *v1alpha3.Pip
 	return
 }
 
+// cancelQueuedPipelineRun completes the PipelineRun whose Jenkins queue item was cancelled before it started a build
+func (r *Reconciler) cancelQueuedPipelineRun(ctx context.Context, pr *v1alpha3.PipelineRun) (err error) {
+	delete(pr.Annotations, v1alpha3.JenkinsQueueItemIDAnnoKey)
+	if err = r.updateLabelsAndAnnotations(ctx, pr); err != nil {
+		return
+	}
+	now := v1.Now()
+	status := pr.Status.DeepCopy()
+	status.AddCondition(&v1alpha3.Condition{
+		Type:               v1alpha3.ConditionSucceeded,
+		Status:             v1alpha3.ConditionFalse,
+		Reason:             "CANCELLED",
+		Message:            "the Jenkins queue item was cancelled before it started a build",
+		LastTransitionTime: now,
+		LastProbeTime:      now,
+	})
+	status.Phase = v1alpha3.Cancelled
+	status.CompletionTime = &now
+	status.UpdateTime = &now
+	if err = r.updateStatus(ctx, status, client.ObjectKeyFromObject(pr)); err == nil {
+		r.recorder.Eventf(pr, corev1.EventTypeWarning, v1alpha3.TriggerFailed, "The Jenkins queue item of PipelineRun %s was cancelled", client.ObjectKeyFromObject(pr))
+	}
+	return
+}
+
 func (r *Reconciler) getOrCreateJenkinsCore(annotations map[string]string) (*core.JenkinsCore, error) {
 	creator, ok := annotations[v1alpha3.PipelineRunCreatorAnnoKey]
 	if !ok || creator == "" {
@@ -35,6 +35,8 @@ import (
 	"kubesphere.io/devops/pkg/api/devops/v1alpha3"
 	"kubesphere.io/devops/pkg/client/clientset/versioned/scheme"
 	"kubesphere.io/devops/pkg/jwt/token"
+	"net/http"
+	"net/http/httptest"
 	"reflect"
 	controllerruntime "sigs.k8s.io/controller-runtime"
 	ctrl "sigs.k8s.io/controller-runtime"
@@ -432,6 +434,11 @@ func TestPipelineRunReconcile(t *testing.T) {
 		Name:      "pipeline",
 	}
 
+	branchRemovedPipelineRun := normalPipeline.DeepCopy()
+	branchRemovedPipelineRun.LabelAsBranchRemoved()
+	branchRemovedPipelineRun.Finalizers = []string{v1alpha3.PipelineRunFinalizerName}
+	branchRemovedPipelineRun.DeletionTimestamp = &now
+
 	defaultReq := ctrl.Request{
 		NamespacedName: types.NamespacedName{
 			Namespace: "ns", Name: "name",
@@ -463,6 +470,11 @@ func TestPipelineRunReconcile(t *testing.T) {
 		k8sclient: fake.NewClientBuilder().WithScheme(schema).WithObjects(normalPipeline.DeepCopy()).Build(),
 		request:   defaultReq,
 		wantErr:   false,
+	}, {
+		name:      "delete the PipelineRun of a removed branch without Jenkins",
+		k8sclient: fake.NewClientBuilder().WithScheme(schema).WithObjects(branchRemovedPipelineRun.DeepCopy()).Build(),
+		request:   defaultReq,
+		wantErr:   false,
 	}}
 	for i, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -480,6 +492,87 @@ func TestPipelineRunReconcile(t *testing.T) {
 	}
 }
 
+func TestPipelineRunReconcile_queued(t *testing.T) {
+	schema, err := v1alpha3.SchemeBuilder.Register().Build()
+	assert.Nil(t, err)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/queue/item/7/api/json":
+			_, _ = w.Write([]byte(`{"executable": {"number": 4}}`))
+		case "/queue/item/8/api/json":
+			_, _ = w.Write([]byte(`{"executable": null}`))
+		case "/queue/item/9/api/json":
+			_, _ = w.Write([]byte(`{"cancelled": true}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	pipeline := &v1alpha3.Pipeline{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "pipeline"},
+		Spec:       v1alpha3.PipelineSpec{Type: v1alpha3.NoScmPipelineType},
+	}
+	newPipelineRun := func(queueID string) *v1alpha3.PipelineRun {
+		return &v1alpha3.PipelineRun{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: "ns",
+				Name:      "name",
+				Annotations: map[string]string{
+					v1alpha3.PipelineRunReplayAnnoKey:  "2",
+					v1alpha3.JenkinsQueueItemIDAnnoKey: queueID,
+				},
+			},
+			Spec: v1alpha3.PipelineRunSpec{
+				PipelineRef: &v1.ObjectReference{Namespace: "ns", Name: "pipeline"},
+			},
+		}
+	}
+
+	tests := []struct {
+		name        string
+		queueID     string
+		wantRequeue bool
+		wantRunID   string
+		wantPhase   v1alpha3.RunPhase
+	}{{
+		name:      "the queue item started a build",
+		queueID:   "7",
+		wantRunID: "4",
+	}, {
+		name:        "the queue item is waiting",
+		queueID:     "8",
+		wantRequeue: true,
+	}, {
+		name:      "the queue item was cancelled",
+		queueID:   "9",
+		wantPhase: v1alpha3.Cancelled,
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := &Reconciler{
+				Client:      fake.NewClientBuilder().WithScheme(schema).WithObjects(pipeline.DeepCopy(), newPipelineRun(tt.queueID)).Build(),
+				log:         logr.New(log.NullLogSink{}),
+				JenkinsCore: core.JenkinsCore{URL: server.URL},
+				recorder:    &record.FakeRecorder{},
+			}
+			key := types.NamespacedName{Namespace: "ns", Name: "name"}
+			result, err := r.Reconcile(context.Background(), ctrl.Request{NamespacedName: key})
+			assert.Nil(t, err)
+			assert.Equal(t, tt.wantRequeue, result.RequeueAfter > 0)
+
+			pipelineRun := &v1alpha3.PipelineRun{}
+			assert.Nil(t, r.Get(context.Background(), key, pipelineRun))
+			runID, _ := pipelineRun.GetPipelineRunID()
+			assert.Equal(t, tt.wantRunID, runID)
+			assert.Equal(t, tt.wantPhase, pipelineRun.Status.Phase)
+			_, queued := pipelineRun.Annotations[v1alpha3.JenkinsQueueItemIDAnnoKey]
+			assert.Equal(t, tt.wantRequeue, queued)
+		})
+	}
+}
+
 func TestStorePipelineRunData(t *testing.T) {
 	schema, err := v1alpha3.SchemeBuilder.Register().Build()
 	assert.Nil(t, err)
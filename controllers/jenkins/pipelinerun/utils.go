@@ -18,10 +18,12 @@ package pipelinerun
 
 import (
 	"time"
+	"unicode/utf8"
 
 	"github.com/jenkins-zh/jenkins-client/pkg/job"
 	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"kubesphere.io/devops/pkg/api/devops/v1alpha3"
+	"kubesphere.io/devops/pkg/models/pipelinerun"
 )
 
 // JenkinsRunState represents current PipelineRun state.
@@ -146,6 +148,82 @@ func (pbApplier pipelineBuildApplier) whenPipelineRunFinished(condition *v1alpha
 	}
 }
 
+// maxStepDescriptionLength is the max length of the step description in the stage summaries
+const maxStepDescriptionLength = 256
+
+// stagesApplier applies the node details of a PipelineRun to the stage summaries of PipelineRunStatus.
+type stagesApplier struct {
+	nodeDetails []pipelinerun.NodeDetail
+}
+
+func (applier stagesApplier) apply(prStatus *v1alpha3.PipelineRunStatus) {
+	nodeDetails := applier.nodeDetails
+	if len(nodeDetails) > v1alpha3.MaxStageStatuses {
+		nodeDetails = nodeDetails[:v1alpha3.MaxStageStatuses]
+	}
+
+	var stages []v1alpha3.StageStatus
+	var runningStage, failedStage string
+	for i := range nodeDetails {
+		stage := newStageStatus(&nodeDetails[i])
+		stages = append(stages, stage)
+		if runningStage == "" && (stage.State == Running.String() || stage.State == Paused.String()) {
+			runningStage = stage.Name
+		}
+		if failedStage == "" && isFailedResult(stage.Result) {
+			failedStage = stage.Name
+		}
+	}
+	prStatus.Stages = stages
+	prStatus.CurrentStage = runningStage
+	if prStatus.CurrentStage == "" {
+		prStatus.CurrentStage = failedStage
+	}
+}
+
+func newStageStatus(nodeDetail *pipelinerun.NodeDetail) v1alpha3.StageStatus {
+	node := &nodeDetail.Node
+	stage := v1alpha3.StageStatus{
+		Name:   node.DisplayName,
+		State:  node.State,
+		Result: node.Result,
+	}
+	if !node.StartTime.IsZero() {
+		stage.StartTime = &v1.Time{Time: node.StartTime.Time}
+	}
+	if node.DurationInMillis > 0 {
+		stage.Duration = &v1.Duration{Duration: time.Duration(node.DurationInMillis) * time.Millisecond}
+	}
+	for i := range nodeDetail.Steps {
+		if step := &nodeDetail.Steps[i]; isFailedResult(step.Result) {
+			stage.FailedStep = &v1alpha3.StepSummary{
+				Name:        step.DisplayName,
+				Description: truncate(step.DisplayDescription, maxStepDescriptionLength),
+				Result:      step.Result,
+			}
+			break
+		}
+	}
+	return stage
+}
+
+// truncate cuts the text to the max length without breaking a UTF-8 character
+func truncate(text string, maxLength int) string {
+	if len(text) <= maxLength {
+		return text
+	}
+	cut := maxLength
+	for cut > 0 && !utf8.RuneStart(text[cut]) {
+		cut--
+	}
+	return text[:cut]
+}
+
+// isFailedResult returns true if the result is not successful
+func isFailedResult(result string) bool {
+	return result == Failure.String() || result == Unstable.String() || result == Aborted.String()
+}
+
 // parameterConverter is responsible to convert Parameter slice of PipelineRun into job.Parameter slice.
 type parameterConverter struct {
 	parameters []v1alpha3.Parameter
@@ -25,6 +25,7 @@ import (
 	"github.com/stretchr/testify/assert"
 	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"kubesphere.io/devops/pkg/api/devops/v1alpha3"
+	"kubesphere.io/devops/pkg/models/pipelinerun"
 )
 
 func Test_pipelineBuildApplier_apply(t *testing.T) {
@@ -365,3 +366,101 @@ func Test_parameterConverter_convert(t *testing.T) {
 		})
 	}
 }
+
+func Test_stagesApplier_apply(t *testing.T) {
+	startTime := time.Date(2022, 9, 1, 8, 0, 0, 0, time.UTC)
+	newNode := func(name, state, result string, steps ...pipelinerun.Step) pipelinerun.NodeDetail {
+		return pipelinerun.NodeDetail{
+			Node: job.Node{
+				DisplayName:      name,
+				State:            state,
+				Result:           result,
+				StartTime:        job.Time{Time: startTime},
+				DurationInMillis: 1500,
+			},
+			Steps: steps,
+		}
+	}
+	newStep := func(name, description, result string) pipelinerun.Step {
+		return pipelinerun.Step{Step: job.Step{DisplayName: name, DisplayDescription: description, Result: result}}
+	}
+
+	tests := []struct {
+		name        string
+		nodeDetails []pipelinerun.NodeDetail
+		verify      func(t *testing.T, prStatus *v1alpha3.PipelineRunStatus)
+	}{{
+		name: "no stages",
+		verify: func(t *testing.T, prStatus *v1alpha3.PipelineRunStatus) {
+			assert.Empty(t, prStatus.Stages)
+			assert.Empty(t, prStatus.CurrentStage)
+		},
+	}, {
+		name: "running stage",
+		nodeDetails: []pipelinerun.NodeDetail{
+			newNode("build", "FINISHED", "SUCCESS", newStep("Shell Script", "make", "SUCCESS")),
+			newNode("test", "RUNNING", "UNKNOWN"),
+			{Node: job.Node{DisplayName: "deploy"}},
+		},
+		verify: func(t *testing.T, prStatus *v1alpha3.PipelineRunStatus) {
+			assert.Equal(t, "test", prStatus.CurrentStage)
+			assert.Equal(t, []v1alpha3.StageStatus{{
+				Name:      "build",
+				State:     "FINISHED",
+				Result:    "SUCCESS",
+				StartTime: &v1.Time{Time: startTime},
+				Duration:  &v1.Duration{Duration: 1500 * time.Millisecond},
+			}, {
+				Name:      "test",
+				State:     "RUNNING",
+				Result:    "UNKNOWN",
+				StartTime: &v1.Time{Time: startTime},
+				Duration:  &v1.Duration{Duration: 1500 * time.Millisecond},
+			}, {
+				Name: "deploy",
+			}}, prStatus.Stages)
+		},
+	}, {
+		name: "failed stage",
+		nodeDetails: []pipelinerun.NodeDetail{
+			newNode("build", "FINISHED", "SUCCESS"),
+			newNode("test", "FINISHED", "FAILURE",
+				newStep("Shell Script", "make fmt", "SUCCESS"),
+				newStep("Shell Script", "make test", "FAILURE"),
+				newStep("Shell Script", "make e2e", "NOT_BUILT")),
+			newNode("deploy", "SKIPPED", "NOT_BUILT"),
+		},
+		verify: func(t *testing.T, prStatus *v1alpha3.PipelineRunStatus) {
+			assert.Equal(t, "test", prStatus.CurrentStage)
+			assert.Equal(t, &v1alpha3.StepSummary{Name: "Shell Script", Description: "make test", Result: "FAILURE"},
+				prStatus.Stages[1].FailedStep)
+			assert.Nil(t, prStatus.Stages[0].FailedStep)
+		},
+	}, {
+		name: "too many stages",
+		nodeDetails: func() (nodeDetails []pipelinerun.NodeDetail) {
+			for i := 0; i < v1alpha3.MaxStageStatuses+10; i++ {
+				nodeDetails = append(nodeDetails, newNode("stage", "FINISHED", "SUCCESS"))
+			}
+			return
+		}(),
+		verify: func(t *testing.T, prStatus *v1alpha3.PipelineRunStatus) {
+			assert.Len(t, prStatus.Stages, v1alpha3.MaxStageStatuses)
+			assert.Empty(t, prStatus.CurrentStage)
+		},
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			prStatus := &v1alpha3.PipelineRunStatus{CurrentStage: "old", Stages: []v1alpha3.StageStatus{{Name: "old"}}}
+			stagesApplier{tt.nodeDetails}.apply(prStatus)
+			tt.verify(t, prStatus)
+		})
+	}
+}
+
+func Test_truncate(t *testing.T) {
+	assert.Equal(t, "abc", truncate("abc", 3))
+	assert.Equal(t, "ab", truncate("abc", 2))
+	assert.Equal(t, "a", truncate("a你好", 3))
+	assert.Equal(t, "a你", truncate("a你好", 4))
+}
@@ -0,0 +1,110 @@
+/*
+Copyright 2022 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sharedlibrary
+
+import (
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"kubesphere.io/devops/pkg/client/devops"
+	"sigs.k8s.io/yaml"
+)
+
+// globalLibrariesPath is the path of the global shared libraries in the Jenkins CasC
+var globalLibrariesPath = []string{"unclassified", "globalLibraries", "libraries"}
+
+// replaceOrAddGlobalLibrary replaces the global library with the same name, or adds it if it does not exist
+func replaceOrAddGlobalLibrary(config string, library *devops.SharedLibrary) (string, error) {
+	scm := map[string]interface{}{
+		"remote": library.Remote,
+	}
+	if library.CredentialID != "" {
+		scm["credentialsId"] = library.CredentialID
+	}
+	target := map[string]interface{}{
+		"name":                 library.Name,
+		"defaultVersion":       library.DefaultVersion,
+		"implicit":             library.Implicit,
+		"allowVersionOverride": true,
+		"includeInChangesets":  true,
+		"retriever": map[string]interface{}{
+			"modernSCM": map[string]interface{}{
+				"scm": map[string]interface{}{
+					"git": scm,
+				},
+			},
+		},
+	}
+
+	return updateGlobalLibraries(config, func(libraries []interface{}) ([]interface{}, bool) {
+		for i := range libraries {
+			if isLibrary(libraries[i], library.Name) {
+				libraries[i] = target
+				return libraries, true
+			}
+		}
+		return append(libraries, target), true
+	})
+}
+
+// removeGlobalLibrary removes the global library, the config is not changed if the library does not exist
+func removeGlobalLibrary(config, name string) (string, error) {
+	return updateGlobalLibraries(config, func(libraries []interface{}) ([]interface{}, bool) {
+		result := make([]interface{}, 0, len(libraries))
+		for i := range libraries {
+			if !isLibrary(libraries[i], name) {
+				result = append(result, libraries[i])
+			}
+		}
+		return result, len(result) != len(libraries)
+	})
+}
+
+// updateGlobalLibraries updates the global libraries, the update function returns false if nothing needs to be changed
+func updateGlobalLibraries(config string, update func(libraries []interface{}) ([]interface{}, bool)) (string, error) {
+	casc := map[string]interface{}{}
+	if err := yaml.Unmarshal([]byte(config), &casc); err != nil {
+		return "", fmt.Errorf("failed to unmarshal YAML to map structure, error: %v", err)
+	}
+	libraries, _, err := unstructured.NestedSlice(casc, globalLibrariesPath...)
+	if err != nil {
+		return "", err
+	}
+	// compare the normalized configs, the config is kept as it is if the libraries are not changed
+	current, err := yaml.Marshal(casc)
+	if err != nil {
+		return "", err
+	}
+
+	libraries, changed := update(libraries)
+	if !changed {
+		return config, nil
+	}
+	if err = unstructured.SetNestedSlice(casc, libraries, globalLibrariesPath...); err != nil {
+		return "", err
+	}
+	updated, err := yaml.Marshal(casc)
+	if err != nil || string(updated) == string(current) {
+		return config, err
+	}
+	return string(updated), nil
+}
+
+func isLibrary(library interface{}, name string) bool {
+	libraryMap, ok := library.(map[string]interface{})
+	return ok && libraryMap["name"] == name
+}
@@ -0,0 +1,98 @@
+/*
+Copyright 2022 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sharedlibrary
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"kubesphere.io/devops/pkg/client/devops"
+	"sigs.k8s.io/yaml"
+)
+
+const cascConfig = `jenkins:
+  systemMessage: KubeSphere DevOps
+unclassified:
+  gitLabServers:
+    servers:
+    - name: https://gitlab.com
+`
+
+func getGlobalLibraries(t *testing.T, config string) []interface{} {
+	casc := map[string]interface{}{}
+	assert.Nil(t, yaml.Unmarshal([]byte(config), &casc))
+	libraries, _, err := unstructured.NestedSlice(casc, globalLibrariesPath...)
+	assert.Nil(t, err)
+	return libraries
+}
+
+func TestGlobalLibrary(t *testing.T) {
+	library := &devops.SharedLibrary{
+		Name:           "lib",
+		Remote:         "https://github.com/linuxsuren/lib.git",
+		CredentialID:   "git",
+		DefaultVersion: "main",
+	}
+
+	// nothing to remove
+	config, err := removeGlobalLibrary(cascConfig, "lib")
+	assert.Nil(t, err)
+	assert.Equal(t, cascConfig, config)
+
+	config, err = replaceOrAddGlobalLibrary(cascConfig, library)
+	assert.Nil(t, err)
+	assert.Contains(t, config, "systemMessage: KubeSphere DevOps")
+	assert.Contains(t, config, "gitLabServers")
+	libraries := getGlobalLibraries(t, config)
+	if assert.Len(t, libraries, 1) {
+		lib := libraries[0].(map[string]interface{})
+		assert.Equal(t, "lib", lib["name"])
+		assert.Equal(t, "main", lib["defaultVersion"])
+		assert.Equal(t, false, lib["implicit"])
+		remote, _, _ := unstructured.NestedString(lib, "retriever", "modernSCM", "scm", "git", "remote")
+		assert.Equal(t, "https://github.com/linuxsuren/lib.git", remote)
+		credentialID, _, _ := unstructured.NestedString(lib, "retriever", "modernSCM", "scm", "git", "credentialsId")
+		assert.Equal(t, "git", credentialID)
+	}
+
+	// the config stays the same if nothing changed
+	unchanged, err := replaceOrAddGlobalLibrary(config, library)
+	assert.Nil(t, err)
+	assert.Equal(t, config, unchanged)
+
+	library.Implicit = true
+	config, err = replaceOrAddGlobalLibrary(config, library)
+	assert.Nil(t, err)
+	config, err = replaceOrAddGlobalLibrary(config, &devops.SharedLibrary{Name: "another", Remote: "https://github.com/linuxsuren/another.git"})
+	assert.Nil(t, err)
+	libraries = getGlobalLibraries(t, config)
+	if assert.Len(t, libraries, 2) {
+		assert.Equal(t, true, libraries[0].(map[string]interface{})["implicit"])
+		assert.Equal(t, "another", libraries[1].(map[string]interface{})["name"])
+	}
+
+	config, err = removeGlobalLibrary(config, "lib")
+	assert.Nil(t, err)
+	libraries = getGlobalLibraries(t, config)
+	if assert.Len(t, libraries, 1) {
+		assert.Equal(t, "another", libraries[0].(map[string]interface{})["name"])
+	}
+
+	_, err = replaceOrAddGlobalLibrary("invalid", library)
+	assert.NotNil(t, err)
+}
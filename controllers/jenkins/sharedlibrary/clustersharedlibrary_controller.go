@@ -0,0 +1,192 @@
+/*
+Copyright 2022 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sharedlibrary
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/go-logr/logr"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"kubesphere.io/devops/pkg/api/devops/v1alpha3"
+	"kubesphere.io/devops/pkg/client/devops"
+	"kubesphere.io/devops/pkg/utils/k8sutil"
+	"kubesphere.io/devops/pkg/utils/stringutils"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+)
+
+//+kubebuilder:rbac:groups=devops.kubesphere.io,resources=clustersharedlibraries,verbs=get;list;watch;update
+//+kubebuilder:rbac:groups=devops.kubesphere.io,resources=clustersharedlibraries/status,verbs=get;update
+//+kubebuilder:rbac:groups=devops.kubesphere.io,resources=gitrepositories,verbs=get;list;watch
+//+kubebuilder:rbac:groups="",resources=configmaps,verbs=get;update
+//+kubebuilder:rbac:groups="",resources=events,verbs=create;patch
+
+var errEmptyCasC = errors.New("the Jenkins CasC config is empty")
+
+// ClusterReconciler configures the ClusterSharedLibrary as a global library through the Jenkins CasC.
+// The GitRepository of a ClusterSharedLibrary must be in the system namespace which holds the Jenkins CasC,
+// otherwise anyone who could write a GitRepository in a DevOps project would change the library of all the Pipelines.
+type ClusterReconciler struct {
+	TargetConfigMapName      string
+	TargetConfigMapNamespace string
+	TargetConfigMapKey       string
+	Interval                 time.Duration
+
+	client.Client
+	log      logr.Logger
+	recorder record.EventRecorder
+}
+
+// Reconcile is the main entrypoint of this controller
+func (r *ClusterReconciler) Reconcile(ctx context.Context, req ctrl.Request) (result ctrl.Result, err error) {
+	log := r.log.WithValues("ClusterSharedLibrary", req.Name)
+	library := &v1alpha3.ClusterSharedLibrary{}
+	if err = r.Get(ctx, req.NamespacedName, library); err != nil {
+		err = client.IgnoreNotFound(err)
+		return
+	}
+
+	if !library.DeletionTimestamp.IsZero() {
+		// nothing needs to be removed if there is no Jenkins CasC
+		if err = r.updateCasC(ctx, func(config string) (string, error) {
+			return removeGlobalLibrary(config, library.Name)
+		}); client.IgnoreNotFound(err) != nil && err != errEmptyCasC {
+			log.Error(err, "failed to remove the shared library from the Jenkins CasC")
+			return
+		}
+		k8sutil.RemoveFinalizer(&library.ObjectMeta, v1alpha3.SharedLibraryFinalizerName)
+		err = r.Update(ctx, library)
+		return
+	}
+
+	if k8sutil.AddFinalizer(&library.ObjectMeta, v1alpha3.SharedLibraryFinalizerName) {
+		if err = r.Update(ctx, library); err != nil {
+			return
+		}
+	}
+
+	var jenkinsLibrary *devops.SharedLibrary
+	if namespace := r.getGitRepositoryNamespace(library); namespace != r.TargetConfigMapNamespace {
+		err = fmt.Errorf("the GitRepository of a ClusterSharedLibrary must be in the namespace %s instead of %s",
+			r.TargetConfigMapNamespace, namespace)
+	} else {
+		jenkinsLibrary, err = getLibrary(ctx, r.Client, library.Name, namespace, library.Spec)
+	}
+	if err == nil {
+		err = r.updateCasC(ctx, func(config string) (string, error) {
+			return replaceOrAddGlobalLibrary(config, jenkinsLibrary)
+		})
+	}
+	if err != nil {
+		log.Error(err, "failed to configure the shared library in the Jenkins CasC")
+		r.recorder.Event(library, v1.EventTypeWarning, string(v1alpha3.SharedLibraryPhaseFailed), err.Error())
+	}
+
+	if status, changed := newStatus(library.Status, library.Generation, err); changed {
+		library.Status = status
+		if updateErr := r.Status().Update(ctx, library); updateErr != nil {
+			log.Error(updateErr, "failed to update the status")
+		}
+	}
+	if err == nil {
+		// make sure the library always could be in the Jenkins CasC
+		result = ctrl.Result{RequeueAfter: r.Interval}
+	}
+	return
+}
+
+// getGitRepositoryNamespace returns the namespace of the GitRepository, it is the system namespace by default
+func (r *ClusterReconciler) getGitRepositoryNamespace(library *v1alpha3.ClusterSharedLibrary) string {
+	return stringutils.SetOrDefault(library.Spec.GitRepository.Namespace, r.TargetConfigMapNamespace)
+}
+
+// findLibraries returns the ClusterSharedLibraries which load from the GitRepository
+func (r *ClusterReconciler) findLibraries(repo client.Object) (requests []reconcile.Request) {
+	if repo.GetNamespace() != r.TargetConfigMapNamespace {
+		return
+	}
+	libraries := &v1alpha3.ClusterSharedLibraryList{}
+	if err := r.List(context.Background(), libraries); err != nil {
+		r.log.Error(err, "failed to list ClusterSharedLibraries")
+		return
+	}
+	for i := range libraries.Items {
+		library := &libraries.Items[i]
+		if referencesGitRepository(library.Spec, r.getGitRepositoryNamespace(library), repo) {
+			requests = append(requests, reconcile.Request{NamespacedName: client.ObjectKeyFromObject(library)})
+		}
+	}
+	return
+}
+
+// updateCasC updates the Jenkins CasC ConfigMap, it will be applied to Jenkins by the Jenkins config controller
+func (r *ClusterReconciler) updateCasC(ctx context.Context, update func(config string) (string, error)) (err error) {
+	cm := &v1.ConfigMap{}
+	if err = r.Get(ctx, types.NamespacedName{
+		Namespace: r.TargetConfigMapNamespace,
+		Name:      r.TargetConfigMapName,
+	}, cm); err != nil {
+		return
+	}
+	data := strings.TrimSpace(cm.Data[r.TargetConfigMapKey])
+	if data == "" {
+		return errEmptyCasC
+	}
+
+	var updated string
+	if updated, err = update(data); err != nil || updated == data {
+		return
+	}
+	cm.Data[r.TargetConfigMapKey] = updated
+	return r.Update(ctx, cm)
+}
+
+// GetName returns the name of this controller
+func (r *ClusterReconciler) GetName() string {
+	return "clustersharedlibrary-controller"
+}
+
+// GetGroupName returns the group name of this controller
+func (r *ClusterReconciler) GetGroupName() string {
+	return controllerGroupName
+}
+
+// SetupWithManager setups the log and recorder
+func (r *ClusterReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	r.log = ctrl.Log.WithName(r.GetName())
+	r.recorder = mgr.GetEventRecorderFor(r.GetName())
+	r.TargetConfigMapName = stringutils.SetOrDefault(r.TargetConfigMapName, "jenkins-casc-config")
+	r.TargetConfigMapNamespace = stringutils.SetOrDefault(r.TargetConfigMapNamespace, "kubesphere-devops-system")
+	r.TargetConfigMapKey = stringutils.SetOrDefault(r.TargetConfigMapKey, "jenkins_user.yaml")
+	if r.Interval == 0 {
+		r.Interval = defaultInterval
+	}
+	return ctrl.NewControllerManagedBy(mgr).
+		Named(r.GetName()).
+		For(&v1alpha3.ClusterSharedLibrary{}).
+		Watches(&source.Kind{Type: &v1alpha3.GitRepository{}}, handler.EnqueueRequestsFromMapFunc(r.findLibraries)).
+		Complete(r)
+}
@@ -0,0 +1,187 @@
+/*
+Copyright 2022 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sharedlibrary
+
+import (
+	"context"
+	"testing"
+
+	"github.com/go-logr/logr"
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	mgrcore "kubesphere.io/devops/controllers/core"
+	"kubesphere.io/devops/pkg/api/devops/v1alpha3"
+	"kubesphere.io/devops/pkg/client/devops"
+	controllerruntime "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+func TestClusterReconciler_SetupWithManager(t *testing.T) {
+	schema, err := v1alpha3.SchemeBuilder.Register().Build()
+	assert.Nil(t, err)
+
+	r := &ClusterReconciler{}
+	assert.Nil(t, r.SetupWithManager(&mgrcore.FakeManager{Scheme: schema}))
+	assert.Equal(t, "jenkins-casc-config", r.TargetConfigMapName)
+	assert.Equal(t, "kubesphere-devops-system", r.TargetConfigMapNamespace)
+	assert.Equal(t, "jenkins_user.yaml", r.TargetConfigMapKey)
+	assert.Equal(t, defaultInterval, r.Interval)
+}
+
+func TestClusterReconciler_Reconcile(t *testing.T) {
+	schema, err := v1alpha3.SchemeBuilder.Register().Build()
+	assert.Nil(t, err)
+	assert.Nil(t, v1.SchemeBuilder.AddToScheme(schema))
+
+	req := controllerruntime.Request{NamespacedName: types.NamespacedName{Name: "lib"}}
+	library := &v1alpha3.ClusterSharedLibrary{}
+	library.SetName("lib")
+	library.Spec = v1alpha3.SharedLibrarySpec{
+		GitRepository:  v1alpha3.GitRepositoryReference{Name: "repo"},
+		DefaultVersion: "main",
+	}
+
+	deletingLibrary := library.DeepCopy()
+	now := metav1.Now()
+	deletingLibrary.SetDeletionTimestamp(&now)
+	deletingLibrary.SetFinalizers([]string{v1alpha3.SharedLibraryFinalizerName})
+
+	newCasC := func(config string) *v1.ConfigMap {
+		cm := &v1.ConfigMap{}
+		cm.SetNamespace("kubesphere-devops-system")
+		cm.SetName("jenkins-casc-config")
+		cm.Data = map[string]string{"jenkins_user.yaml": config}
+		return cm
+	}
+	getCasC := func(t *testing.T, c client.Client) string {
+		cm := &v1.ConfigMap{}
+		assert.Nil(t, c.Get(context.Background(), types.NamespacedName{
+			Namespace: "kubesphere-devops-system", Name: "jenkins-casc-config"}, cm))
+		return cm.Data["jenkins_user.yaml"]
+	}
+	configWithLibrary, err := replaceOrAddGlobalLibrary(cascConfig, &devops.SharedLibrary{
+		Name:           "lib",
+		Remote:         "https://github.com/linuxsuren/lib.git",
+		CredentialID:   "git",
+		DefaultVersion: "main",
+	})
+	assert.Nil(t, err)
+
+	tests := []struct {
+		name    string
+		objects []client.Object
+		wantErr bool
+		requeue bool
+		verify  func(t *testing.T, c client.Client)
+	}{{
+		name: "not found",
+	}, {
+		name:    "the Jenkins CasC is not found",
+		objects: []client.Object{library.DeepCopy(), newGitRepository("kubesphere-devops-system")},
+		wantErr: true,
+		verify: func(t *testing.T, c client.Client) {
+			result := &v1alpha3.ClusterSharedLibrary{}
+			assert.Nil(t, c.Get(context.Background(), req.NamespacedName, result))
+			assert.Equal(t, v1alpha3.SharedLibraryPhaseFailed, result.Status.Phase)
+		},
+	}, {
+		name:    "configure the library",
+		objects: []client.Object{library.DeepCopy(), newGitRepository("kubesphere-devops-system"), newCasC(cascConfig)},
+		requeue: true,
+		verify: func(t *testing.T, c client.Client) {
+			result := &v1alpha3.ClusterSharedLibrary{}
+			assert.Nil(t, c.Get(context.Background(), req.NamespacedName, result))
+			assert.Equal(t, []string{v1alpha3.SharedLibraryFinalizerName}, result.Finalizers)
+			assert.Equal(t, v1alpha3.SharedLibraryPhaseSynced, result.Status.Phase)
+			assert.Equal(t, configWithLibrary, getCasC(t, c))
+		},
+	}, {
+		name: "the GitRepository is out of the system namespace",
+		objects: func() []client.Object {
+			foreignLibrary := library.DeepCopy()
+			foreignLibrary.Spec.GitRepository.Namespace = "ns"
+			return []client.Object{foreignLibrary, newGitRepository("ns"), newCasC(cascConfig)}
+		}(),
+		wantErr: true,
+		verify: func(t *testing.T, c client.Client) {
+			result := &v1alpha3.ClusterSharedLibrary{}
+			assert.Nil(t, c.Get(context.Background(), req.NamespacedName, result))
+			assert.Equal(t, v1alpha3.SharedLibraryPhaseFailed, result.Status.Phase)
+			assert.Equal(t, cascConfig, getCasC(t, c))
+		},
+	}, {
+		name:    "remove the library",
+		objects: []client.Object{deletingLibrary.DeepCopy(), newCasC(configWithLibrary)},
+		verify: func(t *testing.T, c client.Client) {
+			assert.Empty(t, getGlobalLibraries(t, getCasC(t, c)))
+		},
+	}, {
+		name:    "remove the library without the Jenkins CasC",
+		objects: []client.Object{deletingLibrary.DeepCopy()},
+		verify: func(t *testing.T, c client.Client) {
+			assert.NotNil(t, c.Get(context.Background(), req.NamespacedName, &v1alpha3.ClusterSharedLibrary{}))
+		},
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := fake.NewClientBuilder().WithScheme(schema).WithObjects(tt.objects...).Build()
+			r := &ClusterReconciler{
+				TargetConfigMapName:      "jenkins-casc-config",
+				TargetConfigMapNamespace: "kubesphere-devops-system",
+				TargetConfigMapKey:       "jenkins_user.yaml",
+				Interval:                 defaultInterval,
+				Client:                   c,
+				log:                      logr.Discard(),
+				recorder:                 record.NewFakeRecorder(10),
+			}
+			result, err := r.Reconcile(context.Background(), req)
+			if tt.wantErr {
+				assert.NotNil(t, err)
+			} else {
+				assert.Nil(t, err)
+			}
+			assert.Equal(t, tt.requeue, result.RequeueAfter == defaultInterval)
+			if tt.verify != nil {
+				tt.verify(t, c)
+			}
+		})
+	}
+}
+
+func TestClusterReconciler_findLibraries(t *testing.T) {
+	schema, err := v1alpha3.SchemeBuilder.Register().Build()
+	assert.Nil(t, err)
+
+	library := &v1alpha3.ClusterSharedLibrary{}
+	library.SetName("lib")
+	library.Spec.GitRepository.Name = "repo"
+	foreignLibrary := library.DeepCopy()
+	foreignLibrary.SetName("foreign")
+	foreignLibrary.Spec.GitRepository.Namespace = "ns"
+	c := fake.NewClientBuilder().WithScheme(schema).WithObjects(library, foreignLibrary).Build()
+
+	r := &ClusterReconciler{TargetConfigMapNamespace: "kubesphere-devops-system", Client: c, log: logr.Discard()}
+	assert.Equal(t, []reconcile.Request{{NamespacedName: types.NamespacedName{Name: "lib"}}},
+		r.findLibraries(newGitRepository("kubesphere-devops-system")))
+	// the GitRepositories out of the system namespace are never used
+	assert.Empty(t, r.findLibraries(newGitRepository("ns")))
+}
@@ -0,0 +1,99 @@
+/*
+Copyright 2022 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sharedlibrary
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"kubesphere.io/devops/pkg/api/devops/v1alpha3"
+	"kubesphere.io/devops/pkg/client/devops"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// controllerGroupName is the group name of the shared library controllers
+	controllerGroupName = "jenkins"
+
+	// defaultInterval is the interval of making sure the libraries are still in Jenkins,
+	// the libraries might be lost when Jenkins is rebuilt
+	defaultInterval = 5 * time.Minute
+)
+
+// getLibrary returns the Jenkins shared library which is loaded from the referenced GitRepository in the namespace.
+// The secret of the GitRepository must be in the same namespace, its name is the Jenkins credential ID.
+func getLibrary(ctx context.Context, reader client.Reader, name, namespace string,
+	spec v1alpha3.SharedLibrarySpec) (library *devops.SharedLibrary, err error) {
+	if spec.GitRepository.Name == "" || namespace == "" {
+		err = fmt.Errorf("the name and namespace of the GitRepository are required")
+		return
+	}
+
+	repo := &v1alpha3.GitRepository{}
+	if err = reader.Get(ctx, client.ObjectKey{Namespace: namespace, Name: spec.GitRepository.Name}, repo); err != nil {
+		err = fmt.Errorf("failed to get GitRepository %s/%s: %v", namespace, spec.GitRepository.Name, err)
+		return
+	}
+	if repo.Spec.URL == "" {
+		err = fmt.Errorf("the URL of GitRepository %s/%s is empty", namespace, repo.Name)
+		return
+	}
+
+	library = &devops.SharedLibrary{
+		Name:           name,
+		Remote:         repo.Spec.URL,
+		DefaultVersion: spec.DefaultVersion,
+		Implicit:       spec.Implicit,
+	}
+	if repo.Spec.Secret != nil {
+		if repo.Spec.Secret.Namespace != "" && repo.Spec.Secret.Namespace != namespace {
+			library = nil
+			err = fmt.Errorf("the secret %s/%s of GitRepository %s/%s is out of the namespace %s",
+				repo.Spec.Secret.Namespace, repo.Spec.Secret.Name, namespace, repo.Name, namespace)
+			return
+		}
+		library.CredentialID = repo.Spec.Secret.Name
+	}
+	return
+}
+
+// referencesGitRepository returns true if the library loads from the GitRepository
+func referencesGitRepository(spec v1alpha3.SharedLibrarySpec, namespace string, repo client.Object) bool {
+	return spec.GitRepository.Name == repo.GetName() && namespace == repo.GetNamespace()
+}
+
+// newStatus returns the status according to the result of the synchronization,
+// the last sync time is only changed when the status changed. It avoids updating the status all the time.
+func newStatus(current v1alpha3.SharedLibraryStatus, generation int64, syncErr error) (status v1alpha3.SharedLibraryStatus, changed bool) {
+	status = v1alpha3.SharedLibraryStatus{
+		Phase:              v1alpha3.SharedLibraryPhaseSynced,
+		ObservedGeneration: generation,
+		LastSyncTime:       current.LastSyncTime,
+	}
+	if syncErr != nil {
+		status.Phase = v1alpha3.SharedLibraryPhaseFailed
+		status.Message = syncErr.Error()
+	}
+	if changed = status.Phase != current.Phase || status.Message != current.Message ||
+		status.ObservedGeneration != current.ObservedGeneration; changed {
+		now := metav1.Now()
+		status.LastSyncTime = &now
+	}
+	return
+}
@@ -0,0 +1,147 @@
+/*
+Copyright 2022 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sharedlibrary
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/emicklei/go-restful"
+	"github.com/go-logr/logr"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/tools/record"
+	"kubesphere.io/devops/pkg/api/devops/v1alpha3"
+	"kubesphere.io/devops/pkg/client/devops"
+	"kubesphere.io/devops/pkg/utils/k8sutil"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+)
+
+//+kubebuilder:rbac:groups=devops.kubesphere.io,resources=sharedlibraries,verbs=get;list;watch;update
+//+kubebuilder:rbac:groups=devops.kubesphere.io,resources=sharedlibraries/status,verbs=get;update
+//+kubebuilder:rbac:groups=devops.kubesphere.io,resources=gitrepositories,verbs=get;list;watch
+//+kubebuilder:rbac:groups="",resources=events,verbs=create;patch
+
+// Reconciler configures the SharedLibrary as a library of the Jenkins folder of the DevOps project
+type Reconciler struct {
+	client.Client
+	DevOpsClient devops.SharedLibraryOperator
+	Interval     time.Duration
+
+	log      logr.Logger
+	recorder record.EventRecorder
+}
+
+// Reconcile is the main entrypoint of this controller
+func (r *Reconciler) Reconcile(ctx context.Context, req ctrl.Request) (result ctrl.Result, err error) {
+	log := r.log.WithValues("SharedLibrary", req.NamespacedName)
+	library := &v1alpha3.SharedLibrary{}
+	if err = r.Get(ctx, req.NamespacedName, library); err != nil {
+		err = client.IgnoreNotFound(err)
+		return
+	}
+
+	if !library.DeletionTimestamp.IsZero() {
+		// the library is gone together with the folder when the DevOps project is deleted
+		if err = r.DevOpsClient.RemoveProjectSharedLibrary(library.Namespace, library.Name); err != nil && !isNotFound(err) {
+			log.Error(err, "failed to remove the shared library from Jenkins")
+			return
+		}
+		k8sutil.RemoveFinalizer(&library.ObjectMeta, v1alpha3.SharedLibraryFinalizerName)
+		err = r.Update(ctx, library)
+		return
+	}
+
+	if k8sutil.AddFinalizer(&library.ObjectMeta, v1alpha3.SharedLibraryFinalizerName) {
+		if err = r.Update(ctx, library); err != nil {
+			return
+		}
+	}
+
+	var jenkinsLibrary *devops.SharedLibrary
+	jenkinsLibrary, err = getLibrary(ctx, r.Client, library.Name, library.Namespace, library.Spec)
+	if err == nil {
+		err = r.DevOpsClient.ReplaceOrAddProjectSharedLibrary(library.Namespace, jenkinsLibrary)
+	}
+	if err != nil {
+		log.Error(err, "failed to configure the shared library in Jenkins")
+		r.recorder.Event(library, v1.EventTypeWarning, string(v1alpha3.SharedLibraryPhaseFailed), err.Error())
+	}
+
+	if status, changed := newStatus(library.Status, library.Generation, err); changed {
+		library.Status = status
+		if updateErr := r.Status().Update(ctx, library); updateErr != nil {
+			log.Error(updateErr, "failed to update the status")
+		}
+	}
+	if err == nil {
+		// make sure the library always could be in Jenkins
+		result = ctrl.Result{RequeueAfter: r.Interval}
+	}
+	return
+}
+
+func isNotFound(err error) bool {
+	if serviceErr, ok := err.(restful.ServiceError); ok {
+		return serviceErr.Code == http.StatusNotFound
+	}
+	return devops.GetDevOpsStatusCode(err) == http.StatusNotFound
+}
+
+// findLibraries returns the SharedLibraries which load from the GitRepository
+func (r *Reconciler) findLibraries(repo client.Object) (requests []reconcile.Request) {
+	libraries := &v1alpha3.SharedLibraryList{}
+	if err := r.List(context.Background(), libraries, client.InNamespace(repo.GetNamespace())); err != nil {
+		r.log.Error(err, "failed to list SharedLibraries", "namespace", repo.GetNamespace())
+		return
+	}
+	for i := range libraries.Items {
+		library := &libraries.Items[i]
+		if referencesGitRepository(library.Spec, library.Namespace, repo) {
+			requests = append(requests, reconcile.Request{NamespacedName: client.ObjectKeyFromObject(library)})
+		}
+	}
+	return
+}
+
+// GetName returns the name of this controller
+func (r *Reconciler) GetName() string {
+	return "sharedlibrary-controller"
+}
+
+// GetGroupName returns the group name of this controller
+func (r *Reconciler) GetGroupName() string {
+	return controllerGroupName
+}
+
+// SetupWithManager setups the log and recorder
+func (r *Reconciler) SetupWithManager(mgr ctrl.Manager) error {
+	r.log = ctrl.Log.WithName(r.GetName())
+	r.recorder = mgr.GetEventRecorderFor(r.GetName())
+	if r.Interval == 0 {
+		r.Interval = defaultInterval
+	}
+	return ctrl.NewControllerManagedBy(mgr).
+		Named(r.GetName()).
+		For(&v1alpha3.SharedLibrary{}).
+		Watches(&source.Kind{Type: &v1alpha3.GitRepository{}}, handler.EnqueueRequestsFromMapFunc(r.findLibraries)).
+		Complete(r)
+}
@@ -0,0 +1,191 @@
+/*
+Copyright 2022 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sharedlibrary
+
+import (
+	"context"
+	"testing"
+
+	"github.com/go-logr/logr"
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"kubesphere.io/devops/pkg/api/devops/v1alpha3"
+	"kubesphere.io/devops/pkg/client/devops"
+	fakedevops "kubesphere.io/devops/pkg/client/devops/fake"
+	controllerruntime "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+func newGitRepository(namespace string) *v1alpha3.GitRepository {
+	repo := &v1alpha3.GitRepository{}
+	repo.SetNamespace(namespace)
+	repo.SetName("repo")
+	repo.Spec.URL = "https://github.com/linuxsuren/lib.git"
+	repo.Spec.Secret = &v1.SecretReference{Name: "git", Namespace: namespace}
+	return repo
+}
+
+func TestReconciler_Reconcile(t *testing.T) {
+	schema, err := v1alpha3.SchemeBuilder.Register().Build()
+	assert.Nil(t, err)
+
+	req := controllerruntime.Request{NamespacedName: types.NamespacedName{Namespace: "ns", Name: "lib"}}
+	library := &v1alpha3.SharedLibrary{}
+	library.SetNamespace("ns")
+	library.SetName("lib")
+	library.SetGeneration(2)
+	library.Spec = v1alpha3.SharedLibrarySpec{
+		GitRepository:  v1alpha3.GitRepositoryReference{Name: "repo"},
+		DefaultVersion: "main",
+		Implicit:       true,
+	}
+
+	deletingLibrary := library.DeepCopy()
+	now := metav1.Now()
+	deletingLibrary.SetDeletionTimestamp(&now)
+	deletingLibrary.SetFinalizers([]string{v1alpha3.SharedLibraryFinalizerName})
+
+	getLibrary := func(t *testing.T, c client.Client) *v1alpha3.SharedLibrary {
+		result := &v1alpha3.SharedLibrary{}
+		assert.Nil(t, c.Get(context.Background(), req.NamespacedName, result))
+		return result
+	}
+
+	tests := []struct {
+		name      string
+		objects   []client.Object
+		libraries map[string]*devops.SharedLibrary
+		wantErr   bool
+		requeue   bool
+		verify    func(t *testing.T, c client.Client, devopsClient *fakedevops.Devops)
+	}{{
+		name: "not found",
+	}, {
+		name:    "the GitRepository is not found",
+		objects: []client.Object{library.DeepCopy()},
+		wantErr: true,
+		verify: func(t *testing.T, c client.Client, devopsClient *fakedevops.Devops) {
+			result := getLibrary(t, c)
+			assert.Equal(t, []string{v1alpha3.SharedLibraryFinalizerName}, result.Finalizers)
+			assert.Equal(t, v1alpha3.SharedLibraryPhaseFailed, result.Status.Phase)
+			assert.NotEmpty(t, result.Status.Message)
+			assert.Empty(t, devopsClient.Libraries["ns"])
+		},
+	}, {
+		name:    "configure the library",
+		objects: []client.Object{library.DeepCopy(), newGitRepository("ns")},
+		requeue: true,
+		verify: func(t *testing.T, c client.Client, devopsClient *fakedevops.Devops) {
+			result := getLibrary(t, c)
+			assert.Equal(t, v1alpha3.SharedLibraryPhaseSynced, result.Status.Phase)
+			assert.Equal(t, int64(2), result.Status.ObservedGeneration)
+			assert.NotNil(t, result.Status.LastSyncTime)
+			assert.Equal(t, &devops.SharedLibrary{
+				Name:           "lib",
+				Remote:         "https://github.com/linuxsuren/lib.git",
+				CredentialID:   "git",
+				DefaultVersion: "main",
+				Implicit:       true,
+			}, devopsClient.Libraries["ns"]["lib"])
+		},
+	}, {
+		name: "the secret of the GitRepository is out of the namespace",
+		objects: func() []client.Object {
+			repo := newGitRepository("ns")
+			repo.Spec.Secret.Namespace = "other"
+			return []client.Object{library.DeepCopy(), repo}
+		}(),
+		wantErr: true,
+		verify: func(t *testing.T, c client.Client, devopsClient *fakedevops.Devops) {
+			assert.Equal(t, v1alpha3.SharedLibraryPhaseFailed, getLibrary(t, c).Status.Phase)
+			assert.Empty(t, devopsClient.Libraries["ns"])
+		},
+	}, {
+		name:      "remove the library",
+		objects:   []client.Object{deletingLibrary.DeepCopy()},
+		libraries: map[string]*devops.SharedLibrary{"lib": {Name: "lib"}},
+		verify: func(t *testing.T, c client.Client, devopsClient *fakedevops.Devops) {
+			// the library is deleted once the finalizer is removed
+			assert.NotNil(t, c.Get(context.Background(), req.NamespacedName, &v1alpha3.SharedLibrary{}))
+			assert.Empty(t, devopsClient.Libraries["ns"])
+		},
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := fake.NewClientBuilder().WithScheme(schema).WithObjects(tt.objects...).Build()
+			devopsClient := fakedevops.New("ns")
+			if tt.libraries != nil {
+				devopsClient.Libraries = map[string]map[string]*devops.SharedLibrary{"ns": tt.libraries}
+			}
+			r := &Reconciler{
+				Client:       c,
+				DevOpsClient: devopsClient,
+				Interval:     defaultInterval,
+				log:          logr.Discard(),
+				recorder:     record.NewFakeRecorder(10),
+			}
+			result, err := r.Reconcile(context.Background(), req)
+			if tt.wantErr {
+				assert.NotNil(t, err)
+			} else {
+				assert.Nil(t, err)
+			}
+			assert.Equal(t, tt.requeue, result.RequeueAfter == defaultInterval)
+			if tt.verify != nil {
+				tt.verify(t, c, devopsClient)
+			}
+		})
+	}
+}
+
+func TestNewStatus(t *testing.T) {
+	status, changed := newStatus(v1alpha3.SharedLibraryStatus{}, 1, nil)
+	assert.True(t, changed)
+	assert.Equal(t, v1alpha3.SharedLibraryPhaseSynced, status.Phase)
+	assert.NotNil(t, status.LastSyncTime)
+
+	unchanged, changed := newStatus(status, 1, nil)
+	assert.False(t, changed)
+	assert.Equal(t, status, unchanged)
+
+	_, changed = newStatus(status, 2, nil)
+	assert.True(t, changed)
+}
+
+func TestReconciler_findLibraries(t *testing.T) {
+	schema, err := v1alpha3.SchemeBuilder.Register().Build()
+	assert.Nil(t, err)
+
+	library := &v1alpha3.SharedLibrary{}
+	library.SetNamespace("ns")
+	library.SetName("lib")
+	library.Spec.GitRepository.Name = "repo"
+	otherLibrary := library.DeepCopy()
+	otherLibrary.SetName("other")
+	otherLibrary.Spec.GitRepository.Name = "other"
+	c := fake.NewClientBuilder().WithScheme(schema).WithObjects(library, otherLibrary).Build()
+
+	r := &Reconciler{Client: c, log: logr.Discard()}
+	assert.Equal(t, []reconcile.Request{{NamespacedName: types.NamespacedName{Namespace: "ns", Name: "lib"}}},
+		r.findLibraries(newGitRepository("ns")))
+	assert.Empty(t, r.findLibraries(newGitRepository("other")))
+}
@@ -67,6 +67,31 @@ const (
 	SecretTypeKubeConfig v1.SecretType = DevOpsCredentialPrefix + "kubeconfig"
 	// KubeConfigSecretKey is the key of the secret for SecretTypeKubeConfig secrets
 	KubeConfigSecretKey = "content"
+	// SecretTypeGitHubApp contains data needed for the authentication of a GitHub App installation.
+	// It is used by the git clients of KubeSphere DevOps only, so it is not synchronized to Jenkins as a credential.
+	//
+	// Required fields:
+	// - Secret.Data["app_id"] - the ID of the GitHub App
+	// - Secret.Data["installation_id"] - the ID of the installation of the GitHub App
+	// - Secret.Data["private_key"] - the PEM encoded private key of the GitHub App
+	SecretTypeGitHubApp v1.SecretType = "devops.kubesphere.io/github-app"
+	// GitHubAppIDKey is the key of the app ID for SecretTypeGitHubApp secrets
+	GitHubAppIDKey = "app_id"
+	// GitHubAppInstallationIDKey is the key of the installation ID for SecretTypeGitHubApp secrets
+	GitHubAppInstallationIDKey = "installation_id"
+	// GitHubAppPrivateKey is the key of the private key for SecretTypeGitHubApp secrets
+	GitHubAppPrivateKey = "private_key"
+
+	// OAuthRefreshTokenKey is the key of the refresh token for the SecretTypeBasicAuth secrets which come from the SCM OAuth flow
+	OAuthRefreshTokenKey = "refresh_token"
+	// OAuthExpiryKey is the key of the expiry time (RFC3339) of the access token for the SecretTypeBasicAuth secrets
+	// which come from the SCM OAuth flow
+	OAuthExpiryKey = "expiry"
+	// CredentialSCMOAuthProviderAnnoKey is the annotation key of the git provider which issued the OAuth token
+	CredentialSCMOAuthProviderAnnoKey = DevOpsCredentialPrefix + "scm-oauth-provider"
+	// CredentialSCMOAuthServerAnnoKey is the annotation key of the git server address which issued the OAuth token
+	CredentialSCMOAuthServerAnnoKey = DevOpsCredentialPrefix + "scm-oauth-server"
+
 	//	CredentialAutoSyncAnnoKey is used to indicate whether the secret is automatically synchronized to devops.
 	//	In the old version, the credential is stored in jenkins and cannot be obtained.
 	//	This field is set to ensure that the secret is not overwritten by a nil value.
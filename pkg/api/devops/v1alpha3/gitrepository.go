@@ -24,16 +24,25 @@ import (
 // AnnotationKeyWebhookUpdates is a signal that should update the webhooks
 const AnnotationKeyWebhookUpdates = "devops.kubesphere.io/webhook-updates"
 
+// AnnotationKeyPRComment indicates if the Pipelines of the git repository post a summary comment to the pull requests,
+// the value is "true" or "false"
+const AnnotationKeyPRComment = "devops.kubesphere.io/scm-pr-comment"
+
 // GitRepoFinalizerName is the finalizer name of the git repository
 const GitRepoFinalizerName = "finalizer.gitrepository.devops.kubesphere.io"
 
+// GitRepoWebhookFinalizerName is the finalizer name which makes sure the webhooks are removed from the git provider
+const GitRepoWebhookFinalizerName = "webhook.finalizer.gitrepository.devops.kubesphere.io"
+
 // +genclient
 // +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
 
 // GitRepository is the Schema for the webhook API
 // +k8s:openapi-gen=true
+// +kubebuilder:subresource:status
 // +kubebuilder:printcolumn:name="Provider",type="string",JSONPath=".spec.provider"
 // +kubebuilder:printcolumn:name="Server",type="string",JSONPath=".spec.server"
+// +kubebuilder:printcolumn:name="Connection",type="string",JSONPath=".status.connection"
 type GitRepository struct {
 	metav1.TypeMeta   `json:",inline"`
 	metav1.ObjectMeta `json:"metadata,omitempty"`
@@ -48,6 +57,106 @@ type GitRepositoryStatus struct {
 	Connection string `json:"connection,omitempty"`
 	// Message describes the message when trying to connect it
 	Message string `json:"message,omitempty"`
+	// DefaultBranch is the default branch of the git repository
+	// +optional
+	DefaultBranch string `json:"defaultBranch,omitempty"`
+	// LatestCommit is the latest commit SHA of the default branch
+	// +optional
+	LatestCommit string `json:"latestCommit,omitempty"`
+	// LastCheckedTime is the last time when the git repository was checked
+	// +optional
+	LastCheckedTime *metav1.Time `json:"lastCheckedTime,omitempty"`
+	// ConsecutiveFailures is the number of continuous failed checks, it decides the backoff of the next check
+	// +optional
+	ConsecutiveFailures int `json:"consecutiveFailures,omitempty"`
+	// Conditions are the detailed results of the latest check
+	// +optional
+	// +patchMergeKey=type
+	// +patchStrategy=merge
+	Conditions []Condition `json:"conditions,omitempty"`
+	// Webhooks are the results of registering the referenced webhooks on the git provider
+	// +optional
+	Webhooks []WebhookStatus `json:"webhooks,omitempty"`
+}
+
+// WebhookStatus represents the status of a webhook which is registered on the git provider
+type WebhookStatus struct {
+	// Name is the name of the referenced Webhook
+	Name string `json:"name"`
+	// ID is the identity of the hook on the git provider
+	// +optional
+	ID string `json:"id,omitempty"`
+	// Server is the address which receives the events
+	// +optional
+	Server string `json:"server,omitempty"`
+	// Events are the provider-specific events of the hook
+	// +optional
+	Events []string `json:"events,omitempty"`
+	// ObservedGeneration is the generation of the Webhook which was registered
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+	// Registered indicates if the hook is registered on the git provider
+	Registered bool `json:"registered"`
+	// Message describes the reason of the failure
+	// +optional
+	Message string `json:"message,omitempty"`
+	// LastSyncTime is the last time when the hook was synchronized
+	// +optional
+	LastSyncTime *metav1.Time `json:"lastSyncTime,omitempty"`
+}
+
+// GetWebhookStatus returns the status of the webhook with the given name, returns nil if it does not exist
+func (status *GitRepositoryStatus) GetWebhookStatus(name string) *WebhookStatus {
+	for i := range status.Webhooks {
+		if status.Webhooks[i].Name == name {
+			return &status.Webhooks[i]
+		}
+	}
+	return nil
+}
+
+const (
+	// GitRepoConnectionOK indicates that the git repository is reachable with valid credentials
+	GitRepoConnectionOK = "ok"
+	// GitRepoConnectionFailed indicates that the git repository is not reachable or the credentials are invalid
+	GitRepoConnectionFailed = "failed"
+)
+
+const (
+	// GitRepoConditionReachable indicates if the git provider is reachable
+	GitRepoConditionReachable ConditionType = "Reachable"
+	// GitRepoConditionAuthenticated indicates if the referenced secret is valid for the git repository
+	GitRepoConditionAuthenticated ConditionType = "Authenticated"
+	// GitRepoConditionWebhookRegistered indicates if all the referenced webhooks are registered on the git provider
+	GitRepoConditionWebhookRegistered ConditionType = "WebhookRegistered"
+)
+
+// GetCondition returns the condition with the given type, returns nil if it does not exist
+func (status *GitRepositoryStatus) GetCondition(conditionType ConditionType) *Condition {
+	for i := range status.Conditions {
+		if status.Conditions[i].Type == conditionType {
+			return &status.Conditions[i]
+		}
+	}
+	return nil
+}
+
+// SetCondition adds or replaces a condition by its type.
+// The last transition time is only changed when the status of the condition changed.
+func (status *GitRepositoryStatus) SetCondition(newCondition Condition) {
+	now := metav1.Now()
+	newCondition.LastProbeTime = now
+	if existing := status.GetCondition(newCondition.Type); existing != nil {
+		if existing.Status == newCondition.Status {
+			newCondition.LastTransitionTime = existing.LastTransitionTime
+		} else {
+			newCondition.LastTransitionTime = now
+		}
+		*existing = newCondition
+		return
+	}
+	newCondition.LastTransitionTime = now
+	status.Conditions = append(status.Conditions, newCondition)
 }
 
 // +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
@@ -18,8 +18,10 @@ limitations under the License.
 package v1alpha3
 
 import (
+	v1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"kubesphere.io/devops/pkg/api/devops"
+	"kubesphere.io/devops/pkg/constants"
 	"sigs.k8s.io/controller-runtime/pkg/scheme"
 )
 
@@ -32,14 +34,42 @@ const (
 	JenkinsPipelineRunStagesStatusAnnoKey = devops.GroupName + "/jenkins-pipelinerun-stages-status"
 	// PipelineRunOrphanLabelKey is label key of orphan Jenkins PipelineRun which type of value is bool.
 	PipelineRunOrphanLabelKey = devops.GroupName + "/jenkins-pipelinerun-orphan"
+	// PipelineRunBranchRemovedLabelKey is label key of the PipelineRun which belongs to a removed branch, the type of value is bool.
+	PipelineRunBranchRemovedLabelKey = devops.GroupName + "/jenkins-pipelinerun-branch-removed"
 	// PipelineNameLabelKey is label key of Pipeline name.
 	PipelineNameLabelKey = devops.GroupName + "/pipeline"
+	// PipelineRevisionLabelKey is label key of the revision number of a Pipeline revision ConfigMap.
+	PipelineRevisionLabelKey = devops.GroupName + "/pipeline-revision"
 	// PipelineRunCreatorAnnoKey is annotation key of PipelineRun's creator
 	PipelineRunCreatorAnnoKey = devops.GroupName + "/creator"
+	// PipelineRunCommitAnnoKey is annotation key of the SCM commit SHA which triggered a PipelineRun
+	PipelineRunCommitAnnoKey = devops.GroupName + "/scm-commit"
+	// PipelineRunStatusCommitAnnoKey is annotation key of the SCM commit SHA which the commit statuses of a PipelineRun are sent to
+	PipelineRunStatusCommitAnnoKey = devops.GroupName + "/scm-status-commit"
+	// PipelineRunRerunOfLabelKey is label key of the name of the original PipelineRun which a PipelineRun runs again
+	PipelineRunRerunOfLabelKey = devops.GroupName + "/rerun-of"
+	// PipelineRunReplayAnnoKey is annotation key of the Jenkins run ID which a PipelineRun replays.
+	// The replay runs with the same commit and parameters as the original run does.
+	PipelineRunReplayAnnoKey = devops.GroupName + "/replay-of"
+	// PipelineRunReplayJenkinsfileSignatureAnnoKey is annotation key of the signature which the apiserver signs the
+	// replaced Jenkinsfile of a replay with. A replay without a valid signature runs with the original Jenkinsfile.
+	PipelineRunReplayJenkinsfileSignatureAnnoKey = devops.GroupName + "/replay-jenkinsfile-signature"
+	// JenkinsQueueItemIDAnnoKey is annotation key of the Jenkins queue item which a PipelineRun waits for
+	JenkinsQueueItemIDAnnoKey = devops.GroupName + "/jenkins-queue-item-id"
 	// PipelineRunSCMRefNameField is the field name of SCM reference name in PipelineRun spec.
 	PipelineRunSCMRefNameField = "spec.scm.ref-name"
 	// PipelineRunIdentifierIndexerName is an indexer name of PipelineRun identifier.
 	PipelineRunIdentifierIndexerName = "pipelinerun.identifier"
+	// PipelineRepoURLIndexerName is an indexer name of the normalized repository address of Pipeline.
+	PipelineRepoURLIndexerName = "pipeline.repo-url"
+	// PipelineGenericWebhookTokenIndexerName is an indexer name of the generic webhook token of Pipeline.
+	PipelineGenericWebhookTokenIndexerName = "pipeline.generic-webhook-token"
+	// PipelineCredentialIDIndexerName is an indexer name of the credential IDs which are referenced by Pipeline.
+	PipelineCredentialIDIndexerName = "pipeline.credential-id"
+	// GitRepositoryURLIndexerName is an indexer name of the normalized address of GitRepository.
+	GitRepositoryURLIndexerName = "gitrepository.url"
+	// PipelineSCMAnnoKey is annotation key of the repository address of a non multi-branch Pipeline.
+	PipelineSCMAnnoKey = "scm.devops.kubesphere.io"
 )
 
 var (
@@ -51,6 +81,31 @@ var (
 
 	// AddToScheme adds the types in this group-version to the given scheme.
 	AddToScheme = SchemeBuilder.AddToScheme
+
+	// TransientAnnotations are the annotations which describe the state of a resource in the current cluster,
+	// such as the state of the Jenkins job and the synchronization. They are dropped when copying a resource.
+	TransientAnnotations = []string{
+		PipelineSpecHash,
+		PipelineSyncStatusAnnoKey,
+		PipelineSyncTimeAnnoKey,
+		PipelineSyncMsgAnnoKey,
+		PipelineLastChanges,
+		PipelineJenkinsMetadataAnnoKey,
+		PipelineJenkinsBranchesAnnoKey,
+		PipelineRequestToSyncRunsAnnoKey,
+		PipelineRemovedBranchesAnnoKey,
+		PipelineAsCodeRequestSyncAnnoKey,
+		PipelineAsCodeRevisionAnnoKey,
+		PipelineAsCodeSyncStatusAnnoKey,
+		PipelineAsCodeSyncMsgAnnoKey,
+		PipelineRevisionAnnoKey,
+		PipelineLastModifierAnnoKey,
+		PipelineLastModifierSpecHashAnnoKey,
+		AnnotationKeyGitRepos,
+		AnnotationKeyWebhookUpdates,
+		constants.CreatorAnnotationKey,
+		v1.LastAppliedConfigAnnotation,
+	}
 )
 
 // Resource is required by pkg/client/listers/...
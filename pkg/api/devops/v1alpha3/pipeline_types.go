@@ -17,8 +17,14 @@ limitations under the License.
 package v1alpha3
 
 import (
+	"encoding/json"
 	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"kubesphere.io/devops/pkg/utils"
 )
 
 // EDIT THIS FILE!  THIS IS SCAFFOLDING FOR YOU TO OWN!
@@ -47,6 +53,58 @@ const (
 	PipelineJenkinsfileEditModeAnnoKey = PipelinePrefix + "jenkinsfile.edit.mode"
 	// PipelineJenkinsfileValidateAnnoKey is the annotation key of the Jenkinsfile validate, success or failure
 	PipelineJenkinsfileValidateAnnoKey = PipelinePrefix + "jenkinsfile.validate"
+	// PipelineStageStatusAnnoKey is the annotation key of sending a commit status for each stage, the value is "true" or "false"
+	PipelineStageStatusAnnoKey = PipelinePrefix + "scm-stage-status"
+	// PipelinePRCommentAnnoKey is the annotation key of posting a summary comment to the pull requests, the value is "true" or "false".
+	// It takes precedence over the same annotation of the GitRepository.
+	PipelinePRCommentAnnoKey = PipelinePrefix + "scm-pr-comment"
+	// PipelineBranchCleanupPolicyAnnoKey is the annotation key of the policy of cleaning up the PipelineRuns of the removed branches.
+	// See also BranchCleanupPolicyArchive and BranchCleanupPolicyDelete.
+	PipelineBranchCleanupPolicyAnnoKey = PipelinePrefix + "branch-cleanup-policy"
+	// PipelineRemovedBranchesAnnoKey is the annotation key of the branches which are removed according to the SCM events,
+	// but might still exist in Jenkins. The value is a JSON array of the branch names.
+	PipelineRemovedBranchesAnnoKey = PipelinePrefix + "removed-branches"
+	// PipelineLastScanTimeAnnoKey is the annotation key of the start time of the latest finished branch scan in Jenkins,
+	// the value is in RFC3339 format. The branches which do not exist in Jenkins are removed only after a newer scan.
+	PipelineLastScanTimeAnnoKey = PipelinePrefix + "last-scan-time"
+	// PipelineAsCodeGitRepoAnnoKey is the annotation key of the GitRepository name in the same namespace.
+	// The spec of a Pipeline with this annotation is reconciled from a YAML file of the git repository.
+	PipelineAsCodeGitRepoAnnoKey = PipelinePrefix + "pac-gitrepository"
+	// PipelineAsCodeBranchAnnoKey is the annotation key of the branch which the pipeline-as-code file is read from.
+	// The default branch of the GitRepository is used if it is empty.
+	PipelineAsCodeBranchAnnoKey = PipelinePrefix + "pac-branch"
+	// PipelineAsCodePathAnnoKey is the annotation key of the pipeline-as-code file path, see also DefaultPipelineAsCodePath
+	PipelineAsCodePathAnnoKey = PipelinePrefix + "pac-path"
+	// PipelineAsCodeRequestSyncAnnoKey is the annotation key of requesting to synchronize the pipeline-as-code file.
+	// The value is the commit which triggers the synchronization, it is set by the SCM webhook.
+	PipelineAsCodeRequestSyncAnnoKey = PipelinePrefix + "pac-request-sync"
+	// PipelineAsCodeRevisionAnnoKey is the annotation key of the commit which the Pipeline spec is synchronized from
+	PipelineAsCodeRevisionAnnoKey = PipelinePrefix + "pac-revision"
+	// PipelineAsCodeSyncStatusAnnoKey is the annotation key of the pipeline-as-code synchronization status, successful or failed
+	PipelineAsCodeSyncStatusAnnoKey = PipelinePrefix + "pac-syncstatus"
+	// PipelineAsCodeSyncMsgAnnoKey is the annotation key of the pipeline-as-code synchronization error message
+	PipelineAsCodeSyncMsgAnnoKey = PipelinePrefix + "pac-syncmsg"
+	// PipelineRevisionAnnoKey is the annotation key of the revision number of the Pipeline spec.
+	// A PipelineRun has the same annotation, it is the revision which the PipelineRun ran with.
+	PipelineRevisionAnnoKey = PipelinePrefix + "revision"
+	// PipelineRevisionSpecHashAnnoKey is the annotation key of the spec hash of the revision which is set to the Pipeline.
+	// The revision of the Pipeline is out of date if it does not match the hash of the current spec.
+	PipelineRevisionSpecHashAnnoKey = PipelinePrefix + "revision-spechash"
+	// PipelineLastModifierAnnoKey is the annotation key of the user who changed the Pipeline spec last time
+	PipelineLastModifierAnnoKey = PipelinePrefix + "last-modifier"
+	// PipelineLastModifierSpecHashAnnoKey is the annotation key of the spec hash which was changed by the last modifier.
+	// The last modifier is unknown if it does not match the hash of the current spec, e.g. the spec was edited by kubectl.
+	PipelineLastModifierSpecHashAnnoKey = PipelinePrefix + "last-modifier-spechash"
+	// PipelineRevisionAuthorAnnoKey is the annotation key of the author of a Pipeline revision
+	PipelineRevisionAuthorAnnoKey = PipelinePrefix + "revision-author"
+	// PipelineDriftCorrectionAnnoKey is the annotation key of correcting the changes which were made in Jenkins directly.
+	// The Jenkins job is checked on each resync, and overwritten by the Pipeline spec if the value is "true".
+	PipelineDriftCorrectionAnnoKey = PipelinePrefix + "drift-correction"
+
+	// DefaultPipelineAsCodePath is the default path of the pipeline-as-code file in the git repository
+	DefaultPipelineAsCodePath = ".ks-devops/pipeline.yaml"
+	// DefaultPipelineAsCodeBranch is the branch of the pipeline-as-code file if the default branch is unknown
+	DefaultPipelineAsCodeBranch = "master"
 
 	// PipelineJenkinsfileEditModeJSON indicates the Jenkinsfile editing mode is JSON
 	PipelineJenkinsfileEditModeJSON = "json"
@@ -57,6 +115,12 @@ const (
 	PipelineJenkinsfileValidateSuccess = "success"
 	// PipelineJenkinsfileValidateFailure indicates the Jenkinsfile validate is failure
 	PipelineJenkinsfileValidateFailure = "failure"
+
+	// BranchCleanupPolicyArchive indicates the PipelineRuns of the removed branches are labeled and no longer synchronized.
+	// It is the default policy.
+	BranchCleanupPolicyArchive = "Archive"
+	// BranchCleanupPolicyDelete indicates the PipelineRuns of the removed branches are deleted
+	BranchCleanupPolicyDelete = "Delete"
 )
 
 // PipelineSpec defines the desired state of Pipeline
@@ -68,8 +132,50 @@ type PipelineSpec struct {
 
 // PipelineStatus defines the observed state of Pipeline
 type PipelineStatus struct {
-	// INSERT ADDITIONAL STATUS FIELD - define observed state of cluster
-	// Important: Run "make" to regenerate code after modifying this file
+	// Conditions are the results of checking the Pipeline, such as the referenced credentials
+	// +optional
+	// +patchMergeKey=type
+	// +patchStrategy=merge
+	Conditions []Condition `json:"conditions,omitempty"`
+}
+
+const (
+	// PipelineConditionCredentialsResolved indicates if all the credentials referenced by the Pipeline exist with the expected types
+	PipelineConditionCredentialsResolved ConditionType = "CredentialsResolved"
+)
+
+// GetCondition returns the condition with the given type, returns nil if it does not exist
+func (status *PipelineStatus) GetCondition(conditionType ConditionType) *Condition {
+	for i := range status.Conditions {
+		if status.Conditions[i].Type == conditionType {
+			return &status.Conditions[i]
+		}
+	}
+	return nil
+}
+
+// SetCondition adds or replaces a condition by its type, returns false if nothing is changed.
+// The times of the condition are only changed when the status, reason or message changed.
+func (status *PipelineStatus) SetCondition(newCondition Condition) (changed bool) {
+	now := metav1.Now()
+	existing := status.GetCondition(newCondition.Type)
+	if existing == nil {
+		newCondition.LastProbeTime = now
+		newCondition.LastTransitionTime = now
+		status.Conditions = append(status.Conditions, newCondition)
+		return true
+	}
+	if existing.Status == newCondition.Status && existing.Reason == newCondition.Reason &&
+		existing.Message == newCondition.Message {
+		return false
+	}
+	newCondition.LastProbeTime = now
+	newCondition.LastTransitionTime = existing.LastTransitionTime
+	if existing.Status != newCondition.Status {
+		newCondition.LastTransitionTime = now
+	}
+	*existing = newCondition
+	return true
 }
 
 // +genclient
@@ -105,6 +211,110 @@ func (p *Pipeline) IsMultiBranch() bool {
 	return p.Spec.Type == MultiBranchPipelineType
 }
 
+// GetBranchCleanupPolicy returns the policy of cleaning up the PipelineRuns of the removed branches
+func (p *Pipeline) GetBranchCleanupPolicy() string {
+	if p.Annotations[PipelineBranchCleanupPolicyAnnoKey] == BranchCleanupPolicyDelete {
+		return BranchCleanupPolicyDelete
+	}
+	return BranchCleanupPolicyArchive
+}
+
+// IsPipelineAsCode returns true if the spec of this Pipeline is reconciled from a file of the git repository
+func (p *Pipeline) IsPipelineAsCode() bool {
+	return p != nil && p.Annotations[PipelineAsCodeGitRepoAnnoKey] != ""
+}
+
+// GetPipelineAsCodeBranch returns the branch of the pipeline-as-code file, falls back to the given default branch
+func (p *Pipeline) GetPipelineAsCodeBranch(defaultBranch string) string {
+	if branch := p.Annotations[PipelineAsCodeBranchAnnoKey]; branch != "" {
+		return branch
+	}
+	if defaultBranch != "" {
+		return defaultBranch
+	}
+	return DefaultPipelineAsCodeBranch
+}
+
+// GetPipelineAsCodePath returns the path of the pipeline-as-code file
+func (p *Pipeline) GetPipelineAsCodePath() string {
+	if path := strings.TrimPrefix(p.Annotations[PipelineAsCodePathAnnoKey], "/"); path != "" {
+		return path
+	}
+	return DefaultPipelineAsCodePath
+}
+
+// GetRemovedBranches returns the branches which are removed according to the SCM events
+func (p *Pipeline) GetRemovedBranches() (branches []string) {
+	if value := p.Annotations[PipelineRemovedBranchesAnnoKey]; value != "" {
+		_ = json.Unmarshal([]byte(value), &branches)
+	}
+	return
+}
+
+// GetLastScanTime returns the start time of the latest finished branch scan, returns nil if it is unknown
+func (p *Pipeline) GetLastScanTime() *metav1.Time {
+	lastScanTime, err := time.Parse(time.RFC3339, p.Annotations[PipelineLastScanTimeAnnoKey])
+	if err != nil {
+		return nil
+	}
+	return &metav1.Time{Time: lastScanTime}
+}
+
+// SetRemovedBranchState marks or unmarks a branch as removed, returns true if the annotations changed
+func (p *Pipeline) SetRemovedBranchState(branch string, removed bool) bool {
+	branches := p.GetRemovedBranches()
+	index := -1
+	for i := range branches {
+		if branches[i] == branch {
+			index = i
+			break
+		}
+	}
+	if (index >= 0) == removed {
+		return false
+	}
+
+	if removed {
+		branches = append(branches, branch)
+	} else {
+		branches = append(branches[:index], branches[index+1:]...)
+	}
+	p.SetRemovedBranches(branches)
+	return true
+}
+
+// SetRemovedBranches sets the removed branches, the annotation is removed if there are no branches
+func (p *Pipeline) SetRemovedBranches(branches []string) {
+	if len(branches) == 0 {
+		delete(p.Annotations, PipelineRemovedBranchesAnnoKey)
+		return
+	}
+	if p.Annotations == nil {
+		p.Annotations = map[string]string{}
+	}
+	data, _ := json.Marshal(branches)
+	p.Annotations[PipelineRemovedBranchesAnnoKey] = string(data)
+}
+
+// SetLastModifier records the user who changes the Pipeline spec, it must be called after the spec is changed
+func (p *Pipeline) SetLastModifier(user string) {
+	if p.Annotations == nil {
+		p.Annotations = map[string]string{}
+	}
+	p.Annotations[PipelineLastModifierAnnoKey] = user
+	p.Annotations[PipelineLastModifierSpecHashAnnoKey] = utils.ComputeHash(p.Spec)
+}
+
+// GetLastModifier returns the user who changed the Pipeline spec last time.
+// It is empty if the current spec is not the one which the user changed.
+func (p *Pipeline) GetLastModifier() string {
+	modifier := p.Annotations[PipelineLastModifierAnnoKey]
+	if modifier == "" || p.Annotations[PipelineLastModifierSpecHashAnnoKey] != utils.ComputeHash(p.Spec) {
+		return ""
+	}
+	return modifier
+}
+
 // PipelineType is an alias of string that represents the type of Pipelines
 type PipelineType string
 
@@ -120,6 +330,10 @@ const (
 	SourceTypeGitlab    = "gitlab"
 	SourceTypeGithub    = "github"
 	SourceTypeBitbucket = "bitbucket_server"
+	// SourceTypeAzureDevOps represents the git repositories of Azure DevOps (Azure Repos)
+	SourceTypeAzureDevOps = "azure_devops"
+	// SourceTypeGitee represents the git repositories of Gitee
+	SourceTypeGitee = "gitee"
 )
 
 type NoScmPipeline struct {
@@ -146,10 +360,13 @@ type MultiBranchPipeline struct {
 	SvnSource             *SvnSource             `json:"svn_source,omitempty" description:"multi branch svn scm define"`
 	SingleSvnSource       *SingleSvnSource       `json:"single_svn_source,omitempty" description:"single branch svn scm define"`
 	BitbucketServerSource *BitbucketServerSource `json:"bitbucket_server_source,omitempty" description:"bitbucket server scm defile"`
+	AzureDevOpsSource     *AzureDevOpsSource     `json:"azure_devops_source,omitempty" description:"azure devops scm define"`
+	GiteeSource           *GiteeSource           `json:"gitee_source,omitempty" description:"gitee scm define"`
 	ScriptPath            string                 `json:"script_path" mapstructure:"script_path" description:"script path in scm"`
 	MultiBranchJobTrigger *MultiBranchJobTrigger `json:"multibranch_job_trigger,omitempty" mapstructure:"multibranch_job_trigger" description:"Pipeline tasks that need to be triggered when branch creation/deletion"`
 }
 
+// GetGitURL returns the address of the git repository, the self-hosted server is taken from the API URI
 func (b *MultiBranchPipeline) GetGitURL() string {
 	switch b.SourceType {
 	case SourceTypeGit:
@@ -158,20 +375,72 @@ func (b *MultiBranchPipeline) GetGitURL() string {
 		}
 	case SourceTypeGithub:
 		if b.GitHubSource != nil {
-			return fmt.Sprintf("https://github.com/%s/%s", b.GitHubSource.Owner, b.GitHubSource.Repo)
+			return fmt.Sprintf("%s/%s/%s", getServerFromAPIURI(b.GitHubSource.ApiUri, "https://github.com"),
+				b.GitHubSource.Owner, b.GitHubSource.Repo)
 		}
 	case SourceTypeGitlab:
 		if b.GitlabSource != nil {
-			return fmt.Sprintf("https://gitlab.com/%s/%s", b.GitlabSource.Owner, b.GitlabSource.Repo)
+			return fmt.Sprintf("%s/%s/%s", getServerFromAPIURI(b.GitlabSource.ApiUri, "https://gitlab.com"),
+				b.GitlabSource.Owner, b.GitlabSource.Repo)
 		}
 	case SourceTypeBitbucket:
 		if b.BitbucketServerSource != nil {
-			return fmt.Sprintf("https://bitbucket.org/%s/%s", b.BitbucketServerSource.Owner, b.BitbucketServerSource.Repo)
+			server := getServerFromAPIURI(b.BitbucketServerSource.ApiUri, "https://bitbucket.org")
+			if server != "https://bitbucket.org" {
+				// the clone address of Bitbucket Server is like: https://bitbucket.example.com/scm/owner/repo
+				server += "/scm"
+			}
+			return fmt.Sprintf("%s/%s/%s", server, b.BitbucketServerSource.Owner, b.BitbucketServerSource.Repo)
+		}
+	case SourceTypeAzureDevOps:
+		if b.AzureDevOpsSource != nil {
+			// the clone address of Azure Repos is like: https://dev.azure.com/organization/project/_git/repo
+			return fmt.Sprintf("%s/%s/%s/_git/%s", getServerFromAPIURI(b.AzureDevOpsSource.ApiUri, "https://dev.azure.com"),
+				b.AzureDevOpsSource.Owner, b.AzureDevOpsSource.Project, b.AzureDevOpsSource.Repo)
+		}
+	case SourceTypeGitee:
+		if b.GiteeSource != nil {
+			return fmt.Sprintf("%s/%s/%s", getServerFromAPIURI(b.GiteeSource.ApiUri, "https://gitee.com"),
+				b.GiteeSource.Owner, b.GiteeSource.Repo)
 		}
 	}
 	return ""
 }
 
+// GetRepoURL returns the address of the repository, it supports both the git and SVN repositories
+func (b *MultiBranchPipeline) GetRepoURL() string {
+	switch b.SourceType {
+	case SourceTypeSVN:
+		if b.SvnSource != nil {
+			return b.SvnSource.Remote
+		}
+	case SourceTypeSingleSVN:
+		if b.SingleSvnSource != nil {
+			return b.SingleSvnSource.Remote
+		}
+	}
+	return b.GetGitURL()
+}
+
+// getServerFromAPIURI returns the server address from the API URI of a git provider, such as:
+// https://api.github.com -> https://github.com, https://github.example.com/api/v3 -> https://github.example.com
+func getServerFromAPIURI(apiURI, defaultServer string) string {
+	apiURL, err := url.Parse(strings.TrimSpace(apiURI))
+	if apiURI == "" || err != nil || apiURL.Host == "" {
+		return defaultServer
+	}
+
+	switch host := strings.ToLower(apiURL.Host); host {
+	case "api.github.com", "api.bitbucket.org":
+		apiURL.Host = strings.TrimPrefix(host, "api.")
+	}
+	path := strings.TrimSuffix(apiURL.Path, "/")
+	for _, suffix := range []string{"/api/v3", "/api/v4", "/rest/api/1.0", "/2.0", "/api/v5"} {
+		path = strings.TrimSuffix(path, suffix)
+	}
+	return fmt.Sprintf("%s://%s%s", apiURL.Scheme, apiURL.Host, path)
+}
+
 type GitSource struct {
 	ScmId            string          `json:"scm_id,omitempty" description:"uid of scm"`
 	Url              string          `json:"url,omitempty" mapstructure:"url" description:"url of git source"`
@@ -229,6 +498,35 @@ type BitbucketServerSource struct {
 	AcceptJenkinsNotification bool                 `json:"accept_jenkins_notification,omitempty"  mapstructure:"accept_jenkins_notification" description:"Allow Jenkins send build status notification to Bitbucket"`
 }
 
+// AzureDevOpsSource is a git repository of Azure Repos, the organization of Azure DevOps is taken as the owner
+type AzureDevOpsSource struct {
+	ScmId                string          `json:"scm_id,omitempty" description:"uid of scm"`
+	Owner                string          `json:"owner,omitempty" mapstructure:"owner" description:"organization of azure devops repo"`
+	Project              string          `json:"project,omitempty" mapstructure:"project" description:"project of azure devops repo"`
+	Repo                 string          `json:"repo,omitempty" mapstructure:"repo" description:"repo name of azure devops repo"`
+	CredentialId         string          `json:"credential_id,omitempty" mapstructure:"credential_id" description:"credential id to access azure devops source"`
+	ApiUri               string          `json:"api_uri,omitempty" mapstructure:"api_uri" description:"The api url can specify the location of the azure devops server.For private cloud configuration"`
+	DiscoverBranches     bool            `json:"discover_branches,omitempty" mapstructure:"discover_branches" description:"Whether to discover a branch"`
+	DiscoverPullRequests bool            `json:"discover_pull_requests,omitempty" mapstructure:"discover_pull_requests" description:"Whether to discover the pull requests"`
+	DiscoverTags         bool            `json:"discover_tags,omitempty" mapstructure:"discover_tags" description:"Discover tag configuration"`
+	CloneOption          *GitCloneOption `json:"git_clone_option,omitempty" mapstructure:"git_clone_option" description:"advavced git clone options"`
+	RegexFilter          string          `json:"regex_filter,omitempty" mapstructure:"regex_filter" description:"Regex used to match the name of the branch that needs to be run"`
+}
+
+// GiteeSource is a git repository of Gitee
+type GiteeSource struct {
+	ScmId                string          `json:"scm_id,omitempty" description:"uid of scm"`
+	Owner                string          `json:"owner,omitempty" mapstructure:"owner" description:"owner of gitee repo"`
+	Repo                 string          `json:"repo,omitempty" mapstructure:"repo" description:"repo name of gitee repo"`
+	CredentialId         string          `json:"credential_id,omitempty" mapstructure:"credential_id" description:"credential id to access gitee source"`
+	ApiUri               string          `json:"api_uri,omitempty" mapstructure:"api_uri" description:"The api url can specify the location of the gitee apiserver.For private cloud configuration"`
+	DiscoverBranches     bool            `json:"discover_branches,omitempty" mapstructure:"discover_branches" description:"Whether to discover a branch"`
+	DiscoverPullRequests bool            `json:"discover_pull_requests,omitempty" mapstructure:"discover_pull_requests" description:"Whether to discover the pull requests"`
+	DiscoverTags         bool            `json:"discover_tags,omitempty" mapstructure:"discover_tags" description:"Discover tag configuration"`
+	CloneOption          *GitCloneOption `json:"git_clone_option,omitempty" mapstructure:"git_clone_option" description:"advavced git clone options"`
+	RegexFilter          string          `json:"regex_filter,omitempty" mapstructure:"regex_filter" description:"Regex used to match the name of the branch that needs to be run"`
+}
+
 type MultiBranchJobTrigger struct {
 	CreateActionJobsToTrigger string `json:"create_action_job_to_trigger,omitempty" description:"pipeline name to trigger"`
 	DeleteActionJobsToTrigger string `json:"delete_action_job_to_trigger,omitempty" description:"pipeline name to trigger"`
@@ -268,6 +566,8 @@ type ParameterDefinition struct {
 	DefaultValue string `json:"default_value,omitempty" yaml:"default_value" mapstructure:"default_value" description:"default value of param"`
 	Type         string `json:"type" description:"type of param"`
 	Description  string `json:"description,omitempty" description:"description of pipeline"`
+	// Required indicates the parameter must be provided when running the Pipeline
+	Required bool `json:"required,omitempty" description:"the parameter must be provided when running the Pipeline"`
 }
 
 type TimerTrigger struct {
@@ -283,15 +583,16 @@ type RemoteTrigger struct {
 }
 
 type GenericWebhook struct {
-	Enable           bool              `json:"enable,omitempty" description:"Indicate if the generic webhook is enabled"`
-	Token            string            `json:"token,omitempty" description:"The token of generic webhook"`
-	Cause            string            `json:"cause,omitempty" description:"Indicate the reason why a webhook triggered"`
-	PrintVariables   bool              `json:"print_variables,omitempty" description:"Indicate if print the variables"`
-	PrintPostContent bool              `json:"print_post_content,omitempty" description:"Indicate if print the post content"`
-	RequestVariables []GenericVariable `json:"request_variables,omitempty" description:"Define variables which come from the HTTP request"`
-	HeaderVariables  []GenericVariable `json:"header_variables,omitempty" description:"Define variables which come from the HTTP request header"`
-	FilterText       string            `json:"filter_text,omitempty" description:"Filter name for the generic webhook, it could be a variable name"`
-	FilterExpression string            `json:"filter_expression,omitempty" description:"Filter expression which against the filter name"`
+	Enable               bool                         `json:"enable,omitempty" description:"Indicate if the generic webhook is enabled"`
+	Token                string                       `json:"token,omitempty" description:"The token of generic webhook"`
+	Cause                string                       `json:"cause,omitempty" description:"Indicate the reason why a webhook triggered"`
+	PrintVariables       bool                         `json:"print_variables,omitempty" description:"Indicate if print the variables"`
+	PrintPostContent     bool                         `json:"print_post_content,omitempty" description:"Indicate if print the post content"`
+	RequestVariables     []GenericVariable            `json:"request_variables,omitempty" description:"Define variables which come from the HTTP request"`
+	HeaderVariables      []GenericVariable            `json:"header_variables,omitempty" description:"Define variables which come from the HTTP request header"`
+	PostContentVariables []GenericPostContentVariable `json:"post_content_variables,omitempty" description:"Define variables which come from the HTTP request body with JSONPath or XPath"`
+	FilterText           string                       `json:"filter_text,omitempty" description:"Filter name for the generic webhook, it could be a variable name"`
+	FilterExpression     string                       `json:"filter_expression,omitempty" description:"Filter expression which against the filter name"`
 }
 
 type GenericVariable struct {
@@ -299,6 +600,22 @@ type GenericVariable struct {
 	RegexpFilter string `json:"regexp_filter,omitempty" description:"A regexp filter which take value from HTTP request, or header etc."`
 }
 
+// GenericPostContentVariable is a variable which is extracted from the post content
+type GenericPostContentVariable struct {
+	Key            string `json:"key,omitempty" description:"Variable name as a key"`
+	Value          string `json:"value,omitempty" description:"The JSONPath or XPath expression, such as $.ref or /project/name"`
+	ExpressionType string `json:"expression_type,omitempty" description:"The type of the expression, JSONPath or XPath. JSONPath is the default one"`
+	DefaultValue   string `json:"default_value,omitempty" description:"The value when nothing is extracted from the post content"`
+	RegexpFilter   string `json:"regexp_filter,omitempty" description:"A regexp filter, the matched text is removed from the value"`
+}
+
+const (
+	// GenericExpressionTypeJSONPath indicates the expression of a variable is JSONPath
+	GenericExpressionTypeJSONPath = "JSONPath"
+	// GenericExpressionTypeXPath indicates the expression of a variable is XPath
+	GenericExpressionTypeXPath = "XPath"
+)
+
 func init() {
 	SchemeBuilder.Register(&Pipeline{}, &PipelineList{})
 }
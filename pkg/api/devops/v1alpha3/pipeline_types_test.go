@@ -19,6 +19,7 @@ package v1alpha3
 import (
 	"github.com/stretchr/testify/assert"
 	"testing"
+	"time"
 )
 
 func TestPipeline_IsMultiBranch(t *testing.T) {
@@ -70,6 +71,8 @@ func TestMultiBranchPipeline_GetGitURL(t *testing.T) {
 		GitHubSource          *GithubSource
 		GitlabSource          *GitlabSource
 		BitbucketServerSource *BitbucketServerSource
+		AzureDevOpsSource     *AzureDevOpsSource
+		GiteeSource           *GiteeSource
 	}
 	tests := []struct {
 		name   string
@@ -103,6 +106,62 @@ func TestMultiBranchPipeline_GetGitURL(t *testing.T) {
 			BitbucketServerSource: &BitbucketServerSource{Owner: "linuxsuren", Repo: "tools"},
 		},
 		want: "https://bitbucket.org/linuxsuren/tools",
+	}, {
+		name: "GitHub Enterprise",
+		fields: fields{
+			SourceType:   SourceTypeGithub,
+			GitHubSource: &GithubSource{Owner: "linuxsuren", Repo: "tools", ApiUri: "https://github.example.com/api/v3/"},
+		},
+		want: "https://github.example.com/linuxsuren/tools",
+	}, {
+		name: "github with the public API",
+		fields: fields{
+			SourceType:   SourceTypeGithub,
+			GitHubSource: &GithubSource{Owner: "linuxsuren", Repo: "tools", ApiUri: "https://api.github.com"},
+		},
+		want: "https://github.com/linuxsuren/tools",
+	}, {
+		name: "self-hosted gitlab",
+		fields: fields{
+			SourceType:   SourceTypeGitlab,
+			GitlabSource: &GitlabSource{Owner: "linuxsuren", Repo: "tools", ApiUri: "http://gitlab.example.com:8080"},
+		},
+		want: "http://gitlab.example.com:8080/linuxsuren/tools",
+	}, {
+		name: "bitbucket server",
+		fields: fields{
+			SourceType:            SourceTypeBitbucket,
+			BitbucketServerSource: &BitbucketServerSource{Owner: "linuxsuren", Repo: "tools", ApiUri: "https://bitbucket.example.com"},
+		},
+		want: "https://bitbucket.example.com/scm/linuxsuren/tools",
+	}, {
+		name: "azure devops",
+		fields: fields{
+			SourceType:        SourceTypeAzureDevOps,
+			AzureDevOpsSource: &AzureDevOpsSource{Owner: "kubesphere", Project: "devops", Repo: "tools"},
+		},
+		want: "https://dev.azure.com/kubesphere/devops/_git/tools",
+	}, {
+		name: "azure devops server",
+		fields: fields{
+			SourceType:        SourceTypeAzureDevOps,
+			AzureDevOpsSource: &AzureDevOpsSource{Owner: "collection", Project: "devops", Repo: "tools", ApiUri: "https://tfs.example.com/"},
+		},
+		want: "https://tfs.example.com/collection/devops/_git/tools",
+	}, {
+		name: "gitee",
+		fields: fields{
+			SourceType:  SourceTypeGitee,
+			GiteeSource: &GiteeSource{Owner: "linuxsuren", Repo: "tools"},
+		},
+		want: "https://gitee.com/linuxsuren/tools",
+	}, {
+		name: "gitee with the API URI",
+		fields: fields{
+			SourceType:  SourceTypeGitee,
+			GiteeSource: &GiteeSource{Owner: "linuxsuren", Repo: "tools", ApiUri: "https://gitee.com/api/v5"},
+		},
+		want: "https://gitee.com/linuxsuren/tools",
 	}, {
 		name: "fake",
 		fields: fields{
@@ -119,8 +178,121 @@ func TestMultiBranchPipeline_GetGitURL(t *testing.T) {
 				GitHubSource:          tt.fields.GitHubSource,
 				GitlabSource:          tt.fields.GitlabSource,
 				BitbucketServerSource: tt.fields.BitbucketServerSource,
+				AzureDevOpsSource:     tt.fields.AzureDevOpsSource,
+				GiteeSource:           tt.fields.GiteeSource,
 			}
 			assert.Equalf(t, tt.want, b.GetGitURL(), "GetGitURL()")
 		})
 	}
 }
+
+func TestMultiBranchPipeline_GetRepoURL(t *testing.T) {
+	assert.Equal(t, "svn://svn.example.com/repo", (&MultiBranchPipeline{
+		SourceType: SourceTypeSVN,
+		SvnSource:  &SvnSource{Remote: "svn://svn.example.com/repo"},
+	}).GetRepoURL())
+	assert.Equal(t, "svn://svn.example.com/repo/trunk", (&MultiBranchPipeline{
+		SourceType:      SourceTypeSingleSVN,
+		SingleSvnSource: &SingleSvnSource{Remote: "svn://svn.example.com/repo/trunk"},
+	}).GetRepoURL())
+	assert.Equal(t, "https://fake.com", (&MultiBranchPipeline{
+		SourceType: SourceTypeGit,
+		GitSource:  &GitSource{Url: "https://fake.com"},
+	}).GetRepoURL())
+}
+
+func TestPipeline_GetBranchCleanupPolicy(t *testing.T) {
+	pipeline := &Pipeline{}
+	assert.Equal(t, BranchCleanupPolicyArchive, pipeline.GetBranchCleanupPolicy())
+
+	pipeline.Annotations = map[string]string{PipelineBranchCleanupPolicyAnnoKey: "invalid"}
+	assert.Equal(t, BranchCleanupPolicyArchive, pipeline.GetBranchCleanupPolicy())
+
+	pipeline.Annotations[PipelineBranchCleanupPolicyAnnoKey] = BranchCleanupPolicyDelete
+	assert.Equal(t, BranchCleanupPolicyDelete, pipeline.GetBranchCleanupPolicy())
+}
+
+func TestPipeline_SetRemovedBranchState(t *testing.T) {
+	pipeline := &Pipeline{}
+	assert.Nil(t, pipeline.GetRemovedBranches())
+	assert.False(t, pipeline.SetRemovedBranchState("master", false))
+
+	assert.True(t, pipeline.SetRemovedBranchState("master", true))
+	assert.True(t, pipeline.SetRemovedBranchState("PR-1", true))
+	assert.False(t, pipeline.SetRemovedBranchState("PR-1", true))
+	assert.Equal(t, []string{"master", "PR-1"}, pipeline.GetRemovedBranches())
+	assert.Equal(t, `["master","PR-1"]`, pipeline.Annotations[PipelineRemovedBranchesAnnoKey])
+
+	assert.True(t, pipeline.SetRemovedBranchState("master", false))
+	assert.Equal(t, []string{"PR-1"}, pipeline.GetRemovedBranches())
+	assert.True(t, pipeline.SetRemovedBranchState("PR-1", false))
+	_, ok := pipeline.Annotations[PipelineRemovedBranchesAnnoKey]
+	assert.False(t, ok)
+
+	pipeline.Annotations[PipelineRemovedBranchesAnnoKey] = "invalid"
+	assert.Nil(t, pipeline.GetRemovedBranches())
+}
+
+func TestPipeline_GetLastScanTime(t *testing.T) {
+	pipeline := &Pipeline{}
+	assert.Nil(t, pipeline.GetLastScanTime())
+
+	pipeline.Annotations = map[string]string{PipelineLastScanTimeAnnoKey: "invalid"}
+	assert.Nil(t, pipeline.GetLastScanTime())
+
+	pipeline.Annotations[PipelineLastScanTimeAnnoKey] = "2022-08-01T10:00:00Z"
+	lastScanTime := pipeline.GetLastScanTime()
+	assert.NotNil(t, lastScanTime)
+	assert.Equal(t, "2022-08-01T10:00:00Z", lastScanTime.UTC().Format(time.RFC3339))
+}
+
+func TestPipeline_PipelineAsCode(t *testing.T) {
+	var nilPipeline *Pipeline
+	assert.False(t, nilPipeline.IsPipelineAsCode())
+
+	pipeline := &Pipeline{}
+	assert.False(t, pipeline.IsPipelineAsCode())
+	assert.Equal(t, DefaultPipelineAsCodePath, pipeline.GetPipelineAsCodePath())
+	assert.Equal(t, DefaultPipelineAsCodeBranch, pipeline.GetPipelineAsCodeBranch(""))
+	assert.Equal(t, "main", pipeline.GetPipelineAsCodeBranch("main"))
+
+	pipeline.Annotations = map[string]string{
+		PipelineAsCodeGitRepoAnnoKey: "repo",
+		PipelineAsCodeBranchAnnoKey:  "release",
+		PipelineAsCodePathAnnoKey:    "/ci/pipeline.yaml",
+	}
+	assert.True(t, pipeline.IsPipelineAsCode())
+	assert.Equal(t, "ci/pipeline.yaml", pipeline.GetPipelineAsCodePath())
+	assert.Equal(t, "release", pipeline.GetPipelineAsCodeBranch("main"))
+}
+
+func TestPipeline_LastModifier(t *testing.T) {
+	pipeline := &Pipeline{}
+	assert.Equal(t, "", pipeline.GetLastModifier())
+
+	pipeline.Annotations = map[string]string{PipelineLastModifierAnnoKey: "admin"}
+	assert.Equal(t, "", pipeline.GetLastModifier())
+
+	pipeline.SetLastModifier("tester")
+	assert.Equal(t, "tester", pipeline.GetLastModifier())
+
+	// the spec is changed without recording the modifier
+	pipeline.Spec.Type = NoScmPipelineType
+	assert.Equal(t, "", pipeline.GetLastModifier())
+}
+
+func TestPipelineStatus_SetCondition(t *testing.T) {
+	status := &PipelineStatus{}
+	assert.Nil(t, status.GetCondition(PipelineConditionCredentialsResolved))
+
+	assert.True(t, status.SetCondition(Condition{Type: PipelineConditionCredentialsResolved, Status: ConditionTrue}))
+	condition := status.GetCondition(PipelineConditionCredentialsResolved)
+	if assert.NotNil(t, condition) {
+		assert.False(t, condition.LastTransitionTime.IsZero())
+	}
+	assert.False(t, status.SetCondition(Condition{Type: PipelineConditionCredentialsResolved, Status: ConditionTrue}))
+
+	assert.True(t, status.SetCondition(Condition{Type: PipelineConditionCredentialsResolved, Status: ConditionFalse, Message: "missing"}))
+	assert.Len(t, status.Conditions, 1)
+	assert.Equal(t, "missing", status.Conditions[0].Message)
+}
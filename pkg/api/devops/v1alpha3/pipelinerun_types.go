@@ -72,12 +72,64 @@ type PipelineRunStatus struct {
 	// Current phase of PipelineRun.
 	// +optional
 	Phase RunPhase `json:"phase,omitempty"`
+
+	// CurrentStage is the name of the running stage, or the failed stage after the PipelineRun completes.
+	// +optional
+	CurrentStage string `json:"currentStage,omitempty"`
+
+	// Stages are the summaries of the stages and parallel branches, at most MaxStageStatuses stages are kept.
+	// +optional
+	Stages []StageStatus `json:"stages,omitempty"`
+}
+
+// MaxStageStatuses is the max number of the stages kept in the status of a PipelineRun
+const MaxStageStatuses = 50
+
+// StageStatus is the summary of a stage or a parallel branch of a PipelineRun
+type StageStatus struct {
+	// Name is the display name of the stage.
+	Name string `json:"name"`
+
+	// State of the stage, such as QUEUED, RUNNING, PAUSED, SKIPPED, NOT_BUILT and FINISHED.
+	// +optional
+	State string `json:"state,omitempty"`
+
+	// Result of the stage, such as SUCCESS, UNSTABLE, FAILURE, NOT_BUILT, UNKNOWN and ABORTED.
+	// +optional
+	Result string `json:"result,omitempty"`
+
+	// Start timestamp of the stage.
+	// +optional
+	StartTime *metav1.Time `json:"startTime,omitempty"`
+
+	// Duration of the stage.
+	// +optional
+	Duration *metav1.Duration `json:"duration,omitempty"`
+
+	// FailedStep is the first step which did not succeed in the stage.
+	// +optional
+	FailedStep *StepSummary `json:"failedStep,omitempty"`
+}
+
+// StepSummary is the summary of a step of a PipelineRun
+type StepSummary struct {
+	// Name is the display name of the step, such as "Shell Script".
+	Name string `json:"name"`
+
+	// Description is the display description of the step, such as the shell script, it might be truncated.
+	// +optional
+	Description string `json:"description,omitempty"`
+
+	// Result of the step.
+	// +optional
+	Result string `json:"result,omitempty"`
 }
 
 // +kubebuilder:object:root=true
 // +kubebuilder:subresource:status
 // +kubebuilder:printcolumn:name="ID",type=string,JSONPath=`.metadata.annotations.devops\.kubesphere\.io/jenkins-pipelinerun-id`,description="The id of a PipelineRun"
 // +kubebuilder:printcolumn:name="Phase",type=string,JSONPath=`.status.phase`,description="The phase of a PipelineRun"
+// +kubebuilder:printcolumn:name="Stage",type=string,JSONPath=`.status.currentStage`,description="The running stage, or the failed stage of a PipelineRun"
 // +kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`,description="The age of a PipelineRun"
 // +kubebuilder:resource:shortName="pr",categories="devops"
 
@@ -153,9 +205,25 @@ func (pr *PipelineRun) LabelAsAnOrphan() {
 	pr.Labels[PipelineRunOrphanLabelKey] = "true"
 }
 
+// LabelAsBranchRemoved labels PipelineRun as a run of a removed branch.
+func (pr *PipelineRun) LabelAsBranchRemoved() {
+	if pr == nil {
+		return
+	}
+	if pr.Labels == nil {
+		pr.Labels = make(map[string]string)
+	}
+	pr.Labels[PipelineRunBranchRemovedLabelKey] = "true"
+}
+
+// IsBranchRemoved indicates if the PipelineRun belongs to a removed branch.
+func (pr *PipelineRun) IsBranchRemoved() bool {
+	return pr.Labels[PipelineRunBranchRemovedLabelKey] == "true"
+}
+
 // Buildable returns true if the PipelineRun is buildable, false otherwise.
 func (pr *PipelineRun) Buildable() bool {
-	return !pr.HasCompleted() && pr.Labels[PipelineRunOrphanLabelKey] != "true"
+	return !pr.HasCompleted() && pr.Labels[PipelineRunOrphanLabelKey] != "true" && !pr.IsBranchRemoved()
 }
 
 // IsMultiBranchPipeline indicates if the PipelineRun belongs a multi-branch pipeline.
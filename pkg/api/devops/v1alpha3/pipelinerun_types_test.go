@@ -141,6 +141,14 @@ func TestPipelineRun_Buildable(t *testing.T) {
 			},
 		},
 		want: false,
+	}, {
+		name: "not buildable due to its branch was removed",
+		fields: fields{
+			ObjectMeta: v1.ObjectMeta{
+				Labels: map[string]string{PipelineRunBranchRemovedLabelKey: "true"},
+			},
+		},
+		want: false,
 	}, {
 		name:   "not completed yet",
 		fields: fields{},
@@ -0,0 +1,128 @@
+/*
+Copyright 2022 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha3
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// SharedLibraryFinalizerName is the finalizer name which makes sure the library is removed from Jenkins
+const SharedLibraryFinalizerName = "finalizer.sharedlibrary.devops.kubesphere.io"
+
+// SharedLibraryPhase is the phase of the synchronization of a shared library
+type SharedLibraryPhase string
+
+const (
+	// SharedLibraryPhaseSynced indicates that the library is configured in Jenkins
+	SharedLibraryPhaseSynced SharedLibraryPhase = "Synced"
+	// SharedLibraryPhaseFailed indicates that the library failed to be configured in Jenkins
+	SharedLibraryPhaseFailed SharedLibraryPhase = "Failed"
+)
+
+//+kubebuilder:object:root=true
+//+kubebuilder:subresource:status
+//+kubebuilder:printcolumn:name="Repository",type="string",JSONPath=".spec.gitRepository.name"
+//+kubebuilder:printcolumn:name="Version",type="string",JSONPath=".spec.defaultVersion"
+//+kubebuilder:printcolumn:name="Implicit",type="boolean",JSONPath=".spec.implicit"
+//+kubebuilder:printcolumn:name="Phase",type="string",JSONPath=".status.phase"
+
+// SharedLibrary is a Pipeline shared library which is available for the Pipelines of a DevOps project
+type SharedLibrary struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   SharedLibrarySpec   `json:"spec,omitempty"`
+	Status SharedLibraryStatus `json:"status,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// SharedLibraryList contains a list of SharedLibrary
+type SharedLibraryList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []SharedLibrary `json:"items"`
+}
+
+//+kubebuilder:object:root=true
+//+kubebuilder:subresource:status
+//+kubebuilder:resource:scope=Cluster
+//+kubebuilder:printcolumn:name="Repository",type="string",JSONPath=".spec.gitRepository.name"
+//+kubebuilder:printcolumn:name="Version",type="string",JSONPath=".spec.defaultVersion"
+//+kubebuilder:printcolumn:name="Implicit",type="boolean",JSONPath=".spec.implicit"
+//+kubebuilder:printcolumn:name="Phase",type="string",JSONPath=".status.phase"
+
+// ClusterSharedLibrary is a Pipeline shared library which is available for all the Pipelines
+type ClusterSharedLibrary struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   SharedLibrarySpec   `json:"spec,omitempty"`
+	Status SharedLibraryStatus `json:"status,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// ClusterSharedLibraryList contains a list of ClusterSharedLibrary
+type ClusterSharedLibraryList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []ClusterSharedLibrary `json:"items"`
+}
+
+// SharedLibrarySpec represents the desired state of a shared library
+type SharedLibrarySpec struct {
+	// GitRepository is the git repository which holds the source code of the library
+	GitRepository GitRepositoryReference `json:"gitRepository"`
+	// DefaultVersion is the branch, tag or commit which is loaded when the Pipeline does not specify a version
+	// +optional
+	DefaultVersion string `json:"defaultVersion,omitempty"`
+	// Implicit indicates if the library is loaded without declaring it in the Jenkinsfile
+	// +optional
+	Implicit bool `json:"implicit,omitempty"`
+}
+
+// GitRepositoryReference is the reference of a GitRepository
+type GitRepositoryReference struct {
+	// Name is the name of the GitRepository
+	Name string `json:"name"`
+	// Namespace is the namespace of the GitRepository, it is used by the ClusterSharedLibrary only.
+	// The GitRepository of a ClusterSharedLibrary must be in the system namespace, it is the default value.
+	// The GitRepository of a SharedLibrary must be in the same namespace.
+	// +optional
+	Namespace string `json:"namespace,omitempty"`
+}
+
+// SharedLibraryStatus represents the result of configuring a shared library in Jenkins
+type SharedLibraryStatus struct {
+	// Phase is the result of the latest synchronization
+	// +optional
+	Phase SharedLibraryPhase `json:"phase,omitempty"`
+	// Message describes the reason of the failure
+	// +optional
+	Message string `json:"message,omitempty"`
+	// ObservedGeneration is the generation which was synchronized
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+	// LastSyncTime is the last time when the library was synchronized
+	// +optional
+	LastSyncTime *metav1.Time `json:"lastSyncTime,omitempty"`
+}
+
+func init() {
+	SchemeBuilder.Register(&SharedLibrary{}, &SharedLibraryList{}, &ClusterSharedLibrary{}, &ClusterSharedLibraryList{})
+}
@@ -294,6 +294,26 @@ func (in *Argo) DeepCopy() *Argo {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AzureDevOpsSource) DeepCopyInto(out *AzureDevOpsSource) {
+	*out = *in
+	if in.CloneOption != nil {
+		in, out := &in.CloneOption, &out.CloneOption
+		*out = new(GitCloneOption)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AzureDevOpsSource.
+func (in *AzureDevOpsSource) DeepCopy() *AzureDevOpsSource {
+	if in == nil {
+		return nil
+	}
+	out := new(AzureDevOpsSource)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *BitbucketServerSource) DeepCopyInto(out *BitbucketServerSource) {
 	*out = *in
@@ -319,6 +339,65 @@ func (in *BitbucketServerSource) DeepCopy() *BitbucketServerSource {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClusterSharedLibrary) DeepCopyInto(out *ClusterSharedLibrary) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	out.Spec = in.Spec
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterSharedLibrary.
+func (in *ClusterSharedLibrary) DeepCopy() *ClusterSharedLibrary {
+	if in == nil {
+		return nil
+	}
+	out := new(ClusterSharedLibrary)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ClusterSharedLibrary) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClusterSharedLibraryList) DeepCopyInto(out *ClusterSharedLibraryList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]ClusterSharedLibrary, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterSharedLibraryList.
+func (in *ClusterSharedLibraryList) DeepCopy() *ClusterSharedLibraryList {
+	if in == nil {
+		return nil
+	}
+	out := new(ClusterSharedLibraryList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ClusterSharedLibraryList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ClusterStepTemplate) DeepCopyInto(out *ClusterStepTemplate) {
 	*out = *in
@@ -578,6 +657,21 @@ func (in *DiscoverPRFromForks) DeepCopy() *DiscoverPRFromForks {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GenericPostContentVariable) DeepCopyInto(out *GenericPostContentVariable) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GenericPostContentVariable.
+func (in *GenericPostContentVariable) DeepCopy() *GenericPostContentVariable {
+	if in == nil {
+		return nil
+	}
+	out := new(GenericPostContentVariable)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *GenericVariable) DeepCopyInto(out *GenericVariable) {
 	*out = *in
@@ -606,6 +700,11 @@ func (in *GenericWebhook) DeepCopyInto(out *GenericWebhook) {
 		*out = make([]GenericVariable, len(*in))
 		copy(*out, *in)
 	}
+	if in.PostContentVariables != nil {
+		in, out := &in.PostContentVariables, &out.PostContentVariables
+		*out = make([]GenericPostContentVariable, len(*in))
+		copy(*out, *in)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GenericWebhook.
@@ -639,7 +738,7 @@ func (in *GitRepository) DeepCopyInto(out *GitRepository) {
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
 	in.Spec.DeepCopyInto(&out.Spec)
-	out.Status = in.Status
+	in.Status.DeepCopyInto(&out.Status)
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GitRepository.
@@ -692,6 +791,21 @@ func (in *GitRepositoryList) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GitRepositoryReference) DeepCopyInto(out *GitRepositoryReference) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GitRepositoryReference.
+func (in *GitRepositoryReference) DeepCopy() *GitRepositoryReference {
+	if in == nil {
+		return nil
+	}
+	out := new(GitRepositoryReference)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *GitRepositorySpec) DeepCopyInto(out *GitRepositorySpec) {
 	*out = *in
@@ -720,6 +834,24 @@ func (in *GitRepositorySpec) DeepCopy() *GitRepositorySpec {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *GitRepositoryStatus) DeepCopyInto(out *GitRepositoryStatus) {
 	*out = *in
+	if in.LastCheckedTime != nil {
+		in, out := &in.LastCheckedTime, &out.LastCheckedTime
+		*out = (*in).DeepCopy()
+	}
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Webhooks != nil {
+		in, out := &in.Webhooks, &out.Webhooks
+		*out = make([]WebhookStatus, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GitRepositoryStatus.
@@ -752,6 +884,26 @@ func (in *GitSource) DeepCopy() *GitSource {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GiteeSource) DeepCopyInto(out *GiteeSource) {
+	*out = *in
+	if in.CloneOption != nil {
+		in, out := &in.CloneOption, &out.CloneOption
+		*out = new(GitCloneOption)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GiteeSource.
+func (in *GiteeSource) DeepCopy() *GiteeSource {
+	if in == nil {
+		return nil
+	}
+	out := new(GiteeSource)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *GithubSource) DeepCopyInto(out *GithubSource) {
 	*out = *in
@@ -875,6 +1027,16 @@ func (in *MultiBranchPipeline) DeepCopyInto(out *MultiBranchPipeline) {
 		*out = new(BitbucketServerSource)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.AzureDevOpsSource != nil {
+		in, out := &in.AzureDevOpsSource, &out.AzureDevOpsSource
+		*out = new(AzureDevOpsSource)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.GiteeSource != nil {
+		in, out := &in.GiteeSource, &out.GiteeSource
+		*out = new(GiteeSource)
+		(*in).DeepCopyInto(*out)
+	}
 	if in.MultiBranchJobTrigger != nil {
 		in, out := &in.MultiBranchJobTrigger, &out.MultiBranchJobTrigger
 		*out = new(MultiBranchJobTrigger)
@@ -1038,7 +1200,7 @@ func (in *Pipeline) DeepCopyInto(out *Pipeline) {
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
 	in.Spec.DeepCopyInto(&out.Spec)
-	out.Status = in.Status
+	in.Status.DeepCopyInto(&out.Status)
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Pipeline.
@@ -1212,6 +1374,13 @@ func (in *PipelineRunStatus) DeepCopyInto(out *PipelineRunStatus) {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.Stages != nil {
+		in, out := &in.Stages, &out.Stages
+		*out = make([]StageStatus, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PipelineRunStatus.
@@ -1252,6 +1421,13 @@ func (in *PipelineSpec) DeepCopy() *PipelineSpec {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *PipelineStatus) DeepCopyInto(out *PipelineStatus) {
 	*out = *in
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PipelineStatus.
@@ -1346,6 +1522,100 @@ func (in *SecretInStep) DeepCopy() *SecretInStep {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SharedLibrary) DeepCopyInto(out *SharedLibrary) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	out.Spec = in.Spec
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SharedLibrary.
+func (in *SharedLibrary) DeepCopy() *SharedLibrary {
+	if in == nil {
+		return nil
+	}
+	out := new(SharedLibrary)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *SharedLibrary) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SharedLibraryList) DeepCopyInto(out *SharedLibraryList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]SharedLibrary, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SharedLibraryList.
+func (in *SharedLibraryList) DeepCopy() *SharedLibraryList {
+	if in == nil {
+		return nil
+	}
+	out := new(SharedLibraryList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *SharedLibraryList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SharedLibrarySpec) DeepCopyInto(out *SharedLibrarySpec) {
+	*out = *in
+	out.GitRepository = in.GitRepository
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SharedLibrarySpec.
+func (in *SharedLibrarySpec) DeepCopy() *SharedLibrarySpec {
+	if in == nil {
+		return nil
+	}
+	out := new(SharedLibrarySpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SharedLibraryStatus) DeepCopyInto(out *SharedLibraryStatus) {
+	*out = *in
+	if in.LastSyncTime != nil {
+		in, out := &in.LastSyncTime, &out.LastSyncTime
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SharedLibraryStatus.
+func (in *SharedLibraryStatus) DeepCopy() *SharedLibraryStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(SharedLibraryStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *SignatureKey) DeepCopyInto(out *SignatureKey) {
 	*out = *in
@@ -1376,6 +1646,50 @@ func (in *SingleSvnSource) DeepCopy() *SingleSvnSource {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *StageStatus) DeepCopyInto(out *StageStatus) {
+	*out = *in
+	if in.StartTime != nil {
+		in, out := &in.StartTime, &out.StartTime
+		*out = (*in).DeepCopy()
+	}
+	if in.Duration != nil {
+		in, out := &in.Duration, &out.Duration
+		*out = new(v1.Duration)
+		**out = **in
+	}
+	if in.FailedStep != nil {
+		in, out := &in.FailedStep, &out.FailedStep
+		*out = new(StepSummary)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new StageStatus.
+func (in *StageStatus) DeepCopy() *StageStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(StageStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *StepSummary) DeepCopyInto(out *StepSummary) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new StepSummary.
+func (in *StepSummary) DeepCopy() *StepSummary {
+	if in == nil {
+		return nil
+	}
+	out := new(StepSummary)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *StepTemplateSpec) DeepCopyInto(out *StepTemplateSpec) {
 	*out = *in
@@ -1696,3 +2010,27 @@ func (in *WebhookSpec) DeepCopy() *WebhookSpec {
 	in.DeepCopyInto(out)
 	return out
 }
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *WebhookStatus) DeepCopyInto(out *WebhookStatus) {
+	*out = *in
+	if in.Events != nil {
+		in, out := &in.Events, &out.Events
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.LastSyncTime != nil {
+		in, out := &in.LastSyncTime, &out.LastSyncTime
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new WebhookStatus.
+func (in *WebhookStatus) DeepCopy() *WebhookStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(WebhookStatus)
+	in.DeepCopyInto(out)
+	return out
+}
@@ -157,7 +157,8 @@ func (s *APIServer) installKubeSphereAPIs() {
 		jenkinsCore)
 	utilruntime.Must(err)
 	wss = append(wss, v1alpha2WSS...)
-	wss = append(wss, devopsv1alpha3.AddToContainer(s.container, s.DevopsClient, s.KubernetesClient, s.Client, tokenIssue, jenkinsCore)...)
+	wss = append(wss, devopsv1alpha3.AddToContainer(s.container, s.DevopsClient, s.KubernetesClient, s.Client, tokenIssue, jenkinsCore,
+		s.Config.SCMOAuthOption, s.CacheClient, s.Config.AuthenticationOptions.JwtSecret)...)
 	wss = append(wss, oauth.AddToContainer(s.container,
 		auth.NewTokenOperator(
 			s.CacheClient,
@@ -180,6 +181,15 @@ func (s *APIServer) Run(stopCh context.Context) (err error) {
 	if err := indexers.CreatePipelineRunIdentityIndexer(s.RuntimeCache); err != nil {
 		return err
 	}
+	if err := indexers.CreatePipelineRepoURLIndexer(s.RuntimeCache); err != nil {
+		return err
+	}
+	if err := indexers.CreatePipelineGenericWebhookTokenIndexer(s.RuntimeCache); err != nil {
+		return err
+	}
+	if err := indexers.CreateGitRepositoryURLIndexer(s.RuntimeCache); err != nil {
+		return err
+	}
 
 	err = s.waitForResourceSync(stopCh)
 	if err != nil {
@@ -19,6 +19,7 @@ package cache
 import (
 	"regexp"
 	"strings"
+	"sync"
 	"time"
 
 	"kubesphere.io/devops/pkg/server/errors"
@@ -35,6 +36,8 @@ type simpleObject struct {
 // SimpleCache implements cache.Interface use memory objects, it should be used only for testing
 type simpleCache struct {
 	store map[string]simpleObject
+	// it is shared by the concurrent API requests
+	lock sync.RWMutex
 }
 
 func NewSimpleCache() Interface {
@@ -50,6 +53,8 @@ func (s *simpleCache) Keys(pattern string) ([]string, error) {
 	if err != nil {
 		return nil, err
 	}
+	s.lock.RLock()
+	defer s.lock.RUnlock()
 	var keys []string
 	for k := range s.store {
 		if re.MatchString(k) {
@@ -71,11 +76,15 @@ func (s *simpleCache) Set(key string, value string, duration time.Duration) erro
 		sobject.neverExpire = true
 	}
 
+	s.lock.Lock()
+	defer s.lock.Unlock()
 	s.store[key] = sobject
 	return nil
 }
 
 func (s *simpleCache) Del(keys ...string) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
 	for _, key := range keys {
 		delete(s.store, key)
 	}
@@ -83,6 +92,8 @@ func (s *simpleCache) Del(keys ...string) error {
 }
 
 func (s *simpleCache) Get(key string) (string, error) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
 	if sobject, ok := s.store[key]; ok {
 		if sobject.neverExpire || time.Now().Before(sobject.expiredAt) {
 			return sobject.value, nil
@@ -93,6 +104,8 @@ func (s *simpleCache) Get(key string) (string, error) {
 }
 
 func (s *simpleCache) Exists(keys ...string) (bool, error) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
 	for _, key := range keys {
 		if _, ok := s.store[key]; !ok {
 			return false, nil
@@ -118,6 +131,8 @@ func (s *simpleCache) Expire(key string, duration time.Duration) error {
 		sobject.neverExpire = true
 	}
 
+	s.lock.Lock()
+	defer s.lock.Unlock()
 	s.store[key] = sobject
 	return nil
 }
@@ -26,6 +26,7 @@ import (
 
 	"github.com/emicklei/go-restful"
 	v1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/yaml"
 
 	devopsv1alpha3 "kubesphere.io/devops/pkg/api/devops/v1alpha3"
 
@@ -40,6 +41,8 @@ type Devops struct {
 	Pipelines map[string]map[string]*devopsv1alpha3.Pipeline
 
 	Credentials map[string]map[string]*v1.Secret
+
+	Libraries map[string]map[string]*devops.SharedLibrary
 }
 
 func New(projects ...string) *Devops {
@@ -561,6 +564,34 @@ func (d *Devops) GetProjectPipelineConfig(projectId, pipelineId string) (*devops
 	return d.Pipelines[projectId][pipelineId], nil
 }
 
+// PreviewProjectPipelineConfig returns the spec in YAML instead of the Jenkins config.xml
+func (d *Devops) PreviewProjectPipelineConfig(projectId string, pipeline *devopsv1alpha3.Pipeline) (string, error) {
+	data, err := yaml.Marshal(pipeline.Spec)
+	return string(data), err
+}
+
+// GetProjectPipelineDesiredConfig returns a copy of the Pipeline.
+// The description of an existing NoScm Pipeline is kept as same as the real generator does.
+func (d *Devops) GetProjectPipelineDesiredConfig(projectId string, pipeline *devopsv1alpha3.Pipeline) (*devopsv1alpha3.Pipeline, error) {
+	desired := pipeline.DeepCopy()
+	if current, ok := d.Pipelines[projectId][pipeline.Name]; ok && desired.Spec.Pipeline != nil {
+		desired.Spec.Pipeline.Description = ""
+		if current.Spec.Pipeline != nil {
+			desired.Spec.Pipeline.Description = current.Spec.Pipeline.Description
+		}
+	}
+	return desired, nil
+}
+
+// GetProjectPipelineConfigXML returns the spec in YAML of an existing Pipeline
+func (d *Devops) GetProjectPipelineConfigXML(projectId, pipelineId string) (string, error) {
+	pipeline, ok := d.Pipelines[projectId][pipelineId]
+	if !ok {
+		return "", restful.NewError(http.StatusNotFound, fmt.Sprintf("pipeline %s not found", pipelineId))
+	}
+	return d.PreviewProjectPipelineConfig(projectId, pipeline)
+}
+
 func (d *Devops) ReloadConfiguration() error {
 	return nil
 }
@@ -568,3 +599,27 @@ func (d *Devops) ReloadConfiguration() error {
 func (d *Devops) ApplyNewSource(string) error {
 	return nil
 }
+
+// ReplaceOrAddProjectSharedLibrary keeps the shared library in memory
+func (d *Devops) ReplaceOrAddProjectSharedLibrary(projectID string, library *devops.SharedLibrary) error {
+	if _, ok := d.Projects[projectID]; !ok {
+		return restful.NewError(http.StatusNotFound, fmt.Sprintf("project %s not found", projectID))
+	}
+	if d.Libraries == nil {
+		d.Libraries = map[string]map[string]*devops.SharedLibrary{}
+	}
+	if d.Libraries[projectID] == nil {
+		d.Libraries[projectID] = map[string]*devops.SharedLibrary{}
+	}
+	d.Libraries[projectID][library.Name] = library
+	return nil
+}
+
+// RemoveProjectSharedLibrary removes the shared library from memory
+func (d *Devops) RemoveProjectSharedLibrary(projectID, name string) error {
+	if _, ok := d.Projects[projectID]; !ok {
+		return restful.NewError(http.StatusNotFound, fmt.Sprintf("project %s not found", projectID))
+	}
+	delete(d.Libraries[projectID], name)
+	return nil
+}
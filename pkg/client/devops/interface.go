@@ -37,6 +37,8 @@ type Interface interface {
 	ProjectOperator
 
 	ConfigurationOperator
+
+	SharedLibraryOperator
 }
 
 func GetDevOpsStatusCode(devopsErr error) int {
@@ -0,0 +1,29 @@
+/*
+Copyright 2022 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package jclient
+
+import "kubesphere.io/devops/pkg/client/devops"
+
+// ReplaceOrAddProjectSharedLibrary replaces or adds a shared library of a devops project
+func (j *JenkinsClient) ReplaceOrAddProjectSharedLibrary(projectID string, library *devops.SharedLibrary) error {
+	return j.jenkins.ReplaceOrAddProjectSharedLibrary(projectID, library)
+}
+
+// RemoveProjectSharedLibrary removes a shared library of a devops project
+func (j *JenkinsClient) RemoveProjectSharedLibrary(projectID, name string) error {
+	return j.jenkins.RemoveProjectSharedLibrary(projectID, name)
+}
@@ -91,6 +91,21 @@ func (j *JenkinsClient) GetProjectPipelineConfig(projectID, pipelineID string) (
 	return j.jenkins.GetProjectPipelineConfig(projectID, pipelineID)
 }
 
+// PreviewProjectPipelineConfig returns the config.xml which would be pushed
+func (j *JenkinsClient) PreviewProjectPipelineConfig(projectID string, pipeline *devopsv1alpha3.Pipeline) (string, error) {
+	return j.jenkins.PreviewProjectPipelineConfig(projectID, pipeline)
+}
+
+// GetProjectPipelineDesiredConfig returns the pipeline config which would be pushed
+func (j *JenkinsClient) GetProjectPipelineDesiredConfig(projectID string, pipeline *devopsv1alpha3.Pipeline) (*devopsv1alpha3.Pipeline, error) {
+	return j.jenkins.GetProjectPipelineDesiredConfig(projectID, pipeline)
+}
+
+// GetProjectPipelineConfigXML returns the current config.xml of the pipeline
+func (j *JenkinsClient) GetProjectPipelineConfigXML(projectID, pipelineID string) (string, error) {
+	return j.jenkins.GetProjectPipelineConfigXML(projectID, pipelineID)
+}
+
 func getCreatePayload(pipeline *devopsv1alpha3.NoScmPipeline) (jobPayload *job.CreateJobPayload, err error) {
 	// NoScmPipeline do not have copy mode to create a pipeline
 	jobPayload = &job.CreateJobPayload{
@@ -0,0 +1,96 @@
+/*
+Copyright 2022 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package internal
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/beevik/etree"
+	"k8s.io/klog/v2"
+
+	devopsv1alpha3 "kubesphere.io/devops/pkg/api/devops/v1alpha3"
+)
+
+const (
+	azureDevOpsServer = "https://dev.azure.com"
+	// azureDevOpsPullRequestRef is the merge reference of the pull requests of Azure Repos
+	azureDevOpsPullRequestRef = "pull/*/merge"
+)
+
+// AppendAzureDevOpsSourceToEtree appends the Azure Repos source as a git source, because there is no Azure DevOps
+// branch source plugin required in Jenkins. The pull requests are discovered by their merge references.
+func AppendAzureDevOpsSourceToEtree(source *etree.Element, azureSource *devopsv1alpha3.AzureDevOpsSource) {
+	if azureSource == nil {
+		klog.Warning("please provide Azure DevOps source when the sourceType is Azure DevOps")
+		return
+	}
+	server := strings.TrimSuffix(azureSource.ApiUri, "/")
+	if server == "" {
+		server = azureDevOpsServer
+	}
+	AppendGitSourceToEtree(source, &devopsv1alpha3.GitSource{
+		ScmId:            azureSource.ScmId,
+		Url:              fmt.Sprintf("%s/%s/%s/_git/%s", server, azureSource.Owner, azureSource.Project, azureSource.Repo),
+		CredentialId:     azureSource.CredentialId,
+		DiscoverBranches: azureSource.DiscoverBranches,
+		DiscoverTags:     azureSource.DiscoverTags,
+		CloneOption:      azureSource.CloneOption,
+		RegexFilter:      azureSource.RegexFilter,
+	})
+	setGitSourceType(source, devopsv1alpha3.SourceTypeAzureDevOps, azureSource.ScmId)
+	if azureSource.DiscoverPullRequests {
+		appendOtherRefsTrait(source, azureDevOpsPullRequestRef, "PR-@{1}")
+	}
+}
+
+// GetAzureDevOpsSourceFromEtree parses the Azure Repos source from a git source
+func GetAzureDevOpsSourceFromEtree(source *etree.Element) *devopsv1alpha3.AzureDevOpsSource {
+	gitSource := GetGitSourcefromEtree(source)
+	azureSource := &devopsv1alpha3.AzureDevOpsSource{
+		CredentialId:         gitSource.CredentialId,
+		DiscoverBranches:     gitSource.DiscoverBranches,
+		DiscoverPullRequests: hasOtherRefsTrait(source, azureDevOpsPullRequestRef),
+		DiscoverTags:         gitSource.DiscoverTags,
+		CloneOption:          gitSource.CloneOption,
+		RegexFilter:          gitSource.RegexFilter,
+	}
+
+	remote, err := url.Parse(gitSource.Url)
+	if err != nil {
+		return azureSource
+	}
+	items := strings.SplitN(remote.Path, "/_git/", 2)
+	if len(items) != 2 {
+		return azureSource
+	}
+	azureSource.Repo = strings.Trim(items[1], "/")
+	if index := strings.LastIndex(items[0], "/"); index >= 0 {
+		azureSource.Project = items[0][index+1:]
+		items[0] = items[0][:index]
+	}
+	if index := strings.LastIndex(items[0], "/"); index >= 0 {
+		azureSource.Owner = items[0][index+1:]
+		items[0] = items[0][:index]
+	}
+	// the users could be in the address, such as: https://organization@dev.azure.com/organization/project/_git/repo
+	if server := fmt.Sprintf("%s://%s%s", remote.Scheme, remote.Host, items[0]); server != azureDevOpsServer {
+		azureSource.ApiUri = server
+	}
+	return azureSource
+}
@@ -32,6 +32,8 @@ func TestCommonSituation(t *testing.T) {
 	AppendGitSourceToEtree(nil, nil)
 	AppendSingleSvnSourceToEtree(nil, nil)
 	AppendSvnSourceToEtree(nil, nil)
+	AppendAzureDevOpsSourceToEtree(nil, nil)
+	AppendGiteeSourceToEtree(nil, nil)
 }
 
 func TestSkipJenkinsNotification(t *testing.T) {
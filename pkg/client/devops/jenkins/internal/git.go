@@ -18,6 +18,7 @@ package internal
 
 import (
 	"strconv"
+	"strings"
 
 	"github.com/beevik/etree"
 	"k8s.io/klog/v2"
@@ -25,6 +26,9 @@ import (
 	devopsv1alpha3 "kubesphere.io/devops/pkg/api/devops/v1alpha3"
 )
 
+// gitSourceTypeSeparator separates the source type and the SCM id in the id of a git source
+const gitSourceTypeSeparator = ":"
+
 func AppendGitSourceToEtree(source *etree.Element, gitSource *devopsv1alpha3.GitSource) {
 	if gitSource == nil {
 		klog.Warning("please provide Git source when the sourceType is Git")
@@ -114,3 +118,52 @@ func GetGitSourcefromEtree(source *etree.Element) *devopsv1alpha3.GitSource {
 	}
 	return &gitSource
 }
+
+// setGitSourceType keeps the source type in the id of a git source, because some kinds of sources, such as
+// Azure Repos and Gitee, are stored as the git sources. The id is kept as it is by Jenkins.
+func setGitSourceType(source *etree.Element, sourceType, scmID string) {
+	id := source.SelectElement("id")
+	if id == nil {
+		id = source.CreateElement("id")
+	}
+	if scmID != "" {
+		sourceType = sourceType + gitSourceTypeSeparator + scmID
+	}
+	id.SetText(sourceType)
+}
+
+// GetGitSourceType returns the source type which is kept in the id of a git source
+func GetGitSourceType(source *etree.Element) string {
+	if id := source.SelectElement("id"); id != nil {
+		switch sourceType := strings.SplitN(id.Text(), gitSourceTypeSeparator, 2)[0]; sourceType {
+		case devopsv1alpha3.SourceTypeAzureDevOps, devopsv1alpha3.SourceTypeGitee:
+			return sourceType
+		}
+	}
+	return devopsv1alpha3.SourceTypeGit
+}
+
+// appendOtherRefsTrait discovers the references which are neither branches nor tags, such as the pull requests
+func appendOtherRefsTrait(source *etree.Element, ref, nameMapping string) {
+	traits := source.SelectElement("traits")
+	if traits == nil {
+		traits = source.CreateElement("traits")
+	}
+	refTrait := traits.CreateElement("jenkins.plugins.git.traits.DiscoverOtherRefsTrait")
+	refTrait.CreateElement("ref").SetText(ref)
+	refTrait.CreateElement("nameMapping").SetText(nameMapping)
+}
+
+// hasOtherRefsTrait checks if the references are discovered by the git source
+func hasOtherRefsTrait(source *etree.Element, ref string) bool {
+	traits := source.SelectElement("traits")
+	if traits == nil {
+		return false
+	}
+	for _, refTrait := range traits.SelectElements("jenkins.plugins.git.traits.DiscoverOtherRefsTrait") {
+		if refElement := refTrait.SelectElement("ref"); refElement != nil && refElement.Text() == ref {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,94 @@
+/*
+Copyright 2022 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package internal
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/beevik/etree"
+	"k8s.io/klog/v2"
+
+	devopsv1alpha3 "kubesphere.io/devops/pkg/api/devops/v1alpha3"
+)
+
+const (
+	giteeServer = "https://gitee.com"
+	// giteePullRequestRef is the head reference of the pull requests of Gitee
+	giteePullRequestRef = "pull/*/head"
+)
+
+// AppendGiteeSourceToEtree appends the Gitee source as a git source, because there is no Gitee branch source
+// plugin required in Jenkins. The pull requests are discovered by their head references.
+func AppendGiteeSourceToEtree(source *etree.Element, giteeSource *devopsv1alpha3.GiteeSource) {
+	if giteeSource == nil {
+		klog.Warning("please provide Gitee source when the sourceType is Gitee")
+		return
+	}
+	server := strings.TrimSuffix(strings.TrimSuffix(giteeSource.ApiUri, "/"), "/api/v5")
+	if server == "" {
+		server = giteeServer
+	}
+	AppendGitSourceToEtree(source, &devopsv1alpha3.GitSource{
+		ScmId:            giteeSource.ScmId,
+		Url:              fmt.Sprintf("%s/%s/%s.git", server, giteeSource.Owner, giteeSource.Repo),
+		CredentialId:     giteeSource.CredentialId,
+		DiscoverBranches: giteeSource.DiscoverBranches,
+		DiscoverTags:     giteeSource.DiscoverTags,
+		CloneOption:      giteeSource.CloneOption,
+		RegexFilter:      giteeSource.RegexFilter,
+	})
+	setGitSourceType(source, devopsv1alpha3.SourceTypeGitee, giteeSource.ScmId)
+	if giteeSource.DiscoverPullRequests {
+		appendOtherRefsTrait(source, giteePullRequestRef, "PR-@{1}")
+	}
+}
+
+// GetGiteeSourceFromEtree parses the Gitee source from a git source
+func GetGiteeSourceFromEtree(source *etree.Element) *devopsv1alpha3.GiteeSource {
+	gitSource := GetGitSourcefromEtree(source)
+	giteeSource := &devopsv1alpha3.GiteeSource{
+		CredentialId:         gitSource.CredentialId,
+		DiscoverBranches:     gitSource.DiscoverBranches,
+		DiscoverPullRequests: hasOtherRefsTrait(source, giteePullRequestRef),
+		DiscoverTags:         gitSource.DiscoverTags,
+		CloneOption:          gitSource.CloneOption,
+		RegexFilter:          gitSource.RegexFilter,
+	}
+
+	remote, err := url.Parse(gitSource.Url)
+	if err != nil {
+		return giteeSource
+	}
+	path := strings.TrimSuffix(strings.Trim(remote.Path, "/"), ".git")
+	if index := strings.LastIndex(path, "/"); index >= 0 {
+		giteeSource.Repo = path[index+1:]
+		path = path[:index]
+	}
+	if index := strings.LastIndex(path, "/"); index >= 0 {
+		giteeSource.Owner = path[index+1:]
+		path = "/" + path[:index]
+	} else {
+		giteeSource.Owner = path
+		path = ""
+	}
+	if server := fmt.Sprintf("%s://%s%s", remote.Scheme, remote.Host, path); server != giteeServer {
+		giteeSource.ApiUri = server
+	}
+	return giteeSource
+}
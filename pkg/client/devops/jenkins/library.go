@@ -0,0 +1,153 @@
+/*
+Copyright 2022 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package jenkins
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/beevik/etree"
+	"github.com/emicklei/go-restful"
+
+	"kubesphere.io/devops/pkg/client/devops"
+)
+
+const (
+	folderLibrariesTag      = "org.jenkinsci.plugins.workflow.libs.FolderLibraries"
+	librariesTag            = "libraries"
+	libraryConfigurationTag = "org.jenkinsci.plugins.workflow.libs.LibraryConfiguration"
+)
+
+// ReplaceOrAddProjectSharedLibrary replaces or adds a shared library in the folder of the DevOps project
+func (j *Jenkins) ReplaceOrAddProjectSharedLibrary(projectID string, library *devops.SharedLibrary) error {
+	return j.updateFolderConfig(projectID, func(config string) (string, error) {
+		return replaceOrAddFolderLibrary(config, library)
+	})
+}
+
+// RemoveProjectSharedLibrary removes a shared library from the folder of the DevOps project
+func (j *Jenkins) RemoveProjectSharedLibrary(projectID, name string) error {
+	return j.updateFolderConfig(projectID, func(config string) (string, error) {
+		return removeFolderLibrary(config, name)
+	})
+}
+
+func (j *Jenkins) updateFolderConfig(projectID string, update func(config string) (string, error)) error {
+	folder, err := j.GetJob(projectID)
+	if err != nil {
+		return restful.NewError(devops.GetDevOpsStatusCode(err), err.Error())
+	}
+	config, err := folder.GetConfig()
+	if err != nil {
+		return restful.NewError(devops.GetDevOpsStatusCode(err), err.Error())
+	}
+
+	var updatedConfig string
+	if updatedConfig, err = update(config); err != nil || updatedConfig == config {
+		return err
+	}
+	if err = folder.UpdateConfig(updatedConfig); err != nil {
+		return restful.NewError(devops.GetDevOpsStatusCode(err), err.Error())
+	}
+	return nil
+}
+
+func replaceOrAddFolderLibrary(config string, library *devops.SharedLibrary) (string, error) {
+	doc, libraries, err := parseFolderLibraries(config)
+	if err != nil {
+		return "", err
+	}
+	// keep the config as it is if the library is not changed, it avoids updating the folder all the time
+	var current string
+	if current, err = writeFolderConfig(doc); err != nil {
+		return "", err
+	}
+	removeLibraryElement(libraries, library.Name)
+
+	libraryEle := libraries.CreateElement(libraryConfigurationTag)
+	libraryEle.CreateElement("name").SetText(library.Name)
+	retriever := libraryEle.CreateElement("retriever")
+	retriever.CreateAttr(ClassKey, "org.jenkinsci.plugins.workflow.libs.SCMSourceRetriever")
+	scm := retriever.CreateElement("scm")
+	scm.CreateAttr(ClassKey, "jenkins.plugins.git.GitSCMSource")
+	scm.CreateAttr(PluginKey, "git")
+	scm.CreateElement("id").SetText(library.Name)
+	scm.CreateElement("remote").SetText(library.Remote)
+	scm.CreateElement("credentialsId").SetText(library.CredentialID)
+	scm.CreateElement("traits").CreateElement("jenkins.plugins.git.traits.BranchDiscoveryTrait")
+	libraryEle.CreateElement("defaultVersion").SetText(library.DefaultVersion)
+	libraryEle.CreateElement("implicit").SetText(strconv.FormatBool(library.Implicit))
+	libraryEle.CreateElement("allowVersionOverride").SetText("true")
+	libraryEle.CreateElement("includeInChangesets").SetText("true")
+
+	var updated string
+	if updated, err = writeFolderConfig(doc); err == nil && updated == current {
+		updated = config
+	}
+	return updated, err
+}
+
+func removeFolderLibrary(config, name string) (string, error) {
+	doc, libraries, err := parseFolderLibraries(config)
+	if err != nil {
+		return "", err
+	}
+	if !removeLibraryElement(libraries, name) {
+		return config, nil
+	}
+	return writeFolderConfig(doc)
+}
+
+// parseFolderLibraries returns the libraries element of the folder config, it will be created if it does not exist
+func parseFolderLibraries(config string) (doc *etree.Document, libraries *etree.Element, err error) {
+	doc = etree.NewDocument()
+	if err = doc.ReadFromString(replaceXmlVersion(config, "1.1", "1.0")); err != nil {
+		return
+	}
+	folder := doc.Root()
+	if folder == nil {
+		err = fmt.Errorf("invalid folder config")
+		return
+	}
+	properties := addOrUpdateElement(folder, PropertiesTag, StringNull)
+	folderLibraries := properties.SelectElement(folderLibrariesTag)
+	if folderLibraries == nil {
+		folderLibraries = properties.CreateElement(folderLibrariesTag)
+		folderLibraries.CreateAttr(PluginKey, "pipeline-groovy-lib")
+	}
+	libraries = addOrUpdateElement(folderLibraries, librariesTag, StringNull)
+	return
+}
+
+func removeLibraryElement(libraries *etree.Element, name string) (removed bool) {
+	for _, library := range libraries.SelectElements(libraryConfigurationTag) {
+		if getElementTextValueOrEmpty(library, "name") == name {
+			libraries.RemoveChild(library)
+			removed = true
+		}
+	}
+	return
+}
+
+func writeFolderConfig(doc *etree.Document) (string, error) {
+	doc.Indent(2)
+	stringXml, err := doc.WriteToString()
+	if err != nil {
+		return "", err
+	}
+	return replaceXmlVersion(stringXml, "1.0", "1.1"), nil
+}
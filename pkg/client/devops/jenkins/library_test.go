@@ -0,0 +1,90 @@
+/*
+Copyright 2022 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package jenkins
+
+import (
+	"testing"
+
+	"github.com/beevik/etree"
+	"github.com/stretchr/testify/assert"
+
+	"kubesphere.io/devops/pkg/client/devops"
+)
+
+const emptyFolderConfig = `<?xml version='1.1' encoding='UTF-8'?>
+<com.cloudbees.hudson.plugins.folder.Folder plugin="cloudbees-folder">
+  <description></description>
+</com.cloudbees.hudson.plugins.folder.Folder>`
+
+func getFolderLibraries(t *testing.T, config string) []*etree.Element {
+	doc := etree.NewDocument()
+	assert.Nil(t, doc.ReadFromString(replaceXmlVersion(config, "1.1", "1.0")))
+	return doc.FindElements("//" + libraryConfigurationTag)
+}
+
+func TestFolderLibrary(t *testing.T) {
+	library := &devops.SharedLibrary{
+		Name:           "lib",
+		Remote:         "https://github.com/linuxsuren/lib.git",
+		CredentialID:   "git",
+		DefaultVersion: "main",
+		Implicit:       true,
+	}
+
+	config, err := replaceOrAddFolderLibrary(emptyFolderConfig, library)
+	assert.Nil(t, err)
+	libraries := getFolderLibraries(t, config)
+	if assert.Len(t, libraries, 1) {
+		assert.Equal(t, "lib", getElementTextValueOrEmpty(libraries[0], "name"))
+		assert.Equal(t, "main", getElementTextValueOrEmpty(libraries[0], "defaultVersion"))
+		assert.Equal(t, "true", getElementTextValueOrEmpty(libraries[0], "implicit"))
+		assert.Equal(t, "https://github.com/linuxsuren/lib.git", libraries[0].FindElement("retriever/scm/remote").Text())
+		assert.Equal(t, "git", libraries[0].FindElement("retriever/scm/credentialsId").Text())
+	}
+
+	// the config stays the same if nothing changed
+	unchanged, err := replaceOrAddFolderLibrary(config, library)
+	assert.Nil(t, err)
+	assert.Equal(t, config, unchanged)
+
+	// replace the library with the same name
+	library.DefaultVersion = "v1"
+	config, err = replaceOrAddFolderLibrary(config, library)
+	assert.Nil(t, err)
+	another := &devops.SharedLibrary{Name: "another", Remote: "https://github.com/linuxsuren/another.git"}
+	config, err = replaceOrAddFolderLibrary(config, another)
+	assert.Nil(t, err)
+	libraries = getFolderLibraries(t, config)
+	if assert.Len(t, libraries, 2) {
+		assert.Equal(t, "v1", getElementTextValueOrEmpty(libraries[0], "defaultVersion"))
+		assert.Equal(t, "another", getElementTextValueOrEmpty(libraries[1], "name"))
+	}
+
+	// remove the libraries
+	config, err = removeFolderLibrary(config, "lib")
+	assert.Nil(t, err)
+	libraries = getFolderLibraries(t, config)
+	if assert.Len(t, libraries, 1) {
+		assert.Equal(t, "another", getElementTextValueOrEmpty(libraries[0], "name"))
+	}
+	unchanged, err = removeFolderLibrary(config, "lib")
+	assert.Nil(t, err)
+	assert.Equal(t, config, unchanged)
+
+	_, err = replaceOrAddFolderLibrary("invalid", library)
+	assert.NotNil(t, err)
+}
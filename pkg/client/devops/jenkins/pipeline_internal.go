@@ -18,6 +18,7 @@ package jenkins
 
 import (
 	"fmt"
+	"net/http"
 	"strconv"
 	"strings"
 	"time"
@@ -25,6 +26,7 @@ import (
 	"kubesphere.io/devops/pkg/client/devops/jenkins/triggers"
 
 	"github.com/beevik/etree"
+	"github.com/emicklei/go-restful"
 
 	devopsv1alpha3 "kubesphere.io/devops/pkg/api/devops/v1alpha3"
 
@@ -38,6 +40,52 @@ func replaceXmlVersion(config, oldVersion, targetVersion string) string {
 	return output
 }
 
+// generatePipelineConfigXml generates the config.xml in the same way of creating or updating a Pipeline.
+// The current config is updated if it is not empty, it works for the NoScm Pipeline only.
+func generatePipelineConfigXml(projectName string, pipeline *devopsv1alpha3.Pipeline, current string) (string, error) {
+	switch pipeline.Spec.Type {
+	case devopsv1alpha3.NoScmPipelineType:
+		if pipeline.Spec.Pipeline == nil {
+			return "", restful.NewError(http.StatusBadRequest, "the pipeline field is required")
+		}
+		if current != "" {
+			return updatePipelineConfigXml(current, pipeline.Spec.Pipeline)
+		}
+		return createPipelineConfigXml(pipeline.Spec.Pipeline)
+	case devopsv1alpha3.MultiBranchPipelineType:
+		if pipeline.Spec.MultiBranchPipeline == nil {
+			return "", restful.NewError(http.StatusBadRequest, "the multi_branch_pipeline field is required")
+		}
+		return createMultiBranchPipelineConfigXml(projectName, pipeline.Spec.MultiBranchPipeline)
+	default:
+		return "", restful.NewError(http.StatusBadRequest, "error unsupport job type")
+	}
+}
+
+// parseProjectPipelineConfigXml parses the config.xml of a Pipeline according to its type
+func parseProjectPipelineConfigXml(pipelineType devopsv1alpha3.PipelineType, name, config string) (*devopsv1alpha3.Pipeline, error) {
+	pipeline := &devopsv1alpha3.Pipeline{Spec: devopsv1alpha3.PipelineSpec{Type: pipelineType}}
+	switch pipelineType {
+	case devopsv1alpha3.NoScmPipelineType:
+		noScm, err := parsePipelineConfigXml(config)
+		if err != nil {
+			return nil, err
+		}
+		noScm.Name = name
+		pipeline.Spec.Pipeline = noScm
+	case devopsv1alpha3.MultiBranchPipelineType:
+		multiBranch, err := parseMultiBranchPipelineConfigXml(config)
+		if err != nil {
+			return nil, err
+		}
+		multiBranch.Name = name
+		pipeline.Spec.MultiBranchPipeline = multiBranch
+	default:
+		return nil, fmt.Errorf("unsupported pipeline type: %s", pipelineType)
+	}
+	return pipeline, nil
+}
+
 func createPipelineConfigXml(pipeline *devopsv1alpha3.NoScmPipeline) (string, error) {
 	doc := etree.NewDocument()
 	xmlString := `<?xml version='1.0' encoding='UTF-8'?>
@@ -483,6 +531,10 @@ func createMultiBranchPipelineConfigXml(projectName string, pipeline *devopsv1al
 		internal.AppendSingleSvnSourceToEtree(source, pipeline.SingleSvnSource)
 	case devopsv1alpha3.SourceTypeBitbucket:
 		internal.AppendBitbucketServerSourceToEtree(source, pipeline.BitbucketServerSource)
+	case devopsv1alpha3.SourceTypeAzureDevOps:
+		internal.AppendAzureDevOpsSourceToEtree(source, pipeline.AzureDevOpsSource)
+	case devopsv1alpha3.SourceTypeGitee:
+		internal.AppendGiteeSourceToEtree(source, pipeline.GiteeSource)
 
 	default:
 		return "", fmt.Errorf("unsupport source type: %s", pipeline.SourceType)
@@ -553,8 +605,16 @@ func parseMultiBranchPipelineConfigXml(config string) (*devopsv1alpha3.MultiBran
 					pipeline.SourceType = devopsv1alpha3.SourceTypeGitlab
 
 				case "jenkins.plugins.git.GitSCMSource":
-					pipeline.SourceType = devopsv1alpha3.SourceTypeGit
-					pipeline.GitSource = internal.GetGitSourcefromEtree(source)
+					// Azure Repos and Gitee are stored as the git sources, their source types are kept in the source
+					switch pipeline.SourceType = internal.GetGitSourceType(source); pipeline.SourceType {
+					case devopsv1alpha3.SourceTypeAzureDevOps:
+						pipeline.AzureDevOpsSource = internal.GetAzureDevOpsSourceFromEtree(source)
+					case devopsv1alpha3.SourceTypeGitee:
+						pipeline.GiteeSource = internal.GetGiteeSourceFromEtree(source)
+					default:
+						pipeline.SourceType = devopsv1alpha3.SourceTypeGit
+						pipeline.GitSource = internal.GetGitSourcefromEtree(source)
+					}
 
 				case "jenkins.scm.impl.SingleSCMSource":
 					pipeline.SourceType = devopsv1alpha3.SourceTypeSingleSVN
@@ -493,6 +493,86 @@ func Test_MultiBranchPipelineConfig_Source(t *testing.T) {
 				CredentialId: "svn",
 			},
 		},
+		{
+			Name:        "",
+			Description: "for test",
+			ScriptPath:  "Jenkinsfile",
+			SourceType:  "azure_devops",
+			AzureDevOpsSource: &devopsv1alpha3.AzureDevOpsSource{
+				Owner:                "kubesphere",
+				Project:              "devops",
+				Repo:                 "devops",
+				CredentialId:         "azure",
+				DiscoverBranches:     true,
+				DiscoverPullRequests: true,
+				DiscoverTags:         true,
+				RegexFilter:          "*-dev",
+			},
+		},
+		{
+			Name:        "",
+			Description: "for test",
+			ScriptPath:  "Jenkinsfile",
+			SourceType:  "azure_devops",
+			AzureDevOpsSource: &devopsv1alpha3.AzureDevOpsSource{
+				Owner:            "collection",
+				Project:          "devops",
+				Repo:             "devops",
+				ApiUri:           "https://tfs.example.com",
+				DiscoverBranches: true,
+			},
+		},
+		{
+			Name:        "",
+			Description: "for test",
+			ScriptPath:  "Jenkinsfile",
+			SourceType:  "gitee",
+			GiteeSource: &devopsv1alpha3.GiteeSource{
+				Owner:                "kubesphere",
+				Repo:                 "devops",
+				CredentialId:         "gitee",
+				DiscoverBranches:     true,
+				DiscoverPullRequests: true,
+				CloneOption: &devopsv1alpha3.GitCloneOption{
+					Timeout: 10,
+					Depth:   10,
+				},
+			},
+		},
+		{
+			Name:        "",
+			Description: "for test",
+			ScriptPath:  "Jenkinsfile",
+			SourceType:  "gitee",
+			GiteeSource: &devopsv1alpha3.GiteeSource{
+				Owner:                "kubesphere",
+				Repo:                 "devops",
+				ApiUri:               "https://gitee.example.com",
+				DiscoverPullRequests: true,
+			},
+		},
+		{
+			Name:        "",
+			Description: "for test",
+			ScriptPath:  "Jenkinsfile",
+			SourceType:  "gitee",
+			GiteeSource: &devopsv1alpha3.GiteeSource{
+				Owner:            "kubesphere",
+				Repo:             "devops",
+				ApiUri:           "https://gitee.example.com",
+				DiscoverBranches: true,
+			},
+		},
+		{
+			Name:        "",
+			Description: "for test",
+			ScriptPath:  "Jenkinsfile",
+			SourceType:  "git",
+			GitSource: &devopsv1alpha3.GitSource{
+				Url:              "https://git.example.com/kubesphere/devops/_git/devops",
+				DiscoverBranches: true,
+			},
+		},
 	}
 
 	for _, input := range inputs {
@@ -1232,3 +1312,101 @@ func Test_getElementTextValueOrEmpty(t *testing.T) {
 		})
 	}
 }
+
+func Test_parseProjectPipelineConfigXml(t *testing.T) {
+	current, err := createPipelineConfigXml(&devopsv1alpha3.NoScmPipeline{Name: "fake"})
+	assert.Nil(t, err)
+	current, err = updatePipelineConfigXml(current, &devopsv1alpha3.NoScmPipeline{Name: "fake", Jenkinsfile: "echo 1"})
+	assert.Nil(t, err)
+
+	// the desired config equals to the current one if only the unmanaged fields are different
+	desired, err := generatePipelineConfigXml("project", &devopsv1alpha3.Pipeline{Spec: devopsv1alpha3.PipelineSpec{
+		Type:     devopsv1alpha3.NoScmPipelineType,
+		Pipeline: &devopsv1alpha3.NoScmPipeline{Name: "fake", Description: "desc", Jenkinsfile: "echo 1"},
+	}}, current)
+	assert.Nil(t, err)
+	currentPipeline, err := parseProjectPipelineConfigXml(devopsv1alpha3.NoScmPipelineType, "fake", current)
+	assert.Nil(t, err)
+	desiredPipeline, err := parseProjectPipelineConfigXml(devopsv1alpha3.NoScmPipelineType, "fake", desired)
+	assert.Nil(t, err)
+	assert.Equal(t, currentPipeline.Spec, desiredPipeline.Spec)
+	assert.Equal(t, "fake", desiredPipeline.Spec.Pipeline.Name)
+
+	multiBranch, err := createMultiBranchPipelineConfigXml("project", &devopsv1alpha3.MultiBranchPipeline{
+		Name: "fake", ScriptPath: "Jenkinsfile", SourceType: "git",
+		GitSource: &devopsv1alpha3.GitSource{Url: "https://github.com/kubesphere/devops"},
+	})
+	assert.Nil(t, err)
+	multiBranchPipeline, err := parseProjectPipelineConfigXml(devopsv1alpha3.MultiBranchPipelineType, "fake", multiBranch)
+	assert.Nil(t, err)
+	assert.Equal(t, "Jenkinsfile", multiBranchPipeline.Spec.MultiBranchPipeline.ScriptPath)
+
+	_, err = parseProjectPipelineConfigXml("unknown", "fake", current)
+	assert.NotNil(t, err)
+}
+
+func Test_generatePipelineConfigXml(t *testing.T) {
+	noScm := &devopsv1alpha3.NoScmPipeline{Name: "fake", Jenkinsfile: "node{echo 'hello'}"}
+	current, err := createPipelineConfigXml(&devopsv1alpha3.NoScmPipeline{Name: "fake", Description: "old"})
+	assert.Nil(t, err)
+
+	tests := []struct {
+		name    string
+		spec    devopsv1alpha3.PipelineSpec
+		current string
+		verify  func(t *testing.T, config string)
+		wantErr bool
+	}{{
+		name: "create a NoScm Pipeline",
+		spec: devopsv1alpha3.PipelineSpec{Type: devopsv1alpha3.NoScmPipelineType, Pipeline: noScm},
+		verify: func(t *testing.T, config string) {
+			pipeline, err := parsePipelineConfigXml(config)
+			assert.Nil(t, err)
+			assert.Equal(t, noScm.Jenkinsfile, pipeline.Jenkinsfile)
+		},
+	}, {
+		name:    "update a NoScm Pipeline",
+		spec:    devopsv1alpha3.PipelineSpec{Type: devopsv1alpha3.NoScmPipelineType, Pipeline: noScm},
+		current: current,
+		verify: func(t *testing.T, config string) {
+			pipeline, err := parsePipelineConfigXml(config)
+			assert.Nil(t, err)
+			assert.Equal(t, noScm.Jenkinsfile, pipeline.Jenkinsfile)
+			// the description is not managed by the update
+			assert.Equal(t, "old", pipeline.Description)
+		},
+	}, {
+		name: "multi-branch Pipeline",
+		spec: devopsv1alpha3.PipelineSpec{
+			Type: devopsv1alpha3.MultiBranchPipelineType,
+			MultiBranchPipeline: &devopsv1alpha3.MultiBranchPipeline{
+				Name: "fake", ScriptPath: "Jenkinsfile", SourceType: "git",
+				GitSource: &devopsv1alpha3.GitSource{Url: "https://github.com/kubesphere/devops"},
+			},
+		},
+		verify: func(t *testing.T, config string) {
+			pipeline, err := parseMultiBranchPipelineConfigXml(config)
+			assert.Nil(t, err)
+			assert.Equal(t, "Jenkinsfile", pipeline.ScriptPath)
+		},
+	}, {
+		name:    "missing the NoScm Pipeline",
+		spec:    devopsv1alpha3.PipelineSpec{Type: devopsv1alpha3.NoScmPipelineType},
+		wantErr: true,
+	}, {
+		name:    "unknown type",
+		spec:    devopsv1alpha3.PipelineSpec{Type: "unknown"},
+		wantErr: true,
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config, err := generatePipelineConfigXml("project", &devopsv1alpha3.Pipeline{Spec: tt.spec}, tt.current)
+			if tt.wantErr {
+				assert.NotNil(t, err)
+				return
+			}
+			assert.Nil(t, err)
+			tt.verify(t, config)
+		})
+	}
+}
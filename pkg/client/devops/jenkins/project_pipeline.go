@@ -148,42 +148,68 @@ func (j *Jenkins) GetProjectPipelineConfig(projectId, pipelineId string) (*devop
 		klog.Errorf("%+v", err)
 		return nil, restful.NewError(devops.GetDevOpsStatusCode(err), err.Error())
 	}
+	var pipelineType devopsv1alpha3.PipelineType
 	switch job.Raw.Class {
 	case "org.jenkinsci.plugins.workflow.job.WorkflowJob":
-		config, err := job.GetConfig()
-		if err != nil {
-			return nil, restful.NewError(devops.GetDevOpsStatusCode(err), err.Error())
-		}
-		pipeline, err := parsePipelineConfigXml(config)
-		if err != nil {
-			return nil, restful.NewError(devops.GetDevOpsStatusCode(err), err.Error())
-		}
-		pipeline.Name = pipelineId
-		return &devopsv1alpha3.Pipeline{
-			Spec: devopsv1alpha3.PipelineSpec{
-				Type:     devopsv1alpha3.NoScmPipelineType,
-				Pipeline: pipeline,
-			},
-		}, nil
-
+		pipelineType = devopsv1alpha3.NoScmPipelineType
 	case "org.jenkinsci.plugins.workflow.multibranch.WorkflowMultiBranchProject":
-		config, err := job.GetConfig()
-		if err != nil {
-			return nil, restful.NewError(devops.GetDevOpsStatusCode(err), err.Error())
-		}
-		pipeline, err := parseMultiBranchPipelineConfigXml(config)
-		if err != nil {
-			return nil, restful.NewError(devops.GetDevOpsStatusCode(err), err.Error())
-		}
-		pipeline.Name = pipelineId
-		return &devopsv1alpha3.Pipeline{
-			Spec: devopsv1alpha3.PipelineSpec{
-				Type:                devopsv1alpha3.MultiBranchPipelineType,
-				MultiBranchPipeline: pipeline,
-			},
-		}, nil
+		pipelineType = devopsv1alpha3.MultiBranchPipelineType
 	default:
+		err = fmt.Errorf("unsupported job class: %s", job.Raw.Class)
 		klog.Errorf("%+v", err)
 		return nil, restful.NewError(http.StatusBadRequest, err.Error())
 	}
+	config, err := job.GetConfig()
+	if err != nil {
+		return nil, restful.NewError(devops.GetDevOpsStatusCode(err), err.Error())
+	}
+	pipeline, err := parseProjectPipelineConfigXml(pipelineType, pipelineId, config)
+	if err != nil {
+		return nil, restful.NewError(devops.GetDevOpsStatusCode(err), err.Error())
+	}
+	return pipeline, nil
+}
+
+// GetProjectPipelineDesiredConfig returns the Pipeline parsed from the config.xml which would be pushed to Jenkins.
+// It only has the fields written to Jenkins, so it can be compared with the one of GetProjectPipelineConfig.
+func (j *Jenkins) GetProjectPipelineDesiredConfig(projectId string, pipeline *devopsv1alpha3.Pipeline) (*devopsv1alpha3.Pipeline, error) {
+	config, err := j.PreviewProjectPipelineConfig(projectId, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	desired, err := parseProjectPipelineConfigXml(pipeline.Spec.Type, pipeline.Name, config)
+	if err != nil {
+		return nil, restful.NewError(http.StatusInternalServerError, err.Error())
+	}
+	return desired, nil
+}
+
+// PreviewProjectPipelineConfig returns the config.xml which would be pushed to Jenkins without changing anything
+func (j *Jenkins) PreviewProjectPipelineConfig(projectId string, pipeline *devopsv1alpha3.Pipeline) (string, error) {
+	var current string
+	if pipeline.Spec.Type == devopsv1alpha3.NoScmPipelineType {
+		// the existing config of a Pipeline is updated instead of being replaced
+		job, err := j.GetJob(pipeline.Name, projectId)
+		if err == nil {
+			if current, err = job.GetConfig(); err != nil {
+				return "", restful.NewError(devops.GetDevOpsStatusCode(err), err.Error())
+			}
+		} else if devops.GetDevOpsStatusCode(err) != http.StatusNotFound {
+			return "", restful.NewError(devops.GetDevOpsStatusCode(err), err.Error())
+		}
+	}
+	return generatePipelineConfigXml(projectId, pipeline, current)
+}
+
+// GetProjectPipelineConfigXML returns the current config.xml of a Pipeline in Jenkins
+func (j *Jenkins) GetProjectPipelineConfigXML(projectId, pipelineId string) (string, error) {
+	job, err := j.GetJob(pipelineId, projectId)
+	if err != nil {
+		return "", restful.NewError(devops.GetDevOpsStatusCode(err), err.Error())
+	}
+	config, err := job.GetConfig()
+	if err != nil {
+		return "", restful.NewError(devops.GetDevOpsStatusCode(err), err.Error())
+	}
+	return config, nil
 }
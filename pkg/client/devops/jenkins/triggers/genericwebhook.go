@@ -42,6 +42,16 @@ func CreateGenericWebhookXML(parent *etree.Element, webhook *v1alpha3.GenericWeb
 	ele.CreateElement("regexpFilterText").SetText(webhook.FilterText)
 	ele.CreateElement("regexpFilterExpression").SetText(webhook.FilterExpression)
 
+	postVarsEle := ele.CreateElement("genericVariables")
+	for _, item := range webhook.PostContentVariables {
+		varEle := postVarsEle.CreateElement("org.jenkinsci.plugins.gwt.GenericVariable")
+		varEle.CreateElement("expressionType").SetText(getExpressionType(item.ExpressionType))
+		varEle.CreateElement("key").SetText(item.Key)
+		varEle.CreateElement("value").SetText(item.Value)
+		varEle.CreateElement("regexpFilter").SetText(item.RegexpFilter)
+		varEle.CreateElement("defaultValue").SetText(item.DefaultValue)
+	}
+
 	requestVarsEle := ele.CreateElement("genericRequestVariables")
 	for _, item := range webhook.RequestVariables {
 		varEle := requestVarsEle.CreateElement("org.jenkinsci.plugins.gwt.GenericRequestVariable")
@@ -74,6 +84,20 @@ func ParseGenericWebhookXML(ele *etree.Element) (webhook *v1alpha3.GenericWebhoo
 		FilterExpression: getElementText(ele, "regexpFilterExpression"),
 	}
 
+	if postVarsEle := ele.SelectElement("genericVariables"); postVarsEle != nil {
+		if eles := postVarsEle.SelectElements("org.jenkinsci.plugins.gwt.GenericVariable"); len(eles) > 0 {
+			for i := range eles {
+				webhook.PostContentVariables = append(webhook.PostContentVariables, v1alpha3.GenericPostContentVariable{
+					Key:            getElementText(eles[i], "key"),
+					Value:          getElementText(eles[i], "value"),
+					ExpressionType: getElementText(eles[i], "expressionType"),
+					DefaultValue:   getElementText(eles[i], "defaultValue"),
+					RegexpFilter:   getElementText(eles[i], "regexpFilter"),
+				})
+			}
+		}
+	}
+
 	if reqVarsEle := ele.SelectElement("genericRequestVariables"); reqVarsEle != nil {
 		if eles := reqVarsEle.SelectElements("org.jenkinsci.plugins.gwt.GenericRequestVariable"); eles != nil {
 			webhook.RequestVariables = []v1alpha3.GenericVariable{}
@@ -102,6 +126,14 @@ func ParseGenericWebhookXML(ele *etree.Element) (webhook *v1alpha3.GenericWebhoo
 	return
 }
 
+// getExpressionType returns the expression type of the generic variable, JSONPath is the default one
+func getExpressionType(expressionType string) string {
+	if expressionType == v1alpha3.GenericExpressionTypeXPath {
+		return expressionType
+	}
+	return v1alpha3.GenericExpressionTypeJSONPath
+}
+
 func getElementText(ele *etree.Element, childName string) string {
 	if ele == nil {
 		return ""
@@ -67,6 +67,36 @@ func TestCreateGenericWebhookXML(t *testing.T) {
 	assert.Equal(t, headerVarsEle[0].SelectElement("regexpFilter").Text(), webhook.HeaderVariables[0].RegexpFilter)
 }
 
+func TestGenericWebhookXMLWithPostContentVariables(t *testing.T) {
+	webhook := &v1alpha3.GenericWebhook{
+		Enable: true,
+		Token:  "token",
+		PostContentVariables: []v1alpha3.GenericPostContentVariable{{
+			Key:   "ref",
+			Value: "$.ref",
+		}, {
+			Key:            "name",
+			Value:          "/project/name",
+			ExpressionType: v1alpha3.GenericExpressionTypeXPath,
+			DefaultValue:   "unknown",
+			RegexpFilter:   "-",
+		}},
+	}
+	ele := CreateGenericWebhookXML(&etree.Element{}, webhook)
+	varsEle := ele.SelectElement("genericVariables").SelectElements("org.jenkinsci.plugins.gwt.GenericVariable")
+	assert.Equal(t, 2, len(varsEle))
+	// JSONPath is the default expression type
+	assert.Equal(t, v1alpha3.GenericExpressionTypeJSONPath, varsEle[0].SelectElement("expressionType").Text())
+	assert.Equal(t, "$.ref", varsEle[0].SelectElement("value").Text())
+
+	parsed := ParseGenericWebhookXML(ele)
+	assert.Equal(t, []v1alpha3.GenericPostContentVariable{{
+		Key:            "ref",
+		Value:          "$.ref",
+		ExpressionType: v1alpha3.GenericExpressionTypeJSONPath,
+	}, webhook.PostContentVariables[1]}, parsed.PostContentVariables)
+}
+
 func TestParseGenericWebhookXML(t *testing.T) {
 	type args struct {
 		ele *etree.Element
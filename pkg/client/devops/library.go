@@ -0,0 +1,35 @@
+/*
+Copyright 2022 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package devops
+
+// SharedLibrary is a Pipeline shared library which is loaded from a git repository
+type SharedLibrary struct {
+	Name           string
+	Remote         string
+	CredentialID   string
+	DefaultVersion string
+	Implicit       bool
+}
+
+// SharedLibraryOperator provides APIs for operating the shared libraries of the DevOps projects
+type SharedLibraryOperator interface {
+	// ReplaceOrAddProjectSharedLibrary replaces the shared library with the same name, or adds it if it does not exist
+	ReplaceOrAddProjectSharedLibrary(projectID string, library *SharedLibrary) error
+
+	// RemoveProjectSharedLibrary removes the shared library, it does nothing if the library does not exist
+	RemoveProjectSharedLibrary(projectID, name string) error
+}
@@ -23,4 +23,11 @@ type ProjectPipelineOperator interface {
 	DeleteProjectPipeline(projectId string, pipelineId string) (string, error)
 	UpdateProjectPipeline(projectId string, pipeline *v1alpha3.Pipeline) (string, error)
 	GetProjectPipelineConfig(projectId, pipelineId string) (*v1alpha3.Pipeline, error)
+	// PreviewProjectPipelineConfig returns the job config.xml which would be pushed to Jenkins, nothing is changed
+	PreviewProjectPipelineConfig(projectId string, pipeline *v1alpha3.Pipeline) (string, error)
+	// GetProjectPipelineDesiredConfig returns the Pipeline parsed from the job config.xml which would be pushed to Jenkins,
+	// it has the fields written to Jenkins only, nothing is changed
+	GetProjectPipelineDesiredConfig(projectId string, pipeline *v1alpha3.Pipeline) (*v1alpha3.Pipeline, error)
+	// GetProjectPipelineConfigXML returns the current job config.xml in Jenkins
+	GetProjectPipelineConfigXML(projectId, pipelineId string) (string, error)
 }
@@ -0,0 +1,191 @@
+/*
+Copyright 2022 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package azure is a minimal go-scm driver of Azure DevOps (Azure Repos). It supports the APIs which are used by
+// KubeSphere DevOps: the credential verification, the listing of projects and repositories, the commit statuses and
+// the payloads of the service hooks, the other APIs return scm.ErrNotSupported.
+//
+// The server address is the organization of Azure DevOps, such as https://dev.azure.com/organization, or the
+// collection of Azure DevOps Server. The projects are taken as the organizations of go-scm, so the repository
+// name is like: project/repo.
+package azure
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/jenkins-x/go-scm/scm"
+	"github.com/jenkins-x/go-scm/scm/transport"
+)
+
+// apiVersion is the version of the REST API, it is supported by both Azure DevOps Services and Azure DevOps Server 2020
+const apiVersion = "6.0"
+
+// New returns a new Azure DevOps API client, the uri is the address of the organization or the collection
+func New(uri string) (*scm.Client, error) {
+	if uri == "" {
+		return nil, errors.New("the organization address of Azure DevOps is required")
+	}
+	base, err := url.Parse(uri)
+	if err != nil {
+		return nil, err
+	}
+	if !strings.HasSuffix(base.Path, "/") {
+		base.Path += "/"
+	}
+	client := &wrapper{new(scm.Client)}
+	client.BaseURL = base
+	client.Contents = &contentService{client}
+	client.Git = &gitService{client}
+	client.Organizations = &organizationService{client}
+	client.PullRequests = &pullService{client}
+	client.Repositories = &repositoryService{client}
+	client.Users = &userService{client}
+	client.Webhooks = &webhookService{}
+	return client.Client, nil
+}
+
+// NewWithToken returns a new Azure DevOps API client which authenticates with a personal access token
+func NewWithToken(uri, token string) (client *scm.Client, err error) {
+	if client, err = New(uri); err == nil && token != "" {
+		// the personal access token is sent as the password of the basic auth with an empty username
+		client.Client = &http.Client{
+			Transport: &transport.BasicAuth{Password: token},
+		}
+	}
+	return
+}
+
+// NewWebHookService returns a new WebhookService which parses the payloads of the service hooks
+func NewWebHookService() scm.WebhookService {
+	return &webhookService{}
+}
+
+// wrapper wraps the Client to provide high level helper functions for making http requests and unmarshalling
+// the response.
+type wrapper struct {
+	*scm.Client
+}
+
+// do sends the request with the API version, then unmarshalls the response into out
+func (c *wrapper) do(ctx context.Context, method, path string, in, out interface{}) (res *scm.Response, err error) {
+	req := &scm.Request{
+		Method: method,
+		Path:   withAPIVersion(path),
+		Header: map[string][]string{"Accept": {"application/json"}},
+	}
+	if in != nil {
+		buf := new(bytes.Buffer)
+		if err = json.NewEncoder(buf).Encode(in); err != nil {
+			return
+		}
+		req.Header["Content-Type"] = []string{"application/json"}
+		req.Body = buf
+	}
+
+	if res, err = c.Client.Do(ctx, req); err != nil {
+		return
+	}
+	defer func() {
+		_ = res.Body.Close()
+	}()
+
+	switch {
+	case res.Status == http.StatusNotFound:
+		err = scm.ErrNotFound
+	case res.Status == http.StatusUnauthorized || res.Status == http.StatusNonAuthoritativeInfo:
+		// an invalid token is redirected to the sign-in page with status 203
+		err = scm.ErrNotAuthorized
+	case res.Status == http.StatusForbidden:
+		err = scm.ErrForbidden
+	case res.Status >= http.StatusMultipleChoices:
+		apiErr := &Error{}
+		if json.NewDecoder(res.Body).Decode(apiErr) != nil || apiErr.Message == "" {
+			apiErr.Message = http.StatusText(res.Status)
+		}
+		err = apiErr
+	case out != nil:
+		err = json.NewDecoder(res.Body).Decode(out)
+	}
+	return
+}
+
+func withAPIVersion(path string) string {
+	path = strings.TrimSuffix(path, "?")
+	if strings.Contains(path, "?") {
+		return path + "&api-version=" + apiVersion
+	}
+	return path + "?api-version=" + apiVersion
+}
+
+// Error represents an error of the Azure DevOps REST API
+type Error struct {
+	Message string `json:"message"`
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+// splitRepo splits the repository name like project/repo
+func splitRepo(repo string) (project, name string, err error) {
+	items := strings.SplitN(strings.Trim(repo, "/"), "/", 2)
+	if len(items) != 2 || items[0] == "" || items[1] == "" {
+		err = fmt.Errorf("invalid repository %q of Azure DevOps, it should be like: project/repo", repo)
+		return
+	}
+	project, name = items[0], items[1]
+	return
+}
+
+// repoPath returns the API path of a repository
+func repoPath(repo string) (path string, err error) {
+	var project, name string
+	if project, name, err = splitRepo(repo); err == nil {
+		path = fmt.Sprintf("%s/_apis/git/repositories/%s", url.PathEscape(project), url.PathEscape(name))
+	}
+	return
+}
+
+// encodeListOptions returns the paging parameters of the list APIs
+func encodeListOptions(opts *scm.ListOptions) string {
+	params := url.Values{}
+	if opts != nil && opts.Size > 0 {
+		params.Set("$top", fmt.Sprint(opts.Size))
+		if opts.Page > 1 {
+			params.Set("$skip", fmt.Sprint((opts.Page-1)*opts.Size))
+		}
+	}
+	return params.Encode()
+}
+
+// setNextPage sets the next page if the current page is full, there is no link header in Azure DevOps
+func setNextPage(res *scm.Response, opts *scm.ListOptions, count int) {
+	if res == nil || opts == nil || opts.Size <= 0 || count < opts.Size {
+		return
+	}
+	page := opts.Page
+	if page < 1 {
+		page = 1
+	}
+	res.Page.Next = page + 1
+}
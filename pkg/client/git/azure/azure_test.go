@@ -0,0 +1,228 @@
+/*
+Copyright 2022 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package azure
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/jenkins-x/go-scm/scm"
+	"github.com/stretchr/testify/assert"
+)
+
+func newFakeServer(t *testing.T, handler http.HandlerFunc) *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, password, ok := r.BasicAuth(); !ok || password != "token" {
+			// Azure DevOps redirects the anonymous requests to the sign-in page
+			w.WriteHeader(http.StatusNonAuthoritativeInfo)
+			return
+		}
+		if r.URL.Query().Get("api-version") != apiVersion {
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`{"message": "api-version is required"}`))
+			return
+		}
+		handler(w, r)
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestNew(t *testing.T) {
+	_, err := New("")
+	assert.NotNil(t, err)
+
+	client, err := New("https://dev.azure.com/linuxsuren")
+	assert.Nil(t, err)
+	assert.Equal(t, "https://dev.azure.com/linuxsuren/", client.BaseURL.String())
+}
+
+func Test_splitRepo(t *testing.T) {
+	project, name, err := splitRepo("demo/test")
+	assert.Nil(t, err)
+	assert.Equal(t, "demo", project)
+	assert.Equal(t, "test", name)
+
+	_, _, err = splitRepo("test")
+	assert.NotNil(t, err)
+}
+
+func TestUsers(t *testing.T) {
+	server := newFakeServer(t, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/linuxsuren/_apis/connectionData", r.URL.Path)
+		_, _ = w.Write([]byte(`{"authenticatedUser": {"providerDisplayName": "LinuxSuRen",
+"properties": {"Account": {"$value": "linuxsuren@example.com"}}}}`))
+	})
+
+	client, err := NewWithToken(server.URL+"/linuxsuren", "token")
+	assert.Nil(t, err)
+	user, _, err := client.Users.Find(context.TODO())
+	assert.Nil(t, err)
+	assert.Equal(t, "linuxsuren@example.com", user.Login)
+	assert.Equal(t, "LinuxSuRen", user.Name)
+
+	client, err = NewWithToken(server.URL+"/linuxsuren", "fake")
+	assert.Nil(t, err)
+	_, _, err = client.Users.Find(context.TODO())
+	assert.Equal(t, scm.ErrNotAuthorized, err)
+}
+
+func TestOrganizationsAndRepositories(t *testing.T) {
+	server := newFakeServer(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/linuxsuren/_apis/projects":
+			assert.Equal(t, "1", r.URL.Query().Get("$top"))
+			_, _ = w.Write([]byte(`{"value": [{"id": "1", "name": "demo"}]}`))
+		case "/linuxsuren/demo/_apis/git/repositories":
+			_, _ = w.Write([]byte(`{"value": [{"id": "2", "name": "test", "project": {"name": "demo"},
+"defaultBranch": "refs/heads/master", "remoteUrl": "https://linuxsuren@dev.azure.com/linuxsuren/demo/_git/test",
+"webUrl": "https://dev.azure.com/linuxsuren/demo/_git/test"}]}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	})
+
+	client, err := NewWithToken(server.URL+"/linuxsuren", "token")
+	assert.Nil(t, err)
+	ctx := context.TODO()
+
+	orgs, res, err := client.Organizations.List(ctx, &scm.ListOptions{Page: 1, Size: 1})
+	assert.Nil(t, err)
+	if assert.Equal(t, 1, len(orgs)) {
+		assert.Equal(t, "demo", orgs[0].Name)
+	}
+	// the page is full, so there might be a next page
+	assert.Equal(t, 2, res.Page.Next)
+
+	repos, _, err := client.Repositories.ListOrganisation(ctx, "demo", nil)
+	assert.Nil(t, err)
+	if assert.Equal(t, 1, len(repos)) {
+		assert.Equal(t, "demo/test", repos[0].FullName)
+		assert.Equal(t, "master", repos[0].Branch)
+		assert.Equal(t, "https://dev.azure.com/linuxsuren/demo/_git/test", repos[0].Link)
+	}
+
+	_, _, err = client.Repositories.Find(ctx, "demo/fake")
+	assert.Equal(t, scm.ErrNotFound, err)
+}
+
+func TestStatuses(t *testing.T) {
+	server := newFakeServer(t, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/linuxsuren/demo/_apis/git/repositories/test/commits/abc/statuses", r.URL.Path)
+		switch r.Method {
+		case http.MethodPost:
+			in := &status{}
+			assert.Nil(t, json.NewDecoder(r.Body).Decode(in))
+			assert.Equal(t, "succeeded", in.State)
+			assert.Equal(t, "kubesphere", in.Context.Name)
+			_ = json.NewEncoder(w).Encode(in)
+		default:
+			_, _ = w.Write([]byte(`{"value": [{"state": "failed", "context": {"name": "build", "genre": "ci"}}]}`))
+		}
+	})
+
+	client, err := NewWithToken(server.URL+"/linuxsuren", "token")
+	assert.Nil(t, err)
+	ctx := context.TODO()
+
+	created, _, err := client.Repositories.CreateStatus(ctx, "demo/test", "abc", &scm.StatusInput{
+		State: scm.StateSuccess,
+		Label: "kubesphere",
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, scm.StateSuccess, created.State)
+
+	statuses, _, err := client.Repositories.ListStatus(ctx, "demo/test", "abc", nil)
+	assert.Nil(t, err)
+	if assert.Equal(t, 1, len(statuses)) {
+		assert.Equal(t, scm.StateFailure, statuses[0].State)
+		assert.Equal(t, "ci/build", statuses[0].Label)
+	}
+
+	_, _, err = client.Repositories.CreateStatus(ctx, "test", "abc", &scm.StatusInput{})
+	assert.NotNil(t, err)
+}
+
+func TestWebhookParse(t *testing.T) {
+	secretFunc := func(scm.Webhook) (string, error) {
+		return "secret", nil
+	}
+
+	tests := []struct {
+		name      string
+		body      string
+		password  string
+		wantErr   error
+		assertion func(t *testing.T, hook scm.Webhook)
+	}{{
+		name: "push",
+		body: `{"id": "1", "eventType": "git.push", "resource": {
+"refUpdates": [{"name": "refs/heads/master", "oldObjectId": "0000000000000000000000000000000000000000", "newObjectId": "abc"}],
+"commits": [{"commitId": "abc", "comment": "init"}],
+"repository": {"name": "test", "project": {"name": "demo"}, "webUrl": "https://dev.azure.com/linuxsuren/demo/_git/test"}}}`,
+		password: "secret",
+		assertion: func(t *testing.T, hook scm.Webhook) {
+			pushHook, ok := hook.(*scm.PushHook)
+			if assert.True(t, ok) {
+				assert.Equal(t, "refs/heads/master", pushHook.Ref)
+				assert.Equal(t, "abc", pushHook.After)
+				assert.True(t, pushHook.Created)
+				assert.Equal(t, "init", pushHook.Commit.Message)
+				assert.Equal(t, "demo/test", pushHook.Repo.FullName)
+			}
+		},
+	}, {
+		name: "pull request merged",
+		body: `{"id": "2", "eventType": "git.pullrequest.merged", "resource": {"pullRequestId": 3, "status": "completed",
+"sourceRefName": "refs/heads/feature", "targetRefName": "refs/heads/master",
+"repository": {"name": "test", "project": {"name": "demo"}}}}`,
+		password: "secret",
+		assertion: func(t *testing.T, hook scm.Webhook) {
+			prHook, ok := hook.(*scm.PullRequestHook)
+			if assert.True(t, ok) {
+				assert.Equal(t, scm.ActionMerge, prHook.Action)
+				assert.Equal(t, 3, prHook.PullRequest.Number)
+			}
+		},
+	}, {
+		name:     "invalid password",
+		body:     `{"eventType": "git.push", "resource": {}}`,
+		password: "fake",
+		wantErr:  scm.ErrSignatureInvalid,
+	}, {
+		name:    "unknown event",
+		body:    `{"eventType": "build.complete", "resource": {}}`,
+		wantErr: scm.UnknownWebhook{Event: "build.complete"},
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/webhooks", strings.NewReader(tt.body))
+			if tt.password != "" {
+				req.SetBasicAuth("", tt.password)
+			}
+			hook, err := NewWebHookService().Parse(req, secretFunc)
+			assert.Equal(t, tt.wantErr, err)
+			if tt.assertion != nil {
+				tt.assertion(t, hook)
+			}
+		})
+	}
+}
@@ -0,0 +1,118 @@
+/*
+Copyright 2022 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package azure
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/jenkins-x/go-scm/scm"
+)
+
+type contentService struct {
+	client *wrapper
+}
+
+type item struct {
+	ObjectID      string `json:"objectId"`
+	GitObjectType string `json:"gitObjectType"`
+	Path          string `json:"path"`
+	IsFolder      bool   `json:"isFolder"`
+	Content       string `json:"content"`
+	URL           string `json:"url"`
+}
+
+type itemList struct {
+	Value []*item `json:"value"`
+}
+
+// Find returns the content of a file
+func (s *contentService) Find(ctx context.Context, repo, path, ref string) (*scm.Content, *scm.Response, error) {
+	params := versionParams(ref)
+	params.Set("path", path)
+	params.Set("includeContent", "true")
+	out := &item{}
+	res, err := s.items(ctx, repo, params, out)
+	if err != nil {
+		return nil, res, err
+	}
+	return &scm.Content{
+		Path: strings.TrimPrefix(out.Path, "/"),
+		Data: []byte(out.Content),
+		Sha:  out.ObjectID,
+	}, res, nil
+}
+
+// List returns the files and directories of a directory
+func (s *contentService) List(ctx context.Context, repo, path, ref string) ([]*scm.FileEntry, *scm.Response, error) {
+	params := versionParams(ref)
+	params.Set("scopePath", path)
+	params.Set("recursionLevel", "OneLevel")
+	out := &itemList{}
+	res, err := s.items(ctx, repo, params, out)
+	if err != nil {
+		return nil, res, err
+	}
+	scopePath := "/" + strings.Trim(path, "/")
+	entries := make([]*scm.FileEntry, 0, len(out.Value))
+	for _, item := range out.Value {
+		// the directory itself is in the list as well
+		if item.Path == scopePath {
+			continue
+		}
+		entryType := "file"
+		if item.IsFolder {
+			entryType = "dir"
+		}
+		entries = append(entries, &scm.FileEntry{
+			Name: item.Path[strings.LastIndex(item.Path, "/")+1:],
+			Path: strings.TrimPrefix(item.Path, "/"),
+			Type: entryType,
+			Sha:  item.ObjectID,
+			Link: item.URL,
+		})
+	}
+	return entries, res, nil
+}
+
+func (s *contentService) items(ctx context.Context, repo string, params url.Values, out interface{}) (*scm.Response, error) {
+	path, err := repoPath(repo)
+	if err != nil {
+		return nil, err
+	}
+	params.Set("$format", "json")
+	return s.client.do(ctx, http.MethodGet, fmt.Sprintf("%s/items?%s", path, params.Encode()), nil, out)
+}
+
+// versionParams returns the parameters of a version, it could be a branch, tag or commit
+func versionParams(ref string) url.Values {
+	params := url.Values{}
+	if ref == "" {
+		return params
+	}
+	params.Set("versionDescriptor.version", scm.TrimRef(ref))
+	switch {
+	case shaPattern.MatchString(ref):
+		params.Set("versionDescriptor.versionType", "commit")
+	case strings.HasPrefix(ref, "refs/tags/"):
+		params.Set("versionDescriptor.versionType", "tag")
+	}
+	return params
+}
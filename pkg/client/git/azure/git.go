@@ -0,0 +1,192 @@
+/*
+Copyright 2022 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package azure
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"time"
+
+	"github.com/jenkins-x/go-scm/scm"
+)
+
+type gitService struct {
+	client *wrapper
+}
+
+type ref struct {
+	Name     string `json:"name"`
+	ObjectID string `json:"objectId"`
+}
+
+type refList struct {
+	Value []*ref `json:"value"`
+}
+
+type commit struct {
+	CommitID  string    `json:"commitId"`
+	Comment   string    `json:"comment"`
+	Author    signature `json:"author"`
+	Committer signature `json:"committer"`
+	RemoteURL string    `json:"remoteUrl"`
+}
+
+type signature struct {
+	Name  string    `json:"name"`
+	Email string    `json:"email"`
+	Date  time.Time `json:"date"`
+}
+
+type commitList struct {
+	Value []*commit `json:"value"`
+}
+
+var shaPattern = regexp.MustCompile(`^[0-9a-fA-F]{40}$`)
+
+// FindBranch finds a branch by name
+func (s *gitService) FindBranch(ctx context.Context, repo, name string) (*scm.Reference, *scm.Response, error) {
+	return s.findRef(ctx, repo, "heads/"+name)
+}
+
+// FindCommit finds a commit by its SHA, or the name of a branch or tag
+func (s *gitService) FindCommit(ctx context.Context, repo, ref string) (*scm.Commit, *scm.Response, error) {
+	path, err := repoPath(repo)
+	if err != nil {
+		return nil, nil, err
+	}
+	if shaPattern.MatchString(ref) {
+		out := &commit{}
+		res, err := s.client.do(ctx, http.MethodGet, fmt.Sprintf("%s/commits/%s", path, ref), nil, out)
+		if err != nil {
+			return nil, res, err
+		}
+		return convertCommit(out), res, nil
+	}
+
+	var res *scm.Response
+	for _, versionType := range []string{"branch", "tag"} {
+		out := &commitList{}
+		params := url.Values{}
+		params.Set("searchCriteria.itemVersion.version", ref)
+		params.Set("searchCriteria.itemVersion.versionType", versionType)
+		params.Set("searchCriteria.$top", "1")
+		if res, err = s.client.do(ctx, http.MethodGet, fmt.Sprintf("%s/commits?%s", path, params.Encode()), nil, out); err != nil &&
+			!errors.Is(err, scm.ErrNotFound) {
+			return nil, res, err
+		}
+		if err == nil && len(out.Value) > 0 {
+			return convertCommit(out.Value[0]), res, nil
+		}
+	}
+	return nil, res, scm.ErrNotFound
+}
+
+// ListBranches returns the branches, Azure DevOps returns all of them in one page
+func (s *gitService) ListBranches(ctx context.Context, repo string, _ *scm.ListOptions) ([]*scm.Reference, *scm.Response, error) {
+	return s.listRefs(ctx, repo, "heads/")
+}
+
+// ListTags returns the tags, Azure DevOps returns all of them in one page
+func (s *gitService) ListTags(ctx context.Context, repo string, _ *scm.ListOptions) ([]*scm.Reference, *scm.Response, error) {
+	return s.listRefs(ctx, repo, "tags/")
+}
+
+// ListCommits returns the commits of a branch
+func (s *gitService) ListCommits(ctx context.Context, repo string, opts scm.CommitListOptions) ([]*scm.Commit, *scm.Response, error) {
+	path, err := repoPath(repo)
+	if err != nil {
+		return nil, nil, err
+	}
+	params := url.Values{}
+	if opts.Ref != "" {
+		params.Set("searchCriteria.itemVersion.version", opts.Ref)
+	}
+	if opts.Size > 0 {
+		params.Set("searchCriteria.$top", fmt.Sprint(opts.Size))
+		if opts.Page > 1 {
+			params.Set("searchCriteria.$skip", fmt.Sprint((opts.Page-1)*opts.Size))
+		}
+	}
+	out := &commitList{}
+	res, err := s.client.do(ctx, http.MethodGet, fmt.Sprintf("%s/commits?%s", path, params.Encode()), nil, out)
+	if err != nil {
+		return nil, res, err
+	}
+	commits := make([]*scm.Commit, 0, len(out.Value))
+	for _, item := range out.Value {
+		commits = append(commits, convertCommit(item))
+	}
+	setNextPage(res, &scm.ListOptions{Page: opts.Page, Size: opts.Size}, len(out.Value))
+	return commits, res, nil
+}
+
+// findRef finds a reference by the exact name, the filter of Azure DevOps matches the prefix of the names
+func (s *gitService) findRef(ctx context.Context, repo, name string) (*scm.Reference, *scm.Response, error) {
+	refs, res, err := s.listRefs(ctx, repo, name)
+	if err != nil {
+		return nil, res, err
+	}
+	for _, item := range refs {
+		if "refs/"+name == item.Path {
+			return item, res, nil
+		}
+	}
+	return nil, res, scm.ErrNotFound
+}
+
+func (s *gitService) listRefs(ctx context.Context, repo, filter string) ([]*scm.Reference, *scm.Response, error) {
+	path, err := repoPath(repo)
+	if err != nil {
+		return nil, nil, err
+	}
+	out := &refList{}
+	res, err := s.client.do(ctx, http.MethodGet, fmt.Sprintf("%s/refs?filter=%s", path, url.QueryEscape(filter)), nil, out)
+	if err != nil {
+		return nil, res, err
+	}
+	refs := make([]*scm.Reference, 0, len(out.Value))
+	for _, item := range out.Value {
+		refs = append(refs, &scm.Reference{
+			Name: scm.TrimRef(item.Name),
+			Path: item.Name,
+			Sha:  item.ObjectID,
+		})
+	}
+	return refs, res, nil
+}
+
+func convertCommit(from *commit) *scm.Commit {
+	return &scm.Commit{
+		Sha:     from.CommitID,
+		Message: from.Comment,
+		Author: scm.Signature{
+			Name:  from.Author.Name,
+			Email: from.Author.Email,
+			Date:  from.Author.Date,
+		},
+		Committer: scm.Signature{
+			Name:  from.Committer.Name,
+			Email: from.Committer.Email,
+			Date:  from.Committer.Date,
+		},
+		Link: from.RemoteURL,
+	}
+}
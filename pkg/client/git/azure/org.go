@@ -0,0 +1,69 @@
+/*
+Copyright 2022 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package azure
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/jenkins-x/go-scm/scm"
+)
+
+// organizationService takes the projects of Azure DevOps as the organizations
+type organizationService struct {
+	client *wrapper
+}
+
+type project struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+type projectList struct {
+	Value []*project `json:"value"`
+}
+
+// Find returns a project by name
+func (s *organizationService) Find(ctx context.Context, name string) (*scm.Organization, *scm.Response, error) {
+	out := &project{}
+	res, err := s.client.do(ctx, http.MethodGet, fmt.Sprintf("_apis/projects/%s", url.PathEscape(name)), nil, out)
+	if err != nil {
+		return nil, res, err
+	}
+	return convertProject(out), res, nil
+}
+
+// List returns the projects of the organization
+func (s *organizationService) List(ctx context.Context, opts *scm.ListOptions) ([]*scm.Organization, *scm.Response, error) {
+	out := &projectList{}
+	res, err := s.client.do(ctx, http.MethodGet, "_apis/projects?"+encodeListOptions(opts), nil, out)
+	if err != nil {
+		return nil, res, err
+	}
+	orgs := make([]*scm.Organization, 0, len(out.Value))
+	for _, item := range out.Value {
+		orgs = append(orgs, convertProject(item))
+	}
+	setNextPage(res, opts, len(out.Value))
+	return orgs, res, nil
+}
+
+func convertProject(from *project) *scm.Organization {
+	return &scm.Organization{Name: from.Name}
+}
@@ -0,0 +1,105 @@
+/*
+Copyright 2022 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package azure
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/jenkins-x/go-scm/scm"
+)
+
+type pullService struct {
+	client *wrapper
+}
+
+type pullRequest struct {
+	PullRequestID         int         `json:"pullRequestId"`
+	Status                string      `json:"status"`
+	Title                 string      `json:"title"`
+	Description           string      `json:"description"`
+	SourceRefName         string      `json:"sourceRefName"`
+	TargetRefName         string      `json:"targetRefName"`
+	IsDraft               bool        `json:"isDraft"`
+	CreatedBy             identityRef `json:"createdBy"`
+	CreationDate          time.Time   `json:"creationDate"`
+	Repository            repository  `json:"repository"`
+	LastMergeSourceCommit commit      `json:"lastMergeSourceCommit"`
+	LastMergeTargetCommit commit      `json:"lastMergeTargetCommit"`
+	LastMergeCommit       commit      `json:"lastMergeCommit"`
+}
+
+type identityRef struct {
+	ID          string `json:"id"`
+	DisplayName string `json:"displayName"`
+	UniqueName  string `json:"uniqueName"`
+	ImageURL    string `json:"imageUrl"`
+}
+
+// Find returns a pull request by its ID
+func (s *pullService) Find(ctx context.Context, repo string, number int) (*scm.PullRequest, *scm.Response, error) {
+	path, err := repoPath(repo)
+	if err != nil {
+		return nil, nil, err
+	}
+	out := &pullRequest{}
+	res, err := s.client.do(ctx, http.MethodGet, fmt.Sprintf("%s/pullrequests/%d", path, number), nil, out)
+	if err != nil {
+		return nil, res, err
+	}
+	return convertPullRequest(out), res, nil
+}
+
+func convertPullRequest(from *pullRequest) *scm.PullRequest {
+	source := scm.TrimRef(from.SourceRefName)
+	target := scm.TrimRef(from.TargetRefName)
+	repo := convertRepository(&from.Repository)
+	link := ""
+	if repo.Link != "" {
+		link = fmt.Sprintf("%s/pullrequest/%d", strings.TrimSuffix(repo.Link, "/"), from.PullRequestID)
+	}
+	return &scm.PullRequest{
+		Number:   from.PullRequestID,
+		Title:    from.Title,
+		Body:     from.Description,
+		Sha:      from.LastMergeSourceCommit.CommitID,
+		Ref:      fmt.Sprintf("refs/pull/%d/merge", from.PullRequestID),
+		Source:   source,
+		Target:   target,
+		Base:     scm.PullRequestBranch{Ref: target, Sha: from.LastMergeTargetCommit.CommitID, Repo: *repo},
+		Head:     scm.PullRequestBranch{Ref: source, Sha: from.LastMergeSourceCommit.CommitID, Repo: *repo},
+		State:    from.Status,
+		Closed:   from.Status != "active",
+		Merged:   from.Status == "completed",
+		Draft:    from.IsDraft,
+		MergeSha: from.LastMergeCommit.CommitID,
+		Author:   convertIdentity(&from.CreatedBy),
+		Created:  from.CreationDate,
+		Link:     link,
+	}
+}
+
+func convertIdentity(from *identityRef) scm.User {
+	return scm.User{
+		Login:  from.UniqueName,
+		Name:   from.DisplayName,
+		Avatar: from.ImageURL,
+	}
+}
@@ -0,0 +1,197 @@
+/*
+Copyright 2022 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package azure
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/jenkins-x/go-scm/scm"
+)
+
+type repositoryService struct {
+	client *wrapper
+}
+
+type repository struct {
+	ID            string  `json:"id"`
+	Name          string  `json:"name"`
+	Project       project `json:"project"`
+	DefaultBranch string  `json:"defaultBranch"`
+	RemoteURL     string  `json:"remoteUrl"`
+	SSHURL        string  `json:"sshUrl"`
+	WebURL        string  `json:"webUrl"`
+	IsDisabled    bool    `json:"isDisabled"`
+}
+
+type repositoryList struct {
+	Value []*repository `json:"value"`
+}
+
+type status struct {
+	State       string        `json:"state"`
+	Description string        `json:"description,omitempty"`
+	TargetURL   string        `json:"targetUrl,omitempty"`
+	Context     statusContext `json:"context"`
+	CreatedDate time.Time     `json:"creationDate,omitempty"`
+}
+
+type statusContext struct {
+	Name  string `json:"name"`
+	Genre string `json:"genre,omitempty"`
+}
+
+type statusList struct {
+	Value []*status `json:"value"`
+}
+
+// Find returns a repository by name, such as: project/repo
+func (s *repositoryService) Find(ctx context.Context, repo string) (*scm.Repository, *scm.Response, error) {
+	path, err := repoPath(repo)
+	if err != nil {
+		return nil, nil, err
+	}
+	out := &repository{}
+	res, err := s.client.do(ctx, http.MethodGet, path, nil, out)
+	if err != nil {
+		return nil, res, err
+	}
+	return convertRepository(out), res, nil
+}
+
+// List returns the repositories of all the projects, Azure DevOps returns all of them in one page
+func (s *repositoryService) List(ctx context.Context, _ *scm.ListOptions) ([]*scm.Repository, *scm.Response, error) {
+	return s.list(ctx, "_apis/git/repositories")
+}
+
+// ListOrganisation returns the repositories of a project, Azure DevOps returns all of them in one page
+func (s *repositoryService) ListOrganisation(ctx context.Context, org string, _ *scm.ListOptions) ([]*scm.Repository, *scm.Response, error) {
+	return s.list(ctx, fmt.Sprintf("%s/_apis/git/repositories", url.PathEscape(org)))
+}
+
+func (s *repositoryService) list(ctx context.Context, path string) ([]*scm.Repository, *scm.Response, error) {
+	out := &repositoryList{}
+	res, err := s.client.do(ctx, http.MethodGet, path, nil, out)
+	if err != nil {
+		return nil, res, err
+	}
+	repos := make([]*scm.Repository, 0, len(out.Value))
+	for _, item := range out.Value {
+		repos = append(repos, convertRepository(item))
+	}
+	return repos, res, nil
+}
+
+// ListStatus returns the statuses of a commit
+func (s *repositoryService) ListStatus(ctx context.Context, repo, ref string, _ *scm.ListOptions) ([]*scm.Status, *scm.Response, error) {
+	path, err := repoPath(repo)
+	if err != nil {
+		return nil, nil, err
+	}
+	out := &statusList{}
+	res, err := s.client.do(ctx, http.MethodGet, fmt.Sprintf("%s/commits/%s/statuses", path, url.PathEscape(ref)), nil, out)
+	if err != nil {
+		return nil, res, err
+	}
+	statuses := make([]*scm.Status, 0, len(out.Value))
+	for _, item := range out.Value {
+		statuses = append(statuses, convertStatus(item))
+	}
+	return statuses, res, nil
+}
+
+// CreateStatus creates a status of a commit, the label is taken as the name of the status context
+func (s *repositoryService) CreateStatus(ctx context.Context, repo, ref string, input *scm.StatusInput) (*scm.Status, *scm.Response, error) {
+	path, err := repoPath(repo)
+	if err != nil {
+		return nil, nil, err
+	}
+	in := &status{
+		State:       convertFromState(input.State),
+		Description: input.Desc,
+		TargetURL:   input.Target,
+		Context:     statusContext{Name: input.Label},
+	}
+	out := &status{}
+	res, err := s.client.do(ctx, http.MethodPost, fmt.Sprintf("%s/commits/%s/statuses", path, url.PathEscape(ref)), in, out)
+	if err != nil {
+		return nil, res, err
+	}
+	return convertStatus(out), res, nil
+}
+
+func convertRepository(from *repository) *scm.Repository {
+	return &scm.Repository{
+		ID:        from.ID,
+		Namespace: from.Project.Name,
+		Name:      from.Name,
+		FullName:  from.Project.Name + "/" + from.Name,
+		Branch:    strings.TrimPrefix(from.DefaultBranch, "refs/heads/"),
+		Archived:  from.IsDisabled,
+		Clone:     from.RemoteURL,
+		CloneSSH:  from.SSHURL,
+		Link:      from.WebURL,
+	}
+}
+
+func convertStatus(from *status) *scm.Status {
+	label := from.Context.Name
+	if from.Context.Genre != "" {
+		label = from.Context.Genre + "/" + label
+	}
+	return &scm.Status{
+		State:  convertToState(from.State),
+		Label:  label,
+		Desc:   from.Description,
+		Target: from.TargetURL,
+	}
+}
+
+// convertFromState converts the state to the one of Azure DevOps: notSet, pending, succeeded, failed, error, notApplicable
+func convertFromState(state scm.State) string {
+	switch state {
+	case scm.StatePending, scm.StateRunning:
+		return "pending"
+	case scm.StateSuccess:
+		return "succeeded"
+	case scm.StateFailure, scm.StateCanceled:
+		return "failed"
+	case scm.StateError:
+		return "error"
+	default:
+		return "notSet"
+	}
+}
+
+func convertToState(state string) scm.State {
+	switch state {
+	case "pending":
+		return scm.StatePending
+	case "succeeded":
+		return scm.StateSuccess
+	case "failed":
+		return scm.StateFailure
+	case "error":
+		return scm.StateError
+	default:
+		return scm.StateUnknown
+	}
+}
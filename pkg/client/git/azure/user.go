@@ -0,0 +1,58 @@
+/*
+Copyright 2022 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package azure
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/jenkins-x/go-scm/scm"
+)
+
+type userService struct {
+	client *wrapper
+}
+
+type connectionData struct {
+	AuthenticatedUser identity `json:"authenticatedUser"`
+}
+
+type identity struct {
+	ID                  string `json:"id"`
+	ProviderDisplayName string `json:"providerDisplayName"`
+	Properties          struct {
+		Account struct {
+			Value string `json:"$value"`
+		} `json:"Account"`
+	} `json:"properties"`
+}
+
+// Find returns the authenticated user, the anonymous user is taken as unauthorized
+func (s *userService) Find(ctx context.Context) (*scm.User, *scm.Response, error) {
+	out := &connectionData{}
+	res, err := s.client.do(ctx, http.MethodGet, "_apis/connectionData", nil, out)
+	if err == nil && out.AuthenticatedUser.Properties.Account.Value == "" {
+		err = scm.ErrNotAuthorized
+	}
+	if err != nil {
+		return nil, res, err
+	}
+	return &scm.User{
+		Login: out.AuthenticatedUser.Properties.Account.Value,
+		Name:  out.AuthenticatedUser.ProviderDisplayName,
+	}, res, nil
+}
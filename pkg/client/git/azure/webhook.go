@@ -0,0 +1,139 @@
+/*
+Copyright 2022 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package azure
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/jenkins-x/go-scm/scm"
+)
+
+// webhookService parses the payloads of the service hooks, the secret is taken as the password of the basic auth
+// which is configured in the service hook subscriptions
+type webhookService struct{}
+
+type serviceHookEvent struct {
+	ID        string          `json:"id"`
+	EventType string          `json:"eventType"`
+	Resource  json.RawMessage `json:"resource"`
+}
+
+type pushResource struct {
+	Commits    []commit    `json:"commits"`
+	RefUpdates []refUpdate `json:"refUpdates"`
+	Repository repository  `json:"repository"`
+	PushedBy   identityRef `json:"pushedBy"`
+}
+
+type refUpdate struct {
+	Name        string `json:"name"`
+	OldObjectID string `json:"oldObjectId"`
+	NewObjectID string `json:"newObjectId"`
+}
+
+// emptyObjectID is the object ID of a created or deleted reference
+const emptyObjectID = "0000000000000000000000000000000000000000"
+
+// Parse parses the push and pull request events of the service hooks
+func (s *webhookService) Parse(req *http.Request, fn scm.SecretFunc) (hook scm.Webhook, err error) {
+	var data []byte
+	if data, err = io.ReadAll(io.LimitReader(req.Body, 10000000)); err != nil {
+		return
+	}
+	event := &serviceHookEvent{}
+	if err = json.Unmarshal(data, event); err != nil {
+		return
+	}
+
+	switch event.EventType {
+	case "git.push":
+		hook, err = parsePushHook(event)
+	case "git.pullrequest.created", "git.pullrequest.updated", "git.pullrequest.merged":
+		hook, err = parsePullRequestHook(event)
+	default:
+		return nil, scm.UnknownWebhook{Event: event.EventType}
+	}
+	if err != nil || fn == nil {
+		return
+	}
+
+	var secret string
+	if secret, err = fn(hook); err != nil || secret == "" {
+		return
+	}
+	if _, password, ok := req.BasicAuth(); !ok || subtle.ConstantTimeCompare([]byte(password), []byte(secret)) != 1 {
+		return hook, scm.ErrSignatureInvalid
+	}
+	return
+}
+
+func parsePushHook(event *serviceHookEvent) (scm.Webhook, error) {
+	resource := &pushResource{}
+	if err := json.Unmarshal(event.Resource, resource); err != nil {
+		return nil, err
+	}
+	hook := &scm.PushHook{
+		Repo:   *convertRepository(&resource.Repository),
+		Sender: convertIdentity(&resource.PushedBy),
+		GUID:   event.ID,
+	}
+	if len(resource.RefUpdates) > 0 {
+		update := resource.RefUpdates[0]
+		hook.Ref = update.Name
+		hook.Before = update.OldObjectID
+		hook.After = update.NewObjectID
+		hook.Created = update.OldObjectID == emptyObjectID
+		hook.Deleted = update.NewObjectID == emptyObjectID
+	}
+	for i := range resource.Commits {
+		item := resource.Commits[i]
+		hook.Commits = append(hook.Commits, scm.PushCommit{ID: item.CommitID, Message: item.Comment})
+		if item.CommitID == hook.After {
+			hook.Commit = *convertCommit(&item)
+		}
+	}
+	return hook, nil
+}
+
+func parsePullRequestHook(event *serviceHookEvent) (scm.Webhook, error) {
+	resource := &pullRequest{}
+	if err := json.Unmarshal(event.Resource, resource); err != nil {
+		return nil, err
+	}
+	pr := convertPullRequest(resource)
+	hook := &scm.PullRequestHook{
+		Repo:        pr.Base.Repo,
+		PullRequest: *pr,
+		Sender:      pr.Author,
+		GUID:        event.ID,
+	}
+	switch {
+	case resource.Status == "completed":
+		hook.Action = scm.ActionMerge
+	case resource.Status == "abandoned":
+		hook.Action = scm.ActionClose
+	case strings.HasSuffix(event.EventType, ".created"):
+		hook.Action = scm.ActionOpen
+	default:
+		hook.Action = scm.ActionSync
+	}
+	return hook, nil
+}
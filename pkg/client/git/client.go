@@ -22,9 +22,12 @@ import (
 
 	goscm "github.com/jenkins-x/go-scm/scm"
 	"github.com/jenkins-x/go-scm/scm/factory"
+	"golang.org/x/oauth2"
 	v1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/types"
 	"kubesphere.io/devops/pkg/api/devops/v1alpha3"
+	"kubesphere.io/devops/pkg/client/git/azure"
+	"kubesphere.io/devops/pkg/client/git/gitee"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
@@ -48,6 +51,11 @@ func NewClientFactory(provider string, secretRef *v1.SecretReference, k8sClient
 
 // GetClient returns the git client with auth
 func (c *ClientFactory) GetClient() (client *goscm.Client, err error) {
+	return c.GetClientWithContext(context.TODO())
+}
+
+// GetClientWithContext returns the git client with auth, the context is used to get the token from the git provider
+func (c *ClientFactory) GetClientWithContext(ctx context.Context) (client *goscm.Client, err error) {
 	provider := c.provider
 	switch c.provider {
 	case "bitbucket_cloud":
@@ -62,31 +70,109 @@ func (c *ClientFactory) GetClient() (client *goscm.Client, err error) {
 
 	var token string
 	username := ""
+	bearer := false
 	if c.secretRef != nil {
-		if token, username, err = c.getTokenFromSecret(c.secretRef); err != nil {
+		if token, username, bearer, err = c.getTokenFromSecret(ctx, provider, c.secretRef); err != nil {
 			return
 		}
 	}
-	client, err = factory.NewClient(provider, c.Server, token, func(scmClient *goscm.Client) {
+	client, err = NewClient(provider, c.Server, token, func(scmClient *goscm.Client) {
 		scmClient.Username = username
 	})
+	// Gitee takes the OAuth tokens as the access tokens
+	if err == nil && bearer && provider != "gitee" {
+		// the OAuth tokens are sent as bearer tokens for all the providers, go-scm uses the other schemes for some of them
+		client.Client = oauth2.NewClient(context.TODO(), oauth2.StaticTokenSource(&oauth2.Token{AccessToken: token}))
+	}
+	return
+}
+
+// NewClient creates a git client of the provider, Azure DevOps and Gitee are supported besides the ones of go-scm
+func NewClient(provider, server, token string, opts ...factory.ClientOptionFunc) (client *goscm.Client, err error) {
+	switch provider {
+	case "azure_devops":
+		client, err = azure.NewWithToken(server, token)
+	case "gitee":
+		client, err = gitee.NewWithToken(server, token)
+	default:
+		return factory.NewClient(provider, server, token, opts...)
+	}
+	if err == nil {
+		for _, opt := range opts {
+			opt(client)
+		}
+	}
+	return
+}
+
+// GetSSHAuth returns the SSHAuth if the secret is a SSH auth secret, returns nil if it's not
+func (c *ClientFactory) GetSSHAuth() (auth *SSHAuth, err error) {
+	if c.secretRef == nil {
+		return
+	}
+
+	var gitSecret *v1.Secret
+	if gitSecret, err = c.getSecret(c.secretRef); err == nil && IsSSHAuthSecret(gitSecret) {
+		auth, err = NewSSHAuthFromSecret(gitSecret)
+	}
 	return
 }
 
-func (c *ClientFactory) getTokenFromSecret(secretRef *v1.SecretReference) (token, username string, err error) {
+func (c *ClientFactory) getTokenFromSecret(ctx context.Context, provider string, secretRef *v1.SecretReference) (token, username string, bearer bool, err error) {
 	var gitSecret *v1.Secret
 	if gitSecret, err = c.getSecret(secretRef); err != nil {
 		return
 	}
 
-	switch gitSecret.Type {
-	case v1.SecretTypeBasicAuth, v1alpha3.SecretTypeBasicAuth:
-		token = string(gitSecret.Data[v1.BasicAuthPasswordKey])
-		username = string(gitSecret.Data[v1.BasicAuthUsernameKey])
-	case v1.SecretTypeOpaque:
-		token = string(gitSecret.Data[v1.ServiceAccountTokenKey])
+	if gitSecret.Type == v1alpha3.SecretTypeGitHubApp {
+		token, err = c.getGitHubAppToken(ctx, provider, gitSecret)
+		return
+	}
+	if token, username, err = GetTokenFromSecret(gitSecret); err == nil {
+		bearer = gitSecret.Annotations[v1alpha3.CredentialSCMOAuthProviderAnnoKey] != ""
+	}
+	return
+}
+
+// GetTokenFromSecret returns the token and username of a secret. Both the Kubernetes secrets and all the DevOps
+// credentials which hold a token are supported, such as basic-auth and secret-text.
+func GetTokenFromSecret(secret *v1.Secret) (token, username string, err error) {
+	switch secret.Type {
+	case v1.SecretTypeBasicAuth:
+		token = string(secret.Data[v1.BasicAuthPasswordKey])
+		username = string(secret.Data[v1.BasicAuthUsernameKey])
+	case v1alpha3.SecretTypeBasicAuth:
+		token = string(secret.Data[v1alpha3.BasicAuthPasswordKey])
+		username = string(secret.Data[v1alpha3.BasicAuthUsernameKey])
+	case v1.SecretTypeOpaque, "":
+		// the type of a secret is Opaque if it is empty
+		token = string(secret.Data[v1.ServiceAccountTokenKey])
 	case v1alpha3.SecretTypeSecretText:
-		token = string(gitSecret.Data["secret"])
+		token = string(secret.Data[v1alpha3.SecretTextSecretKey])
+	case v1.SecretTypeSSHAuth, v1alpha3.SecretTypeSSHAuth:
+		err = ErrSSHAuth
+	default:
+		err = fmt.Errorf("no token found in secret %s/%s with type %s", secret.Namespace, secret.Name, secret.Type)
+	}
+	return
+}
+
+// getGitHubAppToken returns the installation token of a GitHub App
+func (c *ClientFactory) getGitHubAppToken(ctx context.Context, provider string, secret *v1.Secret) (token string, err error) {
+	if provider != "github" {
+		err = fmt.Errorf("the GitHub App secret cannot be used by git provider %q", provider)
+		return
+	}
+
+	// take the API address from an anonymous client, it works for both GitHub and GitHub Enterprise
+	var anonymous *goscm.Client
+	if anonymous, err = factory.NewClient(provider, c.Server, ""); err != nil {
+		return
+	}
+
+	var app *GitHubApp
+	if app, err = NewGitHubAppFromSecret(secret, anonymous.BaseURL.String()); err == nil {
+		token, err = app.GetToken(ctx)
 	}
 	return
 }
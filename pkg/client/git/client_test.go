@@ -17,10 +17,13 @@ limitations under the License.
 package git
 
 import (
+	"context"
 	"fmt"
+	"net/http"
 	"strings"
 	"testing"
 
+	"github.com/h2non/gock"
 	"github.com/jenkins-x/go-scm/scm"
 	"github.com/stretchr/testify/assert"
 	v1 "k8s.io/api/core/v1"
@@ -198,3 +201,117 @@ func TestGetClient(t *testing.T) {
 		})
 	}
 }
+
+func TestGetClientWithOAuthToken(t *testing.T) {
+	schema, err := v1alpha1.SchemeBuilder.Register().Build()
+	assert.Nil(t, err)
+	err = v1.SchemeBuilder.AddToScheme(schema)
+	assert.Nil(t, err)
+
+	oauthSecret := &v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "oauth",
+			Namespace: "ns",
+			Annotations: map[string]string{
+				v1alpha3.CredentialSCMOAuthProviderAnnoKey: "gitlab",
+			},
+		},
+		Type: v1alpha3.SecretTypeBasicAuth,
+		Data: map[string][]byte{
+			v1alpha3.BasicAuthUsernameKey: []byte("oauth2"),
+			v1alpha3.BasicAuthPasswordKey: []byte("token"),
+		},
+	}
+
+	defer gock.Off()
+	gock.New("https://gitlab.com").
+		Get("/api/v4/user").
+		MatchHeader("Authorization", "Bearer token").
+		Reply(http.StatusOK).
+		JSON(map[string]interface{}{"username": "linuxsuren"})
+
+	factory := NewClientFactory("gitlab", &v1.SecretReference{Namespace: "ns", Name: "oauth"},
+		fake.NewFakeClientWithScheme(schema, oauthSecret))
+	c, err := factory.GetClient()
+	assert.Nil(t, err)
+
+	user, _, err := c.Users.Find(context.TODO())
+	assert.Nil(t, err)
+	assert.Equal(t, "linuxsuren", user.Login)
+}
+
+func TestNewClient(t *testing.T) {
+	setUsername := func(client *scm.Client) {
+		client.Username = "linuxsuren"
+	}
+
+	client, err := NewClient("azure_devops", "https://dev.azure.com/linuxsuren", "token", setUsername)
+	assert.Nil(t, err)
+	assert.Equal(t, "https://dev.azure.com/linuxsuren/", client.BaseURL.String())
+	assert.Equal(t, "linuxsuren", client.Username)
+
+	// the organization is required for Azure DevOps
+	_, err = NewClient("azure_devops", "", "token")
+	assert.NotNil(t, err)
+
+	client, err = NewClient("gitee", "", "token", setUsername)
+	assert.Nil(t, err)
+	assert.Equal(t, "https://gitee.com/api/v5/", client.BaseURL.String())
+	assert.Equal(t, "linuxsuren", client.Username)
+
+	client, err = NewClient("github", "", "token")
+	assert.Nil(t, err)
+	assert.Equal(t, scm.DriverGithub, client.Driver)
+}
+
+func TestGetTokenFromSecret(t *testing.T) {
+	tests := []struct {
+		name         string
+		secret       *v1.Secret
+		wantToken    string
+		wantUsername string
+		wantErr      bool
+	}{{
+		name: "basic auth",
+		secret: &v1.Secret{Type: v1.SecretTypeBasicAuth, Data: map[string][]byte{
+			v1.BasicAuthUsernameKey: []byte("admin"), v1.BasicAuthPasswordKey: []byte("token"),
+		}},
+		wantToken:    "token",
+		wantUsername: "admin",
+	}, {
+		name: "DevOps basic auth",
+		secret: &v1.Secret{Type: v1alpha3.SecretTypeBasicAuth, Data: map[string][]byte{
+			v1alpha3.BasicAuthUsernameKey: []byte("admin"), v1alpha3.BasicAuthPasswordKey: []byte("token"),
+		}},
+		wantToken:    "token",
+		wantUsername: "admin",
+	}, {
+		name:      "opaque",
+		secret:    &v1.Secret{Type: v1.SecretTypeOpaque, Data: map[string][]byte{v1.ServiceAccountTokenKey: []byte("token")}},
+		wantToken: "token",
+	}, {
+		name:      "without type",
+		secret:    &v1.Secret{Data: map[string][]byte{v1.ServiceAccountTokenKey: []byte("token")}},
+		wantToken: "token",
+	}, {
+		name:      "DevOps secret text",
+		secret:    &v1.Secret{Type: v1alpha3.SecretTypeSecretText, Data: map[string][]byte{v1alpha3.SecretTextSecretKey: []byte("token")}},
+		wantToken: "token",
+	}, {
+		name:    "SSH auth",
+		secret:  &v1.Secret{Type: v1alpha3.SecretTypeSSHAuth},
+		wantErr: true,
+	}, {
+		name:    "kubeconfig",
+		secret:  &v1.Secret{Type: v1alpha3.SecretTypeKubeConfig},
+		wantErr: true,
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			token, username, err := GetTokenFromSecret(tt.secret)
+			assert.Equal(t, tt.wantToken, token)
+			assert.Equal(t, tt.wantUsername, username)
+			assert.Equal(t, tt.wantErr, err != nil)
+		})
+	}
+}
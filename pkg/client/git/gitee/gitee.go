@@ -0,0 +1,184 @@
+/*
+Copyright 2022 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package gitee is a go-scm driver of Gitee. The API v5 of Gitee is compatible with the one of GitHub in most cases,
+// so it is built on the GitHub driver of go-scm, and the hooks, the comments of the pull requests and the payloads
+// of the webhooks are handled in the Gitee way.
+// Gitee has no API of the commit statuses, the related APIs return scm.ErrNotSupported.
+package gitee
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/jenkins-x/go-scm/scm"
+	"github.com/jenkins-x/go-scm/scm/driver/github"
+)
+
+// DefaultServer is the address of the public Gitee service
+const DefaultServer = "https://gitee.com"
+
+// New returns a new Gitee API client, the public Gitee service is used if the uri is empty
+func New(uri string) (*scm.Client, error) {
+	client, err := github.New(getAPIURL(uri))
+	if err != nil {
+		return nil, err
+	}
+	wrapped := &wrapper{client}
+	client.PullRequests = &pullService{PullRequestService: client.PullRequests, client: wrapped}
+	client.Repositories = &repositoryService{RepositoryService: client.Repositories, client: wrapped}
+	client.Webhooks = &webhookService{}
+	return client, nil
+}
+
+// NewDefault returns a new Gitee API client of the public Gitee service
+func NewDefault() *scm.Client {
+	client, _ := New(DefaultServer)
+	return client
+}
+
+// NewWithToken returns a new Gitee API client which authenticates with a personal access token or an OAuth token
+func NewWithToken(uri, token string) (client *scm.Client, err error) {
+	if client, err = New(uri); err == nil {
+		client.Client = &http.Client{
+			Transport: &tokenTransport{token: token},
+		}
+	}
+	return
+}
+
+// NewWebHookService returns a new WebhookService which parses the payloads of the webhooks of Gitee
+func NewWebHookService() scm.WebhookService {
+	return &webhookService{}
+}
+
+// getAPIURL returns the address of the API v5 from the server address
+func getAPIURL(uri string) string {
+	uri = strings.TrimSuffix(uri, "/")
+	if uri == "" {
+		uri = DefaultServer
+	}
+	if !strings.HasSuffix(uri, "/api/v5") {
+		uri += "/api/v5"
+	}
+	return uri
+}
+
+// tokenTransport sends the token as a query parameter, and it takes the total pages in the headers as the Link
+// header which is used by go-scm for the pagination
+type tokenTransport struct {
+	token string
+	base  http.RoundTripper
+}
+
+// RoundTrip adds the access token to the request, then sets the next page to the response
+func (t *tokenTransport) RoundTrip(req *http.Request) (res *http.Response, err error) {
+	if t.token != "" {
+		req = req.Clone(req.Context())
+		query := req.URL.Query()
+		query.Set("access_token", t.token)
+		req.URL.RawQuery = query.Encode()
+	}
+
+	base := t.base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	if res, err = base.RoundTrip(req); err == nil && res.Header.Get("Link") == "" {
+		setLinkHeader(req.URL, res.Header)
+	}
+	return
+}
+
+// setLinkHeader sets the Link header of the next page from the header total_page of Gitee
+func setLinkHeader(reqURL *url.URL, header http.Header) {
+	totalPage, err := strconv.Atoi(header.Get("total_page"))
+	if err != nil {
+		return
+	}
+	page, err := strconv.Atoi(reqURL.Query().Get("page"))
+	if err != nil || page < 1 {
+		page = 1
+	}
+	if page >= totalPage {
+		return
+	}
+
+	nextURL := *reqURL
+	query := nextURL.Query()
+	query.Del("access_token")
+	query.Set("page", strconv.Itoa(page+1))
+	nextURL.RawQuery = query.Encode()
+	header.Set("Link", fmt.Sprintf(`<%s>; rel="next"`, nextURL.String()))
+}
+
+// wrapper wraps the Client to send the requests which are different from the GitHub ones
+type wrapper struct {
+	*scm.Client
+}
+
+func (c *wrapper) do(ctx context.Context, method, path string, in, out interface{}) (res *scm.Response, err error) {
+	req := &scm.Request{
+		Method: method,
+		Path:   path,
+	}
+	if in != nil {
+		buf := new(bytes.Buffer)
+		if err = json.NewEncoder(buf).Encode(in); err != nil {
+			return
+		}
+		req.Header = map[string][]string{"Content-Type": {"application/json"}}
+		req.Body = buf
+	}
+
+	if res, err = c.Client.Do(ctx, req); err != nil {
+		return
+	}
+	defer func() {
+		_ = res.Body.Close()
+	}()
+
+	switch {
+	case res.Status == http.StatusNotFound:
+		err = scm.ErrNotFound
+	case res.Status == http.StatusUnauthorized:
+		err = scm.ErrNotAuthorized
+	case res.Status >= http.StatusMultipleChoices:
+		apiErr := &Error{}
+		if json.NewDecoder(res.Body).Decode(apiErr) != nil || apiErr.Message == "" {
+			apiErr.Message = http.StatusText(res.Status)
+		}
+		err = apiErr
+	case out != nil:
+		err = json.NewDecoder(res.Body).Decode(out)
+	}
+	return
+}
+
+// Error represents an error of the Gitee API
+type Error struct {
+	Message string `json:"message"`
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
@@ -0,0 +1,253 @@
+/*
+Copyright 2022 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gitee
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/jenkins-x/go-scm/scm"
+	"github.com/stretchr/testify/assert"
+)
+
+func newFakeServer(t *testing.T, handler http.HandlerFunc) *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("access_token") != "token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		handler(w, r)
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func Test_getAPIURL(t *testing.T) {
+	assert.Equal(t, "https://gitee.com/api/v5", getAPIURL(""))
+	assert.Equal(t, "https://gitee.com/api/v5", getAPIURL("https://gitee.com/"))
+	assert.Equal(t, "https://gitee.example.com/api/v5", getAPIURL("https://gitee.example.com/api/v5"))
+}
+
+func TestRepositories(t *testing.T) {
+	server := newFakeServer(t, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/api/v5/user/repos", r.URL.Path)
+		w.Header().Set("total_page", "2")
+		_, _ = w.Write([]byte(`[{"id": 1, "name": "test", "full_name": "linuxsuren/test",
+"owner": {"login": "linuxsuren"}, "html_url": "https://gitee.com/linuxsuren/test"}]`))
+	})
+
+	client, err := NewWithToken(server.URL, "token")
+	assert.Nil(t, err)
+	repos, res, err := client.Repositories.List(context.TODO(), &scm.ListOptions{Page: 1, Size: 1})
+	assert.Nil(t, err)
+	if assert.Equal(t, 1, len(repos)) {
+		assert.Equal(t, "linuxsuren/test", repos[0].FullName)
+	}
+	// the next page comes from the header total_page
+	assert.Equal(t, 2, res.Page.Next)
+
+	// unauthorized without the token
+	client, err = New(server.URL)
+	assert.Nil(t, err)
+	_, _, err = client.Repositories.List(context.TODO(), &scm.ListOptions{Page: 1, Size: 1})
+	assert.NotNil(t, err)
+}
+
+func TestHooks(t *testing.T) {
+	server := newFakeServer(t, func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodPost && r.URL.Path == "/api/v5/repos/linuxsuren/test/hooks":
+			in := &hook{}
+			assert.Nil(t, json.NewDecoder(r.Body).Decode(in))
+			assert.Equal(t, "secret", in.Password)
+			assert.True(t, in.PushEvents)
+			assert.True(t, in.MergeRequestsEvents)
+			assert.False(t, in.TagPushEvents)
+			in.ID = 12
+			_ = json.NewEncoder(w).Encode(in)
+		case r.Method == http.MethodPatch && r.URL.Path == "/api/v5/repos/linuxsuren/test/hooks/12":
+			in := &hook{}
+			assert.Nil(t, json.NewDecoder(r.Body).Decode(in))
+			assert.True(t, in.TagPushEvents)
+			in.ID = 12
+			_ = json.NewEncoder(w).Encode(in)
+		case r.Method == http.MethodGet && r.URL.Path == "/api/v5/repos/linuxsuren/test/hooks":
+			_, _ = w.Write([]byte(`[{"id": 12, "url": "https://ks.example.com/webhook", "push_events": true,
+"note_events": true}]`))
+		case r.Method == http.MethodDelete && r.URL.Path == "/api/v5/repos/linuxsuren/test/hooks/12":
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"message": "Not Found"}`))
+		}
+	})
+
+	client, err := NewWithToken(server.URL, "token")
+	assert.Nil(t, err)
+	ctx := context.TODO()
+
+	hook, _, err := client.Repositories.CreateHook(ctx, "linuxsuren/test", &scm.HookInput{
+		Target: "https://ks.example.com/webhook",
+		Secret: "secret",
+		Events: scm.HookEvents{Push: true, PullRequest: true},
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, "12", hook.ID)
+	assert.Equal(t, []string{"push", "merge_requests"}, hook.Events)
+
+	hook, _, err = client.Repositories.UpdateHook(ctx, "linuxsuren/test", &scm.HookInput{
+		Name:         "12",
+		Target:       "https://ks.example.com/webhook",
+		NativeEvents: []string{"tag_push"},
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"tag_push"}, hook.Events)
+
+	hooks, _, err := client.Repositories.ListHooks(ctx, "linuxsuren/test", &scm.ListOptions{Page: 1, Size: 10})
+	assert.Nil(t, err)
+	if assert.Equal(t, 1, len(hooks)) {
+		assert.Equal(t, []string{"push", "note"}, hooks[0].Events)
+		assert.Equal(t, "https://ks.example.com/webhook", hooks[0].Target)
+	}
+
+	_, err = client.Repositories.DeleteHook(ctx, "linuxsuren/test", "12")
+	assert.Nil(t, err)
+
+	_, _, err = client.Repositories.FindHook(ctx, "linuxsuren/test", "13")
+	assert.Equal(t, scm.ErrNotFound, err)
+
+	_, _, err = client.Repositories.CreateStatus(ctx, "linuxsuren/test", "sha", &scm.StatusInput{})
+	assert.Equal(t, scm.ErrNotSupported, err)
+}
+
+func TestPullRequestComments(t *testing.T) {
+	server := newFakeServer(t, func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/api/v5/repos/linuxsuren/test/pulls/3/comments":
+			_, _ = w.Write([]byte(`[{"id": 1, "body": "LGTM", "user": {"login": "linuxsuren"}}]`))
+		case r.Method == http.MethodPost && r.URL.Path == "/api/v5/repos/linuxsuren/test/pulls/3/comments":
+			in := &commentInput{}
+			assert.Nil(t, json.NewDecoder(r.Body).Decode(in))
+			_ = json.NewEncoder(w).Encode(&comment{ID: 2, Body: in.Body})
+		case r.Method == http.MethodPatch && r.URL.Path == "/api/v5/repos/linuxsuren/test/pulls/comments/2":
+			in := &commentInput{}
+			assert.Nil(t, json.NewDecoder(r.Body).Decode(in))
+			_ = json.NewEncoder(w).Encode(&comment{ID: 2, Body: in.Body})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	})
+
+	client, err := NewWithToken(server.URL, "token")
+	assert.Nil(t, err)
+	ctx := context.TODO()
+
+	comments, _, err := client.PullRequests.ListComments(ctx, "linuxsuren/test", 3, &scm.ListOptions{Page: 1, Size: 100})
+	assert.Nil(t, err)
+	if assert.Equal(t, 1, len(comments)) {
+		assert.Equal(t, "LGTM", comments[0].Body)
+		assert.Equal(t, "linuxsuren", comments[0].Author.Login)
+	}
+
+	created, _, err := client.PullRequests.CreateComment(ctx, "linuxsuren/test", 3, &scm.CommentInput{Body: "summary"})
+	assert.Nil(t, err)
+	assert.Equal(t, 2, created.ID)
+
+	edited, _, err := client.PullRequests.EditComment(ctx, "linuxsuren/test", 3, 2, &scm.CommentInput{Body: "new summary"})
+	assert.Nil(t, err)
+	assert.Equal(t, "new summary", edited.Body)
+}
+
+func TestWebhookParse(t *testing.T) {
+	const secret = "secret"
+	signature := func(timestamp string) string {
+		mac := hmac.New(sha256.New, []byte(secret))
+		_, _ = mac.Write([]byte(timestamp + "\n" + secret))
+		return base64.StdEncoding.EncodeToString(mac.Sum(nil))
+	}
+	secretFunc := func(scm.Webhook) (string, error) {
+		return secret, nil
+	}
+
+	tests := []struct {
+		name      string
+		event     string
+		body      string
+		header    map[string]string
+		wantErr   error
+		assertion func(t *testing.T, hook scm.Webhook)
+	}{{
+		name:   "push hook with the password",
+		event:  "Push Hook",
+		body:   `{"ref": "refs/heads/master", "after": "abc", "repository": {"path": "test", "namespace": "linuxsuren", "html_url": "https://gitee.com/linuxsuren/test"}}`,
+		header: map[string]string{"X-Gitee-Token": secret},
+		assertion: func(t *testing.T, hook scm.Webhook) {
+			pushHook, ok := hook.(*scm.PushHook)
+			if assert.True(t, ok) {
+				assert.Equal(t, "refs/heads/master", pushHook.Ref)
+				assert.Equal(t, "linuxsuren/test", pushHook.Repo.FullName)
+				assert.Equal(t, "https://gitee.com/linuxsuren/test", pushHook.Repo.Link)
+			}
+		},
+	}, {
+		name:   "merge request hook with the signature",
+		event:  "Merge Request Hook",
+		body:   `{"action": "open", "pull_request": {"number": 3, "state": "open", "head": {"ref": "feature", "sha": "abc"}, "base": {"ref": "master"}}}`,
+		header: map[string]string{"X-Gitee-Token": signature("1654045152000"), "X-Gitee-Timestamp": "1654045152000"},
+		assertion: func(t *testing.T, hook scm.Webhook) {
+			prHook, ok := hook.(*scm.PullRequestHook)
+			if assert.True(t, ok) {
+				assert.Equal(t, scm.ActionOpen, prHook.Action)
+				assert.Equal(t, 3, prHook.PullRequest.Number)
+				assert.Equal(t, "refs/pull/3/head", prHook.PullRequest.Ref)
+				assert.Equal(t, "feature", prHook.PullRequest.Source)
+			}
+		},
+	}, {
+		name:    "invalid token",
+		event:   "Push Hook",
+		body:    `{"ref": "refs/heads/master"}`,
+		header:  map[string]string{"X-Gitee-Token": "fake", "X-Gitee-Timestamp": "1654045152000"},
+		wantErr: scm.ErrSignatureInvalid,
+	}, {
+		name:    "unknown event",
+		event:   "Note Hook",
+		body:    `{}`,
+		wantErr: scm.UnknownWebhook{Event: "Note Hook"},
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/webhooks", strings.NewReader(tt.body))
+			req.Header.Set("X-Gitee-Event", tt.event)
+			for k, v := range tt.header {
+				req.Header.Set(k, v)
+			}
+			hook, err := NewWebHookService().Parse(req, secretFunc)
+			assert.Equal(t, tt.wantErr, err)
+			if tt.assertion != nil {
+				tt.assertion(t, hook)
+			}
+		})
+	}
+}
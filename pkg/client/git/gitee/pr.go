@@ -0,0 +1,96 @@
+/*
+Copyright 2022 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gitee
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/jenkins-x/go-scm/scm"
+)
+
+// pullService overrides the comments of the GitHub driver, the pull requests are not issues in Gitee
+type pullService struct {
+	scm.PullRequestService
+	client *wrapper
+}
+
+type comment struct {
+	ID        int       `json:"id"`
+	Body      string    `json:"body"`
+	User      user      `json:"user"`
+	HTMLURL   string    `json:"html_url"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type commentInput struct {
+	Body string `json:"body"`
+}
+
+// ListComments returns the comments of a pull request
+func (s *pullService) ListComments(ctx context.Context, repo string, number int, opts *scm.ListOptions) ([]*scm.Comment, *scm.Response, error) {
+	path := fmt.Sprintf("repos/%s/pulls/%d/comments", repo, number)
+	if opts != nil && opts.Page > 0 && opts.Size > 0 {
+		path = fmt.Sprintf("%s?page=%d&per_page=%d", path, opts.Page, opts.Size)
+	}
+	var out []*comment
+	res, err := s.client.do(ctx, http.MethodGet, path, nil, &out)
+	if err != nil {
+		return nil, res, err
+	}
+	comments := make([]*scm.Comment, 0, len(out))
+	for _, item := range out {
+		comments = append(comments, convertComment(item))
+	}
+	return comments, res, nil
+}
+
+// CreateComment creates a comment in a pull request
+func (s *pullService) CreateComment(ctx context.Context, repo string, number int, input *scm.CommentInput) (*scm.Comment, *scm.Response, error) {
+	out := &comment{}
+	res, err := s.client.do(ctx, http.MethodPost, fmt.Sprintf("repos/%s/pulls/%d/comments", repo, number),
+		&commentInput{Body: input.Body}, out)
+	if err != nil {
+		return nil, res, err
+	}
+	return convertComment(out), res, nil
+}
+
+// EditComment updates a comment of a pull request
+func (s *pullService) EditComment(ctx context.Context, repo string, _, id int, input *scm.CommentInput) (*scm.Comment, *scm.Response, error) {
+	out := &comment{}
+	res, err := s.client.do(ctx, http.MethodPatch, fmt.Sprintf("repos/%s/pulls/comments/%d", repo, id),
+		&commentInput{Body: input.Body}, out)
+	if err != nil {
+		return nil, res, err
+	}
+	return convertComment(out), res, nil
+}
+
+func convertComment(from *comment) *scm.Comment {
+	return &scm.Comment{
+		ID:      from.ID,
+		Body:    from.Body,
+		Author:  convertUser(&from.User),
+		Link:    from.HTMLURL,
+		Created: from.CreatedAt,
+		Updated: from.UpdatedAt,
+	}
+}
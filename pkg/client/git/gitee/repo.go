@@ -0,0 +1,165 @@
+/*
+Copyright 2022 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gitee
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/jenkins-x/go-scm/scm"
+)
+
+// repositoryService overrides the hooks and the statuses of the GitHub driver
+type repositoryService struct {
+	scm.RepositoryService
+	client *wrapper
+}
+
+type hook struct {
+	ID                  int    `json:"id"`
+	URL                 string `json:"url"`
+	Password            string `json:"password,omitempty"`
+	PushEvents          bool   `json:"push_events"`
+	TagPushEvents       bool   `json:"tag_push_events"`
+	IssuesEvents        bool   `json:"issues_events"`
+	NoteEvents          bool   `json:"note_events"`
+	MergeRequestsEvents bool   `json:"merge_requests_events"`
+}
+
+// FindHook returns a hook by its ID
+func (s *repositoryService) FindHook(ctx context.Context, repo, id string) (*scm.Hook, *scm.Response, error) {
+	out := &hook{}
+	res, err := s.client.do(ctx, http.MethodGet, fmt.Sprintf("repos/%s/hooks/%s", repo, url.PathEscape(id)), nil, out)
+	if err != nil {
+		return nil, res, err
+	}
+	return convertHook(out), res, nil
+}
+
+// ListHooks returns the hooks of a repository
+func (s *repositoryService) ListHooks(ctx context.Context, repo string, opts *scm.ListOptions) ([]*scm.Hook, *scm.Response, error) {
+	path := fmt.Sprintf("repos/%s/hooks", repo)
+	if opts != nil && opts.Page > 0 && opts.Size > 0 {
+		path = fmt.Sprintf("%s?page=%d&per_page=%d", path, opts.Page, opts.Size)
+	}
+	var out []*hook
+	res, err := s.client.do(ctx, http.MethodGet, path, nil, &out)
+	if err != nil {
+		return nil, res, err
+	}
+	hooks := make([]*scm.Hook, 0, len(out))
+	for _, item := range out {
+		hooks = append(hooks, convertHook(item))
+	}
+	return hooks, res, nil
+}
+
+// CreateHook creates a hook, the secret is taken as the password of the hook
+func (s *repositoryService) CreateHook(ctx context.Context, repo string, input *scm.HookInput) (*scm.Hook, *scm.Response, error) {
+	out := &hook{}
+	res, err := s.client.do(ctx, http.MethodPost, fmt.Sprintf("repos/%s/hooks", repo), convertHookInput(input), out)
+	if err != nil {
+		return nil, res, err
+	}
+	return convertHook(out), res, nil
+}
+
+// UpdateHook updates a hook, the name of the input is the ID of the hook
+func (s *repositoryService) UpdateHook(ctx context.Context, repo string, input *scm.HookInput) (*scm.Hook, *scm.Response, error) {
+	out := &hook{}
+	res, err := s.client.do(ctx, http.MethodPatch, fmt.Sprintf("repos/%s/hooks/%s", repo, url.PathEscape(input.Name)),
+		convertHookInput(input), out)
+	if err != nil {
+		return nil, res, err
+	}
+	return convertHook(out), res, nil
+}
+
+// DeleteHook deletes a hook by its ID
+func (s *repositoryService) DeleteHook(ctx context.Context, repo, id string) (*scm.Response, error) {
+	return s.client.do(ctx, http.MethodDelete, fmt.Sprintf("repos/%s/hooks/%s", repo, url.PathEscape(id)), nil, nil)
+}
+
+// ListStatus is not supported, there is no API of the commit statuses in Gitee
+func (s *repositoryService) ListStatus(context.Context, string, string, *scm.ListOptions) ([]*scm.Status, *scm.Response, error) {
+	return nil, nil, scm.ErrNotSupported
+}
+
+// FindCombinedStatus is not supported, there is no API of the commit statuses in Gitee
+func (s *repositoryService) FindCombinedStatus(context.Context, string, string) (*scm.CombinedStatus, *scm.Response, error) {
+	return nil, nil, scm.ErrNotSupported
+}
+
+// CreateStatus is not supported, there is no API of the commit statuses in Gitee
+func (s *repositoryService) CreateStatus(context.Context, string, string, *scm.StatusInput) (*scm.Status, *scm.Response, error) {
+	return nil, nil, scm.ErrNotSupported
+}
+
+func convertHookInput(input *scm.HookInput) *hook {
+	out := &hook{
+		URL:                 input.Target,
+		Password:            input.Secret,
+		PushEvents:          input.Events.Push || input.Events.Branch,
+		TagPushEvents:       input.Events.Tag,
+		IssuesEvents:        input.Events.Issue,
+		NoteEvents:          input.Events.IssueComment || input.Events.PullRequestComment || input.Events.ReviewComment,
+		MergeRequestsEvents: input.Events.PullRequest,
+	}
+	for _, event := range input.NativeEvents {
+		switch event {
+		case "push":
+			out.PushEvents = true
+		case "tag_push":
+			out.TagPushEvents = true
+		case "issues":
+			out.IssuesEvents = true
+		case "note":
+			out.NoteEvents = true
+		case "merge_requests":
+			out.MergeRequestsEvents = true
+		}
+	}
+	return out
+}
+
+func convertHook(from *hook) *scm.Hook {
+	var events []string
+	if from.PushEvents {
+		events = append(events, "push")
+	}
+	if from.TagPushEvents {
+		events = append(events, "tag_push")
+	}
+	if from.IssuesEvents {
+		events = append(events, "issues")
+	}
+	if from.NoteEvents {
+		events = append(events, "note")
+	}
+	if from.MergeRequestsEvents {
+		events = append(events, "merge_requests")
+	}
+	return &scm.Hook{
+		ID:     strconv.Itoa(from.ID),
+		Target: from.URL,
+		Events: events,
+		Active: true,
+	}
+}
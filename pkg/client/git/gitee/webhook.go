@@ -0,0 +1,288 @@
+/*
+Copyright 2022 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gitee
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/jenkins-x/go-scm/scm"
+)
+
+// webhookService parses the payloads of the webhooks of Gitee
+type webhookService struct{}
+
+type repository struct {
+	ID            int    `json:"id"`
+	Name          string `json:"name"`
+	Path          string `json:"path"`
+	FullName      string `json:"full_name"`
+	Namespace     string `json:"namespace"`
+	Private       bool   `json:"private"`
+	DefaultBranch string `json:"default_branch"`
+	HTMLURL       string `json:"html_url"`
+	CloneURL      string `json:"clone_url"`
+	SSHURL        string `json:"ssh_url"`
+	Owner         user   `json:"owner"`
+}
+
+type user struct {
+	ID        int    `json:"id"`
+	Login     string `json:"login"`
+	Name      string `json:"name"`
+	Email     string `json:"email"`
+	AvatarURL string `json:"avatar_url"`
+	HTMLURL   string `json:"html_url"`
+}
+
+type commit struct {
+	ID        string    `json:"id"`
+	Message   string    `json:"message"`
+	Timestamp time.Time `json:"timestamp"`
+	URL       string    `json:"url"`
+	Author    author    `json:"author"`
+	Committer author    `json:"committer"`
+	Added     []string  `json:"added"`
+	Removed   []string  `json:"removed"`
+	Modified  []string  `json:"modified"`
+}
+
+type author struct {
+	Name     string `json:"name"`
+	Email    string `json:"email"`
+	Username string `json:"username"`
+}
+
+type pushHook struct {
+	Ref        string     `json:"ref"`
+	Before     string     `json:"before"`
+	After      string     `json:"after"`
+	Created    bool       `json:"created"`
+	Deleted    bool       `json:"deleted"`
+	Compare    string     `json:"compare"`
+	Commits    []commit   `json:"commits"`
+	HeadCommit *commit    `json:"head_commit"`
+	Repository repository `json:"repository"`
+	Sender     user       `json:"sender"`
+}
+
+type pullRequestHook struct {
+	Action      string      `json:"action"`
+	PullRequest pullRequest `json:"pull_request"`
+	Repository  repository  `json:"repository"`
+	Sender      user        `json:"sender"`
+}
+
+type pullRequest struct {
+	Number         int       `json:"number"`
+	State          string    `json:"state"`
+	HTMLURL        string    `json:"html_url"`
+	Title          string    `json:"title"`
+	Body           string    `json:"body"`
+	Merged         bool      `json:"merged"`
+	Mergeable      bool      `json:"mergeable"`
+	Draft          bool      `json:"draft"`
+	MergeCommitSha string    `json:"merge_commit_sha"`
+	Head           branch    `json:"head"`
+	Base           branch    `json:"base"`
+	User           user      `json:"user"`
+	CreatedAt      time.Time `json:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
+}
+
+type branch struct {
+	Ref  string     `json:"ref"`
+	Sha  string     `json:"sha"`
+	Repo repository `json:"repo"`
+}
+
+// Parse parses the push, tag push and merge request events, the token of the request could be the password of
+// the webhook, or the signature which is calculated from the secret and the timestamp
+func (s *webhookService) Parse(req *http.Request, fn scm.SecretFunc) (hook scm.Webhook, err error) {
+	var data []byte
+	if data, err = io.ReadAll(io.LimitReader(req.Body, 10000000)); err != nil {
+		return
+	}
+
+	switch event := req.Header.Get("X-Gitee-Event"); event {
+	case "Push Hook", "Tag Push Hook":
+		hook, err = parsePushHook(data)
+	case "Merge Request Hook":
+		hook, err = parsePullRequestHook(data)
+	default:
+		return nil, scm.UnknownWebhook{Event: event}
+	}
+	if err != nil || fn == nil {
+		return
+	}
+
+	var secret string
+	if secret, err = fn(hook); err != nil || secret == "" {
+		return
+	}
+	if !validateToken(req.Header.Get("X-Gitee-Token"), req.Header.Get("X-Gitee-Timestamp"), secret) {
+		return hook, scm.ErrSignatureInvalid
+	}
+	return
+}
+
+// validateToken validates the token of a request, see also https://gitee.com/help/articles/4290
+func validateToken(token, timestamp, secret string) bool {
+	if subtle.ConstantTimeCompare([]byte(token), []byte(secret)) == 1 {
+		return true
+	}
+	mac := hmac.New(sha256.New, []byte(secret))
+	_, _ = mac.Write([]byte(fmt.Sprintf("%s\n%s", timestamp, secret)))
+	signature := base64.StdEncoding.EncodeToString(mac.Sum(nil))
+	return timestamp != "" && subtle.ConstantTimeCompare([]byte(token), []byte(signature)) == 1
+}
+
+func parsePushHook(data []byte) (scm.Webhook, error) {
+	src := &pushHook{}
+	if err := json.Unmarshal(data, src); err != nil {
+		return nil, err
+	}
+	hook := &scm.PushHook{
+		Ref:     src.Ref,
+		Repo:    convertRepository(&src.Repository),
+		Before:  src.Before,
+		After:   src.After,
+		Created: src.Created,
+		Deleted: src.Deleted,
+		Compare: src.Compare,
+		Sender:  convertUser(&src.Sender),
+	}
+	for _, item := range src.Commits {
+		hook.Commits = append(hook.Commits, scm.PushCommit{
+			ID:       item.ID,
+			Message:  item.Message,
+			Added:    item.Added,
+			Removed:  item.Removed,
+			Modified: item.Modified,
+		})
+	}
+	if src.HeadCommit != nil {
+		hook.Commit = scm.Commit{
+			Sha:     src.HeadCommit.ID,
+			Message: src.HeadCommit.Message,
+			Author: scm.Signature{
+				Name:  src.HeadCommit.Author.Name,
+				Email: src.HeadCommit.Author.Email,
+				Login: src.HeadCommit.Author.Username,
+				Date:  src.HeadCommit.Timestamp,
+			},
+			Committer: scm.Signature{
+				Name:  src.HeadCommit.Committer.Name,
+				Email: src.HeadCommit.Committer.Email,
+				Login: src.HeadCommit.Committer.Username,
+				Date:  src.HeadCommit.Timestamp,
+			},
+			Link: src.HeadCommit.URL,
+		}
+	}
+	return hook, nil
+}
+
+func parsePullRequestHook(data []byte) (scm.Webhook, error) {
+	src := &pullRequestHook{}
+	if err := json.Unmarshal(data, src); err != nil {
+		return nil, err
+	}
+	pr := &src.PullRequest
+	hook := &scm.PullRequestHook{
+		Repo: convertRepository(&src.Repository),
+		PullRequest: scm.PullRequest{
+			Number:   pr.Number,
+			Title:    pr.Title,
+			Body:     pr.Body,
+			Sha:      pr.Head.Sha,
+			Ref:      fmt.Sprintf("refs/pull/%d/head", pr.Number),
+			Source:   pr.Head.Ref,
+			Target:   pr.Base.Ref,
+			Base:     scm.PullRequestBranch{Ref: pr.Base.Ref, Sha: pr.Base.Sha, Repo: convertRepository(&pr.Base.Repo)},
+			Head:     scm.PullRequestBranch{Ref: pr.Head.Ref, Sha: pr.Head.Sha, Repo: convertRepository(&pr.Head.Repo)},
+			State:    pr.State,
+			Closed:   pr.State != "open",
+			Merged:   pr.Merged,
+			Draft:    pr.Draft,
+			MergeSha: pr.MergeCommitSha,
+			Author:   convertUser(&pr.User),
+			Created:  pr.CreatedAt,
+			Updated:  pr.UpdatedAt,
+			Link:     pr.HTMLURL,
+		},
+		Sender: convertUser(&src.Sender),
+	}
+	switch src.Action {
+	case "open":
+		hook.Action = scm.ActionOpen
+	case "close":
+		hook.Action = scm.ActionClose
+	case "reopen":
+		hook.Action = scm.ActionReopen
+	case "merge":
+		hook.Action = scm.ActionMerge
+	default:
+		// the source branch was changed, or the title, labels and so on
+		hook.Action = scm.ActionSync
+	}
+	return hook, nil
+}
+
+func convertRepository(from *repository) scm.Repository {
+	namespace := from.Namespace
+	if namespace == "" {
+		namespace = from.Owner.Login
+	}
+	name := from.Path
+	if name == "" {
+		name = from.Name
+	}
+	fullName := from.FullName
+	if fullName == "" {
+		fullName = scm.Join(namespace, name)
+	}
+	return scm.Repository{
+		ID:        fmt.Sprint(from.ID),
+		Namespace: namespace,
+		Name:      name,
+		FullName:  fullName,
+		Branch:    from.DefaultBranch,
+		Private:   from.Private,
+		Clone:     from.CloneURL,
+		CloneSSH:  from.SSHURL,
+		Link:      from.HTMLURL,
+	}
+}
+
+func convertUser(from *user) scm.User {
+	return scm.User{
+		ID:     from.ID,
+		Login:  from.Login,
+		Name:   from.Name,
+		Email:  from.Email,
+		Avatar: from.AvatarURL,
+		Link:   from.HTMLURL,
+	}
+}
@@ -0,0 +1,236 @@
+/*
+Copyright 2022 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package git
+
+import (
+	"context"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/form3tech-oss/jwt-go"
+	v1 "k8s.io/api/core/v1"
+	"kubesphere.io/devops/pkg/api/devops/v1alpha3"
+)
+
+const (
+	// githubAppJWTExpiration is the lifetime of the JWT which is used to mint the installation tokens, the maximum is 10 minutes
+	githubAppJWTExpiration = 9 * time.Minute
+	// githubAppTokenExpiryDelta makes sure that an installation token is refreshed before it is expired
+	githubAppTokenExpiryDelta = 5 * time.Minute
+	// githubAppRequestTimeout is the timeout of minting an installation token
+	githubAppRequestTimeout = 30 * time.Second
+)
+
+// now returns the current time, it is a variable for the tests
+var now = time.Now
+
+// installationToken is the access token of a GitHub App installation
+type installationToken struct {
+	Token     string    `json:"token"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+func (t *installationToken) valid() bool {
+	return t != nil && t.Token != "" && now().Add(githubAppTokenExpiryDelta).Before(t.ExpiresAt)
+}
+
+// installationTokenCache holds the installation tokens. The key consists of the API address, app ID, installation ID,
+// the secret and the hash of the private key, so a token is only shared by the callers which hold the same private key.
+type installationTokenCache struct {
+	sync.Mutex
+	tokens map[string]*installationTokenEntry
+}
+
+// installationTokenEntry is the cached token of an installation, the lock only blocks the same installation
+type installationTokenEntry struct {
+	sync.Mutex
+	token *installationToken
+	// expiresAt is protected by the lock of the cache, the entry is removed once it is expired
+	expiresAt time.Time
+}
+
+// getEntry returns the entry of the key, it creates the entry if it does not exist.
+// The expired entries are removed at the same time.
+func (c *installationTokenCache) getEntry(key string) *installationTokenEntry {
+	c.Lock()
+	defer c.Unlock()
+	current := now()
+	for k, item := range c.tokens {
+		if !item.expiresAt.IsZero() && !current.Before(item.expiresAt) {
+			delete(c.tokens, k)
+		}
+	}
+
+	entry, ok := c.tokens[key]
+	if !ok {
+		entry = &installationTokenEntry{}
+		c.tokens[key] = entry
+	}
+	return entry
+}
+
+// setToken sets the token of an entry, the entry is removed if there is no token
+func (c *installationTokenCache) setToken(key string, entry *installationTokenEntry, token *installationToken) {
+	c.Lock()
+	defer c.Unlock()
+	entry.token = token
+	if token == nil {
+		if c.tokens[key] == entry {
+			delete(c.tokens, key)
+		}
+		return
+	}
+	entry.expiresAt = token.ExpiresAt
+}
+
+var githubAppTokens = &installationTokenCache{tokens: map[string]*installationTokenEntry{}}
+
+// githubAppHTTPClient is the default HTTP client to mint the installation tokens
+var githubAppHTTPClient = &http.Client{Timeout: githubAppRequestTimeout}
+
+// GitHubApp mints the installation tokens of a GitHub App
+type GitHubApp struct {
+	AppID          string
+	InstallationID string
+	PrivateKey     *rsa.PrivateKey
+	// BaseURL is the address of the GitHub API, such as: https://api.github.com/
+	BaseURL    string
+	HTTPClient *http.Client
+	// Secret is the namespace and name of the secret which the GitHub App comes from
+	Secret string
+}
+
+// NewGitHubAppFromSecret creates a GitHubApp from a GitHub App secret
+func NewGitHubAppFromSecret(secret *v1.Secret, baseURL string) (app *GitHubApp, err error) {
+	if secret.Type != v1alpha3.SecretTypeGitHubApp {
+		err = fmt.Errorf("the type of secret %s/%s is not %s", secret.Namespace, secret.Name, v1alpha3.SecretTypeGitHubApp)
+		return
+	}
+
+	app = &GitHubApp{
+		AppID:          strings.TrimSpace(string(secret.Data[v1alpha3.GitHubAppIDKey])),
+		InstallationID: strings.TrimSpace(string(secret.Data[v1alpha3.GitHubAppInstallationIDKey])),
+		BaseURL:        baseURL,
+		Secret:         secret.Namespace + "/" + secret.Name,
+	}
+	if app.AppID == "" || app.InstallationID == "" {
+		err = fmt.Errorf("both %s and %s are required in secret %s/%s",
+			v1alpha3.GitHubAppIDKey, v1alpha3.GitHubAppInstallationIDKey, secret.Namespace, secret.Name)
+		return
+	}
+	if app.PrivateKey, err = jwt.ParseRSAPrivateKeyFromPEM(secret.Data[v1alpha3.GitHubAppPrivateKey]); err != nil {
+		err = fmt.Errorf("failed to parse the private key of secret %s/%s, error: %v", secret.Namespace, secret.Name, err)
+	}
+	return
+}
+
+// GetToken returns a cached installation token, or mints a new one if it is about to expire
+func (a *GitHubApp) GetToken(ctx context.Context) (token string, err error) {
+	if a.PrivateKey == nil {
+		err = fmt.Errorf("no private key found for GitHub App %s", a.AppID)
+		return
+	}
+	key := a.getCacheKey()
+
+	// only the callers of the same installation wait for the minting
+	entry := githubAppTokens.getEntry(key)
+	entry.Lock()
+	defer entry.Unlock()
+	if entry.token.valid() {
+		token = entry.token.Token
+		return
+	}
+
+	var newToken *installationToken
+	if newToken, err = a.mintToken(ctx); err == nil {
+		token = newToken.Token
+	}
+	githubAppTokens.setToken(key, entry, newToken)
+	return
+}
+
+// getCacheKey returns the key of the cached installation token.
+// It contains the hash of the private key, the token cannot be taken by the ones without the private key.
+func (a *GitHubApp) getCacheKey() string {
+	keyHash := sha256.Sum256(x509.MarshalPKCS1PrivateKey(a.PrivateKey))
+	return fmt.Sprintf("%s#%s#%s#%s#%s", a.BaseURL, a.AppID, a.InstallationID, a.Secret, hex.EncodeToString(keyHash[:]))
+}
+
+// mintToken creates an installation token, see also
+// https://docs.github.com/en/rest/apps/apps#create-an-installation-access-token-for-an-app
+func (a *GitHubApp) mintToken(ctx context.Context) (token *installationToken, err error) {
+	var appJWT string
+	if appJWT, err = a.createJWT(); err != nil {
+		return
+	}
+
+	api := fmt.Sprintf("%s/app/installations/%s/access_tokens", strings.TrimSuffix(a.BaseURL, "/"), a.InstallationID)
+	var req *http.Request
+	if req, err = http.NewRequestWithContext(ctx, http.MethodPost, api, nil); err != nil {
+		return
+	}
+	req.Header.Set("Authorization", "Bearer "+appJWT)
+	req.Header.Set("Accept", "application/vnd.github+json")
+
+	httpClient := a.HTTPClient
+	if httpClient == nil {
+		httpClient = githubAppHTTPClient
+	}
+	var resp *http.Response
+	if resp, err = httpClient.Do(req); err != nil {
+		return
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+
+	var data []byte
+	if data, err = io.ReadAll(resp.Body); err != nil {
+		return
+	}
+	if resp.StatusCode != http.StatusCreated && resp.StatusCode != http.StatusOK {
+		err = fmt.Errorf("failed to create the installation token of GitHub App %s, status code: %d, response: %s",
+			a.AppID, resp.StatusCode, string(data))
+		return
+	}
+
+	token = &installationToken{}
+	if err = json.Unmarshal(data, token); err == nil && token.Token == "" {
+		err = fmt.Errorf("no installation token found in the response of GitHub App %s", a.AppID)
+	}
+	return
+}
+
+// createJWT creates the JWT which is signed by the private key of the GitHub App
+func (a *GitHubApp) createJWT() (string, error) {
+	issuedAt := now()
+	return jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.StandardClaims{
+		// allow the clock drift
+		IssuedAt:  issuedAt.Add(-time.Minute).Unix(),
+		ExpiresAt: issuedAt.Add(githubAppJWTExpiration).Unix(),
+		Issuer:    a.AppID,
+	}).SignedString(a.PrivateKey)
+}
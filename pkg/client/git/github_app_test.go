@@ -0,0 +1,256 @@
+/*
+Copyright 2022 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package git
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/form3tech-oss/jwt-go"
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"kubesphere.io/devops/pkg/api/devops/v1alpha1"
+	"kubesphere.io/devops/pkg/api/devops/v1alpha3"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+// fakeGitHubAppServer mints the installation tokens like GitHub Enterprise
+type fakeGitHubAppServer struct {
+	*httptest.Server
+	publicKey *rsa.PublicKey
+	minted    int32
+	expiresIn time.Duration
+}
+
+func newFakeGitHubAppServer(t *testing.T, publicKey *rsa.PublicKey) *fakeGitHubAppServer {
+	server := &fakeGitHubAppServer{publicKey: publicKey, expiresIn: time.Hour}
+	server.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/api/v3/app/installations/456/access_tokens" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		claims := &jwt.StandardClaims{}
+		// the time is faked in the tests
+		parser := &jwt.Parser{SkipClaimsValidation: true}
+		if _, err := parser.ParseWithClaims(strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer "), claims,
+			func(token *jwt.Token) (interface{}, error) {
+				return server.publicKey, nil
+			}); err != nil || claims.Issuer != "123" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		minted := atomic.AddInt32(&server.minted, 1)
+		w.WriteHeader(http.StatusCreated)
+		_, _ = fmt.Fprintf(w, `{"token": "token-%d", "expires_at": "%s"}`, minted,
+			now().Add(server.expiresIn).UTC().Format(time.RFC3339))
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func generateGitHubAppSecret(t *testing.T) (secret *v1.Secret, privateKey *rsa.PrivateKey) {
+	privateKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.Nil(t, err)
+	secret = &v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "app", Namespace: "ns"},
+		Type:       v1alpha3.SecretTypeGitHubApp,
+		Data: map[string][]byte{
+			v1alpha3.GitHubAppIDKey:             []byte("123"),
+			v1alpha3.GitHubAppInstallationIDKey: []byte("456"),
+			v1alpha3.GitHubAppPrivateKey: pem.EncodeToMemory(&pem.Block{
+				Type:  "RSA PRIVATE KEY",
+				Bytes: x509.MarshalPKCS1PrivateKey(privateKey),
+			}),
+		},
+	}
+	return
+}
+
+func TestClientFactory_GetClientWithGitHubApp(t *testing.T) {
+	schema, err := v1alpha1.SchemeBuilder.Register().Build()
+	assert.Nil(t, err)
+	err = v1.SchemeBuilder.AddToScheme(schema)
+	assert.Nil(t, err)
+
+	secret, privateKey := generateGitHubAppSecret(t)
+	server := newFakeGitHubAppServer(t, &privateKey.PublicKey)
+	k8sClient := fake.NewClientBuilder().WithScheme(schema).WithObjects(secret).Build()
+
+	defer func() {
+		now = time.Now
+		githubAppTokens.tokens = map[string]*installationTokenEntry{}
+	}()
+	current := time.Now()
+	now = func() time.Time {
+		return current
+	}
+
+	factory := NewClientFactory("github", &v1.SecretReference{Namespace: "ns", Name: "app"}, k8sClient)
+	factory.Server = server.URL
+	gitClient, err := factory.GetClient()
+	assert.Nil(t, err)
+	assert.NotNil(t, gitClient)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&server.minted))
+
+	// take the cached token
+	_, err = factory.GetClient()
+	assert.Nil(t, err)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&server.minted))
+
+	// refresh the token before it expires
+	current = current.Add(time.Hour - githubAppTokenExpiryDelta)
+	_, err = factory.GetClient()
+	assert.Nil(t, err)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&server.minted))
+
+	// only GitHub supports the GitHub App
+	factory = NewClientFactory("gitlab", &v1.SecretReference{Namespace: "ns", Name: "app"}, k8sClient)
+	_, err = factory.GetClient()
+	assert.NotNil(t, err)
+}
+
+func TestGitHubApp_GetToken(t *testing.T) {
+	secret, privateKey := generateGitHubAppSecret(t)
+	server := newFakeGitHubAppServer(t, &privateKey.PublicKey)
+	defer func() {
+		githubAppTokens.tokens = map[string]*installationTokenEntry{}
+	}()
+
+	t.Run("normal case", func(t *testing.T) {
+		app, err := NewGitHubAppFromSecret(secret, server.URL+"/api/v3/")
+		assert.Nil(t, err)
+
+		token, err := app.GetToken(context.TODO())
+		assert.Nil(t, err)
+		assert.True(t, strings.HasPrefix(token, "token-"))
+	})
+
+	t.Run("invalid private key", func(t *testing.T) {
+		otherSecret, _ := generateGitHubAppSecret(t)
+		app, err := NewGitHubAppFromSecret(otherSecret, server.URL+"/api/v3/")
+		assert.Nil(t, err)
+
+		// the cached token of the same installation is not taken without the same private key
+		_, err = app.GetToken(context.TODO())
+		assert.NotNil(t, err)
+		assert.Contains(t, err.Error(), "401")
+	})
+
+	t.Run("another secret of the same installation", func(t *testing.T) {
+		githubAppTokens.tokens = map[string]*installationTokenEntry{}
+		app, err := NewGitHubAppFromSecret(secret, server.URL+"/api/v3/")
+		assert.Nil(t, err)
+		token, err := app.GetToken(context.TODO())
+		assert.Nil(t, err)
+
+		otherSecret := secret.DeepCopy()
+		otherSecret.Namespace = "other"
+		otherApp, err := NewGitHubAppFromSecret(otherSecret, server.URL+"/api/v3/")
+		assert.Nil(t, err)
+		otherToken, err := otherApp.GetToken(context.TODO())
+		assert.Nil(t, err)
+		assert.NotEqual(t, token, otherToken)
+		assert.Equal(t, 2, len(githubAppTokens.tokens))
+	})
+
+	t.Run("remove the expired tokens", func(t *testing.T) {
+		defer func() {
+			now = time.Now
+		}()
+		current := time.Now()
+		now = func() time.Time {
+			return current
+		}
+		githubAppTokens.tokens = map[string]*installationTokenEntry{}
+		app, err := NewGitHubAppFromSecret(secret, server.URL+"/api/v3/")
+		assert.Nil(t, err)
+		_, err = app.GetToken(context.TODO())
+		assert.Nil(t, err)
+		assert.Equal(t, 1, len(githubAppTokens.tokens))
+
+		current = current.Add(2 * time.Hour)
+		githubAppTokens.getEntry("https://api.github.com/#1#1")
+		assert.Equal(t, 1, len(githubAppTokens.tokens))
+		_, ok := githubAppTokens.tokens["https://api.github.com/#1#1"]
+		assert.True(t, ok)
+	})
+
+	t.Run("cancelled context", func(t *testing.T) {
+		app, err := NewGitHubAppFromSecret(secret, server.URL+"/api/v3/")
+		assert.Nil(t, err)
+		githubAppTokens.tokens = map[string]*installationTokenEntry{}
+
+		ctx, cancel := context.WithCancel(context.TODO())
+		cancel()
+		_, err = app.GetToken(ctx)
+		assert.ErrorIs(t, err, context.Canceled)
+		// the failed minting is not cached
+		assert.Equal(t, 0, len(githubAppTokens.tokens))
+	})
+
+	t.Run("lock per installation", func(t *testing.T) {
+		entry := githubAppTokens.getEntry("https://api.github.com/#1#1")
+		assert.Same(t, entry, githubAppTokens.getEntry("https://api.github.com/#1#1"))
+
+		entry.Lock()
+		defer entry.Unlock()
+		// other installations are not blocked
+		other := githubAppTokens.getEntry("https://api.github.com/#1#2")
+		assert.NotSame(t, entry, other)
+		locked := make(chan struct{})
+		go func() {
+			other.Lock()
+			defer other.Unlock()
+			close(locked)
+		}()
+		select {
+		case <-locked:
+		case <-time.After(time.Second):
+			t.Error("the installation should not be blocked by the others")
+		}
+	})
+
+	t.Run("invalid secrets", func(t *testing.T) {
+		invalid := secret.DeepCopy()
+		invalid.Type = v1.SecretTypeOpaque
+		_, err := NewGitHubAppFromSecret(invalid, server.URL)
+		assert.NotNil(t, err)
+
+		invalid = secret.DeepCopy()
+		delete(invalid.Data, v1alpha3.GitHubAppInstallationIDKey)
+		_, err = NewGitHubAppFromSecret(invalid, server.URL)
+		assert.NotNil(t, err)
+
+		invalid = secret.DeepCopy()
+		invalid.Data[v1alpha3.GitHubAppPrivateKey] = []byte("invalid")
+		_, err = NewGitHubAppFromSecret(invalid, server.URL)
+		assert.NotNil(t, err)
+	})
+}
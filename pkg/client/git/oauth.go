@@ -0,0 +1,80 @@
+/*
+Copyright 2022 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package git
+
+import (
+	"fmt"
+	"time"
+
+	"golang.org/x/oauth2"
+	v1 "k8s.io/api/core/v1"
+	"kubesphere.io/devops/pkg/api/devops/v1alpha3"
+)
+
+// oauthUsernames are the usernames of the OAuth tokens which are required by git over HTTP
+var oauthUsernames = map[string]string{
+	"gitlab":          "oauth2",
+	"bitbucket_cloud": "x-token-auth",
+}
+
+// GetOAuthToken returns the OAuth token of a credential which was issued by the SCM OAuth flow,
+// the token is nil if the credential is not from the SCM OAuth flow or cannot be refreshed.
+func GetOAuthToken(secret *v1.Secret) (token *oauth2.Token, provider, server string, err error) {
+	provider = secret.Annotations[v1alpha3.CredentialSCMOAuthProviderAnnoKey]
+	server = secret.Annotations[v1alpha3.CredentialSCMOAuthServerAnnoKey]
+	refreshToken := string(secret.Data[v1alpha3.OAuthRefreshTokenKey])
+	if provider == "" || refreshToken == "" {
+		return
+	}
+
+	token = &oauth2.Token{
+		AccessToken:  string(secret.Data[v1alpha3.BasicAuthPasswordKey]),
+		RefreshToken: refreshToken,
+	}
+	if expiry := string(secret.Data[v1alpha3.OAuthExpiryKey]); expiry != "" {
+		if token.Expiry, err = time.Parse(time.RFC3339, expiry); err != nil {
+			token = nil
+			err = fmt.Errorf("invalid expiry of the OAuth token in secret %s/%s, error is: %v",
+				secret.Namespace, secret.Name, err)
+		}
+	}
+	return
+}
+
+// SetOAuthToken sets the OAuth token to a basic-auth secret
+func SetOAuthToken(secret *v1.Secret, provider, server string, oauthToken *oauth2.Token) {
+	if secret.Annotations == nil {
+		secret.Annotations = map[string]string{}
+	}
+	secret.Annotations[v1alpha3.CredentialSCMOAuthProviderAnnoKey] = provider
+	secret.Annotations[v1alpha3.CredentialSCMOAuthServerAnnoKey] = server
+
+	username, ok := oauthUsernames[provider]
+	if !ok {
+		username = "oauth2"
+	}
+	secret.Data = map[string][]byte{
+		v1alpha3.BasicAuthUsernameKey: []byte(username),
+		v1alpha3.BasicAuthPasswordKey: []byte(oauthToken.AccessToken),
+	}
+	if oauthToken.RefreshToken != "" {
+		secret.Data[v1alpha3.OAuthRefreshTokenKey] = []byte(oauthToken.RefreshToken)
+	}
+	if !oauthToken.Expiry.IsZero() {
+		secret.Data[v1alpha3.OAuthExpiryKey] = []byte(oauthToken.Expiry.UTC().Format(time.RFC3339))
+	}
+}
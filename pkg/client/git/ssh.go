@@ -0,0 +1,540 @@
+/*
+Copyright 2022 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package git
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha1"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+	v1 "k8s.io/api/core/v1"
+	"kubesphere.io/devops/pkg/api/devops/v1alpha3"
+)
+
+const (
+	// SSHKnownHostsKey is the key of the known hosts in a SSH auth secret.
+	// The host key of the SSH server must be one of them.
+	SSHKnownHostsKey = "known_hosts"
+	// SSHInsecureSkipHostKeyVerifyKey is the optional key in a SSH auth secret.
+	// The host key is not verified if its value is "true" and there are no known hosts, it is insecure.
+	SSHInsecureSkipHostKeyVerifyKey = "insecure_skip_host_key_verify"
+
+	defaultSSHUser    = "git"
+	defaultSSHPort    = "22"
+	defaultSSHTimeout = 30 * time.Second
+
+	refPrefixBranch = "refs/heads/"
+	refPrefixTag    = "refs/tags/"
+	peeledRefSuffix = "^{}"
+)
+
+// ErrSSHAuth indicates that a SSH auth secret cannot be used by the HTTP API clients of git providers
+var ErrSSHAuth = errors.New("the SSH auth secret can only be used through the git protocol")
+
+// ErrNoKnownHosts indicates that the host key of a SSH server cannot be verified
+var ErrNoKnownHosts = fmt.Errorf("no known hosts provided to verify the SSH server, please set %q in the secret", SSHKnownHostsKey)
+
+// SSHAuth is the authentication of the git SSH protocol
+type SSHAuth struct {
+	// Username is the default SSH user if it is not provided by the repository URL
+	Username        string
+	Signer          ssh.Signer
+	HostKeyCallback ssh.HostKeyCallback
+	// InsecureSkipHostKeyVerify accepts any host key if there is no HostKeyCallback
+	InsecureSkipHostKeyVerify bool
+	Timeout                   time.Duration
+}
+
+// RemoteRef is a reference of a remote git repository
+type RemoteRef struct {
+	// Name is the full name of a reference, such as: refs/heads/master
+	Name string
+	SHA  string
+}
+
+// ShortName returns the name without the prefix of the branches or tags
+func (r RemoteRef) ShortName() string {
+	return strings.TrimPrefix(strings.TrimPrefix(r.Name, refPrefixBranch), refPrefixTag)
+}
+
+// RemoteRefs are the references which are advertised by a remote git repository
+type RemoteRefs struct {
+	// HEAD is the reference of the default branch, such as: refs/heads/master
+	HEAD string
+	Refs []RemoteRef
+}
+
+// DefaultBranch returns the default branch of the remote git repository
+func (r *RemoteRefs) DefaultBranch() (branch RemoteRef, ok bool) {
+	return r.find(r.HEAD)
+}
+
+// Branches returns all the branches
+func (r *RemoteRefs) Branches() []RemoteRef {
+	return r.filter(refPrefixBranch)
+}
+
+// Tags returns all the tags
+func (r *RemoteRefs) Tags() []RemoteRef {
+	return r.filter(refPrefixTag)
+}
+
+func (r *RemoteRefs) find(name string) (ref RemoteRef, ok bool) {
+	for i := range r.Refs {
+		if r.Refs[i].Name == name {
+			return r.Refs[i], true
+		}
+	}
+	return
+}
+
+func (r *RemoteRefs) filter(prefix string) (refs []RemoteRef) {
+	for i := range r.Refs {
+		if strings.HasPrefix(r.Refs[i].Name, prefix) {
+			refs = append(refs, r.Refs[i])
+		}
+	}
+	return
+}
+
+// IsSSHAuthSecret returns true if the secret is able to authenticate through the SSH protocol
+func IsSSHAuthSecret(secret *v1.Secret) bool {
+	return secret != nil && (secret.Type == v1.SecretTypeSSHAuth || secret.Type == v1alpha3.SecretTypeSSHAuth)
+}
+
+// NewSSHAuthFromSecret creates the SSHAuth from a kubernetes.io/ssh-auth or a DevOps ssh-auth secret
+func NewSSHAuthFromSecret(secret *v1.Secret) (auth *SSHAuth, err error) {
+	if !IsSSHAuthSecret(secret) {
+		err = fmt.Errorf("the type of secret %s/%s is not SSH auth", secret.Namespace, secret.Name)
+		return
+	}
+
+	var privateKey, passphrase []byte
+	auth = &SSHAuth{}
+	if secret.Type == v1.SecretTypeSSHAuth {
+		privateKey = secret.Data[v1.SSHAuthPrivateKey]
+	} else {
+		privateKey = secret.Data[v1alpha3.SSHAuthPrivateKey]
+		passphrase = secret.Data[v1alpha3.SSHAuthPassphraseKey]
+		auth.Username = string(secret.Data[v1alpha3.SSHAuthUsernameKey])
+	}
+
+	if len(passphrase) > 0 {
+		auth.Signer, err = ssh.ParsePrivateKeyWithPassphrase(privateKey, passphrase)
+	} else {
+		auth.Signer, err = ssh.ParsePrivateKey(privateKey)
+	}
+	if err != nil {
+		err = fmt.Errorf("failed to parse the private key of secret %s/%s, error: %v", secret.Namespace, secret.Name, err)
+		return
+	}
+
+	if knownHosts := secret.Data[SSHKnownHostsKey]; len(knownHosts) > 0 {
+		auth.HostKeyCallback, err = newKnownHostsCallback(knownHosts)
+	}
+	auth.InsecureSkipHostKeyVerify = string(secret.Data[SSHInsecureSkipHostKeyVerifyKey]) == "true"
+	return
+}
+
+// knownHost is an entry of the known hosts
+type knownHost struct {
+	revoked  bool
+	patterns []string
+	key      ssh.PublicKey
+}
+
+// newKnownHostsCallback creates the host key callback from the content of a known_hosts file.
+// The entries are kept in memory, the hashed host names, wildcards, negations and revoked keys are supported,
+// but the host certificates are not.
+func newKnownHostsCallback(knownHosts []byte) (callback ssh.HostKeyCallback, err error) {
+	var hosts []knownHost
+	for rest := knownHosts; ; {
+		var (
+			marker   string
+			patterns []string
+			key      ssh.PublicKey
+		)
+		if marker, patterns, key, _, rest, err = ssh.ParseKnownHosts(rest); err == io.EOF {
+			err = nil
+			break
+		} else if err != nil {
+			err = fmt.Errorf("invalid known hosts, error: %v", err)
+			return
+		}
+		if marker == "cert-authority" {
+			continue
+		}
+		hosts = append(hosts, knownHost{revoked: marker == "revoked", patterns: patterns, key: key})
+	}
+	if len(hosts) == 0 {
+		err = errors.New("no valid entry found in the known hosts")
+		return
+	}
+
+	callback = func(hostname string, remote net.Addr, key ssh.PublicKey) error {
+		addresses := []string{knownhosts.Normalize(hostname)}
+		if remote != nil {
+			addresses = append(addresses, knownhosts.Normalize(remote.String()))
+		}
+
+		keyData := key.Marshal()
+		known, accepted := false, false
+		for _, host := range hosts {
+			if !host.match(addresses) {
+				continue
+			}
+			sameKey := bytes.Equal(host.key.Marshal(), keyData)
+			if host.revoked {
+				if sameKey {
+					return fmt.Errorf("the host key of %s is revoked", hostname)
+				}
+				continue
+			}
+			known = true
+			accepted = accepted || sameKey
+		}
+
+		switch {
+		case accepted:
+			return nil
+		case known:
+			return fmt.Errorf("the host key of %s does not match the known hosts", hostname)
+		default:
+			return fmt.Errorf("%s is not a known host", hostname)
+		}
+	}
+	return
+}
+
+// match checks if one of the addresses matches the host patterns, and none of them matches a negated pattern
+func (h knownHost) match(addresses []string) bool {
+	for _, address := range addresses {
+		matched, negated := false, false
+		for _, pattern := range h.patterns {
+			if strings.HasPrefix(pattern, "!") {
+				negated = negated || matchHostPattern(pattern[1:], address)
+			} else {
+				matched = matched || matchHostPattern(pattern, address)
+			}
+		}
+		if matched && !negated {
+			return true
+		}
+	}
+	return false
+}
+
+// hashedHostPrefix is the prefix of the hashed host names, such as: |1|salt|hash
+const hashedHostPrefix = "|1|"
+
+// matchHostPattern checks if the address matches a host pattern of the known hosts
+func matchHostPattern(pattern, address string) bool {
+	if strings.HasPrefix(pattern, hashedHostPrefix) {
+		items := strings.Split(strings.TrimPrefix(pattern, hashedHostPrefix), "|")
+		if len(items) != 2 {
+			return false
+		}
+		salt, saltErr := base64.StdEncoding.DecodeString(items[0])
+		hash, hashErr := base64.StdEncoding.DecodeString(items[1])
+		if saltErr != nil || hashErr != nil {
+			return false
+		}
+		mac := hmac.New(sha1.New, salt)
+		_, _ = mac.Write([]byte(address))
+		return hmac.Equal(mac.Sum(nil), hash)
+	}
+	return matchWildcard(strings.ToLower(pattern), strings.ToLower(address))
+}
+
+// matchWildcard matches the text with a pattern which contains the wildcards '*' and '?'
+func matchWildcard(pattern, text string) bool {
+	for len(pattern) > 0 {
+		switch pattern[0] {
+		case '*':
+			for i := 0; i <= len(text); i++ {
+				if matchWildcard(pattern[1:], text[i:]) {
+					return true
+				}
+			}
+			return false
+		case '?':
+			if len(text) == 0 {
+				return false
+			}
+		default:
+			if len(text) == 0 || pattern[0] != text[0] {
+				return false
+			}
+		}
+		pattern, text = pattern[1:], text[1:]
+	}
+	return len(text) == 0
+}
+
+// ListRemoteRefs lists the references of a remote git repository through the SSH protocol, just like git ls-remote.
+// Both the scp-like address (git@github.com:owner/repo.git) and the SSH URL (ssh://git@github.com/owner/repo.git) are supported.
+// The HTTP URL is accepted as well, the same host is connected with the default SSH port in this case.
+func (a *SSHAuth) ListRemoteRefs(ctx context.Context, repoURL string) (refs *RemoteRefs, err error) {
+	var user, address, path string
+	if user, address, path, err = parseSSHURL(repoURL); err != nil {
+		return
+	}
+	if user == "" {
+		user = a.Username
+	}
+	if user == "" {
+		user = defaultSSHUser
+	}
+
+	var command string
+	if command, err = getUploadPackCommand(path); err != nil {
+		return
+	}
+
+	hostKeyCallback := a.HostKeyCallback
+	if hostKeyCallback == nil {
+		if !a.InsecureSkipHostKeyVerify {
+			err = ErrNoKnownHosts
+			return
+		}
+		// the same as StrictHostKeyChecking=no
+		hostKeyCallback = ssh.InsecureIgnoreHostKey()
+	}
+	timeout := a.Timeout
+	if timeout <= 0 {
+		timeout = defaultSSHTimeout
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	var conn net.Conn
+	if conn, err = (&net.Dialer{}).DialContext(ctx, "tcp", address); err != nil {
+		return
+	}
+	// make sure that the connection will be closed when the context is done
+	go func() {
+		<-ctx.Done()
+		_ = conn.Close()
+	}()
+
+	var sshConn ssh.Conn
+	var chans <-chan ssh.NewChannel
+	var reqs <-chan *ssh.Request
+	if sshConn, chans, reqs, err = ssh.NewClientConn(conn, address, &ssh.ClientConfig{
+		User:            user,
+		Auth:            []ssh.AuthMethod{ssh.PublicKeys(a.Signer)},
+		HostKeyCallback: hostKeyCallback,
+		Timeout:         timeout,
+	}); err != nil {
+		return
+	}
+	client := ssh.NewClient(sshConn, chans, reqs)
+	defer func() {
+		_ = client.Close()
+	}()
+
+	var session *ssh.Session
+	if session, err = client.NewSession(); err != nil {
+		return
+	}
+	defer func() {
+		_ = session.Close()
+	}()
+
+	var stdin io.WriteCloser
+	var stdout io.Reader
+	if stdin, err = session.StdinPipe(); err != nil {
+		return
+	}
+	if stdout, err = session.StdoutPipe(); err != nil {
+		return
+	}
+	stderr := &bytes.Buffer{}
+	session.Stderr = stderr
+
+	if err = session.Start(command); err != nil {
+		return
+	}
+
+	if refs, err = readRefAdvertisement(bufio.NewReader(stdout)); err != nil {
+		// wait for the error message from the server
+		_ = session.Wait()
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			err = fmt.Errorf("%v: %s", err, msg)
+		}
+		return
+	}
+	// tell the server that we do not want anything
+	_, _ = stdin.Write([]byte(flushPkt))
+	_ = stdin.Close()
+	return
+}
+
+// parseSSHURL parses the user, address (host:port) and the repository path from a SSH git URL
+func parseSSHURL(repoURL string) (user, address, path string, err error) {
+	host := ""
+	port := defaultSSHPort
+	if strings.HasPrefix(repoURL, "ssh://") {
+		var u *url.URL
+		if u, err = url.Parse(repoURL); err != nil {
+			return
+		}
+		user = u.User.Username()
+		host = u.Hostname()
+		if u.Port() != "" {
+			port = u.Port()
+		}
+		path = u.Path
+	} else if strings.HasPrefix(repoURL, "https://") || strings.HasPrefix(repoURL, "http://") {
+		// take the same host and path with the default SSH port
+		var u *url.URL
+		if u, err = url.Parse(repoURL); err != nil {
+			return
+		}
+		host = u.Hostname()
+		path = strings.TrimPrefix(u.Path, "/")
+	} else if !strings.Contains(repoURL, "://") && strings.Contains(repoURL, ":") {
+		// scp-like address, such as: git@github.com:owner/repo.git
+		items := strings.SplitN(repoURL, ":", 2)
+		host, path = items[0], items[1]
+		if index := strings.LastIndex(host, "@"); index >= 0 {
+			user, host = host[:index], host[index+1:]
+		}
+	}
+
+	if host == "" || strings.Trim(path, "/") == "" {
+		err = fmt.Errorf("invalid SSH git URL: %q", repoURL)
+		return
+	}
+	address = net.JoinHostPort(host, port)
+	return
+}
+
+// getUploadPackCommand returns the command of git-upload-pack, the repository path is quoted for the remote shell
+func getUploadPackCommand(path string) (command string, err error) {
+	if strings.IndexFunc(path, func(r rune) bool {
+		return r < 0x20 || r == 0x7f
+	}) >= 0 {
+		err = fmt.Errorf("invalid repository path: %q", path)
+		return
+	}
+	// the same as the quoting of git, see also sq_quote_buf in quote.c
+	command = "git-upload-pack '" + strings.ReplaceAll(path, "'", `'\''`) + "'"
+	return
+}
+
+const flushPkt = "0000"
+
+// readRefAdvertisement reads the reference advertisement of git-upload-pack, see also
+// https://git-scm.com/docs/pack-protocol#_reference_discovery
+func readRefAdvertisement(reader *bufio.Reader) (refs *RemoteRefs, err error) {
+	refs = &RemoteRefs{}
+	peeled := map[string]string{}
+	for first := true; ; first = false {
+		var line []byte
+		if line, err = readPktLine(reader); err != nil {
+			return
+		}
+		if line == nil {
+			// flush-pkt, the end of the advertisement
+			break
+		}
+
+		if first {
+			if index := bytes.IndexByte(line, 0); index >= 0 {
+				refs.HEAD = getSymRef(string(line[index+1:]), "HEAD")
+				line = line[:index]
+			}
+		}
+
+		fields := strings.Fields(string(line))
+		if len(fields) != 2 {
+			err = fmt.Errorf("invalid reference line: %q", string(line))
+			return
+		}
+		sha, name := fields[0], fields[1]
+		if name == "capabilities"+peeledRefSuffix {
+			// an empty repository
+			continue
+		}
+		if strings.HasSuffix(name, peeledRefSuffix) {
+			peeled[strings.TrimSuffix(name, peeledRefSuffix)] = sha
+			continue
+		}
+		refs.Refs = append(refs.Refs, RemoteRef{Name: name, SHA: sha})
+	}
+
+	// take the commit instead of the annotated tag object
+	for i := range refs.Refs {
+		if sha, ok := peeled[refs.Refs[i].Name]; ok {
+			refs.Refs[i].SHA = sha
+		}
+	}
+	return
+}
+
+// getSymRef returns the target of a symbolic reference from the capabilities, such as: symref=HEAD:refs/heads/master
+func getSymRef(capabilities, name string) string {
+	prefix := "symref=" + name + ":"
+	for _, capability := range strings.Fields(capabilities) {
+		if strings.HasPrefix(capability, prefix) {
+			return strings.TrimPrefix(capability, prefix)
+		}
+	}
+	return ""
+}
+
+// readPktLine reads a pkt-line, returns nil if it is a flush-pkt
+func readPktLine(reader *bufio.Reader) (line []byte, err error) {
+	header := make([]byte, 4)
+	if _, err = io.ReadFull(reader, header); err != nil {
+		return
+	}
+
+	var length int64
+	if length, err = strconv.ParseInt(string(header), 16, 32); err != nil {
+		err = fmt.Errorf("invalid pkt-line length: %q", string(header))
+		return
+	}
+	if length == 0 {
+		return
+	}
+	if length < 4 {
+		err = fmt.Errorf("invalid pkt-line length: %d", length)
+		return
+	}
+
+	line = make([]byte, length-4)
+	if _, err = io.ReadFull(reader, line); err == nil {
+		line = bytes.TrimSuffix(line, []byte("\n"))
+	}
+	return
+}
@@ -0,0 +1,563 @@
+/*
+Copyright 2022 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package git
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"kubesphere.io/devops/pkg/api/devops/v1alpha1"
+	"kubesphere.io/devops/pkg/api/devops/v1alpha3"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+var fakeAdvertisement = pktLines(
+	"7fd1a60b01f91b314f59955a4e4d4e80d8edf11d HEAD\x00multi_ack thin-pack side-band ofs-delta symref=HEAD:refs/heads/master agent=git/2.36.1\n",
+	"7fd1a60b01f91b314f59955a4e4d4e80d8edf11d refs/heads/master\n",
+	"553c2077f0edc3d5dc5d17262f6aa498e69d6f8e refs/heads/feature\n",
+	"c5b97d5ae6c19d5c5df71a34c7fbeeda2479ccbc refs/tags/v0.1\n",
+	"6dcb09b5b57875f334f61aebed695e2e4193db5e refs/tags/v0.1^{}\n")
+
+// pktLines formats the lines as pkt-lines which end with a flush-pkt
+func pktLines(lines ...string) string {
+	buf := &strings.Builder{}
+	for _, line := range lines {
+		buf.WriteString(fmt.Sprintf("%04x%s", len(line)+4, line))
+	}
+	buf.WriteString(flushPkt)
+	return buf.String()
+}
+
+// fakeSSHServer is a git server which only supports the reference discovery of git-upload-pack
+type fakeSSHServer struct {
+	listener      net.Listener
+	config        *ssh.ServerConfig
+	hostKey       ssh.Signer
+	advertisement string
+}
+
+func startFakeSSHServer(t *testing.T, authorized ssh.PublicKey, advertisement string) *fakeSSHServer {
+	hostKey, _ := generatePrivateKey(t)
+	server := &fakeSSHServer{
+		hostKey:       hostKey,
+		advertisement: advertisement,
+		config: &ssh.ServerConfig{
+			PublicKeyCallback: func(conn ssh.ConnMetadata, key ssh.PublicKey) (*ssh.Permissions, error) {
+				if conn.User() == "git" && bytes.Equal(key.Marshal(), authorized.Marshal()) {
+					return nil, nil
+				}
+				return nil, errors.New("unauthorized")
+			},
+		},
+	}
+	server.config.AddHostKey(hostKey)
+
+	var err error
+	server.listener, err = net.Listen("tcp", "127.0.0.1:0")
+	assert.Nil(t, err)
+	go server.serve()
+	t.Cleanup(func() {
+		_ = server.listener.Close()
+	})
+	return server
+}
+
+func (s *fakeSSHServer) address() string {
+	return s.listener.Addr().String()
+}
+
+func (s *fakeSSHServer) serve() {
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+		go s.handle(conn)
+	}
+}
+
+func (s *fakeSSHServer) handle(conn net.Conn) {
+	_, chans, reqs, err := ssh.NewServerConn(conn, s.config)
+	if err != nil {
+		return
+	}
+	go ssh.DiscardRequests(reqs)
+
+	for newChannel := range chans {
+		if newChannel.ChannelType() != "session" {
+			_ = newChannel.Reject(ssh.UnknownChannelType, "unknown channel type")
+			continue
+		}
+		channel, requests, err := newChannel.Accept()
+		if err != nil {
+			continue
+		}
+		go func() {
+			for req := range requests {
+				if req.Type != "exec" {
+					_ = req.Reply(false, nil)
+					continue
+				}
+				var payload struct{ Command string }
+				_ = ssh.Unmarshal(req.Payload, &payload)
+				_ = req.Reply(true, nil)
+
+				exitStatus := uint32(0)
+				// the leading slash of the repository path is optional
+				if strings.Replace(payload.Command, "'/", "'", 1) == "git-upload-pack 'owner/repo.git'" {
+					_, _ = channel.Write([]byte(s.advertisement))
+					// wait for the flush-pkt from the client
+					_, _ = readPktLine(bufio.NewReader(channel))
+				} else {
+					_, _ = channel.Stderr().Write([]byte("ERROR: Repository not found."))
+					exitStatus = 1
+				}
+				_, _ = channel.SendRequest("exit-status", false, ssh.Marshal(struct{ Status uint32 }{exitStatus}))
+				_ = channel.Close()
+			}
+		}()
+	}
+}
+
+func generatePrivateKey(t *testing.T) (signer ssh.Signer, privateKeyPEM []byte) {
+	privateKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.Nil(t, err)
+	privateKeyPEM = pem.EncodeToMemory(&pem.Block{
+		Type:  "RSA PRIVATE KEY",
+		Bytes: x509.MarshalPKCS1PrivateKey(privateKey),
+	})
+	signer, err = ssh.NewSignerFromKey(privateKey)
+	assert.Nil(t, err)
+	return
+}
+
+func TestSSHAuth_ListRemoteRefs(t *testing.T) {
+	signer, _ := generatePrivateKey(t)
+	unauthorizedSigner, _ := generatePrivateKey(t)
+	server := startFakeSSHServer(t, signer.PublicKey(), fakeAdvertisement)
+
+	repoURL := fmt.Sprintf("ssh://git@%s/owner/repo.git", server.address())
+
+	t.Run("list references", func(t *testing.T) {
+		auth := &SSHAuth{Signer: signer, InsecureSkipHostKeyVerify: true}
+		refs, err := auth.ListRemoteRefs(context.TODO(), repoURL)
+		assert.Nil(t, err)
+		assert.Equal(t, "refs/heads/master", refs.HEAD)
+
+		branch, ok := refs.DefaultBranch()
+		assert.True(t, ok)
+		assert.Equal(t, "master", branch.ShortName())
+		assert.Equal(t, "7fd1a60b01f91b314f59955a4e4d4e80d8edf11d", branch.SHA)
+
+		assert.Equal(t, []RemoteRef{
+			{Name: "refs/heads/master", SHA: "7fd1a60b01f91b314f59955a4e4d4e80d8edf11d"},
+			{Name: "refs/heads/feature", SHA: "553c2077f0edc3d5dc5d17262f6aa498e69d6f8e"},
+		}, refs.Branches())
+		// take the commit of the annotated tag
+		assert.Equal(t, []RemoteRef{
+			{Name: "refs/tags/v0.1", SHA: "6dcb09b5b57875f334f61aebed695e2e4193db5e"},
+		}, refs.Tags())
+	})
+
+	t.Run("verify the host key", func(t *testing.T) {
+		knownHosts := knownhosts.Line([]string{server.address()}, server.hostKey.PublicKey())
+		callback, err := newKnownHostsCallback([]byte(knownHosts))
+		assert.Nil(t, err)
+
+		auth := &SSHAuth{Signer: signer, HostKeyCallback: callback}
+		_, err = auth.ListRemoteRefs(context.TODO(), repoURL)
+		assert.Nil(t, err)
+
+		otherKey, _ := generatePrivateKey(t)
+		knownHosts = knownhosts.Line([]string{server.address()}, otherKey.PublicKey())
+		callback, err = newKnownHostsCallback([]byte(knownHosts))
+		assert.Nil(t, err)
+
+		auth = &SSHAuth{Signer: signer, HostKeyCallback: callback}
+		_, err = auth.ListRemoteRefs(context.TODO(), repoURL)
+		assert.NotNil(t, err)
+	})
+
+	t.Run("no known hosts", func(t *testing.T) {
+		auth := &SSHAuth{Signer: signer}
+		_, err := auth.ListRemoteRefs(context.TODO(), repoURL)
+		assert.Equal(t, ErrNoKnownHosts, err)
+	})
+
+	t.Run("repository path with a quote", func(t *testing.T) {
+		auth := &SSHAuth{Signer: signer, InsecureSkipHostKeyVerify: true}
+		_, err := auth.ListRemoteRefs(context.TODO(), fmt.Sprintf("ssh://git@%s/owner/repo.git';id;'", server.address()))
+		assert.NotNil(t, err)
+		assert.Contains(t, err.Error(), "Repository not found")
+	})
+
+	t.Run("repository not found", func(t *testing.T) {
+		auth := &SSHAuth{Signer: signer, InsecureSkipHostKeyVerify: true}
+		_, err := auth.ListRemoteRefs(context.TODO(), fmt.Sprintf("ssh://%s/owner/fake.git", server.address()))
+		assert.NotNil(t, err)
+		assert.Contains(t, err.Error(), "Repository not found")
+	})
+
+	t.Run("unauthorized", func(t *testing.T) {
+		auth := &SSHAuth{Signer: unauthorizedSigner, InsecureSkipHostKeyVerify: true}
+		_, err := auth.ListRemoteRefs(context.TODO(), repoURL)
+		assert.NotNil(t, err)
+		assert.Contains(t, err.Error(), "unable to authenticate")
+	})
+
+	t.Run("unreachable", func(t *testing.T) {
+		listener, err := net.Listen("tcp", "127.0.0.1:0")
+		assert.Nil(t, err)
+		address := listener.Addr().String()
+		_ = listener.Close()
+
+		auth := &SSHAuth{Signer: signer, InsecureSkipHostKeyVerify: true}
+		_, err = auth.ListRemoteRefs(context.TODO(), fmt.Sprintf("ssh://git@%s/owner/repo.git", address))
+		var netErr net.Error
+		assert.True(t, errors.As(err, &netErr))
+	})
+}
+
+func Test_newKnownHostsCallback(t *testing.T) {
+	hostSigner, _ := generatePrivateKey(t)
+	otherSigner, _ := generatePrivateKey(t)
+	hostKey, otherKey := hostSigner.PublicKey(), otherSigner.PublicKey()
+	remote := &net.TCPAddr{IP: net.ParseIP("10.0.0.1"), Port: 22}
+
+	tests := []struct {
+		name       string
+		knownHosts string
+		hostname   string
+		wantErr    bool
+	}{{
+		name:       "plain host name",
+		knownHosts: "# comment\n" + knownhosts.Line([]string{"github.com"}, hostKey),
+		hostname:   "github.com:22",
+	}, {
+		name:       "host with a non-default port",
+		knownHosts: knownhosts.Line([]string{"git.example.com:2222"}, hostKey),
+		hostname:   "git.example.com:2222",
+	}, {
+		name:       "the remote address",
+		knownHosts: knownhosts.Line([]string{"10.0.0.1"}, hostKey),
+		hostname:   "github.com:22",
+	}, {
+		name:       "hashed host name",
+		knownHosts: knownhosts.Line([]string{knownhosts.HashHostname("github.com")}, hostKey),
+		hostname:   "github.com:22",
+	}, {
+		name:       "wildcard",
+		knownHosts: knownhosts.Line([]string{"*.example.com"}, hostKey),
+		hostname:   "git.example.com:22",
+	}, {
+		name:       "negated pattern",
+		knownHosts: knownhosts.Line([]string{"*.example.com", "!git.example.com"}, hostKey),
+		hostname:   "git.example.com:22",
+		wantErr:    true,
+	}, {
+		name:       "unknown host",
+		knownHosts: knownhosts.Line([]string{"gitlab.com"}, hostKey),
+		hostname:   "github.com:22",
+		wantErr:    true,
+	}, {
+		name:       "mismatched key",
+		knownHosts: knownhosts.Line([]string{"github.com"}, otherKey),
+		hostname:   "github.com:22",
+		wantErr:    true,
+	}, {
+		name:       "one of the keys matches",
+		knownHosts: knownhosts.Line([]string{"github.com"}, otherKey) + "\n" + knownhosts.Line([]string{"github.com"}, hostKey),
+		hostname:   "github.com:22",
+	}, {
+		name: "revoked key",
+		knownHosts: "@revoked " + knownhosts.Line([]string{"*"}, hostKey) + "\n" +
+			knownhosts.Line([]string{"github.com"}, hostKey),
+		hostname: "github.com:22",
+		wantErr:  true,
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			callback, err := newKnownHostsCallback([]byte(tt.knownHosts))
+			assert.Nil(t, err)
+			err = callback(tt.hostname, remote, hostKey)
+			assert.Equal(t, tt.wantErr, err != nil, err)
+		})
+	}
+
+	t.Run("invalid known hosts", func(t *testing.T) {
+		_, err := newKnownHostsCallback([]byte("github.com invalid"))
+		assert.NotNil(t, err)
+	})
+}
+
+func Test_getUploadPackCommand(t *testing.T) {
+	tests := []struct {
+		name        string
+		path        string
+		wantCommand string
+		wantErr     bool
+	}{{
+		name:        "normal path",
+		path:        "/owner/repo.git",
+		wantCommand: "git-upload-pack '/owner/repo.git'",
+	}, {
+		name:        "path with quotes",
+		path:        "owner/repo.git';touch /tmp/x;'",
+		wantCommand: `git-upload-pack 'owner/repo.git'\'';touch /tmp/x;'\'''`,
+	}, {
+		name:    "path with a new line",
+		path:    "owner/repo.git\nid",
+		wantErr: true,
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			command, err := getUploadPackCommand(tt.path)
+			assert.Equal(t, tt.wantErr, err != nil, err)
+			assert.Equal(t, tt.wantCommand, command)
+		})
+	}
+}
+
+func Test_parseSSHURL(t *testing.T) {
+	tests := []struct {
+		name        string
+		repoURL     string
+		wantUser    string
+		wantAddress string
+		wantPath    string
+		wantErr     bool
+	}{{
+		name:        "scp-like address",
+		repoURL:     "git@github.com:owner/repo.git",
+		wantUser:    "git",
+		wantAddress: "github.com:22",
+		wantPath:    "owner/repo.git",
+	}, {
+		name:        "scp-like address without user",
+		repoURL:     "github.com:owner/repo.git",
+		wantAddress: "github.com:22",
+		wantPath:    "owner/repo.git",
+	}, {
+		name:        "SSH URL",
+		repoURL:     "ssh://user@gitlab.com:2222/group/repo.git",
+		wantUser:    "user",
+		wantAddress: "gitlab.com:2222",
+		wantPath:    "/group/repo.git",
+	}, {
+		name:        "HTTP URL",
+		repoURL:     "https://github.com/owner/repo.git",
+		wantAddress: "github.com:22",
+		wantPath:    "owner/repo.git",
+	}, {
+		name:    "no path",
+		repoURL: "ssh://github.com",
+		wantErr: true,
+	}, {
+		name:    "unknown protocol",
+		repoURL: "file:///tmp/repo",
+		wantErr: true,
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			user, address, path, err := parseSSHURL(tt.repoURL)
+			assert.Equal(t, tt.wantErr, err != nil, err)
+			assert.Equal(t, tt.wantUser, user)
+			assert.Equal(t, tt.wantAddress, address)
+			assert.Equal(t, tt.wantPath, path)
+		})
+	}
+}
+
+func Test_readRefAdvertisement(t *testing.T) {
+	tests := []struct {
+		name          string
+		advertisement string
+		wantRefs      *RemoteRefs
+		wantErr       bool
+	}{{
+		name:          "empty repository",
+		advertisement: pktLines("0000000000000000000000000000000000000000 capabilities^{}\x00agent=git\n"),
+		wantRefs:      &RemoteRefs{},
+	}, {
+		name:          "without capabilities",
+		advertisement: pktLines("7fd1a60b01f91b314f59955a4e4d4e80d8edf11d refs/heads/master\n"),
+		wantRefs: &RemoteRefs{Refs: []RemoteRef{
+			{Name: "refs/heads/master", SHA: "7fd1a60b01f91b314f59955a4e4d4e80d8edf11d"},
+		}},
+	}, {
+		name:          "invalid length",
+		advertisement: "xyz",
+		wantErr:       true,
+	}, {
+		name:          "invalid reference",
+		advertisement: pktLines("abcd\n"),
+		wantErr:       true,
+	}, {
+		name:          "no flush-pkt",
+		advertisement: "003f7fd1a60b01f91b314f59955a4e4d4e80d8edf11d refs/heads/master\n",
+		wantErr:       true,
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			refs, err := readRefAdvertisement(bufio.NewReader(strings.NewReader(tt.advertisement)))
+			assert.Equal(t, tt.wantErr, err != nil, err)
+			if !tt.wantErr {
+				assert.Equal(t, tt.wantRefs, refs)
+			}
+		})
+	}
+}
+
+func TestNewSSHAuthFromSecret(t *testing.T) {
+	_, privateKey := generatePrivateKey(t)
+	encryptedBlock, err := x509.EncryptPEMBlock(rand.Reader, "RSA PRIVATE KEY", mustDecodePEM(privateKey), []byte("passphrase"), x509.PEMCipherAES256) //nolint:staticcheck
+	assert.Nil(t, err)
+	encryptedKey := pem.EncodeToMemory(encryptedBlock)
+
+	tests := []struct {
+		name         string
+		secret       *v1.Secret
+		wantUsername string
+		wantInsecure bool
+		wantErr      bool
+	}{{
+		name: "kubernetes ssh-auth secret",
+		secret: &v1.Secret{
+			Type: v1.SecretTypeSSHAuth,
+			Data: map[string][]byte{v1.SSHAuthPrivateKey: privateKey},
+		},
+	}, {
+		name: "DevOps ssh-auth secret with passphrase",
+		secret: &v1.Secret{
+			Type: v1alpha3.SecretTypeSSHAuth,
+			Data: map[string][]byte{
+				v1alpha3.SSHAuthUsernameKey:   []byte("admin"),
+				v1alpha3.SSHAuthPrivateKey:    encryptedKey,
+				v1alpha3.SSHAuthPassphraseKey: []byte("passphrase"),
+			},
+		},
+		wantUsername: "admin",
+	}, {
+		name: "skip the host key verification",
+		secret: &v1.Secret{
+			Type: v1.SecretTypeSSHAuth,
+			Data: map[string][]byte{
+				v1.SSHAuthPrivateKey:            privateKey,
+				SSHInsecureSkipHostKeyVerifyKey: []byte("true"),
+			},
+		},
+		wantInsecure: true,
+	}, {
+		name: "wrong passphrase",
+		secret: &v1.Secret{
+			Type: v1alpha3.SecretTypeSSHAuth,
+			Data: map[string][]byte{
+				v1alpha3.SSHAuthPrivateKey:    encryptedKey,
+				v1alpha3.SSHAuthPassphraseKey: []byte("wrong"),
+			},
+		},
+		wantErr: true,
+	}, {
+		name: "invalid known hosts",
+		secret: &v1.Secret{
+			Type: v1.SecretTypeSSHAuth,
+			Data: map[string][]byte{
+				v1.SSHAuthPrivateKey: privateKey,
+				SSHKnownHostsKey:     []byte("invalid"),
+			},
+		},
+		wantErr: true,
+	}, {
+		name: "not a SSH auth secret",
+		secret: &v1.Secret{
+			Type: v1.SecretTypeBasicAuth,
+		},
+		wantErr: true,
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			auth, err := NewSSHAuthFromSecret(tt.secret)
+			assert.Equal(t, tt.wantErr, err != nil, err)
+			if !tt.wantErr {
+				assert.NotNil(t, auth.Signer)
+				assert.Equal(t, tt.wantUsername, auth.Username)
+				assert.Equal(t, tt.wantInsecure, auth.InsecureSkipHostKeyVerify)
+			}
+		})
+	}
+}
+
+func mustDecodePEM(data []byte) []byte {
+	block, _ := pem.Decode(data)
+	return block.Bytes
+}
+
+func TestClientFactory_GetSSHAuth(t *testing.T) {
+	schema, err := v1alpha1.SchemeBuilder.Register().Build()
+	assert.Nil(t, err)
+	err = v1.SchemeBuilder.AddToScheme(schema)
+	assert.Nil(t, err)
+
+	_, privateKey := generatePrivateKey(t)
+	sshSecret := &v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "ssh", Namespace: "ns"},
+		Type:       v1.SecretTypeSSHAuth,
+		Data:       map[string][]byte{v1.SSHAuthPrivateKey: privateKey},
+	}
+	tokenSecret := &v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "token", Namespace: "ns"},
+		Type:       v1.SecretTypeBasicAuth,
+		Data:       map[string][]byte{v1.BasicAuthPasswordKey: []byte("token")},
+	}
+	k8sClient := fake.NewClientBuilder().WithScheme(schema).WithObjects(sshSecret, tokenSecret).Build()
+
+	factory := NewClientFactory("github", &v1.SecretReference{Namespace: "ns", Name: "ssh"}, k8sClient)
+	auth, err := factory.GetSSHAuth()
+	assert.Nil(t, err)
+	assert.NotNil(t, auth)
+	_, err = factory.GetClient()
+	assert.True(t, errors.Is(err, ErrSSHAuth))
+
+	factory = NewClientFactory("github", &v1.SecretReference{Namespace: "ns", Name: "token"}, k8sClient)
+	auth, err = factory.GetSSHAuth()
+	assert.Nil(t, err)
+	assert.Nil(t, auth)
+
+	factory = NewClientFactory("github", nil, k8sClient)
+	auth, err = factory.GetSSHAuth()
+	assert.Nil(t, err)
+	assert.Nil(t, auth)
+
+	factory = NewClientFactory("github", &v1.SecretReference{Namespace: "ns", Name: "fake"}, k8sClient)
+	_, err = factory.GetSSHAuth()
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,104 @@
+/*
+Copyright 2022 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package git
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+
+	"kubesphere.io/devops/pkg/api/devops/v1alpha3"
+)
+
+// defaultPorts are the ports which could be omitted in the URLs
+var defaultPorts = map[string]string{
+	"http":    "80",
+	"https":   "443",
+	"ssh":     "22",
+	"git":     "9418",
+	"svn":     "3690",
+	"svn+ssh": "22",
+}
+
+// NormalizeURL normalizes the address of a repository, then the different addresses of the same repository are comparable.
+// The scheme, user info, default port, ".git" suffix and trailing slashes are removed, and it is case-insensitive.
+// For example, all of the following addresses are normalized as "github.com/owner/repo":
+//   https://github.com/Owner/Repo.git
+//   ssh://git@github.com:22/owner/repo
+//   git@github.com:owner/repo.git
+// It returns an empty string if the address is invalid.
+func NormalizeURL(repoURL string) string {
+	host, port, path := parseRepoURL(repoURL)
+	if host == "" || path == "" {
+		return ""
+	}
+	if port != "" {
+		host = net.JoinHostPort(host, port)
+	}
+	return strings.ToLower(host + "/" + path)
+}
+
+// GetRepoPath returns the path of a GitRepository, such as: owner/repo. The owner of Gitlab might contain the subgroups.
+// It takes the owner and repo of the spec, or the path of the address.
+func GetRepoPath(repo *v1alpha3.GitRepository) string {
+	if repo == nil {
+		return ""
+	}
+	if repo.Spec.Owner != "" && repo.Spec.Repo != "" {
+		return fmt.Sprintf("%s/%s", repo.Spec.Owner, strings.TrimPrefix(repo.Spec.Repo, repo.Spec.Owner+"/"))
+	}
+	if host, _, path := parseRepoURL(repo.Spec.URL); host != "" {
+		return path
+	}
+	return ""
+}
+
+// parseRepoURL parses the host, the non-default port and the path of a repository address
+func parseRepoURL(repoURL string) (host, port, path string) {
+	repoURL = strings.TrimSpace(repoURL)
+	if repoURL == "" {
+		return
+	}
+
+	if strings.Contains(repoURL, "://") {
+		u, err := url.Parse(repoURL)
+		if err != nil {
+			return
+		}
+		host, port, path = u.Hostname(), u.Port(), u.Path
+		if port == defaultPorts[strings.ToLower(u.Scheme)] {
+			port = ""
+		}
+	} else if items := strings.SplitN(repoURL, ":", 2); len(items) == 2 {
+		// scp-like address, such as: git@github.com:owner/repo.git
+		host, path = items[0], items[1]
+		if index := strings.LastIndex(host, "@"); index >= 0 {
+			host = host[index+1:]
+		}
+	} else {
+		// the address without a scheme, such as: github.com/owner/repo
+		items := strings.SplitN(repoURL, "/", 2)
+		host = items[0]
+		if len(items) == 2 {
+			path = items[1]
+		}
+	}
+
+	path = strings.TrimSuffix(strings.Trim(path, "/"), ".git")
+	return
+}
@@ -86,6 +86,8 @@ type Config struct {
 	SonarQubeOptions      *sonarqube.Options                 `json:"sonarqube,omitempty" yaml:"sonarQube,omitempty" mapstructure:"sonarqube"`
 	ArgoCDOption          *ArgoCDOption                      `json:"argocd,omitempty" yaml:"argocd,omitempty" mapstructure:"argocd"`
 	FluxCDOption          *FluxCDOption                      `json:"fluxcd,omitempty" yaml:"fluxcd,omitempty" mapstructure:"fluxcd"`
+	ConsoleOption         *ConsoleOption                     `json:"console,omitempty" yaml:"console,omitempty" mapstructure:"console"`
+	SCMOAuthOption        *SCMOAuthOption                    `json:"scmOAuth,omitempty" yaml:"scmOAuth,omitempty" mapstructure:"scmOAuth"`
 	AuthenticationOptions *authoptions.AuthenticationOptions `json:"authentication,omitempty" yaml:"authentication,omitempty" mapstructure:"authentication"`
 	AuthMode              AuthMode                           `json:"authMode,omitempty" yaml:"authMode,omitempty" mapstructure:"authMode"`
 	JWTSecret             string                             `json:"jwtSecret,omitempty" yaml:"jwtSecret,omitempty" mapstructure:"jwtSecret"`
@@ -101,6 +103,8 @@ func New() *Config {
 		AuthMode:          AuthModeToken,
 		ArgoCDOption:      &ArgoCDOption{},
 		FluxCDOption:      &FluxCDOption{},
+		ConsoleOption:     NewConsoleOption(),
+		SCMOAuthOption:    &SCMOAuthOption{},
 	}
 }
 
@@ -0,0 +1,91 @@
+/*
+Copyright 2022 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package config
+
+import (
+	"strings"
+
+	"github.com/spf13/pflag"
+)
+
+const (
+	// DefaultPipelineRunURLTemplate is the default console link of a PipelineRun which belongs to a plain Pipeline
+	DefaultPipelineRunURLTemplate = "{address}/{workspace}/clusters/{cluster}/devops/{namespace}/pipelines/{pipeline}/run/{run}/task-status"
+	// DefaultMultiBranchPipelineRunURLTemplate is the default console link of a PipelineRun which belongs to a multi-branch Pipeline
+	DefaultMultiBranchPipelineRunURLTemplate = "{address}/{workspace}/clusters/{cluster}/devops/{namespace}/pipelines/{pipeline}/branch/{branch}/run/{run}/task-status"
+)
+
+// ConsoleOption is the configuration of the console links, such as the details link of SCM commit statuses.
+// The templates support the following placeholders:
+// {address}, {workspace}, {cluster}, {namespace}, {pipeline}, {branch} and {run}
+type ConsoleOption struct {
+	PipelineRunURLTemplate            string `json:"pipelineRunURLTemplate,omitempty" yaml:"pipelineRunURLTemplate,omitempty" mapstructure:"pipelineRunURLTemplate"`
+	MultiBranchPipelineRunURLTemplate string `json:"multiBranchPipelineRunURLTemplate,omitempty" yaml:"multiBranchPipelineRunURLTemplate,omitempty" mapstructure:"multiBranchPipelineRunURLTemplate"`
+}
+
+// NewConsoleOption creates a ConsoleOption with the default templates
+func NewConsoleOption() *ConsoleOption {
+	return &ConsoleOption{
+		PipelineRunURLTemplate:            DefaultPipelineRunURLTemplate,
+		MultiBranchPipelineRunURLTemplate: DefaultMultiBranchPipelineRunURLTemplate,
+	}
+}
+
+// AddFlags adds the flags which related to the console
+func (o *ConsoleOption) AddFlags(fs *pflag.FlagSet) {
+	fs.StringVar(&o.PipelineRunURLTemplate, "console-pipelinerun-url-template", o.PipelineRunURLTemplate,
+		"The console link template of a PipelineRun")
+	fs.StringVar(&o.MultiBranchPipelineRunURLTemplate, "console-multi-branch-pipelinerun-url-template",
+		o.MultiBranchPipelineRunURLTemplate, "The console link template of a multi-branch PipelineRun")
+}
+
+// PipelineRunURLParams holds the values of the placeholders in a PipelineRun link template
+type PipelineRunURLParams struct {
+	Address   string
+	Workspace string
+	Cluster   string
+	Namespace string
+	Pipeline  string
+	Branch    string
+	Run       string
+}
+
+// GetPipelineRunURL renders the console link of a PipelineRun.
+// The template of the multi-branch Pipeline is used if the branch is not empty.
+// The branch name is expected to be encoded already, just like the branch name of Jenkins.
+func (o *ConsoleOption) GetPipelineRunURL(params PipelineRunURLParams) string {
+	tpl := o.PipelineRunURLTemplate
+	if tpl == "" {
+		tpl = DefaultPipelineRunURLTemplate
+	}
+	if params.Branch != "" {
+		tpl = o.MultiBranchPipelineRunURLTemplate
+		if tpl == "" {
+			tpl = DefaultMultiBranchPipelineRunURLTemplate
+		}
+	}
+
+	replacer := strings.NewReplacer(
+		"{address}", strings.TrimSuffix(params.Address, "/"),
+		"{workspace}", params.Workspace,
+		"{cluster}", params.Cluster,
+		"{namespace}", params.Namespace,
+		"{pipeline}", params.Pipeline,
+		"{branch}", params.Branch,
+		"{run}", params.Run)
+	return replacer.Replace(tpl)
+}
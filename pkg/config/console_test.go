@@ -0,0 +1,98 @@
+/*
+Copyright 2022 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package config
+
+import (
+	"testing"
+
+	"github.com/spf13/pflag"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConsoleOption_GetPipelineRunURL(t *testing.T) {
+	params := PipelineRunURLParams{
+		Address:   "https://ks.com/",
+		Workspace: "ws",
+		Cluster:   "host",
+		Namespace: "ns",
+		Pipeline:  "pipeline",
+		Run:       "run",
+	}
+	multiBranchParams := params
+	multiBranchParams.Branch = "feat%2Fa"
+
+	tests := []struct {
+		name   string
+		option *ConsoleOption
+		params PipelineRunURLParams
+		want   string
+	}{{
+		name:   "plain Pipeline with the default template",
+		option: NewConsoleOption(),
+		params: params,
+		want:   "https://ks.com/ws/clusters/host/devops/ns/pipelines/pipeline/run/run/task-status",
+	}, {
+		name:   "multi-branch Pipeline with the default template",
+		option: NewConsoleOption(),
+		params: multiBranchParams,
+		want:   "https://ks.com/ws/clusters/host/devops/ns/pipelines/pipeline/branch/feat%2Fa/run/run/task-status",
+	}, {
+		name:   "empty templates",
+		option: &ConsoleOption{},
+		params: multiBranchParams,
+		want:   "https://ks.com/ws/clusters/host/devops/ns/pipelines/pipeline/branch/feat%2Fa/run/run/task-status",
+	}, {
+		name: "plain Pipeline with a custom template",
+		option: &ConsoleOption{
+			PipelineRunURLTemplate: "{address}/{cluster}/{namespace}/{pipeline}/{run}",
+		},
+		params: params,
+		want:   "https://ks.com/host/ns/pipeline/run",
+	}, {
+		name: "multi-branch Pipeline with a custom template",
+		option: &ConsoleOption{
+			MultiBranchPipelineRunURLTemplate: "https://other.com/{workspace}/{pipeline}/{branch}/{run}",
+		},
+		params: multiBranchParams,
+		want:   "https://other.com/ws/pipeline/feat%2Fa/run",
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.option.GetPipelineRunURL(tt.params))
+		})
+	}
+}
+
+func TestConsoleOption_AddFlags(t *testing.T) {
+	option := NewConsoleOption()
+	fs := pflag.NewFlagSet("console", pflag.ContinueOnError)
+	option.AddFlags(fs)
+	assert.Equal(t, DefaultPipelineRunURLTemplate, option.PipelineRunURLTemplate)
+	assert.Equal(t, DefaultMultiBranchPipelineRunURLTemplate, option.MultiBranchPipelineRunURLTemplate)
+
+	err := fs.Parse([]string{"--console-pipelinerun-url-template", "{address}/{run}"})
+	assert.Nil(t, err)
+	assert.Equal(t, "{address}/{run}", option.PipelineRunURLTemplate)
+
+	// the values of the config file are the defaults of the flags
+	option = &ConsoleOption{MultiBranchPipelineRunURLTemplate: "{address}/{branch}/{run}"}
+	fs = pflag.NewFlagSet("console", pflag.ContinueOnError)
+	option.AddFlags(fs)
+	err = fs.Parse([]string{})
+	assert.Nil(t, err)
+	assert.Equal(t, "{address}/{branch}/{run}", option.MultiBranchPipelineRunURLTemplate)
+}
@@ -0,0 +1,138 @@
+/*
+Copyright 2022 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package config
+
+import (
+	"fmt"
+	"strings"
+
+	"golang.org/x/oauth2"
+)
+
+// scmOAuthEndpoint is the default OAuth2 endpoint of a git provider
+type scmOAuthEndpoint struct {
+	server    string
+	authPath  string
+	tokenPath string
+	scopes    []string
+}
+
+// scmOAuthEndpoints are the default OAuth2 endpoints of the supported git providers.
+// An empty server means that the address of a self-hosted git server is required.
+var scmOAuthEndpoints = map[string]scmOAuthEndpoint{
+	"github": {
+		server:    "https://github.com",
+		authPath:  "/login/oauth/authorize",
+		tokenPath: "/login/oauth/access_token",
+		scopes:    []string{"repo", "read:org", "admin:repo_hook"},
+	},
+	"gitlab": {
+		server:    "https://gitlab.com",
+		authPath:  "/oauth/authorize",
+		tokenPath: "/oauth/token",
+		scopes:    []string{"api"},
+	},
+	"bitbucket_cloud": {
+		server:    "https://bitbucket.org",
+		authPath:  "/site/oauth2/authorize",
+		tokenPath: "/site/oauth2/access_token",
+	},
+	"gitea": {
+		authPath:  "/login/oauth/authorize",
+		tokenPath: "/login/oauth/access_token",
+	},
+}
+
+// SCMOAuthOption is the configuration of the OAuth2 applications of the git providers.
+// Users could connect their SCM accounts through the applications instead of creating the access tokens by hand.
+type SCMOAuthOption struct {
+	Servers []SCMOAuthServer `json:"servers,omitempty" yaml:"servers,omitempty" mapstructure:"servers"`
+}
+
+// SCMOAuthServer is the OAuth2 application of a git server
+type SCMOAuthServer struct {
+	// Provider is the type of the git provider, the supported ones are: github, gitlab, bitbucket_cloud and gitea
+	Provider string `json:"provider" yaml:"provider" mapstructure:"provider"`
+	// Server is the address of a self-hosted git server, the public service of the provider is used if it is empty
+	Server       string `json:"server,omitempty" yaml:"server,omitempty" mapstructure:"server"`
+	ClientID     string `json:"clientID" yaml:"clientID" mapstructure:"clientID"`
+	ClientSecret string `json:"clientSecret" yaml:"clientSecret" mapstructure:"clientSecret"`
+	// RedirectURL is the callback address which is registered in the OAuth2 application, such as:
+	// https://ks.example.com/kapis/devops.kubesphere.io/v1alpha3/scms/github/oauth/callback
+	RedirectURL string `json:"redirectURL" yaml:"redirectURL" mapstructure:"redirectURL"`
+	// Scopes overrides the default scopes of the provider
+	Scopes []string `json:"scopes,omitempty" yaml:"scopes,omitempty" mapstructure:"scopes"`
+	// AuthURL and TokenURL override the default endpoints of the provider
+	AuthURL  string `json:"authURL,omitempty" yaml:"authURL,omitempty" mapstructure:"authURL"`
+	TokenURL string `json:"tokenURL,omitempty" yaml:"tokenURL,omitempty" mapstructure:"tokenURL"`
+}
+
+// GetServer returns the OAuth2 application of the git server, returns nil if it was not configured
+func (o *SCMOAuthOption) GetServer(provider, server string) *SCMOAuthServer {
+	if o == nil {
+		return nil
+	}
+	server = strings.TrimSuffix(server, "/")
+	for i := range o.Servers {
+		item := &o.Servers[i]
+		if item.Provider == provider && strings.TrimSuffix(item.Server, "/") == server {
+			return item
+		}
+	}
+	return nil
+}
+
+// GetOAuth2Config returns the OAuth2 config of the application
+func (s *SCMOAuthServer) GetOAuth2Config() (config *oauth2.Config, err error) {
+	endpoint, ok := scmOAuthEndpoints[s.Provider]
+	if !ok {
+		err = fmt.Errorf("git provider %q does not support OAuth2", s.Provider)
+		return
+	}
+
+	server := strings.TrimSuffix(s.Server, "/")
+	if server == "" {
+		server = endpoint.server
+	}
+	authURL, tokenURL := s.AuthURL, s.TokenURL
+	if server == "" && (authURL == "" || tokenURL == "") {
+		err = fmt.Errorf("the server address of git provider %q is required", s.Provider)
+		return
+	}
+	if authURL == "" {
+		authURL = server + endpoint.authPath
+	}
+	if tokenURL == "" {
+		tokenURL = server + endpoint.tokenPath
+	}
+
+	scopes := s.Scopes
+	if len(scopes) == 0 {
+		scopes = endpoint.scopes
+	}
+	config = &oauth2.Config{
+		ClientID:     s.ClientID,
+		ClientSecret: s.ClientSecret,
+		RedirectURL:  s.RedirectURL,
+		Scopes:       scopes,
+		Endpoint: oauth2.Endpoint{
+			AuthURL:  authURL,
+			TokenURL: tokenURL,
+		},
+	}
+	return
+}
@@ -0,0 +1,101 @@
+/*
+Copyright 2022 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/oauth2"
+)
+
+func TestSCMOAuthOption_GetServer(t *testing.T) {
+	option := &SCMOAuthOption{Servers: []SCMOAuthServer{{
+		Provider: "github",
+		ClientID: "public",
+	}, {
+		Provider: "github",
+		Server:   "https://ghe.com/",
+		ClientID: "enterprise",
+	}}}
+
+	assert.Equal(t, "public", option.GetServer("github", "").ClientID)
+	assert.Equal(t, "enterprise", option.GetServer("github", "https://ghe.com").ClientID)
+	assert.Nil(t, option.GetServer("gitlab", ""))
+	assert.Nil(t, option.GetServer("github", "https://other.com"))
+
+	var nilOption *SCMOAuthOption
+	assert.Nil(t, nilOption.GetServer("github", ""))
+}
+
+func TestSCMOAuthServer_GetOAuth2Config(t *testing.T) {
+	tests := []struct {
+		name      string
+		server    SCMOAuthServer
+		want      *oauth2.Config
+		wantError bool
+	}{{
+		name:   "GitHub with the default endpoint",
+		server: SCMOAuthServer{Provider: "github", ClientID: "id", ClientSecret: "secret", RedirectURL: "https://ks.com/callback"},
+		want: &oauth2.Config{
+			ClientID: "id", ClientSecret: "secret", RedirectURL: "https://ks.com/callback",
+			Scopes: []string{"repo", "read:org", "admin:repo_hook"},
+			Endpoint: oauth2.Endpoint{
+				AuthURL:  "https://github.com/login/oauth/authorize",
+				TokenURL: "https://github.com/login/oauth/access_token",
+			},
+		},
+	}, {
+		name:   "self-hosted GitLab with custom scopes",
+		server: SCMOAuthServer{Provider: "gitlab", Server: "https://gitlab.ks.com/", Scopes: []string{"read_api"}},
+		want: &oauth2.Config{
+			Scopes: []string{"read_api"},
+			Endpoint: oauth2.Endpoint{
+				AuthURL:  "https://gitlab.ks.com/oauth/authorize",
+				TokenURL: "https://gitlab.ks.com/oauth/token",
+			},
+		},
+	}, {
+		name:   "Gitea with custom endpoints",
+		server: SCMOAuthServer{Provider: "gitea", AuthURL: "https://gitea.com/auth", TokenURL: "https://gitea.com/token"},
+		want: &oauth2.Config{
+			Endpoint: oauth2.Endpoint{
+				AuthURL:  "https://gitea.com/auth",
+				TokenURL: "https://gitea.com/token",
+			},
+		},
+	}, {
+		name:      "Gitea without the server address",
+		server:    SCMOAuthServer{Provider: "gitea"},
+		wantError: true,
+	}, {
+		name:      "not supported provider",
+		server:    SCMOAuthServer{Provider: "bitbucket-server"},
+		wantError: true,
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config, err := tt.server.GetOAuth2Config()
+			if tt.wantError {
+				assert.NotNil(t, err)
+			} else {
+				assert.Nil(t, err)
+				assert.Equal(t, tt.want, config)
+			}
+		})
+	}
+}
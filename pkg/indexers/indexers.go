@@ -21,6 +21,9 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
 	"kubesphere.io/devops/pkg/api/devops/v1alpha3"
+	"kubesphere.io/devops/pkg/client/git"
+	modelpipeline "kubesphere.io/devops/pkg/models/pipeline"
+	"kubesphere.io/devops/pkg/utils/sliceutil"
 	"sigs.k8s.io/controller-runtime/pkg/cache"
 )
 
@@ -59,3 +62,110 @@ func extractPipelineRunIdentifier(o client.Object) []string {
 	}
 	return []string{pipelineRun.GetPipelineRunIdentifier()}
 }
+
+// CreatePipelineRepoURLIndexer creates an indexer which aims for locating Pipelines with the normalized address of a repository.
+// See also git.NormalizeURL.
+func CreatePipelineRepoURLIndexer(runtimeCache cache.Cache) error {
+	return runtimeCache.IndexField(context.Background(),
+		&v1alpha3.Pipeline{},
+		v1alpha3.PipelineRepoURLIndexerName,
+		extractPipelineRepoURL)
+}
+
+func extractPipelineRepoURL(o client.Object) []string {
+	pipeline, ok := o.(*v1alpha3.Pipeline)
+	if !ok || pipeline == nil {
+		return []string{}
+	}
+	if repoURL := GetPipelineRepoURL(pipeline); repoURL != "" {
+		return []string{repoURL}
+	}
+	return []string{}
+}
+
+// GetPipelineRepoURL returns the normalized repository address of a Pipeline, returns an empty string if there is no repository
+func GetPipelineRepoURL(pipeline *v1alpha3.Pipeline) string {
+	var repoURL string
+	if pipeline.IsMultiBranch() {
+		if pipeline.Spec.MultiBranchPipeline != nil {
+			repoURL = pipeline.Spec.MultiBranchPipeline.GetRepoURL()
+		}
+	} else {
+		repoURL = pipeline.GetAnnotations()[v1alpha3.PipelineSCMAnnoKey]
+	}
+	return git.NormalizeURL(repoURL)
+}
+
+// CreateGitRepositoryURLIndexer creates an indexer which aims for locating GitRepositories with the normalized address.
+// See also git.NormalizeURL.
+func CreateGitRepositoryURLIndexer(runtimeCache cache.Cache) error {
+	return runtimeCache.IndexField(context.Background(),
+		&v1alpha3.GitRepository{},
+		v1alpha3.GitRepositoryURLIndexerName,
+		extractGitRepositoryURL)
+}
+
+func extractGitRepositoryURL(o client.Object) []string {
+	repo, ok := o.(*v1alpha3.GitRepository)
+	if !ok || repo == nil {
+		return []string{}
+	}
+	if repoURL := git.NormalizeURL(repo.Spec.URL); repoURL != "" {
+		return []string{repoURL}
+	}
+	return []string{}
+}
+
+// CreatePipelineGenericWebhookTokenIndexer creates an indexer which aims for locating Pipelines with the token of the generic webhook.
+func CreatePipelineGenericWebhookTokenIndexer(runtimeCache cache.Cache) error {
+	return runtimeCache.IndexField(context.Background(),
+		&v1alpha3.Pipeline{},
+		v1alpha3.PipelineGenericWebhookTokenIndexerName,
+		extractPipelineGenericWebhookToken)
+}
+
+func extractPipelineGenericWebhookToken(o client.Object) []string {
+	pipeline, ok := o.(*v1alpha3.Pipeline)
+	if !ok || pipeline == nil {
+		return []string{}
+	}
+	if webhookToken := GetPipelineGenericWebhookToken(pipeline); webhookToken != "" {
+		return []string{webhookToken}
+	}
+	return []string{}
+}
+
+// GetPipelineGenericWebhookToken returns the token of the enabled generic webhook, returns an empty string if there is no such webhook
+func GetPipelineGenericWebhookToken(pipeline *v1alpha3.Pipeline) string {
+	if pipeline.Spec.Pipeline == nil || pipeline.Spec.Pipeline.GenericWebhook == nil ||
+		!pipeline.Spec.Pipeline.GenericWebhook.Enable {
+		return ""
+	}
+	return pipeline.Spec.Pipeline.GenericWebhook.Token
+}
+
+// CreatePipelineCredentialIDIndexer creates an indexer which aims for locating Pipelines with the ID of a referenced credential.
+// See also modelpipeline.ExtractCredentialReferences.
+func CreatePipelineCredentialIDIndexer(runtimeCache cache.Cache) error {
+	return runtimeCache.IndexField(context.Background(),
+		&v1alpha3.Pipeline{},
+		v1alpha3.PipelineCredentialIDIndexerName,
+		extractPipelineCredentialIDs)
+}
+
+func extractPipelineCredentialIDs(o client.Object) []string {
+	pipeline, ok := o.(*v1alpha3.Pipeline)
+	if !ok || pipeline == nil {
+		return []string{}
+	}
+	return GetPipelineCredentialIDs(pipeline)
+}
+
+// GetPipelineCredentialIDs returns the distinct IDs of the credentials which are referenced by the Pipeline
+func GetPipelineCredentialIDs(pipeline *v1alpha3.Pipeline) []string {
+	ids := []string{}
+	for _, reference := range modelpipeline.ExtractCredentialReferences(pipeline) {
+		ids = sliceutil.AddToSlice(reference.ID, ids)
+	}
+	return ids
+}
@@ -150,3 +150,188 @@ func Test_extractPipelineRunIdentifier(t *testing.T) {
 		})
 	}
 }
+
+func TestCreatePipelineRepoURLIndexer(t *testing.T) {
+	if err := CreatePipelineRepoURLIndexer(&informertest.FakeInformers{}); err != nil {
+		t.Errorf("CreatePipelineRepoURLIndexer() error = %v", err)
+	}
+}
+
+func Test_extractPipelineRepoURL(t *testing.T) {
+	tests := []struct {
+		name string
+		o    client.Object
+		want []string
+	}{{
+		name: "not expect kind",
+		o:    &v1.ConfigMap{},
+		want: []string{},
+	}, {
+		name: "pipeline without any repository",
+		o:    &v1alpha3.Pipeline{},
+		want: []string{},
+	}, {
+		name: "pipeline with the SCM annotation",
+		o: &v1alpha3.Pipeline{
+			ObjectMeta: v12.ObjectMeta{
+				Annotations: map[string]string{
+					v1alpha3.PipelineSCMAnnoKey: "https://github.com/LinuxSuRen/tools.git",
+				},
+			},
+		},
+		want: []string{"github.com/linuxsuren/tools"},
+	}, {
+		name: "multi-branch pipeline with a self-hosted GitLab",
+		o: &v1alpha3.Pipeline{
+			Spec: v1alpha3.PipelineSpec{
+				Type: v1alpha3.MultiBranchPipelineType,
+				MultiBranchPipeline: &v1alpha3.MultiBranchPipeline{
+					SourceType: v1alpha3.SourceTypeGitlab,
+					GitlabSource: &v1alpha3.GitlabSource{
+						Owner:  "devops",
+						Repo:   "tools",
+						ApiUri: "https://gitlab.example.com:8443",
+					},
+				},
+			},
+		},
+		want: []string{"gitlab.example.com:8443/devops/tools"},
+	}, {
+		name: "multi-branch pipeline with a SVN repository",
+		o: &v1alpha3.Pipeline{
+			Spec: v1alpha3.PipelineSpec{
+				Type: v1alpha3.MultiBranchPipelineType,
+				MultiBranchPipeline: &v1alpha3.MultiBranchPipeline{
+					SourceType:      v1alpha3.SourceTypeSingleSVN,
+					SingleSvnSource: &v1alpha3.SingleSvnSource{Remote: "svn://svn.example.com/repo/trunk"},
+				},
+			},
+		},
+		want: []string{"svn.example.com/repo/trunk"},
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := extractPipelineRepoURL(tt.o); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("extractPipelineRepoURL() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCreatePipelineGenericWebhookTokenIndexer(t *testing.T) {
+	if err := CreatePipelineGenericWebhookTokenIndexer(&informertest.FakeInformers{}); err != nil {
+		t.Errorf("CreatePipelineGenericWebhookTokenIndexer() error = %v", err)
+	}
+}
+
+func Test_extractPipelineGenericWebhookToken(t *testing.T) {
+	tests := []struct {
+		name string
+		o    client.Object
+		want []string
+	}{{
+		name: "not expect kind",
+		o:    &v1.ConfigMap{},
+		want: []string{},
+	}, {
+		name: "pipeline without the generic webhook",
+		o:    &v1alpha3.Pipeline{Spec: v1alpha3.PipelineSpec{Pipeline: &v1alpha3.NoScmPipeline{}}},
+		want: []string{},
+	}, {
+		name: "disabled generic webhook",
+		o: &v1alpha3.Pipeline{Spec: v1alpha3.PipelineSpec{Pipeline: &v1alpha3.NoScmPipeline{
+			GenericWebhook: &v1alpha3.GenericWebhook{Token: "token"},
+		}}},
+		want: []string{},
+	}, {
+		name: "enabled generic webhook",
+		o: &v1alpha3.Pipeline{Spec: v1alpha3.PipelineSpec{Pipeline: &v1alpha3.NoScmPipeline{
+			GenericWebhook: &v1alpha3.GenericWebhook{Enable: true, Token: "token"},
+		}}},
+		want: []string{"token"},
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := extractPipelineGenericWebhookToken(tt.o); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("extractPipelineGenericWebhookToken() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCreateGitRepositoryURLIndexer(t *testing.T) {
+	if err := CreateGitRepositoryURLIndexer(&informertest.FakeInformers{}); err != nil {
+		t.Errorf("CreateGitRepositoryURLIndexer() error = %v", err)
+	}
+}
+
+func Test_extractGitRepositoryURL(t *testing.T) {
+	tests := []struct {
+		name string
+		o    client.Object
+		want []string
+	}{{
+		name: "not expect kind",
+		o:    &v1.ConfigMap{},
+		want: []string{},
+	}, {
+		name: "repository without address",
+		o:    &v1alpha3.GitRepository{},
+		want: []string{},
+	}, {
+		name: "repository with the SSH address",
+		o: &v1alpha3.GitRepository{
+			Spec: v1alpha3.GitRepositorySpec{URL: "git@github.com:LinuxSuRen/tools.git"},
+		},
+		want: []string{"github.com/linuxsuren/tools"},
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := extractGitRepositoryURL(tt.o); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("extractGitRepositoryURL() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCreatePipelineCredentialIDIndexer(t *testing.T) {
+	if err := CreatePipelineCredentialIDIndexer(&informertest.FakeInformers{}); err != nil {
+		t.Errorf("CreatePipelineCredentialIDIndexer() error = %v", err)
+	}
+}
+
+func Test_extractPipelineCredentialIDs(t *testing.T) {
+	tests := []struct {
+		name string
+		o    client.Object
+		want []string
+	}{{
+		name: "not expect kind",
+		o:    &v1.ConfigMap{},
+		want: []string{},
+	}, {
+		name: "pipeline without credentials",
+		o:    &v1alpha3.Pipeline{Spec: v1alpha3.PipelineSpec{Pipeline: &v1alpha3.NoScmPipeline{Jenkinsfile: "echo 1"}}},
+		want: []string{},
+	}, {
+		name: "the same credential is referenced twice",
+		o: &v1alpha3.Pipeline{Spec: v1alpha3.PipelineSpec{Pipeline: &v1alpha3.NoScmPipeline{
+			Jenkinsfile: `environment { A = credentials('a'); B = credentials('b'); C = credentials('a') }`,
+		}}},
+		want: []string{"a", "b"},
+	}, {
+		name: "the credential of the SCM source",
+		o: &v1alpha3.Pipeline{Spec: v1alpha3.PipelineSpec{MultiBranchPipeline: &v1alpha3.MultiBranchPipeline{
+			SourceType: v1alpha3.SourceTypeGit,
+			GitSource:  &v1alpha3.GitSource{CredentialId: "git"},
+		}}},
+		want: []string{"git"},
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := extractPipelineCredentialIDs(tt.o); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("extractPipelineCredentialIDs() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,73 @@
+/*
+Copyright 2022 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package jenkinsfile
+
+import (
+	"github.com/jenkins-zh/jenkins-client/pkg/core"
+)
+
+// Converter converts a Pipeline between the JSON and Jenkinsfile formats. core.Client implements it by Jenkins.
+type Converter interface {
+	ToJSON(jenkinsfile string) (core.GenericResult, error)
+	ToJenkinsfile(json string) (core.GenericResult, error)
+}
+
+// NewConverter returns a converter which converts without Jenkins first. The fallback converter is used
+// if the native conversion fails, such as the unsupported constructs or the scripted Pipelines.
+func NewConverter(fallback Converter) Converter {
+	return &nativeConverter{fallback: fallback}
+}
+
+type nativeConverter struct {
+	fallback Converter
+}
+
+// ToJSON converts a Jenkinsfile to JSON
+func (c *nativeConverter) ToJSON(jenkinsfile string) (core.GenericResult, error) {
+	if data, err := ToJSON(jenkinsfile); err == nil {
+		return &result{value: data}, nil
+	}
+	return c.fallback.ToJSON(jenkinsfile)
+}
+
+// ToJenkinsfile converts JSON to a Jenkinsfile
+func (c *nativeConverter) ToJenkinsfile(json string) (core.GenericResult, error) {
+	if jenkinsfile, err := ToJenkinsfile(json); err == nil {
+		return &result{value: jenkinsfile}, nil
+	}
+	return c.fallback.ToJenkinsfile(json)
+}
+
+// result is a successful result of the native converter
+type result struct {
+	value string
+}
+
+// GetResult returns the converted data
+func (r *result) GetResult() string {
+	return r.value
+}
+
+// GetErrors returns nothing, the failed conversions are done by the fallback converter
+func (r *result) GetErrors() []interface{} {
+	return nil
+}
+
+// GetStatus returns the status like Jenkins does
+func (r *result) GetStatus() string {
+	return "success"
+}
@@ -0,0 +1,195 @@
+/*
+Copyright 2022 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package jenkinsfile
+
+import (
+	"errors"
+	"os"
+	"testing"
+
+	"github.com/jenkins-zh/jenkins-client/pkg/core"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConvert_golden(t *testing.T) {
+	for _, name := range []string{"simple", "full"} {
+		t.Run(name, func(t *testing.T) {
+			jenkinsfile, err := os.ReadFile("testdata/" + name + ".jenkinsfile")
+			assert.Nil(t, err)
+			data, err := os.ReadFile("testdata/" + name + ".json")
+			assert.Nil(t, err)
+
+			result, err := ToJSON(string(jenkinsfile))
+			assert.Nil(t, err)
+			assert.JSONEq(t, string(data), result)
+
+			result, err = ToJenkinsfile(string(data))
+			assert.Nil(t, err)
+			assert.Equal(t, string(jenkinsfile), result)
+		})
+	}
+}
+
+func TestToJSON(t *testing.T) {
+	tests := []struct {
+		name        string
+		jenkinsfile string
+		want        string
+		unsupported bool
+	}{{
+		name:        "the positional arguments are named",
+		jenkinsfile: "pipeline {\n  agent none\n  stages {\n    stage('a') {\n      steps { sh 'make'; echo \"${A}\"; mail(to: 'a') }\n    }\n  }\n}",
+		want: `{"pipeline":{"agent":{"type":"none"},"stages":[{"name":"a","branches":[{"name":"default","steps":[
+{"name":"sh","arguments":[{"key":"script","value":{"isLiteral":true,"value":"make"}}]},
+{"name":"echo","arguments":[{"key":"message","value":{"isLiteral":false,"value":"\"${A}\""}}]},
+{"name":"mail","arguments":[{"key":"to","value":{"isLiteral":true,"value":"a"}}]}]}]}]}}`,
+	}, {
+		name:        "agent with a single argument",
+		jenkinsfile: "pipeline {\n  agent { label 'go' }\n  stages {\n  }\n}",
+		want:        `{"pipeline":{"agent":{"type":"label","argument":{"isLiteral":true,"value":"go"}},"stages":[]}}`,
+	}, {
+		name:        "scripted Pipeline",
+		jenkinsfile: "node {\n  sh 'make'\n}",
+		unsupported: true,
+	}, {
+		name:        "shared library",
+		jenkinsfile: "@Library('lib') _\npipeline {\n  agent any\n}",
+		unsupported: true,
+	}, {
+		name:        "matrix",
+		jenkinsfile: "pipeline {\n  agent any\n  stages {\n    stage('a') {\n      matrix {}\n    }\n  }\n}",
+		unsupported: true,
+	}, {
+		name:        "Groovy statements out of a script block",
+		jenkinsfile: "pipeline {\n  agent any\n  stages {\n    stage('a') {\n      steps {\n        def a = 1\n      }\n    }\n  }\n}",
+		unsupported: true,
+	}, {
+		name:        "assignment",
+		jenkinsfile: "pipeline {\n  agent any\n  stages {\n    stage('a') {\n      steps {\n        env.A = '1'\n      }\n    }\n  }\n}",
+		unsupported: true,
+	}, {
+		name:        "interpolated stage name",
+		jenkinsfile: "pipeline {\n  agent any\n  stages {\n    stage(\"${A}\") {\n      steps { sh 'make' }\n    }\n  }\n}",
+		unsupported: true,
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ToJSON(tt.jenkinsfile)
+			if tt.unsupported {
+				assert.True(t, errors.Is(err, ErrUnsupported), err)
+				return
+			}
+			assert.Nil(t, err)
+			assert.JSONEq(t, tt.want, got)
+		})
+	}
+}
+
+func TestToJenkinsfile(t *testing.T) {
+	tests := []struct {
+		name        string
+		json        string
+		want        string
+		unsupported bool
+	}{{
+		name: "the default arguments are positional",
+		json: `{"pipeline":{"agent":{"type":"any"},"stages":[{"name":"it's","branches":[{"name":"default","steps":[
+{"name":"sh","arguments":[{"key":"script","value":{"isLiteral":true,"value":"make"}}]},
+{"name":"sh","arguments":[{"key":"script","value":{"isLiteral":true,"value":"make"}},{"key":"returnStatus","value":{"isLiteral":true,"value":true}}]},
+{"name":"timestamps","arguments":[],"children":[{"name":"sleep","arguments":{"isLiteral":true,"value":1.5}}]}]}]}]}}`,
+		want: `pipeline {
+  agent any
+  stages {
+    stage('it\'s') {
+      steps {
+        sh 'make'
+        sh(script: 'make', returnStatus: true)
+        timestamps {
+          sleep 1.5
+        }
+      }
+    }
+  }
+}
+`,
+	}, {
+		name:        "invalid JSON",
+		json:        "json",
+		unsupported: true,
+	}, {
+		name:        "missing pipeline",
+		json:        "{}",
+		unsupported: true,
+	}, {
+		name:        "unknown fields",
+		json:        `{"pipeline":{"stages":[{"name":"a","matrix":{}}]}}`,
+		unsupported: true,
+	}, {
+		name:        "literal object",
+		json:        `{"pipeline":{"stages":[],"environment":[{"key":"A","value":{"isLiteral":true,"value":{}}}]}}`,
+		unsupported: true,
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ToJenkinsfile(tt.json)
+			if tt.unsupported {
+				assert.True(t, errors.Is(err, ErrUnsupported), err)
+				return
+			}
+			assert.Nil(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+type fakeConverter struct {
+	calls int
+}
+
+func (c *fakeConverter) ToJSON(jenkinsfile string) (core.GenericResult, error) {
+	c.calls++
+	return &core.JSONResult{Result: "success", JSON: map[string]interface{}{"a": "b"}}, nil
+}
+
+func (c *fakeConverter) ToJenkinsfile(json string) (core.GenericResult, error) {
+	c.calls++
+	return &core.JenkinsfileResult{Result: "success", Jenkinsfile: "jenkinsfile"}, nil
+}
+
+func TestNewConverter(t *testing.T) {
+	fallback := &fakeConverter{}
+	converter := NewConverter(fallback)
+
+	result, err := converter.ToJSON("pipeline {\n  agent any\n  stages {\n  }\n}")
+	assert.Nil(t, err)
+	assert.Equal(t, "success", result.GetStatus())
+	assert.Equal(t, `{"pipeline":{"stages":[],"agent":{"type":"any"}}}`, result.GetResult())
+	assert.Empty(t, result.GetErrors())
+
+	result, err = converter.ToJenkinsfile(`{"pipeline":{"stages":[],"agent":{"type":"any"}}}`)
+	assert.Nil(t, err)
+	assert.Equal(t, "pipeline {\n  agent any\n  stages {\n  }\n}\n", result.GetResult())
+	assert.Equal(t, 0, fallback.calls)
+
+	result, err = converter.ToJSON("node {\n}")
+	assert.Nil(t, err)
+	assert.Equal(t, `{"a":"b"}`, result.GetResult())
+	result, err = converter.ToJenkinsfile("json")
+	assert.Nil(t, err)
+	assert.Equal(t, "jenkinsfile", result.GetResult())
+	assert.Equal(t, 2, fallback.calls)
+}
@@ -0,0 +1,306 @@
+/*
+Copyright 2022 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package jenkinsfile
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+)
+
+var (
+	// pipelineSections are the sections of the pipeline block
+	pipelineSections = newSet("agent", "environment", "libraries", "options", "parameters", "post", "stages",
+		"tools", "triggers")
+	// stageSections are the sections of a stage
+	stageSections = newSet("agent", "environment", "failFast", "input", "matrix", "options", "parallel", "post",
+		"stages", "steps", "tools", "when")
+	// stageBodies are the sections of a stage which define what to execute, a stage must have one of them
+	stageBodies = []string{"steps", "stages", "parallel", "matrix"}
+	// matrixSections are the sections of a matrix
+	matrixSections = newSet("agent", "axes", "environment", "excludes", "input", "options", "post", "stages",
+		"tools", "when")
+	// agentTypes are the types of the agent block
+	agentTypes = newSet("docker", "dockerfile", "kubernetes", "label", "node")
+	// whenConditions are the built-in conditions of the when section
+	whenConditions = newSet("allOf", "anyOf", "branch", "buildingTag", "changelog", "changeRequest", "changeset",
+		"environment", "equals", "expression", "isRestartedRun", "not", "tag", "triggeredBy")
+	// whenOptions are the options of the when section which are not conditions
+	whenOptions = newSet("beforeAgent", "beforeInput", "beforeOptions")
+	// postConditions are the conditions of the post section
+	postConditions = newSet("always", "changed", "fixed", "regression", "aborted", "failure", "success",
+		"unstable", "unsuccessful", "cleanup")
+	// blockSections are the sections which must have a block
+	blockSections = newSet("stages", "steps", "post", "when", "environment", "options", "parameters", "triggers",
+		"tools", "parallel", "matrix", "axes", "excludes", "libraries")
+)
+
+var declarativeRegexp = regexp.MustCompile(`(?m)^\s*pipeline\s*\{`)
+
+// IsDeclarative checks if the Jenkinsfile looks like a declarative Pipeline
+func IsDeclarative(jenkinsfile string) bool {
+	return declarativeRegexp.MatchString(jenkinsfile)
+}
+
+// Lint checks the structure of a declarative Jenkinsfile without Jenkins.
+// It returns the errors sorted by the line numbers, or nil if there is no error.
+// The unknown names are warnings, because they could be provided by the plugins of Jenkins.
+func Lint(jenkinsfile string) (errs []*Error) {
+	root, err := Parse(jenkinsfile)
+	if err != nil {
+		return []*Error{err.(*Error)}
+	}
+
+	l := &linter{stageNames: map[string]int{}}
+	l.lintFile(root)
+	sort.SliceStable(l.errs, func(i, j int) bool {
+		return l.errs[i].Line < l.errs[j].Line
+	})
+	return l.errs
+}
+
+type linter struct {
+	errs []*Error
+	// stageNames are the names of the stages and their lines, the stage names must be unique in a Pipeline
+	stageNames map[string]int
+}
+
+func (l *linter) addError(line int, format string, args ...interface{}) {
+	l.errs = append(l.errs, newError(line, format, args...))
+}
+
+func (l *linter) addWarning(line int, format string, args ...interface{}) {
+	warning := newError(line, format, args...)
+	warning.Warning = true
+	l.errs = append(l.errs, warning)
+}
+
+// HasErrors checks if there are problems other than the warnings
+func HasErrors(errs []*Error) bool {
+	for _, err := range errs {
+		if !err.Warning {
+			return true
+		}
+	}
+	return false
+}
+
+func (l *linter) lintFile(root *Node) {
+	var pipeline *Node
+	for _, node := range root.Children {
+		if node.Name != "pipeline" || !node.HasBlock {
+			continue
+		}
+		if pipeline != nil {
+			l.addError(node.Line, "only one pipeline block is allowed")
+			continue
+		}
+		pipeline = node
+	}
+	if pipeline == nil {
+		l.addError(1, "missing pipeline block, only the declarative Pipeline is supported")
+		return
+	}
+
+	sections := l.lintSections(pipeline, "pipeline", pipelineSections)
+	if sections["agent"] == nil {
+		l.addError(pipeline.Line, "missing agent section in pipeline")
+	}
+	if sections["stages"] == nil {
+		l.addError(pipeline.Line, "missing stages section in pipeline")
+	}
+	l.lintCommonSections(sections)
+}
+
+// lintSections checks the unknown and duplicate sections, then returns the sections by the names
+func (l *linter) lintSections(node *Node, kind string, allowed set) (sections map[string]*Node) {
+	sections = map[string]*Node{}
+	for _, child := range node.Children {
+		switch {
+		case child.Name == "":
+			l.addError(child.Line, "unexpected statement in %s", kind)
+		case !allowed[child.Name]:
+			l.addWarning(child.Line, "unknown section '%s' in %s", child.Name, kind)
+		case sections[child.Name] != nil:
+			l.addError(child.Line, "duplicate section '%s' in %s", child.Name, kind)
+		default:
+			sections[child.Name] = child
+			if blockSections[child.Name] && !child.HasBlock {
+				l.addError(child.Line, "section '%s' requires a block", child.Name)
+			}
+		}
+	}
+	return
+}
+
+func (l *linter) lintCommonSections(sections map[string]*Node) {
+	if agent := sections["agent"]; agent != nil {
+		l.lintAgent(agent)
+	}
+	if stages := sections["stages"]; stages != nil && stages.HasBlock {
+		l.lintStages(stages, "stages")
+	}
+	if post := sections["post"]; post != nil && post.HasBlock {
+		l.lintPost(post)
+	}
+	if when := sections["when"]; when != nil && when.HasBlock {
+		l.lintWhen(when)
+	}
+}
+
+func (l *linter) lintAgent(agent *Node) {
+	if !agent.HasBlock {
+		if agent.ArgsText != "any" && agent.ArgsText != "none" {
+			l.addError(agent.Line, "invalid agent '%s', it should be any, none or a block", agent.ArgsText)
+		}
+		return
+	}
+	if len(agent.Children) == 0 {
+		l.addError(agent.Line, "agent block must not be empty")
+	}
+	for _, child := range agent.Children {
+		if !agentTypes[child.Name] {
+			l.addWarning(child.Line, "unknown agent type '%s'", child.Name)
+		}
+	}
+}
+
+// lintStages checks the stages of a stages or parallel block
+func (l *linter) lintStages(stages *Node, kind string) {
+	if len(stages.Children) == 0 {
+		l.addError(stages.Line, "%s must contain at least one stage", kind)
+	}
+	for _, stage := range stages.Children {
+		if stage.Name != "stage" {
+			l.addError(stage.Line, "unexpected '%s' in %s, only stage is allowed", stage.Name, kind)
+			continue
+		}
+		l.lintStage(stage)
+	}
+}
+
+func (l *linter) lintStage(stage *Node) {
+	name, ok := stage.StringArg()
+	if !ok || name == "" {
+		l.addError(stage.Line, "stage name is required")
+	} else if line, exist := l.stageNames[name]; exist {
+		l.addError(stage.Line, "duplicate stage name '%s', it is already defined at line %d", name, line)
+	} else {
+		l.stageNames[name] = stage.Line
+	}
+	if !stage.HasBlock {
+		l.addError(stage.Line, "stage '%s' requires a block", name)
+		return
+	}
+
+	sections := l.lintSections(stage, "stage", stageSections)
+	var bodies []string
+	for _, body := range stageBodies {
+		if sections[body] != nil {
+			bodies = append(bodies, body)
+		}
+	}
+	switch len(bodies) {
+	case 0:
+		l.addError(stage.Line, "stage '%s' must have one of steps, stages, parallel or matrix", name)
+	case 1:
+	default:
+		l.addError(stage.Line, "stage '%s' can only have one of %s", name, strings.Join(bodies, ", "))
+	}
+
+	l.lintCommonSections(sections)
+	if steps := sections["steps"]; steps != nil && steps.HasBlock && len(steps.Children) == 0 {
+		l.addError(steps.Line, "steps of stage '%s' must not be empty", name)
+	}
+	if parallel := sections["parallel"]; parallel != nil && parallel.HasBlock {
+		l.lintStages(parallel, "parallel")
+	}
+	if matrix := sections["matrix"]; matrix != nil && matrix.HasBlock {
+		cellSections := l.lintSections(matrix, "matrix", matrixSections)
+		if cellSections["axes"] == nil {
+			l.addError(matrix.Line, "missing axes section in matrix")
+		}
+		if cellSections["stages"] == nil {
+			l.addError(matrix.Line, "missing stages section in matrix")
+		}
+		l.lintCommonSections(cellSections)
+	}
+}
+
+func (l *linter) lintWhen(when *Node) {
+	conditions := 0
+	for _, child := range when.Children {
+		if whenOptions[child.Name] {
+			continue
+		}
+		conditions++
+		l.lintCondition(child)
+	}
+	if conditions == 0 {
+		l.addError(when.Line, "when must have at least one condition")
+	}
+}
+
+func (l *linter) lintCondition(condition *Node) {
+	if !whenConditions[condition.Name] {
+		l.addWarning(condition.Line, "unknown when condition '%s'", condition.Name)
+		return
+	}
+
+	switch condition.Name {
+	case "not", "allOf", "anyOf":
+		if !condition.HasBlock || len(condition.Children) == 0 {
+			l.addError(condition.Line, "when condition '%s' requires nested conditions", condition.Name)
+			return
+		}
+		if condition.Name == "not" && len(condition.Children) > 1 {
+			l.addError(condition.Line, "when condition 'not' only accepts one nested condition")
+		}
+		for _, child := range condition.Children {
+			l.lintCondition(child)
+		}
+	case "expression":
+		if !condition.HasBlock {
+			l.addError(condition.Line, "when condition 'expression' requires a block")
+		}
+	}
+}
+
+func (l *linter) lintPost(post *Node) {
+	found := map[string]bool{}
+	for _, child := range post.Children {
+		switch {
+		case !postConditions[child.Name]:
+			l.addWarning(child.Line, "unknown post condition '%s'", child.Name)
+		case found[child.Name]:
+			l.addError(child.Line, "duplicate post condition '%s'", child.Name)
+		case !child.HasBlock:
+			l.addError(child.Line, "post condition '%s' requires a block", child.Name)
+		}
+		found[child.Name] = true
+	}
+}
+
+type set map[string]bool
+
+func newSet(items ...string) set {
+	result := set{}
+	for _, item := range items {
+		result[item] = true
+	}
+	return result
+}
@@ -0,0 +1,204 @@
+/*
+Copyright 2022 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package jenkinsfile
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLint(t *testing.T) {
+	tests := []struct {
+		name        string
+		jenkinsfile string
+		want        []*Error
+	}{{
+		name: "valid",
+		jenkinsfile: `pipeline {
+  agent {
+    kubernetes {
+      inheritFrom 'go'
+    }
+  }
+  options { timeout(time: 1, unit: 'HOURS') }
+  parameters { string(name: 'VERSION', defaultValue: 'v1') }
+  stages {
+    stage('build') {
+      when {
+        beforeAgent true
+        anyOf {
+          branch 'master'
+          not { changeRequest() }
+          not { isRestartedRun() }
+        }
+        expression { return params.VERSION != '' }
+      }
+      steps {
+        container('go') {
+          sh 'make build'
+        }
+      }
+    }
+    stage('test') {
+      parallel {
+        stage('unit') { steps { sh 'make test' } }
+        stage('e2e') {
+          agent any
+          steps { sh 'make e2e' }
+          post { failure { echo 'failed' } }
+        }
+      }
+    }
+    stage('matrix') {
+      matrix {
+        axes {
+          axis {
+            name 'OS'
+            values 'linux', 'darwin'
+          }
+        }
+        stages {
+          stage('cross build') { steps { sh "GOOS=${OS} make" } }
+        }
+      }
+    }
+  }
+  post {
+    always { echo 'done' }
+    cleanup { deleteDir() }
+  }
+}`,
+	}, {
+		name:        "scripted Pipeline",
+		jenkinsfile: "node {\n  sh 'make'\n}",
+		want:        []*Error{{Line: 1, Message: "missing pipeline block, only the declarative Pipeline is supported"}},
+	}, {
+		name:        "syntax error",
+		jenkinsfile: "pipeline {\n  agent any\n",
+		want:        []*Error{{Line: 1, Message: "unclosed block, missing '}'"}},
+	}, {
+		name: "missing agent and stages",
+		jenkinsfile: `pipeline {
+  options { timeout(time: 1, unit: 'HOURS') }
+  stage('build') { steps { sh 'make' } }
+}
+pipeline {}`,
+		want: []*Error{
+			{Line: 1, Message: "missing agent section in pipeline"},
+			{Line: 1, Message: "missing stages section in pipeline"},
+			{Line: 3, Message: "unknown section 'stage' in pipeline", Warning: true},
+			{Line: 5, Message: "only one pipeline block is allowed"},
+		},
+	}, {
+		name: "invalid sections",
+		jenkinsfile: `pipeline {
+  agent something
+  agent any
+  stages {
+    stage('build') {
+      steps {}
+      stages { stage('nested') { steps { sh 'make' } } }
+      unknown {}
+    }
+    stage('build') {
+      agent { pod 'name' }
+    }
+    stage {
+      environment
+      steps { sh 'make' }
+    }
+    echo 'hello'
+  }
+}`,
+		want: []*Error{
+			{Line: 2, Message: "invalid agent 'something', it should be any, none or a block"},
+			{Line: 3, Message: "duplicate section 'agent' in pipeline"},
+			{Line: 5, Message: "stage 'build' can only have one of steps, stages"},
+			{Line: 6, Message: "steps of stage 'build' must not be empty"},
+			{Line: 8, Message: "unknown section 'unknown' in stage", Warning: true},
+			{Line: 10, Message: "duplicate stage name 'build', it is already defined at line 5"},
+			{Line: 10, Message: "stage 'build' must have one of steps, stages, parallel or matrix"},
+			{Line: 11, Message: "unknown agent type 'pod'", Warning: true},
+			{Line: 13, Message: "stage name is required"},
+			{Line: 14, Message: "section 'environment' requires a block"},
+			{Line: 17, Message: "unexpected 'echo' in stages, only stage is allowed"},
+		},
+	}, {
+		name: "invalid when and post conditions",
+		jenkinsfile: `pipeline {
+  agent none
+  stages {
+    stage('build') {
+      when {
+        beforeAgent true
+        branches 'master'
+        not {
+          branch 'a'
+          branch 'b'
+        }
+        allOf {}
+        expression 'true'
+      }
+      steps { sh 'make' }
+    }
+    stage('test') {
+      when { beforeAgent true }
+      matrix {
+        stages { stage('cross test') { steps { sh 'make' } } }
+      }
+    }
+  }
+  post {
+    always { echo 'a' }
+    always { echo 'b' }
+    finally { echo 'c' }
+    success
+  }
+}`,
+		want: []*Error{
+			{Line: 7, Message: "unknown when condition 'branches'", Warning: true},
+			{Line: 8, Message: "when condition 'not' only accepts one nested condition"},
+			{Line: 12, Message: "when condition 'allOf' requires nested conditions"},
+			{Line: 13, Message: "when condition 'expression' requires a block"},
+			{Line: 18, Message: "when must have at least one condition"},
+			{Line: 19, Message: "missing axes section in matrix"},
+			{Line: 26, Message: "duplicate post condition 'always'"},
+			{Line: 27, Message: "unknown post condition 'finally'", Warning: true},
+			{Line: 28, Message: "post condition 'success' requires a block"},
+		},
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, Lint(tt.jenkinsfile))
+		})
+	}
+}
+
+func TestHasErrors(t *testing.T) {
+	assert.False(t, HasErrors(nil))
+	assert.False(t, HasErrors([]*Error{{Line: 1, Message: "unknown agent type 'pod'", Warning: true}}))
+	assert.True(t, HasErrors([]*Error{{Line: 1, Message: "unknown agent type 'pod'", Warning: true},
+		{Line: 2, Message: "missing stages section in pipeline"}}))
+}
+
+func TestIsDeclarative(t *testing.T) {
+	assert.True(t, IsDeclarative("pipeline {\n}"))
+	assert.True(t, IsDeclarative("@Library('a') _\n  pipeline{\n}"))
+	assert.False(t, IsDeclarative("node {\n}"))
+	assert.False(t, IsDeclarative("// pipeline {"))
+}
@@ -0,0 +1,178 @@
+/*
+Copyright 2022 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package jenkinsfile
+
+import (
+	"bytes"
+	"encoding/json"
+)
+
+// The types below are the JSON representation of a declarative Pipeline, which is compatible with the
+// pipeline-model-converter of Jenkins. See also
+// https://github.com/jenkinsci/pipeline-model-definition-plugin/blob/master/pipeline-model-api/src/main/resources/ast-schema.json
+
+// Model is the root of the JSON representation
+type Model struct {
+	Pipeline *Pipeline `json:"pipeline"`
+}
+
+// Pipeline is the pipeline block
+type Pipeline struct {
+	Stages      []*Stage    `json:"stages"`
+	Agent       *Agent      `json:"agent,omitempty"`
+	Environment []*KeyValue `json:"environment,omitempty"`
+	Parameters  *Parameters `json:"parameters,omitempty"`
+	Options     *Options    `json:"options,omitempty"`
+	Triggers    *Triggers   `json:"triggers,omitempty"`
+	Tools       []*KeyValue `json:"tools,omitempty"`
+	Post        *Post       `json:"post,omitempty"`
+}
+
+// Stage is a stage of the Pipeline, it has one of the branches, parallel or stages
+type Stage struct {
+	Name        string      `json:"name"`
+	Agent       *Agent      `json:"agent,omitempty"`
+	When        *When       `json:"when,omitempty"`
+	Environment []*KeyValue `json:"environment,omitempty"`
+	Tools       []*KeyValue `json:"tools,omitempty"`
+	Options     *Options    `json:"options,omitempty"`
+	FailFast    *bool       `json:"failFast,omitempty"`
+	Branches    []*Branch   `json:"branches,omitempty"`
+	Parallel    []*Stage    `json:"parallel,omitempty"`
+	Stages      []*Stage    `json:"stages,omitempty"`
+	Post        *Post       `json:"post,omitempty"`
+}
+
+// Branch is a list of steps, a stage has only the default branch
+type Branch struct {
+	Name  string  `json:"name"`
+	Steps []*Step `json:"steps"`
+}
+
+// Step is a step or a block-scoped step which has the children
+type Step struct {
+	Name      string    `json:"name"`
+	Arguments Arguments `json:"arguments"`
+	Children  []*Step   `json:"children,omitempty"`
+}
+
+// Agent is the agent section, the arguments come from the nested block like: agent { node { label 'go' } },
+// the argument comes from the statement like: agent { label 'go' }
+type Agent struct {
+	Type      string      `json:"type"`
+	Arguments []*KeyValue `json:"arguments,omitempty"`
+	Argument  *Value      `json:"argument,omitempty"`
+}
+
+// When is the when section of a stage
+type When struct {
+	Conditions    []*Condition `json:"conditions"`
+	BeforeAgent   *bool        `json:"beforeAgent,omitempty"`
+	BeforeInput   *bool        `json:"beforeInput,omitempty"`
+	BeforeOptions *bool        `json:"beforeOptions,omitempty"`
+}
+
+// Condition is a when condition, the conditions like allOf have the children
+type Condition struct {
+	Name      string       `json:"name"`
+	Arguments *Arguments   `json:"arguments,omitempty"`
+	Children  []*Condition `json:"children,omitempty"`
+}
+
+// Post is the post section
+type Post struct {
+	Conditions []*PostCondition `json:"conditions"`
+}
+
+// PostCondition is a condition of the post section, such as: always, failure
+type PostCondition struct {
+	Condition string    `json:"condition"`
+	Branches  []*Branch `json:"branches"`
+}
+
+// Parameters is the parameters section
+type Parameters struct {
+	Parameters []*MethodCall `json:"parameters"`
+}
+
+// Options is the options section
+type Options struct {
+	Options []*MethodCall `json:"options"`
+}
+
+// Triggers is the triggers section
+type Triggers struct {
+	Triggers []*MethodCall `json:"triggers"`
+}
+
+// MethodCall is an item of the parameters, options or triggers, such as: timeout(time: 1, unit: 'HOURS')
+type MethodCall struct {
+	Name      string    `json:"name"`
+	Arguments Arguments `json:"arguments"`
+}
+
+// KeyValue is a named argument, an environment variable, or a tool
+type KeyValue struct {
+	Key   string `json:"key"`
+	Value *Value `json:"value"`
+}
+
+// Value is a literal value, or a Groovy expression if it is not a literal
+type Value struct {
+	IsLiteral bool        `json:"isLiteral"`
+	Value     interface{} `json:"value"`
+}
+
+// Arguments are the named arguments, or a single positional argument.
+// It is an array of KeyValue or a single Value in JSON.
+type Arguments struct {
+	Named  []*KeyValue
+	Single *Value
+}
+
+// MarshalJSON marshals the arguments as an array or a single value
+func (a Arguments) MarshalJSON() ([]byte, error) {
+	if a.Single != nil {
+		return json.Marshal(a.Single)
+	}
+	if a.Named == nil {
+		return []byte("[]"), nil
+	}
+	return json.Marshal(a.Named)
+}
+
+// UnmarshalJSON unmarshals the arguments from an array or a single value
+func (a *Arguments) UnmarshalJSON(data []byte) error {
+	data = bytes.TrimSpace(data)
+	switch {
+	case bytes.HasPrefix(data, []byte("[")):
+		a.Named = []*KeyValue{}
+		return decodeStrict(data, &a.Named)
+	case bytes.HasPrefix(data, []byte("{")):
+		a.Single = &Value{}
+		return decodeStrict(data, a.Single)
+	}
+	return nil
+}
+
+// decodeStrict decodes the JSON data and rejects the unknown fields, the numbers are kept as json.Number
+func decodeStrict(data []byte, v interface{}) error {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	decoder.UseNumber()
+	return decoder.Decode(v)
+}
@@ -0,0 +1,376 @@
+/*
+Copyright 2022 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package jenkinsfile
+
+import (
+	"fmt"
+	"strings"
+)
+
+// TokenType is the type of a token
+type TokenType int
+
+const (
+	// TokenIdent is an identifier, such as: pipeline, stage, sh
+	TokenIdent TokenType = iota
+	// TokenString is a string literal, the value does not contain the quotes
+	TokenString
+	// TokenNumber is a number literal
+	TokenNumber
+	// TokenPunct is a punctuation or an operator character
+	TokenPunct
+	// TokenNewline is a line break
+	TokenNewline
+)
+
+// Token is a lexical token of a Jenkinsfile
+type Token struct {
+	Type  TokenType
+	Value string
+	// Quote is the quote of a string literal, such as: ', ", ''' or """
+	Quote string
+	Line  int
+	// Start and End are the offsets of the token in the Jenkinsfile
+	Start int
+	End   int
+}
+
+// Node is a statement of a Jenkinsfile. A declarative Jenkinsfile is a tree of the statements like:
+// name args { children }
+type Node struct {
+	// Name is the leading identifier of the statement, it is empty if the statement does not start with an identifier
+	Name string
+	// Args are the tokens between the name and the block
+	Args []Token
+	// ArgsText is the source text of the arguments
+	ArgsText string
+	// HasBlock indicates if the statement has a block, the children are the statements of the block
+	HasBlock bool
+	Children []*Node
+	// BlockText is the source text between the braces of the block
+	BlockText string
+	Line      int
+	// Start and End are the offsets of the statement in the Jenkinsfile, BlockStart is the offset after the opening brace
+	Start      int
+	End        int
+	BlockStart int
+}
+
+// Child returns the first child with the given name
+func (n *Node) Child(name string) *Node {
+	for _, child := range n.Children {
+		if child.Name == name {
+			return child
+		}
+	}
+	return nil
+}
+
+// StringArg returns the first string literal argument, such as the name of a stage
+func (n *Node) StringArg() (value string, ok bool) {
+	for _, arg := range n.Args {
+		if arg.Type == TokenString {
+			return arg.Value, true
+		}
+	}
+	return
+}
+
+// Error is a problem of a Jenkinsfile at a line
+type Error struct {
+	Line    int    `json:"line"`
+	Message string `json:"message"`
+	// Warning indicates the problem might be fine in Jenkins, such as the names which are provided by the plugins
+	Warning bool `json:"warning,omitempty"`
+}
+
+// Error returns the error message with the line number
+func (e *Error) Error() string {
+	return fmt.Sprintf("line %d: %s", e.Line, e.Message)
+}
+
+func newError(line int, format string, args ...interface{}) *Error {
+	return &Error{Line: line, Message: fmt.Sprintf(format, args...)}
+}
+
+// Parse parses a Jenkinsfile into a tree of statements, the returned node is the root of the file
+func Parse(jenkinsfile string) (root *Node, err error) {
+	var tokens []Token
+	if tokens, err = tokenize(jenkinsfile); err != nil {
+		return
+	}
+	p := &parser{text: jenkinsfile, tokens: tokens}
+	root = &Node{HasBlock: true, Line: 1}
+	if root.Children, err = p.parseBlock(nil); err != nil {
+		root = nil
+	}
+	return
+}
+
+type parser struct {
+	text   string
+	tokens []Token
+	pos    int
+}
+
+func (p *parser) peek() *Token {
+	if p.pos < len(p.tokens) {
+		return &p.tokens[p.pos]
+	}
+	return nil
+}
+
+// parseBlock parses the statements until the closing brace of the block, or the end of the file if it is the root
+func (p *parser) parseBlock(opening *Token) (nodes []*Node, err error) {
+	for {
+		token := p.peek()
+		switch {
+		case token == nil:
+			if opening != nil {
+				err = newError(opening.Line, "unclosed block, missing '}'")
+			}
+			return
+		case token.Type == TokenNewline || token.Type == TokenPunct && token.Value == ";":
+			p.pos++
+		case token.Type == TokenPunct && token.Value == "}":
+			if opening == nil {
+				err = newError(token.Line, "unexpected '}'")
+				return
+			}
+			p.pos++
+			return
+		default:
+			var node *Node
+			if node, err = p.parseStatement(); err != nil {
+				return
+			}
+			nodes = append(nodes, node)
+		}
+	}
+}
+
+// parseStatement parses a statement which ends with a line break, a semicolon, a block, or the end of the parent block
+func (p *parser) parseStatement() (node *Node, err error) {
+	first := p.peek()
+	node = &Node{Line: first.Line, Start: first.Start, End: first.End}
+	if first.Type == TokenIdent {
+		node.Name = first.Value
+		p.pos++
+	}
+
+	var brackets []*Token
+	for {
+		token := p.peek()
+		if token == nil {
+			if len(brackets) > 0 {
+				err = newError(brackets[len(brackets)-1].Line, "unclosed '%s'", brackets[len(brackets)-1].Value)
+			}
+			break
+		}
+
+		if len(brackets) == 0 {
+			if token.Type == TokenNewline && !p.continued() ||
+				token.Type == TokenPunct && (token.Value == ";" || token.Value == "}") {
+				break
+			}
+			if token.Type == TokenPunct && token.Value == "{" {
+				p.pos++
+				node.HasBlock = true
+				node.BlockStart = token.End
+				if node.Children, err = p.parseBlock(token); err == nil {
+					node.BlockText = p.text[token.End:p.tokens[p.pos-1].Start]
+					node.End = p.tokens[p.pos-1].End
+				}
+				break
+			}
+		}
+
+		if token.Type == TokenPunct {
+			switch token.Value {
+			case "(", "[", "{":
+				brackets = append(brackets, token)
+			case ")", "]", "}":
+				if len(brackets) == 0 || closing(brackets[len(brackets)-1].Value) != token.Value {
+					err = newError(token.Line, "unexpected '%s'", token.Value)
+					return
+				}
+				brackets = brackets[:len(brackets)-1]
+			}
+		}
+		if token.Type != TokenNewline {
+			node.Args = append(node.Args, *token)
+			node.End = token.End
+		}
+		p.pos++
+	}
+
+	if len(node.Args) > 0 {
+		node.ArgsText = p.text[node.Args[0].Start:node.Args[len(node.Args)-1].End]
+	}
+	return
+}
+
+// continued checks if the statement continues on the next line, such as a trailing operator or a leading dot
+func (p *parser) continued() bool {
+	if p.pos > 0 {
+		if previous := p.tokens[p.pos-1]; previous.Type == TokenPunct && strings.Contains(",+-*/=&|?:.", previous.Value) {
+			return true
+		}
+	}
+	for i := p.pos; i < len(p.tokens); i++ {
+		if p.tokens[i].Type != TokenNewline {
+			return p.tokens[i].Type == TokenPunct && p.tokens[i].Value == "."
+		}
+	}
+	return false
+}
+
+func closing(opening string) string {
+	switch opening {
+	case "(":
+		return ")"
+	case "[":
+		return "]"
+	}
+	return "}"
+}
+
+// tokenize splits a Jenkinsfile into tokens, the comments are dropped
+func tokenize(text string) (tokens []Token, err error) {
+	line := 1
+	for i := 0; i < len(text); {
+		ch := text[i]
+		rest := text[i:]
+		switch {
+		case ch == '\n':
+			tokens = append(tokens, Token{Type: TokenNewline, Value: "\n", Line: line, Start: i, End: i + 1})
+			line++
+			i++
+		case ch == ' ' || ch == '\t' || ch == '\r':
+			i++
+		case ch == '\\' && strings.HasPrefix(rest, "\\\n"):
+			// line continuation
+			line++
+			i += 2
+		case strings.HasPrefix(rest, "//") || i == 0 && strings.HasPrefix(rest, "#!"):
+			if end := strings.IndexByte(rest, '\n'); end >= 0 {
+				i += end
+			} else {
+				i = len(text)
+			}
+		case strings.HasPrefix(rest, "/*"):
+			end := strings.Index(rest[2:], "*/")
+			if end < 0 {
+				err = newError(line, "unclosed comment")
+				return
+			}
+			line += strings.Count(rest[:end+4], "\n")
+			i += end + 4
+		case ch == '"' || ch == '\'':
+			var token Token
+			if token, err = readString(text, i, line); err != nil {
+				return
+			}
+			tokens = append(tokens, token)
+			line += strings.Count(text[token.Start:token.End], "\n")
+			i = token.End
+		case isIdentStart(ch):
+			end := i + 1
+			for end < len(text) && isIdentPart(text[end]) {
+				end++
+			}
+			tokens = append(tokens, Token{Type: TokenIdent, Value: text[i:end], Line: line, Start: i, End: end})
+			i = end
+		case ch >= '0' && ch <= '9':
+			end := i + 1
+			for end < len(text) && (isIdentPart(text[end]) || text[end] == '.' && end+1 < len(text) &&
+				text[end+1] >= '0' && text[end+1] <= '9') {
+				end++
+			}
+			tokens = append(tokens, Token{Type: TokenNumber, Value: text[i:end], Line: line, Start: i, End: end})
+			i = end
+		default:
+			tokens = append(tokens, Token{Type: TokenPunct, Value: text[i : i+1], Line: line, Start: i, End: i + 1})
+			i++
+		}
+	}
+	return
+}
+
+// readString reads a string literal which starts at the given offset.
+// The GString expressions like ${a["b"]} are kept as they are in the value.
+func readString(text string, start, line int) (token Token, err error) {
+	quote := text[start : start+1]
+	if strings.HasPrefix(text[start:], strings.Repeat(quote, 3)) {
+		quote = strings.Repeat(quote, 3)
+	}
+	token = Token{Type: TokenString, Quote: quote, Line: line, Start: start}
+
+	var value strings.Builder
+	depth := 0
+	for i := start + len(quote); i < len(text); i++ {
+		ch := text[i]
+		switch {
+		case ch == '\\' && i+1 < len(text):
+			value.WriteString(unescape(text[i+1]))
+			i++
+			continue
+		case depth == 0 && strings.HasPrefix(text[i:], quote):
+			token.Value = value.String()
+			token.End = i + len(quote)
+			return
+		case ch == '\n' && len(quote) == 1:
+			err = newError(line, "unclosed string literal")
+			return
+		case quote[0] == '"' && strings.HasPrefix(text[i:], "${"):
+			depth++
+			value.WriteString("${")
+			i++
+			continue
+		case depth > 0 && ch == '{':
+			depth++
+		case depth > 0 && ch == '}':
+			depth--
+		}
+		value.WriteByte(ch)
+	}
+	err = newError(line, "unclosed string literal")
+	return
+}
+
+func unescape(ch byte) string {
+	switch ch {
+	case 'n':
+		return "\n"
+	case 't':
+		return "\t"
+	case 'r':
+		return "\r"
+	case '\n':
+		// line continuation
+		return ""
+	}
+	return string(ch)
+}
+
+func isIdentStart(ch byte) bool {
+	return ch == '_' || ch == '$' || ch >= 'a' && ch <= 'z' || ch >= 'A' && ch <= 'Z'
+}
+
+func isIdentPart(ch byte) bool {
+	return isIdentStart(ch) || ch >= '0' && ch <= '9'
+}
@@ -0,0 +1,157 @@
+/*
+Copyright 2022 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package jenkinsfile
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParse(t *testing.T) {
+	root, err := Parse(`#!groovy
+@Library('shared') _
+/* comment
+ * pipeline { */
+pipeline {
+  agent { node { label 'go' } } // comment
+  environment {
+    NAME = "${env.BRANCH_NAME}-{}"
+  }
+  stages {
+    stage("build") {
+      steps {
+        sh '''
+          make }
+        '''
+        sh script: 'make test',
+           returnStatus: true
+        script {
+          def result = sh(script: 'echo a', returnStdout: true)
+            .trim()
+          if (result == "a") { echo 'a' } else { echo "b\"" }
+        }
+      }
+    }
+  }
+}
+`)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(root.Children))
+	assert.Equal(t, "", root.Children[0].Name)
+	assert.Equal(t, "@Library('shared') _", root.Children[0].ArgsText)
+
+	pipeline := root.Child("pipeline")
+	assert.NotNil(t, pipeline)
+	assert.Equal(t, 5, pipeline.Line)
+	assert.Equal(t, 3, len(pipeline.Children))
+
+	agent := pipeline.Child("agent")
+	assert.True(t, agent.HasBlock)
+	assert.Equal(t, "label", agent.Child("node").Children[0].Name)
+	assert.Equal(t, "= \"${env.BRANCH_NAME}-{}\"", pipeline.Child("environment").Child("NAME").ArgsText)
+
+	stage := pipeline.Child("stages").Child("stage")
+	assert.Equal(t, 11, stage.Line)
+	name, ok := stage.StringArg()
+	assert.True(t, ok)
+	assert.Equal(t, "build", name)
+
+	steps := stage.Child("steps").Children
+	assert.Equal(t, 3, len(steps))
+	assert.Equal(t, "sh", steps[0].Name)
+	assert.Equal(t, "\n          make }\n        ", steps[0].Args[0].Value)
+	assert.Equal(t, "sh", steps[1].Name)
+	assert.Equal(t, 16, steps[1].Line)
+	assert.Equal(t, "script: 'make test',\n           returnStatus: true", steps[1].ArgsText)
+
+	script := steps[2].Children
+	assert.Contains(t, steps[2].BlockText, "def result = sh(script: 'echo a', returnStdout: true)\n            .trim()")
+	assert.Equal(t, 3, len(script))
+	assert.Equal(t, "def", script[0].Name)
+	assert.Equal(t, "if", script[1].Name)
+	assert.Equal(t, "(result == \"a\")", script[1].ArgsText)
+	assert.Equal(t, "else", script[2].Name)
+	assert.Equal(t, `b"`, script[2].Children[0].Args[0].Value)
+}
+
+func TestParseOffsets(t *testing.T) {
+	jenkinsfile := "pipeline {\n  agent any // comment\n  stages { stage('a') { steps { echo '}' } } }\n}"
+	root, err := Parse(jenkinsfile)
+	assert.Nil(t, err)
+
+	pipeline := root.Child("pipeline")
+	assert.Equal(t, jenkinsfile, jenkinsfile[pipeline.Start:pipeline.End])
+	assert.Equal(t, "\n", jenkinsfile[pipeline.BlockStart:pipeline.BlockStart+1])
+
+	agent := pipeline.Child("agent")
+	assert.Equal(t, "agent any", jenkinsfile[agent.Start:agent.End])
+
+	stages := pipeline.Child("stages")
+	assert.Equal(t, "stages { stage('a') { steps { echo '}' } } }", jenkinsfile[stages.Start:stages.End])
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		name        string
+		jenkinsfile string
+		wantLine    int
+		wantMessage string
+	}{{
+		name:        "unclosed block",
+		jenkinsfile: "pipeline {\n  stages {\n    stage('a') {\n  }\n}",
+		wantLine:    1,
+		wantMessage: "unclosed block, missing '}'",
+	}, {
+		name:        "unexpected closing brace",
+		jenkinsfile: "pipeline {\n}\n}",
+		wantLine:    3,
+		wantMessage: "unexpected '}'",
+	}, {
+		name:        "unbalanced parentheses",
+		jenkinsfile: "pipeline {\n  steps {\n    sh(script: 'a'\n  }\n}",
+		wantLine:    4,
+		wantMessage: "unexpected '}'",
+	}, {
+		name:        "unclosed parentheses",
+		jenkinsfile: "sh(script: 'a'",
+		wantLine:    1,
+		wantMessage: "unclosed '('",
+	}, {
+		name:        "unclosed string",
+		jenkinsfile: "pipeline {\n  agent any\n  stages { echo 'a }\n}",
+		wantLine:    3,
+		wantMessage: "unclosed string literal",
+	}, {
+		name:        "unclosed multi-line string",
+		jenkinsfile: "pipeline {\n  sh \"\"\"\n  make\n}",
+		wantLine:    2,
+		wantMessage: "unclosed string literal",
+	}, {
+		name:        "unclosed comment",
+		jenkinsfile: "pipeline {\n}\n/* comment",
+		wantLine:    3,
+		wantMessage: "unclosed comment",
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root, err := Parse(tt.jenkinsfile)
+			assert.Nil(t, root)
+			assert.Equal(t, &Error{Line: tt.wantLine, Message: tt.wantMessage}, err)
+		})
+	}
+}
@@ -0,0 +1,384 @@
+/*
+Copyright 2022 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package jenkinsfile
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ToJenkinsfile converts the JSON format to a declarative Jenkinsfile without Jenkins.
+// It returns an error which wraps ErrUnsupported if there are fields out of the supported subset.
+func ToJenkinsfile(data string) (jenkinsfile string, err error) {
+	model := &Model{}
+	if err = decodeStrict([]byte(data), model); err != nil {
+		err = fmt.Errorf("%v: %w", err, ErrUnsupported)
+		return
+	}
+	if model.Pipeline == nil {
+		err = fmt.Errorf("missing pipeline: %w", ErrUnsupported)
+		return
+	}
+
+	w := &writer{}
+	if err = w.writePipeline(model.Pipeline); err == nil {
+		jenkinsfile = w.String()
+	}
+	return
+}
+
+type writer struct {
+	strings.Builder
+	indent int
+}
+
+func (w *writer) line(format string, args ...interface{}) {
+	w.WriteString(strings.Repeat("  ", w.indent))
+	w.WriteString(fmt.Sprintf(format, args...))
+	w.WriteString("\n")
+}
+
+// block writes a block with the header, then the content is written by the given function
+func (w *writer) block(header string, content func() error) (err error) {
+	w.line("%s {", header)
+	w.indent++
+	err = content()
+	w.indent--
+	w.line("}")
+	return
+}
+
+func (w *writer) writePipeline(pipeline *Pipeline) error {
+	return w.block("pipeline", func() (err error) {
+		if err = w.writeAgent(pipeline.Agent); err != nil {
+			return
+		}
+		if err = w.writeKeyValues("environment", pipeline.Environment, " = "); err != nil {
+			return
+		}
+		if pipeline.Parameters != nil {
+			if err = w.writeMethodCalls("parameters", pipeline.Parameters.Parameters); err != nil {
+				return
+			}
+		}
+		if pipeline.Options != nil {
+			if err = w.writeMethodCalls("options", pipeline.Options.Options); err != nil {
+				return
+			}
+		}
+		if pipeline.Triggers != nil {
+			if err = w.writeMethodCalls("triggers", pipeline.Triggers.Triggers); err != nil {
+				return
+			}
+		}
+		if err = w.writeKeyValues("tools", pipeline.Tools, " "); err != nil {
+			return
+		}
+		if err = w.writeStages("stages", pipeline.Stages); err != nil {
+			return
+		}
+		return w.writePost(pipeline.Post)
+	})
+}
+
+func (w *writer) writeStages(kind string, stages []*Stage) error {
+	return w.block(kind, func() error {
+		for _, stage := range stages {
+			if err := w.writeStage(stage); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (w *writer) writeStage(stage *Stage) error {
+	return w.block(fmt.Sprintf("stage(%s)", quote(stage.Name)), func() (err error) {
+		if err = w.writeAgent(stage.Agent); err != nil {
+			return
+		}
+		if err = w.writeWhen(stage.When); err != nil {
+			return
+		}
+		if err = w.writeKeyValues("environment", stage.Environment, " = "); err != nil {
+			return
+		}
+		if err = w.writeKeyValues("tools", stage.Tools, " "); err != nil {
+			return
+		}
+		if stage.Options != nil {
+			if err = w.writeMethodCalls("options", stage.Options.Options); err != nil {
+				return
+			}
+		}
+		if stage.FailFast != nil {
+			w.line("failFast %t", *stage.FailFast)
+		}
+
+		switch {
+		case len(stage.Branches) == 1:
+			err = w.writeSteps("steps", stage.Branches[0].Steps)
+		case len(stage.Branches) > 1:
+			err = fmt.Errorf("stage '%s' has multiple branches: %w", stage.Name, ErrUnsupported)
+		case stage.Parallel != nil:
+			err = w.writeStages("parallel", stage.Parallel)
+		case stage.Stages != nil:
+			err = w.writeStages("stages", stage.Stages)
+		}
+		if err != nil {
+			return
+		}
+		return w.writePost(stage.Post)
+	})
+}
+
+func (w *writer) writeAgent(agent *Agent) (err error) {
+	switch {
+	case agent == nil:
+	case agent.Type == "any" || agent.Type == "none":
+		w.line("agent %s", agent.Type)
+	case agent.Argument != nil:
+		err = w.block("agent", func() (err error) {
+			var value string
+			if value, err = formatValue(agent.Argument); err == nil {
+				w.line("%s %s", agent.Type, value)
+			}
+			return
+		})
+	default:
+		err = w.block("agent", func() error {
+			return w.writeKeyValues(agent.Type, agent.Arguments, " ")
+		})
+	}
+	return
+}
+
+// writeKeyValues writes a block of the key-values, such as the environment variables
+func (w *writer) writeKeyValues(kind string, items []*KeyValue, separator string) error {
+	if items == nil {
+		return nil
+	}
+	return w.block(kind, func() error {
+		for _, item := range items {
+			value, err := formatValue(item.Value)
+			if err != nil {
+				return err
+			}
+			w.line("%s%s%s", item.Key, separator, value)
+		}
+		return nil
+	})
+}
+
+func (w *writer) writeMethodCalls(kind string, calls []*MethodCall) error {
+	return w.block(kind, func() error {
+		for _, call := range calls {
+			statement, err := formatCall(call.Name, call.Arguments, true)
+			if err != nil {
+				return err
+			}
+			w.line("%s", statement)
+		}
+		return nil
+	})
+}
+
+func (w *writer) writeWhen(when *When) error {
+	if when == nil {
+		return nil
+	}
+	return w.block("when", func() error {
+		if when.BeforeAgent != nil {
+			w.line("beforeAgent %t", *when.BeforeAgent)
+		}
+		if when.BeforeInput != nil {
+			w.line("beforeInput %t", *when.BeforeInput)
+		}
+		if when.BeforeOptions != nil {
+			w.line("beforeOptions %t", *when.BeforeOptions)
+		}
+		return w.writeConditions(when.Conditions)
+	})
+}
+
+func (w *writer) writeConditions(conditions []*Condition) error {
+	for _, condition := range conditions {
+		var err error
+		switch {
+		case condition.Children != nil:
+			err = w.block(condition.Name, func() error {
+				return w.writeConditions(condition.Children)
+			})
+		case condition.Name == "expression":
+			err = w.writeScriptBlock(condition.Name, condition.Arguments)
+		case condition.Arguments == nil:
+			w.line("%s()", condition.Name)
+		default:
+			var statement string
+			if statement, err = formatCall(condition.Name, *condition.Arguments, false); err == nil {
+				w.line("%s", statement)
+			}
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (w *writer) writePost(post *Post) error {
+	if post == nil {
+		return nil
+	}
+	return w.block("post", func() error {
+		for _, condition := range post.Conditions {
+			if len(condition.Branches) != 1 {
+				return fmt.Errorf("post condition '%s' must have one branch: %w", condition.Condition, ErrUnsupported)
+			}
+			if err := w.writeSteps(condition.Condition, condition.Branches[0].Steps); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (w *writer) writeSteps(kind string, steps []*Step) error {
+	return w.block(kind, func() error {
+		for _, step := range steps {
+			if err := w.writeStep(step); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (w *writer) writeStep(step *Step) error {
+	if step.Name == "script" && step.Children == nil {
+		return w.writeScriptBlock(step.Name, &step.Arguments)
+	}
+
+	statement, err := formatCall(step.Name, step.Arguments, step.Children != nil)
+	if err != nil {
+		return err
+	}
+	if step.Children == nil {
+		w.line("%s", statement)
+		return nil
+	}
+	if step.Arguments.Single == nil && len(step.Arguments.Named) == 0 {
+		// a block-scoped step without arguments, such as: timestamps {
+		statement = step.Name
+	}
+	return w.block(statement, func() error {
+		for _, child := range step.Children {
+			if err := w.writeStep(child); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// writeScriptBlock writes a block of Groovy code, such as the script step and the expression condition
+func (w *writer) writeScriptBlock(name string, arguments *Arguments) error {
+	if arguments == nil || len(arguments.Named) != 1 || arguments.Named[0].Key != "scriptBlock" {
+		return fmt.Errorf("'%s' requires the scriptBlock argument: %w", name, ErrUnsupported)
+	}
+	code, ok := arguments.Named[0].Value.Value.(string)
+	if !ok {
+		return fmt.Errorf("scriptBlock of '%s' must be a string: %w", name, ErrUnsupported)
+	}
+	return w.block(name, func() error {
+		for _, line := range strings.Split(code, "\n") {
+			if strings.TrimSpace(line) == "" {
+				w.WriteString("\n")
+				continue
+			}
+			w.line("%s", line)
+		}
+		return nil
+	})
+}
+
+// formatCall formats a step or a method, such as: sh 'make', or timeout(time: 1, unit: 'HOURS').
+// The parentheses are kept for a single argument if it is required, such as: container('go') {
+func formatCall(name string, arguments Arguments, parentheses bool) (statement string, err error) {
+	var single *Value
+	switch {
+	case arguments.Single != nil:
+		single = arguments.Single
+	case len(arguments.Named) == 1 && arguments.Named[0].Key == defaultArguments[name]:
+		single = arguments.Named[0].Value
+	}
+
+	if single != nil {
+		var value string
+		if value, err = formatValue(single); err != nil {
+			return
+		}
+		if parentheses {
+			return fmt.Sprintf("%s(%s)", name, value), nil
+		}
+		return fmt.Sprintf("%s %s", name, value), nil
+	}
+
+	args := make([]string, len(arguments.Named))
+	for i, arg := range arguments.Named {
+		var value string
+		if value, err = formatValue(arg.Value); err != nil {
+			return
+		}
+		args[i] = fmt.Sprintf("%s: %s", arg.Key, value)
+	}
+	return fmt.Sprintf("%s(%s)", name, strings.Join(args, ", ")), nil
+}
+
+// formatValue formats a value as a Groovy literal, or returns the expression as it is
+func formatValue(value *Value) (string, error) {
+	if value == nil {
+		return "", fmt.Errorf("missing value: %w", ErrUnsupported)
+	}
+	if !value.IsLiteral {
+		if expression, ok := value.Value.(string); ok {
+			return expression, nil
+		}
+		return "", fmt.Errorf("expression must be a string: %w", ErrUnsupported)
+	}
+
+	switch literal := value.Value.(type) {
+	case string:
+		return quote(literal), nil
+	case json.Number:
+		return literal.String(), nil
+	case bool:
+		return strconv.FormatBool(literal), nil
+	}
+	return "", fmt.Errorf("literal %v: %w", value.Value, ErrUnsupported)
+}
+
+// quote quotes a string as a single-quoted Groovy string, a multi-line string is triple-quoted
+func quote(text string) string {
+	text = strings.NewReplacer(`\`, `\\`, `'`, `\'`, "\t", `\t`, "\r", `\r`).Replace(text)
+	if strings.Contains(text, "\n") {
+		return "'''" + text + "'''"
+	}
+	return "'" + text + "'"
+}
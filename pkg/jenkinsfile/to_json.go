@@ -0,0 +1,530 @@
+/*
+Copyright 2022 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package jenkinsfile
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// ErrUnsupported indicates that a construct is out of the declarative subset which the native converter supports
+var ErrUnsupported = errors.New("unsupported by the native converter")
+
+// defaultArguments are the parameter names of the steps or methods which accept a single positional argument,
+// for example, sh 'make' is the same as sh(script: 'make')
+var defaultArguments = map[string]string{
+	"archiveArtifacts": "artifacts",
+	"bat":              "script",
+	"build":            "job",
+	"checkout":         "scm",
+	"container":        "name",
+	"cron":             "spec",
+	"dir":              "path",
+	"echo":             "message",
+	"error":            "message",
+	"git":              "url",
+	"input":            "message",
+	"junit":            "testResults",
+	"pollSCM":          "scmpoll_spec",
+	"powershell":       "script",
+	"retry":            "count",
+	"sh":               "script",
+	"sleep":            "time",
+	"stash":            "name",
+	"timeout":          "time",
+	"unstash":          "name",
+	"withCredentials":  "bindings",
+	"withEnv":          "overrides",
+}
+
+// keywords are the Groovy statements which are only allowed in a script block
+var keywords = newSet("def", "if", "else", "for", "while", "try", "catch", "finally", "return", "switch", "import")
+
+var numberRegexp = regexp.MustCompile(`^-?(0|[1-9][0-9]*)(\.[0-9]+)?$`)
+
+func unsupported(line int, format string, args ...interface{}) error {
+	return fmt.Errorf("line %d: %s: %w", line, fmt.Sprintf(format, args...), ErrUnsupported)
+}
+
+// ToJSON converts a declarative Jenkinsfile to the JSON format without Jenkins.
+// It returns an error which wraps ErrUnsupported if there are constructs out of the supported subset.
+func ToJSON(jenkinsfile string) (data string, err error) {
+	var root *Node
+	if root, err = Parse(jenkinsfile); err != nil {
+		return
+	}
+	if len(root.Children) != 1 || root.Children[0].Name != "pipeline" || !root.Children[0].HasBlock {
+		err = unsupported(1, "only a single pipeline block is supported")
+		return
+	}
+
+	c := &jsonConverter{text: jenkinsfile}
+	model := &Model{}
+	if model.Pipeline, err = c.convertPipeline(root.Children[0]); err != nil {
+		return
+	}
+	var raw []byte
+	if raw, err = json.Marshal(model); err == nil {
+		data = string(raw)
+	}
+	return
+}
+
+type jsonConverter struct {
+	text string
+}
+
+func (c *jsonConverter) convertPipeline(node *Node) (pipeline *Pipeline, err error) {
+	pipeline = &Pipeline{Stages: []*Stage{}}
+	for _, section := range node.Children {
+		if !section.HasBlock && section.Name != "agent" {
+			return nil, unsupported(section.Line, "section '%s' requires a block", section.Name)
+		}
+		switch section.Name {
+		case "agent":
+			pipeline.Agent, err = c.convertAgent(section)
+		case "environment":
+			pipeline.Environment, err = c.convertEnvironment(section)
+		case "parameters":
+			pipeline.Parameters = &Parameters{}
+			pipeline.Parameters.Parameters, err = c.convertMethodCalls(section)
+		case "options":
+			pipeline.Options = &Options{}
+			pipeline.Options.Options, err = c.convertMethodCalls(section)
+		case "triggers":
+			pipeline.Triggers = &Triggers{}
+			pipeline.Triggers.Triggers, err = c.convertMethodCalls(section)
+		case "tools":
+			pipeline.Tools, err = c.convertTools(section)
+		case "stages":
+			pipeline.Stages, err = c.convertStages(section)
+		case "post":
+			pipeline.Post, err = c.convertPost(section)
+		default:
+			err = unsupported(section.Line, "section '%s' in pipeline", section.Name)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return
+}
+
+func (c *jsonConverter) convertStages(node *Node) (stages []*Stage, err error) {
+	stages = []*Stage{}
+	for _, child := range node.Children {
+		if child.Name != "stage" || !child.HasBlock {
+			return nil, unsupported(child.Line, "'%s' in %s", child.Name, node.Name)
+		}
+		var stage *Stage
+		if stage, err = c.convertStage(child); err != nil {
+			return nil, err
+		}
+		stages = append(stages, stage)
+	}
+	return
+}
+
+func (c *jsonConverter) convertStage(node *Node) (stage *Stage, err error) {
+	if args := stripParentheses(node.Args); len(args) != 1 || !isLiteral(c.text, args[0]) {
+		return nil, unsupported(node.Line, "stage name must be a string literal")
+	}
+	name, _ := node.StringArg()
+	stage = &Stage{Name: name}
+	for _, section := range node.Children {
+		if !section.HasBlock && section.Name != "agent" && section.Name != "failFast" {
+			return nil, unsupported(section.Line, "section '%s' requires a block", section.Name)
+		}
+		switch section.Name {
+		case "agent":
+			stage.Agent, err = c.convertAgent(section)
+		case "when":
+			stage.When, err = c.convertWhen(section)
+		case "environment":
+			stage.Environment, err = c.convertEnvironment(section)
+		case "tools":
+			stage.Tools, err = c.convertTools(section)
+		case "options":
+			stage.Options = &Options{}
+			stage.Options.Options, err = c.convertMethodCalls(section)
+		case "failFast":
+			var value *Value
+			if value, err = c.convertSingleValue(section); err == nil {
+				failFast, ok := value.Value.(bool)
+				if !ok {
+					err = unsupported(section.Line, "failFast must be true or false")
+				}
+				stage.FailFast = &failFast
+			}
+		case "steps":
+			branch := &Branch{Name: "default"}
+			if branch.Steps, err = c.convertSteps(section.Children); err == nil {
+				stage.Branches = []*Branch{branch}
+			}
+		case "parallel":
+			stage.Parallel, err = c.convertStages(section)
+		case "stages":
+			stage.Stages, err = c.convertStages(section)
+		case "post":
+			stage.Post, err = c.convertPost(section)
+		default:
+			err = unsupported(section.Line, "section '%s' in stage", section.Name)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return
+}
+
+func (c *jsonConverter) convertAgent(node *Node) (agent *Agent, err error) {
+	if !node.HasBlock {
+		if node.ArgsText != "any" && node.ArgsText != "none" {
+			return nil, unsupported(node.Line, "agent '%s'", node.ArgsText)
+		}
+		return &Agent{Type: node.ArgsText}, nil
+	}
+	if len(node.Children) != 1 {
+		return nil, unsupported(node.Line, "agent must have exactly one type")
+	}
+
+	typeNode := node.Children[0]
+	agent = &Agent{Type: typeNode.Name}
+	if !typeNode.HasBlock {
+		agent.Argument, err = c.convertSingleValue(typeNode)
+		return
+	}
+	agent.Arguments = []*KeyValue{}
+	for _, child := range typeNode.Children {
+		var value *Value
+		if value, err = c.convertSingleValue(child); err != nil {
+			return nil, err
+		}
+		agent.Arguments = append(agent.Arguments, &KeyValue{Key: child.Name, Value: value})
+	}
+	return
+}
+
+func (c *jsonConverter) convertEnvironment(node *Node) (environment []*KeyValue, err error) {
+	environment = []*KeyValue{}
+	for _, child := range node.Children {
+		if child.Name == "" || child.HasBlock || len(child.Args) < 2 || child.Args[0].Value != "=" {
+			return nil, unsupported(child.Line, "environment variable must be like: NAME = value")
+		}
+		environment = append(environment, &KeyValue{Key: child.Name, Value: c.convertValue(child.Args[1:])})
+	}
+	return
+}
+
+func (c *jsonConverter) convertTools(node *Node) (tools []*KeyValue, err error) {
+	tools = []*KeyValue{}
+	for _, child := range node.Children {
+		var value *Value
+		if value, err = c.convertSingleValue(child); err != nil {
+			return nil, err
+		}
+		tools = append(tools, &KeyValue{Key: child.Name, Value: value})
+	}
+	return
+}
+
+func (c *jsonConverter) convertMethodCalls(node *Node) (calls []*MethodCall, err error) {
+	calls = []*MethodCall{}
+	for _, child := range node.Children {
+		if child.Name == "" || child.HasBlock {
+			return nil, unsupported(child.Line, "statement in %s", node.Name)
+		}
+		call := &MethodCall{Name: child.Name}
+		if call.Arguments, err = c.convertArguments(child); err != nil {
+			return nil, err
+		}
+		calls = append(calls, call)
+	}
+	return
+}
+
+func (c *jsonConverter) convertPost(node *Node) (post *Post, err error) {
+	post = &Post{Conditions: []*PostCondition{}}
+	for _, child := range node.Children {
+		if !postConditions[child.Name] || !child.HasBlock {
+			return nil, unsupported(child.Line, "post condition '%s'", child.Name)
+		}
+		branch := &Branch{Name: "default"}
+		if branch.Steps, err = c.convertSteps(child.Children); err != nil {
+			return nil, err
+		}
+		post.Conditions = append(post.Conditions, &PostCondition{Condition: child.Name, Branches: []*Branch{branch}})
+	}
+	return
+}
+
+func (c *jsonConverter) convertWhen(node *Node) (when *When, err error) {
+	when = &When{Conditions: []*Condition{}}
+	for _, child := range node.Children {
+		if whenOptions[child.Name] {
+			var value *Value
+			if value, err = c.convertSingleValue(child); err != nil {
+				return nil, err
+			}
+			option, ok := value.Value.(bool)
+			if !ok {
+				return nil, unsupported(child.Line, "%s must be true or false", child.Name)
+			}
+			switch child.Name {
+			case "beforeAgent":
+				when.BeforeAgent = &option
+			case "beforeInput":
+				when.BeforeInput = &option
+			case "beforeOptions":
+				when.BeforeOptions = &option
+			}
+			continue
+		}
+
+		var condition *Condition
+		if condition, err = c.convertCondition(child); err != nil {
+			return nil, err
+		}
+		when.Conditions = append(when.Conditions, condition)
+	}
+	return
+}
+
+func (c *jsonConverter) convertCondition(node *Node) (condition *Condition, err error) {
+	condition = &Condition{Name: node.Name}
+	switch {
+	case (node.Name == "not" || node.Name == "allOf" || node.Name == "anyOf") && node.HasBlock:
+		for _, child := range node.Children {
+			var nested *Condition
+			if nested, err = c.convertCondition(child); err != nil {
+				return nil, err
+			}
+			condition.Children = append(condition.Children, nested)
+		}
+	case node.Name == "expression" && node.HasBlock:
+		condition.Arguments = &Arguments{Named: []*KeyValue{scriptBlock(node)}}
+	case node.Name != "" && !node.HasBlock:
+		var arguments Arguments
+		if arguments, err = c.convertArguments(node); err == nil {
+			condition.Arguments = &arguments
+		}
+	default:
+		err = unsupported(node.Line, "when condition '%s'", node.Name)
+	}
+	return
+}
+
+func (c *jsonConverter) convertSteps(nodes []*Node) (steps []*Step, err error) {
+	steps = []*Step{}
+	for _, node := range nodes {
+		var step *Step
+		if step, err = c.convertStep(node); err != nil {
+			return nil, err
+		}
+		steps = append(steps, step)
+	}
+	return
+}
+
+func (c *jsonConverter) convertStep(node *Node) (step *Step, err error) {
+	if node.Name == "" || keywords[node.Name] {
+		return nil, unsupported(node.Line, "Groovy statement out of a script block")
+	}
+	step = &Step{Name: node.Name}
+	if node.Name == "script" && node.HasBlock && len(node.Args) == 0 {
+		step.Arguments = Arguments{Named: []*KeyValue{scriptBlock(node)}}
+		return
+	}
+	if step.Arguments, err = c.convertArguments(node); err != nil {
+		return nil, err
+	}
+	if node.HasBlock {
+		if step.Children, err = c.convertSteps(node.Children); err != nil {
+			return nil, err
+		}
+	}
+	return
+}
+
+// convertArguments converts the arguments of a step or a method, such as: 'make', or (script: 'make')
+func (c *jsonConverter) convertArguments(node *Node) (arguments Arguments, err error) {
+	args := stripParentheses(node.Args)
+	if args == nil {
+		err = unsupported(node.Line, "arguments of '%s'", node.Name)
+		return
+	}
+
+	var positional [][]Token
+	arguments.Named = []*KeyValue{}
+	for _, arg := range splitArguments(args) {
+		if len(arg) > 2 && (arg[0].Type == TokenIdent || arg[0].Type == TokenString) &&
+			arg[1].Type == TokenPunct && arg[1].Value == ":" {
+			arguments.Named = append(arguments.Named, &KeyValue{Key: arg[0].Value, Value: c.convertValue(arg[2:])})
+		} else {
+			positional = append(positional, arg)
+		}
+	}
+
+	switch {
+	case len(positional) == 0:
+	case len(positional) == 1 && len(arguments.Named) == 0:
+		value := c.convertValue(positional[0])
+		if key, ok := defaultArguments[node.Name]; ok {
+			arguments.Named = []*KeyValue{{Key: key, Value: value}}
+		} else {
+			arguments = Arguments{Single: value}
+		}
+	default:
+		err = unsupported(node.Line, "positional arguments of '%s'", node.Name)
+	}
+	return
+}
+
+// convertSingleValue converts the only argument of a statement, such as: label 'go'
+func (c *jsonConverter) convertSingleValue(node *Node) (value *Value, err error) {
+	args := stripParentheses(node.Args)
+	if node.HasBlock || len(args) == 0 || len(splitArguments(args)) != 1 {
+		return nil, unsupported(node.Line, "'%s' requires a single value", node.Name)
+	}
+	return c.convertValue(args), nil
+}
+
+// convertValue converts the tokens of an expression to a literal value, or keeps the source text if it is not a literal
+func (c *jsonConverter) convertValue(tokens []Token) *Value {
+	if len(tokens) == 1 && isLiteral(c.text, tokens[0]) {
+		token := tokens[0]
+		switch token.Type {
+		case TokenString:
+			return &Value{IsLiteral: true, Value: token.Value}
+		case TokenNumber:
+			return &Value{IsLiteral: true, Value: json.Number(token.Value)}
+		default:
+			return &Value{IsLiteral: true, Value: token.Value == "true"}
+		}
+	}
+	return &Value{Value: c.text[tokens[0].Start:tokens[len(tokens)-1].End]}
+}
+
+// isLiteral checks if a token is a literal, a GString with the expressions is not a literal
+func isLiteral(text string, token Token) bool {
+	switch token.Type {
+	case TokenString:
+		return token.Quote[0] == '\'' || !strings.Contains(text[token.Start:token.End], "$")
+	case TokenNumber:
+		return numberRegexp.MatchString(token.Value)
+	case TokenIdent:
+		return token.Value == "true" || token.Value == "false"
+	}
+	return false
+}
+
+// stripParentheses removes the parentheses around all the arguments, it returns nil if the arguments are not a call,
+// such as: sh('make').trim()
+func stripParentheses(args []Token) []Token {
+	if len(args) == 0 {
+		return []Token{}
+	}
+	if args[0].Type == TokenPunct {
+		if args[0].Value != "(" {
+			return nil
+		}
+		if end := matchingBracket(args); end != len(args)-1 {
+			return nil
+		}
+		return args[1 : len(args)-1]
+	}
+	return args
+}
+
+// matchingBracket returns the index of the bracket which closes the first token
+func matchingBracket(tokens []Token) int {
+	depth := 0
+	for i, token := range tokens {
+		if token.Type != TokenPunct {
+			continue
+		}
+		switch token.Value {
+		case "(", "[", "{":
+			depth++
+		case ")", "]", "}":
+			if depth--; depth == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// splitArguments splits the arguments by the top level commas
+func splitArguments(tokens []Token) (args [][]Token) {
+	depth, start := 0, 0
+	for i, token := range tokens {
+		if token.Type != TokenPunct {
+			continue
+		}
+		switch token.Value {
+		case "(", "[", "{":
+			depth++
+		case ")", "]", "}":
+			depth--
+		case ",":
+			if depth == 0 {
+				args = append(args, tokens[start:i])
+				start = i + 1
+			}
+		}
+	}
+	if start < len(tokens) {
+		args = append(args, tokens[start:])
+	}
+	return
+}
+
+// scriptBlock returns the source code of a block as the scriptBlock argument, the common indentation is removed
+func scriptBlock(node *Node) *KeyValue {
+	return &KeyValue{Key: "scriptBlock", Value: &Value{IsLiteral: true, Value: dedent(node.BlockText)}}
+}
+
+func dedent(text string) string {
+	lines := strings.Split(text, "\n")
+	for len(lines) > 0 && strings.TrimSpace(lines[0]) == "" {
+		lines = lines[1:]
+	}
+	for len(lines) > 0 && strings.TrimSpace(lines[len(lines)-1]) == "" {
+		lines = lines[:len(lines)-1]
+	}
+
+	indent := -1
+	for _, line := range lines {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		if width := len(line) - len(strings.TrimLeft(line, " \t")); indent < 0 || width < indent {
+			indent = width
+		}
+	}
+	for i, line := range lines {
+		if len(line) >= indent && indent > 0 {
+			line = line[indent:]
+		}
+		lines[i] = strings.TrimRight(line, " \t\r")
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,62 @@
+// Copyright 2022 KubeSphere Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package common
+
+import (
+	"context"
+	"fmt"
+
+	authorizationv1 "k8s.io/api/authorization/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apiserver/pkg/authentication/user"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+//+kubebuilder:rbac:groups=authorization.k8s.io,resources=subjectaccessreviews,verbs=create
+
+// CheckAccess checks if the user is allowed to access the resource through a SubjectAccessReview.
+// It returns a forbidden error if the user is unknown or the access is denied.
+func CheckAccess(ctx context.Context, k8sClient client.Client, currentUser user.Info,
+	attributes authorizationv1.ResourceAttributes) error {
+	resource := schema.GroupResource{Group: attributes.Group, Resource: attributes.Resource}
+	if currentUser == nil || currentUser.GetName() == "" {
+		return errors.NewForbidden(resource, attributes.Name,
+			fmt.Errorf("unknown user cannot %s %s in %s", attributes.Verb, resource.String(), attributes.Namespace))
+	}
+
+	extra := map[string]authorizationv1.ExtraValue{}
+	for key, value := range currentUser.GetExtra() {
+		extra[key] = value
+	}
+	review := &authorizationv1.SubjectAccessReview{
+		Spec: authorizationv1.SubjectAccessReviewSpec{
+			ResourceAttributes: &attributes,
+			User:               currentUser.GetName(),
+			Groups:             currentUser.GetGroups(),
+			UID:                currentUser.GetUID(),
+			Extra:              extra,
+		},
+	}
+	if err := k8sClient.Create(ctx, review); err != nil {
+		return err
+	}
+	if !review.Status.Allowed {
+		return errors.NewForbidden(resource, attributes.Name,
+			fmt.Errorf("user %s cannot %s %s in %s", currentUser.GetName(), attributes.Verb, resource.String(), attributes.Namespace))
+	}
+	return nil
+}
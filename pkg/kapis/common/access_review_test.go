@@ -0,0 +1,96 @@
+// Copyright 2022 KubeSphere Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package common
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apiserver/pkg/authentication/user"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+// accessReviewClient allows the users in the allowed group
+type accessReviewClient struct {
+	client.Client
+	review *authorizationv1.SubjectAccessReview
+}
+
+func (c *accessReviewClient) Create(ctx context.Context, obj client.Object, opts ...client.CreateOption) error {
+	if review, ok := obj.(*authorizationv1.SubjectAccessReview); ok {
+		c.review = review
+		for _, group := range review.Spec.Groups {
+			review.Status.Allowed = review.Status.Allowed || group == "allowed"
+		}
+		return nil
+	}
+	return c.Client.Create(ctx, obj, opts...)
+}
+
+func TestCheckAccess(t *testing.T) {
+	attributes := authorizationv1.ResourceAttributes{
+		Namespace: "ns",
+		Verb:      "create",
+		Resource:  "secrets",
+	}
+
+	tests := []struct {
+		name          string
+		user          user.Info
+		wantForbidden bool
+		verify        func(t *testing.T, review *authorizationv1.SubjectAccessReview)
+	}{{
+		name:          "unknown user",
+		wantForbidden: true,
+	}, {
+		name:          "anonymous user",
+		user:          &user.DefaultInfo{},
+		wantForbidden: true,
+	}, {
+		name:          "denied",
+		user:          &user.DefaultInfo{Name: "tester", Groups: []string{"denied"}},
+		wantForbidden: true,
+	}, {
+		name: "allowed",
+		user: &user.DefaultInfo{
+			Name:   "tester",
+			UID:    "uid",
+			Groups: []string{"allowed"},
+			Extra:  map[string][]string{"scopes": {"all"}},
+		},
+		verify: func(t *testing.T, review *authorizationv1.SubjectAccessReview) {
+			assert.Equal(t, "tester", review.Spec.User)
+			assert.Equal(t, "uid", review.Spec.UID)
+			assert.Equal(t, authorizationv1.ExtraValue{"all"}, review.Spec.Extra["scopes"])
+			assert.Equal(t, attributes, *review.Spec.ResourceAttributes)
+		},
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &accessReviewClient{Client: fake.NewClientBuilder().WithScheme(runtime.NewScheme()).Build()}
+			err := CheckAccess(context.Background(), c, tt.user, attributes)
+			assert.Equal(t, tt.wantForbidden, errors.IsForbidden(err), err)
+			if tt.verify != nil {
+				tt.verify(t, c.review)
+			}
+		})
+	}
+}
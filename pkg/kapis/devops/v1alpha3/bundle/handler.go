@@ -0,0 +1,128 @@
+/*
+Copyright 2022 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package bundle
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/emicklei/go-restful"
+	"kubesphere.io/devops/pkg/kapis"
+	"kubesphere.io/devops/pkg/kapis/devops/v1alpha3/common"
+	"kubesphere.io/devops/pkg/models/bundle"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/yaml"
+)
+
+type handler struct {
+	client.Client
+}
+
+func newHandler(options *common.Options) *handler {
+	return &handler{
+		Client: options.GenericClient,
+	}
+}
+
+func (h *handler) handleExport(request *restful.Request, response *restful.Response) {
+	devopsName := request.PathParameter(common.DevopsPathParameter.Data().Name)
+	format := request.QueryParameter(FormatQueryParameter.Data().Name)
+	if format == "" {
+		format = formatYAML
+	}
+	if format != formatYAML && format != formatTar {
+		kapis.HandleBadRequest(response, request, fmt.Errorf("unsupported format: %s", format))
+		return
+	}
+
+	exported, err := bundle.Export(context.Background(), h, devopsName)
+	if err != nil {
+		kapis.HandleError(request, response, err)
+		return
+	}
+
+	buf := &bytes.Buffer{}
+	contentType, fileName := mimeYAML, devopsName+".yaml"
+	if format == formatTar {
+		contentType, fileName = mimeGzip, devopsName+".tar.gz"
+		err = exported.WriteTar(buf)
+	} else {
+		var data []byte
+		if data, err = yaml.Marshal(exported); err == nil {
+			_, err = buf.Write(data)
+		}
+	}
+	if err != nil {
+		kapis.HandleError(request, response, err)
+		return
+	}
+
+	response.Header().Set(restful.HEADER_ContentType, contentType)
+	response.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", fileName))
+	_, _ = response.Write(buf.Bytes())
+}
+
+func (h *handler) handleImport(request *restful.Request, response *restful.Response) {
+	options, err := parseImportOptions(request)
+	if err != nil {
+		kapis.HandleBadRequest(response, request, err)
+		return
+	}
+
+	var data []byte
+	if data, err = kapis.ReadAllWithLimit(request.Request.Body, bundle.MaxSize); err != nil {
+		kapis.HandleError(request, response, err)
+		return
+	}
+	var target *bundle.Bundle
+	if target, err = bundle.Parse(data); err == bundle.ErrTooLarge {
+		kapis.HandleError(request, response, restful.NewError(http.StatusRequestEntityTooLarge, err.Error()))
+		return
+	} else if err != nil {
+		kapis.HandleBadRequest(response, request, err)
+		return
+	}
+
+	kapis.ResponseWriter{Response: response}.WriteEntityOrError(bundle.Import(context.Background(), h, target, options))
+}
+
+func parseImportOptions(request *restful.Request) (options bundle.ImportOptions, err error) {
+	options.Namespace = request.PathParameter(common.DevopsPathParameter.Data().Name)
+	options.ConflictStrategy = bundle.ConflictStrategy(request.QueryParameter(StrategyQueryParameter.Data().Name))
+	if dryRun := request.QueryParameter(DryRunQueryParameter.Data().Name); dryRun != "" {
+		if options.DryRun, err = strconv.ParseBool(dryRun); err != nil {
+			err = fmt.Errorf("invalid dryRun: %s", dryRun)
+			return
+		}
+	}
+
+	options.NameMapping = map[string]string{}
+	for _, mapping := range request.QueryParameters(NameMappingQueryParameter.Data().Name) {
+		pair := strings.SplitN(mapping, ":", 2)
+		if len(pair) != 2 || pair[0] == "" || pair[1] == "" {
+			err = fmt.Errorf("invalid nameMapping: %s, the format is old:new", mapping)
+			return
+		}
+		options.NameMapping[pair[0]] = pair[1]
+	}
+	err = options.Validate()
+	return
+}
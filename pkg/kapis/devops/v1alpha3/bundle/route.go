@@ -0,0 +1,80 @@
+/*
+Copyright 2022 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package bundle
+
+import (
+	"net/http"
+
+	"github.com/emicklei/go-restful"
+	restfulspec "github.com/emicklei/go-restful-openapi"
+	"kubesphere.io/devops/pkg/api"
+	"kubesphere.io/devops/pkg/constants"
+	"kubesphere.io/devops/pkg/kapis/devops/v1alpha3/common"
+	"kubesphere.io/devops/pkg/models/bundle"
+)
+
+//+kubebuilder:rbac:groups=devops.kubesphere.io,resources=templates;webhooks,verbs=get;list;update;create
+
+const (
+	mimeYAML = "application/yaml"
+	mimeGzip = "application/gzip"
+
+	formatYAML = "yaml"
+	formatTar  = "tar"
+)
+
+var (
+	// FormatQueryParameter is the query parameter definition of the bundle format
+	FormatQueryParameter = restful.QueryParameter("format", "Format of the bundle, allowed values: yaml and tar").
+				DefaultValue(formatYAML)
+	// StrategyQueryParameter is the query parameter definition of the conflict strategy
+	StrategyQueryParameter = restful.QueryParameter("strategy",
+		"What to do if a resource exists, allowed values: skip, overwrite and rename").DefaultValue(string(bundle.ConflictSkip))
+	// DryRunQueryParameter is the query parameter definition of the dry-run mode
+	DryRunQueryParameter = restful.QueryParameter("dryRun", "Report what would happen without changing anything").
+				DataType("boolean").DefaultValue("false")
+	// NameMappingQueryParameter is the query parameter definition of the name mapping
+	NameMappingQueryParameter = restful.QueryParameter("nameMapping",
+		"Map a name in the bundle to a new one, the format is old:new. It could be repeated.").AllowMultiple(true)
+)
+
+// RegisterRoutes is for registering the bundle routes into WebService.
+func RegisterRoutes(service *restful.WebService, options *common.Options) {
+	handler := newHandler(options)
+
+	service.Route(service.GET("/devops/{devops}/bundle").
+		To(handler.handleExport).
+		Param(common.DevopsPathParameter).
+		Param(FormatQueryParameter).
+		Doc("Export the Pipelines, Templates, GitRepositories, Webhooks, Applications and the credential references "+
+			"of a DevOps project as a bundle. The secret values of the credentials are never exported.").
+		Produces(mimeYAML, mimeGzip).
+		Returns(http.StatusOK, api.StatusOK, bundle.Bundle{}).
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.DevOpsProjectTag}))
+
+	service.Route(service.POST("/devops/{devops}/bundle").
+		To(handler.handleImport).
+		Param(common.DevopsPathParameter).
+		Param(StrategyQueryParameter).
+		Param(DryRunQueryParameter).
+		Param(NameMappingQueryParameter).
+		Doc("Import a bundle in YAML or gzipped tar format into a DevOps project").
+		Consumes(mimeYAML, mimeGzip, restful.MIME_JSON, restful.MIME_OCTET).
+		Reads(bundle.Bundle{}).
+		Returns(http.StatusOK, api.StatusOK, bundle.ImportReport{}).
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.DevOpsProjectTag}))
+}
@@ -0,0 +1,184 @@
+/*
+Copyright 2022 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package bundle
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/emicklei/go-restful"
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"kubesphere.io/devops/pkg/api/devops/v1alpha3"
+	gitopsv1alpha1 "kubesphere.io/devops/pkg/api/gitops/v1alpha1"
+	"kubesphere.io/devops/pkg/apiserver/runtime"
+	"kubesphere.io/devops/pkg/kapis/devops/v1alpha3/common"
+	"kubesphere.io/devops/pkg/models/bundle"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestRegisterRoutes(t *testing.T) {
+	schema, err := v1alpha3.SchemeBuilder.Register().Build()
+	assert.Nil(t, err)
+	assert.Nil(t, gitopsv1alpha1.SchemeBuilder.AddToScheme(schema))
+	assert.Nil(t, v1.SchemeBuilder.AddToScheme(schema))
+
+	template := &v1alpha3.Template{}
+	template.SetNamespace("source")
+	template.SetName("template")
+	template.Spec.Template = "echo 1"
+	c := fake.NewClientBuilder().WithScheme(schema).WithObjects(template).Build()
+
+	service := runtime.NewWebService(v1alpha3.GroupVersion)
+	RegisterRoutes(service, &common.Options{GenericClient: c})
+	container := restful.NewContainer()
+	container.Add(service)
+
+	exported, err := bundle.Export(context.Background(), c, "source")
+	assert.Nil(t, err)
+	tarData := &bytes.Buffer{}
+	assert.Nil(t, exported.WriteTar(tarData))
+
+	largeTemplate := template.DeepCopy()
+	largeTemplate.Spec.Template = strings.Repeat("a", bundle.MaxSize)
+	large := &bundle.Bundle{APIVersion: bundle.APIVersion, Kind: bundle.Kind, Templates: []v1alpha3.Template{*largeTemplate}}
+	largeTarData := &bytes.Buffer{}
+	assert.Nil(t, large.WriteTar(largeTarData))
+
+	tests := []struct {
+		name        string
+		method      string
+		uri         string
+		contentType string
+		body        io.Reader
+		wantCode    int
+		verify      func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{{
+		name:     "export as YAML",
+		method:   http.MethodGet,
+		uri:      "/devops/source/bundle",
+		wantCode: http.StatusOK,
+		verify: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+			assert.Equal(t, mimeYAML, recorder.Header().Get(restful.HEADER_ContentType))
+			parsed, err := bundle.Parse(recorder.Body.Bytes())
+			assert.Nil(t, err)
+			assert.Len(t, parsed.Templates, 1)
+		},
+	}, {
+		name:     "export as tar",
+		method:   http.MethodGet,
+		uri:      "/devops/source/bundle?format=tar",
+		wantCode: http.StatusOK,
+		verify: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+			assert.Equal(t, mimeGzip, recorder.Header().Get(restful.HEADER_ContentType))
+			assert.Contains(t, recorder.Header().Get("Content-Disposition"), "source.tar.gz")
+			parsed, err := bundle.Parse(recorder.Body.Bytes())
+			assert.Nil(t, err)
+			assert.Len(t, parsed.Templates, 1)
+		},
+	}, {
+		name:     "unsupported format",
+		method:   http.MethodGet,
+		uri:      "/devops/source/bundle?format=zip",
+		wantCode: http.StatusBadRequest,
+	}, {
+		name:        "invalid bundle",
+		method:      http.MethodPost,
+		uri:         "/devops/target/bundle",
+		contentType: mimeYAML,
+		body:        bytes.NewBufferString("kind: Pipeline"),
+		wantCode:    http.StatusBadRequest,
+	}, {
+		name:        "too large bundle",
+		method:      http.MethodPost,
+		uri:         "/devops/target/bundle",
+		contentType: mimeYAML,
+		body:        bytes.NewReader(make([]byte, bundle.MaxSize+1)),
+		wantCode:    http.StatusRequestEntityTooLarge,
+	}, {
+		name:        "too large tar archive after extracting",
+		method:      http.MethodPost,
+		uri:         "/devops/target/bundle",
+		contentType: mimeGzip,
+		body:        bytes.NewReader(largeTarData.Bytes()),
+		wantCode:    http.StatusRequestEntityTooLarge,
+	}, {
+		name:        "invalid strategy",
+		method:      http.MethodPost,
+		uri:         "/devops/target/bundle?strategy=merge",
+		contentType: mimeGzip,
+		body:        bytes.NewReader(tarData.Bytes()),
+		wantCode:    http.StatusBadRequest,
+	}, {
+		name:        "invalid name mapping",
+		method:      http.MethodPost,
+		uri:         "/devops/target/bundle?nameMapping=template",
+		contentType: mimeGzip,
+		body:        bytes.NewReader(tarData.Bytes()),
+		wantCode:    http.StatusBadRequest,
+	}, {
+		name:        "dry run",
+		method:      http.MethodPost,
+		uri:         "/devops/target/bundle?dryRun=true&nameMapping=template:new",
+		contentType: mimeGzip,
+		body:        bytes.NewReader(tarData.Bytes()),
+		wantCode:    http.StatusOK,
+		verify: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+			report := &bundle.ImportReport{}
+			assert.Nil(t, json.Unmarshal(recorder.Body.Bytes(), report))
+			assert.True(t, report.DryRun)
+			assert.Equal(t, []bundle.ImportResult{{
+				Kind: "Template", Name: "template", TargetName: "new", Action: bundle.ActionCreate,
+			}}, report.Results)
+			assert.NotNil(t, c.Get(context.Background(), types.NamespacedName{Namespace: "target", Name: "new"},
+				&v1alpha3.Template{}))
+		},
+	}, {
+		name:        "import",
+		method:      http.MethodPost,
+		uri:         "/devops/target/bundle",
+		contentType: mimeGzip,
+		body:        bytes.NewReader(tarData.Bytes()),
+		wantCode:    http.StatusOK,
+		verify: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+			imported := &v1alpha3.Template{}
+			assert.Nil(t, c.Get(context.Background(), types.NamespacedName{Namespace: "target", Name: "template"}, imported))
+			assert.Equal(t, "echo 1", imported.Spec.Template)
+		},
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			request := httptest.NewRequest(tt.method, "/kapis/devops.kubesphere.io/v1alpha3"+tt.uri, tt.body)
+			if tt.contentType != "" {
+				request.Header.Set(restful.HEADER_ContentType, tt.contentType)
+			}
+			recorder := httptest.NewRecorder()
+			container.ServeHTTP(recorder, request)
+			assert.Equal(t, tt.wantCode, recorder.Code, recorder.Body.String())
+			if tt.verify != nil {
+				tt.verify(t, recorder)
+			}
+		})
+	}
+}
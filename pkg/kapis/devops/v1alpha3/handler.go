@@ -23,6 +23,7 @@ import (
 	"k8s.io/klog/v2"
 	"kubesphere.io/devops/pkg/api/devops/v1alpha3"
 	"kubesphere.io/devops/pkg/apiserver/query"
+	apiserverrequest "kubesphere.io/devops/pkg/apiserver/request"
 	devopsClient "kubesphere.io/devops/pkg/client/devops"
 	"kubesphere.io/devops/pkg/client/k8s"
 	"kubesphere.io/devops/pkg/constants"
@@ -176,7 +177,13 @@ func (h *devopsHandler) CreatePipeline(request *restful.Request, response *restf
 		return
 	}
 
+	setLastModifier(request, &pipeline)
 	if client, err := h.getDevOps(request); err == nil {
+		if request.QueryParameter(dryRunParameter) == DryRunXML {
+			config, err := client.PreviewPipelineConfig(devops, &pipeline, false)
+			errorHandle(request, response, config, err)
+			return
+		}
 		created, err := client.CreatePipelineObj(devops, &pipeline)
 		errorHandle(request, response, created, err)
 	} else {
@@ -196,7 +203,13 @@ func (h *devopsHandler) UpdatePipeline(request *restful.Request, response *restf
 		return
 	}
 
+	setLastModifier(request, &pipeline)
 	if client, err := h.getDevOps(request); err == nil {
+		if request.QueryParameter(dryRunParameter) == DryRunXML {
+			config, err := client.PreviewPipelineConfig(devops, &pipeline, true)
+			errorHandle(request, response, config, err)
+			return
+		}
 		obj, err := client.UpdatePipelineObj(devops, &pipeline)
 		errorHandle(request, response, obj, err)
 	} else {
@@ -204,6 +217,24 @@ func (h *devopsHandler) UpdatePipeline(request *restful.Request, response *restf
 	}
 }
 
+// GetPipelineDrift reports the changes of a Pipeline which were made in Jenkins directly
+func (h *devopsHandler) GetPipelineDrift(request *restful.Request, response *restful.Response) {
+	devops := request.PathParameter("devops")
+	pipeline := request.PathParameter("pipeline")
+
+	if client, err := h.getDevOps(request); err == nil {
+		drift, err := client.GetPipelineDrift(devops, pipeline)
+		errorHandle(request, response, drift, err)
+	} else {
+		kapis.HandleBadRequest(response, request, err)
+	}
+}
+
+const dryRunParameter = "dryRun"
+
+// DryRunXML is the dryRun value to preview the Jenkins job config.xml instead of saving a Pipeline
+const DryRunXML = "xml"
+
 // GenericPayload represents a generic HTTP request payload data structure
 type GenericPayload struct {
 	Data string `json:"data"`
@@ -250,11 +281,25 @@ func (h *devopsHandler) UpdateJenkinsfile(request *restful.Request, response *re
 
 	var client devops.DevopsOperator
 	if client, err = h.getDevOps(request); err == nil {
-		err = client.UpdateJenkinsfile(projectName, pipelineName, mode, payload.Data)
+		err = client.UpdateJenkinsfile(projectName, pipelineName, mode, payload.Data, getCurrentUserName(request))
 	}
 	errorHandle(request, response, NewSuccessResponse(), err)
 }
 
+// setLastModifier records the current user as the last modifier of the Pipeline, it is the author of the next revision
+func setLastModifier(request *restful.Request, pipeline *v1alpha3.Pipeline) {
+	if userName := getCurrentUserName(request); userName != "" {
+		pipeline.SetLastModifier(userName)
+	}
+}
+
+func getCurrentUserName(request *restful.Request) string {
+	if currentUser, ok := apiserverrequest.UserFrom(request.Request.Context()); ok && currentUser != nil {
+		return currentUser.GetName()
+	}
+	return ""
+}
+
 func (h *devopsHandler) DeletePipeline(request *restful.Request, response *restful.Response) {
 	devops := request.PathParameter("devops")
 	pipeline := request.PathParameter("pipeline")
@@ -0,0 +1,125 @@
+/*
+Copyright 2022 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pipeline
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/emicklei/go-restful"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apiserver/pkg/authentication/user"
+	"kubesphere.io/devops/pkg/api/devops/v1alpha3"
+	apiserverrequest "kubesphere.io/devops/pkg/apiserver/request"
+	"kubesphere.io/devops/pkg/constants"
+	"kubesphere.io/devops/pkg/kapis"
+	"kubesphere.io/devops/pkg/kapis/common"
+	modelpipeline "kubesphere.io/devops/pkg/models/pipeline"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// clonePipeline creates a copy of the Pipeline in the same or another DevOps project
+func (h *apiHandler) clonePipeline(request *restful.Request, response *restful.Response) {
+	options := modelpipeline.CloneOptions{}
+	if err := request.ReadEntity(&options); err != nil {
+		kapis.HandleBadRequest(response, request, err)
+		return
+	}
+
+	ctx := context.Background()
+	source := &v1alpha3.Pipeline{}
+	key := client.ObjectKey{Namespace: request.PathParameter("namespace"), Name: request.PathParameter("pipeline")}
+	if err := h.client.Get(ctx, key, source); err != nil {
+		kapis.HandleError(request, response, err)
+		return
+	}
+
+	pipeline, err := modelpipeline.Clone(source, options)
+	if err != nil {
+		kapis.HandleBadRequest(response, request, err)
+		return
+	}
+
+	currentUser, _ := apiserverrequest.UserFrom(request.Request.Context())
+	if pipeline.Namespace != source.Namespace {
+		// the request is authorized against the namespace of the source Pipeline only
+		if err = h.checkCreatePermission(ctx, currentUser, pipeline.Namespace); err == nil {
+			err = h.checkGitRepository(ctx, pipeline)
+		}
+		if err == nil {
+			err = h.checkSCMCredentials(ctx, pipeline)
+		}
+		if err != nil {
+			kapis.HandleError(request, response, err)
+			return
+		}
+	}
+
+	if currentUser != nil && currentUser.GetName() != "" {
+		pipeline.Annotations[constants.CreatorAnnotationKey] = currentUser.GetName()
+		pipeline.SetLastModifier(currentUser.GetName())
+	}
+	if err = h.client.Create(ctx, pipeline); err != nil {
+		kapis.HandleError(request, response, err)
+		return
+	}
+	_ = response.WriteEntity(pipeline)
+}
+
+// checkCreatePermission checks if the user is allowed to create Pipelines in the namespace
+func (h *apiHandler) checkCreatePermission(ctx context.Context, currentUser user.Info, namespace string) error {
+	return common.CheckAccess(ctx, h.client, currentUser, authorizationv1.ResourceAttributes{
+		Namespace: namespace,
+		Verb:      "create",
+		Group:     v1alpha3.GroupVersion.Group,
+		Resource:  v1alpha3.ResourcePluralPipeline,
+	})
+}
+
+// checkGitRepository makes sure the GitRepository of a pipeline-as-code Pipeline exists in the target namespace
+func (h *apiHandler) checkGitRepository(ctx context.Context, pipeline *v1alpha3.Pipeline) error {
+	if !pipeline.IsPipelineAsCode() {
+		return nil
+	}
+	repoName := pipeline.Annotations[v1alpha3.PipelineAsCodeGitRepoAnnoKey]
+	err := h.client.Get(ctx, client.ObjectKey{Namespace: pipeline.Namespace, Name: repoName}, &v1alpha3.GitRepository{})
+	if errors.IsNotFound(err) {
+		return errors.NewBadRequest(fmt.Sprintf("GitRepository %s is not found in %s", repoName, pipeline.Namespace))
+	}
+	return err
+}
+
+// checkSCMCredentials makes sure the credentials of the SCM source exist in the target namespace,
+// a multi-branch Pipeline cannot scan its repository without them
+func (h *apiHandler) checkSCMCredentials(ctx context.Context, pipeline *v1alpha3.Pipeline) error {
+	report, err := modelpipeline.CheckCredentials(ctx, h.client, pipeline)
+	if err != nil {
+		return err
+	}
+	var problems []string
+	for _, result := range report.Results {
+		if result.Status != modelpipeline.CredentialFound && strings.HasPrefix(result.Source, modelpipeline.CredentialSourceSCMPrefix) {
+			problems = append(problems, result.Message)
+		}
+	}
+	if len(problems) > 0 {
+		return errors.NewBadRequest(strings.Join(problems, "; "))
+	}
+	return nil
+}
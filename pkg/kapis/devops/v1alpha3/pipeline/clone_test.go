@@ -0,0 +1,198 @@
+/*
+Copyright 2022 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pipeline
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/emicklei/go-restful"
+	"github.com/stretchr/testify/assert"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apiserver/pkg/authentication/user"
+	"kubesphere.io/devops/pkg/api/devops/v1alpha3"
+	apiserverrequest "kubesphere.io/devops/pkg/apiserver/request"
+	"kubesphere.io/devops/pkg/apiserver/runtime"
+	"kubesphere.io/devops/pkg/constants"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+// accessReviewClient answers the SubjectAccessReview according to the allowed namespaces
+type accessReviewClient struct {
+	client.Client
+	allowedNamespaces map[string]bool
+}
+
+func (c *accessReviewClient) Create(ctx context.Context, obj client.Object, opts ...client.CreateOption) error {
+	if review, ok := obj.(*authorizationv1.SubjectAccessReview); ok {
+		review.Status.Allowed = c.allowedNamespaces[review.Spec.ResourceAttributes.Namespace]
+		return nil
+	}
+	return c.Client.Create(ctx, obj, opts...)
+}
+
+func TestClonePipeline(t *testing.T) {
+	schema, err := v1alpha3.SchemeBuilder.Register().Build()
+	assert.Nil(t, err)
+	assert.Nil(t, v1.AddToScheme(schema))
+
+	source := &v1alpha3.Pipeline{}
+	source.SetNamespace("ns")
+	source.SetName("source")
+	source.SetAnnotations(map[string]string{
+		v1alpha3.PipelineJenkinsfileEditModeAnnoKey: v1alpha3.PipelineJenkinsfileEditModeRaw,
+		v1alpha3.PipelineSyncStatusAnnoKey:          "successful",
+	})
+	source.Spec.Type = v1alpha3.NoScmPipelineType
+	source.Spec.Pipeline = &v1alpha3.NoScmPipeline{Name: "source", Jenkinsfile: "echo 1"}
+
+	pacSource := source.DeepCopy()
+	pacSource.SetName("pac")
+	pacSource.Annotations[v1alpha3.PipelineAsCodeGitRepoAnnoKey] = "repo"
+
+	multiBranchSource := source.DeepCopy()
+	multiBranchSource.SetName("multi-branch")
+	multiBranchSource.Spec = v1alpha3.PipelineSpec{
+		Type: v1alpha3.MultiBranchPipelineType,
+		MultiBranchPipeline: &v1alpha3.MultiBranchPipeline{
+			Name:       "multi-branch",
+			SourceType: v1alpha3.SourceTypeGit,
+			GitSource:  &v1alpha3.GitSource{Url: "https://github.com/kubesphere/ks-devops", CredentialId: "git"},
+			ScriptPath: "Jenkinsfile",
+		},
+	}
+	credential := &v1.Secret{}
+	credential.SetNamespace("allowed")
+	credential.SetName("git")
+	credential.Type = v1alpha3.SecretTypeBasicAuth
+
+	tests := []struct {
+		name     string
+		pipeline string
+		body     string
+		user     user.Info
+		objects  []client.Object
+		wantCode int
+		verify   func(t *testing.T, c client.Client)
+	}{{
+		name:     "invalid body",
+		pipeline: "source",
+		body:     "invalid",
+		wantCode: http.StatusBadRequest,
+	}, {
+		name:     "source not found",
+		pipeline: "fake",
+		body:     `{"name": "copy"}`,
+		wantCode: http.StatusNotFound,
+	}, {
+		name:     "invalid options",
+		pipeline: "source",
+		body:     `{"name": "copy", "branch": "main"}`,
+		wantCode: http.StatusBadRequest,
+	}, {
+		name:     "copy in the same namespace",
+		pipeline: "source",
+		body:     `{"name": "copy", "description": "copied"}`,
+		user:     &user.DefaultInfo{Name: "tester"},
+		wantCode: http.StatusOK,
+		verify: func(t *testing.T, c client.Client) {
+			pipeline := &v1alpha3.Pipeline{}
+			assert.Nil(t, c.Get(context.Background(), types.NamespacedName{Namespace: "ns", Name: "copy"}, pipeline))
+			assert.Equal(t, "copied", pipeline.Spec.Pipeline.Description)
+			assert.Equal(t, "tester", pipeline.Annotations[constants.CreatorAnnotationKey])
+			assert.Equal(t, v1alpha3.PipelineJenkinsfileEditModeRaw, pipeline.Annotations[v1alpha3.PipelineJenkinsfileEditModeAnnoKey])
+			assert.Empty(t, pipeline.Annotations[v1alpha3.PipelineSyncStatusAnnoKey])
+		},
+	}, {
+		name:     "the copy exists",
+		pipeline: "source",
+		body:     `{"name": "pac"}`,
+		wantCode: http.StatusConflict,
+	}, {
+		name:     "copy into another namespace without a user",
+		pipeline: "source",
+		body:     `{"name": "copy", "namespace": "allowed"}`,
+		wantCode: http.StatusForbidden,
+	}, {
+		name:     "copy into a forbidden namespace",
+		pipeline: "source",
+		body:     `{"name": "copy", "namespace": "forbidden"}`,
+		user:     &user.DefaultInfo{Name: "tester"},
+		wantCode: http.StatusForbidden,
+	}, {
+		name:     "copy into an allowed namespace",
+		pipeline: "source",
+		body:     `{"name": "copy", "namespace": "allowed"}`,
+		user:     &user.DefaultInfo{Name: "tester"},
+		wantCode: http.StatusOK,
+		verify: func(t *testing.T, c client.Client) {
+			pipeline := &v1alpha3.Pipeline{}
+			assert.Nil(t, c.Get(context.Background(), types.NamespacedName{Namespace: "allowed", Name: "copy"}, pipeline))
+		},
+	}, {
+		name:     "the GitRepository is missing in another namespace",
+		pipeline: "pac",
+		body:     `{"name": "copy", "namespace": "allowed"}`,
+		user:     &user.DefaultInfo{Name: "tester"},
+		wantCode: http.StatusBadRequest,
+	}, {
+		name:     "the credential of the SCM source is missing in another namespace",
+		pipeline: "multi-branch",
+		body:     `{"name": "copy", "namespace": "allowed"}`,
+		user:     &user.DefaultInfo{Name: "tester"},
+		wantCode: http.StatusBadRequest,
+	}, {
+		name:     "the credential of the SCM source exists in another namespace",
+		pipeline: "multi-branch",
+		body:     `{"name": "copy", "namespace": "allowed"}`,
+		user:     &user.DefaultInfo{Name: "tester"},
+		objects:  []client.Object{credential.DeepCopy()},
+		wantCode: http.StatusOK,
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &accessReviewClient{
+				Client: fake.NewClientBuilder().WithScheme(schema).
+					WithObjects(append(tt.objects, source.DeepCopy(), pacSource.DeepCopy(), multiBranchSource.DeepCopy())...).Build(),
+				allowedNamespaces: map[string]bool{"allowed": true},
+			}
+			ws := runtime.NewWebService(v1alpha3.GroupVersion)
+			RegisterRoutes(ws, c)
+			container := restful.NewContainer()
+			container.Add(ws)
+
+			request := httptest.NewRequest(http.MethodPost,
+				"/kapis/devops.kubesphere.io/v1alpha3/namespaces/ns/pipelines/"+tt.pipeline+"/clone", bytes.NewBufferString(tt.body))
+			request.Header.Set(restful.HEADER_ContentType, restful.MIME_JSON)
+			if tt.user != nil {
+				request = request.WithContext(apiserverrequest.WithUser(request.Context(), tt.user))
+			}
+			recorder := httptest.NewRecorder()
+			container.ServeHTTP(recorder, request)
+			assert.Equal(t, tt.wantCode, recorder.Code, recorder.Body.String())
+			if tt.verify != nil {
+				tt.verify(t, c)
+			}
+		})
+	}
+}
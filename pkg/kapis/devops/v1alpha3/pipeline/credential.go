@@ -0,0 +1,47 @@
+/*
+Copyright 2022 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pipeline
+
+import (
+	"context"
+
+	"github.com/emicklei/go-restful"
+	"kubesphere.io/devops/pkg/api/devops/v1alpha3"
+	"kubesphere.io/devops/pkg/kapis"
+	modelpipeline "kubesphere.io/devops/pkg/models/pipeline"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+//+kubebuilder:rbac:groups="",resources=secrets,verbs=get
+
+// checkCredentials reports the credentials referenced by the Pipeline which are missing or have unexpected types
+func (h *apiHandler) checkCredentials(request *restful.Request, response *restful.Response) {
+	ctx := context.Background()
+	pipeline := &v1alpha3.Pipeline{}
+	key := client.ObjectKey{Namespace: request.PathParameter("namespace"), Name: request.PathParameter("pipeline")}
+	if err := h.client.Get(ctx, key, pipeline); err != nil {
+		kapis.HandleError(request, response, err)
+		return
+	}
+
+	report, err := modelpipeline.CheckCredentials(ctx, h.client, pipeline)
+	if err != nil {
+		kapis.HandleError(request, response, err)
+		return
+	}
+	_ = response.WriteEntity(report)
+}
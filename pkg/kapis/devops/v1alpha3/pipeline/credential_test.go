@@ -0,0 +1,98 @@
+/*
+Copyright 2022 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pipeline
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/emicklei/go-restful"
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	"kubesphere.io/devops/pkg/api/devops/v1alpha3"
+	"kubesphere.io/devops/pkg/apiserver/runtime"
+	modelpipeline "kubesphere.io/devops/pkg/models/pipeline"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestCheckCredentials(t *testing.T) {
+	schema, err := v1alpha3.SchemeBuilder.Register().Build()
+	assert.Nil(t, err)
+	assert.Nil(t, v1.SchemeBuilder.AddToScheme(schema))
+
+	pipeline := &v1alpha3.Pipeline{}
+	pipeline.SetNamespace("ns")
+	pipeline.SetName("pipeline")
+	pipeline.Spec.Type = v1alpha3.NoScmPipelineType
+	pipeline.Spec.Pipeline = &v1alpha3.NoScmPipeline{
+		Name: "pipeline",
+		Jenkinsfile: `withCredentials([usernamePassword(credentialsId: 'basic', usernameVariable: 'USER', passwordVariable: 'PASS')]) {
+  git url: 'https://github.com/kubesphere/ks-devops', credentialsId: 'missing'
+}`,
+	}
+
+	secret := &v1.Secret{}
+	secret.SetNamespace("ns")
+	secret.SetName("basic")
+	secret.Type = v1alpha3.SecretTypeBasicAuth
+
+	tests := []struct {
+		name     string
+		pipeline string
+		wantCode int
+		verify   func(t *testing.T, report *modelpipeline.CredentialCheckReport)
+	}{{
+		name:     "pipeline not found",
+		pipeline: "fake",
+		wantCode: http.StatusNotFound,
+	}, {
+		name:     "one of the credentials is missing",
+		pipeline: "pipeline",
+		wantCode: http.StatusOK,
+		verify: func(t *testing.T, report *modelpipeline.CredentialCheckReport) {
+			assert.False(t, report.Resolved)
+			if assert.Len(t, report.Results, 2) {
+				assert.Equal(t, "basic", report.Results[0].ID)
+				assert.Equal(t, modelpipeline.CredentialFound, report.Results[0].Status)
+				assert.Equal(t, "missing", report.Results[1].ID)
+				assert.Equal(t, modelpipeline.CredentialMissing, report.Results[1].Status)
+			}
+		},
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := fake.NewClientBuilder().WithScheme(schema).WithObjects(pipeline.DeepCopy(), secret.DeepCopy()).Build()
+			ws := runtime.NewWebService(v1alpha3.GroupVersion)
+			RegisterRoutes(ws, c)
+			container := restful.NewContainer()
+			container.Add(ws)
+
+			request := httptest.NewRequest(http.MethodGet,
+				"/kapis/devops.kubesphere.io/v1alpha3/namespaces/ns/pipelines/"+tt.pipeline+"/credentialcheck", nil)
+			recorder := httptest.NewRecorder()
+			container.ServeHTTP(recorder, request)
+			assert.Equal(t, tt.wantCode, recorder.Code, recorder.Body.String())
+			if tt.verify != nil {
+				report := &modelpipeline.CredentialCheckReport{}
+				assert.Nil(t, json.Unmarshal(recorder.Body.Bytes(), report))
+				tt.verify(t, report)
+			}
+		})
+	}
+}
@@ -0,0 +1,67 @@
+/*
+Copyright 2022 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pipeline
+
+import (
+	"io"
+	"strings"
+
+	"github.com/emicklei/go-restful"
+	"kubesphere.io/devops/pkg/jenkinsfile"
+	"kubesphere.io/devops/pkg/kapis"
+)
+
+// JenkinsfilePayload is the request body of the Jenkinsfile APIs
+type JenkinsfilePayload struct {
+	Data string `json:"data"`
+}
+
+// LintResult is the result of linting a Jenkinsfile
+type LintResult struct {
+	Errors []*jenkinsfile.Error `json:"errors"`
+}
+
+// readJenkinsfile reads the Jenkinsfile from a JSON payload, or the raw request body
+func readJenkinsfile(request *restful.Request) (text string, err error) {
+	if strings.HasPrefix(request.HeaderParameter("Content-Type"), restful.MIME_JSON) {
+		payload := &JenkinsfilePayload{}
+		if err = request.ReadEntity(payload); err == nil {
+			text = payload.Data
+		}
+		return
+	}
+
+	var data []byte
+	if data, err = io.ReadAll(request.Request.Body); err == nil {
+		text = string(data)
+	}
+	return
+}
+
+func (h *apiHandler) lintJenkinsfile(request *restful.Request, response *restful.Response) {
+	text, err := readJenkinsfile(request)
+	if err != nil {
+		kapis.HandleBadRequest(response, request, err)
+		return
+	}
+
+	result := &LintResult{Errors: jenkinsfile.Lint(text)}
+	if result.Errors == nil {
+		result.Errors = []*jenkinsfile.Error{}
+	}
+	_ = response.WriteEntity(result)
+}
@@ -0,0 +1,84 @@
+/*
+Copyright 2022 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pipeline
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/emicklei/go-restful"
+	"github.com/stretchr/testify/assert"
+	"kubesphere.io/devops/pkg/api/devops/v1alpha3"
+	"kubesphere.io/devops/pkg/apiserver/runtime"
+	"kubesphere.io/devops/pkg/jenkinsfile"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestLintJenkinsfile(t *testing.T) {
+	schema, err := v1alpha3.SchemeBuilder.Register().Build()
+	assert.Nil(t, err)
+	ws := runtime.NewWebService(v1alpha3.GroupVersion)
+	RegisterRoutes(ws, fake.NewClientBuilder().WithScheme(schema).Build())
+	container := restful.NewContainer()
+	container.Add(ws)
+
+	tests := []struct {
+		name        string
+		contentType string
+		body        string
+		wantCode    int
+		wantErrors  []*jenkinsfile.Error
+	}{{
+		name:       "valid raw Jenkinsfile",
+		body:       "pipeline {\n  agent any\n  stages {\n    stage('a') { steps { echo 'a' } }\n  }\n}",
+		wantCode:   http.StatusOK,
+		wantErrors: []*jenkinsfile.Error{},
+	}, {
+		name:        "invalid Jenkinsfile in JSON",
+		contentType: restful.MIME_JSON,
+		body:        `{"data": "pipeline {\n  stages {\n    stage('a') { steps { echo 'a' } }\n  }\n}"}`,
+		wantCode:    http.StatusOK,
+		wantErrors:  []*jenkinsfile.Error{{Line: 1, Message: "missing agent section in pipeline"}},
+	}, {
+		name:        "invalid JSON",
+		contentType: restful.MIME_JSON,
+		body:        `{"data"`,
+		wantCode:    http.StatusBadRequest,
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			request := httptest.NewRequest(http.MethodPost, "/kapis/devops.kubesphere.io/v1alpha3/jenkinsfile/lint",
+				strings.NewReader(tt.body))
+			if tt.contentType != "" {
+				request.Header.Set("Content-Type", tt.contentType)
+			}
+			recorder := httptest.NewRecorder()
+			container.ServeHTTP(recorder, request)
+			assert.Equal(t, tt.wantCode, recorder.Code)
+			if tt.wantCode != http.StatusOK {
+				return
+			}
+
+			result := &LintResult{}
+			assert.Nil(t, json.Unmarshal(recorder.Body.Bytes(), result))
+			assert.Equal(t, tt.wantErrors, result.Errors)
+		})
+	}
+}
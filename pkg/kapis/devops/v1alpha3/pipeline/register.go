@@ -21,6 +21,7 @@ import (
 
 	"github.com/emicklei/go-restful"
 	"kubesphere.io/devops/pkg/api"
+	"kubesphere.io/devops/pkg/api/devops/v1alpha3"
 	"kubesphere.io/devops/pkg/models/pipeline"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
@@ -46,4 +47,61 @@ func RegisterRoutes(ws *restful.WebService, c client.Client) {
 		Param(ws.PathParameter("pipeline", "Name of the Pipeline")).
 		Param(ws.PathParameter("branch", "Name of branch, tag or pull request")).
 		Returns(http.StatusOK, api.StatusOK, pipeline.Branch{}))
+
+	ws.Route(ws.GET("/namespaces/{namespace}/pipelines/{pipeline}/revisions").
+		To(handler.listRevisions).
+		Doc("Paging query the revisions of the Pipeline spec, the latest revision is the first one").
+		Param(ws.PathParameter("namespace", "Namespace of the Pipeline")).
+		Param(ws.PathParameter("pipeline", "Name of the Pipeline")).
+		Returns(http.StatusOK, api.StatusOK, api.ListResult{}))
+
+	ws.Route(ws.GET("/namespaces/{namespace}/pipelines/{pipeline}/revisions/{revision}").
+		To(handler.getRevision).
+		Doc("Get a revision of the Pipeline spec").
+		Param(ws.PathParameter("namespace", "Namespace of the Pipeline")).
+		Param(ws.PathParameter("pipeline", "Name of the Pipeline")).
+		Param(ws.PathParameter("revision", "Number of the revision")).
+		Returns(http.StatusOK, api.StatusOK, pipeline.Revision{}))
+
+	ws.Route(ws.GET("/namespaces/{namespace}/pipelines/{pipeline}/revisions/{revision}/diff").
+		To(handler.diffRevisions).
+		Doc("Get the unified diff between the specs of two revisions").
+		Param(ws.PathParameter("namespace", "Namespace of the Pipeline")).
+		Param(ws.PathParameter("pipeline", "Name of the Pipeline")).
+		Param(ws.PathParameter("revision", "Number of the revision")).
+		Param(ws.QueryParameter("base", "Number of the base revision, the previous revision by default")).
+		Returns(http.StatusOK, api.StatusOK, pipeline.RevisionDiff{}))
+
+	ws.Route(ws.POST("/namespaces/{namespace}/pipelines/{pipeline}/revisions/{revision}/rollback").
+		To(handler.rollbackToRevision).
+		Doc("Roll back the Pipeline spec to a revision, a new revision is recorded after that").
+		Param(ws.PathParameter("namespace", "Namespace of the Pipeline")).
+		Param(ws.PathParameter("pipeline", "Name of the Pipeline")).
+		Param(ws.PathParameter("revision", "Number of the revision")).
+		Returns(http.StatusOK, api.StatusOK, v1alpha3.Pipeline{}))
+
+	ws.Route(ws.POST("/namespaces/{namespace}/pipelines/{pipeline}/clone").
+		To(handler.clonePipeline).
+		Doc("Create a copy of the Pipeline in the same or another DevOps project. The Jenkins state and the sync "+
+			"annotations are not copied, the trigger tokens are regenerated. The SCM branch, parameter defaults, triggers and "+
+			"description could be overridden. The credentials of the SCM source must exist in another DevOps project").
+		Param(ws.PathParameter("namespace", "Namespace of the Pipeline")).
+		Param(ws.PathParameter("pipeline", "Name of the Pipeline")).
+		Reads(pipeline.CloneOptions{}).
+		Returns(http.StatusOK, api.StatusOK, v1alpha3.Pipeline{}))
+
+	ws.Route(ws.GET("/namespaces/{namespace}/pipelines/{pipeline}/credentialcheck").
+		To(handler.checkCredentials).
+		Doc("Check if the credentials referenced by the Jenkinsfile and the SCM source of the Pipeline exist "+
+			"in the DevOps project and have the expected types").
+		Param(ws.PathParameter("namespace", "Namespace of the Pipeline")).
+		Param(ws.PathParameter("pipeline", "Name of the Pipeline")).
+		Returns(http.StatusOK, api.StatusOK, pipeline.CredentialCheckReport{}))
+
+	ws.Route(ws.POST("/jenkinsfile/lint").
+		To(handler.lintJenkinsfile).
+		Doc("Lint a declarative Jenkinsfile without Jenkins. The Jenkinsfile is taken from the raw request body, "+
+			"or the 'data' field of a JSON payload").
+		Reads(JenkinsfilePayload{}).
+		Returns(http.StatusOK, api.StatusOK, LintResult{}))
 }
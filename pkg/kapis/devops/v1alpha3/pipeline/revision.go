@@ -0,0 +1,145 @@
+/*
+Copyright 2022 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pipeline
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/emicklei/go-restful"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/client-go/util/retry"
+	"kubesphere.io/devops/pkg/api"
+	"kubesphere.io/devops/pkg/api/devops/v1alpha3"
+	"kubesphere.io/devops/pkg/apiserver/query"
+	apiserverrequest "kubesphere.io/devops/pkg/apiserver/request"
+	"kubesphere.io/devops/pkg/kapis"
+	modelpipeline "kubesphere.io/devops/pkg/models/pipeline"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func (h *apiHandler) listRevisions(request *restful.Request, response *restful.Response) {
+	namespaceName := request.PathParameter("namespace")
+	pipelineName := request.PathParameter("pipeline")
+
+	revisions, err := modelpipeline.ListRevisions(context.Background(), h.client, namespaceName, pipelineName)
+	if err != nil {
+		kapis.HandleError(request, response, err)
+		return
+	}
+
+	queryParam := query.ParseQueryParameter(request)
+	total := len(revisions)
+	startIndex, endIndex := queryParam.Pagination.GetValidPagination(total)
+	items := make([]interface{}, 0, endIndex-startIndex)
+	for _, revision := range revisions[startIndex:endIndex] {
+		items = append(items, revision)
+	}
+	_ = response.WriteEntity(api.NewListResult(items, total))
+}
+
+func (h *apiHandler) getRevision(request *restful.Request, response *restful.Response) {
+	revision, err := h.getRevisionByParameter(request, "revision")
+	if err != nil {
+		kapis.HandleError(request, response, err)
+		return
+	}
+	_ = response.WriteEntity(revision)
+}
+
+func (h *apiHandler) diffRevisions(request *restful.Request, response *restful.Response) {
+	revision, err := h.getRevisionByParameter(request, "revision")
+	if err != nil {
+		kapis.HandleError(request, response, err)
+		return
+	}
+
+	// compare with the previous revision by default
+	baseNumber := revision.Revision - 1
+	if base := request.QueryParameter("base"); base != "" {
+		if baseNumber, err = strconv.Atoi(base); err != nil {
+			kapis.HandleBadRequest(response, request, fmt.Errorf("invalid base revision: %s", base))
+			return
+		}
+	}
+
+	baseRevision := &modelpipeline.Revision{Revision: baseNumber}
+	if baseNumber > 0 {
+		if baseRevision, err = modelpipeline.GetRevision(context.Background(), h.client, request.PathParameter("namespace"),
+			request.PathParameter("pipeline"), baseNumber); err != nil {
+			kapis.HandleError(request, response, err)
+			return
+		}
+	}
+
+	var diff *modelpipeline.RevisionDiff
+	if diff, err = modelpipeline.DiffRevisions(baseRevision, revision); err != nil {
+		kapis.HandleError(request, response, err)
+		return
+	}
+	_ = response.WriteEntity(diff)
+}
+
+// rollbackToRevision sets the spec of a revision to the Pipeline, then a new revision is recorded by the controller
+func (h *apiHandler) rollbackToRevision(request *restful.Request, response *restful.Response) {
+	revision, err := h.getRevisionByParameter(request, "revision")
+	if err != nil {
+		kapis.HandleError(request, response, err)
+		return
+	}
+
+	key := client.ObjectKey{Namespace: request.PathParameter("namespace"), Name: request.PathParameter("pipeline")}
+	pipeline := &v1alpha3.Pipeline{}
+	err = retry.RetryOnConflict(retry.DefaultRetry, func() (err error) {
+		if err = h.client.Get(context.Background(), key, pipeline); err != nil {
+			return
+		}
+		if pipeline.Spec.Type != revision.Spec.Type {
+			return errors.NewBadRequest(fmt.Sprintf("cannot roll back a Pipeline of type %s to a revision of type %s",
+				pipeline.Spec.Type, revision.Spec.Type))
+		}
+
+		if pipeline.Spec.Pipeline != nil && revision.Spec.Pipeline != nil &&
+			pipeline.Spec.Pipeline.Jenkinsfile != revision.Spec.Pipeline.Jenkinsfile {
+			// refresh the JSON format of the Jenkinsfile
+			if pipeline.Annotations == nil {
+				pipeline.Annotations = map[string]string{}
+			}
+			pipeline.Annotations[v1alpha3.PipelineJenkinsfileEditModeAnnoKey] = v1alpha3.PipelineJenkinsfileEditModeRaw
+		}
+		pipeline.Spec = *revision.Spec
+		if currentUser, ok := apiserverrequest.UserFrom(request.Request.Context()); ok && currentUser != nil {
+			pipeline.SetLastModifier(currentUser.GetName())
+		}
+		return h.client.Update(context.Background(), pipeline)
+	})
+	if err != nil {
+		kapis.HandleError(request, response, err)
+		return
+	}
+	_ = response.WriteEntity(pipeline)
+}
+
+func (h *apiHandler) getRevisionByParameter(request *restful.Request, name string) (*modelpipeline.Revision, error) {
+	revision, err := strconv.Atoi(request.PathParameter(name))
+	if err != nil || revision <= 0 {
+		return nil, errors.NewBadRequest(fmt.Sprintf("invalid revision: %s", request.PathParameter(name)))
+	}
+	return modelpipeline.GetRevision(context.Background(), h.client, request.PathParameter("namespace"),
+		request.PathParameter("pipeline"), revision)
+}
@@ -0,0 +1,157 @@
+/*
+Copyright 2022 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pipeline
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/emicklei/go-restful"
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apiserver/pkg/authentication/user"
+	"kubesphere.io/devops/pkg/api"
+	"kubesphere.io/devops/pkg/api/devops/v1alpha3"
+	apiserverrequest "kubesphere.io/devops/pkg/apiserver/request"
+	"kubesphere.io/devops/pkg/apiserver/runtime"
+	modelpipeline "kubesphere.io/devops/pkg/models/pipeline"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestRevisionAPIs(t *testing.T) {
+	schema, err := v1alpha3.SchemeBuilder.Register().Build()
+	assert.Nil(t, err)
+	assert.Nil(t, v1.SchemeBuilder.AddToScheme(schema))
+
+	pip := &v1alpha3.Pipeline{}
+	pip.SetNamespace("ns")
+	pip.SetName("name")
+	pip.Spec.Type = v1alpha3.NoScmPipelineType
+	pip.Spec.Pipeline = &v1alpha3.NoScmPipeline{Name: "name", Jenkinsfile: "echo 2"}
+
+	objects := []client.Object{pip.DeepCopy()}
+	for i, jenkinsfile := range []string{"echo 1", "echo 2"} {
+		pipeline := pip.DeepCopy()
+		pipeline.Spec.Pipeline.Jenkinsfile = jenkinsfile
+		cm, err := modelpipeline.NewRevisionConfigMap(pipeline, i+1, jenkinsfile)
+		assert.Nil(t, err)
+		objects = append(objects, cm)
+	}
+	c := fake.NewClientBuilder().WithScheme(schema).WithObjects(objects...).Build()
+
+	ws := runtime.NewWebService(v1alpha3.GroupVersion)
+	RegisterRoutes(ws, c)
+	container := restful.NewContainer()
+	container.Add(ws)
+
+	tests := []struct {
+		name     string
+		method   string
+		uri      string
+		wantCode int
+		verify   func(t *testing.T, body []byte)
+	}{{
+		name:     "list revisions",
+		method:   http.MethodGet,
+		uri:      "/revisions",
+		wantCode: http.StatusOK,
+		verify: func(t *testing.T, body []byte) {
+			result := &api.ListResult{}
+			assert.Nil(t, json.Unmarshal(body, result))
+			assert.Equal(t, 2, result.TotalItems)
+		},
+	}, {
+		name:     "get a revision",
+		method:   http.MethodGet,
+		uri:      "/revisions/1",
+		wantCode: http.StatusOK,
+		verify: func(t *testing.T, body []byte) {
+			revision := &modelpipeline.Revision{}
+			assert.Nil(t, json.Unmarshal(body, revision))
+			assert.Equal(t, 1, revision.Revision)
+			assert.Equal(t, "echo 1", revision.Spec.Pipeline.Jenkinsfile)
+		},
+	}, {
+		name:     "invalid revision",
+		method:   http.MethodGet,
+		uri:      "/revisions/a",
+		wantCode: http.StatusBadRequest,
+	}, {
+		name:     "revision not found",
+		method:   http.MethodGet,
+		uri:      "/revisions/3",
+		wantCode: http.StatusNotFound,
+	}, {
+		name:     "diff with the previous revision",
+		method:   http.MethodGet,
+		uri:      "/revisions/2/diff",
+		wantCode: http.StatusOK,
+		verify: func(t *testing.T, body []byte) {
+			diff := &modelpipeline.RevisionDiff{}
+			assert.Nil(t, json.Unmarshal(body, diff))
+			assert.Equal(t, 1, diff.Base)
+			assert.Contains(t, diff.Diff, "-  jenkinsfile: echo 1\n+  jenkinsfile: echo 2\n")
+		},
+	}, {
+		name:     "diff with a given base",
+		method:   http.MethodGet,
+		uri:      "/revisions/1/diff?base=2",
+		wantCode: http.StatusOK,
+		verify: func(t *testing.T, body []byte) {
+			diff := &modelpipeline.RevisionDiff{}
+			assert.Nil(t, json.Unmarshal(body, diff))
+			assert.Equal(t, 2, diff.Base)
+			assert.Contains(t, diff.Diff, "-  jenkinsfile: echo 2\n+  jenkinsfile: echo 1\n")
+		},
+	}, {
+		name:     "invalid base",
+		method:   http.MethodGet,
+		uri:      "/revisions/1/diff?base=a",
+		wantCode: http.StatusBadRequest,
+	}, {
+		name:     "roll back",
+		method:   http.MethodPost,
+		uri:      "/revisions/1/rollback",
+		wantCode: http.StatusOK,
+		verify: func(t *testing.T, body []byte) {
+			pipeline := &v1alpha3.Pipeline{}
+			assert.Nil(t, c.Get(context.Background(), types.NamespacedName{Namespace: "ns", Name: "name"}, pipeline))
+			assert.Equal(t, "echo 1", pipeline.Spec.Pipeline.Jenkinsfile)
+			assert.Equal(t, v1alpha3.PipelineJenkinsfileEditModeRaw,
+				pipeline.Annotations[v1alpha3.PipelineJenkinsfileEditModeAnnoKey])
+			assert.Equal(t, "admin", pipeline.GetLastModifier())
+		},
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			request := httptest.NewRequest(tt.method,
+				"/kapis/devops.kubesphere.io/v1alpha3/namespaces/ns/pipelines/name"+tt.uri, nil)
+			request = request.WithContext(apiserverrequest.WithUser(request.Context(), &user.DefaultInfo{Name: "admin"}))
+			recorder := httptest.NewRecorder()
+			container.ServeHTTP(recorder, request)
+			assert.Equal(t, tt.wantCode, recorder.Code)
+			if tt.verify != nil {
+				tt.verify(t, recorder.Body.Bytes())
+			}
+		})
+	}
+}
@@ -44,6 +44,7 @@ import (
 type apiHandlerOption struct {
 	devopsClient devopsClient.Interface
 	client       client.Client
+	replaySecret string
 }
 
 // apiHandler contains functions to handle coming request and give a response.
@@ -140,6 +141,10 @@ func (h *apiHandler) createPipelineRun(request *restful.Request, response *restf
 	}
 	// create PipelineRun
 	pr := CreatePipelineRun(&pipeline, &payload, scm)
+	if err = ValidateParameters(&pipeline, pr); err != nil {
+		kapis.HandleBadRequest(response, request, err)
+		return
+	}
 	if user.GetName() != "" {
 		pr.GetAnnotations()[v1alpha3.PipelineRunCreatorAnnoKey] = user.GetName()
 	}
@@ -151,6 +156,16 @@ func (h *apiHandler) createPipelineRun(request *restful.Request, response *restf
 	_ = response.WriteEntity(pr)
 }
 
+// ValidateParameters validates the parameters of the PipelineRun and fills in the default values
+func ValidateParameters(pipeline *v1alpha3.Pipeline, pr *v1alpha3.PipelineRun) (err error) {
+	var branch string
+	if pr.Spec.SCM != nil {
+		branch = pr.Spec.SCM.RefName
+	}
+	pr.Spec.Parameters, err = pipelinerun.ValidateParameters(pipeline, branch, pr.Spec.Parameters)
+	return
+}
+
 func (h *apiHandler) getPipelineRun(request *restful.Request, response *restful.Response) {
 	nsName := request.PathParameter("namespace")
 	prName := request.PathParameter("pipelinerun")
@@ -32,11 +32,12 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
-// RegisterRoutes register routes into web service.
-func RegisterRoutes(ws *restful.WebService, devopsClient devopsClient.Interface, c client.Client) {
+// RegisterRoutes register routes into web service. The replay secret signs the Jenkinsfile which a replay runs with.
+func RegisterRoutes(ws *restful.WebService, devopsClient devopsClient.Interface, c client.Client, replaySecret string) {
 	handler := newAPIHandler(apiHandlerOption{
 		devopsClient: devopsClient,
 		client:       c,
+		replaySecret: replaySecret,
 	})
 
 	ws.Route(ws.GET("/namespaces/{namespace}/pipelines/{pipeline}/pipelineruns").
@@ -68,6 +69,16 @@ func RegisterRoutes(ws *restful.WebService, devopsClient devopsClient.Interface,
 		Param(ws.PathParameter("pipelinerun", "Name of the PipelineRun")).
 		Returns(http.StatusOK, api.StatusOK, v1alpha3.PipelineRun{}))
 
+	ws.Route(ws.POST("/namespaces/{namespace}/pipelineruns/{pipelinerun}/rerun").
+		To(handler.rerunPipelineRun).
+		Doc("Create a PipelineRun which runs the Pipeline again with the same parameters and SCM reference as the "+
+			"specified PipelineRun. The parameters could be overridden, or the run could be replayed with the same "+
+			"commit or another Jenkinsfile. Replaying with another Jenkinsfile requires the permission to update the Pipeline").
+		Param(ws.PathParameter("namespace", "Namespace of the PipelineRun")).
+		Param(ws.PathParameter("pipelinerun", "Name of the PipelineRun")).
+		Reads(RerunOptions{}).
+		Returns(http.StatusOK, api.StatusOK, v1alpha3.PipelineRun{}))
+
 	ws.Route(ws.GET("/namespaces/{namespace}/pipelineruns/{pipelinerun}/nodedetails").
 		To(handler.getNodeDetails).
 		Doc("Get node details including steps and approvable for a given Pipeline").
@@ -0,0 +1,197 @@
+/*
+Copyright 2022 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pipelinerun
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/emicklei/go-restful"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	"kubesphere.io/devops/pkg/api/devops/v1alpha3"
+	apiserverrequest "kubesphere.io/devops/pkg/apiserver/request"
+	"kubesphere.io/devops/pkg/kapis"
+	"kubesphere.io/devops/pkg/kapis/common"
+	"kubesphere.io/devops/pkg/models/pipelinerun"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// RerunOptions are the options of running a PipelineRun again
+type RerunOptions struct {
+	// Parameters override the parameters of the original PipelineRun by name
+	Parameters []v1alpha3.Parameter `json:"parameters,omitempty"`
+	// Jenkinsfile replays the original run with another Jenkinsfile, it is only for the Pipeline without SCM
+	Jenkinsfile *string `json:"jenkinsfile,omitempty"`
+	// PinCommit replays the original run with the same commit, instead of the latest commit of the SCM reference
+	PinCommit bool `json:"pinCommit,omitempty"`
+}
+
+func (o *RerunOptions) isReplay() bool {
+	return o.Jenkinsfile != nil || o.PinCommit
+}
+
+// CreateRerunPipelineRun creates a bare PipelineRun which runs the Pipeline again with the same parameters
+// and SCM reference as the original PipelineRun does.
+func CreateRerunPipelineRun(pipeline *v1alpha3.Pipeline, original *v1alpha3.PipelineRun, options *RerunOptions) (*v1alpha3.PipelineRun, error) {
+	var runID string
+	if options.isReplay() {
+		var ok bool
+		if runID, ok = original.GetPipelineRunID(); !ok {
+			return nil, errors.New("the PipelineRun has not started yet, it cannot be replayed")
+		}
+		if len(options.Parameters) > 0 {
+			return nil, errors.New("a replay runs with the parameters of the original PipelineRun, they cannot be overridden")
+		}
+	}
+	if options.Jenkinsfile != nil && pipeline.Spec.Type != v1alpha3.NoScmPipelineType {
+		return nil, fmt.Errorf("only the Jenkinsfile of a Pipeline with type %s could be replaced", v1alpha3.NoScmPipelineType)
+	}
+
+	parameters, err := overrideParameters(pipeline, original.Spec.Parameters, options.Parameters)
+	if err != nil {
+		return nil, err
+	}
+
+	pr := CreateBarePipelineRun(pipeline, parameters, original.Spec.SCM.DeepCopy())
+	pr.Labels[v1alpha3.PipelineRunRerunOfLabelKey] = original.Name
+	if runID == "" {
+		return pr, nil
+	}
+
+	pr.Annotations[v1alpha3.PipelineRunReplayAnnoKey] = runID
+	if commit := original.Annotations[v1alpha3.PipelineRunCommitAnnoKey]; commit != "" {
+		pr.Annotations[v1alpha3.PipelineRunCommitAnnoKey] = commit
+	}
+	pr.Spec.PipelineSpec = pipeline.Spec.DeepCopy()
+	if noScm := pr.Spec.PipelineSpec.Pipeline; noScm != nil {
+		// the Jenkinsfile is replayed as it is in the original PipelineRun unless it is replaced,
+		// the original one is only for display because Jenkins replays the script of the original build
+		if options.Jenkinsfile != nil {
+			noScm.Jenkinsfile = *options.Jenkinsfile
+		} else if original.Spec.PipelineSpec != nil && original.Spec.PipelineSpec.Pipeline != nil {
+			noScm.Jenkinsfile = original.Spec.PipelineSpec.Pipeline.Jenkinsfile
+		}
+	}
+	return pr, nil
+}
+
+// overrideParameters returns a copy of the parameters with the overrides. The overrides of a Pipeline
+// without SCM must be defined in the Pipeline, or exist in the parameters.
+func overrideParameters(pipeline *v1alpha3.Pipeline, parameters, overrides []v1alpha3.Parameter) ([]v1alpha3.Parameter, error) {
+	result := append([]v1alpha3.Parameter{}, parameters...)
+	for _, override := range overrides {
+		found := false
+		for i := range result {
+			if result[i].Name == override.Name {
+				result[i].Value = override.Value
+				found = true
+				break
+			}
+		}
+		if found {
+			continue
+		}
+		if pipeline.Spec.Type == v1alpha3.NoScmPipelineType && !isParameterDefined(pipeline.Spec.Pipeline, override.Name) {
+			return nil, fmt.Errorf("parameter %s is not defined", override.Name)
+		}
+		result = append(result, override)
+	}
+	return result, nil
+}
+
+func isParameterDefined(noScm *v1alpha3.NoScmPipeline, name string) bool {
+	if noScm == nil {
+		return false
+	}
+	for _, parameter := range noScm.Parameters {
+		if parameter.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// rerunPipelineRun creates a new PipelineRun from an existing one
+func (h *apiHandler) rerunPipelineRun(request *restful.Request, response *restful.Response) {
+	options := &RerunOptions{}
+	if err := request.ReadEntity(options); err != nil && err != io.EOF {
+		kapis.HandleBadRequest(response, request, err)
+		return
+	}
+
+	ctx := context.Background()
+	original := &v1alpha3.PipelineRun{}
+	key := client.ObjectKey{Namespace: request.PathParameter("namespace"), Name: request.PathParameter("pipelinerun")}
+	if err := h.client.Get(ctx, key, original); err != nil {
+		kapis.HandleError(request, response, err)
+		return
+	}
+	if original.Spec.PipelineRef == nil || original.Spec.PipelineRef.Name == "" {
+		kapis.HandleBadRequest(response, request, fmt.Errorf("PipelineRun %s does not belong to a Pipeline", key.Name))
+		return
+	}
+
+	pipeline := &v1alpha3.Pipeline{}
+	if err := h.client.Get(ctx, client.ObjectKey{Namespace: key.Namespace, Name: original.Spec.PipelineRef.Name}, pipeline); err != nil {
+		kapis.HandleError(request, response, err)
+		return
+	}
+
+	pr, err := CreateRerunPipelineRun(pipeline, original, options)
+	if err == nil && !options.isReplay() {
+		// a replay runs with the parameters of the original Jenkins build
+		err = ValidateParameters(pipeline, pr)
+	}
+	if err != nil {
+		kapis.HandleBadRequest(response, request, err)
+		return
+	}
+
+	// get current login user from request context
+	user, ok := apiserverrequest.UserFrom(request.Request.Context())
+	if !ok || user == nil {
+		// should never happen
+		err := fmt.Errorf("unauthenticated user entered to rerun PipelineRun '%s/%s'", key.Namespace, key.Name)
+		kapis.HandleUnauthorized(response, request, err)
+		return
+	}
+	if options.Jenkinsfile != nil {
+		// replacing the Jenkinsfile is the same as changing the Pipeline
+		if err = common.CheckAccess(ctx, h.client, user, authorizationv1.ResourceAttributes{
+			Namespace: pipeline.Namespace,
+			Name:      pipeline.Name,
+			Verb:      "update",
+			Group:     v1alpha3.GroupVersion.Group,
+			Resource:  v1alpha3.ResourcePluralPipeline,
+		}); err != nil {
+			kapis.HandleError(request, response, err)
+			return
+		}
+		// the controller only replays with the Jenkinsfile signed here
+		pr.GetAnnotations()[v1alpha3.PipelineRunReplayJenkinsfileSignatureAnnoKey] = pipelinerun.SignReplayJenkinsfile(h.replaySecret, pr)
+	}
+	if user.GetName() != "" {
+		pr.GetAnnotations()[v1alpha3.PipelineRunCreatorAnnoKey] = user.GetName()
+	}
+	if err := h.client.Create(ctx, pr); err != nil {
+		kapis.HandleError(request, response, err)
+		return
+	}
+	_ = response.WriteEntity(pr)
+}
@@ -0,0 +1,280 @@
+/*
+Copyright 2022 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pipelinerun
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/emicklei/go-restful"
+	"github.com/stretchr/testify/assert"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apiserver/pkg/authentication/user"
+	"kubesphere.io/devops/pkg/api/devops/v1alpha3"
+	apiserverrequest "kubesphere.io/devops/pkg/apiserver/request"
+	"kubesphere.io/devops/pkg/apiserver/runtime"
+	fakedevops "kubesphere.io/devops/pkg/client/devops/fake"
+	"kubesphere.io/devops/pkg/models/pipelinerun"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func newRerunSource() (*v1alpha3.Pipeline, *v1alpha3.PipelineRun) {
+	pipeline := &v1alpha3.Pipeline{}
+	pipeline.SetNamespace("ns")
+	pipeline.SetName("pipeline")
+	pipeline.Spec.Type = v1alpha3.NoScmPipelineType
+	pipeline.Spec.Pipeline = &v1alpha3.NoScmPipeline{
+		Name:        "pipeline",
+		Jenkinsfile: "echo 2",
+		Parameters: []v1alpha3.ParameterDefinition{
+			{Name: "env", Type: "string"}, {Name: "debug", Type: "boolean"},
+		},
+	}
+
+	original := &v1alpha3.PipelineRun{}
+	original.SetNamespace("ns")
+	original.SetName("pipeline-abc")
+	original.SetAnnotations(map[string]string{
+		v1alpha3.JenkinsPipelineRunIDAnnoKey: "2",
+		v1alpha3.PipelineRunCommitAnnoKey:    "sha",
+	})
+	original.Spec = v1alpha3.PipelineRunSpec{
+		PipelineRef: &v1.ObjectReference{Name: "pipeline", Namespace: "ns"},
+		PipelineSpec: &v1alpha3.PipelineSpec{
+			Type:     v1alpha3.NoScmPipelineType,
+			Pipeline: &v1alpha3.NoScmPipeline{Name: "pipeline", Jenkinsfile: "echo 1"},
+		},
+		Parameters: []v1alpha3.Parameter{{Name: "env", Value: "test"}},
+	}
+	return pipeline, original
+}
+
+func TestCreateRerunPipelineRun(t *testing.T) {
+	jenkinsfile := "echo 3"
+	pipeline, original := newRerunSource()
+
+	notStarted := original.DeepCopy()
+	delete(notStarted.Annotations, v1alpha3.JenkinsPipelineRunIDAnnoKey)
+
+	multiBranch := pipeline.DeepCopy()
+	multiBranch.Spec = v1alpha3.PipelineSpec{
+		Type:                v1alpha3.MultiBranchPipelineType,
+		MultiBranchPipeline: &v1alpha3.MultiBranchPipeline{Name: "pipeline"},
+	}
+	multiBranchRun := original.DeepCopy()
+	multiBranchRun.Spec.PipelineSpec = multiBranch.Spec.DeepCopy()
+	multiBranchRun.Spec.SCM = &v1alpha3.SCM{RefName: "main"}
+
+	tests := []struct {
+		name     string
+		pipeline *v1alpha3.Pipeline
+		original *v1alpha3.PipelineRun
+		options  RerunOptions
+		wantErr  bool
+		verify   func(t *testing.T, pr *v1alpha3.PipelineRun)
+	}{{
+		name:     "rerun with the same parameters",
+		pipeline: pipeline,
+		original: original,
+		verify: func(t *testing.T, pr *v1alpha3.PipelineRun) {
+			assert.Equal(t, "pipeline-", pr.GenerateName)
+			assert.Equal(t, "pipeline-abc", pr.Labels[v1alpha3.PipelineRunRerunOfLabelKey])
+			assert.Equal(t, original.Spec.Parameters, pr.Spec.Parameters)
+			assert.Empty(t, pr.Annotations[v1alpha3.PipelineRunReplayAnnoKey])
+			assert.Empty(t, pr.Annotations[v1alpha3.PipelineRunCommitAnnoKey])
+		},
+	}, {
+		name:     "override the parameters",
+		pipeline: pipeline,
+		original: original,
+		options:  RerunOptions{Parameters: []v1alpha3.Parameter{{Name: "env", Value: "prod"}, {Name: "debug", Value: "true"}}},
+		verify: func(t *testing.T, pr *v1alpha3.PipelineRun) {
+			assert.Equal(t, []v1alpha3.Parameter{{Name: "env", Value: "prod"}, {Name: "debug", Value: "true"}}, pr.Spec.Parameters)
+			assert.Equal(t, []v1alpha3.Parameter{{Name: "env", Value: "test"}}, original.Spec.Parameters)
+		},
+	}, {
+		name:     "unknown parameter",
+		pipeline: pipeline,
+		original: original,
+		options:  RerunOptions{Parameters: []v1alpha3.Parameter{{Name: "unknown", Value: "value"}}},
+		wantErr:  true,
+	}, {
+		name:     "parameters of a multi-branch Pipeline are defined in the Jenkinsfile",
+		pipeline: multiBranch,
+		original: multiBranchRun,
+		options:  RerunOptions{Parameters: []v1alpha3.Parameter{{Name: "unknown", Value: "value"}}},
+		verify: func(t *testing.T, pr *v1alpha3.PipelineRun) {
+			assert.Equal(t, "main", pr.Spec.SCM.RefName)
+			assert.Len(t, pr.Spec.Parameters, 2)
+		},
+	}, {
+		name:     "replay with the same commit",
+		pipeline: pipeline,
+		original: original,
+		options:  RerunOptions{PinCommit: true},
+		verify: func(t *testing.T, pr *v1alpha3.PipelineRun) {
+			assert.Equal(t, "2", pr.Annotations[v1alpha3.PipelineRunReplayAnnoKey])
+			assert.Equal(t, "sha", pr.Annotations[v1alpha3.PipelineRunCommitAnnoKey])
+			assert.Equal(t, "echo 1", pr.Spec.PipelineSpec.Pipeline.Jenkinsfile)
+			assert.Equal(t, "echo 2", pipeline.Spec.Pipeline.Jenkinsfile)
+		},
+	}, {
+		name:     "replay with another Jenkinsfile",
+		pipeline: pipeline,
+		original: original,
+		options:  RerunOptions{Jenkinsfile: &jenkinsfile},
+		verify: func(t *testing.T, pr *v1alpha3.PipelineRun) {
+			assert.Equal(t, "2", pr.Annotations[v1alpha3.PipelineRunReplayAnnoKey])
+			assert.Equal(t, "echo 3", pr.Spec.PipelineSpec.Pipeline.Jenkinsfile)
+		},
+	}, {
+		name:     "replay a PipelineRun which has not started",
+		pipeline: pipeline,
+		original: notStarted,
+		options:  RerunOptions{PinCommit: true},
+		wantErr:  true,
+	}, {
+		name:     "replay with parameters",
+		pipeline: pipeline,
+		original: original,
+		options:  RerunOptions{PinCommit: true, Parameters: []v1alpha3.Parameter{{Name: "env", Value: "prod"}}},
+		wantErr:  true,
+	}, {
+		name:     "replace the Jenkinsfile of a multi-branch Pipeline",
+		pipeline: multiBranch,
+		original: multiBranchRun,
+		options:  RerunOptions{Jenkinsfile: &jenkinsfile},
+		wantErr:  true,
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pr, err := CreateRerunPipelineRun(tt.pipeline, tt.original, &tt.options)
+			if tt.wantErr {
+				assert.NotNil(t, err)
+				assert.Nil(t, pr)
+				return
+			}
+			assert.Nil(t, err)
+			tt.verify(t, pr)
+		})
+	}
+}
+
+// accessReviewClient answers the SubjectAccessReview with the given result
+type accessReviewClient struct {
+	client.Client
+	allowed bool
+}
+
+func (c *accessReviewClient) Create(ctx context.Context, obj client.Object, opts ...client.CreateOption) error {
+	if review, ok := obj.(*authorizationv1.SubjectAccessReview); ok {
+		review.Status.Allowed = c.allowed
+		return nil
+	}
+	return c.Client.Create(ctx, obj, opts...)
+}
+
+func TestRerunPipelineRun(t *testing.T) {
+	schema, err := v1alpha3.SchemeBuilder.Register().Build()
+	assert.Nil(t, err)
+	pipeline, original := newRerunSource()
+
+	orphan := original.DeepCopy()
+	orphan.SetName("orphan")
+	orphan.Spec.PipelineRef = nil
+
+	tests := []struct {
+		name           string
+		pipelineRun    string
+		body           string
+		allowed        bool
+		wantCode       int
+		wantParameters []v1alpha3.Parameter
+		wantSigned     bool
+	}{{
+		name:        "PipelineRun not found",
+		pipelineRun: "fake",
+		wantCode:    http.StatusNotFound,
+	}, {
+		name:        "PipelineRun without a Pipeline",
+		pipelineRun: "orphan",
+		wantCode:    http.StatusBadRequest,
+	}, {
+		name:        "invalid options",
+		pipelineRun: "pipeline-abc",
+		body:        `{"parameters": [{"name": "unknown", "value": "value"}]}`,
+		wantCode:    http.StatusBadRequest,
+	}, {
+		name:           "rerun",
+		pipelineRun:    "pipeline-abc",
+		body:           `{"parameters": [{"name": "env", "value": "prod"}]}`,
+		wantCode:       http.StatusOK,
+		wantParameters: []v1alpha3.Parameter{{Name: "env", Value: "prod"}, {Name: "debug", Value: "false"}},
+	}, {
+		name:        "replay with another Jenkinsfile without the permission to update the Pipeline",
+		pipelineRun: "pipeline-abc",
+		body:        `{"jenkinsfile": "echo 3"}`,
+		wantCode:    http.StatusForbidden,
+	}, {
+		name:           "replay with another Jenkinsfile",
+		pipelineRun:    "pipeline-abc",
+		body:           `{"jenkinsfile": "echo 3"}`,
+		allowed:        true,
+		wantCode:       http.StatusOK,
+		wantParameters: []v1alpha3.Parameter{{Name: "env", Value: "test"}},
+		wantSigned:     true,
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &accessReviewClient{
+				Client: fake.NewClientBuilder().WithScheme(schema).
+					WithObjects(pipeline.DeepCopy(), original.DeepCopy(), orphan.DeepCopy()).Build(),
+				allowed: tt.allowed,
+			}
+			ws := runtime.NewWebService(v1alpha3.GroupVersion)
+			RegisterRoutes(ws, fakedevops.NewFakeDevops(nil), c, "secret")
+			container := restful.NewContainer()
+			container.Add(ws)
+
+			request := httptest.NewRequest(http.MethodPost,
+				"/kapis/devops.kubesphere.io/v1alpha3/namespaces/ns/pipelineruns/"+tt.pipelineRun+"/rerun",
+				bytes.NewBufferString(tt.body))
+			request.Header.Set(restful.HEADER_ContentType, restful.MIME_JSON)
+			request = request.WithContext(apiserverrequest.WithUser(request.Context(), &user.DefaultInfo{Name: "tester"}))
+			recorder := httptest.NewRecorder()
+			container.ServeHTTP(recorder, request)
+			assert.Equal(t, tt.wantCode, recorder.Code, recorder.Body.String())
+			if tt.wantCode != http.StatusOK {
+				return
+			}
+
+			prs := &v1alpha3.PipelineRunList{}
+			assert.Nil(t, c.List(context.Background(), prs,
+				client.MatchingLabels{v1alpha3.PipelineRunRerunOfLabelKey: "pipeline-abc"}))
+			if assert.Len(t, prs.Items, 1) {
+				assert.Equal(t, "tester", prs.Items[0].Annotations[v1alpha3.PipelineRunCreatorAnnoKey])
+				assert.Equal(t, tt.wantParameters, prs.Items[0].Spec.Parameters)
+				assert.Equal(t, tt.wantSigned, pipelinerun.VerifyReplayJenkinsfile("secret", &prs.Items[0]))
+			}
+		})
+	}
+}
@@ -29,6 +29,7 @@ import (
 	"kubesphere.io/devops/pkg/api/devops/v1alpha3"
 	"kubesphere.io/devops/pkg/apiserver/query"
 	"kubesphere.io/devops/pkg/client/devops"
+	"kubesphere.io/devops/pkg/utils"
 )
 
 func buildLabelSelector(queryParam *query.Query, pipelineName string) (labels.Selector, error) {
@@ -120,5 +121,11 @@ func CreateBarePipelineRun(pipeline *v1alpha3.Pipeline, parameters []v1alpha3.Pa
 			SCM:          scm,
 		},
 	}
+	// the revision is set asynchronously by the revision controller, it might not be the one of the current spec yet
+	if revision := pipeline.Annotations[v1alpha3.PipelineRevisionAnnoKey]; revision != "" &&
+		pipeline.Annotations[v1alpha3.PipelineRevisionSpecHashAnnoKey] == utils.ComputeHash(pipeline.Spec) {
+		// record the revision of the Pipeline spec which the PipelineRun runs with
+		pipelineRun.Annotations[v1alpha3.PipelineRevisionAnnoKey] = revision
+	}
 	return pipelineRun
 }
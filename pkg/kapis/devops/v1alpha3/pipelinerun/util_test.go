@@ -28,6 +28,7 @@ import (
 	"kubesphere.io/devops/pkg/api/devops/v1alpha3"
 	"kubesphere.io/devops/pkg/apiserver/query"
 	"kubesphere.io/devops/pkg/client/devops"
+	"kubesphere.io/devops/pkg/utils"
 )
 
 func Test_buildLabelSelector(t *testing.T) {
@@ -227,3 +228,21 @@ func TestCreatePipelineRun(t *testing.T) {
 	assert.Equal(t, pipelineRun.Namespace, pipeline.Namespace)
 	assert.NotNil(t, pipelineRun.Annotations)
 }
+
+func TestCreatePipelineRun_revision(t *testing.T) {
+	pipeline := &v1alpha3.Pipeline{}
+	pipeline.SetName("name")
+	pipeline.Spec.Type = v1alpha3.NoScmPipelineType
+	pipeline.Annotations = map[string]string{
+		v1alpha3.PipelineRevisionAnnoKey:         "3",
+		v1alpha3.PipelineRevisionSpecHashAnnoKey: utils.ComputeHash(pipeline.Spec),
+	}
+	pipelineRun := CreatePipelineRun(pipeline, nil, nil)
+	assert.Equal(t, "3", pipelineRun.Annotations[v1alpha3.PipelineRevisionAnnoKey])
+
+	// the revision is not recorded by the revision controller yet after the spec is changed
+	pipeline.Spec.Type = v1alpha3.MultiBranchPipelineType
+	pipelineRun = CreatePipelineRun(pipeline, nil, nil)
+	_, ok := pipelineRun.Annotations[v1alpha3.PipelineRevisionAnnoKey]
+	assert.False(t, ok)
+}
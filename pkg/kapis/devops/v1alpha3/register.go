@@ -29,13 +29,17 @@ import (
 	v1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"kubesphere.io/devops/pkg/api/devops/v1alpha3"
+	"kubesphere.io/devops/pkg/client/cache"
 	"kubesphere.io/devops/pkg/client/k8s"
+	"kubesphere.io/devops/pkg/config"
+	"kubesphere.io/devops/pkg/kapis/devops/v1alpha3/bundle"
 	"kubesphere.io/devops/pkg/kapis/devops/v1alpha3/common"
 	"kubesphere.io/devops/pkg/kapis/devops/v1alpha3/pipeline"
 	"kubesphere.io/devops/pkg/kapis/devops/v1alpha3/pipelinerun"
 	"kubesphere.io/devops/pkg/kapis/devops/v1alpha3/scm"
 	"kubesphere.io/devops/pkg/kapis/devops/v1alpha3/template"
 	"kubesphere.io/devops/pkg/kapis/devops/v1alpha3/webhook"
+	"kubesphere.io/devops/pkg/models/devops"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
 	"kubesphere.io/devops/pkg/api"
@@ -56,7 +60,8 @@ var GroupVersion = schema.GroupVersion{Group: api.GroupName, Version: "v1alpha3"
 
 // AddToContainer adds web service into container.
 func AddToContainer(container *restful.Container, devopsClient devopsClient.Interface, k8sClient k8s.Client,
-	client client.Client, tokenIssue token.Issuer, jenkins core.JenkinsCore) (wss []*restful.WebService) {
+	client client.Client, tokenIssue token.Issuer, jenkins core.JenkinsCore, scmOAuthOption *config.SCMOAuthOption,
+	cacheClient cache.Interface, jwtSecret string) (wss []*restful.WebService) {
 
 	services := []*restful.WebService{
 		runtime.NewWebService(v1alpha3.GroupVersion),
@@ -64,8 +69,8 @@ func AddToContainer(container *restful.Container, devopsClient devopsClient.Inte
 	}
 
 	for _, service := range services {
-		registerRoutes(devopsClient, k8sClient, client, service)
-		pipelinerun.RegisterRoutes(service, devopsClient, client)
+		registerRoutes(devopsClient, k8sClient, client, service, scmOAuthOption, cacheClient)
+		pipelinerun.RegisterRoutes(service, devopsClient, client, jwtSecret)
 		pipeline.RegisterRoutes(service, client)
 		template.RegisterRoutes(service, &common.Options{
 			GenericClient: client,
@@ -73,18 +78,22 @@ func AddToContainer(container *restful.Container, devopsClient devopsClient.Inte
 		steptemplate.RegisterRoutes(service, &common.Options{
 			GenericClient: client,
 		})
+		bundle.RegisterRoutes(service, &common.Options{
+			GenericClient: client,
+		})
 		webhook.RegisterWebhooks(client, service, tokenIssue, jenkins)
 		container.Add(service)
 	}
 	return services
 }
 
-func registerRoutes(devopsClient devopsClient.Interface, k8sClient k8s.Client, client client.Client, ws *restful.WebService) {
+func registerRoutes(devopsClient devopsClient.Interface, k8sClient k8s.Client, client client.Client, ws *restful.WebService,
+	scmOAuthOption *config.SCMOAuthOption, cacheClient cache.Interface) {
 	handler := newDevOpsHandler(devopsClient, k8sClient)
 	registerRoutersForCredentials(handler, ws)
 	registerRoutersForPipelines(handler, ws)
 	registerRoutersForWorkspace(handler, ws)
-	scm.RegisterRoutersForSCM(client, ws)
+	scm.RegisterRoutersForSCM(client, ws, scmOAuthOption, cacheClient)
 	registerRoutersForCI(handler, ws)
 }
 
@@ -150,6 +159,7 @@ func registerRoutersForPipelines(handler *devopsHandler, ws *restful.WebService)
 	ws.Route(ws.POST("/devops/{devops}/pipelines").
 		To(handler.CreatePipeline).
 		Param(ws.PathParameter("devops", "devops name")).
+		Param(ws.QueryParameter(dryRunParameter, "return the Jenkins job config.xml without creating the pipeline if it is 'xml'")).
 		Reads(v1alpha3.Pipeline{}).
 		Doc("create the pipeline of the specified devops for the current user").
 		Returns(http.StatusOK, api.StatusOK, v1alpha3.Pipeline{}).
@@ -168,10 +178,19 @@ func registerRoutersForPipelines(handler *devopsHandler, ws *restful.WebService)
 		To(handler.UpdatePipeline).
 		Param(ws.PathParameter("devops", "project name")).
 		Param(ws.PathParameter("pipeline", "pipeline name")).
+		Param(ws.QueryParameter(dryRunParameter, "return the Jenkins job config.xml without updating the pipeline if it is 'xml'")).
 		Doc("put the pipeline of the specified devops for the current user").
 		Returns(http.StatusOK, api.StatusOK, v1alpha3.Pipeline{}).
 		Metadata(restfulspec.KeyOpenAPITags, []string{constants.DevOpsProjectTag}))
 
+	ws.Route(ws.GET("/devops/{devops}/pipelines/{pipeline}/drift").
+		To(handler.GetPipelineDrift).
+		Param(ws.PathParameter("devops", "project name")).
+		Param(ws.PathParameter("pipeline", "pipeline name")).
+		Doc("compare the pipeline with the Jenkins job, then report the changes which were made in Jenkins directly").
+		Returns(http.StatusOK, api.StatusOK, devops.PipelineDrift{}).
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.DevOpsProjectTag}))
+
 	ws.Route(ws.PUT("/devops/{devops}/pipelines/{pipeline}/jenkinsfile").
 		To(handler.UpdateJenkinsfile).
 		Param(ws.PathParameter("devops", "project name")).
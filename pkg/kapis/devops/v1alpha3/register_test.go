@@ -61,7 +61,7 @@ func TestAPIsExist(t *testing.T) {
 		ObjectMeta: metav1.ObjectMeta{
 			Name: "fake", Namespace: "fake",
 		},
-	}), &token.FakeIssuer{}, core.JenkinsCore{})
+	}), &token.FakeIssuer{}, core.JenkinsCore{}, nil, nil, "")
 
 	type args struct {
 		method string
@@ -162,6 +162,29 @@ func TestAPIsExist(t *testing.T) {
 		},
 		body:       `{}`,
 		expectCode: 200,
+	}, {
+		name: "preview the config.xml of a new pipeline",
+		args: args{
+			method: http.MethodPost,
+			uri:    "/devops/fake/pipelines?dryRun=xml",
+		},
+		body:       `{"metadata":{"name":"new"},"spec":{"type":"pipeline","pipeline":{"name":"new"}}}`,
+		expectCode: 200,
+	}, {
+		name: "preview the config.xml of an existing pipeline",
+		args: args{
+			method: http.MethodPut,
+			uri:    "/devops/fake/pipelines/fake?dryRun=xml",
+		},
+		body:       `{"metadata":{"name":"fake"}}`,
+		expectCode: 200,
+	}, {
+		name: "get the drift of a pipeline which is not in Jenkins",
+		args: args{
+			method: http.MethodGet,
+			uri:    "/devops/fake/pipelines/fake/drift",
+		},
+		expectCode: 400,
 	}, {
 		name: "delete a pipeline",
 		args: args{
@@ -271,7 +294,7 @@ func TestGetDevOpsProject(t *testing.T) {
 					constants.WorkspaceLabelKey: "ws",
 				},
 			},
-		})), fake.NewFakeClientWithScheme(schema), &token.FakeIssuer{}, core.JenkinsCore{})
+		})), fake.NewFakeClientWithScheme(schema), &token.FakeIssuer{}, core.JenkinsCore{}, nil, nil, "")
 
 	type args struct {
 		method string
@@ -0,0 +1,295 @@
+/*
+Copyright 2022 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"strings"
+
+	"github.com/emicklei/go-restful"
+	goscm "github.com/jenkins-x/go-scm/scm"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"kubesphere.io/devops/pkg/api/devops/v1alpha3"
+	"kubesphere.io/devops/pkg/client/git"
+	"kubesphere.io/devops/pkg/kapis"
+	"kubesphere.io/devops/pkg/kapis/common"
+)
+
+// repoClient is the client of a git repository.
+// Only the branches and tags are available if it's authenticated by a SSH auth secret.
+type repoClient struct {
+	scm *goscm.Client
+	ssh *git.SSHAuth
+	// repo is the full name of the git repository, such as: owner/repo
+	repo string
+	// url is the address of the git repository, it's required by the SSH auth
+	url string
+}
+
+// repoClientGetter returns the client of the target git repository
+type repoClientGetter func(req *restful.Request) (c *repoClient, err error)
+
+// getRepoClientFromSCM gets the git client from the scm type, server and secret of the request
+func (h *handler) getRepoClientFromSCM(req *restful.Request) (c *repoClient, err error) {
+	scm := common.GetPathParameter(req, pathParameterSCM)
+	server := common.GetQueryParameter(req, queryParameterServer)
+	org := common.GetPathParameter(req, pathParameterOrganization)
+	c = &repoClient{
+		repo: fmt.Sprintf("%s/%s", org, common.GetPathParameter(req, pathParameterRepository)),
+	}
+
+	var secretRef *v1.SecretReference
+	if secretName := common.GetQueryParameter(req, queryParameterSecret); secretName != "" {
+		secretRef = &v1.SecretReference{
+			Namespace: common.GetQueryParameter(req, queryParameterSecretNamespace),
+			Name:      secretName,
+		}
+	}
+
+	if err = h.refreshOAuthToken(req.Request.Context(), secretRef); err != nil {
+		return
+	}
+	factory := git.NewClientFactory(scm, secretRef, h.Client)
+	factory.Server = server
+	c.scm, err = factory.GetClientWithContext(req.Request.Context())
+	return
+}
+
+// getRepoClientFromGitRepository gets the git client from a GitRepository
+func (h *handler) getRepoClientFromGitRepository(req *restful.Request) (c *repoClient, err error) {
+	namespace := common.GetPathParameter(req, common.NamespacePathParameter)
+	repoName := common.GetPathParameter(req, pathParameterGitRepository)
+
+	gitRepo := &v1alpha3.GitRepository{}
+	if err = h.Get(context.Background(), types.NamespacedName{
+		Namespace: namespace,
+		Name:      repoName,
+	}, gitRepo); err != nil {
+		return
+	}
+
+	c = &repoClient{
+		repo: git.GetRepoPath(gitRepo),
+		url:  gitRepo.Spec.URL,
+	}
+	var secretRef *v1.SecretReference
+	if gitRepo.Spec.Secret != nil {
+		secretRef = gitRepo.Spec.Secret.DeepCopy()
+		if secretRef.Namespace == "" {
+			secretRef.Namespace = namespace
+		}
+	}
+
+	factory := git.NewClientFactory(gitRepo.Spec.Provider, secretRef, h.Client)
+	factory.Server = gitRepo.Spec.Server
+	if c.ssh, err = factory.GetSSHAuth(); err != nil || c.ssh != nil {
+		return
+	}
+
+	if c.repo == "" {
+		err = restful.NewError(http.StatusBadRequest, fmt.Sprintf("cannot find the repository name of GitRepository %s/%s", namespace, repoName))
+		return
+	}
+	c.scm, err = factory.GetClientWithContext(req.Request.Context())
+	return
+}
+
+func (h *handler) listBranches(getClient repoClientGetter) restful.RouteFunction {
+	return func(req *restful.Request, rsp *restful.Response) {
+		c, err := getClient(req)
+		if err != nil {
+			kapis.HandleError(req, rsp, convertSCMError(err))
+			return
+		}
+
+		pageNumber, pageSize := common.GetPageParameters(req)
+		var branches []reference
+		if c.ssh != nil {
+			var refs *git.RemoteRefs
+			if refs, err = c.ssh.ListRemoteRefs(context.Background(), c.url); err == nil {
+				branches = paginateReferences(transformRemoteRefs(refs.Branches()), pageNumber, pageSize)
+			}
+		} else {
+			var goSCMBranches []*goscm.Reference
+			if goSCMBranches, _, err = c.scm.Git.ListBranches(context.Background(), c.repo, &goscm.ListOptions{
+				Page: pageNumber,
+				Size: pageSize,
+			}); err == nil {
+				branches = transformReferences(goSCMBranches)
+			}
+		}
+		common.Response(req, rsp, branches, convertSCMError(err))
+	}
+}
+
+func (h *handler) listTags(getClient repoClientGetter) restful.RouteFunction {
+	return func(req *restful.Request, rsp *restful.Response) {
+		c, err := getClient(req)
+		if err != nil {
+			kapis.HandleError(req, rsp, convertSCMError(err))
+			return
+		}
+
+		pageNumber, pageSize := common.GetPageParameters(req)
+		var tags []reference
+		if c.ssh != nil {
+			var refs *git.RemoteRefs
+			if refs, err = c.ssh.ListRemoteRefs(context.Background(), c.url); err == nil {
+				tags = paginateReferences(transformRemoteRefs(refs.Tags()), pageNumber, pageSize)
+			}
+		} else {
+			var goSCMTags []*goscm.Reference
+			if goSCMTags, _, err = c.scm.Git.ListTags(context.Background(), c.repo, &goscm.ListOptions{
+				Page: pageNumber,
+				Size: pageSize,
+			}); err == nil {
+				tags = transformReferences(goSCMTags)
+			}
+		}
+		common.Response(req, rsp, tags, convertSCMError(err))
+	}
+}
+
+func (h *handler) listCommits(getClient repoClientGetter) restful.RouteFunction {
+	return func(req *restful.Request, rsp *restful.Response) {
+		c, err := getClient(req)
+		if err == nil && c.scm == nil {
+			err = git.ErrSSHAuth
+		}
+		if err != nil {
+			kapis.HandleError(req, rsp, convertSCMError(err))
+			return
+		}
+
+		ref := common.GetQueryParameter(req, queryParameterRef)
+		pageNumber, pageSize := common.GetPageParameters(req)
+		// different git providers take the ref from different options
+		commits, _, err := c.scm.Git.ListCommits(context.Background(), c.repo, goscm.CommitListOptions{
+			Ref:  ref,
+			Sha:  ref,
+			Page: pageNumber,
+			Size: pageSize,
+		})
+		common.Response(req, rsp, transformCommits(commits), convertSCMError(err))
+	}
+}
+
+func (h *handler) getFileContent(getClient repoClientGetter) restful.RouteFunction {
+	return func(req *restful.Request, rsp *restful.Response) {
+		path := strings.TrimPrefix(common.GetQueryParameter(req, queryParameterPath), "/")
+		if path == "" {
+			kapis.HandleBadRequest(rsp, req, errors.New("the path of the file is required"))
+			return
+		}
+
+		c, err := getClient(req)
+		if err == nil && c.scm == nil {
+			err = git.ErrSSHAuth
+		}
+		if err != nil {
+			kapis.HandleError(req, rsp, convertSCMError(err))
+			return
+		}
+
+		ref := common.GetQueryParameter(req, queryParameterRef)
+		var content *goscm.Content
+		if content, _, err = c.scm.Contents.Find(context.Background(), c.repo, path, ref); err != nil {
+			kapis.HandleError(req, rsp, convertSCMError(err))
+			return
+		}
+		_ = rsp.WriteEntity(fileContent{
+			Path:    path,
+			Ref:     ref,
+			SHA:     content.Sha,
+			Content: string(content.Data),
+		})
+	}
+}
+
+// convertSCMError converts the errors of go-scm to the errors which carry a proper HTTP status code
+func convertSCMError(err error) error {
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(err, goscm.ErrNotFound):
+		return restful.NewError(http.StatusNotFound, err.Error())
+	case errors.Is(err, goscm.ErrNotSupported), errors.Is(err, goscm.ErrNotAuthorized), errors.Is(err, git.ErrSSHAuth):
+		return restful.NewError(http.StatusBadRequest, err.Error())
+	}
+	return err
+}
+
+func transformReferences(refs []*goscm.Reference) (result []reference) {
+	result = make([]reference, 0, len(refs))
+	for i := range refs {
+		result = append(result, reference{
+			Name: refs[i].Name,
+			SHA:  refs[i].Sha,
+		})
+	}
+	return
+}
+
+func transformRemoteRefs(refs []git.RemoteRef) (result []reference) {
+	result = make([]reference, 0, len(refs))
+	for i := range refs {
+		result = append(result, reference{
+			Name: refs[i].ShortName(),
+			SHA:  refs[i].SHA,
+		})
+	}
+	return
+}
+
+// paginateReferences returns a page of the references, the page number starts from 1
+func paginateReferences(refs []reference, pageNumber, pageSize int) []reference {
+	if pageNumber < 1 || pageSize < 1 {
+		return refs
+	}
+
+	start := (pageNumber - 1) * pageSize
+	if start >= len(refs) {
+		return []reference{}
+	}
+	end := start + pageSize
+	if end > len(refs) {
+		end = len(refs)
+	}
+	return refs[start:end]
+}
+
+func transformCommits(goSCMCommits []*goscm.Commit) (result []commit) {
+	result = make([]commit, 0, len(goSCMCommits))
+	for i := range goSCMCommits {
+		item := goSCMCommits[i]
+		result = append(result, commit{
+			SHA:         item.Sha,
+			Message:     item.Message,
+			Author:      item.Author.Name,
+			AuthorEmail: item.Author.Email,
+			Date:        metav1.NewTime(item.Author.Date),
+			Link:        item.Link,
+		})
+	}
+	return
+}
@@ -0,0 +1,260 @@
+/*
+Copyright 2022 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scm
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/emicklei/go-restful"
+	"github.com/h2non/gock"
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	runtimeSchema "k8s.io/apimachinery/pkg/runtime/schema"
+	"kubesphere.io/devops/pkg/api"
+	"kubesphere.io/devops/pkg/api/devops/v1alpha3"
+	"kubesphere.io/devops/pkg/apiserver/runtime"
+	"kubesphere.io/devops/pkg/constants"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestBrowsingAPI(t *testing.T) {
+	schema, err := v1alpha3.SchemeBuilder.Register().Build()
+	assert.Nil(t, err)
+
+	err = v1.SchemeBuilder.AddToScheme(schema)
+	assert.Nil(t, err)
+
+	secret := &v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "token", Namespace: "default",
+		},
+		Type: v1.SecretTypeOpaque,
+		Data: map[string][]byte{
+			v1.ServiceAccountTokenKey: []byte("token"),
+		},
+	}
+	repo := &v1alpha3.GitRepository{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "repo", Namespace: "default",
+		},
+		Spec: v1alpha3.GitRepositorySpec{
+			Provider: "github",
+			URL:      "https://github.com/octocat/Hello-World.git",
+			Secret:   &v1.SecretReference{Name: "token"},
+		},
+	}
+
+	privateKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.Nil(t, err)
+	sshSecret := &v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "ssh", Namespace: "default",
+		},
+		Type: v1.SecretTypeSSHAuth,
+		Data: map[string][]byte{
+			v1.SSHAuthPrivateKey: pem.EncodeToMemory(&pem.Block{
+				Type:  "RSA PRIVATE KEY",
+				Bytes: x509.MarshalPKCS1PrivateKey(privateKey),
+			}),
+		},
+	}
+	sshRepo := repo.DeepCopy()
+	sshRepo.Name = "ssh-repo"
+	sshRepo.Spec.URL = "git@github.com:octocat/Hello-World.git"
+	sshRepo.Spec.Secret = &v1.SecretReference{Name: "ssh"}
+
+	var mockHeaders = map[string]string{
+		"X-GitHub-Request-Id":   "DD0E:6011:12F21A8:1926790:5A2064E2",
+		"X-RateLimit-Limit":     "60",
+		"X-RateLimit-Remaining": "59",
+		"X-RateLimit-Reset":     "1512076018",
+	}
+
+	tests := []struct {
+		name    string
+		uri     string
+		prepare func()
+		verify  func(code int, response []byte, t *testing.T)
+	}{{
+		name: "list branches with the scm parameters",
+		uri:  "/scms/github/organizations/octocat/repositories/Hello-World/branches?secret=token&secretNamespace=default",
+		prepare: func() {
+			gock.New("https://api.github.com").
+				Get("/repos/octocat/Hello-World/branches").
+				MatchParam("page", "1").
+				MatchParam("per_page", "10").
+				MatchHeader("Authorization", "Bearer token").
+				Reply(200).
+				Type("application/json").
+				SetHeaders(mockHeaders).
+				File("testdata/branches.json")
+		},
+		verify: func(code int, response []byte, t *testing.T) {
+			assert.Equal(t, http.StatusOK, code)
+
+			var branches []reference
+			assert.Nil(t, json.Unmarshal(response, &branches))
+			assert.Equal(t, []reference{{Name: "master", SHA: "6dcb09b5b57875f334f61aebed695e2e4193db5e"}}, branches)
+		},
+	}, {
+		name: "list tags of a GitRepository",
+		uri:  "/namespaces/default/gitrepositories/repo/tags?pageNumber=2&pageSize=5",
+		prepare: func() {
+			gock.New("https://api.github.com").
+				Get("/repos/octocat/Hello-World/tags").
+				MatchParam("page", "2").
+				MatchParam("per_page", "5").
+				MatchHeader("Authorization", "Bearer token").
+				Reply(200).
+				Type("application/json").
+				SetHeaders(mockHeaders).
+				File("testdata/tags.json")
+		},
+		verify: func(code int, response []byte, t *testing.T) {
+			assert.Equal(t, http.StatusOK, code)
+
+			var tags []reference
+			assert.Nil(t, json.Unmarshal(response, &tags))
+			assert.Equal(t, []reference{{Name: "v0.1", SHA: "c5b97d5ae6c19d5c5df71a34c7fbeeda2479ccbc"}}, tags)
+		},
+	}, {
+		name: "list commits of a ref",
+		uri:  "/namespaces/default/gitrepositories/repo/commits?ref=master",
+		prepare: func() {
+			gock.New("https://api.github.com").
+				Get("/repos/octocat/Hello-World/commits").
+				MatchParam("sha", "master").
+				Reply(200).
+				Type("application/json").
+				SetHeaders(mockHeaders).
+				File("testdata/commits.json")
+		},
+		verify: func(code int, response []byte, t *testing.T) {
+			assert.Equal(t, http.StatusOK, code)
+
+			var commits []commit
+			assert.Nil(t, json.Unmarshal(response, &commits))
+			if assert.Equal(t, 1, len(commits)) {
+				assert.Equal(t, "7fd1a60b01f91b314f59955a4e4d4e80d8edf11d", commits[0].SHA)
+				assert.Equal(t, "The Octocat", commits[0].Author)
+				assert.Equal(t, "octocat@nowhere.com", commits[0].AuthorEmail)
+				assert.Contains(t, commits[0].Message, "Merge pull request #6")
+			}
+		},
+	}, {
+		name: "get the content of a file",
+		uri:  "/scms/github/organizations/octocat/repositories/Hello-World/contents?path=README&ref=master",
+		prepare: func() {
+			gock.New("https://api.github.com").
+				Get("/repos/octocat/Hello-World/contents/README").
+				MatchParam("ref", "master").
+				Reply(200).
+				Type("application/json").
+				SetHeaders(mockHeaders).
+				File("testdata/content.json")
+		},
+		verify: func(code int, response []byte, t *testing.T) {
+			assert.Equal(t, http.StatusOK, code)
+
+			content := &fileContent{}
+			assert.Nil(t, json.Unmarshal(response, content))
+			assert.Equal(t, fileContent{
+				Path:    "README",
+				Ref:     "master",
+				SHA:     "980a0d5f19a64b4b30a87d4206aade58726b60e3",
+				Content: "Hello World!\n",
+			}, *content)
+		},
+	}, {
+		name: "get the content of a non-existing file",
+		uri:  "/namespaces/default/gitrepositories/repo/contents?path=Jenkinsfile",
+		prepare: func() {
+			gock.New("https://api.github.com").
+				Get("/repos/octocat/Hello-World/contents/Jenkinsfile").
+				Reply(404).
+				Type("application/json").
+				SetHeaders(mockHeaders).
+				BodyString(`{"message": "Not Found"}`)
+		},
+		verify: func(code int, response []byte, t *testing.T) {
+			assert.Equal(t, http.StatusNotFound, code)
+		},
+	}, {
+		name: "get the content without the path",
+		uri:  "/namespaces/default/gitrepositories/repo/contents",
+		verify: func(code int, response []byte, t *testing.T) {
+			assert.Equal(t, http.StatusBadRequest, code)
+		},
+	}, {
+		name: "list commits with a SSH auth secret",
+		uri:  "/namespaces/default/gitrepositories/ssh-repo/commits",
+		verify: func(code int, response []byte, t *testing.T) {
+			assert.Equal(t, http.StatusBadRequest, code)
+		},
+	}, {
+		name: "list branches with the scm parameters and a SSH auth secret",
+		uri:  "/scms/github/organizations/octocat/repositories/Hello-World/branches?secret=ssh&secretNamespace=default",
+		verify: func(code int, response []byte, t *testing.T) {
+			assert.Equal(t, http.StatusBadRequest, code)
+		},
+	}, {
+		name: "list branches of a non-existing GitRepository",
+		uri:  "/namespaces/default/gitrepositories/fake/branches",
+		verify: func(code int, response []byte, t *testing.T) {
+			assert.Equal(t, http.StatusNotFound, code)
+		},
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer gock.Off()
+			if tt.prepare != nil {
+				tt.prepare()
+			}
+
+			httpRequest, _ := http.NewRequest(http.MethodGet,
+				"http://fake.com/kapis/devops.kubesphere.io/v1alpha3"+tt.uri, nil)
+			httpRequest = httpRequest.WithContext(context.WithValue(context.TODO(), constants.K8SToken, constants.ContextKeyK8SToken("")))
+
+			ws := runtime.NewWebService(runtimeSchema.GroupVersion{Group: api.GroupName, Version: "v1alpha3"})
+			RegisterRoutersForSCM(fake.NewClientBuilder().WithScheme(schema).
+				WithObjects(secret.DeepCopy(), repo.DeepCopy(), sshSecret.DeepCopy(), sshRepo.DeepCopy()).Build(), ws, nil, nil)
+			container := restful.NewContainer()
+			container.Add(ws)
+
+			httpWriter := httptest.NewRecorder()
+			container.Dispatch(httpWriter, httpRequest)
+			tt.verify(httpWriter.Code, httpWriter.Body.Bytes(), t)
+		})
+	}
+}
+
+func Test_paginateReferences(t *testing.T) {
+	refs := []reference{{Name: "a"}, {Name: "b"}, {Name: "c"}}
+	assert.Equal(t, []reference{{Name: "a"}, {Name: "b"}}, paginateReferences(refs, 1, 2))
+	assert.Equal(t, []reference{{Name: "c"}}, paginateReferences(refs, 2, 2))
+	assert.Equal(t, []reference{}, paginateReferences(refs, 3, 2))
+	assert.Equal(t, refs, paginateReferences(refs, 0, 0))
+}
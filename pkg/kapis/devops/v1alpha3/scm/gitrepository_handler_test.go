@@ -167,7 +167,7 @@ func TestGitRepositoryAPI(t *testing.T) {
 			httpRequest.Header.Set("Content-Type", "application/json")
 
 			ws := ksruntime.NewWebService(runtimeSchema.GroupVersion{Group: api.GroupName, Version: "v1alpha3"})
-			RegisterRoutersForSCM(fake.NewFakeClientWithScheme(schema, tt.getInstances()...), ws)
+			RegisterRoutersForSCM(fake.NewFakeClientWithScheme(schema, tt.getInstances()...), ws, nil, nil)
 			container := restful.NewContainer()
 			container.Add(ws)
 
@@ -0,0 +1,272 @@
+/*
+Copyright 2022 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scm
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/emicklei/go-restful"
+	"golang.org/x/oauth2"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"kubesphere.io/devops/pkg/api/devops/v1alpha3"
+	apiserverrequest "kubesphere.io/devops/pkg/apiserver/request"
+	"kubesphere.io/devops/pkg/client/git"
+	"kubesphere.io/devops/pkg/constants"
+	"kubesphere.io/devops/pkg/kapis"
+	"kubesphere.io/devops/pkg/kapis/common"
+)
+
+const (
+	// oauthStateExpiration is how long the users have to finish the authorization on the git provider
+	oauthStateExpiration = 10 * time.Minute
+	// oauthStateKeyPrefix is the key prefix of the OAuth states in the cache
+	oauthStateKeyPrefix = "devops:scm:oauth:state:"
+)
+
+// oauthState is the context of an authorization, it is kept on the server side.
+// Only a random key is sent to the git provider as the state.
+type oauthState struct {
+	Username  string `json:"username"`
+	Provider  string `json:"provider"`
+	Server    string `json:"server"`
+	Namespace string `json:"namespace"`
+	Secret    string `json:"secret"`
+}
+
+// authorizeOAuth returns the address of the OAuth2 authorization page of a git provider.
+// The access token is stored as the specified credential once the current user granted the access.
+func (h *handler) authorizeOAuth(req *restful.Request, rsp *restful.Response) {
+	provider := common.GetPathParameter(req, pathParameterSCM)
+	server := common.GetQueryParameter(req, queryParameterServer)
+	secretName := common.GetQueryParameter(req, queryParameterSecret)
+	secretNamespace := common.GetQueryParameter(req, queryParameterSecretNamespace)
+	if secretName == "" || secretNamespace == "" {
+		kapis.HandleBadRequest(rsp, req, fmt.Errorf("the secret and its namespace are required"))
+		return
+	}
+
+	currentUser, ok := apiserverrequest.UserFrom(req.Request.Context())
+	if !ok || currentUser.GetName() == "" {
+		kapis.HandleUnauthorized(rsp, req, fmt.Errorf("unauthenticated user cannot connect to the git provider %q", provider))
+		return
+	}
+
+	// the callback creates the credential on behalf of the current user
+	ctx := req.Request.Context()
+	if err := common.CheckAccess(ctx, h.Client, currentUser, authorizationv1.ResourceAttributes{
+		Namespace: secretNamespace,
+		Verb:      "create",
+		Resource:  "secrets",
+	}); err != nil {
+		kapis.HandleError(req, rsp, err)
+		return
+	}
+
+	config, err := h.getOAuthConfig(provider, server)
+	if err != nil {
+		kapis.HandleError(req, rsp, err)
+		return
+	}
+
+	var state string
+	if state, err = h.saveOAuthState(&oauthState{
+		Username:  currentUser.GetName(),
+		Provider:  provider,
+		Server:    server,
+		Namespace: secretNamespace,
+		Secret:    secretName,
+	}); err != nil {
+		kapis.HandleInternalError(rsp, req, err)
+		return
+	}
+	_ = rsp.WriteEntity(oauthAuthorization{URL: config.AuthCodeURL(state)})
+}
+
+// oauthCallback exchanges the authorization code for the access token, then stores it as a credential
+func (h *handler) oauthCallback(req *restful.Request, rsp *restful.Response) {
+	if oauthErr := req.QueryParameter("error"); oauthErr != "" {
+		kapis.HandleBadRequest(rsp, req, fmt.Errorf("authorization failed: %s %s", oauthErr, req.QueryParameter("error_description")))
+		return
+	}
+
+	state, err := h.takeOAuthState(common.GetQueryParameter(req, queryParameterOAuthState))
+	if err != nil {
+		kapis.HandleUnauthorized(rsp, req, fmt.Errorf("invalid state, error is: %v", err))
+		return
+	}
+
+	provider, server := state.Provider, state.Server
+	if provider != common.GetPathParameter(req, pathParameterSCM) {
+		kapis.HandleBadRequest(rsp, req, fmt.Errorf("the state was issued for git provider %q", provider))
+		return
+	}
+
+	config, err := h.getOAuthConfig(provider, server)
+	if err != nil {
+		kapis.HandleError(req, rsp, err)
+		return
+	}
+
+	ctx := req.Request.Context()
+	var oauthToken *oauth2.Token
+	if oauthToken, err = config.Exchange(ctx, common.GetQueryParameter(req, queryParameterOAuthCode)); err != nil {
+		kapis.HandleBadRequest(rsp, req, fmt.Errorf("cannot exchange the access token, error is: %v", err))
+		return
+	}
+
+	secretRef := &v1.SecretReference{
+		Namespace: state.Namespace,
+		Name:      state.Secret,
+	}
+	if err = h.saveOAuthToken(ctx, secretRef, state.Username, provider, server, oauthToken); err != nil {
+		kapis.HandleError(req, rsp, err)
+		return
+	}
+	_ = rsp.WriteEntity(oauthCredential{
+		Namespace:    secretRef.Namespace,
+		CredentialID: secretRef.Name,
+	})
+}
+
+// saveOAuthState stores the state on the server side, returns the random key of it
+func (h *handler) saveOAuthState(state *oauthState) (key string, err error) {
+	data := make([]byte, 32)
+	if _, err = rand.Read(data); err != nil {
+		return
+	}
+	key = base64.RawURLEncoding.EncodeToString(data)
+
+	var value []byte
+	if value, err = json.Marshal(state); err == nil {
+		err = h.states.Set(oauthStateKeyPrefix+key, string(value), oauthStateExpiration)
+	}
+	return
+}
+
+// takeOAuthState returns the state of the key, the state can be taken only once
+func (h *handler) takeOAuthState(key string) (state *oauthState, err error) {
+	if key == "" {
+		err = fmt.Errorf("the state is required")
+		return
+	}
+
+	var value string
+	if value, err = h.states.Get(oauthStateKeyPrefix + key); err != nil {
+		err = fmt.Errorf("the state is not found or expired")
+		return
+	}
+	if err = h.states.Del(oauthStateKeyPrefix + key); err != nil {
+		return
+	}
+	state = &oauthState{}
+	err = json.Unmarshal([]byte(value), state)
+	return
+}
+
+func (h *handler) getOAuthConfig(provider, server string) (config *oauth2.Config, err error) {
+	app := h.oauthOption.GetServer(provider, server)
+	if app == nil {
+		err = apierrors.NewNotFound(schema.GroupResource{Resource: "oauth"},
+			fmt.Sprintf("no OAuth application of git provider %q with server %q", provider, server))
+		return
+	}
+	if config, err = app.GetOAuth2Config(); err != nil {
+		err = apierrors.NewBadRequest(err.Error())
+	}
+	return
+}
+
+// saveOAuthToken creates or updates the credential, only the owner is able to update it
+func (h *handler) saveOAuthToken(ctx context.Context, secretRef *v1.SecretReference, username, provider, server string,
+	oauthToken *oauth2.Token) (err error) {
+	secret := &v1.Secret{}
+	if err = h.Get(ctx, types.NamespacedName{Namespace: secretRef.Namespace, Name: secretRef.Name}, secret); err != nil {
+		if !apierrors.IsNotFound(err) {
+			return
+		}
+
+		secret = &v1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: secretRef.Namespace,
+				Name:      secretRef.Name,
+				Annotations: map[string]string{
+					constants.CreatorAnnotationKey: username,
+				},
+			},
+			Type: v1alpha3.SecretTypeBasicAuth,
+		}
+		git.SetOAuthToken(secret, provider, server, oauthToken)
+		err = h.Create(ctx, secret)
+		return
+	}
+
+	if secret.Type != v1alpha3.SecretTypeBasicAuth || secret.Annotations[constants.CreatorAnnotationKey] != username {
+		err = apierrors.NewForbidden(v1.Resource("secrets"), secretRef.Name,
+			fmt.Errorf("the credential is not a basic-auth credential owned by user %q", username))
+		return
+	}
+	git.SetOAuthToken(secret, provider, server, oauthToken)
+	err = h.Update(ctx, secret)
+	return
+}
+
+// refreshOAuthToken refreshes the access token of the credential if it was issued by the SCM OAuth flow and expired.
+// It does nothing if the credential is not from the SCM OAuth flow, or the OAuth application is not configured.
+func (h *handler) refreshOAuthToken(ctx context.Context, secretRef *v1.SecretReference) (err error) {
+	if secretRef == nil || secretRef.Name == "" {
+		return
+	}
+
+	secret := &v1.Secret{}
+	if err = h.Get(ctx, types.NamespacedName{Namespace: secretRef.Namespace, Name: secretRef.Name}, secret); err != nil {
+		// leave it to the git client
+		err = nil
+		return
+	}
+
+	var oauthToken *oauth2.Token
+	var provider, server string
+	if oauthToken, provider, server, err = git.GetOAuthToken(secret); err != nil || oauthToken == nil || oauthToken.Valid() {
+		return
+	}
+
+	var config *oauth2.Config
+	if config, err = h.getOAuthConfig(provider, server); err != nil {
+		// cannot refresh it without the OAuth application
+		err = nil
+		return
+	}
+
+	var newToken *oauth2.Token
+	if newToken, err = config.TokenSource(ctx, oauthToken).Token(); err != nil {
+		err = fmt.Errorf("cannot refresh the access token of secret %s/%s, error is: %v", secret.Namespace, secret.Name, err)
+		return
+	}
+	git.SetOAuthToken(secret, provider, server, newToken)
+	err = h.Update(ctx, secret)
+	return
+}
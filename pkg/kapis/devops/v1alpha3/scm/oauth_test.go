@@ -0,0 +1,352 @@
+/*
+Copyright 2022 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scm
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/emicklei/go-restful"
+	"github.com/stretchr/testify/assert"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	runtimeSchema "k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apiserver/pkg/authentication/user"
+	"kubesphere.io/devops/pkg/api"
+	"kubesphere.io/devops/pkg/api/devops/v1alpha3"
+	apiserverrequest "kubesphere.io/devops/pkg/apiserver/request"
+	"kubesphere.io/devops/pkg/apiserver/runtime"
+	"kubesphere.io/devops/pkg/client/cache"
+	"kubesphere.io/devops/pkg/config"
+	"kubesphere.io/devops/pkg/constants"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+// newFakeOAuthServer creates a local OAuth2 server which issues the tokens of the code "code" and the refresh token "refresh"
+func newFakeOAuthServer(t *testing.T) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/login/oauth/access_token", r.URL.Path)
+		assert.Nil(t, r.ParseForm())
+
+		clientID, clientSecret, ok := r.BasicAuth()
+		if !ok {
+			clientID, clientSecret = r.PostForm.Get("client_id"), r.PostForm.Get("client_secret")
+		}
+		assert.Equal(t, "id", clientID)
+		assert.Equal(t, "secret", clientSecret)
+
+		var accessToken string
+		switch r.PostForm.Get("grant_type") {
+		case "authorization_code":
+			if r.PostForm.Get("code") == "code" {
+				accessToken = "access"
+			}
+		case "refresh_token":
+			if r.PostForm.Get("refresh_token") == "refresh" {
+				accessToken = "new-access"
+			}
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if accessToken == "" {
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`{"error": "invalid_grant"}`))
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"access_token":  accessToken,
+			"refresh_token": "refresh",
+			"token_type":    "bearer",
+			"expires_in":    3600,
+		})
+	}))
+}
+
+// accessReviewClient answers the SubjectAccessReview according to the allowed namespaces
+type accessReviewClient struct {
+	client.Client
+	allowedNamespaces map[string]bool
+}
+
+func (c *accessReviewClient) Create(ctx context.Context, obj client.Object, opts ...client.CreateOption) error {
+	if review, ok := obj.(*authorizationv1.SubjectAccessReview); ok {
+		review.Status.Allowed = c.allowedNamespaces[review.Spec.ResourceAttributes.Namespace]
+		return nil
+	}
+	return c.Client.Create(ctx, obj, opts...)
+}
+
+func TestOAuthAPIs(t *testing.T) {
+	schema, err := v1alpha3.SchemeBuilder.Register().Build()
+	assert.Nil(t, err)
+	err = v1.SchemeBuilder.AddToScheme(schema)
+	assert.Nil(t, err)
+
+	server := newFakeOAuthServer(t)
+	defer server.Close()
+
+	oauthOption := &config.SCMOAuthOption{Servers: []config.SCMOAuthServer{{
+		Provider:     "gitea",
+		Server:       server.URL,
+		ClientID:     "id",
+		ClientSecret: "secret",
+		RedirectURL:  "https://ks.com/callback",
+	}}}
+	states := cache.NewSimpleCache()
+	h := &handler{states: states}
+	saveState := func(username, provider, secret string) string {
+		state, err := h.saveOAuthState(&oauthState{
+			Username:  username,
+			Provider:  provider,
+			Server:    server.URL,
+			Namespace: "default",
+			Secret:    secret,
+		})
+		assert.Nil(t, err)
+		return state
+	}
+	usedState := saveState("alice", "gitea", "used")
+	_, err = h.takeOAuthState(usedState)
+	assert.Nil(t, err)
+	othersSecret := &v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "others", Namespace: "default",
+			Annotations: map[string]string{constants.CreatorAnnotationKey: "bob"},
+		},
+		Type: v1alpha3.SecretTypeBasicAuth,
+	}
+
+	tests := []struct {
+		name   string
+		uri    string
+		user   string
+		verify func(t *testing.T, code int, response []byte, c client.Client)
+	}{{
+		name: "authorize",
+		uri:  "/scms/gitea/oauth/authorize?server=" + url.QueryEscape(server.URL) + "&secret=gitea&secretNamespace=default",
+		user: "alice",
+		verify: func(t *testing.T, code int, response []byte, c client.Client) {
+			assert.Equal(t, http.StatusOK, code)
+
+			authorization := &oauthAuthorization{}
+			assert.Nil(t, json.Unmarshal(response, authorization))
+			authURL, err := url.Parse(authorization.URL)
+			assert.Nil(t, err)
+			assert.Equal(t, server.URL+"/login/oauth/authorize", authURL.Scheme+"://"+authURL.Host+authURL.Path)
+			assert.Equal(t, "id", authURL.Query().Get("client_id"))
+			assert.Equal(t, "https://ks.com/callback", authURL.Query().Get("redirect_uri"))
+
+			state, err := h.takeOAuthState(authURL.Query().Get("state"))
+			assert.Nil(t, err)
+			assert.Equal(t, &oauthState{
+				Username:  "alice",
+				Provider:  "gitea",
+				Server:    server.URL,
+				Namespace: "default",
+				Secret:    "gitea",
+			}, state)
+		},
+	}, {
+		name: "authorize without the permission of the namespace",
+		uri:  "/scms/gitea/oauth/authorize?server=" + url.QueryEscape(server.URL) + "&secret=gitea&secretNamespace=kube-system",
+		user: "alice",
+		verify: func(t *testing.T, code int, response []byte, c client.Client) {
+			assert.Equal(t, http.StatusForbidden, code)
+		},
+	}, {
+		name: "authorize without the secret",
+		uri:  "/scms/gitea/oauth/authorize?server=" + url.QueryEscape(server.URL),
+		user: "alice",
+		verify: func(t *testing.T, code int, response []byte, c client.Client) {
+			assert.Equal(t, http.StatusBadRequest, code)
+		},
+	}, {
+		name: "authorize without a user",
+		uri:  "/scms/gitea/oauth/authorize?server=" + url.QueryEscape(server.URL) + "&secret=gitea&secretNamespace=default",
+		verify: func(t *testing.T, code int, response []byte, c client.Client) {
+			assert.Equal(t, http.StatusUnauthorized, code)
+		},
+	}, {
+		name: "authorize with a not configured server",
+		uri:  "/scms/github/oauth/authorize?secret=gitea&secretNamespace=default",
+		user: "alice",
+		verify: func(t *testing.T, code int, response []byte, c client.Client) {
+			assert.Equal(t, http.StatusNotFound, code)
+		},
+	}, {
+		name: "callback",
+		uri:  "/scms/gitea/oauth/callback?code=code&state=" + saveState("alice", "gitea", "gitea"),
+		verify: func(t *testing.T, code int, response []byte, c client.Client) {
+			assert.Equal(t, http.StatusOK, code)
+
+			credential := &oauthCredential{}
+			assert.Nil(t, json.Unmarshal(response, credential))
+			assert.Equal(t, oauthCredential{Namespace: "default", CredentialID: "gitea"}, *credential)
+
+			secret := &v1.Secret{}
+			assert.Nil(t, c.Get(context.TODO(), types.NamespacedName{Namespace: "default", Name: "gitea"}, secret))
+			assert.Equal(t, v1alpha3.SecretTypeBasicAuth, secret.Type)
+			assert.Equal(t, "alice", secret.Annotations[constants.CreatorAnnotationKey])
+			assert.Equal(t, "gitea", secret.Annotations[v1alpha3.CredentialSCMOAuthProviderAnnoKey])
+			assert.Equal(t, server.URL, secret.Annotations[v1alpha3.CredentialSCMOAuthServerAnnoKey])
+			assert.Equal(t, "access", string(secret.Data[v1alpha3.BasicAuthPasswordKey]))
+			assert.Equal(t, "refresh", string(secret.Data[v1alpha3.OAuthRefreshTokenKey]))
+			assert.NotEmpty(t, secret.Data[v1alpha3.OAuthExpiryKey])
+		},
+	}, {
+		name: "callback with an invalid code",
+		uri:  "/scms/gitea/oauth/callback?code=invalid&state=" + saveState("alice", "gitea", "gitea"),
+		verify: func(t *testing.T, code int, response []byte, c client.Client) {
+			assert.Equal(t, http.StatusBadRequest, code)
+		},
+	}, {
+		name: "callback with an unknown state",
+		uri:  "/scms/gitea/oauth/callback?code=code&state=unknown",
+		verify: func(t *testing.T, code int, response []byte, c client.Client) {
+			assert.Equal(t, http.StatusUnauthorized, code)
+		},
+	}, {
+		name: "callback with a used state",
+		uri:  "/scms/gitea/oauth/callback?code=code&state=" + usedState,
+		verify: func(t *testing.T, code int, response []byte, c client.Client) {
+			assert.Equal(t, http.StatusUnauthorized, code)
+		},
+	}, {
+		name: "callback with the state of another provider",
+		uri:  "/scms/gitea/oauth/callback?code=code&state=" + saveState("alice", "github", "gitea"),
+		verify: func(t *testing.T, code int, response []byte, c client.Client) {
+			assert.Equal(t, http.StatusBadRequest, code)
+		},
+	}, {
+		name: "callback with an error from the git provider",
+		uri:  "/scms/gitea/oauth/callback?error=access_denied",
+		verify: func(t *testing.T, code int, response []byte, c client.Client) {
+			assert.Equal(t, http.StatusBadRequest, code)
+		},
+	}, {
+		name: "callback to the credential of others",
+		uri:  "/scms/gitea/oauth/callback?code=code&state=" + saveState("alice", "gitea", "others"),
+		verify: func(t *testing.T, code int, response []byte, c client.Client) {
+			assert.Equal(t, http.StatusForbidden, code)
+		},
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.TODO()
+			if tt.user != "" {
+				ctx = apiserverrequest.WithUser(ctx, &user.DefaultInfo{Name: tt.user})
+			}
+			httpRequest, _ := http.NewRequestWithContext(ctx, http.MethodGet,
+				"http://fake.com/kapis/devops.kubesphere.io/v1alpha3"+tt.uri, nil)
+
+			c := &accessReviewClient{
+				Client:            fake.NewClientBuilder().WithScheme(schema).WithObjects(othersSecret.DeepCopy()).Build(),
+				allowedNamespaces: map[string]bool{"default": true},
+			}
+			ws := runtime.NewWebService(runtimeSchema.GroupVersion{Group: api.GroupName, Version: "v1alpha3"})
+			RegisterRoutersForSCM(c, ws, oauthOption, states)
+			container := restful.NewContainer()
+			container.Add(ws)
+
+			httpWriter := httptest.NewRecorder()
+			container.Dispatch(httpWriter, httpRequest)
+			tt.verify(t, httpWriter.Code, httpWriter.Body.Bytes(), c)
+		})
+	}
+}
+
+func TestRefreshOAuthToken(t *testing.T) {
+	schema, err := v1alpha3.SchemeBuilder.Register().Build()
+	assert.Nil(t, err)
+	err = v1.SchemeBuilder.AddToScheme(schema)
+	assert.Nil(t, err)
+
+	server := newFakeOAuthServer(t)
+	defer server.Close()
+
+	newSecret := func(expiry time.Time) *v1.Secret {
+		return &v1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "gitea", Namespace: "default",
+				Annotations: map[string]string{
+					v1alpha3.CredentialSCMOAuthProviderAnnoKey: "gitea",
+					v1alpha3.CredentialSCMOAuthServerAnnoKey:   server.URL,
+				},
+			},
+			Type: v1alpha3.SecretTypeBasicAuth,
+			Data: map[string][]byte{
+				v1alpha3.BasicAuthPasswordKey: []byte("access"),
+				v1alpha3.OAuthRefreshTokenKey: []byte("refresh"),
+				v1alpha3.OAuthExpiryKey:       []byte(expiry.Format(time.RFC3339)),
+			},
+		}
+	}
+	oauthOption := &config.SCMOAuthOption{Servers: []config.SCMOAuthServer{{
+		Provider:     "gitea",
+		Server:       server.URL,
+		ClientID:     "id",
+		ClientSecret: "secret",
+	}}}
+
+	tests := []struct {
+		name        string
+		secret      *v1.Secret
+		oauthOption *config.SCMOAuthOption
+		wantToken   string
+	}{{
+		name:        "expired token",
+		secret:      newSecret(time.Now().Add(-time.Hour)),
+		oauthOption: oauthOption,
+		wantToken:   "new-access",
+	}, {
+		name:        "valid token",
+		secret:      newSecret(time.Now().Add(time.Hour)),
+		oauthOption: oauthOption,
+		wantToken:   "access",
+	}, {
+		name:      "expired token without the OAuth application",
+		secret:    newSecret(time.Now().Add(-time.Hour)),
+		wantToken: "access",
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := newHandler(fake.NewClientBuilder().WithScheme(schema).WithObjects(tt.secret).Build())
+			h.oauthOption = tt.oauthOption
+
+			ctx := context.TODO()
+			assert.Nil(t, h.refreshOAuthToken(ctx, &v1.SecretReference{Namespace: "default", Name: "gitea"}))
+
+			secret := &v1.Secret{}
+			assert.Nil(t, h.Get(ctx, types.NamespacedName{Namespace: "default", Name: "gitea"}, secret))
+			assert.Equal(t, tt.wantToken, string(secret.Data[v1alpha3.BasicAuthPasswordKey]))
+			assert.Equal(t, "refresh", string(secret.Data[v1alpha3.OAuthRefreshTokenKey]))
+		})
+	}
+
+	// the secrets which do not come from the OAuth flow are ignored
+	h := newHandler(fake.NewClientBuilder().WithScheme(schema).Build())
+	assert.Nil(t, h.refreshOAuthToken(context.TODO(), &v1.SecretReference{Namespace: "default", Name: "fake"}))
+	assert.Nil(t, h.refreshOAuthToken(context.TODO(), nil))
+}
@@ -20,7 +20,9 @@ import (
 	"github.com/emicklei/go-restful"
 	"kubesphere.io/devops/pkg/api"
 	"kubesphere.io/devops/pkg/api/devops/v1alpha3"
+	"kubesphere.io/devops/pkg/client/cache"
 	"kubesphere.io/devops/pkg/client/git"
+	"kubesphere.io/devops/pkg/config"
 	"kubesphere.io/devops/pkg/kapis/common"
 	"net/http"
 	"sigs.k8s.io/controller-runtime/pkg/client"
@@ -37,13 +39,32 @@ var (
 	queryParameterSecret          = restful.QueryParameter("secret", "the secret name")
 	queryParameterSecretNamespace = restful.QueryParameter("secretNamespace", "the namespace of target secret")
 	queryParameterIncludeUser     = restful.QueryParameter("includeUser", "Indicate if you want to include the current user")
+	pathParameterRepository       = restful.PathParameter("repository",
+		"The git repository name. For a GitHub repository address: https://github.com/kubesphere/ks-devops. ks-devops is the repository name")
+	queryParameterRef        = restful.QueryParameter("ref", "The branch, tag or commit SHA. The default branch is used if it is empty")
+	queryParameterPath       = restful.QueryParameter("path", "The file path relative to the root of the git repository, such as: Jenkinsfile")
+	queryParameterRefresh    = restful.QueryParameter("refresh", "Fetch the list from the git provider instead of the cache").DataType("boolean")
+	queryParameterOAuthCode  = restful.QueryParameter("code", "The authorization code which is issued by the git provider")
+	queryParameterOAuthState = restful.QueryParameter("state", "The state which is issued by the authorize API")
 )
 
-// RegisterRoutersForSCM registers the APIs which related to scm
-func RegisterRoutersForSCM(k8sClient client.Client, ws *restful.WebService) {
+// RegisterRoutersForSCM registers the APIs which related to scm.
+// The OAuth APIs work only if the OAuth applications of the git providers are configured.
+// The organizations and repositories are not cached if the cacheClient is nil.
+// The OAuth states are kept in the memory if the cacheClient is nil, it does not work with multiple replicas.
+func RegisterRoutersForSCM(k8sClient client.Client, ws *restful.WebService, oauthOption *config.SCMOAuthOption,
+	cacheClient cache.Interface) {
 	h := newHandler(k8sClient)
+	h.oauthOption = oauthOption
+	h.cache = cacheClient
+	h.states = cacheClient
+	if h.states == nil {
+		h.states = cache.NewSimpleCache()
+	}
 	registerSCMAPIs(ws, h)
+	registerOAuthAPIs(ws, h)
 	registerGitRepositoryAPIs(ws, h)
+	registerBrowsingAPIs(ws, h)
 }
 
 func registerSCMAPIs(ws *restful.WebService, h *handler) {
@@ -63,7 +84,13 @@ func registerSCMAPIs(ws *restful.WebService, h *handler) {
 		Param(queryParameterSecret).
 		Param(queryParameterSecretNamespace).
 		Param(queryParameterIncludeUser.DataType("boolean").DefaultValue("true")).
-		Doc("List all the readable organizations").
+		Param(queryParameterRefresh).
+		Param(common.NameQueryParameter).
+		Param(common.PageNumberQueryParameter).
+		Param(common.PageSizeQueryParameter).
+		Doc("List all the readable organizations, they are cached per credential and server. "+
+			"The header X-Total-Count is the count of the matched organizations, "+
+			"X-Truncated is true if there are more organizations than the fetched pages").
 		Returns(http.StatusOK, api.StatusOK, []organization{}))
 
 	ws.Route(ws.GET("/scms/{scm}/organizations/{organization}/repositories").
@@ -73,12 +100,33 @@ func registerSCMAPIs(ws *restful.WebService, h *handler) {
 		Param(pathParameterOrganization).
 		Param(queryParameterSecret.Required(true)).
 		Param(queryParameterSecretNamespace.Required(true)).
+		Param(queryParameterRefresh).
+		Param(common.NameQueryParameter).
 		Param(common.PageNumberQueryParameter).
 		Param(common.PageSizeQueryParameter).
-		Doc("List all the readable Repositories").
+		Doc("List all the readable Repositories, they are cached per credential and server").
 		Returns(http.StatusOK, api.StatusOK, repositoryListResult{}))
 }
 
+func registerOAuthAPIs(ws *restful.WebService, h *handler) {
+	ws.Route(ws.GET("/scms/{scm}/oauth/authorize").
+		To(h.authorizeOAuth).
+		Param(pathParameterSCM).
+		Param(queryParameterServer).
+		Param(queryParameterSecret.Required(true)).
+		Param(queryParameterSecretNamespace.Required(true)).
+		Doc("Get the authorization page of the OAuth application, the access token will be stored as the specified credential").
+		Returns(http.StatusOK, api.StatusOK, oauthAuthorization{}))
+
+	ws.Route(ws.GET("/scms/{scm}/oauth/callback").
+		To(h.oauthCallback).
+		Param(pathParameterSCM).
+		Param(queryParameterOAuthCode.Required(true)).
+		Param(queryParameterOAuthState.Required(true)).
+		Doc("The callback of the OAuth application, it stores the access token as a credential").
+		Returns(http.StatusOK, api.StatusOK, oauthCredential{}))
+}
+
 func registerGitRepositoryAPIs(ws *restful.WebService, h *handler) {
 	ws.Route(ws.GET("/namespaces/{namespace}/gitrepositories").
 		To(h.listGitRepositories).
@@ -117,3 +165,44 @@ func registerGitRepositoryAPIs(ws *restful.WebService, h *handler) {
 		Doc("Update a GitRepositories").
 		Returns(http.StatusOK, api.StatusOK, []v1alpha3.GitRepository{}))
 }
+
+func registerBrowsingAPIs(ws *restful.WebService, h *handler) {
+	registerBrowsingAPIsWithPrefix(ws, h, "/scms/{scm}/organizations/{organization}/repositories/{repository}",
+		h.getRepoClientFromSCM, pathParameterSCM, queryParameterServer, pathParameterOrganization, pathParameterRepository,
+		queryParameterSecret, queryParameterSecretNamespace)
+	registerBrowsingAPIsWithPrefix(ws, h, "/namespaces/{namespace}/gitrepositories/{gitrepository}",
+		h.getRepoClientFromGitRepository, common.NamespacePathParameter, pathParameterGitRepository)
+}
+
+func registerBrowsingAPIsWithPrefix(ws *restful.WebService, h *handler, prefix string, getClient repoClientGetter,
+	params ...*restful.Parameter) {
+	withParams := func(builder *restful.RouteBuilder, extraParams ...*restful.Parameter) *restful.RouteBuilder {
+		for _, param := range params {
+			builder.Param(param)
+		}
+		for _, param := range extraParams {
+			builder.Param(param)
+		}
+		return builder
+	}
+
+	ws.Route(withParams(ws.GET(prefix+"/branches").
+		To(h.listBranches(getClient)), common.PageNumberQueryParameter, common.PageSizeQueryParameter).
+		Doc("List the branches of a git repository").
+		Returns(http.StatusOK, api.StatusOK, []reference{}))
+
+	ws.Route(withParams(ws.GET(prefix+"/tags").
+		To(h.listTags(getClient)), common.PageNumberQueryParameter, common.PageSizeQueryParameter).
+		Doc("List the tags of a git repository").
+		Returns(http.StatusOK, api.StatusOK, []reference{}))
+
+	ws.Route(withParams(ws.GET(prefix+"/commits").
+		To(h.listCommits(getClient)), queryParameterRef, common.PageNumberQueryParameter, common.PageSizeQueryParameter).
+		Doc("List the recent commits of a ref").
+		Returns(http.StatusOK, api.StatusOK, []commit{}))
+
+	ws.Route(withParams(ws.GET(prefix+"/contents").
+		To(h.getFileContent(getClient)), queryParameterPath.Required(true), queryParameterRef).
+		Doc("Get the raw content of a file at a ref").
+		Returns(http.StatusOK, api.StatusOK, fileContent{}))
+}
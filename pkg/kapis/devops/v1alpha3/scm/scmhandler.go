@@ -18,20 +18,47 @@ package scm
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"strconv"
+	"time"
+
 	"github.com/emicklei/go-restful"
 	goscm "github.com/jenkins-x/go-scm/scm"
 	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/klog/v2"
+	"kubesphere.io/devops/pkg/client/cache"
 	"kubesphere.io/devops/pkg/client/git"
+	"kubesphere.io/devops/pkg/config"
 	"kubesphere.io/devops/pkg/kapis"
 	"kubesphere.io/devops/pkg/kapis/common"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"strings"
 )
 
+const (
+	// listCacheTTL is how long the organizations and repositories of a git provider are cached
+	listCacheTTL = 10 * time.Minute
+	// listPageSize is the page size when fetching all the organizations or repositories from a git provider
+	listPageSize = 100
+	// listMaxPages limits the pages which are fetched from a git provider
+	listMaxPages = 50
+
+	// headerTotalCount is the response header of the count of the items which match the search
+	headerTotalCount = "X-Total-Count"
+	// headerTruncated is the response header which indicates the list stops at listMaxPages
+	headerTruncated = "X-Truncated"
+)
+
 // handler holds all the API handlers of SCM
 type handler struct {
 	client.Client
+
+	oauthOption *config.SCMOAuthOption
+	cache       cache.Interface
+	// states holds the states of the OAuth authorizations
+	states cache.Interface
 }
 
 // NewHandler creates the instance of the SCM handler
@@ -48,7 +75,7 @@ func (h *handler) verify(request *restful.Request, response *restful.Response) {
 	secretNamespace := request.QueryParameter("secretNamespace")
 	server := common.GetQueryParameter(request, queryParameterServer)
 
-	_, code, err := h.getOrganizations(scm, server, secretName, secretNamespace, 1, 1, false)
+	_, code, err := h.getOrganizations(scm, server, secretName, secretNamespace, 1, 1)
 
 	response.Header().Set(restful.HEADER_ContentType, restful.MIME_JSON)
 	verifyResult := git.VerifyResult(err, code)
@@ -56,15 +83,10 @@ func (h *handler) verify(request *restful.Request, response *restful.Response) {
 	_ = response.WriteAsJson(verifyResult)
 }
 
-func (h *handler) getOrganizations(scm, server, secret, namespace string, page, size int, includeUser bool) (orgs []*goscm.Organization, code int, err error) {
-	factory := git.NewClientFactory(scm, &v1.SecretReference{
-		Namespace: namespace, Name: secret,
-	}, h.Client)
-	factory.Server = server
-
+func (h *handler) getOrganizations(scm, server, secret, namespace string, page, size int) (orgs []*goscm.Organization, code int, err error) {
 	ctx := context.Background()
 	var c *goscm.Client
-	if c, err = factory.GetClient(); err == nil {
+	if c, err = h.getSCMClient(ctx, scm, server, secret, namespace); err == nil {
 		var resp *goscm.Response
 
 		if orgs, resp, err = c.Organizations.List(ctx, &goscm.ListOptions{Size: size, Page: page}); err == nil {
@@ -72,58 +94,111 @@ func (h *handler) getOrganizations(scm, server, secret, namespace string, page,
 		} else {
 			code = 101
 		}
-
-		if includeUser {
-			var user string
-			if user, err = h.getCurrentUsername(c); err == nil {
-				orgs = append(orgs, &goscm.Organization{
-					Name:   user,
-					Avatar: fmt.Sprintf("https://avatars.githubusercontent.com/%s", user),
-				})
-			}
-		}
 	} else {
 		code = 100
 	}
 	return
 }
 
-func (h *handler) getRepositories(scm, server, org, secret, namespace string, page, size int) (repos []*goscm.Repository, code int, err error) {
-	factory := git.NewClientFactory(scm, &v1.SecretReference{
-		Namespace: namespace, Name: secret,
-	}, h.Client)
-	factory.Server = server
-
+// listAllOrganizations returns all the readable organizations from the git provider
+func (h *handler) listAllOrganizations(scm, server, secret, namespace string, includeUser bool) (result *organizationList, err error) {
+	ctx := context.Background()
 	var c *goscm.Client
-	if c, err = factory.GetClient(); err == nil {
-		// check if the org name is a user account name
+	if c, err = h.getSCMClient(ctx, scm, server, secret, namespace); err != nil {
+		return
+	}
+
+	var orgs []*goscm.Organization
+	var truncated bool
+	if truncated, err = listAllPages(func(options *goscm.ListOptions) (*goscm.Response, error) {
+		items, resp, err := c.Organizations.List(ctx, options)
+		orgs = append(orgs, items...)
+		return resp, err
+	}); err != nil {
+		return
+	}
+
+	if includeUser {
 		var user string
-		var listRepositoryFunc listRepository
-		if user, err = h.getCurrentUsername(c); err == nil {
-			if user == org && !strings.HasPrefix(scm, "bitbucket") {
-				listRepositoryFunc = func(ctx context.Context, s string, options *goscm.ListOptions) ([]*goscm.Repository, *goscm.Response, error) {
-					return c.Repositories.List(ctx, options)
-				}
-			} else {
-				listRepositoryFunc = c.Repositories.ListOrganisation
-			}
-		} else {
+		if user, err = h.getCurrentUsername(c); err != nil {
 			return
 		}
+		orgs = append(orgs, &goscm.Organization{
+			Name:   user,
+			Avatar: fmt.Sprintf("https://avatars.githubusercontent.com/%s", user),
+		})
+	}
+	result = &organizationList{Items: transformOrganizations(orgs), Truncated: truncated}
+	return
+}
 
-		ctx := context.Background()
-		if repos, _, err = listRepositoryFunc(ctx, org, &goscm.ListOptions{
-			Page: page,
-			Size: size,
-		}); err != nil {
-			code = 101
+// listAllRepositories returns all the readable repositories of an organization or the current user from the git provider
+func (h *handler) listAllRepositories(scm, server, org, secret, namespace string) (result *repositoryList, err error) {
+	ctx := context.Background()
+	var c *goscm.Client
+	if c, err = h.getSCMClient(ctx, scm, server, secret, namespace); err != nil {
+		return
+	}
+
+	// check if the org name is a user account name
+	var user string
+	if user, err = h.getCurrentUsername(c); err != nil {
+		return
+	}
+	var listRepositoryFunc listRepository
+	if user == org && !strings.HasPrefix(scm, "bitbucket") {
+		listRepositoryFunc = func(ctx context.Context, s string, options *goscm.ListOptions) ([]*goscm.Repository, *goscm.Response, error) {
+			return c.Repositories.List(ctx, options)
 		}
 	} else {
-		code = 100
+		listRepositoryFunc = c.Repositories.ListOrganisation
+	}
+
+	var repos []*goscm.Repository
+	var truncated bool
+	if truncated, err = listAllPages(func(options *goscm.ListOptions) (*goscm.Response, error) {
+		items, resp, err := listRepositoryFunc(ctx, org, options)
+		repos = append(repos, items...)
+		return resp, err
+	}); err == nil {
+		result = &repositoryList{Items: transformRepositories(repos), Truncated: truncated}
+	}
+	return
+}
+
+func (h *handler) getSCMClient(ctx context.Context, scm, server, secret, namespace string) (c *goscm.Client, err error) {
+	secretRef := &v1.SecretReference{
+		Namespace: namespace, Name: secret,
+	}
+	if err = h.refreshOAuthToken(ctx, secretRef); err != nil {
+		return
 	}
+
+	factory := git.NewClientFactory(scm, secretRef, h.Client)
+	factory.Server = server
+	c, err = factory.GetClientWithContext(ctx)
 	return
 }
 
+// listAllPages calls the list function page by page until there is no next page.
+// It returns true if there are still more pages after listMaxPages.
+func listAllPages(list func(*goscm.ListOptions) (*goscm.Response, error)) (truncated bool, err error) {
+	for page := 1; ; {
+		var resp *goscm.Response
+		if resp, err = list(&goscm.ListOptions{Page: page, Size: listPageSize}); err != nil {
+			return
+		}
+		if resp == nil || resp.Page.Next <= page {
+			return
+		}
+		if page >= listMaxPages {
+			truncated = true
+			return
+		}
+		page = resp.Page.Next
+	}
+}
+
 type listRepository func(context.Context, string, *goscm.ListOptions) ([]*goscm.Repository, *goscm.Response, error)
 
 func (h *handler) listOrganizations(req *restful.Request, rsp *restful.Response) {
@@ -132,14 +207,30 @@ func (h *handler) listOrganizations(req *restful.Request, rsp *restful.Response)
 	secretNamespace := req.QueryParameter("secretNamespace")
 	server := common.GetQueryParameter(req, queryParameterServer)
 	includeUser := common.GetQueryParameter(req, queryParameterIncludeUser) == "true"
+	refresh := common.GetQueryParameter(req, queryParameterRefresh) == "true"
+	keyword := common.GetQueryParameter(req, common.NameQueryParameter)
 	pageNumber, pageSize := common.GetPageParameters(req)
 
-	orgs, _, err := h.getOrganizations(scm, server, secretName, secretNamespace, pageNumber, pageSize, includeUser)
-	if err != nil {
-		kapis.HandleError(req, rsp, err)
-	} else {
-		_ = rsp.WriteEntity(transformOrganizations(orgs))
+	orgs := &organizationList{}
+	key := getListCacheKey("organizations", scm, server, secretNamespace, secretName,
+		h.getSecretVersion(req.Request.Context(), secretNamespace, secretName), strconv.FormatBool(includeUser))
+	if refresh || !h.getListCache(key, orgs) {
+		var err error
+		if orgs, err = h.listAllOrganizations(scm, server, secretName, secretNamespace, includeUser); err != nil {
+			kapis.HandleError(req, rsp, err)
+			return
+		}
+		h.setListCache(key, orgs)
 	}
+
+	// the response body is an array for keeping compatible with the Jenkins response,
+	// so the total count and the truncation are in the headers
+	items := filterOrganizations(orgs.Items, keyword)
+	rsp.AddHeader(headerTotalCount, strconv.Itoa(len(items)))
+	if orgs.Truncated {
+		rsp.AddHeader(headerTruncated, "true")
+	}
+	_ = rsp.WriteEntity(paginateOrganizations(items, pageNumber, pageSize))
 }
 
 func (h *handler) listRepositories(req *restful.Request, rsp *restful.Response) {
@@ -148,13 +239,67 @@ func (h *handler) listRepositories(req *restful.Request, rsp *restful.Response)
 	organization := req.PathParameter("organization")
 	secretName := req.QueryParameter("secret")
 	secretNamespace := req.QueryParameter("secretNamespace")
+	refresh := common.GetQueryParameter(req, queryParameterRefresh) == "true"
+	keyword := common.GetQueryParameter(req, common.NameQueryParameter)
 	pageNumber, pageSize := common.GetPageParameters(req)
 
-	repos, _, err := h.getRepositories(scm, server, organization, secretName, secretNamespace, pageNumber, pageSize)
+	repos := &repositoryList{}
+	key := getListCacheKey("repositories", scm, server, secretNamespace, secretName,
+		h.getSecretVersion(req.Request.Context(), secretNamespace, secretName), organization)
+	if refresh || !h.getListCache(key, repos) {
+		var err error
+		if repos, err = h.listAllRepositories(scm, server, organization, secretName, secretNamespace); err != nil {
+			kapis.HandleError(req, rsp, err)
+			return
+		}
+		h.setListCache(key, repos)
+	}
+
+	items := filterRepositories(repos.Items, keyword)
+	result := &repositoryListResult{}
+	result.Repositories.Items = paginateRepositories(items, pageNumber, pageSize)
+	result.Repositories.Total = len(items)
+	result.Repositories.Truncated = repos.Truncated
+	_ = rsp.WriteEntity(result)
+}
+
+// getListCacheKey returns the cache key of a list, the list is cached per credential and server
+func getListCacheKey(kind string, scm, server, namespace, secret, secretVersion string, extra ...string) string {
+	return strings.Join(append([]string{"devops", "scm", kind, scm, server, namespace, secret, secretVersion}, extra...), ":")
+}
+
+// getSecretVersion returns the resourceVersion of a secret, the cached lists are invalid once the secret changed.
+// It returns an empty string if the secret cannot be found, the error will be reported when listing from the git provider.
+func (h *handler) getSecretVersion(ctx context.Context, namespace, name string) string {
+	secret := &v1.Secret{}
+	if err := h.Get(ctx, types.NamespacedName{Namespace: namespace, Name: name}, secret); err != nil {
+		return ""
+	}
+	return secret.ResourceVersion
+}
+
+// getListCache gets the cached list, returns false if there is no valid cache
+func (h *handler) getListCache(key string, list interface{}) bool {
+	if h.cache == nil {
+		return false
+	}
+	value, err := h.cache.Get(key)
 	if err != nil {
-		kapis.HandleError(req, rsp, err)
-	} else {
-		_ = rsp.WriteEntity(transformRepositories(repos))
+		return false
+	}
+	return json.Unmarshal([]byte(value), list) == nil
+}
+
+func (h *handler) setListCache(key string, list interface{}) {
+	if h.cache == nil {
+		return
+	}
+	data, err := json.Marshal(list)
+	if err == nil {
+		err = h.cache.Set(key, string(data), listCacheTTL)
+	}
+	if err != nil {
+		klog.Warningf("failed to cache %s, error is: %v", key, err)
 	}
 }
 
@@ -170,29 +315,82 @@ func (h *handler) getCurrentUsername(c *goscm.Client) (username string, err erro
 }
 
 func transformOrganizations(orgs []*goscm.Organization) (result []organization) {
-	if orgs != nil {
-		result = make([]organization, len(orgs))
-		for i := range orgs {
-			result[i] = organization{
-				Name:   orgs[i].Name,
-				Avatar: orgs[i].Avatar,
-			}
+	result = make([]organization, len(orgs))
+	for i := range orgs {
+		result[i] = organization{
+			Name:   orgs[i].Name,
+			Avatar: orgs[i].Avatar,
+		}
+	}
+	return
+}
+
+func transformRepositories(goSCMRepos []*goscm.Repository) (result []repository) {
+	result = make([]repository, len(goSCMRepos))
+	for i := range goSCMRepos {
+		result[i] = repository{
+			Name:          goSCMRepos[i].Name,
+			DefaultBranch: goSCMRepos[i].Branch,
 		}
 	}
 	return
 }
 
-func transformRepositories(goSCMRepos []*goscm.Repository) (result *repositoryListResult) {
-	result = &repositoryListResult{}
-	if goSCMRepos != nil {
-		repos := make([]repository, len(goSCMRepos))
-		for i := range goSCMRepos {
-			repos[i] = repository{
-				Name:          goSCMRepos[i].Name,
-				DefaultBranch: goSCMRepos[i].Branch,
-			}
+// filterOrganizations returns the organizations whose names contain the keyword, it is case-insensitive
+func filterOrganizations(orgs []organization, keyword string) []organization {
+	if keyword == "" {
+		return orgs
+	}
+	result := make([]organization, 0, len(orgs))
+	for i := range orgs {
+		if containsIgnoreCase(orgs[i].Name, keyword) {
+			result = append(result, orgs[i])
+		}
+	}
+	return result
+}
+
+// filterRepositories returns the repositories whose names contain the keyword, it is case-insensitive
+func filterRepositories(repos []repository, keyword string) []repository {
+	if keyword == "" {
+		return repos
+	}
+	result := make([]repository, 0, len(repos))
+	for i := range repos {
+		if containsIgnoreCase(repos[i].Name, keyword) {
+			result = append(result, repos[i])
 		}
-		result.Repositories.Items = repos
+	}
+	return result
+}
+
+func containsIgnoreCase(s, substr string) bool {
+	return strings.Contains(strings.ToLower(s), strings.ToLower(substr))
+}
+
+func paginateOrganizations(orgs []organization, pageNumber, pageSize int) []organization {
+	start, end := getPageRange(len(orgs), pageNumber, pageSize)
+	return orgs[start:end]
+}
+
+func paginateRepositories(repos []repository, pageNumber, pageSize int) []repository {
+	start, end := getPageRange(len(repos), pageNumber, pageSize)
+	return repos[start:end]
+}
+
+// getPageRange returns the range of a page, all the items are in the page if the page number or size is invalid
+func getPageRange(total, pageNumber, pageSize int) (start, end int) {
+	if pageNumber < 1 || pageSize < 1 {
+		return 0, total
+	}
+
+	start = (pageNumber - 1) * pageSize
+	if start > total {
+		start = total
+	}
+	end = start + pageSize
+	if end > total {
+		end = total
 	}
 	return
 }
@@ -19,8 +19,10 @@ package scm
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"github.com/emicklei/go-restful"
 	"github.com/h2non/gock"
+	goscm "github.com/jenkins-x/go-scm/scm"
 	"github.com/stretchr/testify/assert"
 	v1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -28,10 +30,12 @@ import (
 	"kubesphere.io/devops/pkg/api"
 	"kubesphere.io/devops/pkg/api/devops/v1alpha1"
 	"kubesphere.io/devops/pkg/apiserver/runtime"
+	"kubesphere.io/devops/pkg/client/cache"
 	"kubesphere.io/devops/pkg/client/git"
 	"kubesphere.io/devops/pkg/constants"
 	"net/http"
 	"net/http/httptest"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 	"testing"
 )
@@ -328,7 +332,7 @@ func TestSCMAPI(t *testing.T) {
 				Data: map[string][]byte{
 					v1.ServiceAccountTokenKey: []byte("token"),
 				},
-			}), ws)
+			}), ws, nil, nil)
 			container := restful.NewContainer()
 			container.Add(ws)
 
@@ -338,3 +342,143 @@ func TestSCMAPI(t *testing.T) {
 		})
 	}
 }
+
+func TestListWithCache(t *testing.T) {
+	schema, err := v1alpha1.SchemeBuilder.Register().Build()
+	assert.Nil(t, err)
+	err = v1.SchemeBuilder.AddToScheme(schema)
+	assert.Nil(t, err)
+
+	ws := runtime.NewWebService(runtimeSchema.GroupVersion{Group: api.GroupName, Version: "v1alpha3"})
+	secret := &v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "token", Namespace: "default",
+		},
+		Type: v1.SecretTypeOpaque,
+		Data: map[string][]byte{
+			v1.ServiceAccountTokenKey: []byte("token"),
+		},
+	}
+	k8sClient := fake.NewFakeClientWithScheme(schema, secret.DeepCopy())
+	RegisterRoutersForSCM(k8sClient, ws, nil, cache.NewSimpleCache())
+	container := restful.NewContainer()
+	container.Add(ws)
+
+	request := func(uri string) (code int, repos repositoryListResult) {
+		httpRequest, _ := http.NewRequest(http.MethodGet, "http://fake.com/kapis/devops.kubesphere.io/v1alpha3"+uri, nil)
+		httpWriter := httptest.NewRecorder()
+		container.Dispatch(httpWriter, httpRequest)
+		code = httpWriter.Code
+		if code == http.StatusOK {
+			assert.Nil(t, json.Unmarshal(httpWriter.Body.Bytes(), &repos))
+		}
+		return
+	}
+
+	defer gock.Off()
+	gock.New("https://api.github.com").
+		Get("/user").
+		Reply(200).
+		Type("application/json").
+		File("testdata/user.json")
+	gock.New("https://api.github.com").
+		Get("/orgs/octocat-org/repos").
+		MatchParam("page", "^1$").
+		MatchParam("per_page", "100").
+		Reply(200).
+		Type("application/json").
+		SetHeader("Link", `<https://api.github.com/orgs/octocat-org/repos?page=2>; rel="next"`).
+		JSON([]map[string]string{{"name": "Hello-World", "default_branch": "master"}, {"name": "Spoon-Knife", "default_branch": "main"}})
+	gock.New("https://api.github.com").
+		Get("/orgs/octocat-org/repos").
+		MatchParam("page", "^2$").
+		Reply(200).
+		Type("application/json").
+		JSON([]map[string]string{{"name": "hello-go", "default_branch": "main"}})
+
+	// fetch all the pages from the git provider, then search and paginate them
+	code, repos := request("/scms/github/organizations/octocat-org/repositories?secret=token&secretNamespace=default&name=hello&pageSize=1")
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, 2, repos.Repositories.Total)
+	assert.Equal(t, []repository{{Name: "Hello-World", DefaultBranch: "master"}}, repos.Repositories.Items)
+	assert.True(t, gock.IsDone())
+
+	// take the list from the cache
+	code, repos = request("/scms/github/organizations/octocat-org/repositories?secret=token&secretNamespace=default&pageNumber=2&pageSize=2")
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, 3, repos.Repositories.Total)
+	assert.Equal(t, []repository{{Name: "hello-go", DefaultBranch: "main"}}, repos.Repositories.Items)
+
+	// the cache is ignored when refreshing, there are no more mock responses from the git provider
+	code, _ = request("/scms/github/organizations/octocat-org/repositories?secret=token&secretNamespace=default&refresh=true")
+	assert.Equal(t, http.StatusInternalServerError, code)
+
+	// the cache is invalid once the secret changed
+	code, _ = request("/scms/github/organizations/octocat-org/repositories?secret=token&secretNamespace=default")
+	assert.Equal(t, http.StatusOK, code)
+	assert.Nil(t, k8sClient.Get(context.Background(), client.ObjectKeyFromObject(secret), secret))
+	secret.Data[v1.ServiceAccountTokenKey] = []byte("new-token")
+	assert.Nil(t, k8sClient.Update(context.Background(), secret))
+	code, _ = request("/scms/github/organizations/octocat-org/repositories?secret=token&secretNamespace=default")
+	assert.Equal(t, http.StatusInternalServerError, code)
+}
+
+func Test_listAllPages(t *testing.T) {
+	tests := []struct {
+		name          string
+		lastPage      int
+		err           error
+		wantPages     int
+		wantTruncated bool
+		wantErr       bool
+	}{{
+		name: "single page", lastPage: 1, wantPages: 1,
+	}, {
+		name: "multiple pages", lastPage: 3, wantPages: 3,
+	}, {
+		name: "all the max pages", lastPage: listMaxPages, wantPages: listMaxPages,
+	}, {
+		name: "more than the max pages", lastPage: listMaxPages + 1, wantPages: listMaxPages, wantTruncated: true,
+	}, {
+		name: "failed to list", lastPage: 3, err: errors.New("fake"), wantPages: 1, wantErr: true,
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pages := 0
+			truncated, err := listAllPages(func(options *goscm.ListOptions) (*goscm.Response, error) {
+				pages++
+				resp := &goscm.Response{}
+				if options.Page < tt.lastPage {
+					resp.Page.Next = options.Page + 1
+				}
+				return resp, tt.err
+			})
+			assert.Equal(t, tt.wantErr, err != nil)
+			assert.Equal(t, tt.wantTruncated, truncated)
+			assert.Equal(t, tt.wantPages, pages)
+		})
+	}
+}
+
+func Test_getPageRange(t *testing.T) {
+	tests := []struct {
+		name                        string
+		total, pageNumber, pageSize int
+		wantStart, wantEnd          int
+	}{{
+		name: "first page", total: 3, pageNumber: 1, pageSize: 2, wantStart: 0, wantEnd: 2,
+	}, {
+		name: "last page", total: 3, pageNumber: 2, pageSize: 2, wantStart: 2, wantEnd: 3,
+	}, {
+		name: "out of range", total: 3, pageNumber: 3, pageSize: 2, wantStart: 3, wantEnd: 3,
+	}, {
+		name: "invalid page", total: 3, pageNumber: 0, pageSize: 0, wantStart: 0, wantEnd: 3,
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			start, end := getPageRange(tt.total, tt.pageNumber, tt.pageSize)
+			assert.Equal(t, tt.wantStart, start)
+			assert.Equal(t, tt.wantEnd, end)
+		})
+	}
+}
@@ -17,6 +17,7 @@ limitations under the License.
 package scm
 
 import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"kubesphere.io/devops/pkg/api/devops/v1alpha3"
 )
 
@@ -36,11 +37,63 @@ type repository struct {
 type repositoryListResult struct {
 	Repositories struct {
 		Items []repository `json:"items"`
+		// Total is the count of the repositories which match the search
+		Total int `json:"total"`
+		// Truncated indicates there are more repositories than the fetched pages
+		Truncated bool `json:"truncated,omitempty"`
 	} `json:"repositories"`
 }
 
+// organizationList is all the organizations which are fetched from a git provider
+type organizationList struct {
+	Items []organization `json:"items"`
+	// Truncated indicates there are more organizations than the fetched pages
+	Truncated bool `json:"truncated,omitempty"`
+}
+
+// repositoryList is all the repositories which are fetched from a git provider
+type repositoryList struct {
+	Items []repository `json:"items"`
+	// Truncated indicates there are more repositories than the fetched pages
+	Truncated bool `json:"truncated,omitempty"`
+}
+
 // GitRepositoryPageResult is the model of page result of GitRepositories.
 type GitRepositoryPageResult struct {
 	Items      []v1alpha3.GitRepository `json:"items"`
 	TotalItems int                      `json:"totalItems"`
 }
+
+// reference is a branch or a tag of a git repository
+type reference struct {
+	Name string `json:"name"`
+	SHA  string `json:"sha"`
+}
+
+type commit struct {
+	SHA         string      `json:"sha"`
+	Message     string      `json:"message"`
+	Author      string      `json:"author"`
+	AuthorEmail string      `json:"authorEmail,omitempty"`
+	Date        metav1.Time `json:"date"`
+	Link        string      `json:"link,omitempty"`
+}
+
+// fileContent is the raw content of a file at a specific ref
+type fileContent struct {
+	Path    string `json:"path"`
+	Ref     string `json:"ref,omitempty"`
+	SHA     string `json:"sha,omitempty"`
+	Content string `json:"content"`
+}
+
+// oauthAuthorization is the authorization page of the OAuth2 application of a git provider
+type oauthAuthorization struct {
+	URL string `json:"url"`
+}
+
+// oauthCredential is the credential which stores the access token of the OAuth2 flow
+type oauthCredential struct {
+	Namespace    string `json:"namespace"`
+	CredentialID string `json:"credentialId"`
+}
@@ -0,0 +1,252 @@
+/*
+Copyright 2022 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhook
+
+import (
+	"context"
+	"crypto/subtle"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/emicklei/go-restful"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/klog/v2"
+	"kubesphere.io/devops/pkg/api/devops/v1alpha3"
+	"kubesphere.io/devops/pkg/client/devops"
+	"kubesphere.io/devops/pkg/indexers"
+	"kubesphere.io/devops/pkg/kapis"
+	"kubesphere.io/devops/pkg/kapis/devops/v1alpha3/pipelinerun"
+	modelpipelinerun "kubesphere.io/devops/pkg/models/pipelinerun"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// triggerCauseAnnotationKey is the annotation key of the cause which triggered a PipelineRun
+	triggerCauseAnnotationKey = "devops.kubesphere.io/trigger-cause"
+	// maxGenericContentLength is the max length of the post content of a generic webhook
+	maxGenericContentLength = 10000000
+)
+
+// TriggerHandler handles the remote triggers and the generic webhooks of the Pipelines without Jenkins
+type TriggerHandler struct {
+	client.Client
+}
+
+// NewTriggerHandler creates a new handler for the remote triggers and the generic webhooks
+func NewTriggerHandler(genericClient client.Client) *TriggerHandler {
+	return &TriggerHandler{Client: genericClient}
+}
+
+// GenericTriggerResult is the result of triggering a Pipeline by a generic webhook
+type GenericTriggerResult struct {
+	Triggered              bool              `json:"triggered"`
+	ID                     string            `json:"id,omitempty"`
+	RegexpFilterText       string            `json:"regexpFilterText"`
+	RegexpFilterExpression string            `json:"regexpFilterExpression"`
+	ResolvedVariables      map[string]string `json:"resolvedVariables,omitempty"`
+	Error                  string            `json:"error,omitempty"`
+}
+
+// GenericTriggerResponse is the response of a generic webhook, the keys of the jobs are like: namespace/pipeline
+type GenericTriggerResponse struct {
+	Jobs    map[string]*GenericTriggerResult `json:"jobs"`
+	Message string                           `json:"message"`
+}
+
+// getGenericToken returns the token of a generic webhook request, it comes from the query parameter, the header
+// token, the header X-Gitlab-Token or the bearer token
+func getGenericToken(req *http.Request) (token string) {
+	if token = req.URL.Query().Get("token"); token != "" {
+		return
+	}
+	if token = req.Header.Get("token"); token != "" {
+		return
+	}
+	if token = req.Header.Get("X-Gitlab-Token"); token != "" {
+		return
+	}
+	if auth := req.Header.Get("Authorization"); strings.HasPrefix(auth, "Bearer ") {
+		token = strings.TrimPrefix(auth, "Bearer ")
+	}
+	return
+}
+
+func (h *TriggerHandler) genericWebhook(request *restful.Request, response *restful.Response) {
+	webhookToken := getGenericToken(request.Request)
+	if webhookToken == "" {
+		kapis.HandleNotFound(response, request, fmt.Errorf("no token found in the request"))
+		return
+	}
+
+	body, err := kapis.ReadAllWithLimit(request.Request.Body, maxGenericContentLength)
+	if err != nil {
+		kapis.HandleError(request, response, err)
+		return
+	}
+
+	ctx := request.Request.Context()
+	var pipelines []v1alpha3.Pipeline
+	if pipelines, err = h.findGenericWebhookPipelines(ctx, webhookToken); err != nil {
+		kapis.HandleError(request, response, err)
+		return
+	} else if len(pipelines) == 0 {
+		kapis.HandleNotFound(response, request, fmt.Errorf("did not find any Pipelines with the generic webhook token"))
+		return
+	}
+
+	req := &genericRequest{
+		header: request.Request.Header,
+		query:  request.Request.URL.Query(),
+		body:   body,
+	}
+	result := &GenericTriggerResponse{
+		Jobs:    map[string]*GenericTriggerResult{},
+		Message: "Triggered jobs.",
+	}
+	for i := range pipelines {
+		pipeline := &pipelines[i]
+		result.Jobs[pipeline.Namespace+"/"+pipeline.Name] = h.triggerByGenericWebhook(ctx, pipeline, req)
+	}
+	_ = response.WriteEntity(result)
+}
+
+// triggerByGenericWebhook creates a PipelineRun if the request matches the filter of the generic webhook
+func (h *TriggerHandler) triggerByGenericWebhook(ctx context.Context, pipeline *v1alpha3.Pipeline, req *genericRequest) (
+	result *GenericTriggerResult) {
+	webhook := pipeline.Spec.Pipeline.GenericWebhook
+	result = &GenericTriggerResult{RegexpFilterExpression: webhook.FilterExpression}
+	if webhook.PrintPostContent {
+		klog.Infof("received the post content of the generic webhook of Pipeline %s/%s: %s",
+			pipeline.Namespace, pipeline.Name, string(req.body))
+	}
+
+	variables, err := resolveGenericVariables(webhook, req)
+	if err == nil {
+		result.RegexpFilterText, result.Triggered, err = matchGenericFilter(webhook, variables)
+	}
+	if webhook.PrintVariables {
+		result.ResolvedVariables = variables
+	}
+	if err != nil || !result.Triggered {
+		result.Triggered = false
+		if err != nil {
+			result.Error = err.Error()
+		}
+		return
+	}
+
+	cause := webhook.Cause
+	if cause == "" {
+		cause = defaultGenericCause
+	}
+	var run *v1alpha3.PipelineRun
+	if run, err = h.createPipelineRun(ctx, pipeline, variables, "generic-webhook",
+		renderGenericText(cause, variables)); err != nil {
+		result.Triggered = false
+		result.Error = err.Error()
+	} else {
+		result.ID = run.Name
+	}
+	return
+}
+
+// findGenericWebhookPipelines finds the Pipelines by the indexer of the generic webhook token
+func (h *TriggerHandler) findGenericWebhookPipelines(ctx context.Context, webhookToken string) (pipelines []v1alpha3.Pipeline, err error) {
+	pipelineList := &v1alpha3.PipelineList{}
+	if err = h.List(ctx, pipelineList, client.MatchingFields{v1alpha3.PipelineGenericWebhookTokenIndexerName: webhookToken}); err != nil {
+		return
+	}
+	for i := range pipelineList.Items {
+		pipeline := pipelineList.Items[i]
+		// double check the token in case the client does not support the field selector
+		if isSameToken(indexers.GetPipelineGenericWebhookToken(&pipeline), webhookToken) {
+			pipelines = append(pipelines, pipeline)
+		}
+	}
+	return
+}
+
+func (h *TriggerHandler) remoteTrigger(request *restful.Request, response *restful.Response) {
+	ctx := request.Request.Context()
+	pipeline := &v1alpha3.Pipeline{}
+	if err := h.Get(ctx, types.NamespacedName{
+		Namespace: request.PathParameter("namespace"),
+		Name:      request.PathParameter("pipeline"),
+	}, pipeline); err != nil {
+		kapis.HandleError(request, response, err)
+		return
+	}
+
+	if err := request.Request.ParseForm(); err != nil {
+		kapis.HandleBadRequest(response, request, err)
+		return
+	}
+	form := request.Request.Form
+	if pipeline.Spec.Pipeline == nil || pipeline.Spec.Pipeline.RemoteTrigger == nil ||
+		!isSameToken(pipeline.Spec.Pipeline.RemoteTrigger.Token, form.Get("token")) {
+		kapis.HandleForbidden(response, request, fmt.Errorf("invalid token of the remote trigger"))
+		return
+	}
+
+	variables := map[string]string{}
+	for key := range form {
+		variables[key] = form.Get(key)
+	}
+	cause := form.Get("cause")
+	if cause == "" {
+		cause = "Started by remote host " + request.Request.RemoteAddr
+	}
+
+	run, err := h.createPipelineRun(ctx, pipeline, variables, "remote-trigger", cause)
+	if err != nil {
+		kapis.HandleError(request, response, err)
+		return
+	}
+	_ = response.WriteHeaderAndEntity(http.StatusCreated, run)
+}
+
+// createPipelineRun creates a PipelineRun with the parameters which are defined in the Pipeline or its Jenkins job.
+// The parameters are validated in the same way as creating a PipelineRun through the API.
+func (h *TriggerHandler) createPipelineRun(ctx context.Context, pipeline *v1alpha3.Pipeline, variables map[string]string,
+	trigger, cause string) (run *v1alpha3.PipelineRun, err error) {
+	payload := &devops.RunPayload{}
+	for _, name := range modelpipelinerun.GetParameterNames(pipeline, "") {
+		if value, ok := variables[name]; ok {
+			payload.Parameters = append(payload.Parameters, devops.Parameter{Name: name, Value: value})
+		}
+	}
+
+	var scmObj *v1alpha3.SCM
+	if scmObj, err = pipelinerun.CreateScm(&pipeline.Spec, ""); err != nil {
+		return
+	}
+	run = pipelinerun.CreatePipelineRun(pipeline, payload, scmObj)
+	if err = pipelinerun.ValidateParameters(pipeline, run); err != nil {
+		err = apierrors.NewBadRequest(err.Error())
+		return
+	}
+	run.Annotations[triggerAnnotationKey] = trigger
+	run.Annotations[triggerCauseAnnotationKey] = cause
+	err = h.Create(ctx, run)
+	return
+}
+
+func isSameToken(expected, actual string) bool {
+	return expected != "" && subtle.ConstantTimeCompare([]byte(expected), []byte(actual)) == 1
+}
@@ -0,0 +1,299 @@
+/*
+Copyright 2022 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/emicklei/go-restful"
+	"github.com/jenkins-zh/jenkins-client/pkg/core"
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/client-go/kubernetes/scheme"
+	"kubesphere.io/devops/pkg/api/devops/v1alpha3"
+	apiserverruntime "kubesphere.io/devops/pkg/apiserver/runtime"
+	"kubesphere.io/devops/pkg/jwt/token"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func Test_getGenericToken(t *testing.T) {
+	tests := []struct {
+		name    string
+		request func() *http.Request
+		want    string
+	}{{
+		name: "query parameter",
+		request: func() *http.Request {
+			req, _ := http.NewRequest(http.MethodPost, "http://fake.com/webhooks/generic?token=query", nil)
+			return req
+		},
+		want: "query",
+	}, {
+		name: "token header",
+		request: func() *http.Request {
+			req, _ := http.NewRequest(http.MethodPost, "http://fake.com/webhooks/generic", nil)
+			req.Header.Set("token", "header")
+			return req
+		},
+		want: "header",
+	}, {
+		name: "GitLab token header",
+		request: func() *http.Request {
+			req, _ := http.NewRequest(http.MethodPost, "http://fake.com/webhooks/generic", nil)
+			req.Header.Set("X-Gitlab-Token", "gitlab")
+			return req
+		},
+		want: "gitlab",
+	}, {
+		name: "bearer token",
+		request: func() *http.Request {
+			req, _ := http.NewRequest(http.MethodPost, "http://fake.com/webhooks/generic", nil)
+			req.Header.Set("Authorization", "Bearer bearer")
+			return req
+		},
+		want: "bearer",
+	}, {
+		name: "no token",
+		request: func() *http.Request {
+			req, _ := http.NewRequest(http.MethodPost, "http://fake.com/webhooks/generic", nil)
+			req.Header.Set("Authorization", "Basic basic")
+			return req
+		},
+		want: "",
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, getGenericToken(tt.request()))
+		})
+	}
+}
+
+func TestTriggerHandler(t *testing.T) {
+	genericPipeline := &v1alpha3.Pipeline{
+		ObjectMeta: v1.ObjectMeta{Name: "generic", Namespace: "devops"},
+		Spec: v1alpha3.PipelineSpec{
+			Type: v1alpha3.NoScmPipelineType,
+			Pipeline: &v1alpha3.NoScmPipeline{
+				Name:       "generic",
+				Parameters: []v1alpha3.ParameterDefinition{{Name: "ref"}, {Name: "branch"}},
+				GenericWebhook: &v1alpha3.GenericWebhook{
+					Enable:           true,
+					Token:            "generic-token",
+					Cause:            "Triggered by $ref",
+					PrintVariables:   true,
+					FilterText:       "$ref",
+					FilterExpression: "^refs/heads/master$",
+					PostContentVariables: []v1alpha3.GenericPostContentVariable{
+						{Key: "ref", Value: "$.ref"},
+					},
+				},
+			},
+		},
+	}
+	disabledPipeline := genericPipeline.DeepCopy()
+	disabledPipeline.Name = "disabled"
+	disabledPipeline.Spec.Pipeline.GenericWebhook.Enable = false
+
+	remotePipeline := &v1alpha3.Pipeline{
+		ObjectMeta: v1.ObjectMeta{Name: "remote", Namespace: "devops"},
+		Spec: v1alpha3.PipelineSpec{
+			Type: v1alpha3.NoScmPipelineType,
+			Pipeline: &v1alpha3.NoScmPipeline{
+				Name:          "remote",
+				Parameters:    []v1alpha3.ParameterDefinition{{Name: "branch"}},
+				RemoteTrigger: &v1alpha3.RemoteTrigger{Token: "remote-token"},
+			},
+		},
+	}
+	choicePipeline := &v1alpha3.Pipeline{
+		ObjectMeta: v1.ObjectMeta{Name: "choice", Namespace: "devops"},
+		Spec: v1alpha3.PipelineSpec{
+			Type: v1alpha3.NoScmPipelineType,
+			Pipeline: &v1alpha3.NoScmPipeline{
+				Name: "choice",
+				Parameters: []v1alpha3.ParameterDefinition{
+					{Name: "env", Type: "choice", DefaultValue: "dev\nprod"},
+				},
+				RemoteTrigger: &v1alpha3.RemoteTrigger{Token: "choice-token"},
+			},
+		},
+	}
+
+	tests := []struct {
+		name        string
+		uri         string
+		contentType string
+		body        string
+		wantCode    int
+		assertion   func(t *testing.T, c client.Client, body []byte)
+	}{{
+		name:     "generic webhook without token",
+		uri:      "/webhooks/generic",
+		body:     `{"ref":"refs/heads/master"}`,
+		wantCode: http.StatusNotFound,
+	}, {
+		name:     "generic webhook with an unknown token",
+		uri:      "/webhooks/generic?token=unknown",
+		body:     `{"ref":"refs/heads/master"}`,
+		wantCode: http.StatusNotFound,
+	}, {
+		name:     "generic webhook matches the filter",
+		uri:      "/webhooks/generic?token=generic-token",
+		body:     `{"ref":"refs/heads/master"}`,
+		wantCode: http.StatusOK,
+		assertion: func(t *testing.T, c client.Client, body []byte) {
+			result := &GenericTriggerResponse{}
+			assert.Nil(t, json.Unmarshal(body, result))
+			assert.Equal(t, 1, len(result.Jobs))
+			job := result.Jobs["devops/generic"]
+			if assert.NotNil(t, job) {
+				assert.True(t, job.Triggered)
+				assert.Equal(t, "refs/heads/master", job.RegexpFilterText)
+				assert.Equal(t, map[string]string{"ref": "refs/heads/master"}, job.ResolvedVariables)
+			}
+
+			runs := &v1alpha3.PipelineRunList{}
+			assert.Nil(t, c.List(context.Background(), runs))
+			if assert.Equal(t, 1, len(runs.Items)) {
+				run := runs.Items[0]
+				assert.Equal(t, job.ID, run.Name)
+				assert.Equal(t, "generic-webhook", run.Annotations[triggerAnnotationKey])
+				assert.Equal(t, "Triggered by refs/heads/master", run.Annotations[triggerCauseAnnotationKey])
+				assert.Equal(t, []v1alpha3.Parameter{{Name: "ref", Value: "refs/heads/master"}}, run.Spec.Parameters)
+			}
+		},
+	}, {
+		name:     "generic webhook does not match the filter",
+		uri:      "/webhooks/generic?token=generic-token",
+		body:     `{"ref":"refs/heads/dev"}`,
+		wantCode: http.StatusOK,
+		assertion: func(t *testing.T, c client.Client, body []byte) {
+			result := &GenericTriggerResponse{}
+			assert.Nil(t, json.Unmarshal(body, result))
+			job := result.Jobs["devops/generic"]
+			if assert.NotNil(t, job) {
+				assert.False(t, job.Triggered)
+				assert.Equal(t, "refs/heads/dev", job.RegexpFilterText)
+			}
+
+			runs := &v1alpha3.PipelineRunList{}
+			assert.Nil(t, c.List(context.Background(), runs))
+			assert.Equal(t, 0, len(runs.Items))
+		},
+	}, {
+		name:     "generic webhook with a too large payload",
+		uri:      "/webhooks/generic?token=generic-token",
+		body:     `{"ref":"` + strings.Repeat("a", maxGenericContentLength) + `"}`,
+		wantCode: http.StatusRequestEntityTooLarge,
+	}, {
+		name:        "remote trigger with an invalid token",
+		uri:         "/namespaces/devops/pipelines/remote/remote-trigger",
+		contentType: "application/x-www-form-urlencoded",
+		body:        "token=invalid",
+		wantCode:    http.StatusForbidden,
+	}, {
+		name:        "remote trigger of a Pipeline without the remote trigger",
+		uri:         "/namespaces/devops/pipelines/generic/remote-trigger",
+		contentType: "application/x-www-form-urlencoded",
+		body:        "token=generic-token",
+		wantCode:    http.StatusForbidden,
+	}, {
+		name:        "remote trigger of a non-existing Pipeline",
+		uri:         "/namespaces/devops/pipelines/fake/remote-trigger",
+		contentType: "application/x-www-form-urlencoded",
+		body:        "token=remote-token",
+		wantCode:    http.StatusNotFound,
+	}, {
+		name:        "remote trigger with a valid token",
+		uri:         "/namespaces/devops/pipelines/remote/remote-trigger?cause=test",
+		contentType: "application/x-www-form-urlencoded",
+		body:        "token=remote-token&branch=master&unknown=value",
+		wantCode:    http.StatusCreated,
+		assertion: func(t *testing.T, c client.Client, body []byte) {
+			runs := &v1alpha3.PipelineRunList{}
+			assert.Nil(t, c.List(context.Background(), runs))
+			if assert.Equal(t, 1, len(runs.Items)) {
+				run := runs.Items[0]
+				assert.Equal(t, "remote-trigger", run.Annotations[triggerAnnotationKey])
+				assert.Equal(t, "test", run.Annotations[triggerCauseAnnotationKey])
+				assert.Equal(t, []v1alpha3.Parameter{{Name: "branch", Value: "master"}}, run.Spec.Parameters)
+			}
+		},
+	}, {
+		name:        "remote trigger with an invalid parameter",
+		uri:         "/namespaces/devops/pipelines/choice/remote-trigger",
+		contentType: "application/x-www-form-urlencoded",
+		body:        "token=choice-token&env=test",
+		wantCode:    http.StatusBadRequest,
+		assertion: func(t *testing.T, c client.Client, body []byte) {
+			runs := &v1alpha3.PipelineRunList{}
+			assert.Nil(t, c.List(context.Background(), runs))
+			assert.Equal(t, 0, len(runs.Items))
+		},
+	}, {
+		name:        "remote trigger without the parameter",
+		uri:         "/namespaces/devops/pipelines/choice/remote-trigger",
+		contentType: "application/x-www-form-urlencoded",
+		body:        "token=choice-token",
+		wantCode:    http.StatusCreated,
+		assertion: func(t *testing.T, c client.Client, body []byte) {
+			runs := &v1alpha3.PipelineRunList{}
+			assert.Nil(t, c.List(context.Background(), runs))
+			if assert.Equal(t, 1, len(runs.Items)) {
+				assert.Equal(t, []v1alpha3.Parameter{{Name: "env", Value: "dev"}}, runs.Items[0].Spec.Parameters)
+			}
+		},
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			utilruntime.Must(v1alpha3.AddToScheme(scheme.Scheme))
+			fakeClient := fake.NewClientBuilder().WithScheme(scheme.Scheme).
+				WithObjects(genericPipeline.DeepCopy(), disabledPipeline.DeepCopy(), remotePipeline.DeepCopy(), choicePipeline.DeepCopy()).Build()
+
+			container := restful.NewContainer()
+			ws := apiserverruntime.NewWebService(v1alpha3.GroupVersion)
+			RegisterWebhooks(fakeClient, ws, &token.FakeIssuer{}, core.JenkinsCore{})
+			container.Add(ws)
+
+			var bodyReader io.Reader
+			if tt.body != "" {
+				bodyReader = strings.NewReader(tt.body)
+			}
+			httpRequest, _ := http.NewRequest(http.MethodPost,
+				"http://fake.com/kapis/devops.kubesphere.io/v1alpha3"+tt.uri, bodyReader)
+			contentType := tt.contentType
+			if contentType == "" {
+				contentType = "application/json"
+			}
+			httpRequest.Header.Set("Content-Type", contentType)
+			httpWriter := httptest.NewRecorder()
+			container.Dispatch(httpWriter, httpRequest)
+			assert.Equal(t, tt.wantCode, httpWriter.Code, httpWriter.Body.String())
+			if tt.assertion != nil {
+				tt.assertion(t, fakeClient, httpWriter.Body.Bytes())
+			}
+		})
+	}
+}
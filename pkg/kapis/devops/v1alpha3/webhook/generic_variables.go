@@ -0,0 +1,275 @@
+/*
+Copyright 2022 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhook
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/beevik/etree"
+	"k8s.io/client-go/util/jsonpath"
+	"kubesphere.io/devops/pkg/api/devops/v1alpha3"
+)
+
+// genericRequest is the content of a generic webhook request which the variables come from
+type genericRequest struct {
+	header http.Header
+	query  url.Values
+	body   []byte
+
+	jsonContent interface{}
+	xmlContent  *etree.Document
+}
+
+// defaultGenericCause is the cause of a PipelineRun when there is no cause in the generic webhook
+const defaultGenericCause = "Generic Cause"
+
+var invalidVariableChars = regexp.MustCompile("[^a-zA-Z0-9_]")
+
+// resolveGenericVariables resolves the variables of a generic webhook request.
+// It follows the semantics of the Jenkins generic-webhook-trigger plugin:
+//   - the request variables come from the query parameters, and the header variables come from the headers,
+//     the names of the header variables are in lower case, and the dashes are replaced with underscores
+//   - the post content variables are extracted with JSONPath or XPath, the objects and arrays are flattened
+//     into the variables like: key_field or key_0
+//   - the text which matches the regexp filter of a variable is removed from the value
+func resolveGenericVariables(webhook *v1alpha3.GenericWebhook, req *genericRequest) (variables map[string]string, err error) {
+	variables = map[string]string{}
+	for _, item := range webhook.RequestVariables {
+		if err = setMultipleValues(variables, item.Key, req.query[item.Key], item.RegexpFilter); err != nil {
+			return
+		}
+	}
+	for _, item := range webhook.HeaderVariables {
+		key := strings.ReplaceAll(strings.ToLower(item.Key), "-", "_")
+		if err = setMultipleValues(variables, key, req.header.Values(item.Key), item.RegexpFilter); err != nil {
+			return
+		}
+	}
+
+	for _, item := range webhook.PostContentVariables {
+		var values []interface{}
+		switch item.ExpressionType {
+		case v1alpha3.GenericExpressionTypeXPath:
+			values, err = req.findXPath(item.Value)
+		default:
+			values, err = req.findJSONPath(item.Value)
+		}
+		if err != nil {
+			err = fmt.Errorf("failed to resolve the variable %s, error: %v", item.Key, err)
+			return
+		}
+
+		var value interface{}
+		switch len(values) {
+		case 0:
+			value = item.DefaultValue
+		case 1:
+			value = values[0]
+		default:
+			value = values
+		}
+		if err = setValue(variables, item.Key, value, item.RegexpFilter); err != nil {
+			return
+		}
+		if variables[item.Key] == "" && item.DefaultValue != "" {
+			variables[item.Key] = item.DefaultValue
+		}
+	}
+	return
+}
+
+// setMultipleValues sets the first value as the variable, the values are also available as key_0, key_1 and so on
+// if there are more than one
+func setMultipleValues(variables map[string]string, key string, values []string, regexpFilter string) (err error) {
+	var value string
+	if len(values) > 0 {
+		value = values[0]
+	}
+	if variables[key], err = filterValue(value, regexpFilter); err != nil || len(values) <= 1 {
+		return
+	}
+	for i, item := range values {
+		if variables[fmt.Sprintf("%s_%d", key, i)], err = filterValue(item, regexpFilter); err != nil {
+			return
+		}
+	}
+	return
+}
+
+// setValue sets a value which could be an object or an array, the object and array are flattened
+func setValue(variables map[string]string, key string, value interface{}, regexpFilter string) (err error) {
+	switch val := value.(type) {
+	case map[string]interface{}:
+		if err = setJSONValue(variables, key, value, regexpFilter); err != nil {
+			return
+		}
+		for subKey, subValue := range val {
+			if err = setValue(variables, key+"_"+invalidVariableChars.ReplaceAllString(subKey, "_"), subValue, regexpFilter); err != nil {
+				return
+			}
+		}
+	case []interface{}:
+		if err = setJSONValue(variables, key, value, regexpFilter); err != nil {
+			return
+		}
+		for i, subValue := range val {
+			if err = setValue(variables, fmt.Sprintf("%s_%d", key, i), subValue, regexpFilter); err != nil {
+				return
+			}
+		}
+	case nil:
+		variables[key] = ""
+	default:
+		variables[key], err = filterValue(fmt.Sprint(val), regexpFilter)
+	}
+	return
+}
+
+func setJSONValue(variables map[string]string, key string, value interface{}, regexpFilter string) (err error) {
+	var data []byte
+	if data, err = json.Marshal(value); err == nil {
+		variables[key], err = filterValue(string(data), regexpFilter)
+	}
+	return
+}
+
+// filterValue removes the text which matches the regexp filter
+func filterValue(value, regexpFilter string) (string, error) {
+	if regexpFilter == "" {
+		return value, nil
+	}
+	filter, err := regexp.Compile(regexpFilter)
+	if err != nil {
+		return "", fmt.Errorf("invalid regexp filter %q, error: %v", regexpFilter, err)
+	}
+	return filter.ReplaceAllString(value, ""), nil
+}
+
+// findJSONPath finds the values by a JSONPath expression, such as: $.ref or {.commits[0].id}
+func (r *genericRequest) findJSONPath(expression string) (values []interface{}, err error) {
+	if r.jsonContent == nil {
+		if len(bytes.TrimSpace(r.body)) == 0 {
+			return
+		}
+		decoder := json.NewDecoder(bytes.NewReader(r.body))
+		decoder.UseNumber()
+		if err = decoder.Decode(&r.jsonContent); err != nil {
+			err = fmt.Errorf("the post content is not a valid JSON, error: %v", err)
+			return
+		}
+	}
+
+	if !strings.HasPrefix(expression, "{") {
+		expression = "{" + expression + "}"
+	}
+	parser := jsonpath.New("generic-webhook").AllowMissingKeys(true)
+	if err = parser.Parse(expression); err != nil {
+		return
+	}
+	results, err := parser.FindResults(r.jsonContent)
+	if err != nil {
+		return
+	}
+	for _, result := range results {
+		for _, item := range result {
+			if item.IsValid() && item.CanInterface() {
+				values = append(values, item.Interface())
+			}
+		}
+	}
+	return
+}
+
+// findXPath finds the text of the elements by a XPath expression, such as: /project/name or //name/text().
+// The attributes are supported as well, such as: /project/@id
+func (r *genericRequest) findXPath(expression string) (values []interface{}, err error) {
+	if r.xmlContent == nil {
+		if len(bytes.TrimSpace(r.body)) == 0 {
+			return
+		}
+		doc := etree.NewDocument()
+		if err = doc.ReadFromBytes(r.body); err != nil {
+			err = fmt.Errorf("the post content is not a valid XML, error: %v", err)
+			return
+		}
+		r.xmlContent = doc
+	}
+
+	expression = strings.TrimSuffix(expression, "/text()")
+	var attr string
+	if index := strings.LastIndex(expression, "/@"); index >= 0 {
+		attr = expression[index+2:]
+		expression = expression[:index]
+	}
+
+	var path etree.Path
+	if path, err = etree.CompilePath(expression); err != nil {
+		return
+	}
+	for _, element := range r.xmlContent.FindElementsPath(path) {
+		if attr != "" {
+			if attribute := element.SelectAttr(attr); attribute != nil {
+				values = append(values, attribute.Value)
+			}
+		} else {
+			values = append(values, element.Text())
+		}
+	}
+	return
+}
+
+// renderGenericText replaces the variables like $name or ${name} in the text,
+// the longer names are replaced first in case one name is the prefix of another
+func renderGenericText(text string, variables map[string]string) string {
+	keys := make([]string, 0, len(variables))
+	for key := range variables {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		return len(keys[i]) > len(keys[j])
+	})
+	for _, key := range keys {
+		text = strings.ReplaceAll(text, "${"+key+"}", variables[key])
+		text = strings.ReplaceAll(text, "$"+key, variables[key])
+	}
+	return text
+}
+
+// matchGenericFilter checks if the rendered filter text matches the filter expression.
+// It always matches if there is no filter expression.
+func matchGenericFilter(webhook *v1alpha3.GenericWebhook, variables map[string]string) (text string, matched bool, err error) {
+	text = renderGenericText(webhook.FilterText, variables)
+	if webhook.FilterExpression == "" {
+		matched = true
+		return
+	}
+
+	var filter *regexp.Regexp
+	if filter, err = regexp.Compile(webhook.FilterExpression); err != nil {
+		err = fmt.Errorf("invalid filter expression %q, error: %v", webhook.FilterExpression, err)
+		return
+	}
+	matched = filter.MatchString(text)
+	return
+}
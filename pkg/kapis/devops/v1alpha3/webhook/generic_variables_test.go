@@ -0,0 +1,158 @@
+/*
+Copyright 2022 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhook
+
+import (
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"kubesphere.io/devops/pkg/api/devops/v1alpha3"
+)
+
+func Test_resolveGenericVariables(t *testing.T) {
+	tests := []struct {
+		name    string
+		webhook *v1alpha3.GenericWebhook
+		req     *genericRequest
+		want    map[string]string
+		wantErr bool
+	}{{
+		name: "request and header variables",
+		webhook: &v1alpha3.GenericWebhook{
+			RequestVariables: []v1alpha3.GenericVariable{{Key: "branch", RegexpFilter: "^refs/heads/"}, {Key: "tag"}},
+			HeaderVariables:  []v1alpha3.GenericVariable{{Key: "X-GitHub-Event"}},
+		},
+		req: &genericRequest{
+			query:  url.Values{"branch": []string{"refs/heads/master", "refs/heads/dev"}},
+			header: http.Header{"X-Github-Event": []string{"push"}},
+		},
+		want: map[string]string{
+			"branch":         "master",
+			"branch_0":       "master",
+			"branch_1":       "dev",
+			"tag":            "",
+			"x_github_event": "push",
+		},
+	}, {
+		name: "JSONPath variables",
+		webhook: &v1alpha3.GenericWebhook{
+			PostContentVariables: []v1alpha3.GenericPostContentVariable{
+				{Key: "ref", Value: "$.ref", RegexpFilter: "^refs/heads/"},
+				{Key: "number", Value: "{.number}"},
+				{Key: "repo", Value: "$.repository"},
+				{Key: "ids", Value: "$.commits[*].id"},
+				{Key: "missing", Value: "$.missing", DefaultValue: "none"},
+			},
+		},
+		req: &genericRequest{
+			body: []byte(`{"ref":"refs/heads/master","number":12,"repository":{"full-name":"a/b"},` +
+				`"commits":[{"id":"1"},{"id":"2"}]}`),
+		},
+		want: map[string]string{
+			"ref":            "master",
+			"number":         "12",
+			"repo":           `{"full-name":"a/b"}`,
+			"repo_full_name": "a/b",
+			"ids":            `["1","2"]`,
+			"ids_0":          "1",
+			"ids_1":          "2",
+			"missing":        "none",
+		},
+	}, {
+		name: "XPath variables",
+		webhook: &v1alpha3.GenericWebhook{
+			PostContentVariables: []v1alpha3.GenericPostContentVariable{
+				{Key: "name", Value: "/project/name/text()", ExpressionType: v1alpha3.GenericExpressionTypeXPath},
+				{Key: "id", Value: "/project/@id", ExpressionType: v1alpha3.GenericExpressionTypeXPath},
+				{Key: "missing", Value: "/project/missing", ExpressionType: v1alpha3.GenericExpressionTypeXPath, DefaultValue: "none"},
+			},
+		},
+		req: &genericRequest{
+			body: []byte(`<project id="1"><name>devops</name></project>`),
+		},
+		want: map[string]string{
+			"name":    "devops",
+			"id":      "1",
+			"missing": "none",
+		},
+	}, {
+		name: "invalid JSON content",
+		webhook: &v1alpha3.GenericWebhook{
+			PostContentVariables: []v1alpha3.GenericPostContentVariable{{Key: "ref", Value: "$.ref"}},
+		},
+		req:     &genericRequest{body: []byte(`<xml/>`)},
+		wantErr: true,
+	}, {
+		name: "invalid regexp filter",
+		webhook: &v1alpha3.GenericWebhook{
+			RequestVariables: []v1alpha3.GenericVariable{{Key: "branch", RegexpFilter: "("}},
+		},
+		req:     &genericRequest{query: url.Values{"branch": []string{"master"}}},
+		wantErr: true,
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := resolveGenericVariables(tt.webhook, tt.req)
+			if tt.wantErr {
+				assert.NotNil(t, err)
+				return
+			}
+			assert.Nil(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func Test_matchGenericFilter(t *testing.T) {
+	variables := map[string]string{"ref": "master", "ref_name": "dev"}
+	tests := []struct {
+		name        string
+		webhook     *v1alpha3.GenericWebhook
+		wantText    string
+		wantMatched bool
+		wantErr     bool
+	}{{
+		name:        "no filter expression",
+		webhook:     &v1alpha3.GenericWebhook{FilterText: "$ref"},
+		wantText:    "master",
+		wantMatched: true,
+	}, {
+		name:        "matched",
+		webhook:     &v1alpha3.GenericWebhook{FilterText: "$ref_name/${ref}", FilterExpression: "^dev/master$"},
+		wantText:    "dev/master",
+		wantMatched: true,
+	}, {
+		name:     "not matched",
+		webhook:  &v1alpha3.GenericWebhook{FilterText: "$ref", FilterExpression: "^dev$"},
+		wantText: "master",
+	}, {
+		name:     "invalid filter expression",
+		webhook:  &v1alpha3.GenericWebhook{FilterText: "$ref", FilterExpression: "("},
+		wantText: "master",
+		wantErr:  true,
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			text, matched, err := matchGenericFilter(tt.webhook, variables)
+			assert.Equal(t, tt.wantText, text)
+			assert.Equal(t, tt.wantMatched, matched)
+			assert.Equal(t, tt.wantErr, err != nil)
+		})
+	}
+}
@@ -23,6 +23,7 @@ import (
 
 	"github.com/emicklei/go-restful"
 	"kubesphere.io/devops/pkg/api"
+	"kubesphere.io/devops/pkg/api/devops/v1alpha3"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
@@ -37,4 +38,19 @@ func RegisterWebhooks(genericClient client.Client, ws *restful.WebService, issue
 	scmHandler := NewSCMHandler(genericClient, issue, jenkins)
 	ws.Route(ws.POST("/webhooks/scm").
 		To(scmHandler.scmWebhook))
+
+	triggerHandler := NewTriggerHandler(genericClient)
+	ws.Route(ws.POST("/webhooks/generic").
+		To(triggerHandler.genericWebhook).
+		Doc("Generic webhook for triggering the Pipelines which have the same token").
+		Param(ws.QueryParameter("token", "the token of the generic webhook, it could be in the header as well")).
+		Returns(http.StatusOK, api.StatusOK, GenericTriggerResponse{}))
+	ws.Route(ws.POST("/namespaces/{namespace}/pipelines/{pipeline}/remote-trigger").
+		To(triggerHandler.remoteTrigger).
+		Doc("Trigger a Pipeline remotely with the token of the remote trigger").
+		Param(ws.PathParameter("namespace", "the namespace of the Pipeline")).
+		Param(ws.PathParameter("pipeline", "the name of the Pipeline")).
+		Param(ws.FormParameter("token", "the token of the remote trigger")).
+		Param(ws.FormParameter("cause", "the cause of the PipelineRun")).
+		Returns(http.StatusCreated, api.StatusOK, v1alpha3.PipelineRun{}))
 }
@@ -18,6 +18,7 @@ package webhook
 
 import (
 	"context"
+	"encoding/base64"
 	"errors"
 	"fmt"
 	"github.com/jenkins-zh/jenkins-client/pkg/core"
@@ -34,6 +35,7 @@ import (
 
 	"github.com/emicklei/go-restful"
 	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
 	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"kubesphere.io/devops/pkg/api/devops/v1alpha3"
@@ -190,6 +192,54 @@ func TestSCMWebhook(t *testing.T) {
 		scmAnnotationKey:    "https://gitlab.com/linuxsuren/test",
 	})
 
+	sshAddressPipeline := defaultPipeline.DeepCopy()
+	sshAddressPipeline.Annotations[scmAnnotationKey] = "ssh://git@GitLab.com:22/LinuxSuRen/test.git"
+
+	otherRepoPipeline := defaultPipeline.DeepCopy()
+	otherRepoPipeline.Annotations[scmAnnotationKey] = "https://gitlab.example.com/linuxsuren/test"
+
+	giteePipeline := defaultPipeline.DeepCopy()
+	giteePipeline.Annotations[scmAnnotationKey] = "https://gitee.com/linuxsuren/test"
+
+	azurePipeline := defaultPipeline.DeepCopy()
+	azurePipeline.Annotations[scmAnnotationKey] = "https://dev.azure.com/linuxsuren/demo/_git/test"
+
+	multiBranchPipeline := defaultPipeline.DeepCopy()
+	multiBranchPipeline.Spec = v1alpha3.PipelineSpec{
+		Type: v1alpha3.MultiBranchPipelineType,
+		MultiBranchPipeline: &v1alpha3.MultiBranchPipeline{
+			Name:       "fake",
+			SourceType: v1alpha3.SourceTypeGit,
+			GitSource:  &v1alpha3.GitSource{Url: "https://gitlab.com/linuxsuren/test"},
+		},
+	}
+	gitRepo := &v1alpha3.GitRepository{}
+	gitRepo.SetName("repo")
+	gitRepo.SetNamespace("default")
+	gitRepo.Spec.URL = "https://gitlab.com/linuxsuren/test.git"
+	gitRepo.Spec.Webhooks = []corev1.LocalObjectReference{{Name: "missing"}, {Name: "hook"}}
+	hook := &v1alpha3.Webhook{}
+	hook.SetName("hook")
+	hook.SetNamespace("default")
+	hook.Spec.Secret = &corev1.SecretReference{Name: "hook-secret"}
+	hookSecret := &corev1.Secret{}
+	hookSecret.SetName("hook-secret")
+	hookSecret.SetNamespace("default")
+	hookSecret.Type = v1alpha3.SecretTypeSecretText
+	hookSecret.Data = map[string][]byte{v1alpha3.SecretTextSecretKey: []byte("secret")}
+	deletedBranchBody := strings.Replace(gitlabWebhookBody, `"after": "bd4f171cec5c6f9b8b184107ce318bf9a54dce26"`,
+		`"after": "0000000000000000000000000000000000000000"`, 1)
+	azureRepo := gitRepo.DeepCopy()
+	azureRepo.SetName("azure")
+	azureRepo.Spec.URL = "https://dev.azure.com/linuxsuren/demo/_git/test"
+	azureAuth := "Basic " + base64.StdEncoding.EncodeToString([]byte("user:secret"))
+
+	isBranchRemoved := func(t *testing.T, c client.Client) bool {
+		pipeline := &v1alpha3.Pipeline{}
+		assert.Nil(t, c.Get(context.Background(), client.ObjectKey{Namespace: "default", Name: "fake"}, pipeline))
+		return pipeline.Annotations[v1alpha3.PipelineRemovedBranchesAnnoKey] != ""
+	}
+
 	type args struct {
 		method     string
 		uri        string
@@ -200,6 +250,7 @@ func TestSCMWebhook(t *testing.T) {
 	tests := []struct {
 		name      string
 		args      args
+		wantCode  int
 		assertion func(t *testing.T, c client.Client, body string)
 	}{{
 		name: "unknown SCM webhook",
@@ -239,6 +290,169 @@ func TestSCMWebhook(t *testing.T) {
 		assertion: func(t *testing.T, c client.Client, body string) {
 			assert.Equal(t, "ok", body)
 		},
+	}, {
+		name: "gitlab webhook, the branch was deleted",
+		args: args{
+			method:     http.MethodPost,
+			uri:        "/webhooks/scm",
+			initObject: []runtime.Object{defaultPipeline.DeepCopy()},
+			bodyJSON: strings.Replace(gitlabWebhookBody, `"after": "bd4f171cec5c6f9b8b184107ce318bf9a54dce26"`,
+				`"after": "0000000000000000000000000000000000000000"`, 1),
+			header: map[string]string{
+				"X-Gitlab-Event": "Push Hook",
+			},
+		},
+		assertion: func(t *testing.T, c client.Client, body string) {
+			assert.Equal(t, "no pipeline matched", body)
+			pipelineruns := &v1alpha3.PipelineRunList{}
+			assert.Nil(t, c.List(context.Background(), pipelineruns))
+			assert.Equal(t, 0, len(pipelineruns.Items))
+		},
+	}, {
+		name: "gitlab webhook, pipeline with a different address format",
+		args: args{
+			method:     http.MethodPost,
+			uri:        "/webhooks/scm",
+			initObject: []runtime.Object{sshAddressPipeline},
+			bodyJSON:   gitlabWebhookBody,
+			header: map[string]string{
+				"X-Gitlab-Event": "Push Hook",
+			},
+		},
+		assertion: func(t *testing.T, c client.Client, body string) {
+			assert.Equal(t, "ok", body)
+			pipelineruns := &v1alpha3.PipelineRunList{}
+			assert.Nil(t, c.List(context.Background(), pipelineruns))
+			assert.Equal(t, 1, len(pipelineruns.Items))
+		},
+	}, {
+		name: "gitlab webhook, pipeline of another git server",
+		args: args{
+			method:     http.MethodPost,
+			uri:        "/webhooks/scm",
+			initObject: []runtime.Object{otherRepoPipeline},
+			bodyJSON:   gitlabWebhookBody,
+			header: map[string]string{
+				"X-Gitlab-Event": "Push Hook",
+			},
+		},
+		assertion: func(t *testing.T, c client.Client, body string) {
+			assert.Equal(t, "no pipeline matched", body)
+		},
+	}, {
+		name: "gitee webhook",
+		args: args{
+			method:     http.MethodPost,
+			uri:        "/webhooks/scm",
+			initObject: []runtime.Object{giteePipeline},
+			bodyJSON:   giteeWebhookBody,
+			header: map[string]string{
+				"X-Gitee-Event": "Push Hook",
+			},
+		},
+		assertion: func(t *testing.T, c client.Client, body string) {
+			assert.Equal(t, "ok", body)
+			pipelineruns := &v1alpha3.PipelineRunList{}
+			assert.Nil(t, c.List(context.Background(), pipelineruns))
+			assert.Equal(t, 1, len(pipelineruns.Items))
+		},
+	}, {
+		name: "azure devops webhook",
+		args: args{
+			method:     http.MethodPost,
+			uri:        "/webhooks/scm",
+			initObject: []runtime.Object{azurePipeline.DeepCopy(), azureRepo.DeepCopy(), hook.DeepCopy(), hookSecret.DeepCopy()},
+			bodyJSON:   azureDevOpsWebhookBody,
+			header: map[string]string{
+				"User-Agent":    "VSServices/16.205.32214.3",
+				"Authorization": azureAuth,
+			},
+		},
+		assertion: func(t *testing.T, c client.Client, body string) {
+			assert.Equal(t, "ok", body)
+			pipelineruns := &v1alpha3.PipelineRunList{}
+			assert.Nil(t, c.List(context.Background(), pipelineruns))
+			assert.Equal(t, 1, len(pipelineruns.Items))
+		},
+	}, {
+		name: "azure devops webhook without the basic authentication",
+		args: args{
+			method:     http.MethodPost,
+			uri:        "/webhooks/scm",
+			initObject: []runtime.Object{azurePipeline.DeepCopy(), azureRepo.DeepCopy(), hook.DeepCopy(), hookSecret.DeepCopy()},
+			bodyJSON:   azureDevOpsWebhookBody,
+			header: map[string]string{
+				"User-Agent": "VSServices/16.205.32214.3",
+			},
+		},
+		wantCode: http.StatusUnauthorized,
+		assertion: func(t *testing.T, c client.Client, body string) {
+			pipelineruns := &v1alpha3.PipelineRunList{}
+			assert.Nil(t, c.List(context.Background(), pipelineruns))
+			assert.Equal(t, 0, len(pipelineruns.Items))
+		},
+	}, {
+		name: "azure devops webhook without the secret of a webhook",
+		args: args{
+			method:     http.MethodPost,
+			uri:        "/webhooks/scm",
+			initObject: []runtime.Object{azurePipeline.DeepCopy()},
+			bodyJSON:   azureDevOpsWebhookBody,
+			header: map[string]string{
+				"User-Agent":    "VSServices/16.205.32214.3",
+				"Authorization": azureAuth,
+			},
+		},
+		wantCode: http.StatusUnauthorized,
+	}, {
+		name: "gitlab webhook, the unsigned delivery cannot remove a branch",
+		args: args{
+			method:     http.MethodPost,
+			uri:        "/webhooks/scm",
+			initObject: []runtime.Object{multiBranchPipeline.DeepCopy()},
+			bodyJSON:   deletedBranchBody,
+			header: map[string]string{
+				"X-Gitlab-Event": "Push Hook",
+			},
+		},
+		wantCode: http.StatusUnauthorized,
+		assertion: func(t *testing.T, c client.Client, body string) {
+			assert.False(t, isBranchRemoved(t, c))
+		},
+	}, {
+		name: "gitlab webhook, the signed delivery removes a branch",
+		args: args{
+			method: http.MethodPost,
+			uri:    "/webhooks/scm",
+			initObject: []runtime.Object{multiBranchPipeline.DeepCopy(), gitRepo.DeepCopy(), hook.DeepCopy(),
+				hookSecret.DeepCopy()},
+			bodyJSON: deletedBranchBody,
+			header: map[string]string{
+				"X-Gitlab-Event": "Push Hook",
+				"X-Gitlab-Token": "secret",
+			},
+		},
+		assertion: func(t *testing.T, c client.Client, body string) {
+			assert.Equal(t, "ok", body)
+			assert.True(t, isBranchRemoved(t, c))
+		},
+	}, {
+		name: "gitlab webhook, the delivery with an invalid token",
+		args: args{
+			method: http.MethodPost,
+			uri:    "/webhooks/scm",
+			initObject: []runtime.Object{multiBranchPipeline.DeepCopy(), gitRepo.DeepCopy(), hook.DeepCopy(),
+				hookSecret.DeepCopy()},
+			bodyJSON: gitlabWebhookBody,
+			header: map[string]string{
+				"X-Gitlab-Event": "Push Hook",
+				"X-Gitlab-Token": "invalid",
+			},
+		},
+		wantCode: http.StatusUnauthorized,
+		assertion: func(t *testing.T, c client.Client, body string) {
+			assert.False(t, isBranchRemoved(t, c))
+		},
 	}}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -263,7 +477,11 @@ func TestSCMWebhook(t *testing.T) {
 			}
 			httpWriter := httptest.NewRecorder()
 			container.Dispatch(httpWriter, httpRequest)
-			assert.Equal(t, 200, httpWriter.Code)
+			wantCode := tt.wantCode
+			if wantCode == 0 {
+				wantCode = http.StatusOK
+			}
+			assert.Equal(t, wantCode, httpWriter.Code)
 			if tt.assertion != nil {
 				body := httpWriter.Body
 				var bodyResponse string
@@ -393,3 +611,69 @@ func Test(t *testing.T) {
 	})
 	fmt.Println(err)
 }
+
+const giteeWebhookBody = `{
+  "ref": "refs/heads/master",
+  "before": "8f4b347e7d6b7647b51647dcd07ddafd4bded19f",
+  "after": "bd4f171cec5c6f9b8b184107ce318bf9a54dce26",
+  "created": false,
+  "deleted": false,
+  "commits": [{
+    "id": "bd4f171cec5c6f9b8b184107ce318bf9a54dce26",
+    "message": "Update README.md",
+    "timestamp": "2022-05-31T09:39:17+08:00",
+    "url": "https://gitee.com/linuxsuren/test/commit/bd4f171cec5c6f9b8b184107ce318bf9a54dce26",
+    "author": {"name": "LinuxSuRen", "email": "linuxsuren@gitee.com", "username": "linuxsuren"},
+    "committer": {"name": "LinuxSuRen", "email": "linuxsuren@gitee.com", "username": "linuxsuren"}
+  }],
+  "head_commit": {
+    "id": "bd4f171cec5c6f9b8b184107ce318bf9a54dce26",
+    "message": "Update README.md",
+    "timestamp": "2022-05-31T09:39:17+08:00",
+    "url": "https://gitee.com/linuxsuren/test/commit/bd4f171cec5c6f9b8b184107ce318bf9a54dce26",
+    "author": {"name": "LinuxSuRen", "email": "linuxsuren@gitee.com", "username": "linuxsuren"},
+    "committer": {"name": "LinuxSuRen", "email": "linuxsuren@gitee.com", "username": "linuxsuren"}
+  },
+  "repository": {
+    "id": 22075829,
+    "name": "test",
+    "path": "test",
+    "full_name": "linuxsuren/test",
+    "namespace": "linuxsuren",
+    "private": false,
+    "default_branch": "master",
+    "html_url": "https://gitee.com/linuxsuren/test",
+    "clone_url": "https://gitee.com/linuxsuren/test.git",
+    "ssh_url": "git@gitee.com:linuxsuren/test.git",
+    "owner": {"id": 1, "login": "linuxsuren", "name": "LinuxSuRen"}
+  },
+  "sender": {"id": 1, "login": "linuxsuren", "name": "LinuxSuRen"}
+}`
+
+const azureDevOpsWebhookBody = `{
+  "id": "03c164c2-8912-4d5e-8009-3707d5f83734",
+  "eventType": "git.push",
+  "resource": {
+    "commits": [{
+      "commitId": "bd4f171cec5c6f9b8b184107ce318bf9a54dce26",
+      "author": {"name": "LinuxSuRen", "email": "linuxsuren@example.com", "date": "2022-05-31T01:39:17Z"},
+      "committer": {"name": "LinuxSuRen", "email": "linuxsuren@example.com", "date": "2022-05-31T01:39:17Z"},
+      "comment": "Update README.md"
+    }],
+    "refUpdates": [{
+      "name": "refs/heads/master",
+      "oldObjectId": "8f4b347e7d6b7647b51647dcd07ddafd4bded19f",
+      "newObjectId": "bd4f171cec5c6f9b8b184107ce318bf9a54dce26"
+    }],
+    "repository": {
+      "id": "278d5cd2-584d-4b63-824a-2ba458937249",
+      "name": "test",
+      "project": {"id": "6ce954b1-ce1f-45d1-b94d-e6bf2464ba2c", "name": "demo"},
+      "defaultBranch": "refs/heads/master",
+      "remoteUrl": "https://linuxsuren@dev.azure.com/linuxsuren/demo/_git/test",
+      "sshUrl": "git@ssh.dev.azure.com:v3/linuxsuren/demo/test",
+      "webUrl": "https://dev.azure.com/linuxsuren/demo/_git/test"
+    },
+    "pushedBy": {"id": "00ca946b-2fe9-4f2a-ae2f-40d5c48001bc", "displayName": "LinuxSuRen", "uniqueName": "linuxsuren@example.com"}
+  }
+}`
@@ -17,8 +17,10 @@ limitations under the License.
 package webhook
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"github.com/emicklei/go-restful"
 	"github.com/jenkins-x/go-scm/scm"
@@ -27,11 +29,21 @@ import (
 	"github.com/jenkins-x/go-scm/scm/driver/gitlab"
 	"github.com/jenkins-zh/jenkins-client/pkg/core"
 	"github.com/jenkins-zh/jenkins-client/pkg/job"
+	"io"
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apiserver/pkg/authentication/user"
+	"k8s.io/client-go/util/retry"
 	"kubesphere.io/devops/pkg/api/devops/v1alpha3"
 	"kubesphere.io/devops/pkg/client/devops"
+	"kubesphere.io/devops/pkg/client/git"
+	"kubesphere.io/devops/pkg/client/git/azure"
+	"kubesphere.io/devops/pkg/client/git/gitee"
+	"kubesphere.io/devops/pkg/indexers"
 	"kubesphere.io/devops/pkg/jwt/token"
 	"kubesphere.io/devops/pkg/kapis/devops/v1alpha3/pipelinerun"
+	modelpipeline "kubesphere.io/devops/pkg/models/pipeline"
 	"net/http"
 	"regexp"
 	"sigs.k8s.io/controller-runtime/pkg/client"
@@ -41,10 +53,18 @@ import (
 
 // tokenExpireIn indicates that the temporary token issued by controller will be expired in some time.
 const tokenExpireIn time.Duration = 5 * time.Minute
-const scmAnnotationKey = "scm.devops.kubesphere.io"
+const scmAnnotationKey = v1alpha3.PipelineSCMAnnoKey
 const scmRefAnnotationKey = "scm.devops.kubesphere.io/ref"
 const triggerAnnotationKey = "devops.kubesphere.io/trigger"
 
+// maxSCMPayloadSize is the maximum size of the payload of a SCM webhook, it is the same as the limit of go-scm
+const maxSCMPayloadSize = 10000000
+
+// errUnverifiedDelivery means that a delivery which removes branches or synchronizes pipeline-as-code Pipelines
+// is not signed with the secret of any webhooks of the repository
+var errUnverifiedDelivery = errors.New("the delivery is not signed with the secret of a webhook of the repository, " +
+	"it cannot remove branches or synchronize pipeline-as-code Pipelines")
+
 // SCMHandler handles requests from webhooks.
 type SCMHandler struct {
 	client.Client
@@ -73,9 +93,23 @@ func getSCMClient(request *http.Request) *scm.Client {
 	if strings.HasPrefix(request.Header.Get("User-Agent"), "Bitbucket-Webhooks") {
 		return bitbucket.NewDefault()
 	}
+
+	if request.Header.Get("X-Gitee-Event") != "" {
+		return gitee.NewDefault()
+	}
+
+	if isAzureDevOps(request) {
+		return &scm.Client{Webhooks: azure.NewWebHookService()}
+	}
 	return nil
 }
 
+// isAzureDevOps checks if the request comes from the service hooks of Azure DevOps,
+// they have no special header except the user agent
+func isAzureDevOps(request *http.Request) bool {
+	return strings.HasPrefix(request.Header.Get("User-Agent"), "VSServices")
+}
+
 func (h *SCMHandler) scmWebhook(request *restful.Request, response *restful.Response) {
 	scmClient := getSCMClient(request.Request)
 	if scmClient == nil {
@@ -83,41 +117,99 @@ func (h *SCMHandler) scmWebhook(request *restful.Request, response *restful.Resp
 		return
 	}
 
-	webhook, err := scmClient.Webhooks.Parse(request.Request, func(webhook scm.Webhook) (string, error) {
-		return "", nil
-	})
-	if err != nil {
+	ctx := context.TODO()
+	webhook, verified, err := h.parseWebhook(ctx, scmClient, request.Request)
+	if errors.Is(err, scm.ErrSignatureInvalid) {
+		_ = response.WriteError(http.StatusUnauthorized, err)
+		return
+	} else if err != nil {
 		_, _ = response.Write([]byte(err.Error()))
 		return
+	} else if webhook == nil {
+		_, _ = response.Write([]byte("unknown event"))
+		return
+	} else if !verified && isAzureDevOps(request.Request) {
+		// the service hooks of Azure DevOps are not signed, the basic authentication with the secret is required
+		_ = response.WriteErrorString(http.StatusUnauthorized,
+			"the service hook of Azure DevOps requires the basic authentication with the secret of a webhook")
+		return
 	}
 
-	ctx := context.TODO()
 	found := false
-	if webhook.Kind() == scm.WebhookKindPush {
-		repo := webhook.Repository()
-		pushHook := webhook.(*scm.PushHook)
-
-		pipelineList := &v1alpha3.PipelineList{}
-		if err = h.List(ctx, pipelineList); err == nil {
-			for i := range pipelineList.Items {
-				pipeline := pipelineList.Items[i]
-				if !branchMatch(pipeline, pushHook.Ref) {
+	repo := webhook.Repository()
+	switch hook := webhook.(type) {
+	case *scm.PushHook:
+		var pipelines []v1alpha3.Pipeline
+		if pipelines, err = h.findPipelines(ctx, repo.Link, repo.Clone, repo.CloneSSH); err == nil {
+			for i := range pipelines {
+				pipeline := pipelines[i]
+				if !branchMatch(pipeline, hook.Ref) {
+					continue
+				}
+				deleted := isDeletedPush(hook)
+				if !pipeline.IsMultiBranch() && deleted {
+					// there is nothing to build for a deleted branch
 					continue
 				}
 				found = true
+				if deleted && !verified {
+					err = errUnverifiedDelivery
+					break
+				}
 
-				gitURL := pipeline.GetAnnotations()[scmAnnotationKey]
 				if pipeline.IsMultiBranch() {
-					gitURL = pipeline.Spec.MultiBranchPipeline.GetGitURL()
-					if gitURL != "" && gitRepoMatch(gitURL, repo.Link, repo.Clone, repo.CloneSSH) {
+					if err = h.setBranchRemoved(ctx, pipeline, getRefName(hook.Ref), deleted); err == nil {
 						err = scanJenkinsMultiBranchPipeline(pipeline, h.jenkins, h.issue)
 					}
-				} else if gitURL != "" {
-					if gitRepoMatch(gitURL, repo.Link, repo.Clone, repo.CloneSSH) {
-						err = h.createPipelineRun(pipeline, pushHook)
-					} else {
-						err = fmt.Errorf("expect URL: %s, got: %v", gitURL, []string{repo.Link, repo.Clone, repo.CloneSSH})
-					}
+				} else {
+					err = h.createPipelineRun(pipeline, hook)
+				}
+			}
+		}
+		if err == nil && !isDeletedPush(hook) && !strings.HasPrefix(hook.Ref, "refs/tags/") {
+			var synced bool
+			if synced, err = h.requestPipelineAsCodeSync(ctx, getRefName(hook.Ref), hook.After, verified,
+				repo.Link, repo.Clone, repo.CloneSSH); synced {
+				found = true
+			}
+		}
+	case *scm.BranchHook:
+		// only the branch deletion and creation matter, the push events trigger the builds
+		if hook.Action != scm.ActionDelete && hook.Action != scm.ActionCreate {
+			break
+		}
+		var pipelines []v1alpha3.Pipeline
+		if pipelines, err = h.findPipelines(ctx, repo.Link, repo.Clone, repo.CloneSSH); err == nil {
+			for i := range pipelines {
+				pipeline := pipelines[i]
+				if !pipeline.IsMultiBranch() {
+					continue
+				}
+				found = true
+				if hook.Action == scm.ActionDelete && !verified {
+					err = errUnverifiedDelivery
+					break
+				}
+				err = h.setBranchRemoved(ctx, pipeline, getRefName(hook.Ref.Name), hook.Action == scm.ActionDelete)
+			}
+		}
+	case *scm.PullRequestHook:
+		// only the multi-branch Pipelines discover the pull requests
+		var pipelines []v1alpha3.Pipeline
+		if pipelines, err = h.findPipelines(ctx, repo.Link, repo.Clone, repo.CloneSSH); err == nil {
+			for i := range pipelines {
+				pipeline := pipelines[i]
+				if !pipeline.IsMultiBranch() {
+					continue
+				}
+				found = true
+				removed := hook.Action == scm.ActionClose || hook.Action == scm.ActionMerge
+				if removed && !verified {
+					err = errUnverifiedDelivery
+					break
+				}
+				if err = h.setBranchRemoved(ctx, pipeline, getPullRequestBranch(pipeline, hook.PullRequest.Number), removed); err == nil {
+					err = scanJenkinsMultiBranchPipeline(pipeline, h.jenkins, h.issue)
 				}
 			}
 		}
@@ -126,6 +218,8 @@ func (h *SCMHandler) scmWebhook(request *restful.Request, response *restful.Resp
 	if !found {
 		_ = response.WriteErrorString(http.StatusOK, "no pipeline matched")
 		return
+	} else if errors.Is(err, errUnverifiedDelivery) {
+		_ = response.WriteError(http.StatusUnauthorized, err)
 	} else if err != nil {
 		_ = response.WriteError(http.StatusBadRequest, err)
 	} else {
@@ -133,6 +227,82 @@ func (h *SCMHandler) scmWebhook(request *restful.Request, response *restful.Resp
 	}
 }
 
+// parseWebhook parses the delivery and verifies it with the secrets of the webhooks of the GitRepositories.
+// The delivery is not verified if there are no secrets of the repository, it fails if none of the secrets match.
+func (h *SCMHandler) parseWebhook(ctx context.Context, scmClient *scm.Client, req *http.Request) (
+	webhook scm.Webhook, verified bool, err error) {
+	var payload []byte
+	if payload, err = io.ReadAll(io.LimitReader(req.Body, maxSCMPayloadSize)); err != nil {
+		return
+	}
+	parse := func(secret string) (scm.Webhook, error) {
+		req.Body = io.NopCloser(bytes.NewReader(payload))
+		return scmClient.Webhooks.Parse(req, func(scm.Webhook) (string, error) {
+			return secret, nil
+		})
+	}
+
+	if webhook, err = parse(""); err != nil || webhook == nil {
+		return
+	}
+	repo := webhook.Repository()
+	var secrets []string
+	if secrets, err = h.getWebhookSecrets(ctx, repo.Link, repo.Clone, repo.CloneSSH); err != nil || len(secrets) == 0 {
+		return
+	}
+	for _, secret := range secrets {
+		if _, err = parse(secret); err == nil {
+			verified = true
+			return
+		}
+	}
+	return
+}
+
+// getWebhookSecrets returns the secrets of the webhooks of the GitRepositories which have the addresses
+func (h *SCMHandler) getWebhookSecrets(ctx context.Context, repoURLs ...string) (secrets []string, err error) {
+	var repos []v1alpha3.GitRepository
+	if repos, err = h.findGitRepositories(ctx, repoURLs...); err != nil {
+		return
+	}
+
+	found := map[string]bool{}
+	for i := range repos {
+		repo := &repos[i]
+		for _, ref := range repo.Spec.Webhooks {
+			webhook := &v1alpha3.Webhook{}
+			if err = h.Get(ctx, types.NamespacedName{Namespace: repo.Namespace, Name: ref.Name}, webhook); err != nil {
+				if apierrors.IsNotFound(err) {
+					err = nil
+					continue
+				}
+				return
+			}
+			if webhook.Spec.Secret == nil || webhook.Spec.Secret.Name == "" {
+				continue
+			}
+
+			secretRef := types.NamespacedName{Namespace: webhook.Spec.Secret.Namespace, Name: webhook.Spec.Secret.Name}
+			if secretRef.Namespace == "" {
+				secretRef.Namespace = webhook.Namespace
+			}
+			secret := &v1.Secret{}
+			if err = h.Get(ctx, secretRef, secret); err != nil {
+				if apierrors.IsNotFound(err) {
+					err = nil
+					continue
+				}
+				return
+			}
+			if token, _, tokenErr := git.GetTokenFromSecret(secret); tokenErr == nil && token != "" && !found[token] {
+				found[token] = true
+				secrets = append(secrets, token)
+			}
+		}
+	}
+	return
+}
+
 func (h *SCMHandler) createPipelineRun(pipeline v1alpha3.Pipeline, hook *scm.PushHook) (err error) {
 	branch := strings.TrimPrefix(hook.Ref, "refs/heads/")
 
@@ -140,11 +310,118 @@ func (h *SCMHandler) createPipelineRun(pipeline v1alpha3.Pipeline, hook *scm.Pus
 	if scmObj, err = pipelinerun.CreateScm(&pipeline.Spec, branch); err == nil {
 		run := pipelinerun.CreatePipelineRun(&pipeline, &devops.RunPayload{}, scmObj)
 		run.Annotations[triggerAnnotationKey] = "webhook"
+		if hook.After != "" {
+			run.Annotations[v1alpha3.PipelineRunCommitAnnoKey] = hook.After
+		}
 		err = h.Create(context.Background(), run)
 	}
 	return
 }
 
+// isDeletedPush checks if the push event is about a deleted branch or tag
+func isDeletedPush(hook *scm.PushHook) bool {
+	return hook.Deleted || (hook.After != "" && strings.Trim(hook.After, "0") == "")
+}
+
+// getRefName returns the branch or tag name of a git reference
+func getRefName(ref string) string {
+	return strings.TrimPrefix(strings.TrimPrefix(ref, "refs/heads/"), "refs/tags/")
+}
+
+// getPullRequestBranch returns the branch name of a pull request in Jenkins, it is like PR-1,
+// or MR-1 for the merge requests of GitLab
+func getPullRequestBranch(pipeline v1alpha3.Pipeline, number int) string {
+	prefix := "PR"
+	if pipeline.Spec.MultiBranchPipeline != nil && pipeline.Spec.MultiBranchPipeline.SourceType == v1alpha3.SourceTypeGitlab {
+		prefix = "MR"
+	}
+	return fmt.Sprintf("%s-%d", prefix, number)
+}
+
+// setBranchRemoved marks or unmarks a branch of a multi-branch Pipeline as removed.
+// The branch is removed from the Jenkins branches annotation as well, then the PipelineRuns of it
+// could be cleaned up without waiting for the Jenkins scan.
+func (h *SCMHandler) setBranchRemoved(ctx context.Context, pipeline v1alpha3.Pipeline, branch string, removed bool) error {
+	return retry.RetryOnConflict(retry.DefaultRetry, func() (err error) {
+		latestPipeline := &v1alpha3.Pipeline{}
+		if err = h.Get(ctx, types.NamespacedName{Namespace: pipeline.Namespace, Name: pipeline.Name}, latestPipeline); err != nil {
+			return
+		}
+		if !latestPipeline.SetRemovedBranchState(branch, removed) {
+			return
+		}
+
+		if branchesJSON, ok := latestPipeline.Annotations[v1alpha3.PipelineJenkinsBranchesAnnoKey]; ok && removed {
+			var branches modelpipeline.BranchSlice
+			if branches, err = modelpipeline.GetBranchSlice(branchesJSON); err == nil {
+				existingBranches := make(modelpipeline.BranchSlice, 0, len(branches))
+				for i := range branches {
+					if !branches[i].MatchName(branch) {
+						existingBranches = append(existingBranches, branches[i])
+					}
+				}
+				if branchesData, marshalErr := json.Marshal(existingBranches); marshalErr == nil {
+					latestPipeline.Annotations[v1alpha3.PipelineJenkinsBranchesAnnoKey] = string(branchesData)
+				}
+			}
+		}
+		return h.Update(ctx, latestPipeline)
+	})
+}
+
+// requestPipelineAsCodeSync requests to synchronize the pipeline-as-code Pipelines whose file is on the pushed branch.
+// It returns true if there are any such Pipelines. The unverified deliveries cannot synchronize them.
+func (h *SCMHandler) requestPipelineAsCodeSync(ctx context.Context, branch, revision string, verified bool, repoURLs ...string) (
+	found bool, err error) {
+	var repos []v1alpha3.GitRepository
+	if repos, err = h.findGitRepositories(ctx, repoURLs...); err != nil || len(repos) == 0 {
+		return
+	}
+	if revision == "" {
+		// make sure the request is different from the previous one
+		revision = time.Now().Format(time.RFC3339)
+	}
+
+	for i := range repos {
+		repo := &repos[i]
+		pipelineList := &v1alpha3.PipelineList{}
+		if err = h.List(ctx, pipelineList, client.InNamespace(repo.Namespace)); err != nil {
+			return
+		}
+		for j := range pipelineList.Items {
+			pipeline := &pipelineList.Items[j]
+			if pipeline.Annotations[v1alpha3.PipelineAsCodeGitRepoAnnoKey] != repo.Name ||
+				pipeline.GetPipelineAsCodeBranch(repo.Status.DefaultBranch) != branch {
+				continue
+			}
+			found = true
+			if !verified {
+				err = errUnverifiedDelivery
+				return
+			}
+			if err = h.setPipelineAsCodeSyncRequest(ctx, types.NamespacedName{Namespace: pipeline.Namespace, Name: pipeline.Name},
+				revision); err != nil {
+				return
+			}
+		}
+	}
+	return
+}
+
+func (h *SCMHandler) setPipelineAsCodeSyncRequest(ctx context.Context, key types.NamespacedName, revision string) error {
+	return retry.RetryOnConflict(retry.DefaultRetry, func() (err error) {
+		pipeline := &v1alpha3.Pipeline{}
+		if err = h.Get(ctx, key, pipeline); err != nil {
+			return
+		}
+		if pipeline.Annotations[v1alpha3.PipelineAsCodeRequestSyncAnnoKey] == revision {
+			return
+		}
+		pipeline.Annotations[v1alpha3.PipelineAsCodeRequestSyncAnnoKey] = revision
+		return h.Update(ctx, pipeline)
+	})
+}
+
 func scanJenkinsMultiBranchPipeline(pipeline v1alpha3.Pipeline, jenkins core.JenkinsCore, issue token.Issuer) (err error) {
 	var accessToken string
 	accessToken, err = issue.IssueTo(&user.DefaultInfo{Name: "admin"}, token.AccessToken, tokenExpireIn)
@@ -184,12 +461,55 @@ func branchMatch(pipeline v1alpha3.Pipeline, branch string) (ok bool) {
 	return
 }
 
-// gitRepoMatch if the source matches target
-func gitRepoMatch(source string, targets ...string) (ok bool) {
-	for _, target := range targets {
-		if target == source {
-			ok = true
-			break
+// findPipelines finds the Pipelines of a repository by the indexer of the normalized repository address
+func (h *SCMHandler) findPipelines(ctx context.Context, repoURLs ...string) (pipelines []v1alpha3.Pipeline, err error) {
+	found := map[types.NamespacedName]bool{}
+	for _, repoURL := range repoURLs {
+		normalizedURL := git.NormalizeURL(repoURL)
+		if normalizedURL == "" {
+			continue
+		}
+
+		pipelineList := &v1alpha3.PipelineList{}
+		if err = h.List(ctx, pipelineList, client.MatchingFields{v1alpha3.PipelineRepoURLIndexerName: normalizedURL}); err != nil {
+			return
+		}
+		for i := range pipelineList.Items {
+			pipeline := pipelineList.Items[i]
+			key := types.NamespacedName{Namespace: pipeline.Namespace, Name: pipeline.Name}
+			// double check the address in case the client does not support the field selector
+			if found[key] || indexers.GetPipelineRepoURL(&pipeline) != normalizedURL {
+				continue
+			}
+			found[key] = true
+			pipelines = append(pipelines, pipeline)
+		}
+	}
+	return
+}
+
+// findGitRepositories finds the GitRepositories of a repository by the indexer of the normalized repository address
+func (h *SCMHandler) findGitRepositories(ctx context.Context, repoURLs ...string) (repos []v1alpha3.GitRepository, err error) {
+	found := map[types.NamespacedName]bool{}
+	for _, repoURL := range repoURLs {
+		normalizedURL := git.NormalizeURL(repoURL)
+		if normalizedURL == "" {
+			continue
+		}
+
+		repoList := &v1alpha3.GitRepositoryList{}
+		if err = h.List(ctx, repoList, client.MatchingFields{v1alpha3.GitRepositoryURLIndexerName: normalizedURL}); err != nil {
+			return
+		}
+		for i := range repoList.Items {
+			repo := repoList.Items[i]
+			key := types.NamespacedName{Namespace: repo.Namespace, Name: repo.Name}
+			// double check the address in case the client does not support the field selector
+			if found[key] || git.NormalizeURL(repo.Spec.URL) != normalizedURL {
+				continue
+			}
+			found[key] = true
+			repos = append(repos, repo)
 		}
 	}
 	return
@@ -17,14 +17,20 @@ limitations under the License.
 package webhook
 
 import (
+	"context"
+
 	"github.com/jenkins-x/go-scm/scm"
 	"github.com/jenkins-x/go-scm/scm/driver/bitbucket"
 	"github.com/jenkins-x/go-scm/scm/driver/github"
 	"github.com/jenkins-x/go-scm/scm/driver/gitlab"
+	"github.com/jenkins-zh/jenkins-client/pkg/core"
 	"github.com/stretchr/testify/assert"
 	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
 	"kubesphere.io/devops/pkg/api/devops/v1alpha3"
+	"kubesphere.io/devops/pkg/jwt/token"
 	"net/http"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 	"testing"
 )
 
@@ -134,3 +140,122 @@ func Test_branchMatch(t *testing.T) {
 		})
 	}
 }
+
+func Test_isDeletedPush(t *testing.T) {
+	assert.True(t, isDeletedPush(&scm.PushHook{Deleted: true}))
+	assert.True(t, isDeletedPush(&scm.PushHook{After: "0000000000000000000000000000000000000000"}))
+	assert.False(t, isDeletedPush(&scm.PushHook{After: "a1b2c3"}))
+	assert.False(t, isDeletedPush(&scm.PushHook{}))
+}
+
+func Test_getRefName(t *testing.T) {
+	assert.Equal(t, "master", getRefName("refs/heads/master"))
+	assert.Equal(t, "feat/a", getRefName("refs/heads/feat/a"))
+	assert.Equal(t, "v1.0", getRefName("refs/tags/v1.0"))
+	assert.Equal(t, "master", getRefName("master"))
+}
+
+func Test_getPullRequestBranch(t *testing.T) {
+	pipeline := v1alpha3.Pipeline{}
+	assert.Equal(t, "PR-1", getPullRequestBranch(pipeline, 1))
+
+	pipeline.Spec.MultiBranchPipeline = &v1alpha3.MultiBranchPipeline{SourceType: v1alpha3.SourceTypeGithub}
+	assert.Equal(t, "PR-2", getPullRequestBranch(pipeline, 2))
+
+	pipeline.Spec.MultiBranchPipeline.SourceType = v1alpha3.SourceTypeGitlab
+	assert.Equal(t, "MR-3", getPullRequestBranch(pipeline, 3))
+}
+
+func TestSCMHandler_setBranchRemoved(t *testing.T) {
+	schema, err := v1alpha3.SchemeBuilder.Register().Build()
+	assert.Nil(t, err)
+
+	pipeline := v1alpha3.Pipeline{}
+	pipeline.SetNamespace("ns")
+	pipeline.SetName("name")
+	pipeline.Annotations = map[string]string{
+		v1alpha3.PipelineJenkinsBranchesAnnoKey: `[{"name":"master"},{"name":"feat%2Fa","rawName":"feat/a"}]`,
+	}
+	handler := NewSCMHandler(fake.NewClientBuilder().WithScheme(schema).WithObjects(pipeline.DeepCopy()).Build(),
+		&token.FakeIssuer{}, core.JenkinsCore{})
+	getPipeline := func() *v1alpha3.Pipeline {
+		latestPipeline := &v1alpha3.Pipeline{}
+		assert.Nil(t, handler.Get(context.Background(), types.NamespacedName{Namespace: "ns", Name: "name"}, latestPipeline))
+		return latestPipeline
+	}
+
+	assert.Nil(t, handler.setBranchRemoved(context.Background(), pipeline, "feat/a", true))
+	latestPipeline := getPipeline()
+	assert.Equal(t, []string{"feat/a"}, latestPipeline.GetRemovedBranches())
+	assert.JSONEq(t, `[{"name":"master","weatherScore":0}]`, latestPipeline.Annotations[v1alpha3.PipelineJenkinsBranchesAnnoKey])
+
+	assert.Nil(t, handler.setBranchRemoved(context.Background(), pipeline, "feat/a", false))
+	assert.Nil(t, getPipeline().GetRemovedBranches())
+
+	pipeline.SetName("fake")
+	assert.NotNil(t, handler.setBranchRemoved(context.Background(), pipeline, "feat/a", true))
+}
+
+func TestSCMHandler_requestPipelineAsCodeSync(t *testing.T) {
+	schema, err := v1alpha3.SchemeBuilder.Register().Build()
+	assert.Nil(t, err)
+
+	repo := &v1alpha3.GitRepository{}
+	repo.SetNamespace("ns")
+	repo.SetName("repo")
+	repo.Spec.URL = "https://github.com/octocat/hello-world.git"
+	repo.Status.DefaultBranch = "main"
+
+	otherRepo := repo.DeepCopy()
+	otherRepo.SetName("other")
+	otherRepo.Spec.URL = "https://github.com/octocat/other.git"
+
+	newPipeline := func(name, repoName, branch string) *v1alpha3.Pipeline {
+		pipeline := &v1alpha3.Pipeline{}
+		pipeline.SetNamespace("ns")
+		pipeline.SetName(name)
+		pipeline.Annotations = map[string]string{v1alpha3.PipelineAsCodeGitRepoAnnoKey: repoName}
+		if branch != "" {
+			pipeline.Annotations[v1alpha3.PipelineAsCodeBranchAnnoKey] = branch
+		}
+		return pipeline
+	}
+	handler := NewSCMHandler(fake.NewClientBuilder().WithScheme(schema).WithObjects(repo, otherRepo,
+		newPipeline("default-branch", "repo", ""),
+		newPipeline("release-branch", "repo", "release"),
+		newPipeline("other-repo", "other", ""),
+	).Build(), &token.FakeIssuer{}, core.JenkinsCore{})
+	getSyncRequest := func(name string) string {
+		pipeline := &v1alpha3.Pipeline{}
+		assert.Nil(t, handler.Get(context.Background(), types.NamespacedName{Namespace: "ns", Name: name}, pipeline))
+		return pipeline.Annotations[v1alpha3.PipelineAsCodeRequestSyncAnnoKey]
+	}
+
+	found, err := handler.requestPipelineAsCodeSync(context.Background(), "main", "sha", true,
+		"https://github.com/octocat/hello-world", "git@github.com:octocat/hello-world.git")
+	assert.Nil(t, err)
+	assert.True(t, found)
+	assert.Equal(t, "sha", getSyncRequest("default-branch"))
+	assert.Empty(t, getSyncRequest("release-branch"))
+	assert.Empty(t, getSyncRequest("other-repo"))
+
+	found, err = handler.requestPipelineAsCodeSync(context.Background(), "dev", "sha", true, "https://github.com/octocat/hello-world")
+	assert.Nil(t, err)
+	assert.False(t, found)
+
+	found, err = handler.requestPipelineAsCodeSync(context.Background(), "release", "", true, "https://github.com/octocat/hello-world")
+	assert.Nil(t, err)
+	assert.True(t, found)
+	assert.NotEmpty(t, getSyncRequest("release-branch"))
+
+	found, err = handler.requestPipelineAsCodeSync(context.Background(), "main", "sha", true)
+	assert.Nil(t, err)
+	assert.False(t, found)
+
+	// the unverified deliveries cannot synchronize the Pipelines
+	found, err = handler.requestPipelineAsCodeSync(context.Background(), "main", "new-sha", false,
+		"https://github.com/octocat/hello-world")
+	assert.ErrorIs(t, err, errUnverifiedDelivery)
+	assert.True(t, found)
+	assert.Equal(t, "sha", getSyncRequest("default-branch"))
+}
@@ -17,6 +17,7 @@ limitations under the License.
 package kapis
 
 import (
+	"fmt"
 	"io"
 	"net/http"
 	"runtime"
@@ -91,6 +92,16 @@ func IgnoreEOF(err error) error {
 	return err
 }
 
+// ReadAllWithLimit reads all the data of the reader.
+// It returns a service error with http.StatusRequestEntityTooLarge if the data is larger than the limit.
+func ReadAllWithLimit(reader io.Reader, limit int64) (data []byte, err error) {
+	if data, err = io.ReadAll(io.LimitReader(reader, limit+1)); err == nil && int64(len(data)) > limit {
+		data = nil
+		err = restful.NewError(http.StatusRequestEntityTooLarge, fmt.Sprintf("the content is larger than %d bytes", limit))
+	}
+	return
+}
+
 // ResponseWriter is a handler for response.
 type ResponseWriter struct {
 	*restful.Response
@@ -18,11 +18,25 @@ import (
 	"github.com/stretchr/testify/assert"
 	"io"
 	"kubesphere.io/devops/pkg/server/errors"
+	"net/http"
 	"net/http/httptest"
 	"reflect"
+	"strings"
 	"testing"
 )
 
+func TestReadAllWithLimit(t *testing.T) {
+	data, err := ReadAllWithLimit(strings.NewReader("abc"), 3)
+	assert.Nil(t, err)
+	assert.Equal(t, "abc", string(data))
+
+	data, err = ReadAllWithLimit(strings.NewReader("abcd"), 3)
+	assert.Nil(t, data)
+	if assert.NotNil(t, err) {
+		assert.Equal(t, http.StatusRequestEntityTooLarge, err.(restful.ServiceError).Code)
+	}
+}
+
 func TestIgnoreEOF(t *testing.T) {
 	type args struct {
 		err error
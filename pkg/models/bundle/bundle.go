@@ -0,0 +1,239 @@
+/*
+Copyright 2022 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package bundle
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"kubesphere.io/devops/pkg/api/devops/v1alpha3"
+	gitopsv1alpha1 "kubesphere.io/devops/pkg/api/gitops/v1alpha1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	// APIVersion is the version of the bundle format
+	APIVersion = "bundle.devops.kubesphere.io/v1"
+	// Kind is the kind of a bundle
+	Kind = "Bundle"
+
+	descriptionAnnoKey = "kubesphere.io/description"
+
+	// MaxSize is the max size of a bundle in bytes.
+	// It limits both the uploaded bundle and the files which are extracted from a tar archive.
+	MaxSize = 10 << 20
+)
+
+// ErrTooLarge is returned when a bundle is larger than MaxSize
+var ErrTooLarge = fmt.Errorf("the bundle is larger than %d bytes", MaxSize)
+
+// Bundle is a portable set of the resources of a DevOps project.
+// The credentials are exported as the references only, the secret values are never included.
+// The trigger tokens of the Pipelines are not included either, they are regenerated when importing.
+type Bundle struct {
+	APIVersion string   `json:"apiVersion"`
+	Kind       string   `json:"kind"`
+	Metadata   Metadata `json:"metadata"`
+
+	Credentials     []CredentialReference        `json:"credentials,omitempty"`
+	Webhooks        []v1alpha3.Webhook           `json:"webhooks,omitempty"`
+	GitRepositories []v1alpha3.GitRepository     `json:"gitRepositories,omitempty"`
+	Templates       []v1alpha3.Template          `json:"templates,omitempty"`
+	Pipelines       []v1alpha3.Pipeline          `json:"pipelines,omitempty"`
+	Applications    []gitopsv1alpha1.Application `json:"applications,omitempty"`
+}
+
+// Metadata describes where a bundle comes from
+type Metadata struct {
+	// Namespace is the namespace of the exported DevOps project
+	Namespace         string      `json:"namespace"`
+	CreationTimestamp metav1.Time `json:"creationTimestamp"`
+}
+
+// CredentialReference is the reference of a credential without the secret values
+type CredentialReference struct {
+	Name        string        `json:"name"`
+	Type        v1.SecretType `json:"type"`
+	Description string        `json:"description,omitempty"`
+}
+
+// Export exports the resources of a namespace as a bundle
+func Export(ctx context.Context, c client.Reader, namespace string) (bundle *Bundle, err error) {
+	bundle = &Bundle{
+		APIVersion: APIVersion,
+		Kind:       Kind,
+		Metadata:   Metadata{Namespace: namespace, CreationTimestamp: metav1.Now()},
+	}
+
+	secrets := &v1.SecretList{}
+	if err = c.List(ctx, secrets, client.InNamespace(namespace)); err != nil {
+		return
+	}
+	for _, secret := range secrets.Items {
+		if isCredential(secret.Type) {
+			bundle.Credentials = append(bundle.Credentials, CredentialReference{
+				Name:        secret.Name,
+				Type:        secret.Type,
+				Description: secret.Annotations[descriptionAnnoKey],
+			})
+		}
+	}
+
+	webhooks := &v1alpha3.WebhookList{}
+	if err = c.List(ctx, webhooks, client.InNamespace(namespace)); err != nil {
+		return
+	}
+	for i := range webhooks.Items {
+		item := webhooks.Items[i]
+		cleanObjectMeta(&item.ObjectMeta)
+		item.TypeMeta = metav1.TypeMeta{APIVersion: v1alpha3.GroupVersion.String(), Kind: "Webhook"}
+		bundle.Webhooks = append(bundle.Webhooks, item)
+	}
+
+	repos := &v1alpha3.GitRepositoryList{}
+	if err = c.List(ctx, repos, client.InNamespace(namespace)); err != nil {
+		return
+	}
+	for i := range repos.Items {
+		item := repos.Items[i]
+		cleanObjectMeta(&item.ObjectMeta)
+		item.TypeMeta = metav1.TypeMeta{APIVersion: v1alpha3.GroupVersion.String(), Kind: "GitRepository"}
+		item.Status = v1alpha3.GitRepositoryStatus{}
+		bundle.GitRepositories = append(bundle.GitRepositories, item)
+	}
+
+	templates := &v1alpha3.TemplateList{}
+	if err = c.List(ctx, templates, client.InNamespace(namespace)); err != nil {
+		return
+	}
+	for i := range templates.Items {
+		item := templates.Items[i]
+		cleanObjectMeta(&item.ObjectMeta)
+		item.TypeMeta = metav1.TypeMeta{APIVersion: v1alpha3.GroupVersion.String(), Kind: "Template"}
+		item.Status = v1alpha3.TemplateStatus{}
+		bundle.Templates = append(bundle.Templates, item)
+	}
+
+	pipelines := &v1alpha3.PipelineList{}
+	if err = c.List(ctx, pipelines, client.InNamespace(namespace)); err != nil {
+		return
+	}
+	for i := range pipelines.Items {
+		item := pipelines.Items[i]
+		cleanObjectMeta(&item.ObjectMeta)
+		item.TypeMeta = metav1.TypeMeta{APIVersion: v1alpha3.GroupVersion.String(), Kind: v1alpha3.ResourceKindPipeline}
+		item.Status = v1alpha3.PipelineStatus{}
+		removeTriggerTokens(&item)
+		bundle.Pipelines = append(bundle.Pipelines, item)
+	}
+
+	apps := &gitopsv1alpha1.ApplicationList{}
+	if err = c.List(ctx, apps, client.InNamespace(namespace)); err != nil {
+		return
+	}
+	for i := range apps.Items {
+		item := apps.Items[i]
+		cleanObjectMeta(&item.ObjectMeta)
+		item.TypeMeta = metav1.TypeMeta{APIVersion: gitopsv1alpha1.GroupVersion.String(), Kind: "Application"}
+		item.Status = gitopsv1alpha1.ApplicationStatus{}
+		bundle.Applications = append(bundle.Applications, item)
+	}
+	return
+}
+
+// Parse parses a bundle from YAML or a gzipped tar archive
+func Parse(data []byte) (bundle *Bundle, err error) {
+	if bytes.HasPrefix(data, gzipMagic) {
+		bundle, err = ReadTar(bytes.NewReader(data))
+	} else {
+		bundle = &Bundle{}
+		err = yaml.UnmarshalStrict(data, bundle)
+	}
+	if err == nil {
+		err = bundle.validate()
+	}
+	return
+}
+
+func (b *Bundle) validate() error {
+	if b.APIVersion != APIVersion || b.Kind != Kind {
+		return fmt.Errorf("unsupported bundle %s/%s, expected %s/%s", b.APIVersion, b.Kind, APIVersion, Kind)
+	}
+	// the credentials out of the exported namespace are not portable
+	for i := range b.Webhooks {
+		if err := b.validateSecretReference(kindWebhook, b.Webhooks[i].Name, b.Webhooks[i].Spec.Secret); err != nil {
+			return err
+		}
+	}
+	for i := range b.GitRepositories {
+		if err := b.validateSecretReference(kindGitRepository, b.GitRepositories[i].Name, b.GitRepositories[i].Spec.Secret); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (b *Bundle) validateSecretReference(kind, name string, ref *v1.SecretReference) error {
+	if ref != nil && ref.Namespace != "" && ref.Namespace != b.Metadata.Namespace {
+		return fmt.Errorf("%s %s refers to the secret %s/%s which is out of the namespace %s",
+			kind, name, ref.Namespace, ref.Name, b.Metadata.Namespace)
+	}
+	return nil
+}
+
+func isCredential(secretType v1.SecretType) bool {
+	return strings.HasPrefix(string(secretType), v1alpha3.DevOpsCredentialPrefix) ||
+		secretType == v1alpha3.SecretTypeGitHubApp
+}
+
+// removeTriggerTokens removes the tokens of the remote trigger and the generic webhook
+func removeTriggerTokens(pipeline *v1alpha3.Pipeline) {
+	noScm := pipeline.Spec.Pipeline
+	if noScm == nil {
+		return
+	}
+	if noScm.RemoteTrigger != nil {
+		noScm.RemoteTrigger.Token = ""
+	}
+	if noScm.GenericWebhook != nil {
+		noScm.GenericWebhook.Token = ""
+	}
+}
+
+// cleanObjectMeta keeps the portable fields only
+func cleanObjectMeta(meta *metav1.ObjectMeta) {
+	annotations := map[string]string{}
+	for key, value := range meta.Annotations {
+		annotations[key] = value
+	}
+	for _, key := range v1alpha3.TransientAnnotations {
+		delete(annotations, key)
+	}
+	if len(annotations) == 0 {
+		annotations = nil
+	}
+	*meta = metav1.ObjectMeta{
+		Name:        meta.Name,
+		Labels:      meta.Labels,
+		Annotations: annotations,
+	}
+}
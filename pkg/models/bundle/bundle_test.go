@@ -0,0 +1,357 @@
+/*
+Copyright 2022 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package bundle
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"kubesphere.io/devops/pkg/api/devops/v1alpha3"
+	gitopsv1alpha1 "kubesphere.io/devops/pkg/api/gitops/v1alpha1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/yaml"
+)
+
+func newScheme(t *testing.T) *runtime.Scheme {
+	schema, err := v1alpha3.SchemeBuilder.Register().Build()
+	assert.Nil(t, err)
+	assert.Nil(t, gitopsv1alpha1.SchemeBuilder.AddToScheme(schema))
+	assert.Nil(t, v1.SchemeBuilder.AddToScheme(schema))
+	return schema
+}
+
+func newSourceObjects() []client.Object {
+	credential := &v1.Secret{}
+	credential.SetNamespace("source")
+	credential.SetName("git")
+	credential.SetAnnotations(map[string]string{descriptionAnnoKey: "git credential"})
+	credential.Type = v1alpha3.SecretTypeBasicAuth
+	credential.Data = map[string][]byte{v1.BasicAuthPasswordKey: []byte("password")}
+
+	opaque := &v1.Secret{}
+	opaque.SetNamespace("source")
+	opaque.SetName("opaque")
+	opaque.Type = v1.SecretTypeOpaque
+
+	webhook := &v1alpha3.Webhook{}
+	webhook.SetNamespace("source")
+	webhook.SetName("hook")
+	webhook.SetAnnotations(map[string]string{v1alpha3.AnnotationKeyGitRepos: "repo"})
+	webhook.Spec.Server = "https://devops.com/hook"
+	webhook.Spec.Secret = &v1.SecretReference{Namespace: "source", Name: "git"}
+
+	repo := &v1alpha3.GitRepository{}
+	repo.SetNamespace("source")
+	repo.SetName("repo")
+	repo.SetFinalizers([]string{v1alpha3.GitRepoFinalizerName})
+	repo.Spec.Provider = "github"
+	repo.Spec.URL = "https://github.com/linuxsuren/tools"
+	repo.Spec.Secret = &v1.SecretReference{Namespace: "source", Name: "git"}
+	repo.Spec.Webhooks = []v1.LocalObjectReference{{Name: "hook"}}
+	repo.Status.Connection = "ok"
+
+	template := &v1alpha3.Template{}
+	template.SetNamespace("source")
+	template.SetName("template")
+	template.Spec.Template = "echo 1"
+
+	pipeline := &v1alpha3.Pipeline{}
+	pipeline.SetNamespace("source")
+	pipeline.SetName("pipeline")
+	pipeline.SetResourceVersion("10")
+	pipeline.SetAnnotations(map[string]string{
+		v1alpha3.PipelineSyncStatusAnnoKey:    "successful",
+		v1alpha3.PipelineAsCodeGitRepoAnnoKey: "repo",
+		"custom":                              "value",
+	})
+	pipeline.Spec.Type = v1alpha3.MultiBranchPipelineType
+	pipeline.Spec.MultiBranchPipeline = &v1alpha3.MultiBranchPipeline{
+		Name:       "pipeline",
+		SourceType: v1alpha3.SourceTypeGit,
+		GitSource:  &v1alpha3.GitSource{Url: "https://github.com/linuxsuren/tools", CredentialId: "git"},
+	}
+
+	app := &gitopsv1alpha1.Application{}
+	app.SetNamespace("source")
+	app.SetName("app")
+	app.Status.ArgoApp = "status"
+	return []client.Object{credential, opaque, webhook, repo, template, pipeline, app}
+}
+
+func TestExport(t *testing.T) {
+	c := fake.NewClientBuilder().WithScheme(newScheme(t)).WithObjects(newSourceObjects()...).Build()
+
+	bundle, err := Export(context.Background(), c, "source")
+	assert.Nil(t, err)
+	assert.Equal(t, APIVersion, bundle.APIVersion)
+	assert.Equal(t, "source", bundle.Metadata.Namespace)
+	assert.Equal(t, []CredentialReference{{Name: "git", Type: v1alpha3.SecretTypeBasicAuth, Description: "git credential"}},
+		bundle.Credentials)
+
+	if assert.Len(t, bundle.Webhooks, 1) {
+		assert.Empty(t, bundle.Webhooks[0].Annotations)
+		assert.Equal(t, "Webhook", bundle.Webhooks[0].Kind)
+	}
+	if assert.Len(t, bundle.GitRepositories, 1) {
+		assert.Empty(t, bundle.GitRepositories[0].Finalizers)
+		assert.Empty(t, bundle.GitRepositories[0].Namespace)
+		assert.Empty(t, bundle.GitRepositories[0].Status)
+	}
+	assert.Len(t, bundle.Templates, 1)
+	if assert.Len(t, bundle.Pipelines, 1) {
+		pipeline := bundle.Pipelines[0]
+		assert.Empty(t, pipeline.ResourceVersion)
+		assert.Equal(t, map[string]string{v1alpha3.PipelineAsCodeGitRepoAnnoKey: "repo", "custom": "value"},
+			pipeline.Annotations)
+	}
+	if assert.Len(t, bundle.Applications, 1) {
+		assert.Empty(t, bundle.Applications[0].Status)
+	}
+
+	// the secret values are never exported
+	data, err := yaml.Marshal(bundle)
+	assert.Nil(t, err)
+	assert.NotContains(t, string(data), "password")
+	assert.NotContains(t, string(data), "cGFzc3dvcmQ=")
+}
+
+func TestParse(t *testing.T) {
+	c := fake.NewClientBuilder().WithScheme(newScheme(t)).WithObjects(newSourceObjects()...).Build()
+	bundle, err := Export(context.Background(), c, "source")
+	assert.Nil(t, err)
+
+	data, err := yaml.Marshal(bundle)
+	assert.Nil(t, err)
+	parsed, err := Parse(data)
+	assert.Nil(t, err)
+	assert.Equal(t, bundle.Pipelines, parsed.Pipelines)
+
+	buf := &bytes.Buffer{}
+	assert.Nil(t, bundle.WriteTar(buf))
+	parsed, err = Parse(buf.Bytes())
+	assert.Nil(t, err)
+	assert.Equal(t, bundle.Metadata.Namespace, parsed.Metadata.Namespace)
+	assert.Equal(t, bundle.Credentials, parsed.Credentials)
+	assert.Equal(t, bundle.Webhooks, parsed.Webhooks)
+	assert.Equal(t, bundle.GitRepositories, parsed.GitRepositories)
+	assert.Equal(t, bundle.Templates, parsed.Templates)
+	assert.Equal(t, bundle.Pipelines, parsed.Pipelines)
+	assert.Equal(t, bundle.Applications, parsed.Applications)
+
+	_, err = Parse([]byte("apiVersion: v1\nkind: Bundle"))
+	assert.NotNil(t, err)
+	_, err = Parse([]byte("invalid: yaml"))
+	assert.NotNil(t, err)
+	_, err = Parse(append([]byte{}, gzipMagic...))
+	assert.NotNil(t, err)
+
+	// the credentials out of the exported namespace are rejected
+	foreign := &Bundle{APIVersion: APIVersion, Kind: Kind, Metadata: Metadata{Namespace: "source"}}
+	foreign.GitRepositories = []v1alpha3.GitRepository{{}}
+	foreign.GitRepositories[0].Spec.Secret = &v1.SecretReference{Namespace: "other", Name: "git"}
+	data, err = yaml.Marshal(foreign)
+	assert.Nil(t, err)
+	_, err = Parse(data)
+	assert.NotNil(t, err)
+
+	// a small archive could not be extracted as a large bundle
+	large := &Bundle{APIVersion: APIVersion, Kind: Kind, Metadata: Metadata{Namespace: "source"}}
+	large.Templates = []v1alpha3.Template{{}}
+	large.Templates[0].Name = "large"
+	large.Templates[0].Spec.Template = strings.Repeat("a", MaxSize)
+	buf.Reset()
+	assert.Nil(t, large.WriteTar(buf))
+	assert.Less(t, buf.Len(), MaxSize)
+	_, err = Parse(buf.Bytes())
+	assert.Equal(t, ErrTooLarge, err)
+}
+
+func TestTriggerTokens(t *testing.T) {
+	pipeline := &v1alpha3.Pipeline{}
+	pipeline.SetNamespace("source")
+	pipeline.SetName("trigger")
+	pipeline.Spec.Type = v1alpha3.NoScmPipelineType
+	pipeline.Spec.Pipeline = &v1alpha3.NoScmPipeline{
+		Name:           "trigger",
+		RemoteTrigger:  &v1alpha3.RemoteTrigger{Token: "remote-token"},
+		GenericWebhook: &v1alpha3.GenericWebhook{Enable: true, Token: "generic-token"},
+	}
+	source := fake.NewClientBuilder().WithScheme(newScheme(t)).WithObjects(pipeline).Build()
+
+	// the tokens are never exported
+	bundle, err := Export(context.Background(), source, "source")
+	assert.Nil(t, err)
+	data, err := yaml.Marshal(bundle)
+	assert.Nil(t, err)
+	assert.NotContains(t, string(data), "remote-token")
+	assert.NotContains(t, string(data), "generic-token")
+
+	// the tokens are regenerated
+	target := fake.NewClientBuilder().WithScheme(newScheme(t)).Build()
+	_, err = Import(context.Background(), target, bundle, ImportOptions{Namespace: "target"})
+	assert.Nil(t, err)
+	imported := &v1alpha3.Pipeline{}
+	assert.Nil(t, target.Get(context.Background(), types.NamespacedName{Namespace: "target", Name: "trigger"}, imported))
+	assert.NotEmpty(t, imported.Spec.Pipeline.RemoteTrigger.Token)
+	assert.NotEmpty(t, imported.Spec.Pipeline.GenericWebhook.Token)
+	assert.NotEqual(t, imported.Spec.Pipeline.RemoteTrigger.Token, imported.Spec.Pipeline.GenericWebhook.Token)
+
+	// the tokens of the overwritten Pipeline are kept
+	_, err = Import(context.Background(), source, bundle, ImportOptions{Namespace: "source", ConflictStrategy: ConflictOverwrite})
+	assert.Nil(t, err)
+	assert.Nil(t, source.Get(context.Background(), types.NamespacedName{Namespace: "source", Name: "trigger"}, imported))
+	assert.Equal(t, "remote-token", imported.Spec.Pipeline.RemoteTrigger.Token)
+	assert.Equal(t, "generic-token", imported.Spec.Pipeline.GenericWebhook.Token)
+}
+
+func TestImport(t *testing.T) {
+	source := fake.NewClientBuilder().WithScheme(newScheme(t)).WithObjects(newSourceObjects()...).Build()
+	bundle, err := Export(context.Background(), source, "source")
+	assert.Nil(t, err)
+
+	existingPipeline := &v1alpha3.Pipeline{}
+	existingPipeline.SetNamespace("target")
+	existingPipeline.SetName("pipeline")
+	existingPipeline.Spec.Type = v1alpha3.NoScmPipelineType
+	existingPipeline.SetFinalizers([]string{v1alpha3.PipelineFinalizerName})
+	existingPipeline.SetLabels(map[string]string{"existing": "value"})
+	existingPipeline.SetOwnerReferences([]metav1.OwnerReference{{APIVersion: "v1", Kind: "Namespace", Name: "target", UID: "uid"}})
+	existingCredential := &v1.Secret{}
+	existingCredential.SetNamespace("target")
+	existingCredential.SetName("new-git")
+	existingCredential.Type = v1alpha3.SecretTypeBasicAuth
+
+	tests := []struct {
+		name    string
+		options ImportOptions
+		wantErr bool
+		verify  func(t *testing.T, c client.Client, report *ImportReport)
+	}{{
+		name:    "without the target namespace",
+		options: ImportOptions{},
+		wantErr: true,
+	}, {
+		name:    "unknown conflict strategy",
+		options: ImportOptions{Namespace: "target", ConflictStrategy: "unknown"},
+		wantErr: true,
+	}, {
+		name:    "dry run",
+		options: ImportOptions{Namespace: "target", DryRun: true, ConflictStrategy: ConflictRename},
+		verify: func(t *testing.T, c client.Client, report *ImportReport) {
+			assert.True(t, report.DryRun)
+			assert.Equal(t, []ImportResult{
+				{Kind: kindCredential, Name: "git", TargetName: "git", Action: ActionMissing,
+					Message: "please create a credential with type credential.devops.kubesphere.io/basic-auth"},
+				{Kind: kindWebhook, Name: "hook", TargetName: "hook", Action: ActionCreate},
+				{Kind: kindGitRepository, Name: "repo", TargetName: "repo", Action: ActionCreate},
+				{Kind: kindTemplate, Name: "template", TargetName: "template", Action: ActionCreate},
+				{Kind: v1alpha3.ResourceKindPipeline, Name: "pipeline", TargetName: "pipeline-1", Action: ActionRename},
+				{Kind: kindApplication, Name: "app", TargetName: "app", Action: ActionCreate},
+			}, report.Results)
+
+			repos := &v1alpha3.GitRepositoryList{}
+			assert.Nil(t, c.List(context.Background(), repos, client.InNamespace("target")))
+			assert.Empty(t, repos.Items)
+		},
+	}, {
+		name:    "skip the existing ones",
+		options: ImportOptions{Namespace: "target"},
+		verify: func(t *testing.T, c client.Client, report *ImportReport) {
+			assert.Equal(t, ActionSkip, report.Results[4].Action)
+			pipeline := &v1alpha3.Pipeline{}
+			assert.Nil(t, c.Get(context.Background(), types.NamespacedName{Namespace: "target", Name: "pipeline"}, pipeline))
+			assert.Equal(t, v1alpha3.NoScmPipelineType, pipeline.Spec.Type)
+
+			repo := &v1alpha3.GitRepository{}
+			assert.Nil(t, c.Get(context.Background(), types.NamespacedName{Namespace: "target", Name: "repo"}, repo))
+			assert.Equal(t, &v1.SecretReference{Namespace: "target", Name: "git"}, repo.Spec.Secret)
+		},
+	}, {
+		name:    "overwrite the existing ones",
+		options: ImportOptions{Namespace: "target", ConflictStrategy: ConflictOverwrite},
+		verify: func(t *testing.T, c client.Client, report *ImportReport) {
+			assert.Equal(t, ActionOverwrite, report.Results[4].Action)
+			pipeline := &v1alpha3.Pipeline{}
+			assert.Nil(t, c.Get(context.Background(), types.NamespacedName{Namespace: "target", Name: "pipeline"}, pipeline))
+			assert.Equal(t, v1alpha3.MultiBranchPipelineType, pipeline.Spec.Type)
+			assert.Equal(t, "value", pipeline.Annotations["custom"])
+			// the fields which are not in the bundle are kept
+			assert.Equal(t, []string{v1alpha3.PipelineFinalizerName}, pipeline.Finalizers)
+			assert.Equal(t, "value", pipeline.Labels["existing"])
+			assert.Len(t, pipeline.OwnerReferences, 1)
+		},
+	}, {
+		name: "rename and remap the references",
+		options: ImportOptions{
+			Namespace:        "target",
+			ConflictStrategy: ConflictRename,
+			NameMapping:      map[string]string{"git": "new-git", "repo": "new-repo", "hook": "new-hook"},
+		},
+		verify: func(t *testing.T, c client.Client, report *ImportReport) {
+			assert.Equal(t, ImportResult{Kind: kindCredential, Name: "git", TargetName: "new-git", Action: ActionSkip},
+				report.Results[0])
+			assert.Equal(t, "pipeline-1", report.Results[4].TargetName)
+
+			repo := &v1alpha3.GitRepository{}
+			assert.Nil(t, c.Get(context.Background(), types.NamespacedName{Namespace: "target", Name: "new-repo"}, repo))
+			assert.Equal(t, &v1.SecretReference{Namespace: "target", Name: "new-git"}, repo.Spec.Secret)
+			assert.Equal(t, []v1.LocalObjectReference{{Name: "new-hook"}}, repo.Spec.Webhooks)
+
+			pipeline := &v1alpha3.Pipeline{}
+			assert.Nil(t, c.Get(context.Background(), types.NamespacedName{Namespace: "target", Name: "pipeline-1"}, pipeline))
+			assert.Equal(t, "pipeline-1", pipeline.Spec.MultiBranchPipeline.Name)
+			assert.Equal(t, "new-git", pipeline.Spec.MultiBranchPipeline.GitSource.CredentialId)
+			assert.Equal(t, "new-repo", pipeline.Annotations[v1alpha3.PipelineAsCodeGitRepoAnnoKey])
+		},
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := fake.NewClientBuilder().WithScheme(newScheme(t)).
+				WithObjects(existingPipeline.DeepCopy(), existingCredential.DeepCopy()).Build()
+			report, err := Import(context.Background(), c, bundle, tt.options)
+			if tt.wantErr {
+				assert.NotNil(t, err)
+				return
+			}
+			assert.Nil(t, err)
+			tt.verify(t, c, report)
+		})
+	}
+}
+
+func Test_credentialIDs(t *testing.T) {
+	assert.Empty(t, credentialIDs(nil))
+	pipeline := &v1alpha3.MultiBranchPipeline{
+		GitSource:    &v1alpha3.GitSource{},
+		GitHubSource: &v1alpha3.GithubSource{CredentialId: "github"},
+		GiteeSource:  &v1alpha3.GiteeSource{CredentialId: "gitee"},
+	}
+	ids := credentialIDs(pipeline)
+	if assert.Len(t, ids, 2) {
+		*ids[0] = "new"
+		assert.Equal(t, "new", pipeline.GitHubSource.CredentialId)
+		assert.Equal(t, "gitee", *ids[1])
+	}
+}
@@ -0,0 +1,473 @@
+/*
+Copyright 2022 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package bundle
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"kubesphere.io/devops/pkg/api/devops/v1alpha3"
+	gitopsv1alpha1 "kubesphere.io/devops/pkg/api/gitops/v1alpha1"
+	modelpipeline "kubesphere.io/devops/pkg/models/pipeline"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// ConflictStrategy decides what to do if a resource already exists in the target namespace
+type ConflictStrategy string
+
+const (
+	// ConflictSkip keeps the existing resource
+	ConflictSkip ConflictStrategy = "skip"
+	// ConflictOverwrite overwrites the spec, labels and annotations of the existing resource with the one in the bundle
+	ConflictOverwrite ConflictStrategy = "overwrite"
+	// ConflictRename imports the resource with a new name, such as "name-1"
+	ConflictRename ConflictStrategy = "rename"
+)
+
+// Action is what happened to a resource of the bundle
+type Action string
+
+const (
+	// ActionCreate means the resource was created
+	ActionCreate Action = "Create"
+	// ActionSkip means the resource was not changed
+	ActionSkip Action = "Skip"
+	// ActionOverwrite means the spec, labels and annotations of the existing resource were overwritten
+	ActionOverwrite Action = "Overwrite"
+	// ActionRename means the resource was created with another name
+	ActionRename Action = "Rename"
+	// ActionMissing means a credential was referenced but does not exist in the target namespace
+	ActionMissing Action = "Missing"
+)
+
+const (
+	kindCredential    = "Credential"
+	kindWebhook       = "Webhook"
+	kindGitRepository = "GitRepository"
+	kindTemplate      = "Template"
+	kindApplication   = "Application"
+)
+
+// maxRenameAttempts is the limit of the suffix when looking for an available name
+const maxRenameAttempts = 100
+
+// ImportOptions are the options of importing a bundle
+type ImportOptions struct {
+	// Namespace is the target namespace
+	Namespace string
+	// NameMapping maps the names in the bundle to the names in the target namespace.
+	// The references between the resources, such as the credential ID of a Pipeline, are updated as well.
+	NameMapping map[string]string
+	// ConflictStrategy is ConflictSkip by default
+	ConflictStrategy ConflictStrategy
+	// DryRun reports what would happen without changing anything
+	DryRun bool
+}
+
+// ImportReport is the result of importing a bundle
+type ImportReport struct {
+	Namespace string         `json:"namespace"`
+	DryRun    bool           `json:"dryRun"`
+	Results   []ImportResult `json:"results"`
+}
+
+// ImportResult is the result of importing a resource
+type ImportResult struct {
+	Kind       string `json:"kind"`
+	Name       string `json:"name"`
+	TargetName string `json:"targetName"`
+	Action     Action `json:"action"`
+	Message    string `json:"message,omitempty"`
+}
+
+// Validate checks the options and fills the default values
+func (o *ImportOptions) Validate() error {
+	if o.Namespace == "" {
+		return fmt.Errorf("the target namespace is required")
+	}
+	switch o.ConflictStrategy {
+	case "":
+		o.ConflictStrategy = ConflictSkip
+	case ConflictSkip, ConflictOverwrite, ConflictRename:
+	default:
+		return fmt.Errorf("unsupported conflict strategy: %s", o.ConflictStrategy)
+	}
+	return nil
+}
+
+type importer struct {
+	client.Client
+	ctx     context.Context
+	options ImportOptions
+	bundle  *Bundle
+	report  *ImportReport
+	// names records the target names of the imported resources by kind
+	names map[string]map[string]string
+}
+
+// Import imports a bundle into the target namespace. The credentials are never created,
+// the missing ones are reported so that they could be created before running the Pipelines.
+func Import(ctx context.Context, c client.Client, bundle *Bundle, options ImportOptions) (report *ImportReport, err error) {
+	if err = options.Validate(); err != nil {
+		return
+	}
+	if err = bundle.validate(); err != nil {
+		return
+	}
+
+	report = &ImportReport{Namespace: options.Namespace, DryRun: options.DryRun, Results: []ImportResult{}}
+	i := &importer{
+		Client:  c,
+		ctx:     ctx,
+		options: options,
+		bundle:  bundle,
+		report:  report,
+		names:   map[string]map[string]string{},
+	}
+
+	// the order makes sure the referenced resources are imported first
+	for _, importFunc := range []func() error{
+		i.importCredentials,
+		i.importWebhooks,
+		i.importGitRepositories,
+		i.importTemplates,
+		i.importPipelines,
+		i.importApplications,
+	} {
+		if err = importFunc(); err != nil {
+			return
+		}
+	}
+	return
+}
+
+func (i *importer) importCredentials() error {
+	for _, credential := range i.bundle.Credentials {
+		result := ImportResult{Kind: kindCredential, Name: credential.Name, TargetName: i.mappedName(credential.Name)}
+		secret := &v1.Secret{}
+		if err := i.Get(i.ctx, types.NamespacedName{Namespace: i.options.Namespace, Name: result.TargetName}, secret); err != nil {
+			if !apierrors.IsNotFound(err) {
+				return err
+			}
+			result.Action = ActionMissing
+			result.Message = fmt.Sprintf("please create a credential with type %s", credential.Type)
+		} else {
+			result.Action = ActionSkip
+			if secret.Type != credential.Type {
+				result.Message = fmt.Sprintf("the type of the existing credential is %s instead of %s", secret.Type, credential.Type)
+			}
+		}
+		i.record(result)
+	}
+	return nil
+}
+
+func (i *importer) importWebhooks() error {
+	for idx := range i.bundle.Webhooks {
+		webhook := i.bundle.Webhooks[idx].DeepCopy()
+		i.rewriteSecretReference(webhook.Spec.Secret)
+		if err := i.apply(kindWebhook, webhook, &v1alpha3.Webhook{}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (i *importer) importGitRepositories() error {
+	for idx := range i.bundle.GitRepositories {
+		repo := i.bundle.GitRepositories[idx].DeepCopy()
+		i.rewriteSecretReference(repo.Spec.Secret)
+		for j := range repo.Spec.Webhooks {
+			repo.Spec.Webhooks[j].Name = i.targetName(kindWebhook, repo.Spec.Webhooks[j].Name)
+		}
+		if err := i.apply(kindGitRepository, repo, &v1alpha3.GitRepository{}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (i *importer) importTemplates() error {
+	for idx := range i.bundle.Templates {
+		if err := i.apply(kindTemplate, i.bundle.Templates[idx].DeepCopy(), &v1alpha3.Template{}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (i *importer) importPipelines() error {
+	for idx := range i.bundle.Pipelines {
+		pipeline := i.bundle.Pipelines[idx].DeepCopy()
+		for _, credentialID := range credentialIDs(pipeline.Spec.MultiBranchPipeline) {
+			*credentialID = i.targetName(kindCredential, *credentialID)
+		}
+		if repo := pipeline.Annotations[v1alpha3.PipelineAsCodeGitRepoAnnoKey]; repo != "" {
+			pipeline.Annotations[v1alpha3.PipelineAsCodeGitRepoAnnoKey] = i.targetName(kindGitRepository, repo)
+		}
+		if err := i.apply(v1alpha3.ResourceKindPipeline, pipeline, &v1alpha3.Pipeline{}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (i *importer) importApplications() error {
+	for idx := range i.bundle.Applications {
+		if err := i.apply(kindApplication, i.bundle.Applications[idx].DeepCopy(), &gitopsv1alpha1.Application{}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// apply creates or updates the object according to the conflict strategy, existing is an empty object of the same type
+func (i *importer) apply(kind string, obj, existing client.Object) (err error) {
+	result := ImportResult{Kind: kind, Name: obj.GetName(), TargetName: i.mappedName(obj.GetName()), Action: ActionCreate}
+	var found bool
+	if found, err = i.exists(result.TargetName, existing); err != nil {
+		return
+	}
+
+	if found {
+		switch i.options.ConflictStrategy {
+		case ConflictSkip:
+			result.Action = ActionSkip
+			result.Message = "already exists"
+		case ConflictOverwrite:
+			result.Action = ActionOverwrite
+		case ConflictRename:
+			result.Action = ActionRename
+			if result.TargetName, err = i.availableName(kind, result.TargetName, existing); err != nil {
+				return
+			}
+		}
+	}
+	i.record(result)
+
+	obj.SetNamespace(i.options.Namespace)
+	obj.SetName(result.TargetName)
+	if pipeline, ok := obj.(*v1alpha3.Pipeline); ok {
+		setPipelineName(pipeline, result.TargetName)
+		var overwritten *v1alpha3.Pipeline
+		if result.Action == ActionOverwrite {
+			overwritten = existing.(*v1alpha3.Pipeline)
+		}
+		if err = setTriggerTokens(pipeline, overwritten); err != nil {
+			return
+		}
+	}
+	if i.options.DryRun {
+		return
+	}
+
+	switch result.Action {
+	case ActionCreate, ActionRename:
+		err = i.Create(i.ctx, obj)
+	case ActionOverwrite:
+		var merged client.Object
+		if merged, err = mergeObject(existing, obj); err == nil {
+			err = i.Update(i.ctx, merged)
+		}
+	}
+	return
+}
+
+// mergeObject merges the spec, labels and annotations of the imported object into a copy of the existing one.
+// The other fields of the existing one are kept, such as the finalizers, owner references and status.
+func mergeObject(existing, obj client.Object) (merged client.Object, err error) {
+	var existingContent, objContent map[string]interface{}
+	if existingContent, err = runtime.DefaultUnstructuredConverter.ToUnstructured(existing); err != nil {
+		return
+	}
+	if objContent, err = runtime.DefaultUnstructuredConverter.ToUnstructured(obj); err != nil {
+		return
+	}
+	if spec, ok := objContent["spec"]; ok {
+		existingContent["spec"] = spec
+	} else {
+		delete(existingContent, "spec")
+	}
+
+	merged = reflect.New(reflect.TypeOf(existing).Elem()).Interface().(client.Object)
+	if err = runtime.DefaultUnstructuredConverter.FromUnstructured(existingContent, merged); err != nil {
+		return
+	}
+	merged.SetLabels(mergeMap(existing.GetLabels(), obj.GetLabels()))
+	merged.SetAnnotations(mergeMap(existing.GetAnnotations(), obj.GetAnnotations()))
+	return
+}
+
+// mergeMap returns a new map which has the items of both maps, the items of the second map take precedence
+func mergeMap(existing, imported map[string]string) map[string]string {
+	if len(existing) == 0 && len(imported) == 0 {
+		return existing
+	}
+	merged := make(map[string]string, len(existing)+len(imported))
+	for key, value := range existing {
+		merged[key] = value
+	}
+	for key, value := range imported {
+		merged[key] = value
+	}
+	return merged
+}
+
+func (i *importer) exists(name string, obj client.Object) (found bool, err error) {
+	if err = i.Get(i.ctx, types.NamespacedName{Namespace: i.options.Namespace, Name: name}, obj); err == nil {
+		found = true
+	} else if apierrors.IsNotFound(err) {
+		err = nil
+	}
+	return
+}
+
+func (i *importer) availableName(kind, name string, obj client.Object) (string, error) {
+	for suffix := 1; suffix <= maxRenameAttempts; suffix++ {
+		candidate := fmt.Sprintf("%s-%d", name, suffix)
+		if i.reserved(kind, candidate) {
+			continue
+		}
+		if found, err := i.exists(candidate, obj); err != nil || !found {
+			return candidate, err
+		}
+	}
+	return "", fmt.Errorf("cannot find an available name for %s", name)
+}
+
+// reserved checks if a name is taken by the resources which are imported in the dry-run mode
+func (i *importer) reserved(kind, name string) bool {
+	for _, result := range i.report.Results {
+		if result.Kind == kind && result.TargetName == name && (result.Action == ActionCreate || result.Action == ActionRename) {
+			return true
+		}
+	}
+	return false
+}
+
+func (i *importer) record(result ImportResult) {
+	i.report.Results = append(i.report.Results, result)
+	if i.names[result.Kind] == nil {
+		i.names[result.Kind] = map[string]string{}
+	}
+	i.names[result.Kind][result.Name] = result.TargetName
+}
+
+func (i *importer) mappedName(name string) string {
+	if target, ok := i.options.NameMapping[name]; ok && target != "" {
+		return target
+	}
+	return name
+}
+
+// targetName returns the name of a referenced resource in the target namespace
+func (i *importer) targetName(kind, name string) string {
+	if target, ok := i.names[kind][name]; ok {
+		return target
+	}
+	return i.mappedName(name)
+}
+
+// rewriteSecretReference points the reference to the target namespace, the foreign ones are rejected by Bundle.validate
+func (i *importer) rewriteSecretReference(ref *v1.SecretReference) {
+	if ref == nil {
+		return
+	}
+	ref.Namespace = i.options.Namespace
+	ref.Name = i.targetName(kindCredential, ref.Name)
+}
+
+func setPipelineName(pipeline *v1alpha3.Pipeline, name string) {
+	if pipeline.Spec.Pipeline != nil {
+		pipeline.Spec.Pipeline.Name = name
+	}
+	if pipeline.Spec.MultiBranchPipeline != nil {
+		pipeline.Spec.MultiBranchPipeline.Name = name
+	}
+}
+
+// setTriggerTokens generates the trigger tokens which are never exported.
+// The tokens of the overwritten Pipeline are kept, so the existing callers are not broken.
+func setTriggerTokens(pipeline, overwritten *v1alpha3.Pipeline) (err error) {
+	noScm := pipeline.Spec.Pipeline
+	if noScm == nil {
+		return
+	}
+	var existing *v1alpha3.NoScmPipeline
+	if overwritten != nil {
+		existing = overwritten.Spec.Pipeline
+	}
+	if noScm.RemoteTrigger != nil {
+		if existing != nil && existing.RemoteTrigger != nil && existing.RemoteTrigger.Token != "" {
+			noScm.RemoteTrigger.Token = existing.RemoteTrigger.Token
+		} else if noScm.RemoteTrigger.Token, err = modelpipeline.NewTriggerToken(); err != nil {
+			return
+		}
+	}
+	if noScm.GenericWebhook != nil {
+		if existing != nil && existing.GenericWebhook != nil && existing.GenericWebhook.Token != "" {
+			noScm.GenericWebhook.Token = existing.GenericWebhook.Token
+		} else if noScm.GenericWebhook.Token, err = modelpipeline.NewTriggerToken(); err != nil {
+			return
+		}
+	}
+	return
+}
+
+// credentialIDs returns the pointers of all the credential IDs of a multi-branch Pipeline
+func credentialIDs(pipeline *v1alpha3.MultiBranchPipeline) (ids []*string) {
+	if pipeline == nil {
+		return
+	}
+	if pipeline.GitSource != nil {
+		ids = append(ids, &pipeline.GitSource.CredentialId)
+	}
+	if pipeline.GitHubSource != nil {
+		ids = append(ids, &pipeline.GitHubSource.CredentialId)
+	}
+	if pipeline.GitlabSource != nil {
+		ids = append(ids, &pipeline.GitlabSource.CredentialId)
+	}
+	if pipeline.SvnSource != nil {
+		ids = append(ids, &pipeline.SvnSource.CredentialId)
+	}
+	if pipeline.SingleSvnSource != nil {
+		ids = append(ids, &pipeline.SingleSvnSource.CredentialId)
+	}
+	if pipeline.BitbucketServerSource != nil {
+		ids = append(ids, &pipeline.BitbucketServerSource.CredentialId)
+	}
+	if pipeline.AzureDevOpsSource != nil {
+		ids = append(ids, &pipeline.AzureDevOpsSource.CredentialId)
+	}
+	if pipeline.GiteeSource != nil {
+		ids = append(ids, &pipeline.GiteeSource.CredentialId)
+	}
+	// an empty credential ID means no credential is required
+	for j := 0; j < len(ids); j++ {
+		if *ids[j] == "" {
+			ids = append(ids[:j], ids[j+1:]...)
+			j--
+		}
+	}
+	return
+}
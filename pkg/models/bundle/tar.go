@@ -0,0 +1,197 @@
+/*
+Copyright 2022 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package bundle
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+
+	"kubesphere.io/devops/pkg/api/devops/v1alpha3"
+	gitopsv1alpha1 "kubesphere.io/devops/pkg/api/gitops/v1alpha1"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	metadataFile    = "metadata.yaml"
+	credentialsFile = "credentials.yaml"
+
+	webhooksDir        = "webhooks"
+	gitRepositoriesDir = "gitrepositories"
+	templatesDir       = "templates"
+	pipelinesDir       = "pipelines"
+	applicationsDir    = "applications"
+)
+
+var gzipMagic = []byte{0x1f, 0x8b}
+
+// header is the content of the metadata file in a tar archive
+type header struct {
+	APIVersion string   `json:"apiVersion"`
+	Kind       string   `json:"kind"`
+	Metadata   Metadata `json:"metadata"`
+}
+
+type tarFile struct {
+	name   string
+	object interface{}
+}
+
+// WriteTar writes the bundle as a gzipped tar archive, each resource is a YAML file in the directory of its kind
+func (b *Bundle) WriteTar(writer io.Writer) (err error) {
+	gzipWriter := gzip.NewWriter(writer)
+	tarWriter := tar.NewWriter(gzipWriter)
+
+	files := []tarFile{{
+		name:   metadataFile,
+		object: header{APIVersion: b.APIVersion, Kind: b.Kind, Metadata: b.Metadata},
+	}}
+	if len(b.Credentials) > 0 {
+		files = append(files, tarFile{name: credentialsFile, object: b.Credentials})
+	}
+	addFile := func(dir, name string, object interface{}) {
+		files = append(files, tarFile{name: path.Join(dir, name+".yaml"), object: object})
+	}
+	for i := range b.Webhooks {
+		addFile(webhooksDir, b.Webhooks[i].Name, &b.Webhooks[i])
+	}
+	for i := range b.GitRepositories {
+		addFile(gitRepositoriesDir, b.GitRepositories[i].Name, &b.GitRepositories[i])
+	}
+	for i := range b.Templates {
+		addFile(templatesDir, b.Templates[i].Name, &b.Templates[i])
+	}
+	for i := range b.Pipelines {
+		addFile(pipelinesDir, b.Pipelines[i].Name, &b.Pipelines[i])
+	}
+	for i := range b.Applications {
+		addFile(applicationsDir, b.Applications[i].Name, &b.Applications[i])
+	}
+
+	for _, file := range files {
+		var data []byte
+		if data, err = yaml.Marshal(file.object); err != nil {
+			return
+		}
+		if err = tarWriter.WriteHeader(&tar.Header{
+			Name:    file.name,
+			Mode:    0644,
+			Size:    int64(len(data)),
+			ModTime: b.Metadata.CreationTimestamp.Time,
+		}); err != nil {
+			return
+		}
+		if _, err = tarWriter.Write(data); err != nil {
+			return
+		}
+	}
+
+	if err = tarWriter.Close(); err != nil {
+		return
+	}
+	err = gzipWriter.Close()
+	return
+}
+
+// ReadTar reads a bundle from a gzipped tar archive which is written by WriteTar
+func ReadTar(reader io.Reader) (bundle *Bundle, err error) {
+	var gzipReader *gzip.Reader
+	if gzipReader, err = gzip.NewReader(reader); err != nil {
+		return
+	}
+	defer func() {
+		_ = gzipReader.Close()
+	}()
+
+	bundle = &Bundle{}
+	foundMetadata := false
+	// the size of the extracted files is limited as well, a small archive could be a large bundle
+	remaining := int64(MaxSize)
+	tarReader := tar.NewReader(gzipReader)
+	for {
+		var fileHeader *tar.Header
+		if fileHeader, err = tarReader.Next(); err == io.EOF {
+			err = nil
+			break
+		} else if err != nil {
+			return
+		}
+		if fileHeader.Typeflag != tar.TypeReg {
+			continue
+		}
+
+		var data []byte
+		if data, err = io.ReadAll(io.LimitReader(tarReader, remaining+1)); err != nil {
+			return
+		}
+		if remaining -= int64(len(data)); remaining < 0 {
+			err = ErrTooLarge
+			return
+		}
+
+		name := strings.TrimPrefix(path.Clean(fileHeader.Name), "./")
+		switch dir := path.Dir(name); {
+		case name == metadataFile:
+			metadata := header{}
+			if err = yaml.UnmarshalStrict(data, &metadata); err == nil {
+				bundle.APIVersion, bundle.Kind, bundle.Metadata = metadata.APIVersion, metadata.Kind, metadata.Metadata
+				foundMetadata = true
+			}
+		case name == credentialsFile:
+			err = yaml.UnmarshalStrict(data, &bundle.Credentials)
+		case dir == webhooksDir:
+			item := v1alpha3.Webhook{}
+			if err = yaml.UnmarshalStrict(data, &item); err == nil {
+				bundle.Webhooks = append(bundle.Webhooks, item)
+			}
+		case dir == gitRepositoriesDir:
+			item := v1alpha3.GitRepository{}
+			if err = yaml.UnmarshalStrict(data, &item); err == nil {
+				bundle.GitRepositories = append(bundle.GitRepositories, item)
+			}
+		case dir == templatesDir:
+			item := v1alpha3.Template{}
+			if err = yaml.UnmarshalStrict(data, &item); err == nil {
+				bundle.Templates = append(bundle.Templates, item)
+			}
+		case dir == pipelinesDir:
+			item := v1alpha3.Pipeline{}
+			if err = yaml.UnmarshalStrict(data, &item); err == nil {
+				bundle.Pipelines = append(bundle.Pipelines, item)
+			}
+		case dir == applicationsDir:
+			item := gitopsv1alpha1.Application{}
+			if err = yaml.UnmarshalStrict(data, &item); err == nil {
+				bundle.Applications = append(bundle.Applications, item)
+			}
+		default:
+			err = fmt.Errorf("unknown file %s in the bundle", fileHeader.Name)
+		}
+		if err != nil {
+			err = fmt.Errorf("failed to parse %s: %v", fileHeader.Name, err)
+			return
+		}
+	}
+
+	if !foundMetadata {
+		err = fmt.Errorf("%s is missing in the bundle", metadataFile)
+	}
+	return
+}
@@ -65,7 +65,9 @@ type DevopsOperator interface {
 	DeletePipelineObj(projectName string, pipelineName string) error
 	UpdatePipelineObj(projectName string, pipeline *v1alpha3.Pipeline) (*v1alpha3.Pipeline, error)
 	ListPipelineObj(projectName string, query *query.Query) (api.ListResult, error)
-	UpdateJenkinsfile(projectName, pipelineName, mode, jenkinsfile string) error
+	UpdateJenkinsfile(projectName, pipelineName, mode, jenkinsfile, modifier string) error
+	PreviewPipelineConfig(projectName string, pipeline *v1alpha3.Pipeline, update bool) (*PipelineConfig, error)
+	GetPipelineDrift(projectName, pipelineName string) (*PipelineDrift, error)
 
 	CreateCredentialObj(projectName string, s *v1.Secret) (*v1.Secret, error)
 	GetCredentialObj(projectName string, secretName string) (*v1.Secret, error)
@@ -297,7 +299,11 @@ func (d devopsOperator) UpdatePipelineObj(projectName string, pipeline *v1alpha3
 
 		// avoid update the Jenkinsfile in this API, see also UpdateJenkinsfile
 		if pipeline.Spec.Pipeline != nil && latestPipe.Spec.Pipeline != nil {
+			modifier := pipeline.GetLastModifier()
 			pipeline.Spec.Pipeline.Jenkinsfile = latestPipe.Spec.Pipeline.Jenkinsfile
+			if modifier != "" {
+				pipeline.SetLastModifier(modifier)
+			}
 		}
 	} else {
 		return nil, fmt.Errorf("cannot found pipeline %s/%s, error: %v", ns, name, err)
@@ -306,8 +312,8 @@ func (d devopsOperator) UpdatePipelineObj(projectName string, pipeline *v1alpha3
 	return d.ksclient.DevopsV1alpha3().Pipelines(ns).Update(d.context, pipeline, metav1.UpdateOptions{})
 }
 
-// UpdateJenkinsfile updates the Jenkinsfile value with specific edit mode
-func (d devopsOperator) UpdateJenkinsfile(projectName, pipelineName, mode, jenkinsfile string) (err error) {
+// UpdateJenkinsfile updates the Jenkinsfile value with specific edit mode, the modifier is recorded if it is not empty
+func (d devopsOperator) UpdateJenkinsfile(projectName, pipelineName, mode, jenkinsfile, modifier string) (err error) {
 	var pipeline *devopsv1alpha3.Pipeline
 	if pipeline, err = d.ksclient.DevopsV1alpha3().Pipelines(projectName).Get(d.context, pipelineName, metav1.GetOptions{}); err != nil {
 		return
@@ -329,6 +335,9 @@ func (d devopsOperator) UpdateJenkinsfile(projectName, pipelineName, mode, jenki
 		err = fmt.Errorf("invalid edit mode: %s", mode)
 		return
 	}
+	if modifier != "" {
+		pipeline.SetLastModifier(modifier)
+	}
 	_, err = d.ksclient.DevopsV1alpha3().Pipelines(projectName).Update(d.context, pipeline, metav1.UpdateOptions{})
 	return
 }
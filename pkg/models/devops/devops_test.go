@@ -347,6 +347,7 @@ func Test_devopsOperator_UpdateJenkinsfile(t *testing.T) {
 		pipelineName string
 		mode         string
 		jenkinsfile  string
+		modifier     string
 	}
 	tests := []struct {
 		name    string
@@ -419,6 +420,7 @@ func Test_devopsOperator_UpdateJenkinsfile(t *testing.T) {
 			pipelineName: "fake",
 			mode:         "raw",
 			jenkinsfile:  "jenkinsfile",
+			modifier:     "tester",
 		},
 		wantErr: func(t assert.TestingT, err error, i ...interface{}) bool {
 			assert.Nil(t, err)
@@ -429,6 +431,7 @@ func Test_devopsOperator_UpdateJenkinsfile(t *testing.T) {
 			assert.Nil(t, err)
 			assert.Equal(t, "jenkinsfile", pip.Spec.Pipeline.Jenkinsfile)
 			assert.Equal(t, "raw", pip.Annotations[v1alpha3.PipelineJenkinsfileEditModeAnnoKey])
+			assert.Equal(t, "tester", pip.Annotations[v1alpha3.PipelineLastModifierAnnoKey])
 		},
 	}}
 	for _, tt := range tests {
@@ -439,7 +442,7 @@ func Test_devopsOperator_UpdateJenkinsfile(t *testing.T) {
 				ksclient:     tt.fields.ksclient,
 				context:      tt.fields.context,
 			}
-			tt.wantErr(t, d.UpdateJenkinsfile(tt.args.projectName, tt.args.pipelineName, tt.args.mode, tt.args.jenkinsfile), fmt.Sprintf("UpdateJenkinsfile(%v, %v, %v, %v)", tt.args.projectName, tt.args.pipelineName, tt.args.mode, tt.args.jenkinsfile))
+			tt.wantErr(t, d.UpdateJenkinsfile(tt.args.projectName, tt.args.pipelineName, tt.args.mode, tt.args.jenkinsfile, tt.args.modifier), fmt.Sprintf("UpdateJenkinsfile(%v, %v, %v, %v)", tt.args.projectName, tt.args.pipelineName, tt.args.mode, tt.args.jenkinsfile))
 			if tt.verify != nil {
 				tt.verify(t, tt.fields.ksclient)
 			}
@@ -0,0 +1,139 @@
+/*
+Copyright 2022 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package devops
+
+import (
+	"reflect"
+	"strings"
+
+	"github.com/pmezard/go-difflib/difflib"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"kubesphere.io/devops/pkg/api/devops/v1alpha3"
+)
+
+// PipelineConfig is the Jenkins job config.xml of a Pipeline
+type PipelineConfig struct {
+	Name      string `json:"name"`
+	ConfigXML string `json:"configXML"`
+}
+
+// PipelineDrift describes the out-of-band changes of a Pipeline which were made in Jenkins directly
+type PipelineDrift struct {
+	Drifted bool `json:"drifted"`
+	// Fields are the JSON paths of the spec fields which are different from the Jenkins job
+	Fields []string `json:"fields,omitempty"`
+	// Diff is the unified diff from the current config.xml in Jenkins to the desired one
+	Diff string `json:"diff,omitempty"`
+}
+
+// PreviewPipelineConfig returns the config.xml which would be pushed to Jenkins without changing anything.
+// The Jenkinsfile is kept as it is when updating a Pipeline, see also UpdatePipelineObj.
+func (d devopsOperator) PreviewPipelineConfig(projectName string, pipeline *v1alpha3.Pipeline, update bool) (*PipelineConfig, error) {
+	projectObj, err := d.ksclient.DevopsV1alpha3().DevOpsProjects().Get(d.context, projectName, metav1.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	ns := projectObj.Status.AdminNamespace
+	pipeline = pipeline.DeepCopy()
+	if update {
+		latestPipe, err := d.ksclient.DevopsV1alpha3().Pipelines(ns).Get(d.context, pipeline.GetName(), metav1.GetOptions{})
+		if err != nil {
+			return nil, err
+		}
+		if pipeline.Spec.Pipeline != nil && latestPipe.Spec.Pipeline != nil {
+			pipeline.Spec.Pipeline.Jenkinsfile = latestPipe.Spec.Pipeline.Jenkinsfile
+		}
+	}
+
+	config, err := d.devopsClient.PreviewProjectPipelineConfig(ns, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	return &PipelineConfig{Name: pipeline.GetName(), ConfigXML: config}, nil
+}
+
+// GetPipelineDrift compares the Pipeline with the job in Jenkins, then reports the out-of-band changes
+func (d devopsOperator) GetPipelineDrift(projectName, pipelineName string) (drift *PipelineDrift, err error) {
+	var pipeline, jenkinsPipeline, desiredPipeline *v1alpha3.Pipeline
+	if pipeline, err = d.GetPipelineObj(projectName, pipelineName); err != nil {
+		return
+	}
+	ns := pipeline.GetNamespace()
+	if jenkinsPipeline, err = d.devopsClient.GetProjectPipelineConfig(ns, pipelineName); err != nil {
+		return
+	}
+	// the fields which are not written to Jenkins are not taken as drifted
+	if desiredPipeline, err = d.devopsClient.GetProjectPipelineDesiredConfig(ns, pipeline); err != nil {
+		return
+	}
+
+	drift = &PipelineDrift{Fields: GetDriftedFields(&desiredPipeline.Spec, &jenkinsPipeline.Spec)}
+	if drift.Drifted = len(drift.Fields) > 0; !drift.Drifted {
+		return
+	}
+
+	var current, desired string
+	if current, err = d.devopsClient.GetProjectPipelineConfigXML(ns, pipelineName); err != nil {
+		return
+	}
+	if desired, err = d.devopsClient.PreviewProjectPipelineConfig(ns, pipeline); err != nil {
+		return
+	}
+	drift.Diff, err = difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+		A:        difflib.SplitLines(current),
+		B:        difflib.SplitLines(desired),
+		FromFile: "jenkins",
+		ToFile:   "desired",
+		Context:  3,
+	})
+	return
+}
+
+// GetDriftedFields returns the JSON paths of the fields which are different between the desired and the actual spec
+func GetDriftedFields(desired, actual *v1alpha3.PipelineSpec) []string {
+	if desired.Type != actual.Type {
+		return []string{"type"}
+	}
+	switch desired.Type {
+	case v1alpha3.NoScmPipelineType:
+		return diffFields("pipeline", reflect.ValueOf(desired.Pipeline), reflect.ValueOf(actual.Pipeline))
+	case v1alpha3.MultiBranchPipelineType:
+		return diffFields("multi_branch_pipeline", reflect.ValueOf(desired.MultiBranchPipeline),
+			reflect.ValueOf(actual.MultiBranchPipeline))
+	}
+	return nil
+}
+
+// diffFields compares the first level fields of two struct pointers
+func diffFields(prefix string, desired, actual reflect.Value) (fields []string) {
+	if desired.IsNil() || actual.IsNil() {
+		if desired.IsNil() != actual.IsNil() {
+			fields = []string{prefix}
+		}
+		return
+	}
+
+	desired, actual = desired.Elem(), actual.Elem()
+	for i := 0; i < desired.NumField(); i++ {
+		if !reflect.DeepEqual(desired.Field(i).Interface(), actual.Field(i).Interface()) {
+			name := strings.Split(desired.Type().Field(i).Tag.Get("json"), ",")[0]
+			fields = append(fields, prefix+"."+name)
+		}
+	}
+	return
+}
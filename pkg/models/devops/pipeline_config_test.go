@@ -0,0 +1,156 @@
+/*
+Copyright 2022 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package devops
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"kubesphere.io/devops/pkg/api/devops/v1alpha3"
+	fakeclientset "kubesphere.io/devops/pkg/client/clientset/versioned/fake"
+	"kubesphere.io/devops/pkg/client/devops/fake"
+)
+
+func newConfigTestPipeline(jenkinsfile string) *v1alpha3.Pipeline {
+	pip := &v1alpha3.Pipeline{}
+	pip.SetName("fake")
+	pip.SetNamespace("ns")
+	pip.Spec.Type = v1alpha3.NoScmPipelineType
+	pip.Spec.Pipeline = &v1alpha3.NoScmPipeline{Name: "fake", Jenkinsfile: jenkinsfile}
+	return pip
+}
+
+func Test_devopsOperator_PreviewPipelineConfig(t *testing.T) {
+	project := &v1alpha3.DevOpsProject{}
+	project.SetName("project")
+	project.Status.AdminNamespace = "ns"
+
+	pip := newConfigTestPipeline("echo 1")
+	newPip := newConfigTestPipeline("echo 2")
+	newPip.Spec.Pipeline.Description = "new"
+
+	d := devopsOperator{
+		devopsClient: fake.New("ns"),
+		ksclient:     fakeclientset.NewSimpleClientset(project.DeepCopy(), pip.DeepCopy()),
+		context:      context.TODO(),
+	}
+
+	config, err := d.PreviewPipelineConfig("project", newPip, false)
+	assert.Nil(t, err)
+	assert.Equal(t, "fake", config.Name)
+	assert.Contains(t, config.ConfigXML, "jenkinsfile: echo 2")
+	assert.Contains(t, config.ConfigXML, "description: new")
+
+	// the Jenkinsfile is not changed by updating a Pipeline
+	config, err = d.PreviewPipelineConfig("project", newPip, true)
+	assert.Nil(t, err)
+	assert.Contains(t, config.ConfigXML, "jenkinsfile: echo 1")
+	assert.Contains(t, config.ConfigXML, "description: new")
+	assert.Equal(t, "echo 2", newPip.Spec.Pipeline.Jenkinsfile)
+
+	missingPip := newPip.DeepCopy()
+	missingPip.SetName("missing")
+	_, err = d.PreviewPipelineConfig("project", missingPip, true)
+	assert.NotNil(t, err)
+	_, err = d.PreviewPipelineConfig("not-found", newPip, false)
+	assert.NotNil(t, err)
+}
+
+func Test_devopsOperator_GetPipelineDrift(t *testing.T) {
+	project := &v1alpha3.DevOpsProject{}
+	project.SetName("project")
+	project.Status.AdminNamespace = "ns"
+	pip := newConfigTestPipeline("echo 1")
+
+	tests := []struct {
+		name            string
+		jenkinsPipeline *v1alpha3.Pipeline
+		wantErr         bool
+		verify          func(t *testing.T, drift *PipelineDrift)
+	}{{
+		name:    "not found in Jenkins",
+		wantErr: true,
+	}, {
+		name:            "no drift",
+		jenkinsPipeline: newConfigTestPipeline("echo 1"),
+		verify: func(t *testing.T, drift *PipelineDrift) {
+			assert.False(t, drift.Drifted)
+			assert.Empty(t, drift.Fields)
+			assert.Empty(t, drift.Diff)
+		},
+	}, {
+		name: "the description is not written to Jenkins",
+		jenkinsPipeline: func() *v1alpha3.Pipeline {
+			pipeline := newConfigTestPipeline("echo 1")
+			pipeline.Spec.Pipeline.Description = "changed in Jenkins"
+			return pipeline
+		}(),
+		verify: func(t *testing.T, drift *PipelineDrift) {
+			assert.False(t, drift.Drifted)
+			assert.Empty(t, drift.Fields)
+		},
+	}, {
+		name:            "the Jenkinsfile was changed in Jenkins",
+		jenkinsPipeline: newConfigTestPipeline("echo 2"),
+		verify: func(t *testing.T, drift *PipelineDrift) {
+			assert.True(t, drift.Drifted)
+			assert.Equal(t, []string{"pipeline.jenkinsfile"}, drift.Fields)
+			assert.Contains(t, drift.Diff, "-  jenkinsfile: echo 2\n+  jenkinsfile: echo 1\n")
+		},
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			devopsClient := fake.New("ns")
+			if tt.jenkinsPipeline != nil {
+				devopsClient = fake.NewWithPipelines("ns", tt.jenkinsPipeline)
+			}
+			d := devopsOperator{
+				devopsClient: devopsClient,
+				ksclient:     fakeclientset.NewSimpleClientset(project.DeepCopy(), pip.DeepCopy()),
+				context:      context.TODO(),
+			}
+			drift, err := d.GetPipelineDrift("project", "fake")
+			if tt.wantErr {
+				assert.NotNil(t, err)
+				return
+			}
+			assert.Nil(t, err)
+			tt.verify(t, drift)
+		})
+	}
+}
+
+func TestGetDriftedFields(t *testing.T) {
+	noScm := newConfigTestPipeline("echo 1").Spec
+	changed := *noScm.DeepCopy()
+	changed.Pipeline.Jenkinsfile = "echo 2"
+	changed.Pipeline.DisableConcurrent = true
+
+	assert.Empty(t, GetDriftedFields(&noScm, noScm.DeepCopy()))
+	assert.Equal(t, []string{"pipeline.disable_concurrent", "pipeline.jenkinsfile"}, GetDriftedFields(&noScm, &changed))
+	assert.Equal(t, []string{"type"}, GetDriftedFields(&noScm, &v1alpha3.PipelineSpec{}))
+	assert.Equal(t, []string{"pipeline"}, GetDriftedFields(&noScm, &v1alpha3.PipelineSpec{Type: v1alpha3.NoScmPipelineType}))
+
+	multiBranch := v1alpha3.PipelineSpec{
+		Type:                v1alpha3.MultiBranchPipelineType,
+		MultiBranchPipeline: &v1alpha3.MultiBranchPipeline{Name: "fake", ScriptPath: "Jenkinsfile"},
+	}
+	changed = *multiBranch.DeepCopy()
+	changed.MultiBranchPipeline.ScriptPath = "ci/Jenkinsfile"
+	assert.Equal(t, []string{"multi_branch_pipeline.script_path"}, GetDriftedFields(&multiBranch, &changed))
+}
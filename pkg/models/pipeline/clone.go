@@ -0,0 +1,262 @@
+/*
+Copyright 2022 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pipeline
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"regexp"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"kubesphere.io/devops/pkg/api/devops/v1alpha3"
+)
+
+// CloneOptions are the options of copying a Pipeline, the empty fields are kept as they are
+type CloneOptions struct {
+	// Name is the name of the copy, it is required
+	Name string `json:"name"`
+	// Namespace is the DevOps project of the copy, it is the same as the source Pipeline by default
+	Namespace string `json:"namespace,omitempty"`
+	// Branch is the branch of the pipeline-as-code file, or the only branch which the multi-branch Pipeline builds
+	Branch string `json:"branch,omitempty"`
+	// Parameters are the default values of the parameters, the key is the parameter name
+	Parameters    map[string]string       `json:"parameters,omitempty"`
+	TimerTrigger  *v1alpha3.TimerTrigger  `json:"timer_trigger,omitempty"`
+	RemoteTrigger *v1alpha3.RemoteTrigger `json:"remote_trigger,omitempty"`
+	Description   *string                 `json:"description,omitempty"`
+}
+
+// Clone returns a copy of the Pipeline with the overrides, the copy is not created.
+// The trigger tokens are regenerated, the source Pipeline cannot be triggered by the ones of the copy and vice versa.
+func Clone(source *v1alpha3.Pipeline, options CloneOptions) (pipeline *v1alpha3.Pipeline, err error) {
+	if options.Name == "" {
+		return nil, fmt.Errorf("the name of the copy is required")
+	}
+	namespace := options.Namespace
+	if namespace == "" {
+		namespace = source.Namespace
+	}
+	if namespace == source.Namespace && options.Name == source.Name {
+		return nil, fmt.Errorf("the name of the copy must be different from the source Pipeline")
+	}
+
+	pipeline = &v1alpha3.Pipeline{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        options.Name,
+			Namespace:   namespace,
+			Labels:      copyMap(source.Labels),
+			Annotations: copyMap(source.Annotations),
+		},
+		Spec: *source.Spec.DeepCopy(),
+	}
+	for _, key := range v1alpha3.TransientAnnotations {
+		delete(pipeline.Annotations, key)
+	}
+
+	if pipeline.IsPipelineAsCode() &&
+		(len(options.Parameters) > 0 || options.TimerTrigger != nil || options.RemoteTrigger != nil || options.Description != nil) {
+		return nil, fmt.Errorf("only the branch could be overridden, " +
+			"the spec of a pipeline-as-code Pipeline is synchronized from the git repository")
+	}
+	if pipeline.IsPipelineAsCode() && options.Branch != "" {
+		pipeline.Annotations[v1alpha3.PipelineAsCodeBranchAnnoKey] = options.Branch
+		options.Branch = ""
+	}
+
+	switch pipeline.Spec.Type {
+	case v1alpha3.NoScmPipelineType:
+		err = overrideNoScmPipeline(pipeline.Spec.Pipeline, options)
+	case v1alpha3.MultiBranchPipelineType:
+		err = overrideMultiBranchPipeline(pipeline.Spec.MultiBranchPipeline, options)
+	default:
+		err = fmt.Errorf("unsupported Pipeline type: %s", pipeline.Spec.Type)
+	}
+	if err != nil {
+		pipeline = nil
+	}
+	return
+}
+
+func overrideNoScmPipeline(noScm *v1alpha3.NoScmPipeline, options CloneOptions) error {
+	if noScm == nil {
+		return fmt.Errorf("the Pipeline does not have a spec of type %s", v1alpha3.NoScmPipelineType)
+	}
+	if options.Branch != "" {
+		return fmt.Errorf("the Pipeline does not have a SCM branch")
+	}
+
+	noScm.Name = options.Name
+	for name, value := range options.Parameters {
+		found := false
+		for i := range noScm.Parameters {
+			if noScm.Parameters[i].Name == name {
+				noScm.Parameters[i].DefaultValue = value
+				found = true
+				break
+			}
+		}
+		if !found {
+			return fmt.Errorf("parameter %s is not defined", name)
+		}
+	}
+	if options.TimerTrigger != nil {
+		noScm.TimerTrigger = options.TimerTrigger
+	}
+	if options.RemoteTrigger != nil {
+		noScm.RemoteTrigger = options.RemoteTrigger
+	} else if noScm.RemoteTrigger != nil && noScm.RemoteTrigger.Token != "" {
+		var err error
+		if noScm.RemoteTrigger.Token, err = NewTriggerToken(); err != nil {
+			return err
+		}
+	}
+	if noScm.GenericWebhook != nil && noScm.GenericWebhook.Token != "" {
+		var err error
+		if noScm.GenericWebhook.Token, err = NewTriggerToken(); err != nil {
+			return err
+		}
+	}
+	if options.Description != nil {
+		noScm.Description = *options.Description
+	}
+	return nil
+}
+
+// NewTriggerToken returns a random token of the remote trigger or the generic webhook
+func NewTriggerToken() (string, error) {
+	data := make([]byte, 16)
+	if _, err := rand.Read(data); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(data), nil
+}
+
+func overrideMultiBranchPipeline(multiBranch *v1alpha3.MultiBranchPipeline, options CloneOptions) error {
+	if multiBranch == nil {
+		return fmt.Errorf("the Pipeline does not have a spec of type %s", v1alpha3.MultiBranchPipelineType)
+	}
+	if len(options.Parameters) > 0 || options.RemoteTrigger != nil {
+		return fmt.Errorf("parameters and remote_trigger are not supported by the multi-branch Pipeline, " +
+			"please define them in the Jenkinsfile")
+	}
+	if options.Branch != "" {
+		if err := setBranchFilter(multiBranch, options.Branch); err != nil {
+			return err
+		}
+	}
+
+	multiBranch.Name = options.Name
+	if options.TimerTrigger != nil {
+		multiBranch.TimerTrigger = options.TimerTrigger
+	}
+	if options.Description != nil {
+		multiBranch.Description = *options.Description
+	}
+	return nil
+}
+
+// setBranchFilter makes the multi-branch Pipeline build the given branch only
+func setBranchFilter(multiBranch *v1alpha3.MultiBranchPipeline, branch string) error {
+	filter := regexp.QuoteMeta(branch)
+	switch multiBranch.SourceType {
+	case v1alpha3.SourceTypeGit:
+		if multiBranch.GitSource != nil {
+			multiBranch.GitSource.RegexFilter = filter
+			return nil
+		}
+	case v1alpha3.SourceTypeGithub:
+		if multiBranch.GitHubSource != nil {
+			multiBranch.GitHubSource.RegexFilter = filter
+			return nil
+		}
+	case v1alpha3.SourceTypeGitlab:
+		if multiBranch.GitlabSource != nil {
+			multiBranch.GitlabSource.RegexFilter = filter
+			return nil
+		}
+	case v1alpha3.SourceTypeBitbucket:
+		if multiBranch.BitbucketServerSource != nil {
+			multiBranch.BitbucketServerSource.RegexFilter = filter
+			return nil
+		}
+	case v1alpha3.SourceTypeAzureDevOps:
+		if multiBranch.AzureDevOpsSource != nil {
+			multiBranch.AzureDevOpsSource.RegexFilter = filter
+			return nil
+		}
+	case v1alpha3.SourceTypeGitee:
+		if multiBranch.GiteeSource != nil {
+			multiBranch.GiteeSource.RegexFilter = filter
+			return nil
+		}
+	case v1alpha3.SourceTypeSVN:
+		if multiBranch.SvnSource != nil {
+			multiBranch.SvnSource.Includes = branch
+			multiBranch.SvnSource.Excludes = ""
+			return nil
+		}
+	}
+	return fmt.Errorf("cannot override the branch of the source type %s", multiBranch.SourceType)
+}
+
+func copyMap(source map[string]string) (target map[string]string) {
+	target = make(map[string]string, len(source))
+	for key, value := range source {
+		target[key] = value
+	}
+	return
+}
+
+// GetSCMCredentialID returns the ID of the credential which the SCM source of a multi-branch Pipeline takes
+func GetSCMCredentialID(multiBranch *v1alpha3.MultiBranchPipeline) string {
+	switch multiBranch.SourceType {
+	case v1alpha3.SourceTypeGit:
+		if multiBranch.GitSource != nil {
+			return multiBranch.GitSource.CredentialId
+		}
+	case v1alpha3.SourceTypeGithub:
+		if multiBranch.GitHubSource != nil {
+			return multiBranch.GitHubSource.CredentialId
+		}
+	case v1alpha3.SourceTypeGitlab:
+		if multiBranch.GitlabSource != nil {
+			return multiBranch.GitlabSource.CredentialId
+		}
+	case v1alpha3.SourceTypeBitbucket:
+		if multiBranch.BitbucketServerSource != nil {
+			return multiBranch.BitbucketServerSource.CredentialId
+		}
+	case v1alpha3.SourceTypeAzureDevOps:
+		if multiBranch.AzureDevOpsSource != nil {
+			return multiBranch.AzureDevOpsSource.CredentialId
+		}
+	case v1alpha3.SourceTypeGitee:
+		if multiBranch.GiteeSource != nil {
+			return multiBranch.GiteeSource.CredentialId
+		}
+	case v1alpha3.SourceTypeSVN:
+		if multiBranch.SvnSource != nil {
+			return multiBranch.SvnSource.CredentialId
+		}
+	case v1alpha3.SourceTypeSingleSVN:
+		if multiBranch.SingleSvnSource != nil {
+			return multiBranch.SingleSvnSource.CredentialId
+		}
+	}
+	return ""
+}
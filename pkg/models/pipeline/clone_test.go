@@ -0,0 +1,239 @@
+/*
+Copyright 2022 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pipeline
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"kubesphere.io/devops/pkg/api/devops/v1alpha3"
+	"kubesphere.io/devops/pkg/constants"
+)
+
+func newCloneSource() *v1alpha3.Pipeline {
+	source := &v1alpha3.Pipeline{}
+	source.SetNamespace("ns")
+	source.SetName("source")
+	source.SetLabels(map[string]string{"app": "demo"})
+	source.SetAnnotations(map[string]string{
+		v1alpha3.PipelineJenkinsfileEditModeAnnoKey: v1alpha3.PipelineJenkinsfileEditModeJSON,
+		v1alpha3.PipelineJenkinsfileValueAnnoKey:    "{}",
+		v1alpha3.PipelineJenkinsMetadataAnnoKey:     "{}",
+		v1alpha3.PipelineJenkinsBranchesAnnoKey:     "[]",
+		v1alpha3.PipelineSyncStatusAnnoKey:          "successful",
+		v1alpha3.PipelineRevisionAnnoKey:            "3",
+		constants.CreatorAnnotationKey:              "admin",
+	})
+	source.Spec.Type = v1alpha3.NoScmPipelineType
+	source.Spec.Pipeline = &v1alpha3.NoScmPipeline{
+		Name:        "source",
+		Description: "old",
+		Parameters:  []v1alpha3.ParameterDefinition{{Name: "env", DefaultValue: "test", Type: "string"}},
+		Jenkinsfile: "echo 1",
+	}
+	return source
+}
+
+func newMultiBranchCloneSource() *v1alpha3.Pipeline {
+	source := newCloneSource()
+	source.Spec = v1alpha3.PipelineSpec{
+		Type: v1alpha3.MultiBranchPipelineType,
+		MultiBranchPipeline: &v1alpha3.MultiBranchPipeline{
+			Name:         "source",
+			SourceType:   v1alpha3.SourceTypeGithub,
+			GitHubSource: &v1alpha3.GithubSource{Owner: "linuxsuren", Repo: "tools", RegexFilter: ".*"},
+			ScriptPath:   "Jenkinsfile",
+		},
+	}
+	return source
+}
+
+func TestClone(t *testing.T) {
+	description := "new"
+	pacSource := newCloneSource()
+	pacSource.Annotations[v1alpha3.PipelineAsCodeGitRepoAnnoKey] = "repo"
+	pacSource.Annotations[v1alpha3.PipelineAsCodeRevisionAnnoKey] = "abc"
+
+	tests := []struct {
+		name    string
+		source  *v1alpha3.Pipeline
+		options CloneOptions
+		wantErr bool
+		verify  func(t *testing.T, pipeline *v1alpha3.Pipeline)
+	}{{
+		name:    "without a name",
+		source:  newCloneSource(),
+		wantErr: true,
+	}, {
+		name:    "same name in the same namespace",
+		source:  newCloneSource(),
+		options: CloneOptions{Name: "source"},
+		wantErr: true,
+	}, {
+		name:    "same name in another namespace",
+		source:  newCloneSource(),
+		options: CloneOptions{Name: "source", Namespace: "other"},
+		verify: func(t *testing.T, pipeline *v1alpha3.Pipeline) {
+			assert.Equal(t, "other", pipeline.Namespace)
+			assert.Equal(t, "source", pipeline.Name)
+		},
+	}, {
+		name:   "copy with overrides",
+		source: newCloneSource(),
+		options: CloneOptions{
+			Name:          "copy",
+			Parameters:    map[string]string{"env": "prod"},
+			TimerTrigger:  &v1alpha3.TimerTrigger{Cron: "H * * * *"},
+			RemoteTrigger: &v1alpha3.RemoteTrigger{Token: "token"},
+			Description:   &description,
+		},
+		verify: func(t *testing.T, pipeline *v1alpha3.Pipeline) {
+			assert.Equal(t, "ns", pipeline.Namespace)
+			assert.Equal(t, "copy", pipeline.Name)
+			assert.Empty(t, pipeline.ResourceVersion)
+			assert.Equal(t, map[string]string{"app": "demo"}, pipeline.Labels)
+			assert.Equal(t, map[string]string{
+				v1alpha3.PipelineJenkinsfileEditModeAnnoKey: v1alpha3.PipelineJenkinsfileEditModeJSON,
+				v1alpha3.PipelineJenkinsfileValueAnnoKey:    "{}",
+			}, pipeline.Annotations)
+
+			noScm := pipeline.Spec.Pipeline
+			assert.Equal(t, "copy", noScm.Name)
+			assert.Equal(t, "new", noScm.Description)
+			assert.Equal(t, "prod", noScm.Parameters[0].DefaultValue)
+			assert.Equal(t, "H * * * *", noScm.TimerTrigger.Cron)
+			assert.Equal(t, "token", noScm.RemoteTrigger.Token)
+			assert.Equal(t, "echo 1", noScm.Jenkinsfile)
+		},
+	}, {
+		name: "regenerate the trigger tokens",
+		source: func() *v1alpha3.Pipeline {
+			source := newCloneSource()
+			source.Spec.Pipeline.RemoteTrigger = &v1alpha3.RemoteTrigger{Token: "remote"}
+			source.Spec.Pipeline.GenericWebhook = &v1alpha3.GenericWebhook{Enable: true, Token: "webhook"}
+			return source
+		}(),
+		options: CloneOptions{Name: "copy"},
+		verify: func(t *testing.T, pipeline *v1alpha3.Pipeline) {
+			noScm := pipeline.Spec.Pipeline
+			assert.NotEmpty(t, noScm.RemoteTrigger.Token)
+			assert.NotEqual(t, "remote", noScm.RemoteTrigger.Token)
+			assert.NotEmpty(t, noScm.GenericWebhook.Token)
+			assert.NotEqual(t, "webhook", noScm.GenericWebhook.Token)
+			assert.True(t, noScm.GenericWebhook.Enable)
+		},
+	}, {
+		name:    "unknown parameter",
+		source:  newCloneSource(),
+		options: CloneOptions{Name: "copy", Parameters: map[string]string{"unknown": "value"}},
+		wantErr: true,
+	}, {
+		name:    "branch of a Pipeline without SCM",
+		source:  newCloneSource(),
+		options: CloneOptions{Name: "copy", Branch: "main"},
+		wantErr: true,
+	}, {
+		name:    "branch of a pipeline-as-code Pipeline",
+		source:  pacSource,
+		options: CloneOptions{Name: "copy", Branch: "release"},
+		verify: func(t *testing.T, pipeline *v1alpha3.Pipeline) {
+			assert.Equal(t, "release", pipeline.Annotations[v1alpha3.PipelineAsCodeBranchAnnoKey])
+			assert.Equal(t, "repo", pipeline.Annotations[v1alpha3.PipelineAsCodeGitRepoAnnoKey])
+			assert.Empty(t, pipeline.Annotations[v1alpha3.PipelineAsCodeRevisionAnnoKey])
+		},
+	}, {
+		name:    "override the spec of a pipeline-as-code Pipeline",
+		source:  pacSource,
+		options: CloneOptions{Name: "copy", Description: &description},
+		wantErr: true,
+	}, {
+		name:   "branch of a multi-branch Pipeline",
+		source: newMultiBranchCloneSource(),
+		options: CloneOptions{
+			Name:         "copy",
+			Branch:       "release-1.0",
+			TimerTrigger: &v1alpha3.TimerTrigger{Interval: "60000"},
+		},
+		verify: func(t *testing.T, pipeline *v1alpha3.Pipeline) {
+			multiBranch := pipeline.Spec.MultiBranchPipeline
+			assert.Equal(t, "copy", multiBranch.Name)
+			assert.Equal(t, `release-1\.0`, multiBranch.GitHubSource.RegexFilter)
+			assert.Equal(t, "60000", multiBranch.TimerTrigger.Interval)
+		},
+	}, {
+		name:    "parameters of a multi-branch Pipeline",
+		source:  newMultiBranchCloneSource(),
+		options: CloneOptions{Name: "copy", Parameters: map[string]string{"env": "prod"}},
+		wantErr: true,
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			source := tt.source.DeepCopy()
+			pipeline, err := Clone(tt.source, tt.options)
+			assert.Equal(t, source, tt.source, "the source Pipeline should not be changed")
+			if tt.wantErr {
+				assert.NotNil(t, err)
+				assert.Nil(t, pipeline)
+				return
+			}
+			assert.Nil(t, err)
+			tt.verify(t, pipeline)
+		})
+	}
+}
+
+func Test_setBranchFilter(t *testing.T) {
+	svn := &v1alpha3.MultiBranchPipeline{
+		SourceType: v1alpha3.SourceTypeSVN,
+		SvnSource:  &v1alpha3.SvnSource{Includes: "trunk,branches/*", Excludes: "branches/old"},
+	}
+	assert.Nil(t, setBranchFilter(svn, "branches/new"))
+	assert.Equal(t, "branches/new", svn.SvnSource.Includes)
+	assert.Empty(t, svn.SvnSource.Excludes)
+
+	assert.NotNil(t, setBranchFilter(&v1alpha3.MultiBranchPipeline{SourceType: v1alpha3.SourceTypeSingleSVN}, "main"))
+	assert.NotNil(t, setBranchFilter(&v1alpha3.MultiBranchPipeline{SourceType: v1alpha3.SourceTypeGit}, "main"))
+}
+
+func TestGetSCMCredentialID(t *testing.T) {
+	tests := []struct {
+		name        string
+		multiBranch *v1alpha3.MultiBranchPipeline
+		want        string
+	}{{
+		name: "git",
+		multiBranch: &v1alpha3.MultiBranchPipeline{SourceType: v1alpha3.SourceTypeGit,
+			GitSource: &v1alpha3.GitSource{CredentialId: "git"}},
+		want: "git",
+	}, {
+		name: "GitHub",
+		multiBranch: &v1alpha3.MultiBranchPipeline{SourceType: v1alpha3.SourceTypeGithub,
+			GitHubSource: &v1alpha3.GithubSource{CredentialId: "github"}},
+		want: "github",
+	}, {
+		name:        "without the source",
+		multiBranch: &v1alpha3.MultiBranchPipeline{SourceType: v1alpha3.SourceTypeGitlab},
+	}, {
+		name:        "unknown source type",
+		multiBranch: &v1alpha3.MultiBranchPipeline{SourceType: "unknown"},
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, GetSCMCredentialID(tt.multiBranch))
+		})
+	}
+}
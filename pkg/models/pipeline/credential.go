@@ -0,0 +1,250 @@
+/*
+Copyright 2022 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pipeline
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"kubesphere.io/devops/pkg/api/devops/v1alpha3"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// CredentialReference is a credential which is referenced by a Pipeline
+type CredentialReference struct {
+	// ID is the credential ID, it is the name of the credential secret in the DevOps project
+	ID string `json:"id"`
+	// Source describes where the credential is referenced, such as a withCredentials binding or the SCM source
+	Source string `json:"source"`
+	// Types are the expected credential types, any type is fine if it is empty
+	Types []v1.SecretType `json:"types,omitempty"`
+}
+
+// CredentialSourceSCMPrefix is the prefix of the source of a credential which is referenced by the SCM source
+const CredentialSourceSCMPrefix = "scm."
+
+// CredentialCheckStatus is the result of checking a credential reference
+type CredentialCheckStatus string
+
+const (
+	// CredentialFound indicates the credential exists with an expected type
+	CredentialFound CredentialCheckStatus = "found"
+	// CredentialMissing indicates the credential does not exist in the DevOps project
+	CredentialMissing CredentialCheckStatus = "missing"
+	// CredentialTypeMismatch indicates the credential exists but its type is not expected
+	CredentialTypeMismatch CredentialCheckStatus = "mismatched"
+)
+
+// CredentialCheckResult is the result of checking a credential reference
+type CredentialCheckResult struct {
+	CredentialReference `json:",inline"`
+	Status              CredentialCheckStatus `json:"status"`
+	// Type is the actual type of the credential
+	Type    v1.SecretType `json:"type,omitempty"`
+	Message string        `json:"message,omitempty"`
+}
+
+// CredentialCheckReport is the result of checking all the credentials referenced by a Pipeline
+type CredentialCheckReport struct {
+	// Resolved indicates if all the credentials exist with the expected types
+	Resolved bool                    `json:"resolved"`
+	Results  []CredentialCheckResult `json:"results"`
+}
+
+var (
+	basicAuthTypes = []v1.SecretType{v1alpha3.SecretTypeBasicAuth}
+	gitAuthTypes   = []v1.SecretType{v1alpha3.SecretTypeBasicAuth, v1alpha3.SecretTypeSSHAuth}
+
+	// bindingTypes are the expected credential types of the withCredentials bindings
+	bindingTypes = map[string][]v1.SecretType{
+		"usernamePassword":      basicAuthTypes,
+		"usernameColonPassword": basicAuthTypes,
+		"gitUsernamePassword":   basicAuthTypes,
+		"string":                {v1alpha3.SecretTypeSecretText},
+		"sshUserPrivateKey":     {v1alpha3.SecretTypeSSHAuth},
+		"gitSshPrivateKey":      {v1alpha3.SecretTypeSSHAuth},
+		"kubeconfigContent":     {v1alpha3.SecretTypeKubeConfig},
+		"kubeconfigFile":        {v1alpha3.SecretTypeKubeConfig},
+	}
+
+	// scmSourceTypes are the expected credential types of the SCM sources, the others take basic-auth credentials
+	scmSourceTypes = map[string][]v1.SecretType{
+		v1alpha3.SourceTypeGit:       gitAuthTypes,
+		v1alpha3.SourceTypeSVN:       gitAuthTypes,
+		v1alpha3.SourceTypeSingleSVN: gitAuthTypes,
+		v1alpha3.SourceTypeGitlab:    {v1alpha3.SecretTypeBasicAuth, v1alpha3.SecretTypeSecretText},
+	}
+
+	bindingPattern       = regexp.MustCompile(`\b(usernamePassword|usernameColonPassword|gitUsernamePassword|string|sshUserPrivateKey|gitSshPrivateKey|kubeconfigContent|kubeconfigFile)\s*\(([^()]*)\)`)
+	gitStepPattern       = regexp.MustCompile(`\bgit\b[^\n]*?credentialsId\s*:\s*['"]([^'"]*)['"]`)
+	credentialIDPattern  = regexp.MustCompile(`credentialsId\s*:\s*['"]([^'"]*)['"]`)
+	credentialsFnPattern = regexp.MustCompile(`\bcredentials\s*\(\s*['"]([^'"]*)['"]\s*\)`)
+)
+
+// ExtractCredentialReferences returns the credentials which are referenced by the Pipeline.
+// The credentials are taken from the Jenkinsfile, including the steps rendered from the step templates, and the SCM source.
+// The credential IDs which come from variables cannot be resolved, they are ignored.
+func ExtractCredentialReferences(pipeline *v1alpha3.Pipeline) (references []CredentialReference) {
+	collector := &credentialCollector{}
+	if noScm := pipeline.Spec.Pipeline; noScm != nil {
+		collector.fromJenkinsfile(noScm.Jenkinsfile)
+	}
+	// the JSON format of the Jenkinsfile might not be converted yet
+	collector.fromJenkinsfile(pipeline.Annotations[v1alpha3.PipelineJenkinsfileValueAnnoKey])
+	if multiBranch := pipeline.Spec.MultiBranchPipeline; multiBranch != nil {
+		collector.fromSCMSource(multiBranch)
+	}
+	return collector.references
+}
+
+type credentialCollector struct {
+	references []CredentialReference
+}
+
+func (c *credentialCollector) add(id, source string, types []v1.SecretType) {
+	if id == "" || strings.Contains(id, "$") {
+		return
+	}
+	for _, reference := range c.references {
+		if reference.ID == id && reference.Source == source {
+			return
+		}
+	}
+	c.references = append(c.references, CredentialReference{ID: id, Source: source, Types: types})
+}
+
+func (c *credentialCollector) fromJenkinsfile(jenkinsfile string) {
+	if jenkinsfile == "" {
+		return
+	}
+	// the positions of the credential IDs which are already collected
+	collected := map[int]bool{}
+	for _, match := range bindingPattern.FindAllStringSubmatchIndex(jenkinsfile, -1) {
+		name := jenkinsfile[match[2]:match[3]]
+		args := jenkinsfile[match[4]:match[5]]
+		if idMatch := credentialIDPattern.FindStringSubmatchIndex(args); idMatch != nil {
+			collected[match[4]+idMatch[2]] = true
+			c.add(args[idMatch[2]:idMatch[3]], "withCredentials."+name, bindingTypes[name])
+		}
+	}
+	for _, match := range gitStepPattern.FindAllStringSubmatchIndex(jenkinsfile, -1) {
+		if !collected[match[2]] {
+			collected[match[2]] = true
+			c.add(jenkinsfile[match[2]:match[3]], "git", gitAuthTypes)
+		}
+	}
+	for _, match := range credentialIDPattern.FindAllStringSubmatchIndex(jenkinsfile, -1) {
+		if !collected[match[2]] {
+			c.add(jenkinsfile[match[2]:match[3]], "credentialsId", nil)
+		}
+	}
+	for _, match := range credentialsFnPattern.FindAllStringSubmatch(jenkinsfile, -1) {
+		c.add(match[1], "credentials", nil)
+	}
+}
+
+func (c *credentialCollector) fromSCMSource(multiBranch *v1alpha3.MultiBranchPipeline) {
+	types, ok := scmSourceTypes[multiBranch.SourceType]
+	if !ok {
+		types = basicAuthTypes
+	}
+	c.add(GetSCMCredentialID(multiBranch), CredentialSourceSCMPrefix+multiBranch.SourceType, types)
+}
+
+// CheckCredentials checks the credentials referenced by the Pipeline against the credentials of its DevOps project
+func CheckCredentials(ctx context.Context, reader client.Reader, pipeline *v1alpha3.Pipeline) (*CredentialCheckReport, error) {
+	report := &CredentialCheckReport{Resolved: true, Results: []CredentialCheckResult{}}
+	for _, reference := range ExtractCredentialReferences(pipeline) {
+		result := CredentialCheckResult{CredentialReference: reference, Status: CredentialFound}
+
+		secret := &v1.Secret{}
+		err := reader.Get(ctx, client.ObjectKey{Namespace: pipeline.Namespace, Name: reference.ID}, secret)
+		switch {
+		case apierrors.IsNotFound(err):
+			result.Status = CredentialMissing
+			result.Message = fmt.Sprintf("credential %s is not found in DevOps project %s", reference.ID, pipeline.Namespace)
+		case err != nil:
+			return nil, err
+		case !isCredentialType(secret.Type):
+			result.Status = CredentialMissing
+			result.Type = secret.Type
+			result.Message = fmt.Sprintf("secret %s is not a DevOps credential", reference.ID)
+		default:
+			result.Type = secret.Type
+			if !containsType(reference.Types, secret.Type) {
+				result.Status = CredentialTypeMismatch
+				result.Message = fmt.Sprintf("the type of credential %s is %s, expected %s",
+					reference.ID, secret.Type, joinTypes(reference.Types))
+			}
+		}
+
+		report.Resolved = report.Resolved && result.Status == CredentialFound
+		report.Results = append(report.Results, result)
+	}
+	sort.SliceStable(report.Results, func(i, j int) bool {
+		return report.Results[i].ID < report.Results[j].ID
+	})
+	return report, nil
+}
+
+// Condition returns the condition of the Pipeline according to the report
+func (r *CredentialCheckReport) Condition() v1alpha3.Condition {
+	condition := v1alpha3.Condition{
+		Type:   v1alpha3.PipelineConditionCredentialsResolved,
+		Status: v1alpha3.ConditionTrue,
+		Reason: "Resolved",
+	}
+	var problems []string
+	for _, result := range r.Results {
+		if result.Status != CredentialFound {
+			problems = append(problems, result.Message)
+		}
+	}
+	if len(problems) > 0 {
+		condition.Status = v1alpha3.ConditionFalse
+		condition.Reason = "Unresolved"
+		condition.Message = strings.Join(problems, "; ")
+	}
+	return condition
+}
+
+func isCredentialType(secretType v1.SecretType) bool {
+	return containsType(v1alpha3.GetSupportedCredentialTypes(), secretType)
+}
+
+// containsType returns true if the types are empty, or the type is one of them
+func containsType(types []v1.SecretType, secretType v1.SecretType) bool {
+	for _, item := range types {
+		if item == secretType {
+			return true
+		}
+	}
+	return len(types) == 0
+}
+
+func joinTypes(types []v1.SecretType) string {
+	items := make([]string, len(types))
+	for i := range types {
+		items[i] = string(types[i])
+	}
+	return strings.Join(items, " or ")
+}
@@ -0,0 +1,128 @@
+/*
+Copyright 2022 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pipeline
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	"kubesphere.io/devops/pkg/api/devops/v1alpha3"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+const credentialJenkinsfile = `pipeline {
+  agent any
+  environment {
+    TOKEN = credentials('token')
+  }
+  stages {
+    stage('checkout') {
+      steps {
+        git url: 'https://github.com/kubesphere/ks-devops', credentialsId: 'github', branch: 'master'
+        checkout([$class: 'GitSCM', userRemoteConfigs: [[url: 'https://github.com/kubesphere/ks-devops', credentialsId: 'scm']]])
+      }
+    }
+    stage('deploy') {
+      steps {
+        withCredentials([usernamePassword(passwordVariable: 'PASS', usernameVariable: 'USER', credentialsId: 'dockerhub'),
+          kubeconfigContent(credentialsId: 'kubeconfig', variable: 'KUBECONFIG_CONTENT'),
+          string(credentialsId: "${params.TOKEN_ID}", variable: 'SECRET')]) {
+          sh 'echo deploy'
+        }
+      }
+    }
+  }
+}`
+
+func TestExtractCredentialReferences(t *testing.T) {
+	noScm := &v1alpha3.Pipeline{}
+	noScm.Spec.Type = v1alpha3.NoScmPipelineType
+	noScm.Spec.Pipeline = &v1alpha3.NoScmPipeline{Jenkinsfile: credentialJenkinsfile}
+	// the step rendered from a step template which wraps the credential
+	noScm.SetAnnotations(map[string]string{
+		v1alpha3.PipelineJenkinsfileValueAnnoKey: `{"value": "${[sshUserPrivateKey(credentialsId: 'ssh', keyFileVariable : 'KEYFILEVARIABLE')]}", "name": "withCredentials"}`,
+	})
+	assert.Equal(t, []CredentialReference{
+		{ID: "dockerhub", Source: "withCredentials.usernamePassword", Types: basicAuthTypes},
+		{ID: "kubeconfig", Source: "withCredentials.kubeconfigContent", Types: []v1.SecretType{v1alpha3.SecretTypeKubeConfig}},
+		{ID: "github", Source: "git", Types: gitAuthTypes},
+		{ID: "scm", Source: "credentialsId"},
+		{ID: "token", Source: "credentials"},
+		{ID: "ssh", Source: "withCredentials.sshUserPrivateKey", Types: []v1.SecretType{v1alpha3.SecretTypeSSHAuth}},
+	}, ExtractCredentialReferences(noScm))
+
+	multiBranch := newMultiBranchCloneSource()
+	multiBranch.Annotations = nil
+	multiBranch.Spec.MultiBranchPipeline.GitHubSource.CredentialId = "github"
+	assert.Equal(t, []CredentialReference{
+		{ID: "github", Source: "scm.github", Types: basicAuthTypes},
+	}, ExtractCredentialReferences(multiBranch))
+
+	assert.Empty(t, ExtractCredentialReferences(&v1alpha3.Pipeline{}))
+}
+
+func TestCheckCredentials(t *testing.T) {
+	schema, err := v1alpha3.SchemeBuilder.Register().Build()
+	assert.Nil(t, err)
+	assert.Nil(t, v1.SchemeBuilder.AddToScheme(schema))
+
+	newSecret := func(name string, secretType v1.SecretType) *v1.Secret {
+		secret := &v1.Secret{Type: secretType}
+		secret.SetNamespace("ns")
+		secret.SetName(name)
+		return secret
+	}
+	c := fake.NewClientBuilder().WithScheme(schema).WithObjects(
+		newSecret("dockerhub", v1alpha3.SecretTypeBasicAuth),
+		newSecret("kubeconfig", v1alpha3.SecretTypeSecretText),
+		newSecret("github", v1alpha3.SecretTypeSSHAuth),
+		newSecret("scm", v1alpha3.SecretTypeBasicAuth),
+		newSecret("token", v1.SecretTypeOpaque)).Build()
+
+	pipeline := &v1alpha3.Pipeline{}
+	pipeline.SetNamespace("ns")
+	pipeline.Spec.Pipeline = &v1alpha3.NoScmPipeline{Jenkinsfile: credentialJenkinsfile}
+	report, err := CheckCredentials(context.Background(), c, pipeline)
+	assert.Nil(t, err)
+	assert.False(t, report.Resolved)
+
+	statuses := map[string]CredentialCheckStatus{}
+	for _, result := range report.Results {
+		statuses[result.ID] = result.Status
+	}
+	assert.Equal(t, map[string]CredentialCheckStatus{
+		"dockerhub":  CredentialFound,
+		"github":     CredentialFound,
+		"kubeconfig": CredentialTypeMismatch,
+		"scm":        CredentialFound,
+		"token":      CredentialMissing,
+	}, statuses)
+	assert.Equal(t, "dockerhub", report.Results[0].ID)
+
+	condition := report.Condition()
+	assert.Equal(t, v1alpha3.ConditionFalse, condition.Status)
+	assert.Contains(t, condition.Message, "the type of credential kubeconfig is credential.devops.kubesphere.io/secret-text")
+	assert.Contains(t, condition.Message, "secret token is not a DevOps credential")
+
+	// without any credentials
+	report, err = CheckCredentials(context.Background(), c, &v1alpha3.Pipeline{})
+	assert.Nil(t, err)
+	assert.True(t, report.Resolved)
+	assert.Equal(t, v1alpha3.ConditionTrue, report.Condition().Status)
+}
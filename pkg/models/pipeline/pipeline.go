@@ -18,6 +18,8 @@ package pipeline
 
 import (
 	"encoding/json"
+	"net/url"
+
 	"github.com/jenkins-zh/jenkins-client/pkg/job"
 )
 
@@ -80,6 +82,18 @@ func (branches BranchSlice) SearchByName(name string) (bool, *Branch) {
 	return true, &branches[i]
 }
 
+// MatchName checks if the name is the escaped name, the display name, or the unescaped name of the branch.
+func (branch *Branch) MatchName(name string) bool {
+	if name == "" {
+		return false
+	}
+	if branch.Name == name || branch.RawName == name {
+		return true
+	}
+	unescapedName, err := url.PathUnescape(branch.Name)
+	return err == nil && unescapedName == name
+}
+
 // LatestRun contains metadata of latest PipelineRun.
 type LatestRun struct {
 	Causes           []Cause  `json:"causes,omitempty"`
@@ -0,0 +1,153 @@
+/*
+Copyright 2022 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pipeline
+
+import (
+	"fmt"
+	"strings"
+
+	"kubesphere.io/devops/pkg/api/devops/v1alpha3"
+	"kubesphere.io/devops/pkg/jenkinsfile"
+	"sigs.k8s.io/yaml"
+)
+
+// DefaultJenkinsfilePath is the default path of the Jenkinsfile in the git repository
+const DefaultJenkinsfilePath = "Jenkinsfile"
+
+// AsCode is the pipeline-as-code file in the git repository, such as: .ks-devops/pipeline.yaml.
+// The fields are the same as the ones of the Pipeline spec, except the tokens of the triggers which should not be
+// stored in the git repository. The tokens are kept in the Pipeline.
+type AsCode struct {
+	Description       string                         `json:"description,omitempty"`
+	Parameters        []v1alpha3.ParameterDefinition `json:"parameters,omitempty"`
+	Discarder         *v1alpha3.DiscarderProperty    `json:"discarder,omitempty"`
+	DisableConcurrent bool                           `json:"disable_concurrent,omitempty"`
+	TimerTrigger      *v1alpha3.TimerTrigger         `json:"timer_trigger,omitempty"`
+	RemoteTrigger     *v1alpha3.RemoteTrigger        `json:"remote_trigger,omitempty"`
+	GenericWebhook    *v1alpha3.GenericWebhook       `json:"generic_webhook,omitempty"`
+	// Agent is the label of the agent which the Pipeline runs on, it overrides the top-level agent of the Jenkinsfile
+	Agent string `json:"agent,omitempty"`
+	// Jenkinsfile is the path of the Jenkinsfile in the git repository
+	Jenkinsfile string `json:"jenkinsfile,omitempty"`
+}
+
+// ParseAsCode parses the pipeline-as-code file, the unknown fields are not allowed
+func ParseAsCode(data []byte) (asCode *AsCode, err error) {
+	asCode = &AsCode{}
+	if err = yaml.UnmarshalStrict(data, asCode); err != nil {
+		err = fmt.Errorf("invalid pipeline-as-code file: %v", err)
+		return
+	}
+	if strings.ContainsAny(asCode.Agent, "'\\\n") {
+		err = fmt.Errorf("invalid agent label: %q", asCode.Agent)
+		return
+	}
+	if asCode.RemoteTrigger != nil && asCode.RemoteTrigger.Token != "" ||
+		asCode.GenericWebhook != nil && asCode.GenericWebhook.Token != "" {
+		err = fmt.Errorf("the tokens of remote_trigger and generic_webhook are not allowed in the pipeline-as-code file, " +
+			"please set them in the Pipeline")
+	}
+	return
+}
+
+// GetJenkinsfilePath returns the path of the Jenkinsfile in the git repository
+func (c *AsCode) GetJenkinsfilePath() string {
+	if path := strings.TrimPrefix(c.Jenkinsfile, "/"); path != "" {
+		return path
+	}
+	return DefaultJenkinsfilePath
+}
+
+// Apply applies the pipeline-as-code file to the spec of a Pipeline.
+// The jenkinsfile is the content of the Jenkinsfile, it is ignored by the multi-branch Pipeline.
+// The tokens of the triggers are kept from the current spec.
+func (c *AsCode) Apply(name string, spec *v1alpha3.PipelineSpec, jenkinsfile string) (err error) {
+	switch spec.Type {
+	case v1alpha3.MultiBranchPipelineType:
+		if spec.MultiBranchPipeline == nil {
+			return fmt.Errorf("the multi-branch Pipeline does not have a git source")
+		}
+		if len(c.Parameters) > 0 || c.DisableConcurrent || c.RemoteTrigger != nil || c.GenericWebhook != nil || c.Agent != "" {
+			return fmt.Errorf("parameters, disable_concurrent, remote_trigger, generic_webhook and agent " +
+				"are not supported by the multi-branch Pipeline, please define them in the Jenkinsfile")
+		}
+		multiBranch := spec.MultiBranchPipeline
+		multiBranch.Description = c.Description
+		multiBranch.Discarder = c.Discarder
+		multiBranch.TimerTrigger = c.TimerTrigger
+		multiBranch.ScriptPath = c.GetJenkinsfilePath()
+	case v1alpha3.NoScmPipelineType, "":
+		if c.Agent != "" {
+			if jenkinsfile, err = SetJenkinsfileAgent(jenkinsfile, c.Agent); err != nil {
+				return
+			}
+		}
+		remoteTrigger, genericWebhook := c.getTriggersWithTokens(spec.Pipeline)
+		spec.Type = v1alpha3.NoScmPipelineType
+		spec.Pipeline = &v1alpha3.NoScmPipeline{
+			Name:              name,
+			Description:       c.Description,
+			Discarder:         c.Discarder,
+			Parameters:        c.Parameters,
+			DisableConcurrent: c.DisableConcurrent,
+			TimerTrigger:      c.TimerTrigger,
+			RemoteTrigger:     remoteTrigger,
+			GenericWebhook:    genericWebhook,
+			Jenkinsfile:       jenkinsfile,
+		}
+	default:
+		err = fmt.Errorf("unsupported Pipeline type: %s", spec.Type)
+	}
+	return
+}
+
+// getTriggersWithTokens returns the triggers of the file with the tokens of the current Pipeline
+func (c *AsCode) getTriggersWithTokens(current *v1alpha3.NoScmPipeline) (
+	remoteTrigger *v1alpha3.RemoteTrigger, genericWebhook *v1alpha3.GenericWebhook) {
+	if c.RemoteTrigger != nil {
+		remoteTrigger = c.RemoteTrigger.DeepCopy()
+		if current != nil && current.RemoteTrigger != nil {
+			remoteTrigger.Token = current.RemoteTrigger.Token
+		}
+	}
+	if c.GenericWebhook != nil {
+		genericWebhook = c.GenericWebhook.DeepCopy()
+		if current != nil && current.GenericWebhook != nil {
+			genericWebhook.Token = current.GenericWebhook.Token
+		}
+	}
+	return
+}
+
+// SetJenkinsfileAgent replaces the top-level agent of a declarative Jenkinsfile with a node label,
+// the agent is inserted if there is no top-level agent.
+func SetJenkinsfileAgent(content, label string) (string, error) {
+	root, err := jenkinsfile.Parse(content)
+	if err != nil {
+		return "", err
+	}
+	pipeline := root.Child("pipeline")
+	if pipeline == nil || !pipeline.HasBlock {
+		return "", fmt.Errorf("the agent can only be set in a declarative Jenkinsfile")
+	}
+	agent := fmt.Sprintf("agent { node { label '%s' } }", label)
+
+	if node := pipeline.Child("agent"); node != nil {
+		return content[:node.Start] + agent + content[node.End:], nil
+	}
+	return content[:pipeline.BlockStart] + "\n  " + agent + content[pipeline.BlockStart:], nil
+}
@@ -0,0 +1,242 @@
+/*
+Copyright 2022 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pipeline
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"kubesphere.io/devops/pkg/api/devops/v1alpha3"
+)
+
+func TestParseAsCode(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		verify  func(t *testing.T, asCode *AsCode)
+		wantErr bool
+	}{{
+		name: "empty",
+		verify: func(t *testing.T, asCode *AsCode) {
+			assert.Equal(t, DefaultJenkinsfilePath, asCode.GetJenkinsfilePath())
+		},
+	}, {
+		name: "normal",
+		data: `description: demo
+jenkinsfile: /ci/Jenkinsfile
+agent: maven
+disable_concurrent: true
+parameters:
+- name: version
+  type: string
+  default_value: v1
+discarder:
+  days_to_keep: "7"
+  num_to_keep: "10"
+timer_trigger:
+  cron: H H * * *
+`,
+		verify: func(t *testing.T, asCode *AsCode) {
+			assert.Equal(t, "demo", asCode.Description)
+			assert.Equal(t, "ci/Jenkinsfile", asCode.GetJenkinsfilePath())
+			assert.Equal(t, "maven", asCode.Agent)
+			assert.True(t, asCode.DisableConcurrent)
+			assert.Equal(t, []v1alpha3.ParameterDefinition{{Name: "version", Type: "string", DefaultValue: "v1"}}, asCode.Parameters)
+			assert.Equal(t, &v1alpha3.DiscarderProperty{DaysToKeep: "7", NumToKeep: "10"}, asCode.Discarder)
+			assert.Equal(t, "H H * * *", asCode.TimerTrigger.Cron)
+		},
+	}, {
+		name:    "unknown field",
+		data:    `descriptions: demo`,
+		wantErr: true,
+	}, {
+		name:    "invalid agent",
+		data:    `agent: "maven'"`,
+		wantErr: true,
+	}, {
+		name: "triggers without tokens",
+		data: `remote_trigger: {}
+generic_webhook:
+  enable: true
+`,
+		verify: func(t *testing.T, asCode *AsCode) {
+			assert.NotNil(t, asCode.RemoteTrigger)
+			assert.True(t, asCode.GenericWebhook.Enable)
+		},
+	}, {
+		name: "token of the remote trigger",
+		data: `remote_trigger:
+  token: token
+`,
+		wantErr: true,
+	}, {
+		name: "token of the generic webhook",
+		data: `generic_webhook:
+  enable: true
+  token: token
+`,
+		wantErr: true,
+	}, {
+		name:    "invalid yaml",
+		data:    `parameters: demo`,
+		wantErr: true,
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			asCode, err := ParseAsCode([]byte(tt.data))
+			if tt.wantErr {
+				assert.NotNil(t, err)
+				return
+			}
+			assert.Nil(t, err)
+			if tt.verify != nil {
+				tt.verify(t, asCode)
+			}
+		})
+	}
+}
+
+func TestAsCode_Apply(t *testing.T) {
+	asCode := &AsCode{
+		Description: "demo",
+		Discarder:   &v1alpha3.DiscarderProperty{NumToKeep: "10"},
+		Jenkinsfile: "ci/Jenkinsfile",
+	}
+
+	// multi-branch Pipeline
+	spec := &v1alpha3.PipelineSpec{
+		Type:                v1alpha3.MultiBranchPipelineType,
+		MultiBranchPipeline: &v1alpha3.MultiBranchPipeline{Name: "name", SourceType: "github", ScriptPath: "Jenkinsfile"},
+	}
+	assert.Nil(t, asCode.Apply("name", spec, ""))
+	assert.Equal(t, &v1alpha3.MultiBranchPipeline{
+		Name:        "name",
+		SourceType:  "github",
+		Description: "demo",
+		Discarder:   &v1alpha3.DiscarderProperty{NumToKeep: "10"},
+		ScriptPath:  "ci/Jenkinsfile",
+	}, spec.MultiBranchPipeline)
+
+	withAgent := *asCode
+	withAgent.Agent = "maven"
+	assert.NotNil(t, withAgent.Apply("name", spec, ""))
+	assert.NotNil(t, asCode.Apply("name", &v1alpha3.PipelineSpec{Type: v1alpha3.MultiBranchPipelineType}, ""))
+
+	// Pipeline without SCM
+	spec = &v1alpha3.PipelineSpec{}
+	assert.Nil(t, withAgent.Apply("name", spec, "pipeline {\n  agent any\n}"))
+	assert.Equal(t, v1alpha3.NoScmPipelineType, spec.Type)
+	assert.Equal(t, &v1alpha3.NoScmPipeline{
+		Name:        "name",
+		Description: "demo",
+		Discarder:   &v1alpha3.DiscarderProperty{NumToKeep: "10"},
+		Jenkinsfile: "pipeline {\n  agent { node { label 'maven' } }\n}",
+	}, spec.Pipeline)
+	assert.NotNil(t, withAgent.Apply("name", spec, "node { echo 'scripted' }"))
+
+	// the tokens of the triggers are kept from the current spec
+	withTriggers := *asCode
+	withTriggers.RemoteTrigger = &v1alpha3.RemoteTrigger{}
+	withTriggers.GenericWebhook = &v1alpha3.GenericWebhook{Enable: true}
+	spec = &v1alpha3.PipelineSpec{}
+	assert.Nil(t, withTriggers.Apply("name", spec, ""))
+	assert.Equal(t, &v1alpha3.RemoteTrigger{}, spec.Pipeline.RemoteTrigger)
+	assert.Equal(t, &v1alpha3.GenericWebhook{Enable: true}, spec.Pipeline.GenericWebhook)
+	spec.Pipeline.RemoteTrigger.Token = "remote"
+	spec.Pipeline.GenericWebhook.Token = "webhook"
+	assert.Nil(t, withTriggers.Apply("name", spec, ""))
+	assert.Equal(t, &v1alpha3.RemoteTrigger{Token: "remote"}, spec.Pipeline.RemoteTrigger)
+	assert.Equal(t, &v1alpha3.GenericWebhook{Enable: true, Token: "webhook"}, spec.Pipeline.GenericWebhook)
+	assert.Equal(t, &v1alpha3.RemoteTrigger{}, withTriggers.RemoteTrigger)
+
+	assert.NotNil(t, asCode.Apply("name", &v1alpha3.PipelineSpec{Type: "unknown"}, ""))
+}
+
+func TestSetJenkinsfileAgent(t *testing.T) {
+	tests := []struct {
+		name        string
+		jenkinsfile string
+		expected    string
+		wantErr     bool
+	}{{
+		name:        "scripted Jenkinsfile",
+		jenkinsfile: "node {\n  echo 'hello'\n}",
+		wantErr:     true,
+	}, {
+		name:        "agent any",
+		jenkinsfile: "pipeline {\n  agent any // comment\n  stages {}\n}",
+		expected:    "pipeline {\n  agent { node { label 'go' } } // comment\n  stages {}\n}",
+	}, {
+		name: "agent block",
+		jenkinsfile: `pipeline {
+  agent {
+    node {
+      label 'maven'
+    }
+  }
+  stages {
+    stage('build') {
+      agent none
+      steps { echo '}' }
+    }
+  }
+}`,
+		expected: `pipeline {
+  agent { node { label 'go' } }
+  stages {
+    stage('build') {
+      agent none
+      steps { echo '}' }
+    }
+  }
+}`,
+	}, {
+		name: "no top-level agent",
+		jenkinsfile: `// pipeline { agent any }
+pipeline {
+  environment { agent_name = "agent { }" }
+  stages {
+    stage('build') {
+      agent any
+      steps { echo 'agent' }
+    }
+  }
+}`,
+		expected: `// pipeline { agent any }
+pipeline {
+  agent { node { label 'go' } }
+  environment { agent_name = "agent { }" }
+  stages {
+    stage('build') {
+      agent any
+      steps { echo 'agent' }
+    }
+  }
+}`,
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := SetJenkinsfileAgent(tt.jenkinsfile, "go")
+			if tt.wantErr {
+				assert.NotNil(t, err)
+				return
+			}
+			assert.Nil(t, err)
+			assert.Equal(t, tt.expected, result)
+		})
+	}
+}
@@ -19,6 +19,8 @@ package pipeline
 import (
 	"reflect"
 	"testing"
+
+	"github.com/stretchr/testify/assert"
 )
 
 func TestBranchSlice_SearchByName(t *testing.T) {
@@ -117,3 +119,14 @@ func TestGetBranchSlice(t *testing.T) {
 		})
 	}
 }
+
+func TestBranch_MatchName(t *testing.T) {
+	branch := &Branch{Name: "feat%2FfeatureA", RawName: "feat/featureA"}
+	assert.True(t, branch.MatchName("feat%2FfeatureA"))
+	assert.True(t, branch.MatchName("feat/featureA"))
+	assert.False(t, branch.MatchName("featureA"))
+	assert.False(t, branch.MatchName(""))
+
+	branch = &Branch{Name: "feat%2FfeatureB"}
+	assert.True(t, branch.MatchName("feat/featureB"))
+}
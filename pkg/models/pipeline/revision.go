@@ -0,0 +1,187 @@
+/*
+Copyright 2022 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pipeline
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+
+	"github.com/pmezard/go-difflib/difflib"
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"kubesphere.io/devops/pkg/api/devops/v1alpha3"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/yaml"
+)
+
+// RevisionSpecKey is the data key of the Pipeline spec snapshot in a revision ConfigMap
+const RevisionSpecKey = "spec.yaml"
+
+// Revision is an immutable snapshot of the Pipeline spec, it is stored in a ConfigMap
+type Revision struct {
+	Revision int `json:"revision"`
+	// Author is empty if the spec was not changed through the API, e.g. it was edited by kubectl
+	Author    string                 `json:"author,omitempty"`
+	Timestamp metav1.Time            `json:"timestamp"`
+	SpecHash  string                 `json:"specHash"`
+	Spec      *v1alpha3.PipelineSpec `json:"spec,omitempty"`
+}
+
+// RevisionDiff is the difference between the specs of two revisions
+type RevisionDiff struct {
+	Base     int    `json:"base"`
+	Revision int    `json:"revision"`
+	Diff     string `json:"diff"`
+}
+
+// GetRevisionName returns the ConfigMap name of a Pipeline revision
+func GetRevisionName(pipelineName string, revision int) string {
+	return fmt.Sprintf("%s-revision-%d", pipelineName, revision)
+}
+
+// NewRevisionConfigMap creates an immutable ConfigMap which holds the current spec of the Pipeline as the given revision.
+// The author is recorded only if the current spec is the one which the last modifier changed.
+func NewRevisionConfigMap(pipeline *v1alpha3.Pipeline, revision int, specHash string) (cm *v1.ConfigMap, err error) {
+	var spec []byte
+	if spec, err = yaml.Marshal(pipeline.Spec); err != nil {
+		return
+	}
+
+	annotations := map[string]string{
+		v1alpha3.PipelineSpecHash: specHash,
+	}
+	if author := pipeline.GetLastModifier(); author != "" {
+		annotations[v1alpha3.PipelineRevisionAuthorAnnoKey] = author
+	}
+	immutable := true
+
+	cm = &v1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      GetRevisionName(pipeline.Name, revision),
+			Namespace: pipeline.Namespace,
+			Labels: map[string]string{
+				v1alpha3.PipelineNameLabelKey:     pipeline.Name,
+				v1alpha3.PipelineRevisionLabelKey: strconv.Itoa(revision),
+			},
+			Annotations: annotations,
+			OwnerReferences: []metav1.OwnerReference{
+				*metav1.NewControllerRef(pipeline, v1alpha3.GroupVersion.WithKind(v1alpha3.ResourceKindPipeline)),
+			},
+		},
+		Data: map[string]string{
+			RevisionSpecKey: string(spec),
+		},
+		Immutable: &immutable,
+	}
+	return
+}
+
+// ParseRevision parses a revision from the ConfigMap, the spec is ignored if withSpec is false
+func ParseRevision(cm *v1.ConfigMap, withSpec bool) (revision *Revision, err error) {
+	revision = &Revision{
+		Author:    cm.Annotations[v1alpha3.PipelineRevisionAuthorAnnoKey],
+		Timestamp: cm.CreationTimestamp,
+		SpecHash:  cm.Annotations[v1alpha3.PipelineSpecHash],
+	}
+	if revision.Revision, err = strconv.Atoi(cm.Labels[v1alpha3.PipelineRevisionLabelKey]); err != nil {
+		err = fmt.Errorf("invalid revision of ConfigMap %s: %v", cm.Name, err)
+		return
+	}
+	if withSpec {
+		revision.Spec = &v1alpha3.PipelineSpec{}
+		if err = yaml.Unmarshal([]byte(cm.Data[RevisionSpecKey]), revision.Spec); err != nil {
+			err = fmt.Errorf("invalid spec of revision %d: %v", revision.Revision, err)
+		}
+	}
+	return
+}
+
+// ListRevisionConfigMaps returns the revision ConfigMaps of a Pipeline, the latest revision is the first one
+func ListRevisionConfigMaps(ctx context.Context, c client.Reader, namespace, pipelineName string) (cms []v1.ConfigMap, err error) {
+	list := &v1.ConfigMapList{}
+	if err = c.List(ctx, list, client.InNamespace(namespace),
+		client.MatchingLabels{v1alpha3.PipelineNameLabelKey: pipelineName}); err != nil {
+		return
+	}
+	for i := range list.Items {
+		// the other ConfigMaps of the Pipeline do not have the revision label
+		if _, err := strconv.Atoi(list.Items[i].Labels[v1alpha3.PipelineRevisionLabelKey]); err == nil {
+			cms = append(cms, list.Items[i])
+		}
+	}
+	sort.SliceStable(cms, func(i, j int) bool {
+		left, _ := strconv.Atoi(cms[i].Labels[v1alpha3.PipelineRevisionLabelKey])
+		right, _ := strconv.Atoi(cms[j].Labels[v1alpha3.PipelineRevisionLabelKey])
+		return left > right
+	})
+	return
+}
+
+// ListRevisions returns the revisions of a Pipeline without the specs, the latest revision is the first one
+func ListRevisions(ctx context.Context, c client.Reader, namespace, pipelineName string) (revisions []*Revision, err error) {
+	var cms []v1.ConfigMap
+	if cms, err = ListRevisionConfigMaps(ctx, c, namespace, pipelineName); err != nil {
+		return
+	}
+	revisions = make([]*Revision, 0, len(cms))
+	for i := range cms {
+		var revision *Revision
+		if revision, err = ParseRevision(&cms[i], false); err != nil {
+			return
+		}
+		revisions = append(revisions, revision)
+	}
+	return
+}
+
+// GetRevision returns a revision of a Pipeline with the spec
+func GetRevision(ctx context.Context, c client.Reader, namespace, pipelineName string, revision int) (*Revision, error) {
+	cm := &v1.ConfigMap{}
+	key := client.ObjectKey{Namespace: namespace, Name: GetRevisionName(pipelineName, revision)}
+	if err := c.Get(ctx, key, cm); err != nil {
+		return nil, err
+	}
+	if cm.Labels[v1alpha3.PipelineNameLabelKey] != pipelineName {
+		// not a revision of this Pipeline
+		return nil, apierrors.NewNotFound(v1.Resource("configmaps"), key.Name)
+	}
+	return ParseRevision(cm, true)
+}
+
+// DiffRevisions returns the unified diff between the specs of two revisions, the Jenkinsfile is a part of the spec
+func DiffRevisions(base, revision *Revision) (diff *RevisionDiff, err error) {
+	var baseSpec, spec []byte
+	if baseSpec, err = yaml.Marshal(base.Spec); err != nil {
+		return
+	}
+	if spec, err = yaml.Marshal(revision.Spec); err != nil {
+		return
+	}
+
+	diff = &RevisionDiff{Base: base.Revision, Revision: revision.Revision}
+	diff.Diff, err = difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+		A:        difflib.SplitLines(string(baseSpec)),
+		B:        difflib.SplitLines(string(spec)),
+		FromFile: fmt.Sprintf("revision-%d", base.Revision),
+		ToFile:   fmt.Sprintf("revision-%d", revision.Revision),
+		Context:  3,
+	})
+	return
+}
@@ -0,0 +1,147 @@
+/*
+Copyright 2022 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pipeline
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"kubesphere.io/devops/pkg/api/devops/v1alpha3"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func newRevisionPipeline(jenkinsfile string) *v1alpha3.Pipeline {
+	pipeline := &v1alpha3.Pipeline{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "ns",
+			Name:      "fake",
+		},
+		Spec: v1alpha3.PipelineSpec{
+			Type:     v1alpha3.NoScmPipelineType,
+			Pipeline: &v1alpha3.NoScmPipeline{Name: "fake", Jenkinsfile: jenkinsfile},
+		},
+	}
+	pipeline.SetLastModifier("tester")
+	return pipeline
+}
+
+func TestRevisionConfigMap(t *testing.T) {
+	pipeline := newRevisionPipeline("echo 1")
+	cm, err := NewRevisionConfigMap(pipeline, 2, "hash")
+	assert.Nil(t, err)
+	assert.Equal(t, "fake-revision-2", cm.Name)
+	assert.Equal(t, "ns", cm.Namespace)
+	assert.Equal(t, "fake", cm.Labels[v1alpha3.PipelineNameLabelKey])
+	assert.Equal(t, "2", cm.Labels[v1alpha3.PipelineRevisionLabelKey])
+	assert.Equal(t, "tester", cm.Annotations[v1alpha3.PipelineRevisionAuthorAnnoKey])
+	assert.Equal(t, 1, len(cm.OwnerReferences))
+	assert.True(t, *cm.Immutable)
+
+	revision, err := ParseRevision(cm, true)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, revision.Revision)
+	assert.Equal(t, "tester", revision.Author)
+	assert.Equal(t, "hash", revision.SpecHash)
+	assert.Equal(t, pipeline.Spec, *revision.Spec)
+
+	revision, err = ParseRevision(cm, false)
+	assert.Nil(t, err)
+	assert.Nil(t, revision.Spec)
+
+	// the spec was changed without the API, e.g. by kubectl
+	pipeline.Spec.Pipeline.Jenkinsfile = "echo 2"
+	cm, err = NewRevisionConfigMap(pipeline, 3, "hash")
+	assert.Nil(t, err)
+	_, ok := cm.Annotations[v1alpha3.PipelineRevisionAuthorAnnoKey]
+	assert.False(t, ok)
+	revision, err = ParseRevision(cm, false)
+	assert.Nil(t, err)
+	assert.Equal(t, "", revision.Author)
+
+	cm.Labels[v1alpha3.PipelineRevisionLabelKey] = "invalid"
+	_, err = ParseRevision(cm, false)
+	assert.NotNil(t, err)
+}
+
+func TestListAndGetRevisions(t *testing.T) {
+	schema := runtime.NewScheme()
+	assert.Nil(t, v1.AddToScheme(schema))
+
+	var objects []runtime.Object
+	for i, jenkinsfile := range []string{"echo 1", "echo 2", "echo 3"} {
+		cm, err := NewRevisionConfigMap(newRevisionPipeline(jenkinsfile), i+1, jenkinsfile)
+		assert.Nil(t, err)
+		objects = append(objects, cm)
+	}
+	objects = append(objects, &v1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "ns",
+			Name:      "other",
+			Labels:    map[string]string{v1alpha3.PipelineNameLabelKey: "fake"},
+		},
+	}, &v1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "ns",
+			Name:      "fake-revision-4",
+			Labels:    map[string]string{v1alpha3.PipelineNameLabelKey: "another"},
+		},
+	})
+	c := fake.NewClientBuilder().WithScheme(schema).WithRuntimeObjects(objects...).Build()
+
+	revisions, err := ListRevisions(context.TODO(), c, "ns", "fake")
+	assert.Nil(t, err)
+	assert.Equal(t, 3, len(revisions))
+	for i, revision := range revisions {
+		assert.Equal(t, 3-i, revision.Revision)
+		assert.Nil(t, revision.Spec)
+	}
+
+	revision, err := GetRevision(context.TODO(), c, "ns", "fake", 2)
+	assert.Nil(t, err)
+	assert.Equal(t, "echo 2", revision.Spec.Pipeline.Jenkinsfile)
+
+	_, err = GetRevision(context.TODO(), c, "ns", "fake", 4)
+	assert.True(t, apierrors.IsNotFound(err))
+	_, err = GetRevision(context.TODO(), c, "ns", "fake", 5)
+	assert.True(t, apierrors.IsNotFound(err))
+}
+
+func TestDiffRevisions(t *testing.T) {
+	base := &Revision{Revision: 1, Spec: &newRevisionPipeline("echo 1").Spec}
+	revision := &Revision{Revision: 2, Spec: &newRevisionPipeline("echo 2").Spec}
+
+	diff, err := DiffRevisions(base, revision)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, diff.Base)
+	assert.Equal(t, 2, diff.Revision)
+	assert.Contains(t, diff.Diff, "--- revision-1\n+++ revision-2\n")
+	assert.Contains(t, diff.Diff, "-  jenkinsfile: echo 1\n+  jenkinsfile: echo 2\n")
+
+	diff, err = DiffRevisions(revision, revision)
+	assert.Nil(t, err)
+	assert.Empty(t, diff.Diff)
+
+	// compare with an empty base
+	diff, err = DiffRevisions(&Revision{}, revision)
+	assert.Nil(t, err)
+	assert.Contains(t, diff.Diff, "+  jenkinsfile: echo 2\n")
+}
@@ -0,0 +1,260 @@
+/*
+Copyright 2022 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pipelinerun
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/jenkins-zh/jenkins-client/pkg/job"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"kubesphere.io/devops/pkg/api/devops/v1alpha3"
+	modelpipeline "kubesphere.io/devops/pkg/models/pipeline"
+)
+
+// the parameter types of the Pipeline, see also the parameter definitions of Jenkins
+const (
+	parameterTypeString   = "string"
+	parameterTypeText     = "text"
+	parameterTypeBoolean  = "boolean"
+	parameterTypeChoice   = "choice"
+	parameterTypeFile     = "file"
+	parameterTypePassword = "password"
+)
+
+// jenkinsParameterTypes are the parameter types of the Jenkins parameter definition classes
+var jenkinsParameterTypes = map[string]string{
+	"StringParameterDefinition":   parameterTypeString,
+	"TextParameterDefinition":     parameterTypeText,
+	"BooleanParameterDefinition":  parameterTypeBoolean,
+	"ChoiceParameterDefinition":   parameterTypeChoice,
+	"FileParameterDefinition":     parameterTypeFile,
+	"PasswordParameterDefinition": parameterTypePassword,
+}
+
+// jenkinsfileParametersPattern matches the parameters directive of a declarative Jenkinsfile
+var jenkinsfileParametersPattern = regexp.MustCompile(`\bparameters\s*\{`)
+
+// parameterDefinition is a parameter definition of a Pipeline or a branch of a multi-branch Pipeline
+type parameterDefinition struct {
+	name         string
+	paramType    string
+	defaultValue string
+	choices      []string
+	required     bool
+}
+
+// ValidateParameters checks the parameters of a PipelineRun against the parameter definitions, and returns
+// the parameters with the default values of the absent ones. The parameter definitions of a multi-branch
+// Pipeline come from the branch metadata, the parameters are returned as they are if the branch is unknown.
+// The parameters of a Pipeline without SCM are defined in its spec or the Jenkins job. The Jenkins job has the
+// parameters of the Jenkinsfile only after a run, so the undefined ones are kept if the Jenkinsfile declares parameters.
+func ValidateParameters(pipeline *v1alpha3.Pipeline, branch string, parameters []v1alpha3.Parameter) ([]v1alpha3.Parameter, error) {
+	definitions, ok := getParameterDefinitions(pipeline, branch)
+	if !ok {
+		return parameters, nil
+	}
+	allowUndefined := declaresParameters(pipeline)
+
+	var errs []error
+	values := map[string]string{}
+	for _, parameter := range parameters {
+		if _, exist := values[parameter.Name]; exist {
+			errs = append(errs, fmt.Errorf("parameter %s is duplicated", parameter.Name))
+			continue
+		}
+		values[parameter.Name] = parameter.Value
+	}
+
+	result := make([]v1alpha3.Parameter, 0, len(definitions))
+	for _, definition := range definitions {
+		value, provided := values[definition.name]
+		delete(values, definition.name)
+		if !provided {
+			if definition.required {
+				errs = append(errs, fmt.Errorf("parameter %s is required", definition.name))
+				continue
+			}
+			if !definition.hasVisibleDefault() {
+				// the default value is left to Jenkins, e.g. the secret is not stored in the PipelineRun
+				continue
+			}
+			value = definition.defaultValue
+		}
+
+		var err error
+		if value, err = definition.validate(value); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		result = append(result, v1alpha3.Parameter{Name: definition.name, Value: value})
+	}
+	for _, parameter := range parameters {
+		if _, unknown := values[parameter.Name]; unknown {
+			if allowUndefined {
+				result = append(result, parameter)
+			} else {
+				errs = append(errs, fmt.Errorf("parameter %s is not defined", parameter.Name))
+			}
+			delete(values, parameter.Name)
+		}
+	}
+
+	if err := utilerrors.NewAggregate(errs); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// hasVisibleDefault returns true if the default value could be stored in the PipelineRun
+func (d *parameterDefinition) hasVisibleDefault() bool {
+	switch d.paramType {
+	case parameterTypeString, parameterTypeText, parameterTypeBoolean, parameterTypeChoice:
+		return true
+	}
+	return false
+}
+
+// validate checks the value and returns the normalized one
+func (d *parameterDefinition) validate(value string) (string, error) {
+	if d.required && value == "" {
+		return "", fmt.Errorf("parameter %s is required", d.name)
+	}
+	switch d.paramType {
+	case parameterTypeBoolean:
+		if value == "" {
+			return "false", nil
+		}
+		parsed, err := strconv.ParseBool(value)
+		if err != nil {
+			return "", fmt.Errorf("parameter %s must be a boolean, got %q", d.name, value)
+		}
+		return strconv.FormatBool(parsed), nil
+	case parameterTypeChoice:
+		for _, choice := range d.choices {
+			if choice == value {
+				return value, nil
+			}
+		}
+		return "", fmt.Errorf("parameter %s must be one of [%s], got %q", d.name, strings.Join(d.choices, ", "), value)
+	case parameterTypeFile:
+		return "", fmt.Errorf("parameter %s is a file, it cannot be provided when creating a PipelineRun", d.name)
+	}
+	return value, nil
+}
+
+// getParameterDefinitions returns the parameter definitions, returns false if they are unknown
+func getParameterDefinitions(pipeline *v1alpha3.Pipeline, branch string) ([]parameterDefinition, bool) {
+	switch pipeline.Spec.Type {
+	case v1alpha3.NoScmPipelineType:
+		if pipeline.Spec.Pipeline == nil {
+			return nil, false
+		}
+		definitions := fromPipelineParameters(pipeline.Spec.Pipeline.Parameters)
+		for _, definition := range fromJenkinsParameters(getJenkinsJobParameters(pipeline)) {
+			// the parameters of the spec are written to the Jenkins job as well
+			if !hasDefinition(definitions, definition.name) {
+				definitions = append(definitions, definition)
+			}
+		}
+		return definitions, true
+	case v1alpha3.MultiBranchPipelineType:
+		branches, err := modelpipeline.GetBranchSlice(pipeline.Annotations[v1alpha3.PipelineJenkinsBranchesAnnoKey])
+		if err != nil {
+			return nil, false
+		}
+		for i := range branches {
+			if branches[i].Name == branch || branches[i].RawName == branch {
+				return fromJenkinsParameters(branches[i].Parameters), true
+			}
+		}
+	}
+	return nil, false
+}
+
+// GetParameterNames returns the names of the parameters which are defined in the Pipeline or the branch
+func GetParameterNames(pipeline *v1alpha3.Pipeline, branch string) (names []string) {
+	definitions, _ := getParameterDefinitions(pipeline, branch)
+	for _, definition := range definitions {
+		names = append(names, definition.name)
+	}
+	return
+}
+
+// getJenkinsJobParameters returns the parameter definitions of the Jenkins job from the metadata of the Pipeline
+func getJenkinsJobParameters(pipeline *v1alpha3.Pipeline) []job.ParameterDefinition {
+	metadata := &modelpipeline.Metadata{}
+	if err := json.Unmarshal([]byte(pipeline.Annotations[v1alpha3.PipelineJenkinsMetadataAnnoKey]), metadata); err != nil {
+		return nil
+	}
+	return metadata.Parameters
+}
+
+// declaresParameters returns true if the Jenkinsfile of a Pipeline without SCM has the parameters directive
+func declaresParameters(pipeline *v1alpha3.Pipeline) bool {
+	return pipeline.Spec.Type == v1alpha3.NoScmPipelineType && pipeline.Spec.Pipeline != nil &&
+		jenkinsfileParametersPattern.MatchString(pipeline.Spec.Pipeline.Jenkinsfile)
+}
+
+func hasDefinition(definitions []parameterDefinition, name string) bool {
+	for _, definition := range definitions {
+		if definition.name == name {
+			return true
+		}
+	}
+	return false
+}
+
+func fromPipelineParameters(parameters []v1alpha3.ParameterDefinition) []parameterDefinition {
+	definitions := make([]parameterDefinition, 0, len(parameters))
+	for _, parameter := range parameters {
+		definition := parameterDefinition{
+			name:         parameter.Name,
+			paramType:    parameter.Type,
+			defaultValue: parameter.DefaultValue,
+			required:     parameter.Required,
+		}
+		if parameter.Type == parameterTypeChoice {
+			// the choices are separated by lines, the first one is the default value
+			definition.choices = strings.Split(parameter.DefaultValue, "\n")
+			definition.defaultValue = definition.choices[0]
+		}
+		definitions = append(definitions, definition)
+	}
+	return definitions
+}
+
+func fromJenkinsParameters(parameters []job.ParameterDefinition) []parameterDefinition {
+	definitions := make([]parameterDefinition, 0, len(parameters))
+	for _, parameter := range parameters {
+		definition := parameterDefinition{
+			name:      parameter.Name,
+			paramType: jenkinsParameterTypes[strings.TrimPrefix(parameter.Type, "hudson.model.")],
+			choices:   parameter.Choices,
+		}
+		if parameter.DefaultParameterValue != nil && parameter.DefaultParameterValue.Value != nil {
+			definition.defaultValue = fmt.Sprint(parameter.DefaultParameterValue.Value)
+		} else if len(parameter.Choices) > 0 {
+			definition.defaultValue = parameter.Choices[0]
+		}
+		definitions = append(definitions, definition)
+	}
+	return definitions
+}
@@ -0,0 +1,164 @@
+/*
+Copyright 2022 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pipelinerun
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"kubesphere.io/devops/pkg/api/devops/v1alpha3"
+)
+
+func TestValidateParameters(t *testing.T) {
+	noScm := &v1alpha3.Pipeline{}
+	noScm.Spec.Type = v1alpha3.NoScmPipelineType
+	noScm.Spec.Pipeline = &v1alpha3.NoScmPipeline{
+		Parameters: []v1alpha3.ParameterDefinition{
+			{Name: "env", Type: "choice", DefaultValue: "test\nprod"},
+			{Name: "debug", Type: "boolean", DefaultValue: "true"},
+			{Name: "version", Type: "string", Required: true},
+			{Name: "token", Type: "password", DefaultValue: "secret"},
+			{Name: "notes", Type: "text"},
+		},
+	}
+
+	jenkinsJob := &v1alpha3.Pipeline{}
+	jenkinsJob.Spec.Type = v1alpha3.NoScmPipelineType
+	jenkinsJob.Spec.Pipeline = &v1alpha3.NoScmPipeline{
+		Parameters: []v1alpha3.ParameterDefinition{{Name: "env", Type: "string", DefaultValue: "test"}},
+	}
+	jenkinsJob.SetAnnotations(map[string]string{
+		v1alpha3.PipelineJenkinsMetadataAnnoKey: `{"parameters": [
+{"name": "env", "type": "StringParameterDefinition", "defaultParameterValue": {"name": "env", "value": "old"}},
+{"name": "region", "type": "ChoiceParameterDefinition", "choices": ["us", "eu"]}]}`,
+	})
+
+	declared := jenkinsJob.DeepCopy()
+	declared.Spec.Pipeline.Jenkinsfile = "pipeline {\n  parameters {\n    string(name: 'zone')\n  }\n}"
+
+	multiBranch := &v1alpha3.Pipeline{}
+	multiBranch.Spec.Type = v1alpha3.MultiBranchPipelineType
+	multiBranch.SetAnnotations(map[string]string{
+		v1alpha3.PipelineJenkinsBranchesAnnoKey: `[{"name": "feat%2Fa", "rawName": "feat/a", "parameters": [
+{"name": "env", "type": "ChoiceParameterDefinition", "choices": ["dev", "prod"]},
+{"name": "debug", "type": "BooleanParameterDefinition", "defaultParameterValue": {"name": "debug", "value": false}}]}]`,
+	})
+
+	tests := []struct {
+		name       string
+		pipeline   *v1alpha3.Pipeline
+		branch     string
+		parameters []v1alpha3.Parameter
+		want       []v1alpha3.Parameter
+		wantErr    string
+	}{{
+		name:       "fill in the default values",
+		pipeline:   noScm,
+		parameters: []v1alpha3.Parameter{{Name: "version", Value: "v1"}},
+		want: []v1alpha3.Parameter{
+			{Name: "env", Value: "test"}, {Name: "debug", Value: "true"}, {Name: "version", Value: "v1"}, {Name: "notes"},
+		},
+	}, {
+		name:     "normalize the values",
+		pipeline: noScm,
+		parameters: []v1alpha3.Parameter{
+			{Name: "debug", Value: "0"}, {Name: "env", Value: "prod"}, {Name: "version", Value: "v1"}, {Name: "token", Value: "abc"},
+		},
+		want: []v1alpha3.Parameter{
+			{Name: "env", Value: "prod"}, {Name: "debug", Value: "false"}, {Name: "version", Value: "v1"},
+			{Name: "token", Value: "abc"}, {Name: "notes"},
+		},
+	}, {
+		name:     "invalid values",
+		pipeline: noScm,
+		parameters: []v1alpha3.Parameter{
+			{Name: "env", Value: "dev"}, {Name: "debug", Value: "yes"}, {Name: "unknown"}, {Name: "unknown"},
+		},
+		wantErr: `[parameter unknown is duplicated, parameter env must be one of [test, prod], got "dev", ` +
+			`parameter debug must be a boolean, got "yes", parameter version is required, parameter unknown is not defined]`,
+	}, {
+		name:       "empty required value",
+		pipeline:   noScm,
+		parameters: []v1alpha3.Parameter{{Name: "version"}},
+		wantErr:    "parameter version is required",
+	}, {
+		name:       "parameters of the Jenkins job",
+		pipeline:   jenkinsJob,
+		parameters: []v1alpha3.Parameter{{Name: "region", Value: "eu"}},
+		want:       []v1alpha3.Parameter{{Name: "env", Value: "test"}, {Name: "region", Value: "eu"}},
+	}, {
+		name:       "undefined parameter of the Jenkins job",
+		pipeline:   jenkinsJob,
+		parameters: []v1alpha3.Parameter{{Name: "zone", Value: "a"}},
+		wantErr:    "parameter zone is not defined",
+	}, {
+		name:       "the parameters declared in the Jenkinsfile",
+		pipeline:   declared,
+		parameters: []v1alpha3.Parameter{{Name: "zone", Value: "a"}, {Name: "region", Value: "cn"}},
+		wantErr:    `parameter region must be one of [us, eu], got "cn"`,
+	}, {
+		name:       "the Jenkins job does not have the parameters declared in the Jenkinsfile yet",
+		pipeline:   declared,
+		parameters: []v1alpha3.Parameter{{Name: "zone", Value: "a"}},
+		want:       []v1alpha3.Parameter{{Name: "env", Value: "test"}, {Name: "region", Value: "us"}, {Name: "zone", Value: "a"}},
+	}, {
+		name:       "a branch of the multi-branch Pipeline",
+		pipeline:   multiBranch,
+		branch:     "feat/a",
+		parameters: []v1alpha3.Parameter{{Name: "debug", Value: "True"}},
+		want:       []v1alpha3.Parameter{{Name: "env", Value: "dev"}, {Name: "debug", Value: "true"}},
+	}, {
+		name:       "invalid choice of a branch",
+		pipeline:   multiBranch,
+		branch:     "feat%2Fa",
+		parameters: []v1alpha3.Parameter{{Name: "env", Value: "test"}},
+		wantErr:    `parameter env must be one of [dev, prod], got "test"`,
+	}, {
+		name:       "unknown branch",
+		pipeline:   multiBranch,
+		branch:     "main",
+		parameters: []v1alpha3.Parameter{{Name: "any", Value: "value"}},
+		want:       []v1alpha3.Parameter{{Name: "any", Value: "value"}},
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ValidateParameters(tt.pipeline, tt.branch, tt.parameters)
+			if tt.wantErr != "" {
+				assert.EqualError(t, err, tt.wantErr)
+				assert.Nil(t, got)
+				return
+			}
+			assert.Nil(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestGetParameterNames(t *testing.T) {
+	pipeline := &v1alpha3.Pipeline{}
+	pipeline.Spec.Type = v1alpha3.NoScmPipelineType
+	pipeline.Spec.Pipeline = &v1alpha3.NoScmPipeline{
+		Parameters: []v1alpha3.ParameterDefinition{{Name: "env", Type: "string"}},
+	}
+	pipeline.SetAnnotations(map[string]string{
+		v1alpha3.PipelineJenkinsMetadataAnnoKey: `{"parameters": [{"name": "region", "type": "StringParameterDefinition"}]}`,
+	})
+	assert.Equal(t, []string{"env", "region"}, GetParameterNames(pipeline, ""))
+
+	pipeline.Spec.Type = v1alpha3.MultiBranchPipelineType
+	assert.Empty(t, GetParameterNames(pipeline, "main"))
+}
@@ -0,0 +1,60 @@
+/*
+Copyright 2022 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pipelinerun
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+
+	"kubesphere.io/devops/pkg/api/devops/v1alpha3"
+)
+
+// SignReplayJenkinsfile signs the Jenkinsfile which a PipelineRun replays with. Only the apiserver and the controller
+// share the secret, so a PipelineRun created by others cannot replay with its own Jenkinsfile.
+func SignReplayJenkinsfile(secret string, pr *v1alpha3.PipelineRun) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strings.Join([]string{"replay", pr.Namespace, getPipelineName(pr),
+		pr.Annotations[v1alpha3.PipelineRunReplayAnnoKey], GetReplayJenkinsfile(&pr.Spec)}, "\x00")))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// VerifyReplayJenkinsfile returns true if the Jenkinsfile which a PipelineRun replays with was signed by the secret
+func VerifyReplayJenkinsfile(secret string, pr *v1alpha3.PipelineRun) bool {
+	signature, ok := pr.Annotations[v1alpha3.PipelineRunReplayJenkinsfileSignatureAnnoKey]
+	if !ok || secret == "" {
+		return false
+	}
+	return hmac.Equal([]byte(signature), []byte(SignReplayJenkinsfile(secret, pr)))
+}
+
+// GetReplayJenkinsfile returns the Jenkinsfile which a Pipeline without SCM replays with
+func GetReplayJenkinsfile(prSpec *v1alpha3.PipelineRunSpec) string {
+	if prSpec.PipelineSpec == nil || prSpec.PipelineSpec.Type != v1alpha3.NoScmPipelineType ||
+		prSpec.PipelineSpec.Pipeline == nil {
+		return ""
+	}
+	return prSpec.PipelineSpec.Pipeline.Jenkinsfile
+}
+
+func getPipelineName(pr *v1alpha3.PipelineRun) string {
+	if pr.Spec.PipelineRef == nil {
+		return ""
+	}
+	return pr.Spec.PipelineRef.Name
+}
@@ -0,0 +1,102 @@
+/*
+Copyright 2022 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pipelinerun
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"kubesphere.io/devops/pkg/api/devops/v1alpha3"
+)
+
+func TestVerifyReplayJenkinsfile(t *testing.T) {
+	newReplay := func() *v1alpha3.PipelineRun {
+		pr := &v1alpha3.PipelineRun{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace:   "ns",
+				Annotations: map[string]string{v1alpha3.PipelineRunReplayAnnoKey: "2"},
+			},
+			Spec: v1alpha3.PipelineRunSpec{
+				PipelineRef: &v1.ObjectReference{Name: "pipeline"},
+				PipelineSpec: &v1alpha3.PipelineSpec{
+					Type:     v1alpha3.NoScmPipelineType,
+					Pipeline: &v1alpha3.NoScmPipeline{Jenkinsfile: "echo 2"},
+				},
+			},
+		}
+		pr.Annotations[v1alpha3.PipelineRunReplayJenkinsfileSignatureAnnoKey] = SignReplayJenkinsfile("secret", pr)
+		return pr
+	}
+
+	tests := []struct {
+		name   string
+		modify func(pr *v1alpha3.PipelineRun)
+		secret string
+		want   bool
+	}{{
+		name:   "signed",
+		modify: func(pr *v1alpha3.PipelineRun) {},
+		secret: "secret",
+		want:   true,
+	}, {
+		name:   "another secret",
+		modify: func(pr *v1alpha3.PipelineRun) {},
+		secret: "another",
+	}, {
+		name:   "empty secret",
+		modify: func(pr *v1alpha3.PipelineRun) {},
+	}, {
+		name: "no signature",
+		modify: func(pr *v1alpha3.PipelineRun) {
+			delete(pr.Annotations, v1alpha3.PipelineRunReplayJenkinsfileSignatureAnnoKey)
+		},
+		secret: "secret",
+	}, {
+		name: "the Jenkinsfile was changed",
+		modify: func(pr *v1alpha3.PipelineRun) {
+			pr.Spec.PipelineSpec.Pipeline.Jenkinsfile = "echo 3"
+		},
+		secret: "secret",
+	}, {
+		name: "another build is replayed",
+		modify: func(pr *v1alpha3.PipelineRun) {
+			pr.Annotations[v1alpha3.PipelineRunReplayAnnoKey] = "1"
+		},
+		secret: "secret",
+	}, {
+		name: "another Pipeline",
+		modify: func(pr *v1alpha3.PipelineRun) {
+			pr.Spec.PipelineRef.Name = "another"
+		},
+		secret: "secret",
+	}, {
+		name: "another namespace",
+		modify: func(pr *v1alpha3.PipelineRun) {
+			pr.Namespace = "another"
+		},
+		secret: "secret",
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pr := newReplay()
+			tt.modify(pr)
+			assert.Equal(t, tt.want, VerifyReplayJenkinsfile(tt.secret, pr))
+		})
+	}
+}